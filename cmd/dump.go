@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var exportDBCmd = &cobra.Command{
+	Use:   "export-db",
+	Short: "Write the whole config database to a newline-delimited JSON dump",
+	Long: `Writes every allowed program, config, favorite and applied config of the storage
+backend serve would use to a dump import-db can read, for moving a deployment between
+Mongo clusters or to SQLite. File content and gallery images are included inline.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dumper, closeDB, err := openDumper(cmd)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		out := cmd.OutOrStdout()
+		if path, _ := cmd.Flags().GetString("output"); path != "-" {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		w := bufio.NewWriter(out)
+		if err := dumper.ExportAll(cmd.Context(), w); err != nil {
+			return err
+		}
+		return w.Flush()
+	},
+}
+
+var importDBCmd = &cobra.Command{
+	Use:   "import-db",
+	Short: "Load a dump written by export-db into the config database",
+	Long: `Validates the records of a dump written by export-db and writes them to the storage
+backend serve would use, keeping their ids and owners. Records that already exist are
+skipped, or replaced with --on-conflict=overwrite. Invalid records are listed and make
+the command fail once the rest has been imported.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dumper, closeDB, err := openDumper(cmd)
+		if err != nil {
+			return err
+		}
+		defer closeDB()
+
+		var in io.Reader = cmd.InOrStdin()
+		if path, _ := cmd.Flags().GetString("input"); path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		res, err := dumper.ImportAll(cmd.Context(), bufio.NewReader(in), hyprconfig.ImportAllOptions{OnConflict: onConflict})
+		if res != nil {
+			printImportAllResult(cmd.OutOrStdout(), res)
+		}
+		if err != nil {
+			return err
+		}
+		if n := len(res.Failures); n > 0 {
+			return fmt.Errorf("%d records could not be imported", n)
+		}
+		return nil
+	},
+}
+
+func printImportAllResult(w io.Writer, res *hyprconfig.ImportAllResult) {
+	for _, f := range res.Failures {
+		fmt.Fprintf(w, "failed   %s %s: %s\n", f.Kind, f.Key, f.Error)
+	}
+	kinds := make([]string, 0, len(res.Counts))
+	for kind := range res.Counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		counts := res.Counts[kind]
+		fmt.Fprintf(w, "%s: %d inserted, %d updated, %d skipped, %d failed\n", kind,
+			counts[hyprconfig.ImportStatusInserted], counts[hyprconfig.ImportStatusUpdated],
+			counts[hyprconfig.ImportStatusSkipped], counts[hyprconfig.ImportStatusFailed])
+	}
+}
+
+// openDumper opens the storage backend selected by the same flags as serve. The returned func
+// closes it.
+func openDumper(cmd *cobra.Command) (hyprconfig.DatabaseDumper, func(), error) {
+	ctx := cmd.Context()
+	sizeLimits, err := utils.LoadConfig[hyprconfig.SizeLimits](cmd, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch storage, _ := cmd.Flags().GetString("storage"); storage {
+	case "mongo":
+		mongoCreds, err := utils.LoadConfig[options.Credential](cmd, "mongo")
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg, err := utils.LoadConfig[Config](cmd, "c")
+		if err != nil {
+			return nil, nil, err
+		}
+		mongoDB, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURL).SetAuth(mongoCreds))
+		if err != nil {
+			return nil, nil, err
+		}
+		disconnect := func() { _ = mongoDB.Disconnect(ctx) }
+		db := mongoDB.Database(cfg.MongoDatabase)
+		manager, err := hyprconfig.NewConfigManager(
+			db.Collection("configs"),
+			db.Collection("favorites"),
+			db.Collection("state"),
+			db.Collection("allowed_programs"),
+			hyprconfig.WithSizeLimits(sizeLimits),
+		)
+		if err != nil {
+			disconnect()
+			return nil, nil, err
+		}
+		return manager.(hyprconfig.DatabaseDumper), disconnect, nil
+	case "sqlite":
+		sqlitePath, _ := cmd.Flags().GetString("sqlite-path")
+		manager, err := hyprconfig.NewConfigManagerSQLite(sqlitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		manager.SetSizeLimits(sizeLimits)
+		return manager, func() { _ = manager.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage backend %q, expected mongo or sqlite", storage)
+	}
+}
+
+// setDumpFlags adds the flags export-db and import-db share with serve.
+func setDumpFlags(cmd *cobra.Command) error {
+	if err := setMongoFlags(cmd); err != nil {
+		return err
+	}
+	return setStorageFlags(cmd)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{exportDBCmd, importDBCmd} {
+		if err := setDumpFlags(cmd); err != nil {
+			fmt.Println(err)
+		}
+		rootCmd.AddCommand(cmd)
+	}
+	exportDBCmd.Flags().StringP("output", "o", "-", "file the dump is written to, - for stdout")
+	importDBCmd.Flags().StringP("input", "i", "-", "dump file to import, - for stdin")
+	importDBCmd.Flags().String("on-conflict", hyprconfig.ImportConflictSkip, "what to do with records that already exist: skip or overwrite")
+}
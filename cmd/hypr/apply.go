@@ -0,0 +1,255 @@
+package hypr
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type ApplyConfig struct {
+	File   string `usage:"path to a config JSON (e.g. a saved GET /config/{config_id} response)"`
+	Home   string `usage:"directory files are installed under (defaults to $HOME)"`
+	DryRun bool   `usage:"print the files that would be written without writing them"`
+
+	ValuesFile string `usage:"file of name=value lines with the values of the config's template variables"`
+	NoPrompt   bool   `usage:"don't ask for template variables missing from the values file, or to run post-install commands"`
+
+	RunHooks bool `usage:"run the config's post-install commands without asking"`
+
+	InstallPrefixes []string `usage:"extra directories below ~/ that files may be installed into"`
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Write a config's files to disk",
+	Long:  ``,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		applyCfg, err := utils.LoadConfig[ApplyConfig](cmd, "")
+		if err != nil {
+			return err
+		}
+		if applyCfg.File == "" {
+			return errors.New("--apply-config-file is required")
+		}
+		if applyCfg.Home == "" {
+			if applyCfg.Home, err = os.UserHomeDir(); err != nil {
+				return err
+			}
+		}
+
+		data, err := os.ReadFile(applyCfg.File)
+		if err != nil {
+			return err
+		}
+		var cfg hyprconfig.HyprConfig
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", applyCfg.File, err)
+		}
+
+		values := map[string]string{}
+		if applyCfg.ValuesFile != "" {
+			if values, err = readValuesFile(applyCfg.ValuesFile); err != nil {
+				return err
+			}
+		}
+		in := bufio.NewReader(os.Stdin)
+		interactive := !applyCfg.NoPrompt && isTerminal(os.Stdin)
+		if interactive {
+			if err := promptValues(cfg.Variables, values, in, os.Stdout); err != nil {
+				return err
+			}
+		}
+
+		files, err := hyprconfig.RenderConfig(&cfg, values, applyCfg.InstallPrefixes...)
+		if err != nil {
+			return err
+		}
+
+		modes := hyprconfig.FileModes(&cfg, applyCfg.InstallPrefixes...)
+		paths := make([]string, 0, len(files))
+		for p := range files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+
+		for _, p := range paths {
+			dest := filepath.Join(applyCfg.Home, filepath.FromSlash(p))
+			if applyCfg.DryRun {
+				fmt.Printf("would write %s (%d bytes)\n", dest, len(files[p]))
+				continue
+			}
+			if err := checkNoSymlinks(applyCfg.Home, p); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+				return err
+			}
+			mode, ok := modes[p]
+			if !ok {
+				mode = 0o644
+			}
+			if err := os.WriteFile(dest, files[p], mode); err != nil {
+				return err
+			}
+			// WriteFile only applies the mode to new files
+			if err := os.Chmod(dest, mode); err != nil {
+				return err
+			}
+			fmt.Printf("wrote %s\n", dest)
+		}
+
+		hooks := hyprconfig.PostInstallCommands(&cfg)
+		if len(hooks) == 0 {
+			return nil
+		}
+		if applyCfg.DryRun {
+			for _, hook := range hooks {
+				fmt.Printf("would run %s (%s)\n", hook.Command, hook.Program)
+			}
+			return nil
+		}
+		run := applyCfg.RunHooks
+		if !run && interactive {
+			if run, err = confirmHooks(hooks, in, os.Stdout); err != nil {
+				return err
+			}
+		}
+		if !run {
+			fmt.Printf("skipped %d post-install commands, pass --apply-config-run-hooks to run them\n", len(hooks))
+			return nil
+		}
+		return runHooks(hooks)
+	},
+}
+
+// confirmHooks lists the post-install commands and asks whether to run them.
+func confirmHooks(hooks []hyprconfig.PostInstallCommand, in *bufio.Reader, out io.Writer) (bool, error) {
+	fmt.Fprintln(out, "The config asks to run these post-install commands:")
+	for _, hook := range hooks {
+		fmt.Fprintf(out, "  %s (%s)\n", hook.Command, hook.Program)
+	}
+	fmt.Fprint(out, "Run them? [y/N] ")
+	answer, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// runHooks runs the post-install commands in order, without a shell, stopping at the first
+// that fails.
+func runHooks(hooks []hyprconfig.PostInstallCommand) error {
+	for _, hook := range hooks {
+		args := strings.Fields(hook.Command)
+		if len(args) == 0 {
+			continue
+		}
+		fmt.Printf("running %s\n", hook.Command)
+		c := exec.Command(args[0], args[1:]...)
+		c.Stdout, c.Stderr = os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			return fmt.Errorf("post-install command %q of %s failed: %w", hook.Command, hook.Program, err)
+		}
+	}
+	return nil
+}
+
+// readValuesFile reads template variable values from name=value lines. Blank lines and lines
+// starting with # are skipped.
+func readValuesFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected name=value", path, i+1)
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return values, nil
+}
+
+// promptValues asks for every variable without a value in values, an empty answer keeping
+// its default.
+func promptValues(vars []hyprconfig.TemplateVariable, values map[string]string, in *bufio.Reader, out io.Writer) error {
+	for _, v := range vars {
+		if _, ok := values[v.Name]; ok {
+			continue
+		}
+		prompt := v.Name
+		if v.Description != "" {
+			prompt += " (" + v.Description + ")"
+		}
+		if v.Default != "" {
+			prompt += " [" + v.Default + "]"
+		}
+		fmt.Fprintf(out, "%s: ", prompt)
+		answer, err := in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if answer = strings.TrimSpace(answer); answer != "" {
+			values[v.Name] = answer
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// checkNoSymlinks fails if any existing part of rel below home is a symlink, so a file
+// can't be written somewhere else through a link planted in the home directory.
+func checkNoSymlinks(home, rel string) error {
+	dest := home
+	for _, part := range strings.Split(rel, "/") {
+		dest = filepath.Join(dest, part)
+		info, err := os.Lstat(dest)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to write through symlink %s", dest)
+		}
+	}
+	return nil
+}
+
+func setApplyFlags(cmd *cobra.Command) error {
+	fs, err := utils.BindFlags(&ApplyConfig{}, "")
+	if err != nil {
+		return err
+	}
+	cmd.Flags().AddFlagSet(fs)
+	return nil
+}
@@ -1,45 +1,209 @@
 package hypr
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/builder"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/configfinder"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
 	"github.com/spf13/cobra"
 )
 
 type BackupConfig struct {
+	Output   string   `usage:"directory the backup is written to (defaults to ~/.local/share/hypr-config-manager/backups)"`
+	Programs []string `usage:"programs to back up along with hyprland (defaults to the ones its exec lines start)"`
+	DryRun   bool     `usage:"print the files that would be backed up without writing anything"`
+	Archive  bool     `usage:"also write a tar.gz of the files laid out relative to $HOME"`
 }
 
+// BackupDir is where backups are written unless --backup-config-output says otherwise,
+// relative to $HOME.
+var BackupDir = filepath.Join(".local", "share", "hypr-config-manager", "backups")
+
+// backupBlacklist are the paths left out of backups besides the finder's blacklist: custom.conf
+// holds the settings of one machine, which don't belong on another.
+var backupBlacklist = []string{`/custom\.conf$`}
+
+// programIssueURL is where users ask for programs to be supported.
+const programIssueURL = "https://github.com/Seann-Moser/hypr-config-manager/issues"
+
+// verifyPrograms reports which programs are installed, replaced in tests.
+var verifyPrograms = utils.VerifyPrograms
+
 var backupCmd = &cobra.Command{
 	Use:   "backup",
-	Short: "A brief description of your command",
-	Long:  ``,
+	Short: "Back up the Hyprland config and the configs of the programs it starts",
+	Long: `Builds a config from hyprland's files and those of the programs its exec lines start,
+found below the XDG config home, and writes it as JSON to a timestamped file that apply can
+install again.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfgFinder, err := configfinder.NewConfigFinder()
+		backupCfg, err := utils.LoadConfig[BackupConfig](cmd, "")
 		if err != nil {
 			return err
 		}
-		files, err := cfgFinder.FindConfigFiles("hyprland")
+		home, err := os.UserHomeDir()
 		if err != nil {
 			return err
 		}
-		for _, file := range files {
-			println(file)
-		}
-		/*
-		   todo:
-
-		   get a list of programs in config
-		   ignore custom.conf
-		   check if all programs are valid
-		   log which ones are not
-		   if not say to put in an issue on the github page
 
+		backup, err := runBackup(cmd.Context(), backupCfg, home, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, p := range backup.Unknown {
+			fmt.Fprintf(os.Stderr, "warning: %s is not a supported program, its config was left out; open an issue at %s or request the program from the server to have it added\n", p, programIssueURL)
+		}
+		for _, p := range backup.Missing {
+			fmt.Fprintf(os.Stderr, "warning: %s is used by the config but not installed; if it is installed under another name, open an issue at %s\n", p, programIssueURL)
+		}
 
-		*/
+		verb := "wrote"
+		if backupCfg.DryRun {
+			verb = "would write"
+			backup.Config.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+				for _, e := range pc.FileEntries() {
+					fmt.Printf("would back up %s (%s, %d bytes)\n", e.TargetPath, pc.Program, len(e.FileContent.Data))
+				}
+			})
+		}
+		for _, f := range backup.Files {
+			fmt.Printf("%s %s\n", verb, f)
+		}
 		return nil
 	},
 }
 
+// backupResult is what runBackup backed up.
+type backupResult struct {
+	Config *hyprconfig.HyprConfig
+
+	// Unknown are the programs that were asked for or started by the config but aren't
+	// supported, so their configs were left out.
+	Unknown []string
+
+	// Missing are the supported programs that were asked for or started by the config but
+	// aren't installed.
+	Missing []string
+
+	// Files are the files the backup was written to, or would be on a dry run.
+	Files []string
+}
+
+// runBackup builds a config from the files below the XDG config home of home: hyprland's, and
+// those of the programs in opts or, when there are none, of the ones its exec lines start.
+// Blacklisted files and backupBlacklist are left out. Unless opts.DryRun is set the config is
+// written as JSON, and with opts.Archive as a tar.gz as well, to files named after now.
+func runBackup(ctx context.Context, opts BackupConfig, home string, now time.Time) (*backupResult, error) {
+	finderOpts := configfinder.FinderOptions{HomeDir: home, Blacklist: backupBlacklist}
+	cfg, err := builder.BuildConfigFromDirectoryWithOptions("", []string{"hyprland"}, finderOpts)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.ProgramConfigs) == 0 {
+		return nil, errors.New("no hyprland config found")
+	}
+	deps := cfg.ProgramConfigs[0].Dependencies
+	wanted := opts.Programs
+	if len(wanted) == 0 {
+		wanted = deps
+	}
+
+	supported := map[string]bool{}
+	for _, p := range hyprconfig.DefaultAllowedPrograms() {
+		supported[p.ProgramName] = true
+	}
+	backup := &backupResult{}
+	programs := []string{"hyprland"}
+	for _, p := range utils.DeduplicateStrings(wanted) {
+		p = hyprconfig.NormalizeProgramName(p)
+		switch {
+		case p == "hyprland":
+		case supported[p]:
+			programs = append(programs, p)
+		default:
+			backup.Unknown = append(backup.Unknown, p)
+		}
+	}
+	if len(programs) > 1 {
+		if cfg, err = builder.BuildConfigFromDirectoryWithOptions("", programs, finderOpts); err != nil {
+			return nil, err
+		}
+	}
+	backup.Config = cfg
+
+	var verify []string
+	for _, p := range utils.DeduplicateStrings(append(slices.Clone(wanted), deps...)) {
+		if p = hyprconfig.NormalizeProgramName(p); p != "hyprland" && supported[p] {
+			verify = append(verify, p)
+		}
+	}
+	for p, installed := range verifyPrograms(verify) {
+		if !installed {
+			backup.Missing = append(backup.Missing, p)
+		}
+	}
+	sort.Strings(backup.Unknown)
+	sort.Strings(backup.Missing)
+
+	dir := opts.Output
+	if dir == "" {
+		dir = filepath.Join(home, BackupDir)
+	}
+	name := filepath.Join(dir, "backup-"+now.Format("20060102-150405"))
+	backup.Files = []string{name + ".json"}
+	if opts.Archive {
+		backup.Files = append(backup.Files, name+"."+hyprconfig.ExportFormatTarGz)
+	}
+	if opts.DryRun {
+		return backup, nil
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(backup.Files[0], data, 0o600); err != nil {
+		return nil, err
+	}
+	if opts.Archive {
+		if err := writeBackupArchive(ctx, cfg, backup.Files[1]); err != nil {
+			return nil, err
+		}
+	}
+	return backup, nil
+}
+
+// writeBackupArchive writes the export archive of cfg to path.
+func writeBackupArchive(ctx context.Context, cfg *hyprconfig.HyprConfig, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := hyprconfig.WriteConfigArchive(ctx, cfg, f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
 func setBackupFlags(cmd *cobra.Command) error {
+	fs, err := utils.BindFlags(&BackupConfig{}, "")
+	if err != nil {
+		return err
+	}
+	cmd.Flags().AddFlagSet(fs)
 	return nil
 }
@@ -0,0 +1,182 @@
+package hypr
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+// fixtureHome copies testdata/home to a temporary home directory, so backups written below it
+// don't end up in testdata.
+func fixtureHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	if err := os.CopyFS(home, os.DirFS(filepath.Join("testdata", "home"))); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	return home
+}
+
+// installed replaces verifyPrograms for the test, with only names installed.
+func installed(t *testing.T, names ...string) {
+	t.Helper()
+	old := verifyPrograms
+	t.Cleanup(func() { verifyPrograms = old })
+	verifyPrograms = func(programs []string) map[string]bool {
+		status := map[string]bool{}
+		for _, p := range programs {
+			status[p] = false
+		}
+		for _, n := range names {
+			status[n] = true
+		}
+		return status
+	}
+}
+
+// installPaths returns the install path of every program config of cfg with a file, sub configs
+// included. Programs without a main file, like dunst, only have sub configs.
+func installPaths(cfg *hyprconfig.HyprConfig) []string {
+	var paths []string
+	cfg.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+		if pc.InstallPath != "" {
+			paths = append(paths, pc.InstallPath)
+		}
+	})
+	return paths
+}
+
+func TestRunBackup(t *testing.T) {
+	home := fixtureHome(t)
+	installed(t, "waybar", "kitty")
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	backup, err := runBackup(context.Background(), BackupConfig{}, home, now)
+	if err != nil {
+		t.Fatalf("runBackup: %v", err)
+	}
+
+	want := []string{
+		"~/.config/dunst/dunstrc",
+		"~/.config/hypr/hyprland.conf",
+		"~/.config/hypr/colors.conf",
+		"~/.config/kitty/kitty.conf",
+		"~/.config/waybar/config",
+		"~/.config/waybar/style.css",
+	}
+	if got := installPaths(backup.Config); !reflect.DeepEqual(got, want) {
+		t.Errorf("backed up %q, want %q", got, want)
+	}
+	if want := []string{"my-widget"}; !reflect.DeepEqual(backup.Unknown, want) {
+		t.Errorf("unknown programs = %q, want %q", backup.Unknown, want)
+	}
+	if want := []string{"dunst"}; !reflect.DeepEqual(backup.Missing, want) {
+		t.Errorf("missing programs = %q, want %q", backup.Missing, want)
+	}
+
+	file := filepath.Join(home, BackupDir, "backup-20261016-093000.json")
+	if !reflect.DeepEqual(backup.Files, []string{file}) {
+		t.Fatalf("files = %q, want %q", backup.Files, file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg hyprconfig.HyprConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("backup is not a config: %v", err)
+	}
+	cfg.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+		if pc.InstallPath != "" && pc.FileContent.Hash != hyprconfig.ComputeHash(pc.FileContent.Data) {
+			t.Errorf("%s: file content = %+v", pc.InstallPath, pc.FileContent)
+		}
+	})
+}
+
+func TestRunBackupPrograms(t *testing.T) {
+	home := fixtureHome(t)
+	installed(t, "rofi")
+	out := t.TempDir()
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	opts := BackupConfig{Output: out, Programs: []string{"rofi", "not-a-program"}, Archive: true}
+	backup, err := runBackup(context.Background(), opts, home, now)
+	if err != nil {
+		t.Fatalf("runBackup: %v", err)
+	}
+	want := []string{"~/.config/hypr/hyprland.conf", "~/.config/hypr/colors.conf", "~/.config/rofi/config.rasi"}
+	if got := installPaths(backup.Config); !reflect.DeepEqual(got, want) {
+		t.Errorf("backed up %q, want %q", got, want)
+	}
+	if want := []string{"not-a-program"}; !reflect.DeepEqual(backup.Unknown, want) {
+		t.Errorf("unknown programs = %q, want %q", backup.Unknown, want)
+	}
+	// The programs the config starts are checked even when others are backed up
+	if want := []string{"dunst", "kitty", "waybar"}; !reflect.DeepEqual(backup.Missing, want) {
+		t.Errorf("missing programs = %q, want %q", backup.Missing, want)
+	}
+
+	f, err := os.Open(filepath.Join(out, "backup-20261016-093000.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names[hdr.Name] = true
+	}
+	for _, name := range []string{hyprconfig.ManifestFile, ".config/hypr/hyprland.conf", ".config/rofi/config.rasi"} {
+		if !names[name] {
+			t.Errorf("archive is missing %s, has %v", name, names)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(out, "backup-20261016-093000.json")); err != nil {
+		t.Errorf("JSON backup: %v", err)
+	}
+}
+
+func TestRunBackupDryRun(t *testing.T) {
+	home := fixtureHome(t)
+	installed(t)
+
+	backup, err := runBackup(context.Background(), BackupConfig{DryRun: true}, home, time.Now())
+	if err != nil {
+		t.Fatalf("runBackup: %v", err)
+	}
+	if len(backup.Files) != 1 {
+		t.Errorf("files = %q, want the JSON backup", backup.Files)
+	}
+	if _, err := os.Stat(filepath.Join(home, BackupDir)); !os.IsNotExist(err) {
+		t.Errorf("dry run created %s: %v", BackupDir, err)
+	}
+}
+
+func TestRunBackupNoHyprlandConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if err := os.MkdirAll(filepath.Join(home, ".config", "waybar"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runBackup(context.Background(), BackupConfig{}, home, time.Now()); err == nil {
+		t.Error("expected an error without a hyprland config")
+	}
+}
@@ -1,6 +1,8 @@
 package hypr
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 )
 
@@ -15,7 +17,18 @@ var HyprCmd = &cobra.Command{
 }
 
 func init() {
+	if err := setBackupFlags(backupCmd); err != nil {
+		fmt.Println(err)
+	}
 	HyprCmd.AddCommand(backupCmd)
+	if err := setApplyFlags(applyCmd); err != nil {
+		fmt.Println(err)
+	}
+	HyprCmd.AddCommand(applyCmd)
+	if err := setPullFlags(pullCmd); err != nil {
+		fmt.Println(err)
+	}
+	HyprCmd.AddCommand(pullCmd)
 
 }
 
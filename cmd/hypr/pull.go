@@ -0,0 +1,105 @@
+package hypr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+type PullConfig struct {
+	Server string `usage:"base url of the config server"`
+	ID     string `usage:"id of the config to download"`
+	Layout string `usage:"layout of the archive: home (relative to $HOME), stow (a GNU Stow package per program) or chezmoi (a chezmoi source directory)"`
+	Output string `usage:"file the archive is written to (defaults to hypr-config-<id>.tar.gz)"`
+
+	APIKey string `flag:"api-key" usage:"API key (hcm_...) to download private configs of its owner"`
+	Share  string `usage:"share link token, to download a private config it was created for"`
+
+	ValuesFile string `usage:"file of name=value lines with the values of the config's template variables"`
+}
+
+var pullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "Download a config's export archive",
+	Long:  ``,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pullCfg, err := utils.LoadConfig[PullConfig](cmd, "")
+		if err != nil {
+			return err
+		}
+		if pullCfg.ID == "" {
+			return errors.New("--pull-config-id is required")
+		}
+		if pullCfg.Output == "" {
+			pullCfg.Output = "hypr-config-" + pullCfg.ID + "." + hyprconfig.ExportFormatTarGz
+		}
+
+		query := url.Values{}
+		query.Set("format", hyprconfig.ExportFormatTarGz)
+		if pullCfg.Layout != "" {
+			query.Set("layout", pullCfg.Layout)
+		}
+		if pullCfg.Share != "" {
+			query.Set("share", pullCfg.Share)
+		}
+		if pullCfg.ValuesFile != "" {
+			values, err := readValuesFile(pullCfg.ValuesFile)
+			if err != nil {
+				return err
+			}
+			for name, value := range values {
+				query.Set("var."+name, value)
+			}
+		}
+		u := strings.TrimSuffix(pullCfg.Server, "/") + "/config/" + url.PathEscape(pullCfg.ID) + "/export?" + query.Encode()
+
+		req, err := http.NewRequestWithContext(cmd.Context(), http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		if pullCfg.APIKey != "" {
+			req.Header.Set("Authorization", "Bearer "+pullCfg.APIKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+			return fmt.Errorf("failed to download config %s: %s: %s", pullCfg.ID, resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		f, err := os.Create(pullCfg.Output)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, resp.Body); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write %s: %w", pullCfg.Output, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s\n", pullCfg.Output)
+		return nil
+	},
+}
+
+func setPullFlags(cmd *cobra.Command) error {
+	fs, err := utils.BindFlags(&PullConfig{Server: "http://localhost:8080", Layout: hyprconfig.ExportLayoutHome}, "")
+	if err != nil {
+		return err
+	}
+	cmd.Flags().AddFlagSet(fs)
+	return nil
+}
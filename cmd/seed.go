@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var seedProgramsCmd = &cobra.Command{
+	Use:   "seed-programs",
+	Short: "Add the built-in programs to the allowed_programs collection",
+	Long: `Adds the built-in programs, with their categories and package names, to the
+allowed_programs collection serve uses. Programs that are already allowed are skipped,
+or replaced by their built-in entry with --update, so it is safe to run on every deploy.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		mongoCreds, err := utils.LoadConfig[options.Credential](cmd, "mongo")
+		if err != nil {
+			return err
+		}
+		cfg, err := utils.LoadConfig[Config](cmd, "c")
+		if err != nil {
+			return err
+		}
+		mongoDB, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURL).SetAuth(mongoCreds))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = mongoDB.Disconnect(ctx) }()
+
+		update, _ := cmd.Flags().GetBool("update")
+		results, err := hyprconfig.SeedAllowedPrograms(ctx, mongoDB.Database(cfg.MongoDatabase).Collection("allowed_programs"), update)
+		if err != nil {
+			return err
+		}
+
+		counts := map[string]int{}
+		for _, r := range results {
+			counts[r.Status]++
+			if r.Error != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%-8s %s: %s\n", r.Status, r.ProgramName, r.Error)
+			} else {
+				fmt.Fprintf(cmd.OutOrStdout(), "%-8s %s\n", r.Status, r.ProgramName)
+			}
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%d inserted, %d updated, %d skipped, %d failed\n",
+			counts[hyprconfig.ImportStatusInserted], counts[hyprconfig.ImportStatusUpdated],
+			counts[hyprconfig.ImportStatusSkipped], counts[hyprconfig.ImportStatusFailed])
+		if n := counts[hyprconfig.ImportStatusFailed]; n > 0 {
+			return fmt.Errorf("%d programs could not be seeded", n)
+		}
+		return nil
+	},
+}
+
+func init() {
+	if err := setMongoFlags(seedProgramsCmd); err != nil {
+		fmt.Println(err)
+	}
+	seedProgramsCmd.Flags().Bool("update", false, "replace programs that are already allowed with their built-in entry")
+	rootCmd.AddCommand(seedProgramsCmd)
+}
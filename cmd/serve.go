@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"time"
 
 	"github.com/Seann-Moser/credentials/oauth/oserver"
@@ -10,12 +12,24 @@ import (
 	"github.com/Seann-Moser/credentials/user"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/hchandler"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprtrace"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
 	"github.com/Seann-Moser/mserve"
 	"github.com/Seann-Moser/rbac"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
@@ -45,7 +59,28 @@ var serveCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		mongoDB, err := mongo.Connect(cmd.Context(), options.Client().ApplyURI(cfg.MongoURL).SetAuth(mongoCreds))
+		sizeLimits, err := utils.LoadConfig[hyprconfig.SizeLimits](cmd, "")
+		if err != nil {
+			return err
+		}
+		if err := hyprconfig.ValidateCommandPatterns(sizeLimits.UnsafeCommandPatterns); err != nil {
+			return err
+		}
+		mongoOpts := options.Client().ApplyURI(cfg.MongoURL).SetAuth(mongoCreds)
+		tracingEndpoint, _ := cmd.Flags().GetString("tracing-endpoint")
+		var tracerProvider trace.TracerProvider
+		if tracingEndpoint != "" {
+			tp, err := newTracerProvider(ctx, tracingEndpoint)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = tp.Shutdown(context.Background()) }()
+			otel.SetTracerProvider(tp)
+			otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+			tracerProvider = tp
+			mongoOpts.SetMonitor(otelmongo.NewMonitor(otelmongo.WithTracerProvider(tp)))
+		}
+		mongoDB, err := mongo.Connect(cmd.Context(), mongoOpts)
 		if err != nil {
 			return err
 		}
@@ -59,35 +94,124 @@ var serveCmd = &cobra.Command{
 
 		ses := session.NewClient(oServer, rbacManager, []byte(cfg.Secret), 24*time.Hour)
 		s := mserve.NewServer("HyprlandConfigManager", rbacManager, []string{}, ses, sslConfig)
+		userStore := user.NewMongoDBStore(mongoDB, cfg.MongoDatabase, "user")
 		userServer, err := user.NewServer(
-			user.NewMongoDBStore(
-				mongoDB,
-				cfg.MongoDatabase,
-				"user",
-			), rbacManager, []byte(cfg.Secret),
+			userStore, rbacManager, []byte(cfg.Secret),
 			cfg.RPId,
 			cfg.OriginName,
 			cfg.Origin,
 		)
 
-		configManager, err := hyprconfig.NewConfigManager(
-			mongoDB.Database(cfg.MongoDatabase).Collection("configs"),
-			mongoDB.Database(cfg.MongoDatabase).Collection("favorites"),
-			mongoDB.Database(cfg.MongoDatabase).Collection("state"),
-			mongoDB.Database(cfg.MongoDatabase).Collection("allowed_programs"),
-		)
+		// Only configs can live in SQLite: users, sessions and roles have Mongo stores only
+		var configManager hyprconfig.ConfigManager
+		switch storage, _ := cmd.Flags().GetString("storage"); storage {
+		case "mongo":
+			programCacheTTL, _ := cmd.Flags().GetDuration("program-cache-ttl")
+			configManager, err = hyprconfig.NewConfigManager(
+				mongoDB.Database(cfg.MongoDatabase).Collection("configs"),
+				mongoDB.Database(cfg.MongoDatabase).Collection("favorites"),
+				mongoDB.Database(cfg.MongoDatabase).Collection("state"),
+				mongoDB.Database(cfg.MongoDatabase).Collection("allowed_programs"),
+				hyprconfig.WithSizeLimits(sizeLimits),
+				hyprconfig.WithProgramCacheTTL(programCacheTTL),
+			)
+		case "sqlite":
+			sqlitePath, _ := cmd.Flags().GetString("sqlite-path")
+			var sqliteManager *hyprconfig.ConfigManagerSQLite
+			sqliteManager, err = hyprconfig.NewConfigManagerSQLite(sqlitePath)
+			if err == nil {
+				defer sqliteManager.Close()
+				sqliteManager.SetSizeLimits(sizeLimits)
+				configManager = sqliteManager
+			}
+		default:
+			err = fmt.Errorf("unknown storage backend %q, expected mongo or sqlite", storage)
+		}
 		if err != nil {
 			return err
 		}
 
+		if migrate, _ := cmd.Flags().GetBool("migrate"); migrate {
+			batchSize, _ := cmd.Flags().GetInt("migrate-batch-size")
+			migrator, ok := configManager.(hyprconfig.ConfigMigrator)
+			if !ok {
+				return fmt.Errorf("the %T storage backend has no migrations", configManager)
+			}
+			migrated, err := migrator.MigrateConfigs(ctx, batchSize)
+			if err != nil {
+				return err
+			}
+			slog.Info("config migrations done", "migrated", migrated, "schema_version", hyprconfig.CurrentSchemaVersion)
+		}
+
+		if cacheSize, _ := cmd.Flags().GetInt("cache-size"); cacheSize > 0 {
+			cacheTTL, _ := cmd.Flags().GetDuration("cache-ttl")
+			configManager = hyprconfig.NewCachedConfigManager(configManager, cacheSize, cacheTTL)
+		}
+
+		readOnly, _ := cmd.Flags().GetBool("read-only")
+		maintenance := hyprconfig.NewReadOnlyConfigManager(configManager, readOnly)
+		configManager = maintenance
+		if readOnly {
+			slog.Warn("starting in read-only mode, writes are rejected until an admin turns it off")
+		}
+
+		if tracerProvider != nil {
+			configManager = hyprtrace.NewConfigManager(configManager, tracerProvider)
+		}
+
+		metricsEnabled, _ := cmd.Flags().GetBool("metrics")
+		if metricsEnabled {
+			configManager, err = hyprconfig.NewInstrumentedConfigManager(configManager, prometheus.DefaultRegisterer)
+			if err != nil {
+				return err
+			}
+		}
+
+		webhookInterval, _ := cmd.Flags().GetDuration("webhook-interval")
+		go hyprconfig.RunWebhookDeliveries(ctx, configManager, webhookInterval)
+
 		hcHandler, _ := hchandler.NewHandler(configManager)
+		maxPageLimit, _ := cmd.Flags().GetInt("max-page-limit")
+		hcHandler.SetMaxPageLimit(maxPageLimit)
+		hcHandler.SetRoleManager(rbacManager, userStore)
+		hcHandler.SetMaintenance(maintenance)
+		if name, _ := cmd.Flags().GetString("bootstrap-admin"); name != "" {
+			if err := bootstrapAdmin(ctx, rbacManager, userStore, mongoDB.Database(cfg.MongoDatabase), name); err != nil {
+				return err
+			}
+		}
 		err = s.AddEndpoints(ctx, hcHandler.GetEndpoints()...)
 		if err != nil {
 			return err
 		}
+		if metricsEnabled {
+			requestMetrics, err := hchandler.NewRequestMetrics(prometheus.DefaultRegisterer)
+			if err != nil {
+				return err
+			}
+			s.AddMiddleware(requestMetrics.Middleware)
+			err = s.AddEndpoints(ctx, &mserve.Endpoint{
+				Name:    "Metrics",
+				Path:    "/metrics",
+				Handler: promhttp.Handler().ServeHTTP,
+				Methods: []string{http.MethodGet},
+				Responses: []mserve.Response{
+					{Status: http.StatusOK, Message: "Prometheus metrics in the text exposition format"},
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
 
-		err = s.SetupOServer(ctx, oServer).
-			SetupRbac(ctx).
+		s.SetupOServer(ctx, oServer)
+		// after the session middleware added by SetupOServer, which would replace the API key user
+		s.AddMiddleware(hcHandler.APIKeyMiddleware, hchandler.RequestIDMiddleware)
+		if tracerProvider != nil {
+			s.AddMiddleware(hyprtrace.Middleware(tracerProvider))
+		}
+		err = s.SetupRbac(ctx).
 			SetupSlog(slog.LevelWarn).
 			//SetupMetrics().
 			SetupUserLogin(ctx, userServer).
@@ -109,6 +233,38 @@ func init() {
 }
 
 func setServerFlags(cmd *cobra.Command) error {
+	if err := setMongoFlags(cmd); err != nil {
+		return err
+	}
+
+	cfg, err := utils.BindFlags(&mserve.SSLConfig{
+		Port: 8080,
+	}, "c")
+	if err != nil {
+		return err
+	}
+
+	cmd.Flags().AddFlagSet(cfg)
+
+	if err := setStorageFlags(cmd); err != nil {
+		return err
+	}
+	cmd.Flags().Bool("migrate", false, "bring configs stored by older versions up to the current schema before serving")
+	cmd.Flags().Int("migrate-batch-size", hyprconfig.DefaultMigrationBatchSize, "configs written per batch by --migrate")
+	cmd.Flags().Int("cache-size", 1000, "number of public configs kept in the read cache, 0 disables it")
+	cmd.Flags().Duration("cache-ttl", time.Minute, "how long a cached config is served before it is reloaded")
+	cmd.Flags().Duration("program-cache-ttl", hyprconfig.DefaultProgramCacheTTL, "how long allowed program lookups are cached with --storage=mongo, 0 disables the cache")
+	cmd.Flags().Duration("webhook-interval", 30*time.Second, "how often pending webhook deliveries are attempted")
+	cmd.Flags().String("tracing-endpoint", "", "OTLP/HTTP endpoint url traces are exported to, e.g. http://localhost:4318, empty disables tracing")
+	cmd.Flags().Bool("read-only", false, "start in read-only maintenance mode, rejecting writes until an admin turns it off")
+	cmd.Flags().Bool("metrics", true, "record Prometheus metrics and serve them on /metrics")
+	cmd.Flags().String("bootstrap-admin", "", "username or id of a user to grant the admin role on startup, ignored once any user is an admin")
+	cmd.Flags().Int("max-page-limit", hchandler.DefaultMaxPageLimit, "largest page size served by the list endpoints")
+	return err
+}
+
+// setMongoFlags adds the flags of the Mongo connection, shared by every command that uses it.
+func setMongoFlags(cmd *cobra.Command) error {
 	mongoCfg, err := utils.BindFlags(&options.Credential{
 		Password: "default",
 		Username: "admin",
@@ -131,14 +287,63 @@ func setServerFlags(cmd *cobra.Command) error {
 	}
 
 	cmd.Flags().AddFlagSet(cfg)
+	return nil
+}
 
-	cfg, err = utils.BindFlags(&mserve.SSLConfig{
-		Port: 8080,
-	}, "c")
+// setStorageFlags adds the flags selecting the config storage backend and its size limits, shared
+// by serve and the commands that work on its database.
+func setStorageFlags(cmd *cobra.Command) error {
+	defaultLimits := hyprconfig.DefaultSizeLimits()
+	limits, err := utils.BindFlags(&defaultLimits, "")
 	if err != nil {
 		return err
 	}
 
-	cmd.Flags().AddFlagSet(cfg)
-	return err
+	cmd.Flags().AddFlagSet(limits)
+	cmd.Flags().String("storage", "mongo", "storage backend for configs: mongo or sqlite; serve keeps users, sessions and roles in Mongo with either")
+	cmd.Flags().String("sqlite-path", "hypr-config-manager.db", "database file used when --storage=sqlite")
+	return nil
+}
+
+// bootstrapAdmin grants the admin role to the user named by usernameOrID when no user has it
+// yet. A user that has not registered yet is only logged, so the server still starts and the
+// flag works on the next restart.
+func bootstrapAdmin(ctx context.Context, roles *rbac.Manager, users user.Store, db *mongo.Database, usernameOrID string) error {
+	u, err := users.GetUserByUsername(ctx, usernameOrID)
+	if err != nil || u == nil {
+		u, err = users.GetUserByID(ctx, usernameOrID)
+	}
+	if err != nil || u == nil {
+		slog.Warn("bootstrap admin not granted, no user has this username or id; register first and restart", "user", usernameOrID, "err", err)
+		return nil
+	}
+
+	// rbac cannot look users up by role, so ask its user_roles collection directly
+	adminExists := func(ctx context.Context) (bool, error) {
+		n, err := db.Collection("user_roles").CountDocuments(ctx, bson.M{"role_id": hyprconfig.AdminRole}, options.Count().SetLimit(1))
+		return n > 0, err
+	}
+	granted, err := hchandler.BootstrapAdmin(ctx, roles, adminExists, u.ID)
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap admin: %w", err)
+	}
+	if granted {
+		slog.Warn("granted the admin role to the bootstrap admin, remove --bootstrap-admin", "user", usernameOrID, "user_id", u.ID)
+	} else {
+		slog.Info("bootstrap admin ignored, an admin already exists", "user", usernameOrID)
+	}
+	return nil
+}
+
+// newTracerProvider exports spans in batches to the OTLP/HTTP collector at endpoint.
+func newTracerProvider(ctx context.Context, endpoint string) (*sdktrace.TracerProvider, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName("hypr-config-manager")))
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res)), nil
 }
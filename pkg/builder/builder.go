@@ -0,0 +1,333 @@
+package builder
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/configfinder"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/google/uuid"
+)
+
+// DefaultTitle is the title given to configs built from a directory.
+const DefaultTitle = "Hyprland config"
+
+// dirPrograms maps config directory names that differ from their program's name.
+var dirPrograms = map[string]string{
+	"hypr": "hyprland",
+}
+
+// BuildConfigFromDirectory builds a HyprConfig from a dotfiles directory laid out like ~/.config.
+// Each recognized program directory becomes one HyprProgramConfig: its main file (if any) is the
+// program's FileContent and every other file becomes a sub-config. An empty root means the XDG
+// config home, ~/.config by default, and nil programs means the default allowed programs.
+// Blacklisted files are skipped.
+// Wallpapers set by hyprpaper or swww that exist below $HOME are bundled as images, see
+// bundleWallpapers.
+//
+// Install paths are recorded relative to $HOME. Files outside $HOME (e.g. a cloned repository)
+// are treated as if root were ~/.config.
+func BuildConfigFromDirectory(root string, programs []string) (*hyprconfig.HyprConfig, error) {
+	return BuildConfigFromDirectoryWithOptions(root, programs, configfinder.FinderOptions{})
+}
+
+// BuildConfigFromDirectoryWithOptions is BuildConfigFromDirectory with the home directory and
+// blacklist of opts, see configfinder.NewConfigFinderWithOptions.
+func BuildConfigFromDirectoryWithOptions(root string, programs []string, opts configfinder.FinderOptions) (*hyprconfig.HyprConfig, error) {
+	finder, err := configfinder.NewConfigFinderWithOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if root == "" {
+		root = finder.ConfigHome
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	recognized := map[string]struct{}{}
+	if programs == nil {
+		for _, p := range hyprconfig.DefaultAllowedPrograms() {
+			programs = append(programs, p.ProgramName)
+		}
+	}
+	for _, p := range programs {
+		recognized[hyprconfig.NormalizeProgramName(p)] = struct{}{}
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+
+	b := &dirBuilder{root: root, home: finder.HomeDir, finder: finder, now: time.Now()}
+	cfg := &hyprconfig.HyprConfig{
+		Title:            DefaultTitle,
+		Version:          hyprconfig.DefaultConfigVersion,
+		CreatedTimestamp: b.now,
+		UpdatedTimestamp: b.now,
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		program := hyprconfig.NormalizeProgramName(entry.Name())
+		if alias, ok := dirPrograms[program]; ok {
+			program = alias
+		}
+		if _, ok := recognized[program]; !ok {
+			continue
+		}
+
+		pc, err := b.buildProgram(program, filepath.Join(root, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if pc != nil {
+			cfg.ProgramConfigs = append(cfg.ProgramConfigs, *pc)
+		}
+	}
+	if err := b.bundleWallpapers(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+type dirBuilder struct {
+	root   string
+	home   string
+	finder *configfinder.ConfigFinder
+	now    time.Time
+}
+
+// buildProgram creates the program config for one program directory, or nil if it has no usable files.
+func (b *dirBuilder) buildProgram(program, dir string) (*hyprconfig.HyprProgramConfig, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Only regular files; symlinks could point anywhere on the system
+		if !d.Type().IsRegular() || b.finder.IsBlacklisted(p) {
+			return nil
+		}
+		files = append(files, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	sort.Strings(files)
+
+	pc := &hyprconfig.HyprProgramConfig{
+		ID:               uuid.NewString(),
+		Title:            program,
+		Program:          program,
+		CreatedTimestamp: b.now,
+		UpdatedTimestamp: b.now,
+	}
+
+	main := mainFile(program, dir, files)
+	for _, f := range files {
+		fc, installPath, err := b.readFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if f == main {
+			pc.FileContent = fc
+			pc.InstallPath = installPath
+			continue
+		}
+		rel, _ := filepath.Rel(dir, f)
+		pc.SubConfigs = append(pc.SubConfigs, &hyprconfig.HyprProgramConfig{
+			ID:               uuid.NewString(),
+			Title:            filepath.ToSlash(rel),
+			Program:          program,
+			InstallPath:      installPath,
+			FileContent:      fc,
+			CreatedTimestamp: b.now,
+			UpdatedTimestamp: b.now,
+		})
+	}
+
+	if program == "hyprland" {
+		if main != "" {
+			if err := b.addSourcedFiles(pc, main, files); err != nil {
+				return nil, err
+			}
+		}
+		pc.Dependencies = execOnceDependencies(pc)
+	}
+	return pc, nil
+}
+
+// addSourcedFiles adds the files main sources from outside its directory, e.g. a pywal or
+// per-machine colors.conf, as sub-configs of pc; files are the ones already in it. Sourced files
+// outside $HOME or the install prefixes can't be installed elsewhere and are left out, like
+// blacklisted ones; those outside $HOME aren't even read.
+func (b *dirBuilder) addSourcedFiles(pc *hyprconfig.HyprProgramConfig, main string, files []string) error {
+	sourced, err := hyprconfig.ResolveSourcedFilesIn(main, b.home)
+	if err != nil {
+		return err
+	}
+	known := map[string]bool{}
+	for _, f := range files {
+		known[f] = true
+	}
+	for _, f := range sourced {
+		rel, err := filepath.Rel(b.home, f)
+		if known[f] || b.finder.IsBlacklisted(f) || err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		fc, installPath, err := b.readFile(f)
+		if err != nil {
+			return err
+		}
+		sub := &hyprconfig.HyprProgramConfig{
+			ID:               uuid.NewString(),
+			Title:            strings.TrimPrefix(installPath, "~/"),
+			Program:          pc.Program,
+			InstallPath:      installPath,
+			FileContent:      fc,
+			CreatedTimestamp: b.now,
+			UpdatedTimestamp: b.now,
+		}
+		if _, err := sub.FilePath(hyprconfig.FileEntry{}); err != nil {
+			continue
+		}
+		pc.SubConfigs = append(pc.SubConfigs, sub)
+	}
+	return nil
+}
+
+// readFile loads a file into FileContent and returns its $HOME relative install path.
+func (b *dirBuilder) readFile(p string) (hyprconfig.FileContent, string, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return hyprconfig.FileContent{}, "", fmt.Errorf("failed to read %s: %w", p, err)
+	}
+
+	rel, err := filepath.Rel(b.home, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel, _ = filepath.Rel(b.root, p)
+		rel = filepath.Join(".config", rel)
+	}
+
+	return hyprconfig.FileContent{
+		Data:     data,
+		FileType: hyprconfig.DetectFileType(filepath.Base(p), data),
+		Hash:     hyprconfig.ComputeHash(data),
+	}, "~/" + filepath.ToSlash(rel), nil
+}
+
+// bundleWallpapers adds the wallpaper images referenced by cfg that exist below $HOME to the
+// files of the program config referencing them, at the path they are referenced by. Images that
+// are missing, blacklisted, over the default size limits or outside the install prefixes are
+// left out; the server lists them as warnings when the config is uploaded. $HOME is the finder's
+// home directory, so configs from untrusted sources only get images from the home they were
+// extracted to.
+func (b *dirBuilder) bundleWallpapers(cfg *hyprconfig.HyprConfig) error {
+	limits := hyprconfig.DefaultSizeLimits()
+	var total int64
+	programs := map[string]*hyprconfig.HyprProgramConfig{}
+	cfg.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+		programs[pc.ID] = pc
+		for _, e := range pc.FileEntries() {
+			total += int64(len(e.FileContent.Data))
+		}
+	})
+
+	for _, w := range hyprconfig.Wallpapers(cfg) {
+		pc := programs[w.ProgramID]
+		if w.Bundled || w.HomePath == "" || pc == nil || len(pc.Files) >= hyprconfig.MaxProgramFiles {
+			continue
+		}
+		p := filepath.Join(b.home, filepath.FromSlash(w.HomePath))
+		if !isBelow(b.home, p) {
+			continue
+		}
+		info, err := os.Lstat(p)
+		if err != nil || !info.Mode().IsRegular() || b.finder.IsBlacklisted(p) {
+			continue
+		}
+		if limits.MaxConfigBytes > 0 && total+info.Size() > limits.MaxConfigBytes {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		entry := hyprconfig.FileEntry{
+			TargetPath: "~/" + w.HomePath,
+			FileContent: hyprconfig.FileContent{
+				Data:     data,
+				FileType: hyprconfig.DetectFileType(filepath.Base(p), data),
+				Hash:     hyprconfig.ComputeHash(data),
+			},
+		}
+		if entry.FileContent.FileType != hyprconfig.FileTypeImage || limits.CheckFile(entry.FileContent) != nil {
+			continue
+		}
+		if _, err := pc.FilePath(entry); err != nil {
+			continue
+		}
+		pc.Files = append(pc.Files, entry)
+		total += int64(len(data))
+	}
+	return nil
+}
+
+// isBelow reports whether the clean path p is inside dir.
+func isBelow(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// mainFile picks the file that is the program's own config, or "" if there is none.
+func mainFile(program, dir string, files []string) string {
+	candidates := []string{
+		filepath.Base(hyprconfig.DefaultInstallPath(program)),
+		program + ".conf",
+		"config",
+	}
+	for _, c := range candidates {
+		want := filepath.Join(dir, c)
+		for _, f := range files {
+			if f == want {
+				return f
+			}
+		}
+	}
+	return ""
+}
+
+// execOnceDependencies returns the programs launched by exec-once in any of the hyprland files.
+func execOnceDependencies(pc *hyprconfig.HyprProgramConfig) []string {
+	seen := map[string]struct{}{}
+	pc.Walk(func(p *hyprconfig.HyprProgramConfig) {
+		if p.FileContent.FileType != hyprconfig.FileTypeConfig && p.FileContent.FileType != hyprconfig.FileTypeText {
+			return
+		}
+		for _, cmd := range hyprconfig.ExtractExecOnceCommands(string(p.FileContent.Data)) {
+			if name := hyprconfig.NormalizeProgramName(cmd); name != "" && name != "hyprland" {
+				seen[name] = struct{}{}
+			}
+		}
+	})
+
+	deps := make([]string, 0, len(seen))
+	for d := range seen {
+		deps = append(deps, d)
+	}
+	sort.Strings(deps)
+	return deps
+}
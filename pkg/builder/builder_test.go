@@ -0,0 +1,166 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/configfinder"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+func writeFile(t *testing.T, p, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildConfigFromDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	root := filepath.Join(home, ".config")
+
+	writeFile(t, filepath.Join(root, "hypr", "hyprland.conf"), "source = ~/.config/hypr/colors.conf\nexec-once = waybar & /usr/bin/swaync\n")
+	writeFile(t, filepath.Join(root, "hypr", "colors.conf"), "$accent = rgb(ff0000)\n")
+	writeFile(t, filepath.Join(root, "hypr", "shaders", "blue.frag"), "void main() {}\n") // blacklisted
+	writeFile(t, filepath.Join(root, "waybar", "config"), "{\"layer\": \"top\"}\n")
+	writeFile(t, filepath.Join(root, "waybar", "style.css"), "* { font-size: 12px; }\n")
+	writeFile(t, filepath.Join(root, "unknown-app", "config"), "x\n")
+
+	cfg, err := BuildConfigFromDirectory("", nil)
+	if err != nil {
+		t.Fatalf("BuildConfigFromDirectory: %v", err)
+	}
+	if len(cfg.ProgramConfigs) != 2 {
+		t.Fatalf("got %d program configs, want 2", len(cfg.ProgramConfigs))
+	}
+
+	hypr := cfg.ProgramConfigs[0]
+	if hypr.Program != "hyprland" || hypr.InstallPath != "~/.config/hypr/hyprland.conf" {
+		t.Errorf("hyprland program config = %q at %q", hypr.Program, hypr.InstallPath)
+	}
+	if hypr.FileContent.Hash != hyprconfig.ComputeHash(hypr.FileContent.Data) || hypr.FileContent.FileType != hyprconfig.FileTypeConfig {
+		t.Errorf("hyprland file content = %+v", hypr.FileContent)
+	}
+	if len(hypr.SubConfigs) != 1 || hypr.SubConfigs[0].InstallPath != "~/.config/hypr/colors.conf" {
+		t.Errorf("expected only colors.conf as a sub-config, got %+v", hypr.SubConfigs)
+	}
+	if want := []string{"swaync", "waybar"}; !reflect.DeepEqual(hypr.Dependencies, want) {
+		t.Errorf("dependencies = %v, want %v", hypr.Dependencies, want)
+	}
+
+	waybar := cfg.ProgramConfigs[1]
+	if waybar.InstallPath != "~/.config/waybar/config" || len(waybar.SubConfigs) != 1 {
+		t.Errorf("waybar = %q with %d sub-configs", waybar.InstallPath, len(waybar.SubConfigs))
+	}
+}
+
+func TestBuildConfigFromDirectoryOutsideHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "kitty", "kitty.conf"), "font_size 12\n")
+
+	cfg, err := BuildConfigFromDirectory(root, []string{"kitty"})
+	if err != nil {
+		t.Fatalf("BuildConfigFromDirectory: %v", err)
+	}
+	if len(cfg.ProgramConfigs) != 1 || cfg.ProgramConfigs[0].InstallPath != "~/.config/kitty/kitty.conf" {
+		t.Fatalf("unexpected program configs: %+v", cfg.ProgramConfigs)
+	}
+}
+
+func TestBuildConfigFromDirectoryWallpapers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	root := filepath.Join(home, ".config")
+
+	writeFile(t, filepath.Join(root, "hypr", "hyprland.conf"), "exec-once = hyprpaper\n")
+	writeFile(t, filepath.Join(root, "hypr", "hyprpaper.conf"),
+		"preload = ~/Pictures/wall.png\npreload = ~/Pictures/missing.png\npreload = ~/Pictures/notes.txt\n")
+	writeFile(t, filepath.Join(home, "Pictures", "wall.png"), "\x89PNG\r\n\x1a\nimage")
+	writeFile(t, filepath.Join(home, "Pictures", "notes.txt"), "not an image\n")
+
+	cfg, err := BuildConfigFromDirectory("", []string{"hyprland"})
+	if err != nil {
+		t.Fatalf("BuildConfigFromDirectory: %v", err)
+	}
+	paper := cfg.ProgramConfigs[0].SubConfigs[0]
+	if len(paper.Files) != 1 {
+		t.Fatalf("bundled files = %+v, want only wall.png", paper.Files)
+	}
+	if e := paper.Files[0]; e.TargetPath != "~/Pictures/wall.png" || e.FileContent.FileType != hyprconfig.FileTypeImage ||
+		e.FileContent.Hash != hyprconfig.ComputeHash(e.FileContent.Data) {
+		t.Errorf("bundled wallpaper = %+v", e)
+	}
+
+	var unbundled []string
+	for _, w := range hyprconfig.Wallpapers(cfg) {
+		if !w.Bundled {
+			unbundled = append(unbundled, w.Path)
+		}
+	}
+	if want := []string{"~/Pictures/missing.png", "~/Pictures/notes.txt"}; !reflect.DeepEqual(unbundled, want) {
+		t.Errorf("unbundled wallpapers = %q, want %q", unbundled, want)
+	}
+}
+
+func TestBuildConfigFromDirectorySourced(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	root := filepath.Join(home, ".config")
+
+	writeFile(t, filepath.Join(root, "hypr", "hyprland.conf"),
+		"source = monitors.conf\nsource = ~/.config/themes/nord/hyprland.conf\nsource = ~/.cache/wal/colors-hyprland.conf\n")
+	writeFile(t, filepath.Join(root, "hypr", "monitors.conf"), "monitor = , preferred, auto, 1\n")
+	writeFile(t, filepath.Join(root, "themes", "nord", "hyprland.conf"), "source = colors.conf\n")
+	writeFile(t, filepath.Join(root, "themes", "nord", "colors.conf"), "$accent = rgb(88c0d0)\n")
+	// Outside the install prefixes, so it can't be installed on another machine
+	writeFile(t, filepath.Join(home, ".cache", "wal", "colors-hyprland.conf"), "$background = rgb(101010)\n")
+
+	cfg, err := BuildConfigFromDirectory("", []string{"hyprland"})
+	if err != nil {
+		t.Fatalf("BuildConfigFromDirectory: %v", err)
+	}
+	var paths []string
+	for _, sub := range cfg.ProgramConfigs[0].SubConfigs {
+		paths = append(paths, sub.InstallPath)
+		if sub.Program != "hyprland" || sub.FileContent.Hash != hyprconfig.ComputeHash(sub.FileContent.Data) {
+			t.Errorf("sub-config %s = %+v", sub.InstallPath, sub)
+		}
+	}
+	want := []string{"~/.config/hypr/monitors.conf", "~/.config/themes/nord/hyprland.conf", "~/.config/themes/nord/colors.conf"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("sub-configs = %q, want %q", paths, want)
+	}
+}
+
+func TestBuildConfigFromDirectoryWithOptions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	root := filepath.Join(home, ".config")
+
+	writeFile(t, filepath.Join(root, "hypr", "hyprland.conf"), "source = custom.conf\n")
+	writeFile(t, filepath.Join(root, "hypr", "custom.conf"), "monitor = , preferred, auto, 1\n")
+	writeFile(t, filepath.Join(root, "hypr", "monitors.conf"), "monitor = DP-1, 2560x1440, 0x0, 1\n")
+
+	opts := configfinder.FinderOptions{HomeDir: home, Blacklist: []string{`/custom\.conf$`}}
+	cfg, err := BuildConfigFromDirectoryWithOptions("", []string{"hyprland"}, opts)
+	if err != nil {
+		t.Fatalf("BuildConfigFromDirectoryWithOptions: %v", err)
+	}
+	hypr := cfg.ProgramConfigs[0]
+	if hypr.InstallPath != "~/.config/hypr/hyprland.conf" {
+		t.Errorf("install path = %q", hypr.InstallPath)
+	}
+	if len(hypr.SubConfigs) != 1 || hypr.SubConfigs[0].InstallPath != "~/.config/hypr/monitors.conf" {
+		t.Errorf("expected only monitors.conf as a sub-config, got %+v", hypr.SubConfigs)
+	}
+}
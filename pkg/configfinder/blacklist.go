@@ -0,0 +1,109 @@
+package configfinder
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+// UserBlacklistFile is where users keep extra blacklist patterns, relative to their XDG config
+// home (~/.config by default).
+var UserBlacklistFile = filepath.Join("hypr-config-manager", "blacklist")
+
+// FinderOptions configures NewConfigFinderWithOptions. The zero value gives the embedded
+// blacklist extended by the user's blacklist file.
+type FinderOptions struct {
+	// HomeDir is searched for config files, the user's home directory when empty.
+	HomeDir string
+
+	// Blacklist are regular expressions added to the embedded blacklist.
+	Blacklist []string
+
+	// BlacklistFile holds more patterns, one per line, with blank lines and lines starting with
+	// # ignored. When empty UserBlacklistFile in the config home is used if it exists.
+	BlacklistFile string
+
+	// Whitelist are regular expressions for paths that are never blacklisted, whatever the
+	// blacklist says.
+	Whitelist []string
+
+	// Locations are the base directories whose program directories are searched, e.g.
+	// ~/.config for ~/.config/waybar. By default the XDG config and data directories, honoring
+	// XDG_CONFIG_HOME, XDG_DATA_HOME, XDG_CONFIG_DIRS and XDG_DATA_DIRS, followed by /etc.
+	Locations []string
+
+	// MaxDepth limits how many directory levels below a program's directory are searched,
+	// DefaultMaxDepth when 0.
+	MaxDepth int
+
+	// MaxFileSize is the largest file FindConfigFilesDetailed reads, in bytes. Larger files are
+	// reported as skipped. DefaultMaxFileSize when 0.
+	MaxFileSize int64
+
+	// StraceTimeout is how long strace traces a program that is started or attached to,
+	// DefaultStraceTimeout when 0.
+	StraceTimeout time.Duration
+
+	// Programs give the Flatpak app ids of programs, so their sandboxes are searched even when
+	// the id doesn't end in the program's name. hyprconfig.DefaultAllowedPrograms when nil.
+	Programs []hyprconfig.AllowedPrograms
+}
+
+// blacklistPatterns returns the patterns of a blacklist file, skipping blank lines and comments.
+func blacklistPatterns(data string) []string {
+	var patterns []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns
+}
+
+// readBlacklistFile returns the patterns in path. A missing file is only an error when it was
+// asked for explicitly.
+func readBlacklistFile(path string, explicit bool) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read blacklist file: %w", err)
+	}
+	return blacklistPatterns(string(data)), nil
+}
+
+// compilePatterns compiles patterns from source, skipping invalid ones with a warning so one bad
+// line can't stop the finder from working.
+func compilePatterns(source string, patterns []string) []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			slog.Warn("skipping invalid config finder pattern", "source", source, "pattern", p, "err", err)
+			continue
+		}
+		res = append(res, re)
+	}
+	return res
+}
+
+// matchesAny reports whether v matches one of res.
+func matchesAny(res []*regexp.Regexp, v string) bool {
+	for _, re := range res {
+		if re.MatchString(v) {
+			return true
+		}
+	}
+	return false
+}
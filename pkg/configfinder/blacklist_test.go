@@ -0,0 +1,72 @@
+package configfinder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlacklistPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	userFile := filepath.Join(home, ".config", UserBlacklistFile)
+	if err := os.MkdirAll(filepath.Dir(userFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	data := "# user patterns\n\n.*\\.bak$\n[unclosed\n"
+	if err := os.WriteFile(userFile, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cf, err := NewConfigFinderWithOptions(FinderOptions{
+		HomeDir:   home,
+		Blacklist: []string{`.*/cache/.*`, `(bad`},
+		Whitelist: []string{`.*/hypr/scripts/.*\.sh$`, `.*/waybar/cache/keep$`},
+	})
+	if err != nil {
+		t.Fatalf("invalid patterns must not fail construction: %v", err)
+	}
+
+	for path, want := range map[string]bool{
+		"/home/u/.config/hypr/hyprland.conf":        false,
+		"/home/u/.config/hypr/shaders/blur.frag":    true,  // embedded
+		"/home/u/.config/waybar/launch.sh":          true,  // embedded
+		"/home/u/.config/waybar/cache/state":        true,  // options
+		"/home/u/.config/kitty/kitty.conf.bak":      true,  // user file
+		"/home/u/.config/hypr/scripts/volume.sh":    false, // whitelist beats embedded
+		"/home/u/.config/waybar/cache/keep":         false, // whitelist beats options
+		"/home/u/.config/hypr/scripts/old.conf.bak": true,  // whitelist doesn't match
+	} {
+		if got := cf.IsBlacklisted(path); got != want {
+			t.Errorf("IsBlacklisted(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestBlacklistFileOption(t *testing.T) {
+	home := t.TempDir()
+
+	// Without a user blacklist only the embedded defaults apply
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.IsBlacklisted("/home/u/.config/foot/foot.ini") || !cf.IsBlacklisted("/home/u/.config/foot/tmp_1") {
+		t.Error("default finder doesn't use the embedded blacklist")
+	}
+
+	file := filepath.Join(home, "patterns")
+	if err := os.WriteFile(file, []byte(".*/foot/.*\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cf, err = NewConfigFinderWithOptions(FinderOptions{HomeDir: home, BlacklistFile: file}); err != nil {
+		t.Fatal(err)
+	}
+	if !cf.IsBlacklisted("/home/u/.config/foot/foot.ini") {
+		t.Error("patterns of BlacklistFile not applied")
+	}
+
+	if _, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, BlacklistFile: filepath.Join(home, "missing")}); err == nil {
+		t.Error("missing BlacklistFile: got nil error")
+	}
+}
@@ -6,16 +6,19 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
 )
 
@@ -25,77 +28,180 @@ var blacklist string
 // ConfigFinder struct contains the logic to find config files.
 type ConfigFinder struct {
 	HomeDir      string
+	ConfigHome   string // $XDG_CONFIG_HOME, ~/.config by default
+	locations    []string
 	blacklistReg []*regexp.Regexp
-	timeout      int
+	whitelistReg []*regexp.Regexp
+	timeout      time.Duration
+	maxDepth     int
+	maxFileSize  int64
+	flatpakApps  map[string]string // program name to Flatpak app id
 }
 
-// NewConfigFinder creates a new instance of ConfigFinder.
+// DefaultMaxDepth is how many directory levels below a program's directory the finder searches
+// unless FinderOptions.MaxDepth says otherwise.
+const DefaultMaxDepth = 6
+
+// DefaultStraceTimeout is how long strace traces a program unless FinderOptions.StraceTimeout
+// says otherwise. Most programs read their config on startup.
+const DefaultStraceTimeout = 2 * time.Second
+
+// straceCommand is the strace binary that is run, replaced in tests.
+var straceCommand = "strace"
+
+// NewConfigFinder creates a new instance of ConfigFinder with the default options.
 func NewConfigFinder() (*ConfigFinder, error) {
-	homeDir, err := os.UserHomeDir()
+	return NewConfigFinderWithOptions(FinderOptions{})
+}
+
+// NewConfigFinderWithOptions creates a ConfigFinder whose blacklist is the embedded one extended
+// by opts. Invalid patterns are skipped with a warning.
+func NewConfigFinderWithOptions(opts FinderOptions) (*ConfigFinder, error) {
+	homeDir := opts.HomeDir
+	if homeDir == "" {
+		var err error
+		if homeDir, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("unable to get home directory: %v", err)
+		}
+	}
+
+	configHome := xdgDir("XDG_CONFIG_HOME", filepath.Join(homeDir, ".config"))
+	locations := opts.Locations
+	if len(locations) == 0 {
+		locations = xdgLocations(homeDir)
+	}
+
+	blacklistFile, explicit := opts.BlacklistFile, opts.BlacklistFile != ""
+	if !explicit {
+		blacklistFile = filepath.Join(configHome, UserBlacklistFile)
+	}
+	userPatterns, err := readBlacklistFile(blacklistFile, explicit)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get home directory: %v", err)
+		return nil, err
 	}
-	var blacklistReg []*regexp.Regexp
-	for _, r := range strings.Split(blacklist, "\n") {
-		re, err := regexp.Compile(r)
-		if err != nil {
-			return nil, err
-		}
-		blacklistReg = append(blacklistReg, re)
 
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	maxFileSize := opts.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = DefaultMaxFileSize
 	}
+	timeout := opts.StraceTimeout
+	if timeout <= 0 {
+		timeout = DefaultStraceTimeout
+	}
+	programs := opts.Programs
+	if programs == nil {
+		programs = hyprconfig.DefaultAllowedPrograms()
+	}
+	flatpakApps := map[string]string{}
+	for _, p := range programs {
+		if p.FlatpakID != "" {
+			flatpakApps[p.ProgramName] = p.FlatpakID
+		}
+	}
+
+	blacklistReg := compilePatterns("embedded", blacklistPatterns(blacklist))
+	blacklistReg = append(blacklistReg, compilePatterns("options", opts.Blacklist)...)
+	blacklistReg = append(blacklistReg, compilePatterns(blacklistFile, userPatterns)...)
 	return &ConfigFinder{
 		HomeDir:      homeDir,
+		ConfigHome:   configHome,
+		locations:    locations,
 		blacklistReg: blacklistReg,
-		timeout:      2,
+		whitelistReg: compilePatterns("whitelist", opts.Whitelist),
+		timeout:      timeout,
+		maxDepth:     maxDepth,
+		maxFileSize:  maxFileSize,
+		flatpakApps:  flatpakApps,
 	}, nil
 }
 
-// SearchCommonLocations searches common directories for config files.
-func (cf *ConfigFinder) SearchCommonLocations(program string) []string {
-	locations := []string{
-		filepath.Join(cf.HomeDir, ".config", program),
-		filepath.Join(cf.HomeDir, ".local", "share", program),
-		filepath.Join("/etc", program),
-		filepath.Join("/usr/share", program),
-	}
+// Locations returns the base directories searched for program configs, see
+// FinderOptions.Locations.
+func (cf *ConfigFinder) Locations() []string {
+	return slices.Clone(cf.locations)
+}
 
-	var configFiles []string
-	for _, location := range locations {
-		files, err := findConfigFiles(location)
-		if err != nil {
-			continue
+// SearchCommonLocations searches the program's directory in each base location, and in those
+// of its Flatpak and Snap sandboxes, for config files, stopping early once ctx is done.
+func (cf *ConfigFinder) SearchCommonLocations(ctx context.Context, program string) []FoundFile {
+	var configFiles []FoundFile
+	for _, base := range cf.programLocations(program) {
+		// Most programs only use some of the locations, missing ones are skipped
+		files, _ := findConfigFiles(ctx, filepath.Join(base, program), cf.maxDepth)
+		for _, f := range files {
+			configFiles = append(configFiles, cf.newFoundFile(f, base))
+		}
+		if ctx.Err() != nil {
+			break
 		}
-		configFiles = append(configFiles, files...)
 	}
 
 	return configFiles
 }
 
 // findConfigFiles searches the given directory for any file named "config", "settings", etc.
-func findConfigFiles(dir string) ([]string, error) {
+// Directories more than maxDepth levels below dir, symlinked directories below it and junkDirs are
+// not entered, so symlink loops and huge dependency trees can't stall the search. On cancellation
+// it returns what it found so far along with ctx's error.
+func findConfigFiles(ctx context.Context, dir string, maxDepth int) ([]string, error) {
 	var configFiles []string
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		return nil, err
-	}
+	// The trailing separator makes WalkDir follow dir itself when it is a symlink, as with
+	// dotfiles managed by stow
+	root := dir + string(filepath.Separator)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Unreadable subdirectories are skipped like before
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
-	for _, file := range files {
-		if file.IsDir() {
-			// Recursively check subdirectories
-			subDir := filepath.Join(dir, file.Name())
-			subFiles, err := findConfigFiles(subDir)
-			if err != nil {
-				continue
+		if d.IsDir() {
+			if path == root {
+				return nil
 			}
-			configFiles = append(configFiles, subFiles...)
-		} else {
-			if strings.Contains(file.Name(), "config") || strings.Contains(file.Name(), "settings") {
-				configFiles = append(configFiles, filepath.Join(dir, file.Name()))
+			rel, _ := filepath.Rel(dir, path)
+			if isJunkDir(d.Name()) || strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// WalkDir doesn't follow symlinks, make sure this one isn't a directory either
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		if strings.Contains(d.Name(), "config") || strings.Contains(d.Name(), "settings") {
+			configFiles = append(configFiles, path)
+		}
+		return nil
+	})
+	return configFiles, err
+}
+
+// junkDirs are directories that never hold config files worth backing up.
+var junkDirs = []string{"node_modules", ".git", "__pycache__", "cache"}
+
+// isJunkDir reports whether a directory named name is one of junkDirs.
+func isJunkDir(name string) bool {
+	for _, junk := range junkDirs {
+		if strings.EqualFold(name, junk) {
+			return true
 		}
 	}
-	return configFiles, nil
+	return false
 }
 
 // RunStrace runs `strace` on the given application to find files it accesses.
@@ -120,126 +226,151 @@ func FindPIDByName(programName string) (string, error) {
 	return pid[0], nil
 }
 
-// RunStrace runs `strace` on the given application to find files it accesses.
-// RunStrace runs `strace` on the given application to find files it accesses.
+// ErrPtraceNotPermitted is returned when strace may not attach to a running program, usually
+// because of the Yama ptrace_scope setting.
+var ErrPtraceNotPermitted = errors.New("not permitted to trace the running program")
+
+// ErrStraceNotInstalled is why a PartialResult skipped tracing when strace isn't on the PATH.
+var ErrStraceNotInstalled = errors.New("strace is not installed")
+
+// PartialResult is returned along with the config files of a program that were found without
+// tracing it, from its config locations and the files it has open if it is running. Files the
+// program only reads on startup or on demand may be missing, so callers can treat it as a
+// warning rather than a failure.
+type PartialResult struct {
+	Program string
+	Err     error // why tracing was skipped, e.g. ErrStraceNotInstalled or ErrPtraceNotPermitted
+}
+
+func (p *PartialResult) Error() string {
+	return fmt.Sprintf("tracing %s was skipped, only its config locations and open files were searched: %v", p.Program, p.Err)
+}
+
+func (p *PartialResult) Unwrap() error {
+	return p.Err
+}
+
 // RunStrace runs `strace` on the given application to find files it accesses.
 func (cf *ConfigFinder) RunStrace(application string) ([]string, error) {
-	// Set a timeout (e.g., 5 seconds)
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cf.timeout)*time.Second)
+	return cf.runStrace(context.Background(), application)
+}
+
+// runStrace is RunStrace stopping strace and everything it started once ctx is done.
+func (cf *ConfigFinder) runStrace(ctx context.Context, application string) ([]string, error) {
+	return cf.strace(ctx, "", "-e", "trace=file", "-f", "-y", application)
+}
+
+// AttachStrace attaches `strace` to the running process pid for the finder's timeout to find the
+// files it accesses, e.g. when it reloads its config. Unlike RunStrace it doesn't start another
+// instance of the program, which for a compositor like Hyprland would start a second session.
+func (cf *ConfigFinder) AttachStrace(ctx context.Context, pid string) ([]string, error) {
+	if _, err := strconv.Atoi(pid); err != nil {
+		return nil, fmt.Errorf("invalid pid %q", pid)
+	}
+	return cf.strace(ctx, pid, "-p", pid, "-f", "-y", "-e", "trace=file")
+}
+
+// strace runs strace with args and a log file until it exits, the finder's timeout passes or
+// parent is done, and returns the config files in the log. When attached to the running process
+// pid, strace is stopped with SIGTERM so it detaches and the process keeps running; otherwise
+// the program was started by strace and is killed along with it.
+func (cf *ConfigFinder) strace(parent context.Context, pid string, args ...string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(parent, cf.timeout)
 	defer cancel()
 
-	logFile := "/tmp/application.log"
+	// Every run gets its own private directory for the log, so several programs can be traced at
+	// once and no other user can put a symlink where strace writes
+	dir, err := os.MkdirTemp("", "hypr-strace-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create strace log directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			slog.Error("failed to remove strace log directory", "dir", dir, "err", err)
+		}
+	}()
+	logFile := filepath.Join(dir, "strace.log")
 
-	// Removed `timeout` from the command. Go's context handles timeout/cancellation
-	// more reliably with the Process Group fix. The `-k` flag to strace
-	// will ensure child processes are traced.
-	cmd := exec.CommandContext(ctx, "strace", "-e", "trace=file", "-f", "-o", logFile, application)
+	cmd := exec.CommandContext(ctx, straceCommand, append([]string{"-o", logFile}, args...)...)
 
-	// 💡 CRITICAL FIX 1: Set the process group ID.
-	// This makes the OS put `strace` (and the application) into a new process group.
+	// Put strace and the application into their own process group, so the whole group can be
+	// killed on timeout or cancellation instead of leaving the application running.
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if pid != "" {
+			return cmd.Process.Signal(syscall.SIGTERM)
+		}
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
 
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
 
-	// --- CRITICAL FIX 2: Process Group Cleanup ---
-	// Start the command first.
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start command: %w", err)
 	}
+	// Whatever the application left running in the group is killed once strace exits
+	defer func() { _ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }()
 
-	// Get the process group ID (which is the PID of the strace process itself).
-	pgid, err := syscall.Getpgid(cmd.Process.Pid)
-	if err != nil {
-		// If we can't get the PGID, we fall back to killing the single process.
-		defer cmd.Process.Kill()
-	} else {
-		// Defer a function to clean up the entire process group.
-		defer func() {
-			// Signal the entire process group (negative PID) with SIGKILL.
-			// This is the most reliable way to stop strace and all its children.
-			// We use SIGKILL (9) to ensure they stop immediately.
-			_ = syscall.Kill(-pgid, syscall.SIGKILL)
-		}()
-	}
-	go func() {
-		t := time.NewTimer(time.Duration(cf.timeout) * time.Second)
-		select {
-		case <-t.C:
-			err = exec.Command("kill", "-9", strconv.Itoa(cmd.Process.Pid)).Run()
-			if err != nil {
-				slog.Info("strace timed out but cleanup should have already been handled.", "e", err)
-			}
-			err = exec.Command("kill", "-TERM", "-g", strconv.Itoa(-pgid)).Run()
-			if err != nil {
-				slog.Info("strace timed out but cleanup should have already been handled.", "e", err)
-			}
-		}
-	}()
-	// --- End of CRITICAL FIX 2 ---
-
-	// Wait for the command to finish or the context to be canceled.
 	err = cmd.Wait()
-
-	// Since we are using an aggressive cleanup (SIGKILL on defer), the process group
-	// should be gone. Now, check the error.
+	if parentErr := parent.Err(); parentErr != nil {
+		return nil, parentErr
+	}
+	if pid != "" && strings.Contains(out.String(), "Operation not permitted") {
+		return nil, ptraceError(pid)
+	}
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		// This is the expected timeout case. We consider this a successful run
-		// that was simply cut short by the timeout.
-		slog.Info("strace timed out but cleanup should have already been handled.")
+		// This is the expected case: the application was traced until the timeout
+		slog.Debug("strace stopped after the timeout", "args", args)
 	} else if err != nil {
-		// Handle other non-timeout errors (e.g., command not found, unexpected exit).
-		// Since we defer-killed the group, the Wait() may return an error like
-		// "signal: killed", which we should ignore if it's due to our cleanup.
-		if !strings.Contains(err.Error(), "signal: killed") {
-			// Only return a failure if it's a *real* error, not a side-effect of SIGKILL.
-			return nil, fmt.Errorf("command failed with error: %w. Output: %s", err, out.String())
-		}
+		return nil, fmt.Errorf("command failed with error: %w. Output: %s", err, out.String())
 	}
 
-	// ... rest of the file parsing and cleanup ...
-	// (Your parsing logic here)
-	// ...
+	// Relative paths in the log are relative to the working directory of the traced program,
+	// which strace started in ours
+	cwd, _ := os.Getwd()
+	if pid != "" {
+		cwd, _ = os.Readlink(filepath.Join("/proc", pid, "cwd"))
+	}
 
 	// Parse the output to extract the file paths
-	var filePaths []string
 	data, err := os.ReadFile(logFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read log file %s: %w", logFile, err)
 	}
+	return parseStraceLog(data, cwd, cf.isConfigPath), nil
+}
 
-	// ... (rest of parsing logic) ...
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, ".config") && strings.Contains(line, "newfstatat") {
-			l, err := ExtractBetweenQuotes(line)
-			if err != nil {
-				continue
-			}
-
-			if !cf.isBlacklisted(l) {
-				continue
-			}
+// ptraceScopeFile holds the Yama ptrace_scope setting, 1 or higher restricting strace -p.
+var ptraceScopeFile = "/proc/sys/kernel/yama/ptrace_scope"
 
-			filePaths = append(filePaths, l)
-		}
+// ptraceError explains how to allow attaching strace to pid.
+func ptraceError(pid string) error {
+	scope := "unknown"
+	if data, err := os.ReadFile(ptraceScopeFile); err == nil {
+		scope = strings.TrimSpace(string(data))
 	}
+	return fmt.Errorf("%w (pid %s, kernel.yama.ptrace_scope = %s): run as root, or allow tracing until the next "+
+		"reboot with `sudo sysctl kernel.yama.ptrace_scope=0`", ErrPtraceNotPermitted, pid, scope)
+}
 
-	// Remove the log file (cleanup from the original function)
-	if err := os.Remove(logFile); err != nil {
-		slog.Error("failed to remove log file", "file", logFile, "err", err)
-	}
+// shouldInclude reports whether a path found by the finder is kept, i.e. not blacklisted.
+func (cf *ConfigFinder) shouldInclude(path string) bool {
+	return !cf.IsBlacklisted(path)
+}
 
-	return utils.DeduplicateStrings(filePaths), nil
+// isConfigPath reports whether a path a program accesses may be one of its config files: below
+// one of the finder's locations or a dotfile in the home directory, and not blacklisted.
+func (cf *ConfigFinder) isConfigPath(path string) bool {
+	return cf.baseOf(path) != "" && cf.shouldInclude(path)
 }
-func (cf *ConfigFinder) isBlacklisted(v string) bool {
-	for _, r := range cf.blacklistReg {
-		if r.MatchString(v) {
-			return false
-		}
-	}
-	return true
+
+// IsBlacklisted reports whether path matches one of the blacklist patterns and none of the
+// whitelist ones.
+func (cf *ConfigFinder) IsBlacklisted(path string) bool {
+	return matchesAny(cf.blacklistReg, path) && !matchesAny(cf.whitelistReg, path)
 }
 
 func ExtractBetweenQuotes(input string) (string, error) {
@@ -258,24 +389,119 @@ func ExtractBetweenQuotes(input string) (string, error) {
 }
 
 // FindConfigFiles combines all methods to locate configuration files for a program.
-func (cf *ConfigFinder) FindConfigFiles(program string) ([]string, error) {
+// See FindConfigFilesDetailed for their metadata. When the program can't be traced, e.g.
+// because strace isn't installed, the files found without tracing are returned along with a
+// *PartialResult.
+func (cf *ConfigFinder) FindConfigFiles(ctx context.Context, program string) ([]string, error) {
+	found, err := cf.locate(ctx, program)
+	var partial *PartialResult
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
+	}
+	paths := make([]string, len(found))
+	for i, f := range found {
+		paths[i] = f.Path
+	}
+	return paths, err
+}
+
+// locate returns the files in the common locations followed by the ones the program accesses,
+// each path once, and a *PartialResult when the program couldn't be traced.
+func (cf *ConfigFinder) locate(ctx context.Context, program string) ([]FoundFile, error) {
 	// Step 1: Search common locations
-	commonConfigs := cf.SearchCommonLocations(program)
+	found := cf.SearchCommonLocations(ctx, program)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	// Step 2: Run `strace` to find files accessed by the program
-	straceConfigs, err := cf.RunStrace(program)
-	if err != nil {
+	// Step 2: Look at the files the running program has open, or run it under strace
+	accessed, err := cf.accessedFiles(ctx, program)
+	var partial *PartialResult
+	if err != nil && !errors.As(err, &partial) {
 		return nil, err
 	}
 
 	// Combine the results
-	allConfigs := append(commonConfigs, straceConfigs...)
-	return allConfigs, nil
+	seen := map[string]bool{}
+	for _, f := range found {
+		seen[f.Path] = true
+	}
+	for _, p := range accessed {
+		if !seen[p] {
+			seen[p] = true
+			found = append(found, cf.newFoundFile(p, cf.baseOf(p)))
+		}
+	}
+	// Step 3: Hyprland configs are often split into files sourced from hyprland.conf
+	if program == "hyprland" {
+		found = cf.appendSourced(found)
+	}
+
+	if partial != nil {
+		return found, partial
+	}
+	return found, nil
+}
+
+// appendSourced appends the files sourced by the hyprland.conf files in found that aren't in it
+// yet and aren't blacklisted, see hyprconfig.ResolveSourcedFiles.
+func (cf *ConfigFinder) appendSourced(found []FoundFile) []FoundFile {
+	seen := map[string]bool{}
+	for _, f := range found {
+		seen[f.Path] = true
+	}
+	for _, f := range slices.Clone(found) {
+		if filepath.Base(f.Path) != "hyprland.conf" {
+			continue
+		}
+		sourced, err := hyprconfig.ResolveSourcedFiles(f.Path)
+		if err != nil {
+			continue // e.g. a stat'ed path that is gone
+		}
+		for _, p := range sourced {
+			if !seen[p] && cf.shouldInclude(p) {
+				seen[p] = true
+				found = append(found, cf.newFoundFile(p, cf.baseOf(p)))
+			}
+		}
+	}
+	return found
+}
+
+// accessedFiles returns the config files program uses. A program that is already running is
+// never started again, as restarting a daemon like waybar or a second Hyprland is wrong: the
+// files it has open are combined with the ones strace sees it access once attached to it.
+// Otherwise the program is started under strace.
+//
+// When strace isn't installed or may not attach, only the files a running program has open are
+// returned, along with a *PartialResult.
+func (cf *ConfigFinder) accessedFiles(ctx context.Context, program string) ([]string, error) {
+	pid, err := FindPIDByName(program)
+	if !cf.IsStraceInstalled() {
+		var open []string
+		if err == nil {
+			// The program may have exited since, leaving nothing to add
+			open, _ = cf.FindOpenConfigFiles(pid)
+		}
+		return open, &PartialResult{Program: program, Err: ErrStraceNotInstalled}
+	}
+	if err != nil {
+		return cf.runStrace(ctx, program)
+	}
+
+	open, openErr := cf.FindOpenConfigFiles(pid)
+	traced, err := cf.AttachStrace(ctx, pid)
+	switch {
+	case err == nil:
+		return utils.DeduplicateStrings(append(open, traced...)), nil
+	case openErr == nil && errors.Is(err, ErrPtraceNotPermitted):
+		return open, &PartialResult{Program: program, Err: err}
+	}
+	return nil, err
 }
 
 // IsStraceInstalled checks if strace is installed on the system.
 func (cf *ConfigFinder) IsStraceInstalled() bool {
-	cmd := exec.Command("which", "strace")
-	out, err := cmd.Output()
-	return err == nil && len(strings.TrimSpace(string(out))) > 0
+	_, err := exec.LookPath(straceCommand)
+	return err == nil
 }
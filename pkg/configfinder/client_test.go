@@ -1,5 +1,19 @@
 package configfinder
 
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
 //func TestFind(t *testing.T) {
 //	// Initialize the ConfigFinder
 //	cf, err := NewConfigFinder()
@@ -49,3 +63,373 @@ package configfinder
 //	}
 //
 //}
+
+func TestParseStraceLog(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	cf, err := NewConfigFinderWithOptions(FinderOptions{
+		HomeDir:   "/home/u",
+		Locations: []string{"/home/u/.config", "/home/u/.local/share"},
+		Blacklist: []string{`.*/cache/.*`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		fixture string
+		want    []string
+	}{
+		{"hyprland.strace", []string{
+			"/home/u/.config/hypr",
+			"/home/u/.config/hypr/hyprland.conf",
+			"/home/u/.config/hypr/monitors.conf",
+			"/home/u/.config/xdg-desktop-portal/hyprland-portals.conf",
+		}},
+		{"waybar.strace", []string{
+			"/home/u/.config/waybar/config",
+			"/home/u/.config/waybar/style.css",
+			"/home/u/.config/gtk-3.0/settings.ini",
+		}},
+		{"nwg-look.strace", []string{
+			"/home/u/.gtkrc-2.0",
+			"/home/u/.config/gtk-3.0/settings.ini",
+			"/home/u/.config/gtk-3.0/bookmarks",
+			"/home/u/.config/nwg-look/config",
+			"/home/u/.local/share/nwg-look/gsettings",
+			"/home/u/.config/gtk-4.0/gtk.css",
+			"/home/u/.config/xsettingsd/xsettingsd.conf", // relative to the cwd
+			"/home/u/.config/nwg-look/themes.json",       // relative to the cwd after chdir
+			"/home/u/.config/gtk-3.0/gtk.css",            // relative to an annotated descriptor
+			"/home/u/.config/dconf/user",                 // unfinished and resumed
+		}},
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := parseStraceLog(data, "/home/u", cf.isConfigPath); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.fixture, got, tt.want)
+		}
+	}
+
+	all := parseStraceLog([]byte(`1 newfstatat(AT_FDCWD, "/home/u/.config/hypr/tmp_x", {st_mode=S_IFREG|0644, ...}, 0) = 0`), "", func(string) bool { return true })
+	if len(all) != 1 {
+		t.Errorf("include accepting everything: got %q", all)
+	}
+
+	// strace writing to a terminal prefixes lines with [pid N], a single process has no pid
+	tty := "[pid 61203] openat(AT_FDCWD, \"/home/u/.config/dconf/user\", O_RDONLY) = 8\n" +
+		"open(\"/home/u/.config/foot/foot\\342\\200\\224.ini\", O_RDONLY) = 3\n" +
+		"openat(AT_FDCWD, \"foot.ini\", O_RDONLY) = 4\n"
+	want := []string{"/home/u/.config/dconf/user", "/home/u/.config/foot/foot\u2014.ini"}
+	if got := parseStraceLog([]byte(tty), "", cf.isConfigPath); !slices.Equal(got, want) {
+		t.Errorf("terminal output: got %q, want %q", got, want)
+	}
+}
+
+func TestFindConfigFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		"waybar/config",
+		"waybar/themes/dark/settings.json",
+		"waybar/style.css",
+		"waybar/node_modules/left-pad/config.js",
+		"waybar/.git/config",
+		"waybar/cache/config.bak",
+		"waybar/a/b/c/d/e/f/config",
+	} {
+		path := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A loop back to the program directory and a symlinked config file
+	if err := os.Symlink(filepath.Join(root, "waybar"), filepath.Join(root, "waybar", "themes", "loop-config")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "waybar", "config"), filepath.Join(root, "waybar", "config.link")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := findConfigFiles(context.Background(), filepath.Join(root, "waybar"), DefaultMaxDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"waybar/config", "waybar/config.link", "waybar/themes/dark/settings.json"}
+	for i := range want {
+		want[i] = filepath.Join(root, want[i])
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, _ = findConfigFiles(context.Background(), filepath.Join(root, "waybar"), 7)
+	if !slices.Contains(got, filepath.Join(root, "waybar/a/b/c/d/e/f/config")) {
+		t.Errorf("maxDepth 7 missed the deepest config: %q", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := findConfigFiles(ctx, filepath.Join(root, "waybar"), DefaultMaxDepth); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled search: got %v, want context.Canceled", err)
+	}
+}
+
+func TestSearchCommonLocations(t *testing.T) {
+	home, configHome, dataHome, sysConfig := t.TempDir(), t.TempDir(), t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_CONFIG_DIRS", "relative/ignored:"+sysConfig)
+	t.Setenv("XDG_DATA_DIRS", "")
+	for _, f := range []string{
+		filepath.Join(configHome, "waybar", "config"),
+		filepath.Join(dataHome, "waybar", "themes", "config.css"),
+		filepath.Join(sysConfig, "waybar", "config"),
+		filepath.Join(home, ".config", "waybar", "config"), // shadowed by XDG_CONFIG_HOME
+	} {
+		if err := os.MkdirAll(filepath.Dir(f), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(f, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.ConfigHome != configHome {
+		t.Errorf("ConfigHome = %q, want %q", cf.ConfigHome, configHome)
+	}
+	wantLocations := []string{configHome, dataHome, sysConfig, "/usr/local/share", "/usr/share", "/etc"}
+	if got := cf.Locations(); !slices.Equal(got, wantLocations) {
+		t.Errorf("Locations() = %q, want %q", got, wantLocations)
+	}
+
+	got := cf.SearchCommonLocations(context.Background(), "waybar")
+	want := []FoundFile{
+		{Path: filepath.Join(configHome, "waybar", "config"), Base: configHome},
+		{Path: filepath.Join(dataHome, "waybar", "themes", "config.css"), Base: dataHome},
+		{Path: filepath.Join(sysConfig, "waybar", "config"), Base: sysConfig},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("SearchCommonLocations = %+v, want %+v", got, want)
+	}
+	if rel := got[1].Rel(); rel != filepath.Join("waybar", "themes", "config.css") {
+		t.Errorf("Rel() = %q", rel)
+	}
+
+	// Locations replace the XDG defaults
+	if cf, err = NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{filepath.Join(home, ".config")}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := cf.SearchCommonLocations(context.Background(), "waybar"); len(got) != 1 || got[0].Path != filepath.Join(home, ".config", "waybar", "config") {
+		t.Errorf("with Locations: %+v", got)
+	}
+}
+
+func TestDescribeFoundFiles(t *testing.T) {
+	home := t.TempDir()
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{home, filepath.Join(home, "share")}, MaxFileSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"hypr/hyprland.conf":     []byte("general {\n    gaps_in = 5\n}\n"),
+		"hypr/notes":             []byte("remember to bind the media keys\n"),
+		"share/hypr/launch":      []byte("#!/bin/sh\nhyprctl reload\n"),
+		"hypr/wall.png":          []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"hypr/plugin.so":         {0x7f, 'E', 'L', 'F', 2, 1, 1, 0, 0, 0},
+		"hypr/huge-settings.txt": []byte(strings.Repeat("x", 65)),
+	}
+	for name, data := range files {
+		path := filepath.Join(home, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"hypr/hyprland.conf": hyprconfig.FileTypeConfig,
+		"hypr/notes":         hyprconfig.FileTypeText,
+		"share/hypr/launch":  hyprconfig.FileTypeScript,
+		"hypr/wall.png":      hyprconfig.FileTypeImage,
+		"hypr/plugin.so":     hyprconfig.FileTypeBinary,
+	} {
+		path := filepath.Join(home, name)
+		f := FoundFile{Path: path, Base: cf.baseOf(path)}
+		if !cf.describe(&f) {
+			t.Fatalf("%s not described", name)
+		}
+		if f.Type != want || f.SHA256 != hyprconfig.ComputeHash(files[name]) || f.Size != int64(len(files[name])) || f.ModTime.IsZero() || f.Skipped != "" {
+			t.Errorf("%s: got %+v, want type %s", name, f, want)
+		}
+	}
+	if base := cf.baseOf(filepath.Join(home, "share/hypr/launch")); base != filepath.Join(home, "share") {
+		t.Errorf("baseOf picked %q, want the deepest location", base)
+	}
+
+	big := FoundFile{Path: filepath.Join(home, "hypr/huge-settings.txt")}
+	if !cf.describe(&big) || big.Skipped == "" || big.SHA256 != "" || big.Type != "" || big.Size != 65 {
+		t.Errorf("oversized file: %+v", big)
+	}
+	for _, path := range []string{filepath.Join(home, "hypr"), filepath.Join(home, "missing")} {
+		if f := (FoundFile{Path: path}); cf.describe(&f) {
+			t.Errorf("%s described as a file: %+v", path, f)
+		}
+	}
+}
+
+func TestAttachStrace(t *testing.T) {
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cf.AttachStrace(context.Background(), "hyprland"); err == nil {
+		t.Error("invalid pid: got nil error")
+	}
+
+	scope := filepath.Join(t.TempDir(), "ptrace_scope")
+	if err := os.WriteFile(scope, []byte("1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := ptraceScopeFile
+	ptraceScopeFile = scope
+	t.Cleanup(func() { ptraceScopeFile = old })
+
+	err = ptraceError("4242")
+	if !errors.Is(err, ErrPtraceNotPermitted) {
+		t.Fatalf("got %v, want ErrPtraceNotPermitted", err)
+	}
+	for _, want := range []string{"pid 4242", "ptrace_scope = 1", "sysctl kernel.yama.ptrace_scope=0"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q doesn't mention %q", err, want)
+		}
+	}
+}
+
+func TestRunStraceConcurrent(t *testing.T) {
+	home := t.TempDir()
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// The fake strace logs a config file named after the program it runs, which is the trivial
+	// `true`, and lingers so both runs write their logs at the same time
+	fake := filepath.Join(t.TempDir(), "strace")
+	script := "#!/bin/sh\nlog=$2\neval app=\\${$#}\n" +
+		"echo \"1 newfstatat(AT_FDCWD, \\\"" + home + "/.config/$app/config\\\", {}, 0) = 0\" > \"$log\"\n" +
+		"sleep 0.2\n\"$app\"\n"
+	if err := os.WriteFile(fake, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	old := straceCommand
+	straceCommand = fake
+	t.Cleanup(func() { straceCommand = old })
+
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, StraceTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	apps := []string{"true", "also-true"}
+	if err := os.Symlink("/bin/true", filepath.Join(bin, apps[1])); err != nil {
+		t.Fatal(err)
+	}
+
+	results := make([][]string, len(apps))
+	errs := make([]error, len(apps))
+	var wg sync.WaitGroup
+	for i, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = cf.RunStrace(app)
+		}()
+	}
+	wg.Wait()
+	for i, app := range apps {
+		if errs[i] != nil {
+			t.Fatalf("%s: %v", app, errs[i])
+		}
+		if want := []string{home + "/.config/" + app + "/config"}; !slices.Equal(results[i], want) {
+			t.Errorf("%s: got %q, want %q", app, results[i], want)
+		}
+	}
+
+	if entries, err := os.ReadDir(tmp); err != nil || len(entries) != 0 {
+		t.Errorf("strace logs left behind: %v %v", entries, err)
+	}
+}
+
+func TestFindConfigFilesWithoutStrace(t *testing.T) {
+	home := t.TempDir()
+	configHome := filepath.Join(home, ".config")
+	writeTree(t, configHome, "hcm-test-program/config")
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{configHome}, StraceTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	old := straceCommand
+	t.Cleanup(func() { straceCommand = old })
+
+	straceCommand = filepath.Join(t.TempDir(), "strace")
+	if cf.IsStraceInstalled() {
+		t.Fatal("missing strace reported as installed")
+	}
+	paths, err := cf.FindConfigFiles(ctx, "hcm-test-program")
+	var partial *PartialResult
+	if !errors.As(err, &partial) || !errors.Is(err, ErrStraceNotInstalled) || partial.Program != "hcm-test-program" {
+		t.Fatalf("got %v, want a PartialResult for missing strace", err)
+	}
+	if want := []string{filepath.Join(configHome, "hcm-test-program/config")}; !slices.Equal(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+
+	// An installed strace sees the program read a file outside its directory
+	if err := os.WriteFile(straceCommand, []byte("#!/bin/sh\necho '1 openat(AT_FDCWD, \""+configHome+"/gtk-3.0/settings.ini\", O_RDONLY) = 3' > \"$2\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTree(t, configHome, "gtk-3.0/settings.ini")
+	files, err := cf.FindConfigFilesDetailed(ctx, "hcm-test-program")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rels []string
+	for _, f := range files {
+		rels = append(rels, f.Rel())
+	}
+	if want := []string{"hcm-test-program/config", "gtk-3.0/settings.ini"}; !slices.Equal(rels, want) {
+		t.Errorf("got %q, want %q", rels, want)
+	}
+}
+
+func TestAppendSourced(t *testing.T) {
+	home := t.TempDir()
+	configHome := filepath.Join(home, ".config")
+	writeTree(t, configHome, "hypr/colors.conf", "hypr/tmp_generated.conf", "themes/nord.conf")
+	main := filepath.Join(configHome, "hypr/hyprland.conf")
+	if err := os.WriteFile(main, []byte("source = colors.conf\nsource = tmp_generated.conf\nsource = ../themes/nord.conf\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{configHome}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rels []string
+	for _, f := range cf.appendSourced([]FoundFile{cf.newFoundFile(main, configHome), cf.newFoundFile(filepath.Join(configHome, "hypr/colors.conf"), configHome)}) {
+		rels = append(rels, f.Rel())
+	}
+	// colors.conf was found already and tmp_generated.conf is blacklisted
+	if want := []string{"hypr/hyprland.conf", "hypr/colors.conf", "themes/nord.conf"}; !slices.Equal(rels, want) {
+		t.Errorf("got %q, want %q", rels, want)
+	}
+}
@@ -0,0 +1,135 @@
+package configfinder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// FindConfigFilesAll is FindConfigFilesDetailed for several programs at once, with up to
+// concurrency workers (1 when less). Each base location is listed once and only the directories
+// of the requested programs in it are walked, followed by their Flatpak and Snap sandboxes. The
+// files programs access as they run are only looked for when there are none in any location, as
+// strace traces each for a few seconds. Programs that can't be traced, e.g. because strace isn't
+// installed, get the files they have open if they are running, with a warning.
+//
+// Every program is a key of the result, with no files if none were found. Cancelling ctx stops
+// the search along with any strace still running.
+func (cf *ConfigFinder) FindConfigFilesAll(ctx context.Context, programs []string, concurrency int) (map[string][]FoundFile, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	wanted := map[string]bool{}
+	for _, p := range programs {
+		wanted[p] = true
+	}
+
+	// One listing per location gives the program directories worth walking
+	type walk struct {
+		program, base string
+		files         []FoundFile
+	}
+	var walks []*walk
+	for _, base := range cf.locations {
+		entries, err := os.ReadDir(base)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if wanted[e.Name()] {
+				walks = append(walks, &walk{program: e.Name(), base: base})
+			}
+		}
+	}
+	// Sandboxes only hold their own program's files
+	for _, p := range utils.DeduplicateStrings(programs) {
+		for _, base := range cf.sandboxLocations(p) {
+			walks = append(walks, &walk{program: p, base: base})
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, w := range walks {
+		g.Go(func() error {
+			paths, err := findConfigFiles(gctx, filepath.Join(w.base, w.program), cf.maxDepth)
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			if err != nil {
+				return nil // e.g. a file named like the program, not a directory
+			}
+			w.files = cf.describeAll(paths, w.base)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Walks are in location order, so are the files of each program
+	found := make(map[string][]FoundFile, len(wanted))
+	for p := range wanted {
+		found[p] = nil
+	}
+	for _, w := range walks {
+		found[w.program] = append(found[w.program], w.files...)
+	}
+
+	var missing []string
+	for _, p := range utils.DeduplicateStrings(programs) {
+		if len(found[p]) == 0 {
+			missing = append(missing, p)
+		}
+	}
+
+	traced := make([][]FoundFile, len(missing))
+	g, gctx = errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i, p := range missing {
+		g.Go(func() error {
+			paths, err := cf.accessedFiles(gctx, p)
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			var partial *PartialResult
+			if err != nil {
+				slog.Warn("failed to find the files a program accesses", "program", p, "err", err)
+				if !errors.As(err, &partial) {
+					return nil
+				}
+			}
+			traced[i] = cf.describeAll(paths, "")
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	for i, p := range missing {
+		found[p] = traced[i]
+	}
+	return found, nil
+}
+
+// describeAll describes the files at paths found below base, see describe. An empty base is
+// looked up with baseOf.
+func (cf *ConfigFinder) describeAll(paths []string, base string) []FoundFile {
+	var files []FoundFile
+	for _, p := range paths {
+		b := base
+		if b == "" {
+			b = cf.baseOf(p)
+		}
+		f := cf.newFoundFile(p, b)
+		if cf.describe(&f) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
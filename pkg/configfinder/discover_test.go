@@ -0,0 +1,104 @@
+package configfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files below root, each containing its own name.
+func writeTree(tb testing.TB, root string, files ...string) {
+	tb.Helper()
+	for _, f := range files {
+		path := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f+"\n"), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestFindConfigFilesAll(t *testing.T) {
+	home, dotfiles := t.TempDir(), t.TempDir()
+	configHome, dataHome := filepath.Join(home, ".config"), filepath.Join(home, ".local", "share")
+	writeTree(t, configHome, "waybar/config", "waybar/style.css", "kitty/kitty.conf", "kitty/settings.json", "unrelated/config")
+	writeTree(t, dataHome, "waybar/themes/config")
+	// hypr is managed by stow, the program directory itself is a symlink
+	writeTree(t, dotfiles, "hypr/hyprland.conf", "hypr/hyprpaper-config")
+	if err := os.Symlink(filepath.Join(dotfiles, "hypr"), filepath.Join(configHome, "hypr")); err != nil {
+		t.Fatal(err)
+	}
+
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{configHome, dataHome}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := cf.FindConfigFilesAll(context.Background(), []string{"waybar", "kitty", "hypr"}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rels := map[string][]string{}
+	for program, files := range found {
+		for _, f := range files {
+			if f.SHA256 == "" || f.Type == "" {
+				t.Errorf("%s: %s not described: %+v", program, f.Path, f)
+			}
+			rels[program] = append(rels[program], f.Rel())
+		}
+	}
+	want := map[string][]string{
+		"waybar": {"waybar/config", "waybar/themes/config"},
+		"kitty":  {"kitty/settings.json"},
+		"hypr":   {"hypr/hyprpaper-config"},
+	}
+	if fmt.Sprint(rels) != fmt.Sprint(want) {
+		t.Errorf("found %v, want %v", rels, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cf.FindConfigFilesAll(ctx, []string{"waybar"}, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled discovery: got %v, want context.Canceled", err)
+	}
+}
+
+// BenchmarkDiscovery compares searching the common locations of several programs one after
+// the other with FindConfigFilesAll.
+func BenchmarkDiscovery(b *testing.B) {
+	home := b.TempDir()
+	configHome := filepath.Join(home, ".config")
+	programs := []string{"hypr", "waybar", "kitty", "wofi", "hyprlock", "mako", "rofi", "swaync"}
+	for _, p := range programs {
+		for i := range 40 {
+			writeTree(b, configHome, fmt.Sprintf("%s/themes/%d/config.conf", p, i), fmt.Sprintf("%s/modules/%d/settings.json", p, i))
+		}
+	}
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{configHome}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b.Run("serial", func(b *testing.B) {
+		for b.Loop() {
+			for _, p := range programs {
+				for _, f := range cf.SearchCommonLocations(ctx, p) {
+					cf.describe(&f)
+				}
+			}
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := cf.FindConfigFilesAll(ctx, programs, 8); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package configfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+// DefaultMaxFileSize is the largest file FindConfigFilesDetailed reads unless
+// FinderOptions.MaxFileSize says otherwise, the biggest file a config may hold by default.
+const DefaultMaxFileSize = 8 << 20
+
+// FoundFile is a config file found below one of the finder's base locations. Only
+// FindConfigFilesDetailed fills in the fields past Base.
+type FoundFile struct {
+	Path string // path of the file
+	Base string // the base location it was found in, e.g. the XDG config home; empty if none
+
+	// Packaging is PackagingFlatpak or PackagingSnap when Base is in the sandbox of a Flatpak or
+	// Snap, so the file is installed back into the sandbox; empty for natively installed programs.
+	Packaging string
+
+	Size    int64
+	ModTime time.Time
+	Type    string // one of the hyprconfig FileType values, sniffed from the content
+	SHA256  string // hex encoded hash of the content
+
+	// Skipped says why the content was not read, e.g. because the file is too large. Type and
+	// SHA256 are empty then.
+	Skipped string
+}
+
+// Rel returns Path relative to Base, e.g. waybar/config, which is where the file belongs below
+// the same base location on another machine.
+func (f FoundFile) Rel() string {
+	if f.Base == "" {
+		return f.Path
+	}
+	rel, err := filepath.Rel(f.Base, f.Path)
+	if err != nil {
+		return f.Path
+	}
+	return rel
+}
+
+// FindConfigFilesDetailed is FindConfigFiles with the size, modification time, type and hash of
+// every file, read in a single pass. Directories and files that no longer exist are left out,
+// files over the size limit are reported with Skipped set. Like FindConfigFiles it returns a
+// *PartialResult along with the files when the program couldn't be traced.
+func (cf *ConfigFinder) FindConfigFilesDetailed(ctx context.Context, program string) ([]FoundFile, error) {
+	found, err := cf.locate(ctx, program)
+	var partial *PartialResult
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
+	}
+
+	files := make([]FoundFile, 0, len(found))
+	for _, f := range found {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if cf.describe(&f) {
+			files = append(files, f)
+		}
+	}
+	return files, err
+}
+
+// describe fills in the metadata of f and reports whether it is a regular file.
+func (cf *ConfigFinder) describe(f *FoundFile) bool {
+	info, err := os.Stat(f.Path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	f.Size, f.ModTime = info.Size(), info.ModTime()
+	if f.Size > cf.maxFileSize {
+		f.Skipped = fmt.Sprintf("file is %d bytes, more than the limit of %d", f.Size, cf.maxFileSize)
+		return true
+	}
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		f.Skipped = fmt.Sprintf("unreadable: %v", err)
+		return true
+	}
+	f.Size = int64(len(data))
+	f.Type = hyprconfig.DetectFileType(filepath.Base(f.Path), data)
+	f.SHA256 = hyprconfig.ComputeHash(data)
+	return true
+}
+
+// baseOf returns the deepest of the finder's locations containing path, the sandbox directory of
+// a Flatpak or Snap containing it, the home directory for dotfiles directly in it like
+// ~/.gtkrc-2.0, or "" otherwise.
+func (cf *ConfigFinder) baseOf(path string) string {
+	var base string
+	for _, location := range cf.locations {
+		rel, err := filepath.Rel(location, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && len(location) > len(base) {
+			base = location
+		}
+	}
+	if base == "" {
+		base, _ = cf.sandboxOf(path)
+	}
+	if base == "" && cf.HomeDir != "" && filepath.Dir(path) == filepath.Clean(cf.HomeDir) && strings.HasPrefix(filepath.Base(path), ".") {
+		base = cf.HomeDir
+	}
+	return base
+}
@@ -0,0 +1,82 @@
+package configfinder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+)
+
+// FindOpenConfigFiles returns the files below the finder's locations that the running process
+// pid has open, e.g. a waybar that is already up, filtered by the blacklist like strace results.
+// The open files are read from /proc/PID/fd, or from lsof where procfs isn't available.
+func (cf *ConfigFinder) FindOpenConfigFiles(pid string) ([]string, error) {
+	if _, err := strconv.Atoi(pid); err != nil {
+		return nil, fmt.Errorf("invalid pid %q", pid)
+	}
+	paths, err := procOpenFiles(pid)
+	if err != nil {
+		lsofPaths, lsofErr := lsofOpenFiles(pid)
+		if lsofErr != nil {
+			return nil, fmt.Errorf("failed to list open files of %s: %w (lsof: %v)", pid, err, lsofErr)
+		}
+		paths = lsofPaths
+	}
+
+	var files []string
+	for _, p := range paths {
+		if cf.isConfigPath(p) {
+			files = append(files, p)
+		}
+	}
+	return utils.DeduplicateStrings(files), nil
+}
+
+// procOpenFiles returns the paths of the files pid has open, from its /proc/PID/fd symlinks.
+// Sockets, pipes and deleted files are left out.
+func procOpenFiles(pid string) ([]string, error) {
+	dir := filepath.Join("/proc", pid, "fd")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		target, err := os.Readlink(filepath.Join(dir, e.Name()))
+		if err != nil || !filepath.IsAbs(target) || strings.HasSuffix(target, " (deleted)") {
+			continue // closed since the listing, or e.g. socket:[1234]
+		}
+		paths = append(paths, target)
+	}
+	return paths, nil
+}
+
+// lsofOpenFiles returns the paths of the files pid has open as reported by lsof.
+func lsofOpenFiles(pid string) ([]string, error) {
+	cmd := exec.Command("lsof", "-n", "-P", "-F", "n", "-p", pid)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil && out.Len() == 0 {
+		return nil, err
+	}
+	return parseLsofOutput(out.Bytes()), nil
+}
+
+// parseLsofOutput returns the absolute paths in `lsof -F n` output, whose name fields are the
+// lines starting with n.
+func parseLsofOutput(data []byte) []string {
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		name, ok := strings.CutPrefix(line, "n")
+		if !ok || !filepath.IsAbs(name) || strings.HasSuffix(name, " (deleted)") {
+			continue
+		}
+		paths = append(paths, name)
+	}
+	return paths
+}
@@ -0,0 +1,47 @@
+package configfinder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestFindOpenConfigFiles(t *testing.T) {
+	home := t.TempDir()
+	writeTree(t, home, "waybar/config", "waybar/tmp_state")
+	outside := filepath.Join(t.TempDir(), "config")
+	writeTree(t, filepath.Dir(outside), "config")
+	for _, p := range []string{filepath.Join(home, "waybar/config"), filepath.Join(home, "waybar/tmp_state"), outside} {
+		f, err := os.Open(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+	}
+
+	cf, err := NewConfigFinderWithOptions(FinderOptions{HomeDir: home, Locations: []string{home}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := cf.FindOpenConfigFiles(strconv.Itoa(os.Getpid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tmp_state is blacklisted, the other file is outside the locations
+	if want := []string{filepath.Join(home, "waybar/config")}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := cf.FindOpenConfigFiles("self"); err == nil {
+		t.Error("invalid pid: got nil error")
+	}
+}
+
+func TestParseLsofOutput(t *testing.T) {
+	out := "p48211\nfcwd\nn/home/u\nf3\nn/home/u/.config/waybar/config\nf4\nnsocket:[88121]\nf5\nn/home/u/.config/waybar/old.css (deleted)\nf6\nnpipe\n"
+	if got, want := parseLsofOutput([]byte(out)), []string{"/home/u", "/home/u/.config/waybar/config"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
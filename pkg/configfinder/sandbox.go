@@ -0,0 +1,98 @@
+package configfinder
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+)
+
+// Packaging formats of sandboxed programs, whose config lives in the sandbox's own XDG
+// directories instead of the user's.
+const (
+	PackagingFlatpak = "flatpak" // ~/.var/app/<app-id>/config
+	PackagingSnap    = "snap"    // ~/snap/<name>/<revision>/.config
+)
+
+// flatpakIDRe matches Flatpak app ids, reverse DNS names like org.wezfurlong.wezterm, so one from
+// the program metadata can't point outside ~/.var/app.
+var flatpakIDRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*(\.[A-Za-z_][A-Za-z0-9_-]*)+$`)
+
+// programLocations returns the base locations searched for program: the finder's locations
+// followed by those of its sandboxes, see sandboxLocations.
+func (cf *ConfigFinder) programLocations(program string) []string {
+	return append(slices.Clone(cf.locations), cf.sandboxLocations(program)...)
+}
+
+// sandboxLocations returns the config and data directories of the Flatpak and Snap sandboxes
+// program is installed in, the ones that exist below the home directory. Inside them the
+// program's files are laid out as usual, e.g. ~/.var/app/<app-id>/config/wezterm/wezterm.lua.
+func (cf *ConfigFinder) sandboxLocations(program string) []string {
+	var dirs []string
+	for _, id := range cf.flatpakIDs(program) {
+		app := filepath.Join(cf.HomeDir, ".var", "app", id)
+		dirs = append(dirs, filepath.Join(app, "config"), filepath.Join(app, "data"))
+	}
+	// current links to the revision in use
+	snap := filepath.Join(cf.HomeDir, "snap", program, "current")
+	dirs = append(dirs, filepath.Join(snap, ".config"), filepath.Join(snap, ".local", "share"))
+
+	var existing []string
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			existing = append(existing, dir)
+		}
+	}
+	return existing
+}
+
+// flatpakIDs returns the app ids program may be installed as with Flatpak: the one of its
+// AllowedPrograms entry, followed by those of the apps in ~/.var/app whose id ends in the
+// program's name, like org.wezfurlong.wezterm for wezterm.
+func (cf *ConfigFinder) flatpakIDs(program string) []string {
+	var ids []string
+	if id := cf.flatpakApps[program]; flatpakIDRe.MatchString(id) {
+		ids = append(ids, id)
+	}
+	entries, _ := os.ReadDir(filepath.Join(cf.HomeDir, ".var", "app"))
+	for _, e := range entries {
+		id := e.Name()
+		if flatpakIDRe.MatchString(id) && strings.EqualFold(id[strings.LastIndexByte(id, '.')+1:], program) {
+			ids = append(ids, id)
+		}
+	}
+	return utils.DeduplicateStrings(ids)
+}
+
+// sandboxOf returns the sandbox directory path is in or below, e.g. ~/.var/app/<app-id>/config,
+// and its packaging format, or "" when it is in no Flatpak or Snap sandbox directory. Any snap
+// revision is recognized, as programs see theirs rather than current.
+func (cf *ConfigFinder) sandboxOf(path string) (base, packaging string) {
+	if cf.HomeDir == "" {
+		return "", ""
+	}
+	rel, err := filepath.Rel(cf.HomeDir, path)
+	if err != nil {
+		return "", ""
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	switch {
+	case len(parts) >= 4 && parts[0] == ".var" && parts[1] == "app" && (parts[3] == "config" || parts[3] == "data"):
+		return filepath.Join(cf.HomeDir, ".var", "app", parts[2], parts[3]), PackagingFlatpak
+	case len(parts) >= 4 && parts[0] == "snap" && parts[3] == ".config":
+		return filepath.Join(cf.HomeDir, "snap", parts[1], parts[2], ".config"), PackagingSnap
+	case len(parts) >= 5 && parts[0] == "snap" && parts[3] == ".local" && parts[4] == "share":
+		return filepath.Join(cf.HomeDir, "snap", parts[1], parts[2], ".local", "share"), PackagingSnap
+	}
+	return "", ""
+}
+
+// newFoundFile returns the FoundFile of path found below base, noting the packaging format
+// when base is in a sandbox.
+func (cf *ConfigFinder) newFoundFile(path, base string) FoundFile {
+	_, packaging := cf.sandboxOf(base)
+	return FoundFile{Path: path, Base: base, Packaging: packaging}
+}
@@ -0,0 +1,77 @@
+package configfinder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+func TestSandboxLocations(t *testing.T) {
+	home := t.TempDir()
+	configHome := filepath.Join(home, ".config")
+	writeTree(t, configHome, "wezterm/colors-config.toml")
+	writeTree(t, filepath.Join(home, ".var/app/org.wezfurlong.wezterm"), "config/wezterm/config.lua", "data/wezterm/settings.json")
+	// Found by its id ending in the program's name, and through the metadata
+	writeTree(t, filepath.Join(home, ".var/app/io.github.Foot"), "config/foot/foot-config.ini")
+	writeTree(t, filepath.Join(home, ".var/app/net.kovidgoyal.kitty-nightly"), "config/kitty/kitty-config.conf")
+	writeTree(t, filepath.Join(home, ".var/app/org.evil"), "config/kitty/settings.json")
+	writeTree(t, filepath.Join(home, "snap/alacritty/x3"), ".config/alacritty/config.toml")
+	if err := os.Symlink("x3", filepath.Join(home, "snap/alacritty/current")); err != nil {
+		t.Fatal(err)
+	}
+
+	cf, err := NewConfigFinderWithOptions(FinderOptions{
+		HomeDir:   home,
+		Locations: []string{configHome},
+		Programs: append(hyprconfig.DefaultAllowedPrograms(),
+			hyprconfig.AllowedPrograms{ProgramName: "kitty", FlatpakID: "net.kovidgoyal.kitty-nightly"},
+			hyprconfig.AllowedPrograms{ProgramName: "foot", FlatpakID: "../../.."},
+		),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct{ rel, packaging string }
+	for program, want := range map[string][]result{
+		"wezterm": {
+			{"wezterm/colors-config.toml", ""},
+			{"wezterm/config.lua", PackagingFlatpak},
+			{"wezterm/settings.json", PackagingFlatpak},
+		},
+		"foot":      {{"foot/foot-config.ini", PackagingFlatpak}},
+		"kitty":     {{"kitty/kitty-config.conf", PackagingFlatpak}},
+		"alacritty": {{"alacritty/config.toml", PackagingSnap}},
+	} {
+		var got []result
+		for _, f := range cf.SearchCommonLocations(context.Background(), program) {
+			got = append(got, result{f.Rel(), f.Packaging})
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", program, got, want)
+		}
+	}
+
+	found, err := cf.FindConfigFilesAll(context.Background(), []string{"alacritty", "kitty"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for program, files := range found {
+		if len(files) != 1 || files[0].Packaging == "" || files[0].SHA256 == "" {
+			t.Errorf("%s: got %+v", program, files)
+		}
+	}
+
+	// Programs see their snap revision rather than current
+	traced := filepath.Join(home, "snap/alacritty/x3/.config/alacritty/config.toml")
+	if f := cf.newFoundFile(traced, cf.baseOf(traced)); f.Rel() != "alacritty/config.toml" || f.Packaging != PackagingSnap {
+		t.Errorf("traced snap file: got %+v", f)
+	}
+	if !cf.isConfigPath(traced) || cf.isConfigPath(filepath.Join(home, "snap/alacritty/x3/notes.txt")) {
+		t.Error("isConfigPath doesn't follow the snap's XDG directories")
+	}
+}
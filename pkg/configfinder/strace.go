@@ -0,0 +1,205 @@
+package configfinder
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+)
+
+// straceFileCalls are the syscalls of a `strace -e trace=file` log whose path argument the parser
+// reads, by whether a directory file descriptor comes before the path.
+var straceFileCalls = map[string]bool{
+	"open": false, "creat": false, "access": false, "stat": false, "lstat": false,
+	"stat64": false, "lstat64": false, "readlink": false, "chdir": false,
+	"openat": true, "openat2": true, "faccessat": true, "faccessat2": true,
+	"newfstatat": true, "fstatat64": true, "statx": true, "readlinkat": true,
+}
+
+// straceCall is a syscall in a strace log.
+type straceCall struct {
+	pid    string
+	name   string
+	args   string // everything after the opening parenthesis
+	result int
+}
+
+// parseStraceLog returns the paths of the files a `strace -f -y -e trace=file` log shows being
+// opened, stat'ed, checked or read as a link, in order of first access and without duplicates,
+// keeping only those include accepts. Failed calls are left out, e.g. a program probing for a
+// config it doesn't have.
+//
+// Relative paths are resolved against the directory file descriptor strace -y annotates, or the
+// process's working directory: cwd until it changes it with chdir.
+func parseStraceLog(data []byte, cwd string, include func(string) bool) []string {
+	cwds := map[string]string{}
+	pending := map[string]straceCall{}
+	var filePaths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		call, ok := parseStraceLine(line, pending)
+		if !ok || call.result < 0 {
+			continue
+		}
+		at, ok := straceFileCalls[call.name]
+		if !ok {
+			continue
+		}
+		dir, ok := cwds[call.pid]
+		if !ok {
+			dir = cwd
+		}
+		path, ok := straceCallPath(call.args, at, dir)
+		if !ok {
+			continue
+		}
+		if call.name == "chdir" {
+			cwds[call.pid] = path
+			continue
+		}
+		if include(path) {
+			filePaths = append(filePaths, path)
+		}
+	}
+	return utils.DeduplicateStrings(filePaths)
+}
+
+// parseStraceLine parses a finished syscall in a line of a strace log. The pid leads the line
+// when strace writes to a file, or is in a [pid N] prefix when it writes to a terminal. A call
+// interrupted by another process is kept in pending until the line it resumes on.
+func parseStraceLine(line string, pending map[string]straceCall) (straceCall, bool) {
+	var pid string
+	rest := strings.TrimSpace(line)
+	if after, ok := strings.CutPrefix(rest, "[pid "); ok {
+		pid, rest, _ = strings.Cut(after, "]")
+	} else if first, after, ok := strings.Cut(rest, " "); ok && isDigits(first) {
+		pid, rest = first, after
+	}
+	pid, rest = strings.TrimSpace(pid), strings.TrimSpace(rest)
+
+	// 61203 <... openat resumed>) = 8
+	if after, ok := strings.CutPrefix(rest, "<... "); ok {
+		name, after, _ := strings.Cut(after, " resumed>")
+		call, ok := pending[pid]
+		if !ok || call.name != name {
+			return straceCall{}, false
+		}
+		delete(pending, pid)
+		i := strings.LastIndex(after, ") = ")
+		if i < 0 {
+			return straceCall{}, false
+		}
+		call.result, ok = straceResult(after[i+len(") = "):])
+		return call, ok
+	}
+
+	name, args, ok := strings.Cut(rest, "(")
+	if !ok || !isSyscallName(name) {
+		return straceCall{}, false // e.g. --- SIGCHLD ... --- or +++ exited with 0 +++
+	}
+	if args, ok := strings.CutSuffix(args, " <unfinished ...>"); ok {
+		pending[pid] = straceCall{pid: pid, name: name, args: args}
+		return straceCall{}, false
+	}
+	i := strings.LastIndex(args, ") = ")
+	if i < 0 {
+		return straceCall{}, false
+	}
+	result, ok := straceResult(args[i+len(") = "):])
+	return straceCall{pid: pid, name: name, args: args[:i], result: result}, ok
+}
+
+// straceResult parses a syscall's return value, e.g. 3 in `3</etc/ld.so.cache>` or -1 in
+// `-1 ENOENT (No such file or directory)`.
+func straceResult(s string) (int, bool) {
+	s, _, _ = strings.Cut(s, " ")
+	s, _, _ = strings.Cut(s, "<")
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// straceCallPath returns the absolute path in the arguments of a syscall, which for at calls
+// follows the directory file descriptor. Relative paths are joined to the descriptor's directory
+// when strace -y annotated it, like 5</home/u/.config>, or to cwd for AT_FDCWD and calls without
+// a descriptor. It fails when the path is empty or can't be resolved.
+func straceCallPath(args string, at bool, cwd string) (string, bool) {
+	dir := cwd
+	if at {
+		comma := strings.Index(args, ", ")
+		if comma < 0 {
+			return "", false
+		}
+		fd := args[:comma]
+		if lt := strings.IndexByte(args, '<'); lt >= 0 && lt < comma {
+			// The annotated directory may itself contain ", "
+			end := strings.Index(args, ">, ")
+			if end < 0 {
+				return "", false
+			}
+			fd, comma = args[:end+1], end+1
+		}
+		args = args[comma+len(", "):]
+
+		switch name, annotated, ok := strings.Cut(fd, "<"); {
+		case ok:
+			dir = strings.TrimSuffix(annotated, ">")
+		case name != "AT_FDCWD":
+			dir = "" // a descriptor the log doesn't say the directory of
+		}
+	}
+
+	path, ok := straceString(args)
+	if !ok || path == "" {
+		return "", false
+	}
+	if !filepath.IsAbs(path) {
+		if !filepath.IsAbs(dir) {
+			return "", false
+		}
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path), true
+}
+
+// straceString unquotes the C string literal s starts with.
+func straceString(s string) (string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", false
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := strconv.Unquote(s[:i+1])
+			return v, err == nil
+		}
+	}
+	return "", false
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isSyscallName reports whether s looks like a syscall name, e.g. newfstatat.
+func isSyscallName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
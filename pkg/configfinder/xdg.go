@@ -0,0 +1,45 @@
+package configfinder
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
+)
+
+// xdgDir returns the directory in the XDG variable env, or fallback when it is unset or not
+// absolute, which the spec says to ignore.
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return fallback
+}
+
+// xdgDirs returns the absolute directories in the colon separated XDG variable env, or fallback
+// when there are none.
+func xdgDirs(env string, fallback ...string) []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(env)) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return fallback
+	}
+	return dirs
+}
+
+// xdgLocations returns the base directories program configs live in, most specific first: the
+// XDG config and data homes of homeDir, then the system XDG config and data directories, then
+// /etc for programs that don't follow the spec.
+func xdgLocations(homeDir string) []string {
+	locations := []string{
+		xdgDir("XDG_CONFIG_HOME", filepath.Join(homeDir, ".config")),
+		xdgDir("XDG_DATA_HOME", filepath.Join(homeDir, ".local", "share")),
+	}
+	locations = append(locations, xdgDirs("XDG_CONFIG_DIRS", "/etc/xdg")...)
+	locations = append(locations, xdgDirs("XDG_DATA_DIRS", "/usr/local/share", "/usr/share")...)
+	return utils.DeduplicateStrings(append(locations, "/etc"))
+}
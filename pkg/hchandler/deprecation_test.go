@@ -0,0 +1,46 @@
+package hchandler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/mserve"
+)
+
+func TestDeprecateConfigEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+	old := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	successor := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice v2", Description: "second try"}))
+	path := "/config/" + old.ID + "/deprecate"
+
+	if status, _ := do(t, srv, http.MethodPost, path, "bob", DeprecateConfigRequest{Message: "mine now"}); status != http.StatusForbidden {
+		t.Errorf("deprecate as non-owner: got %d, want 403", status)
+	}
+	missing := "missing"
+	if status, _ := do(t, srv, http.MethodPost, path, "alice", DeprecateConfigRequest{SuccessorID: &missing}); status != http.StatusUnprocessableEntity {
+		t.Errorf("missing successor: got %d, want 422", status)
+	}
+	if status, body := do(t, srv, http.MethodPost, path, "alice", DeprecateConfigRequest{SuccessorID: &successor.ID, Message: "use v2"}); status != http.StatusOK {
+		t.Fatalf("deprecate: %d %s", status, body)
+	}
+
+	// Applying still works and says where to go instead
+	status, body := do(t, srv, http.MethodPost, "/config/"+old.ID+"/apply", "bob", nil)
+	if resp := decode[ApplyConfigResponse](t, body); status != http.StatusOK || resp.Status != "applied" ||
+		resp.Deprecation == nil || resp.Deprecation.SuccessorID != successor.ID || resp.Deprecation.Message != "use v2" {
+		t.Errorf("apply deprecated config: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodPost, "/config/"+successor.ID+"/apply", "bob", nil)
+	if resp := decode[ApplyConfigResponse](t, body); status != http.StatusOK || resp.Deprecation != nil {
+		t.Errorf("apply successor: %d %s", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/config/search?deprecated=false", "", nil)
+	if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != 1 || page.Items[0].ID != successor.ID {
+		t.Errorf("search without deprecated configs: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?deprecated=soon", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid deprecated: got %d, want 400", status)
+	}
+}
@@ -1,23 +1,238 @@
 package hchandler
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Seann-Moser/credentials/session"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/importer"
 	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// UpdateConfigRequest is the body of the update config endpoint. Absent fields are left alone,
+// while fields set to an empty value are cleared.
+type UpdateConfigRequest struct {
+	Title              *string   `json:"title,omitempty"`
+	Description        *string   `json:"description,omitempty"`
+	Readme             *string   `json:"readme,omitempty"`               // markdown, raw HTML and script URLs are stripped
+	License            *string   `json:"license,omitempty"`              // an SPDX identifier or custom, empty removes it
+	LicenseText        *string   `json:"license_text,omitempty"`         // required with the custom license
+	MinHyprlandVersion *string   `json:"min_hyprland_version,omitempty"` // e.g. 0.41.2, empty removes it
+	Private            *bool     `json:"private,omitempty"`
+	Tags               *[]string `json:"tags,omitempty"`
+	GalleryPictures    *[]string `json:"gallery_pictures,omitempty"`
+
+	Variables *[]hyprconfig.TemplateVariable `json:"variables,omitempty"` // replaces every variable
+
+	// Rejected, program configs are changed through the program endpoints.
+	ProgramConfigs json.RawMessage `json:"program_configs,omitempty"`
+
+	VersionBump      string `json:"version_bump,omitempty"`      // patch (default), minor or major
+	ChangelogMessage string `json:"changelog_message,omitempty"` // optional, recorded in the config changelog
+}
+
+// CreateAPIKeyRequest is the body of the create API key endpoint.
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`                    // read, write and/or apply
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 never expires
+}
+
+// SetUserQuotaRequest is the body of the set user quota endpoint.
+type SetUserQuotaRequest struct {
+	QuotaBytes int64 `json:"quota_bytes"` // 0 restores the server default
+}
+
+// SetUserConfigLimitRequest is the body of the set user config limit endpoint.
+type SetUserConfigLimitRequest struct {
+	Limit int64 `json:"limit"` // 0 restores the server default
+}
+
+// CreateShareLinkRequest is the body of the create share link endpoint.
+type CreateShareLinkRequest struct {
+	ExpiresInHours int `json:"expires_in_hours,omitempty"` // 0 uses the default of 7 days, at most 30 days
+}
+
+// DeprecateConfigRequest is the body of the deprecate config endpoint.
+type DeprecateConfigRequest struct {
+	SuccessorID *string `json:"successor_id,omitempty"` // a public config replacing this one
+	Message     string  `json:"message,omitempty"`
+}
+
+// ApplyConfigResponse is the body of the apply config endpoint. Deprecation is set when the
+// applied config is deprecated, so clients can point the user at its successor.
+type ApplyConfigResponse struct {
+	Status      string                        `json:"status"`
+	Deprecation *hyprconfig.ConfigDeprecation `json:"deprecation,omitempty"`
+}
+
+// DuplicateConfigResponse is the 409 body of the create endpoints when the caller already owns
+// a config with the same programs and files.
+type DuplicateConfigResponse struct {
+	mserve.ErrorR
+	ExistingConfigID string `json:"existing_config_id"`
+}
+
+// AppliedConfigMissingResponse is the 404 body of the applied config endpoint when the config the
+// caller applied has since been deleted.
+type AppliedConfigMissingResponse struct {
+	mserve.ErrorR
+	StaleConfigID string `json:"stale_config_id"`
+}
+
+// ConfigLimitResponse is the 403 body of the create endpoint when the caller already owns as many
+// configs as they are allowed.
+type ConfigLimitResponse struct {
+	mserve.ErrorR
+	Count int64 `json:"count"`
+	Limit int64 `json:"limit"`
+}
+
+// ValidationErrorResponse is the 422 body of endpoints that validate a config. Errors lists
+// every problem found, and is empty when the input was rejected for another reason.
+type ValidationErrorResponse struct {
+	mserve.ErrorR
+	Errors []hyprconfig.FieldError `json:"errors,omitempty"`
+}
+
+// UserConfigsResponse is the body of the list user configs endpoint.
+type UserConfigsResponse struct {
+	Configs mserve.Page[hyprconfig.HyprConfig] `json:"configs"`
+	Stats   *hyprconfig.AuthorStats            `json:"stats"`
+}
+
+// MarkNotificationsReadRequest is the body of the mark notifications read endpoint.
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids,omitempty"` // empty marks every notification read
+}
+
+// MarkNotificationsReadResponse is the body returned by the mark notifications read endpoint.
+type MarkNotificationsReadResponse struct {
+	Marked int64 `json:"marked"` // notifications that were unread
+}
+
+// UnreadNotificationsResponse is the body of the unread notification count endpoint.
+type UnreadNotificationsResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// ReadmeResponse is the body of the get config readme endpoint.
+type ReadmeResponse struct {
+	Format  string `json:"format"`  // markdown or html
+	Content string `json:"content"` // empty when the config has no readme
+}
+
+// StatusResponse is the body of endpoints that change a config, with the keybind conflicts and
+// lint findings the config has after the change.
+type StatusResponse struct {
+	Status   string   `json:"status"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// LintResponse is the body of the config lint endpoint.
+type LintResponse struct {
+	Findings []hyprconfig.LintFinding `json:"findings"`
+}
+
+// AddAllowedProgramRequest is the body of the add allowed program endpoint.
+type AddAllowedProgramRequest struct {
+	ProgramName string `json:"program_name"`
+}
+
+// updates returns the $set style updates for the fields that differ from existing.
+func (req *UpdateConfigRequest) updates(existing *hyprconfig.HyprConfig) bson.M {
+	updates := bson.M{}
+	if req.Title != nil && *req.Title != existing.Title {
+		updates["title"] = *req.Title
+	}
+	if req.Description != nil && *req.Description != existing.Description {
+		updates["description"] = *req.Description
+	}
+	if req.Readme != nil && *req.Readme != existing.Readme {
+		updates["readme"] = *req.Readme
+	}
+	if req.License != nil && *req.License != existing.License {
+		updates["license"] = *req.License
+	}
+	if req.LicenseText != nil && *req.LicenseText != existing.LicenseText {
+		updates["license_text"] = *req.LicenseText
+	}
+	if req.MinHyprlandVersion != nil && *req.MinHyprlandVersion != existing.MinHyprlandVersion {
+		updates["min_hyprland_version"] = *req.MinHyprlandVersion
+	}
+	if req.Private != nil && *req.Private != existing.Private {
+		updates["private"] = *req.Private
+	}
+	if req.Tags != nil && !hyprconfig.StringSlicesEqual(*req.Tags, existing.Tags) {
+		updates["tags"] = nonNil(*req.Tags)
+	}
+	if req.GalleryPictures != nil && !slices.Equal(*req.GalleryPictures, existing.GalleryPictures) {
+		updates["gallery_pictures"] = nonNil(*req.GalleryPictures)
+	}
+	if req.Variables != nil && !slices.Equal(*req.Variables, existing.Variables) {
+		updates["variables"] = nonNil(*req.Variables)
+	}
+	return updates
+}
+
+// nonNil turns a nil slice into an empty one, so clearing a field stores an empty list.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// sseHeartbeatInterval is how often an idle event stream sends a comment line.
+var sseHeartbeatInterval = 15 * time.Second
+
+// DefaultMaxPageLimit is the largest page size a list endpoint serves unless changed with
+// SetMaxPageLimit.
+const DefaultMaxPageLimit = 100
+
 type Handler struct {
 	configManager hyprconfig.ConfigManager
+	gitImporter   *importer.GitImporter
+	maxPageLimit  int
+	roles         RoleManager                       // nil disables the assign role endpoint
+	users         UserFinder                        // looks up the users roles are assigned to
+	maintenance   *hyprconfig.ReadOnlyConfigManager // nil disables the read-only toggle
 }
 
 func NewHandler(configManager hyprconfig.ConfigManager) (*Handler, error) {
 	return &Handler{
 		configManager: configManager,
+		gitImporter:   importer.NewGitImporter(configManager),
+		maxPageLimit:  DefaultMaxPageLimit,
 	}, nil
 }
 
+// SetMaxPageLimit changes the largest page size served by the list endpoints. Larger limits
+// requested by clients are lowered to n.
+func (h *Handler) SetMaxPageLimit(n int) {
+	if n > 0 {
+		h.maxPageLimit = n
+	}
+}
+
 func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 	endpoints := []*mserve.Endpoint{
 		{
@@ -27,6 +242,9 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Methods: []string{http.MethodPost},
 			Request: mserve.Request{
 				Body: hyprconfig.HyprConfig{},
+				Params: map[string]mserve.ROption{
+					"force": {Required: false, Type: "boolean", Description: "create the config even if the caller already owns one with the same content"},
+				},
 			},
 			Responses: []mserve.Response{
 				{
@@ -39,6 +257,31 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 					Message: "Invalid request body",
 					Body:    mserve.ErrorResponse{},
 				},
+				{
+					Status:  http.StatusRequestEntityTooLarge,
+					Message: "Request body too large, or storage quota exceeded",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnauthorized,
+					Message: "Not signed in",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusForbidden,
+					Message: "The caller owns as many configs as they are allowed",
+					Body:    ConfigLimitResponse{},
+				},
+				{
+					Status:  http.StatusConflict,
+					Message: "The caller already owns a config with the same content, retry with force=true to create it anyway",
+					Body:    DuplicateConfigResponse{},
+				},
+				{
+					Status:  http.StatusUnprocessableEntity,
+					Message: "Config failed validation",
+					Body:    ValidationErrorResponse{},
+				},
 				{
 					Status:  http.StatusInternalServerError,
 					Message: "Failed to create config",
@@ -53,7 +296,35 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Methods: []string{"GET", "POST"},
 			Request: mserve.Request{
 				Params: map[string]mserve.ROption{
-					"q": {Required: false},
+					"page":             {Required: false, Type: "integer", Default: "1"},
+					"limit":            {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content":  {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+					"q":                {Required: false, Description: "text search on title, description and tags"},
+					"tags":             {Required: false, Description: "comma separated, configs must have every tag"},
+					"program":          {Required: false, Description: "configs containing this program"},
+					"owner_id":         {Required: false},
+					"private":          {Required: false, Type: "boolean"},
+					"verified":         {Required: false, Type: "boolean", Description: "configs an admin reviewed and verified since their last edit"},
+					"deprecated":       {Required: false, Type: "boolean", Description: "false leaves out deprecated configs, which otherwise rank last"},
+					"platform":         {Required: false, Description: "configs whose required programs support this platform"},
+					"monitors":         {Required: false, Type: "integer", Description: "configs laid out for this many monitors, or for any number"},
+					"resolution":       {Required: false, Description: "configs with a monitor at this resolution, e.g. 2560x1440"},
+					"theme":            {Required: false, Enum: []string{hyprconfig.ThemeDark, hyprconfig.ThemeLight}},
+					"palette":          {Required: false, Description: "comma separated hex colors, # optional, configs must have a color close to each"},
+					"color_tolerance":  {Required: false, Type: "integer", Default: "40", Description: "how far each channel of a palette color may be from a searched one"},
+					"license":          {Required: false, Description: "an SPDX identifier, custom, or unspecified for configs without a license"},
+					"hyprland_version": {Required: false, Description: "configs working on this Hyprland version, e.g. 0.41.2: their minimum is at most it or unset"},
+					"updated_from":     {Required: false, Description: "unix or RFC 3339 timestamp"},
+					"updated_to":       {Required: false, Description: "unix or RFC 3339 timestamp"},
+					"sort": {
+						Required: false,
+						Default:  hyprconfig.SearchSortUpdated,
+						Enum: []string{
+							hyprconfig.SearchSortUpdated, hyprconfig.SearchSortCreated,
+							hyprconfig.SearchSortLikes, hyprconfig.SearchSortTitle,
+							hyprconfig.SearchSortVerified,
+						},
+					},
 				},
 				Body: hyprconfig.ConfigSearchFilters{},
 			},
@@ -65,7 +336,12 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 				},
 				{
 					Status:  http.StatusBadRequest,
-					Message: "Invalid request body",
+					Message: "Invalid request body, query parameters, page or limit",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnprocessableEntity,
+					Message: "Invalid platform or sort",
 					Body:    mserve.ErrorResponse{},
 				},
 				{
@@ -83,20 +359,51 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Request: mserve.Request{
 				Body: hyprconfig.HyprProgramConfig{},
 				Params: map[string]mserve.ROption{
-					"parent_id": {Required: false},
+					"changelog_message": {Required: false, Description: "recorded in the config changelog"},
+					"parent_id":         {Required: false},
 				},
 			},
 			Responses: []mserve.Response{
 				{
 					Status:  http.StatusOK,
-					Message: "Program added successfully",
-					Body:    map[string]string{},
+					Message: "Program added successfully, with warnings for keybind conflicts and lint findings",
+					Body:    StatusResponse{},
 				},
 				{
 					Status:  http.StatusBadRequest,
 					Message: "Invalid request body or parameters",
 					Body:    mserve.ErrorResponse{},
 				},
+				{
+					Status:  http.StatusRequestEntityTooLarge,
+					Message: "Request body too large, or storage quota exceeded",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnauthorized,
+					Message: "Not signed in",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusForbidden,
+					Message: "Config is private or not owned by the caller",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusNotFound,
+					Message: "Config or parent program config not found",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnprocessableEntity,
+					Message: "Program config failed validation",
+					Body:    ValidationErrorResponse{},
+				},
+				{
+					Status:  http.StatusConflict,
+					Message: "Config kept changing concurrently, retry the request",
+					Body:    mserve.ErrorResponse{},
+				},
 				{
 					Status:  http.StatusInternalServerError,
 					Message: "Failed to add program config",
@@ -111,7 +418,8 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Methods: []string{http.MethodDelete},
 			Request: mserve.Request{
 				Params: map[string]mserve.ROption{
-					"prog_id": {Required: true},
+					"changelog_message": {Required: false, Description: "recorded in the config changelog"},
+					"prog_id":           {Required: true},
 				},
 			},
 			Responses: []mserve.Response{
@@ -125,6 +433,26 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 					Message: "Missing prog_id",
 					Body:    mserve.ErrorResponse{},
 				},
+				{
+					Status:  http.StatusUnauthorized,
+					Message: "Not signed in",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusForbidden,
+					Message: "Config is private or not owned by the caller",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusNotFound,
+					Message: "Config or program config not found",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusConflict,
+					Message: "Config kept changing concurrently, retry the request",
+					Body:    mserve.ErrorResponse{},
+				},
 				{
 					Status:  http.StatusInternalServerError,
 					Message: "Failed to remove program",
@@ -140,20 +468,51 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Request: mserve.Request{
 				Body: hyprconfig.HyprProgramConfig{},
 				Params: map[string]mserve.ROption{
-					"prog_id": {Required: true},
+					"changelog_message": {Required: false, Description: "recorded in the config changelog"},
+					"prog_id":           {Required: true},
 				},
 			},
 			Responses: []mserve.Response{
 				{
 					Status:  http.StatusOK,
-					Message: "Program updated successfully",
-					Body:    map[string]string{},
+					Message: "Program updated successfully, with warnings for keybind conflicts and lint findings",
+					Body:    StatusResponse{},
 				},
 				{
 					Status:  http.StatusBadRequest,
 					Message: "Invalid request body or missing prog_id",
 					Body:    mserve.ErrorResponse{},
 				},
+				{
+					Status:  http.StatusRequestEntityTooLarge,
+					Message: "Request body too large, or storage quota exceeded",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnauthorized,
+					Message: "Not signed in",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusForbidden,
+					Message: "Config is private or not owned by the caller",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusNotFound,
+					Message: "Config or program config not found",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnprocessableEntity,
+					Message: "Program config failed validation",
+					Body:    ValidationErrorResponse{},
+				},
+				{
+					Status:  http.StatusConflict,
+					Message: "Config kept changing concurrently, retry the request",
+					Body:    mserve.ErrorResponse{},
+				},
 				{
 					Status:  http.StatusInternalServerError,
 					Message: "Failed to update program config",
@@ -168,8 +527,9 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Methods: []string{http.MethodPut},
 			Request: mserve.Request{
 				Params: map[string]mserve.ROption{
-					"prog_id":       {Required: true},
-					"new_parent_id": {Required: false},
+					"changelog_message": {Required: false, Description: "recorded in the config changelog"},
+					"prog_id":           {Required: true},
+					"new_parent_id":     {Required: false},
 				},
 			},
 			Responses: []mserve.Response{
@@ -183,6 +543,26 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 					Message: "Missing prog_id",
 					Body:    mserve.ErrorResponse{},
 				},
+				{
+					Status:  http.StatusUnauthorized,
+					Message: "Not signed in",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusForbidden,
+					Message: "Config is private or not owned by the caller",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusNotFound,
+					Message: "Config, program config or new parent not found",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusConflict,
+					Message: "Config kept changing concurrently, retry the request",
+					Body:    mserve.ErrorResponse{},
+				},
 				{
 					Status:  http.StatusInternalServerError,
 					Message: "Failed to move program",
@@ -195,12 +575,29 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 			Path:    "/config/favorites",
 			Handler: h.ListFavorites,
 			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":            {Required: false, Type: "integer", Default: "1"},
+					"limit":           {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content": {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+				},
+			},
 			Responses: []mserve.Response{
 				{
 					Status:  http.StatusOK,
 					Message: "Favorites listed successfully",
 					Body:    mserve.Page[hyprconfig.HyprConfig]{},
 				},
+				{
+					Status:  http.StatusBadRequest,
+					Message: "Invalid page or limit",
+					Body:    mserve.ErrorResponse{},
+				},
+				{
+					Status:  http.StatusUnauthorized,
+					Message: "Not signed in",
+					Body:    mserve.ErrorResponse{},
+				},
 				{
 					Status:  http.StatusInternalServerError,
 					Message: "Failed to list favorites",
@@ -240,372 +637,3074 @@ func (h *Handler) GetEndpoints() []*mserve.Endpoint {
 	// --- Missing endpoints ---
 	endpoints = append(endpoints,
 		&mserve.Endpoint{
-			Name:    "Get Config",
-			Path:    "/config/{config_id}",
-			Handler: h.GetConfig,
+			Name:    "Favorite Config",
+			Path:    "/config/{config_id}/favorite",
+			Handler: h.FavoriteConfig,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config favorited", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to favorite config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Unfavorite Config",
+			Path:    "/config/{config_id}/favorite",
+			Handler: h.UnfavoriteConfig,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config unfavorited", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to unfavorite config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Config Favoriters",
+			Path:    "/config/{config_id}/favorites",
+			Handler: h.ListConfigFavoriters,
 			Methods: []string{http.MethodGet},
 			Request: mserve.Request{
 				Params: map[string]mserve.ROption{
 					"config_id": {Required: true},
+					"page":      {Required: false, Type: "integer", Default: "1"},
+					"limit":     {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
 				},
 			},
 			Responses: []mserve.Response{
-				{Status: http.StatusOK, Message: "Config retrieved", Body: hyprconfig.HyprConfig{}},
+				{Status: http.StatusOK, Message: "Users who favorited the config, newest first", Body: mserve.Page[hyprconfig.ConfigFavoriter]{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Caller is neither the config owner nor an admin", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list favoriters", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Count Config Favorites",
+			Path:    "/config/{config_id}/favorites/count",
+			Handler: h.CountConfigFavorites,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Live favorite count", Body: map[string]int64{}},
 				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
-				{Status: http.StatusInternalServerError, Message: "Failed to get config", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to count favorites", Body: mserve.ErrorResponse{}},
 			},
 		},
 		&mserve.Endpoint{
-			Name:    "Update Config",
-			Path:    "/config/{config_id}",
-			Handler: h.UpdateConfig,
-			Methods: []string{http.MethodPut},
+			Name:    "List Related Configs",
+			Path:    "/config/{config_id}/related",
+			Handler: h.ListRelatedConfigs,
+			Methods: []string{http.MethodGet},
 			Request: mserve.Request{
-				Body: hyprconfig.HyprConfig{},
 				Params: map[string]mserve.ROption{
 					"config_id": {Required: true},
+					"limit":     {Required: false, Type: "integer", Default: "10", Description: "at most 50"},
 				},
 			},
 			Responses: []mserve.Response{
-				{Status: http.StatusOK, Message: "Config updated", Body: map[string]string{}},
-				{Status: http.StatusBadRequest, Message: "Invalid request or missing config_id", Body: mserve.ErrorResponse{}},
-				{Status: http.StatusInternalServerError, Message: "Failed to update config", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusOK, Message: "Public configs sharing tags or programs with the config, best match first", Body: []hyprconfig.HyprConfig{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list related configs", Body: mserve.ErrorResponse{}},
 			},
 		},
 		&mserve.Endpoint{
-			Name:    "Delete Config",
-			Path:    "/config/{config_id}",
-			Handler: h.DeleteConfig,
-			Methods: []string{http.MethodDelete},
+			Name:    "Apply Config",
+			Path:    "/config/{config_id}/apply",
+			Handler: h.ApplyConfig,
+			Methods: []string{http.MethodPost},
 			Request: mserve.Request{
 				Params: map[string]mserve.ROption{
 					"config_id": {Required: true},
 				},
 			},
 			Responses: []mserve.Response{
-				{Status: http.StatusOK, Message: "Config deleted", Body: map[string]string{}},
+				{Status: http.StatusOK, Message: "Config applied, with its deprecation if it is deprecated", Body: ApplyConfigResponse{}},
 				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
-				{Status: http.StatusInternalServerError, Message: "Failed to delete config", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to apply config", Body: mserve.ErrorResponse{}},
 			},
 		},
 		&mserve.Endpoint{
-			Name:    "List All Configs",
-			Path:    "/configs",
-			Handler: h.ListConfigs,
+			Name:    "Get Applied Config",
+			Path:    "/config/applied",
+			Handler: h.GetAppliedConfig,
 			Methods: []string{http.MethodGet},
 			Request: mserve.Request{},
 			Responses: []mserve.Response{
-				{Status: http.StatusOK, Message: "Configs listed", Body: mserve.Page[hyprconfig.HyprConfig]{}},
+				{Status: http.StatusOK, Message: "Applied config retrieved", Body: hyprconfig.HyprConfig{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Applied config has been made private", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "No config applied", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "The applied config was deleted, stale_config_id is its ID", Body: AppliedConfigMissingResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get applied config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List My Configs",
+			Path:    "/configs/mine",
+			Handler: h.ListMyConfigs,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":            {Required: false, Type: "integer", Default: "1"},
+					"limit":           {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content": {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Configs owned by the caller", Body: mserve.Page[hyprconfig.HyprConfig]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
 				{Status: http.StatusInternalServerError, Message: "Failed to list configs", Body: mserve.ErrorResponse{}},
 			},
 		},
-	)
-	return endpoints
-}
-
-func (h *Handler) NewConfig(w http.ResponseWriter, r *http.Request) {
-	hc, err := mserve.ReadBody[hyprconfig.HyprConfig](r)
-	if err != nil {
-		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	created, err := h.configManager.CreateConfig(r.Context(), hc)
-	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	mserve.WriteBody(w, r, created)
-}
-
-func (h *Handler) SearchConfigs(w http.ResponseWriter, r *http.Request) {
-	currentPage, limit := mserve.QueryParams(r, 10)
-
-	filter, err := mserve.ReadBody[hyprconfig.ConfigSearchFilters](r)
-	if err != nil {
-		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
-		return
-	}
-
-	page, err := h.configManager.ListConfigsWithFilters(r.Context(), currentPage, limit, *filter, nil)
-	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	mserve.WriteBody(w, r, page)
-}
-
-func (h *Handler) ListMyConfigs(w http.ResponseWriter, r *http.Request) {
-	page, limit := mserve.QueryParams(r, 10)
-
-	result, err := h.configManager.ListMyConfigs(r.Context(), page, limit, nil)
-	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	mserve.WriteBody(w, r, result)
+		&mserve.Endpoint{
+			Name:    "Watch Applied Config",
+			Path:    "/config/applied/watch",
+			Handler: h.WatchAppliedConfig,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Server-sent event stream of applied config changes", Body: hyprconfig.AppliedConfigEvent{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to watch applied config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Config",
+			Path:    "/config/{config_id}",
+			Handler: h.GetConfig,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id":    {Required: true},
+					"raw_encoding": {Required: false, Type: "boolean", Default: "false", Description: "Return file content as stored (possibly gzip compressed)"},
+					"share":        {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config retrieved", Body: hyprconfig.HyprConfig{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found, or the share link is unknown, expired or revoked", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Config Readme",
+			Path:    "/config/{config_id}/readme",
+			Handler: h.GetConfigReadme,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"format":    {Required: false, Default: hyprconfig.ReadmeFormatMarkdown, Enum: []string{hyprconfig.ReadmeFormatMarkdown, hyprconfig.ReadmeFormatHTML}, Description: "html is rendered server-side and safe to embed"},
+					"share":     {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config readme", Body: ReadmeResponse{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or unsupported format", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found, or the share link is unknown, expired or revoked", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Config Keybinds",
+			Path:    "/config/{config_id}/keybinds",
+			Handler: h.GetConfigKeybinds,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"share":     {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Every Hyprland bind of the config, with its description and submap, and the key combinations bound more than once", Body: hyprconfig.KeybindReport{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found, or the share link is unknown, expired or revoked", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Config Lint",
+			Path:    "/config/{config_id}/lint",
+			Handler: h.GetConfigLint,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"share":     {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Deprecated and removed options and invalid values in the Hyprland files of the config", Body: LintResponse{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found, or the share link is unknown, expired or revoked", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Update Config",
+			Path:    "/config/{config_id}",
+			Handler: h.UpdateConfig,
+			Methods: []string{http.MethodPut, http.MethodPatch},
+			Request: mserve.Request{
+				Body: UpdateConfigRequest{},
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config updated, with warnings for keybind conflicts and lint findings", Body: StatusResponse{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request, program_configs included or missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusRequestEntityTooLarge, Message: "Request body too large", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Updated config failed validation", Body: ValidationErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to update config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Delete Config",
+			Path:    "/config/{config_id}",
+			Handler: h.DeleteConfig,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config deleted", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to delete config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Deprecate Config",
+			Description: "Marks a config as deprecated, optionally pointing at its successor. Deprecated configs rank last in searches and the users applying it are notified",
+			Path:        "/config/{config_id}/deprecate",
+			Handler:     h.DeprecateConfig,
+			Methods:     []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: DeprecateConfigRequest{},
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config deprecated", Body: StatusResponse{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body or missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Successor not found, private or the config itself, or message too long", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to deprecate config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Program Config",
+			Path:    "/config/{config_id}/program/{prog_id}",
+			Handler: h.GetProgramConfig,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id":    {Required: true},
+					"prog_id":      {Required: true},
+					"raw_encoding": {Required: false, Type: "boolean", Default: "false", Description: "Return file content as stored (possibly gzip compressed)"},
+					"share":        {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program config retrieved", Body: hyprconfig.HyprProgramConfig{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or prog_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config or program config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get program config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Download Program File",
+			Path:    "/config/{config_id}/program/{prog_id}/file",
+			Handler: h.GetProgramFile,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"prog_id":   {Required: true},
+					"path":      {Required: false, Description: "target path of the file, e.g. style.css, default the first file of the program config"},
+					"share":     {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Raw file content, served as an attachment named after the target path"},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or prog_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config or program config not found, or the program config has no file at path", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get program file or the stored hash does not match", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Upload Gallery Images",
+			Path:    "/config/{config_id}/gallery",
+			Handler: h.AddGalleryImages,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Images added; their URLs are appended to gallery_pictures", Body: []hyprconfig.GalleryImage{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or no image files in a multipart form", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusRequestEntityTooLarge, Message: "Request body too large", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Not a png, jpeg or webp image, image too large or gallery full", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to store gallery image", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Gallery Image",
+			Path:    "/config/{config_id}/gallery/{index}",
+			Handler: h.GetGalleryImage,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"index":     {Required: true, Type: "integer"},
+					"share":     {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Image content"},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid index", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config or gallery image not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get gallery image or the stored hash does not match", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Delete Gallery Image",
+			Path:    "/config/{config_id}/gallery/{index}",
+			Handler: h.RemoveGalleryImage,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"index":     {Required: true, Type: "integer"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Image and its URL removed"},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid index", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config or gallery image not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to delete gallery image", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Create Share Link",
+			Path:    "/config/{config_id}/share-links",
+			Handler: h.CreateShareLink,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: CreateShareLinkRequest{},
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Share link created, the token is only returned this once", Body: hyprconfig.CreatedShareLink{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body or missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Expiry is negative or longer than 30 days", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to create share link", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Share Links",
+			Path:    "/config/{config_id}/share-links",
+			Handler: h.ListShareLinks,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Share links listed, without their tokens", Body: []hyprconfig.ShareLink{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list share links", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Revoke Share Link",
+			Path:    "/config/{config_id}/share-links/{link_id}",
+			Handler: h.RevokeShareLink,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"link_id":   {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Share link revoked", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or link_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config or share link not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to revoke share link", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Export Config",
+			Path:    "/config/{config_id}/export",
+			Handler: h.ExportConfig,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id":  {Required: true},
+					"format":     {Required: false, Default: hyprconfig.ExportFormatTarGz, Enum: []string{hyprconfig.ExportFormatTarGz, hyprconfig.ExportFormatHomeManager}, Description: "home-manager returns a NixOS home-manager module (.nix)"},
+					"layout":     {Required: false, Default: hyprconfig.ExportLayoutHome, Enum: hyprconfig.ExportLayouts(), Description: "layout of the tar.gz: relative to $HOME, one GNU Stow package per program, or a chezmoi source directory"},
+					"share":      {Required: false, Description: "share link token, to read a private config it was created for"},
+					"var.{name}": {Required: false, Description: "value of the template variable name, once per variable"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config archive, or home-manager module (text/plain)"},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or unsupported format or layout", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Config contains an invalid install path or misses a required template variable", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to export config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Install Script",
+			Path:    "/config/{config_id}/install-script",
+			Handler: h.GetInstallScript,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id":        {Required: true},
+					"distro":           {Required: true, Enum: hyprconfig.SupportedDistros()},
+					"include_optional": {Required: false, Type: "boolean", Default: "false"},
+					"share":            {Required: false, Description: "share link token, to read a private config it was created for"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Shell script (text/plain)"},
+				{Status: http.StatusBadRequest, Message: "Missing config_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Unsupported distro or invalid package name", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to generate install script", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Import Config From Git",
+			Path:    "/config/import/git",
+			Handler: h.ImportFromGit,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: importer.GitImportRequest{},
+				Params: map[string]mserve.ROption{
+					"force": {Required: false, Type: "boolean", Description: "import the config even if the caller already owns one with the same content"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config imported", Body: importer.GitImportResult{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusConflict, Message: "The caller already owns a config with the same content", Body: DuplicateConfigResponse{}},
+				{Status: http.StatusRequestEntityTooLarge, Message: "Storage quota exceeded", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Invalid repository url or imported config failed validation", Body: ValidationErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to import config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Config Changelog",
+			Path:    "/config/{config_id}/changelog",
+			Handler: h.GetChangelog,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"page":      {Required: false, Type: "integer", Default: "1"},
+					"limit":     {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Changelog retrieved", Body: mserve.Page[hyprconfig.ChangelogEntry]{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private or not owned by the caller", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get changelog", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Config Audit Log",
+			Path:    "/config/{config_id}/audit",
+			Handler: h.ListAuditLog,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+					"page":      {Required: false, Type: "integer", Default: "1"},
+					"limit":     {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Audit log retrieved", Body: mserve.Page[hyprconfig.AuditEntry]{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Caller is neither the config owner nor an admin", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get audit log", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List All Configs",
+			Path:    "/configs",
+			Handler: h.ListConfigs,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":            {Required: false, Type: "integer", Default: "1"},
+					"limit":           {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content": {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Configs listed", Body: mserve.Page[hyprconfig.HyprConfig]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list configs", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Allowed Programs",
+			Path:    "/programs",
+			Handler: h.ListAllowedPrograms,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":     {Required: false, Type: "integer", Default: "1"},
+					"limit":    {Required: false, Type: "integer", Default: "50", Description: "lowered to the server maximum (100 by default)"},
+					"prefix":   {Required: false, Description: "case-insensitive program name prefix"},
+					"category": {Required: false},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Allowed programs listed", Body: mserve.Page[hyprconfig.AllowedPrograms]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list allowed programs", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Allowed Program",
+			Path:    "/programs/{name}",
+			Handler: h.GetAllowedProgram,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"name": {Required: true, Description: "program name, normalized like uploaded program configs"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Allowed program", Body: hyprconfig.AllowedPrograms{}},
+				{Status: http.StatusNotFound, Message: "Program is not allowed", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Empty program name", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get allowed program", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Add Allowed Program",
+			Path:    "/admin/programs",
+			Handler: h.AddAllowedProgram,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: AddAllowedProgramRequest{},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program allowed", Body: hyprconfig.AllowedPrograms{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Empty or already allowed program name", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to add allowed program", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Remove Allowed Program",
+			Path:    "/admin/programs/{name}",
+			Handler: h.RemoveAllowedProgram,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"name": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program removed from the allowlist"},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Program is not allowed", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Empty program name", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to remove allowed program", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Request Allowed Program",
+			Path:    "/programs/request",
+			Handler: h.RequestAllowedProgram,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: hyprconfig.ProgramRequestBody{},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program request submitted", Body: hyprconfig.ProgramRequest{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Empty or already allowed program name", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to submit program request", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Program Requests",
+			Path:    "/admin/programs/requests",
+			Handler: h.ListProgramRequests,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":  {Required: false, Type: "integer", Default: "1"},
+					"limit": {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"status": {Required: false, Enum: []string{
+						hyprconfig.ProgramRequestPending,
+						hyprconfig.ProgramRequestApproved,
+						hyprconfig.ProgramRequestRejected,
+					}},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program requests listed", Body: mserve.Page[hyprconfig.ProgramRequest]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list program requests", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Approve Program Request",
+			Path:    "/admin/programs/requests/{request_id}/approve",
+			Handler: h.ApproveProgramRequest,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"request_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program request approved", Body: hyprconfig.AllowedPrograms{}},
+				{Status: http.StatusBadRequest, Message: "Missing request_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Program request not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Program request was already reviewed", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to approve program request", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Reject Program Request",
+			Path:    "/admin/programs/requests/{request_id}/reject",
+			Handler: h.RejectProgramRequest,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"request_id": {Required: true},
+					"note":       {Required: false},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Program request rejected", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing request_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Program request not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Program request was already reviewed", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to reject program request", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Create Webhook",
+			Path:    "/webhooks",
+			Handler: h.CreateWebhook,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: hyprconfig.WebhookRequest{},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Webhook created", Body: hyprconfig.Webhook{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Invalid url, secret or events", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to create webhook", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Webhooks",
+			Path:    "/webhooks",
+			Handler: h.ListWebhooks,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Webhooks listed", Body: []hyprconfig.Webhook{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list webhooks", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Delete Webhook",
+			Path:    "/webhooks/{webhook_id}",
+			Handler: h.DeleteWebhook,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"webhook_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Webhook deleted", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing webhook_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Webhook is owned by another user", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Webhook not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to delete webhook", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Webhook Deliveries",
+			Path:    "/webhooks/{webhook_id}/deliveries",
+			Handler: h.ListWebhookDeliveries,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"webhook_id": {Required: true},
+					"page":       {Required: false, Type: "integer", Default: "1"},
+					"limit":      {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Webhook deliveries listed", Body: mserve.Page[hyprconfig.WebhookDelivery]{}},
+				{Status: http.StatusBadRequest, Message: "Missing webhook_id or invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Webhook is owned by another user", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Webhook not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list webhook deliveries", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Create API Key",
+			Path:    "/account/api-keys",
+			Handler: h.CreateAPIKey,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: CreateAPIKeyRequest{},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "API key created, the token is only returned this once", Body: hyprconfig.CreatedAPIKey{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "API keys cannot manage API keys", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Invalid name, scopes or expiry", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to create API key", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List API Keys",
+			Path:    "/account/api-keys",
+			Handler: h.ListAPIKeys,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "API keys listed, without their tokens", Body: []hyprconfig.APIKey{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "API keys cannot manage API keys", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list API keys", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Revoke API Key",
+			Path:    "/account/api-keys/{key_id}",
+			Handler: h.RevokeAPIKey,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"key_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "API key revoked", Body: map[string]string{}},
+				{Status: http.StatusBadRequest, Message: "Missing key_id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "API key is owned by another user, or the caller used an API key", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "API key not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to revoke API key", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Quota Usage",
+			Path:    "/account/quota",
+			Handler: h.GetQuotaUsage,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Bytes of file content stored and allowed", Body: hyprconfig.QuotaUsage{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get quota usage", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Get Author Stats",
+			Description: "Public configs, likes and active users of a user, counting public configs only",
+			Path:        "/users/{user_id}/stats",
+			Handler:     h.GetAuthorStats,
+			Methods:     []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"user_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Author stats", Body: hyprconfig.AuthorStats{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to get author stats", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List User Configs",
+			Path:    "/users/{user_id}/configs",
+			Handler: h.ListUserConfigs,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"user_id":         {Required: true},
+					"page":            {Required: false, Type: "integer", Default: "1"},
+					"limit":           {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content": {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Public configs of the user, most recently updated first, and their author stats", Body: UserConfigsResponse{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list configs", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Follow Author",
+			Path:    "/users/{user_id}/follow",
+			Handler: h.FollowAuthor,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"user_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Author followed", Body: map[string]string{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Caller tried to follow themselves", Body: ValidationErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to follow author", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Unfollow Author",
+			Path:    "/users/{user_id}/follow",
+			Handler: h.UnfollowAuthor,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"user_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Author unfollowed", Body: map[string]string{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to unfollow author", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Following",
+			Path:    "/account/following",
+			Handler: h.ListFollowing,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Authors the caller follows, most recently followed first", Body: []hyprconfig.FollowedAuthor{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list followed authors", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Followed Authors Feed",
+			Path:    "/feed/following",
+			Handler: h.ListFollowedFeed,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":            {Required: false, Type: "integer", Default: "1"},
+					"limit":           {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content": {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Public configs of the followed authors, most recently updated first", Body: mserve.Page[hyprconfig.HyprConfig]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list the feed", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Notifications",
+			Path:    "/notifications",
+			Handler: h.ListNotifications,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":        {Required: false, Type: "integer", Default: "1"},
+					"limit":       {Required: false, Type: "integer", Default: "20", Description: "lowered to the server maximum (100 by default)"},
+					"unread_only": {Required: false, Type: "boolean", Description: "leave out notifications already read"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "The caller's notifications, newest first", Body: mserve.Page[hyprconfig.Notification]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid page, limit or unread_only", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list notifications", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Mark Notifications Read",
+			Path:    "/notifications/read",
+			Handler: h.MarkNotificationsRead,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: MarkNotificationsReadRequest{},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Notifications marked read", Body: MarkNotificationsReadResponse{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to mark notifications read", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Count Unread Notifications",
+			Path:    "/notifications/unread-count",
+			Handler: h.CountUnreadNotifications,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "How many of the caller's notifications are unread", Body: UnreadNotificationsResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to count notifications", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Set User Quota",
+			Path:    "/admin/users/{user_id}/quota",
+			Handler: h.SetUserQuota,
+			Methods: []string{http.MethodPut},
+			Request: mserve.Request{
+				Body: SetUserQuotaRequest{},
+				Params: map[string]mserve.ROption{
+					"user_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Quota set", Body: hyprconfig.QuotaUsage{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Quota is negative", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to set quota", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Set User Config Limit",
+			Description: "Overrides how many configs a user may own",
+			Path:        "/admin/users/{user_id}/config-limit",
+			Handler:     h.SetUserConfigLimit,
+			Methods:     []string{http.MethodPut},
+			Request: mserve.Request{
+				Body: SetUserConfigLimitRequest{},
+				Params: map[string]mserve.ROption{
+					"user_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config limit set", Body: hyprconfig.ConfigLimit{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Limit is negative", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to set config limit", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Get Read-Only Mode",
+			Path:    "/read-only",
+			Handler: h.GetReadOnly,
+			Methods: []string{http.MethodGet},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Whether writes are rejected for maintenance", Body: ReadOnlyStatus{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Set Read-Only Mode",
+			Description: "Rejects writes with 503 while reads keep working, for migrations and incidents",
+			Path:        "/admin/read-only",
+			Handler:     h.SetReadOnly,
+			Methods:     []string{http.MethodPut},
+			Request: mserve.Request{
+				Body: ReadOnlyStatus{},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Read-only mode set", Body: ReadOnlyStatus{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotImplemented, Message: "Read-only mode cannot be toggled on this server", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Assign Role",
+			Description: "Grants a role, such as admin, to a user",
+			Path:        "/admin/users/{user_id}/roles",
+			Handler:     h.AssignRole,
+			Methods:     []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: AssignRoleRequest{},
+				Params: map[string]mserve.ROption{
+					"user_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Roles of the user, the assigned one included", Body: UserRolesResponse{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "No user has this id", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Role is empty", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to assign role", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotImplemented, Message: "Roles cannot be assigned on this server", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Admin List Configs",
+			Description: "Lists every config, private ones of other users included, for moderation",
+			Path:        "/admin/configs",
+			Handler:     h.AdminListConfigs,
+			Methods:     []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"page":             {Required: false, Type: "integer", Default: "1"},
+					"limit":            {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content":  {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+					"q":                {Required: false, Description: "text search on title, description and tags"},
+					"tags":             {Required: false, Description: "comma separated, configs must have every tag"},
+					"program":          {Required: false, Description: "configs containing this program"},
+					"owner_id":         {Required: false},
+					"private":          {Required: false, Type: "boolean"},
+					"verified":         {Required: false, Type: "boolean", Description: "configs an admin reviewed and verified since their last edit"},
+					"deprecated":       {Required: false, Type: "boolean", Description: "false leaves out deprecated configs, which otherwise rank last"},
+					"platform":         {Required: false, Description: "configs whose required programs support this platform"},
+					"monitors":         {Required: false, Type: "integer", Description: "configs laid out for this many monitors, or for any number"},
+					"resolution":       {Required: false, Description: "configs with a monitor at this resolution, e.g. 2560x1440"},
+					"theme":            {Required: false, Enum: []string{hyprconfig.ThemeDark, hyprconfig.ThemeLight}},
+					"palette":          {Required: false, Description: "comma separated hex colors, # optional, configs must have a color close to each"},
+					"color_tolerance":  {Required: false, Type: "integer", Default: "40", Description: "how far each channel of a palette color may be from a searched one"},
+					"license":          {Required: false, Description: "an SPDX identifier, custom, or unspecified for configs without a license"},
+					"hyprland_version": {Required: false, Description: "configs working on this Hyprland version, e.g. 0.41.2: their minimum is at most it or unset"},
+					"updated_from":     {Required: false, Description: "unix or RFC 3339 timestamp"},
+					"updated_to":       {Required: false, Description: "unix or RFC 3339 timestamp"},
+					"sort": {
+						Required: false,
+						Default:  hyprconfig.SearchSortUpdated,
+						Enum: []string{
+							hyprconfig.SearchSortUpdated, hyprconfig.SearchSortCreated,
+							hyprconfig.SearchSortLikes, hyprconfig.SearchSortTitle,
+							hyprconfig.SearchSortVerified,
+						},
+					},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Configs listed", Body: mserve.Page[hyprconfig.HyprConfig]{}},
+				{Status: http.StatusBadRequest, Message: "Invalid query parameters, page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnprocessableEntity, Message: "Invalid platform or sort", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list configs", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:        "Verify Config",
+			Description: "Gives a reviewed config the verified badge, which any later edit of the config drops",
+			Path:        "/admin/config/{config_id}/verify",
+			Handler:     h.VerifyConfig,
+			Methods:     []string{http.MethodPost},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Config verified", Body: StatusResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to verify config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Unverify Config",
+			Path:    "/admin/config/{config_id}/verify",
+			Handler: h.UnverifyConfig,
+			Methods: []string{http.MethodDelete},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id": {Required: true},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Verified badge removed", Body: StatusResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to unverify config", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Import Allowed Programs",
+			Path:    "/admin/programs/import",
+			Handler: h.ImportAllowedPrograms,
+			Methods: []string{http.MethodPost},
+			Request: mserve.Request{
+				Body: []hyprconfig.AllowedPrograms{},
+				Params: map[string]mserve.ROption{
+					"upsert": {Required: false, Type: "boolean", Default: "false"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Programs imported", Body: []hyprconfig.ProgramImportResult{}},
+				{Status: http.StatusBadRequest, Message: "Invalid request body or parameters", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusUnauthorized, Message: "Not signed in", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Admin role required", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to import programs", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "List Similar Configs",
+			Path:    "/configs/similar",
+			Handler: h.ListSimilarConfigs,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"config_id":       {Required: true, Description: "config to find public copies of"},
+					"share":           {Required: false, Description: "share link token, to look up a private config"},
+					"page":            {Required: false, Type: "integer", Default: "1"},
+					"limit":           {Required: false, Type: "integer", Default: "10", Description: "lowered to the server maximum (100 by default)"},
+					"include_content": {Required: false, Type: "boolean", Description: "include file data, lowering the limit to 10"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "Public configs with the same programs and files, most liked first", Body: mserve.Page[hyprconfig.HyprConfig]{}},
+				{Status: http.StatusBadRequest, Message: "Missing config_id or invalid page or limit", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusForbidden, Message: "Config is private", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "Config not found", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to list similar configs", Body: mserve.ErrorResponse{}},
+			},
+		},
+		&mserve.Endpoint{
+			Name:    "Random Config",
+			Path:    "/configs/random",
+			Handler: h.GetRandomConfig,
+			Methods: []string{http.MethodGet},
+			Request: mserve.Request{
+				Params: map[string]mserve.ROption{
+					"q":                {Required: false, Description: "text search on title, description and tags"},
+					"tags":             {Required: false, Description: "comma separated, the config must have every tag"},
+					"program":          {Required: false, Description: "a config containing this program"},
+					"owner_id":         {Required: false},
+					"platform":         {Required: false, Description: "a config whose required programs support this platform"},
+					"monitors":         {Required: false, Type: "integer", Description: "a config laid out for this many monitors, or for any number"},
+					"resolution":       {Required: false, Description: "a config with a monitor at this resolution, e.g. 2560x1440"},
+					"theme":            {Required: false, Enum: []string{hyprconfig.ThemeDark, hyprconfig.ThemeLight}},
+					"palette":          {Required: false, Description: "comma separated hex colors, # optional, the config must have a color close to each"},
+					"license":          {Required: false, Description: "an SPDX identifier, custom, or unspecified for configs without a license"},
+					"hyprland_version": {Required: false, Description: "a config working on this Hyprland version, e.g. 0.41.2: its minimum is at most it or unset"},
+					"verified":         {Required: false, Type: "boolean", Description: "a config an admin reviewed and verified since its last edit"},
+					"deprecated":       {Required: false, Type: "boolean", Description: "false leaves out deprecated configs"},
+				},
+			},
+			Responses: []mserve.Response{
+				{Status: http.StatusOK, Message: "A config picked at random out of the matching ones", Body: hyprconfig.HyprConfig{}},
+				{Status: http.StatusBadRequest, Message: "Invalid filters", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusNotFound, Message: "No config matches the filters", Body: mserve.ErrorResponse{}},
+				{Status: http.StatusInternalServerError, Message: "Failed to pick a config", Body: mserve.ErrorResponse{}},
+			},
+		},
+	)
+	return endpoints
+}
+
+func (h *Handler) NewConfig(w http.ResponseWriter, r *http.Request) {
+	h.limitBody(w, r)
+	hc, err := mserve.ReadBody[hyprconfig.HyprConfig](r)
+	if err != nil {
+		mserve.WriteError(w, r, bodyErrorStatus(err), err.Error())
+		return
+	}
+
+	created, err := h.configManager.CreateConfig(createContext(r), hc)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, created)
+}
+
+// SearchConfigs filters configs by the request body when there is one, and by the query
+// parameters otherwise.
+func (h *Handler) SearchConfigs(w http.ResponseWriter, r *http.Request) {
+	r, currentPage, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	var filter *hyprconfig.ConfigSearchFilters
+	var err error
+	if r.ContentLength != 0 {
+		filter, err = mserve.ReadBody[hyprconfig.ConfigSearchFilters](r)
+	} else {
+		filter, err = searchFiltersFromQuery(r)
+	}
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.configManager.ListConfigsWithFilters(r.Context(), currentPage, limit, *filter, nil)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, page)
+}
+
+// AdminListConfigs lists every config matching the search query parameters, private ones
+// included. Admins only.
+func (h *Handler) AdminListConfigs(w http.ResponseWriter, r *http.Request) {
+	r, currentPage, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	filter, err := searchFiltersFromQuery(r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := h.configManager.AdminListConfigs(r.Context(), currentPage, limit, *filter)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, page)
+}
+
+// searchFiltersFromQuery builds search filters from the query parameters of a GET search.
+func searchFiltersFromQuery(r *http.Request) (*hyprconfig.ConfigSearchFilters, error) {
+	q := r.URL.Query()
+	filter := &hyprconfig.ConfigSearchFilters{
+		Query:      q.Get("q"),
+		Program:    q.Get("program"),
+		OwnerID:    q.Get("owner_id"),
+		Platform:   q.Get("platform"),
+		Resolution: q.Get("resolution"),
+		Theme:      q.Get("theme"),
+		License:    q.Get("license"),
+		Sort:       q.Get("sort"),
+
+		HyprlandVersion: q.Get("hyprland_version"),
+	}
+	for _, tag := range strings.Split(q.Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	if v := q.Get("private"); v != "" {
+		private, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private %q: must be true or false", v)
+		}
+		filter.Private = &private
+	}
+	if v := q.Get("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid verified %q: must be true or false", v)
+		}
+		filter.Verified = &verified
+	}
+	if v := q.Get("deprecated"); v != "" {
+		deprecated, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deprecated %q: must be true or false", v)
+		}
+		filter.Deprecated = &deprecated
+	}
+
+	for _, color := range strings.Split(q.Get("palette"), ",") {
+		if color = strings.TrimSpace(color); color != "" {
+			filter.Palette = append(filter.Palette, color)
+		}
+	}
+	if v := q.Get("color_tolerance"); v != "" {
+		tolerance, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color_tolerance %q: must be an integer", v)
+		}
+		filter.ColorTolerance = tolerance
+	}
+	if v := q.Get("monitors"); v != "" {
+		monitors, err := strconv.Atoi(v)
+		if err != nil || monitors < 0 {
+			return nil, fmt.Errorf("invalid monitors %q: must be a non-negative integer", v)
+		}
+		filter.Monitors = monitors
+	}
+
+	var err error
+	if filter.UpdatedFrom, err = queryTimestamp(q.Get("updated_from")); err != nil {
+		return nil, fmt.Errorf("invalid updated_from: %w", err)
+	}
+	if filter.UpdatedTo, err = queryTimestamp(q.Get("updated_to")); err != nil {
+		return nil, fmt.Errorf("invalid updated_to: %w", err)
+	}
+	return filter, nil
+}
+
+// queryTimestamp parses a unix or RFC 3339 timestamp into unix seconds, or nil if v is empty.
+func queryTimestamp(v string) (*int64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return &sec, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a unix nor an RFC 3339 timestamp", v)
+	}
+	sec := t.Unix()
+	return &sec, nil
+}
+
+func (h *Handler) ListMyConfigs(w http.ResponseWriter, r *http.Request) {
+	r, page, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListMyConfigs(r.Context(), page, limit, nil)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
+}
+
+// ListSimilarConfigs lists the public configs that have the same programs and files as config_id.
+func (h *Handler) ListSimilarConfigs(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.QueryParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+	r, page, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListSimilarConfigs(shareContext(r), configID, page, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
+}
+
+func (h *Handler) GetRandomConfig(w http.ResponseWriter, r *http.Request) {
+	filter, err := searchFiltersFromQuery(r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cfg, err := h.configManager.GetRandomConfig(r.Context(), *filter)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, cfg)
+}
+
+func (h *Handler) FavoriteConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	if err := h.configManager.FavoriteConfig(r.Context(), configID); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "favorited"})
+}
+
+func (h *Handler) UnfavoriteConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	if err := h.configManager.UnfavoriteConfig(r.Context(), configID); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "unfavorited"})
+}
+
+func (h *Handler) ApplyConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	if err := h.configManager.ApplyConfig(r.Context(), configID); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	resp := ApplyConfigResponse{Status: "applied"}
+	// The config was applied either way, a failed lookup only leaves out the deprecation
+	if cfg, err := h.configManager.GetConfig(r.Context(), configID); err == nil {
+		resp.Deprecation = cfg.Deprecation
+	}
+	mserve.WriteBody(w, r, resp)
+}
+
+func (h *Handler) GetAppliedConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.configManager.GetAppliedConfig(r.Context())
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, cfg)
+}
+
+// WatchAppliedConfig streams applied config changes as server-sent events until the client
+// disconnects. Each event is named after its type and carries the event as JSON; a comment line
+// is sent every sseHeartbeatInterval to keep proxies from closing an idle connection.
+func (h *Handler) WatchAppliedConfig(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	events, err := h.configManager.WatchAppliedConfig(ctx)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (h *Handler) AddProgramConfig(w http.ResponseWriter, r *http.Request) {
+	h.limitBody(w, r)
+	prog, err := mserve.ReadBody[hyprconfig.HyprProgramConfig](r)
+	if err != nil {
+		mserve.WriteError(w, r, bodyErrorStatus(err), err.Error())
+		return
+	}
+
+	configID := mserve.PathParam(r, "config_id")
+	parentID := mserve.QueryParam(r, "parent_id")
+
+	var parentPtr *string
+	if parentID != "" {
+		parentPtr = &parentID
+	}
+
+	changelog := mserve.QueryParam(r, "changelog_message")
+	if err := h.configManager.AddProgramConfig(r.Context(), configID, *prog, parentPtr, changelog); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, StatusResponse{Status: "added", Warnings: h.changeWarnings(r.Context(), configID)})
+}
+
+func (h *Handler) RemoveProgramConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	progID := mserve.QueryParam(r, "prog_id")
+	if progID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "prog_id is required")
+		return
+	}
+
+	changelog := mserve.QueryParam(r, "changelog_message")
+	if err := h.configManager.RemoveProgramConfig(r.Context(), configID, progID, changelog); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "removed"})
+}
+
+func (h *Handler) UpdateProgramConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	progID := mserve.QueryParam(r, "prog_id")
+	if progID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "prog_id is required")
+		return
+	}
+
+	h.limitBody(w, r)
+	updates, err := mserve.ReadBody[hyprconfig.HyprProgramConfig](r)
+	if err != nil {
+		mserve.WriteError(w, r, bodyErrorStatus(err), err.Error())
+		return
+	}
+
+	changelog := mserve.QueryParam(r, "changelog_message")
+	if err := h.configManager.UpdateProgramConfig(r.Context(), configID, progID, *updates, changelog); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, StatusResponse{Status: "updated", Warnings: h.changeWarnings(r.Context(), configID)})
+}
+
+func (h *Handler) MoveProgramConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	progID := mserve.QueryParam(r, "prog_id")
+	if progID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "prog_id is required")
+		return
+	}
+
+	newParentID := mserve.QueryParam(r, "new_parent_id")
+	var parentPtr *string
+	if newParentID != "" {
+		parentPtr = &newParentID
+	}
+
+	changelog := mserve.QueryParam(r, "changelog_message")
+	if err := h.configManager.MoveProgramConfig(r.Context(), configID, progID, parentPtr, changelog); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "moved"})
+}
+
+func (h *Handler) ListFavorites(w http.ResponseWriter, r *http.Request) {
+	r, page, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListFavorites(r.Context(), page, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
+}
+
+func (h *Handler) ListConfigFavoriters(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	page, limit, ok := h.pageParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListConfigFavoriters(r.Context(), configID, page, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
+}
+
+func (h *Handler) ListRelatedConfigs(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+	_, limit, ok := h.pageParams(w, r, hyprconfig.DefaultRelatedConfigs)
+	if !ok {
+		return
+	}
+
+	related, err := h.configManager.ListRelatedConfigs(r.Context(), configID, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, related)
+}
+
+func (h *Handler) CountConfigFavorites(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	count, err := h.configManager.CountConfigFavorites(r.Context(), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]int64{"count": count})
+}
+
+func (h *Handler) CountUsersUsingConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	count, err := h.configManager.CountUsersUsingConfig(r.Context(), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]int64{"count": count})
+}
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	cfg, err := h.configManager.GetConfig(readContext(r), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	setDownloadURLs(cfg, mserve.QueryParam(r, "share"))
+	mserve.WriteBody(w, r, cfg)
+}
+
+func (h *Handler) GetConfigReadme(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+	format := mserve.QueryParam(r, "format")
+	if format == "" {
+		format = hyprconfig.ReadmeFormatMarkdown
+	}
+	if format != hyprconfig.ReadmeFormatMarkdown && format != hyprconfig.ReadmeFormatHTML {
+		mserve.WriteError(w, r, http.StatusBadRequest, "unsupported readme format: "+format)
+		return
+	}
+
+	cfg, err := h.configManager.GetConfig(shareContext(r), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	content := cfg.Readme
+	if format == hyprconfig.ReadmeFormatHTML {
+		content = hyprconfig.RenderReadme(content)
+	}
+	mserve.WriteBody(w, r, ReadmeResponse{Format: format, Content: content})
+}
+
+func (h *Handler) GetConfigKeybinds(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	cfg, err := h.configManager.GetConfig(shareContext(r), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	// The binds are extracted when the config is written
+	mserve.WriteBody(w, r, hyprconfig.NewKeybindReport(cfg.Keybinds))
+}
+
+func (h *Handler) GetConfigLint(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	cfg, err := h.configManager.GetConfig(shareContext(r), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	mserve.WriteBody(w, r, LintResponse{Findings: hyprconfig.LintConfig(cfg)})
+}
+
+// changeWarnings returns the keybind conflicts and lint findings of a config that was just
+// changed. Failing to read it back only loses the warnings, not the change.
+func (h *Handler) changeWarnings(ctx context.Context, configID string) []string {
+	cfg, err := h.configManager.GetConfig(ctx, configID)
+	if err != nil {
+		return nil
+	}
+	return append(hyprconfig.KeybindWarnings(cfg), hyprconfig.LintWarnings(cfg)...)
+}
+
+func (h *Handler) ExportConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+	format := mserve.QueryParam(r, "format")
+	if format == "" {
+		format = hyprconfig.ExportFormatTarGz
+	}
+	if format != hyprconfig.ExportFormatTarGz && format != hyprconfig.ExportFormatHomeManager {
+		mserve.WriteError(w, r, http.StatusBadRequest, "unsupported export format: "+format)
+		return
+	}
+	layout := mserve.QueryParam(r, "layout")
+	if layout != "" && !slices.Contains(hyprconfig.ExportLayouts(), layout) {
+		mserve.WriteError(w, r, http.StatusBadRequest, "unsupported export layout: "+layout)
+		return
+	}
+	ctx := hyprconfig.WithTemplateValues(shareContext(r), templateValuesFromQuery(r))
+	ctx = hyprconfig.WithExportLayout(ctx, layout)
+
+	if format == hyprconfig.ExportFormatHomeManager {
+		module, err := h.configManager.ExportHomeManager(ctx, configID)
+		if err != nil {
+			writeDomainError(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "hypr-config-"+configID+".nix"))
+		_, _ = io.WriteString(w, module)
+		return
+	}
+
+	aw := &attachmentWriter{
+		w:           w,
+		contentType: "application/gzip",
+		filename:    "hypr-config-" + configID + "." + format,
+	}
+	if err := h.configManager.ExportConfigArchive(ctx, configID, aw); err != nil && !aw.started {
+		writeDomainError(w, r, err)
+	}
+}
+
+func (h *Handler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	body := &CreateShareLinkRequest{}
+	if r.ContentLength != 0 {
+		var err error
+		if body, err = mserve.ReadBody[CreateShareLinkRequest](r); err != nil {
+			mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ttl := time.Duration(body.ExpiresInHours) * time.Hour
+	link, err := h.configManager.CreateShareLink(r.Context(), configID, ttl)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, link)
+}
+
+func (h *Handler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	links, err := h.configManager.ListShareLinks(r.Context(), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, links)
+}
+
+func (h *Handler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	linkID := mserve.PathParam(r, "link_id")
+	if configID == "" || linkID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id and link_id are required")
+		return
+	}
+
+	if err := h.configManager.RevokeShareLink(r.Context(), configID, linkID); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "revoked"})
+}
+
+func (h *Handler) GetInstallScript(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+	includeOptional, _ := strconv.ParseBool(mserve.QueryParam(r, "include_optional"))
+
+	script, err := h.configManager.GetInstallScript(shareContext(r), configID, mserve.QueryParam(r, "distro"), includeOptional)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, script)
+}
+
+func (h *Handler) ImportFromGit(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[importer.GitImportRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.gitImporter.ImportFromGit(createContext(r), body.URL, body.Ref, body.Subdir)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
+}
+
+// attachmentWriter sets download headers on the first write, so errors that happen
+// before any output can still be reported as a normal error response.
+type attachmentWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (a *attachmentWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		a.w.Header().Set("Content-Type", a.contentType)
+		a.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.filename))
+	}
+	return a.w.Write(p)
+}
+
+// setDownloadURLs points offloaded file content at the download endpoint, passing on the
+// share link token the config was read with.
+func setDownloadURLs(cfg *hyprconfig.HyprConfig, share string) {
+	downloadURL := func(pc *hyprconfig.HyprProgramConfig, filePath string) string {
+		query := url.Values{}
+		if filePath != "" {
+			query.Set("path", filePath)
+		}
+		if share != "" {
+			query.Set("share", share)
+		}
+		u := fmt.Sprintf("/config/%s/program/%s/file", cfg.ID, pc.ID)
+		if len(query) > 0 {
+			u += "?" + query.Encode()
+		}
+		return u
+	}
+	cfg.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+		if pc.FileContent.FileID != "" {
+			pc.FileContent.DownloadURL = downloadURL(pc, "")
+		}
+		for i := range pc.Files {
+			if fc := &pc.Files[i].FileContent; fc.FileID != "" {
+				fc.DownloadURL = downloadURL(pc, pc.Files[i].TargetPath)
+			}
+		}
+	})
+}
+
+func (h *Handler) GetProgramFile(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	progID := mserve.PathParam(r, "prog_id")
+	if configID == "" || progID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id and prog_id are required")
+		return
+	}
+
+	rc, file, err := h.configManager.GetProgramFile(shareContext(r), configID, progID, r.URL.Query().Get("path"))
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	defer rc.Close()
+	content := file.FileContent
+
+	body := bufio.NewReader(rc)
+	w.Header().Set("Content-Type", fileContentType(content.FileType, body))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": path.Base(file.TargetPath),
+	}))
+	if content.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(content.Size, 10))
+	}
+	if content.Hash != "" {
+		w.Header().Set("ETag", strconv.Quote(content.Hash))
+	}
+
+	// Headers are already sent, so a failed copy can only be dropped
+	_, _ = io.Copy(w, body)
+}
+
+// galleryFormMemory is how much of a multipart gallery upload is kept in memory before the
+// rest is spooled to temporary files.
+const galleryFormMemory = 8 << 20
+
+// AddGalleryImages adds every file in the image field of a multipart form to a config's gallery.
+// Images are added one at a time, so when one is rejected the earlier ones stay.
+func (h *Handler) AddGalleryImages(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	h.limitBody(w, r)
+	if err := r.ParseMultipartForm(galleryFormMemory); err != nil {
+		mserve.WriteError(w, r, bodyErrorStatus(err), "invalid multipart form: "+err.Error())
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	files := r.MultipartForm.File["image"]
+	if len(files) == 0 {
+		mserve.WriteError(w, r, http.StatusBadRequest, "no files in the image field")
+		return
+	}
+
+	added := make([]hyprconfig.GalleryImage, 0, len(files))
+	for _, fh := range files {
+		data, err := readFormFile(fh)
+		if err != nil {
+			mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		img, err := h.configManager.AddGalleryImage(r.Context(), configID, data)
+		if err != nil {
+			writeDomainError(w, r, err)
+			return
+		}
+		added = append(added, *img)
+	}
+	mserve.WriteBody(w, r, added)
+}
+
+func readFormFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", fh.Filename, err)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// galleryIndex reads the config_id and index path parameters, writing a 400 if either is invalid.
+func galleryIndex(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	configID := mserve.PathParam(r, "config_id")
+	index, err := strconv.Atoi(mserve.PathParam(r, "index"))
+	if configID == "" || err != nil || index < 0 {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id and a non-negative index are required")
+		return "", 0, false
+	}
+	return configID, index, true
+}
+
+func (h *Handler) GetGalleryImage(w http.ResponseWriter, r *http.Request) {
+	configID, index, ok := galleryIndex(w, r)
+	if !ok {
+		return
+	}
+
+	rc, img, err := h.configManager.GetGalleryImage(shareContext(r), configID, index)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(img.FileContent.Size, 10))
+	w.Header().Set("ETag", strconv.Quote(img.FileContent.Hash))
+
+	// Headers are already sent, so a failed copy can only be dropped
+	_, _ = io.Copy(w, rc)
+}
+
+func (h *Handler) RemoveGalleryImage(w http.ResponseWriter, r *http.Request) {
+	configID, index, ok := galleryIndex(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.configManager.RemoveGalleryImage(r.Context(), configID, index); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// fileContentType returns the Content-Type for a file of the given FileType. Images are sniffed
+// from the start of the content.
+func fileContentType(fileType string, body *bufio.Reader) string {
+	switch fileType {
+	case hyprconfig.FileTypeText, hyprconfig.FileTypeConfig, hyprconfig.FileTypeScript:
+		return "text/plain; charset=utf-8"
+	case hyprconfig.FileTypeImage:
+		head, _ := body.Peek(512)
+		if ct := http.DetectContentType(head); strings.HasPrefix(ct, "image/") {
+			return ct
+		}
+	}
+	return "application/octet-stream"
+}
+
+func (h *Handler) GetProgramConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	progID := mserve.PathParam(r, "prog_id")
+	if configID == "" || progID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id and prog_id are required")
+		return
+	}
+
+	prog, err := h.configManager.GetProgramConfig(readContext(r), configID, progID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, prog)
+}
+
+// limitBody caps the request body so oversized uploads are rejected before they are decoded.
+func (h *Handler) limitBody(w http.ResponseWriter, r *http.Request) {
+	if n := h.configManager.SizeLimits().MaxRequestBytes(); n > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, n)
+	}
+}
+
+// pageParams reads the page and limit query parameters, defaulting to page 1 and defaultLimit.
+// Limits above the handler's maximum are lowered to it. It writes a 400 and returns false if
+// either value is not a positive integer.
+func (h *Handler) pageParams(w http.ResponseWriter, r *http.Request, defaultLimit int) (page, limit int, ok bool) {
+	positive := func(name string, def int) (int, bool) {
+		v := mserve.QueryParam(r, name)
+		if v == "" {
+			return def, true
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			mserve.WriteError(w, r, http.StatusBadRequest, name+" must be a positive integer")
+			return 0, false
+		}
+		return n, true
+	}
+
+	if page, ok = positive("page", 1); !ok {
+		return 0, 0, false
+	}
+	if limit, ok = positive("limit", defaultLimit); !ok {
+		return 0, 0, false
+	}
+	return page, min(limit, h.maxPageLimit), true
+}
+
+// maxContentPageLimit is the largest page size served when a list includes file content.
+const maxContentPageLimit = 10
+
+// listParams is pageParams for the config lists, which also read include_content. When it is
+// true the returned request asks for file data and the limit is lowered to maxContentPageLimit.
+func (h *Handler) listParams(w http.ResponseWriter, r *http.Request, defaultLimit int) (*http.Request, int, int, bool) {
+	page, limit, ok := h.pageParams(w, r, defaultLimit)
+	if !ok {
+		return r, 0, 0, false
+	}
+
+	v := mserve.QueryParam(r, "include_content")
+	if v == "" {
+		return r, page, limit, true
+	}
+	include, err := strconv.ParseBool(v)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, "include_content must be true or false")
+		return r, 0, 0, false
+	}
+	if include {
+		r = r.WithContext(hyprconfig.WithFileContent(r.Context()))
+		limit = min(limit, maxContentPageLimit)
+	}
+	return r, page, limit, true
+}
+
+// validationErrors are the domain errors caused by invalid input rather than a failed operation.
+var validationErrors = []error{
+	hyprconfig.ErrValidation,
+	hyprconfig.ErrInvalidPlatform,
+	hyprconfig.ErrInvalidLicense,
+	hyprconfig.ErrInvalidPlaceholder,
+	hyprconfig.ErrMissingVariable,
+	hyprconfig.ErrDependencyCycle,
+	hyprconfig.ErrInvalidEnvVar,
+	hyprconfig.ErrContentTooLarge,
+	hyprconfig.ErrBinaryNotAllowed,
+	hyprconfig.ErrMissingHash,
+	hyprconfig.ErrHashMismatch,
+	hyprconfig.ErrInvalidInstallPath,
+	hyprconfig.ErrInvalidFileMode,
+	hyprconfig.ErrUnsafeCommand,
+	hyprconfig.ErrUnknownFile,
+	hyprconfig.ErrUnsupportedDistro,
+	hyprconfig.ErrInvalidWebhook,
+	hyprconfig.ErrInvalidAPIKey,
+	hyprconfig.ErrInvalidImage,
+	hyprconfig.ErrGalleryFull,
+	importer.ErrInvalidRepoURL,
+}
+
+// domainErrorStatus maps an error returned by the config manager to its response status.
+func domainErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, hyprconfig.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, hyprconfig.ErrForbidden), errors.Is(err, hyprconfig.ErrLimitExceeded):
+		return http.StatusForbidden
+	case errors.Is(err, hyprconfig.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, hyprconfig.ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, hyprconfig.ErrDuplicateConfig), errors.Is(err, hyprconfig.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, hyprconfig.ErrAllowlistDisabled):
+		return http.StatusNotImplemented
+	case errors.Is(err, hyprconfig.ErrReadOnly):
+		return http.StatusServiceUnavailable
+	}
+	for _, target := range validationErrors {
+		if errors.Is(err, target) {
+			return http.StatusUnprocessableEntity
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// writeDomainError writes err with the status of the domain error it wraps.
+// Duplicate configs also return the id of the config they duplicate, failed validations
+// every problem that was found, a deleted applied config its stale id, and a reached config limit
+// the caller's count and limit. Writes rejected in read-only mode say when to retry.
+func writeDomainError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, hyprconfig.ErrReadOnly) {
+		w.Header().Set("Retry-After", strconv.Itoa(readOnlyRetryAfter))
+	}
+	var (
+		dup     *hyprconfig.DuplicateConfigError
+		verr    *hyprconfig.ValidationError
+		missing *hyprconfig.AppliedConfigMissingError
+		limit   *hyprconfig.ConfigLimitError
+	)
+	switch {
+	case errors.As(err, &dup):
+		status := http.StatusConflict
+		writeErrorBody(w, r, status, DuplicateConfigResponse{ErrorR: errorR(status, err), ExistingConfigID: dup.ExistingID})
+	case errors.As(err, &missing):
+		status := http.StatusNotFound
+		writeErrorBody(w, r, status, AppliedConfigMissingResponse{ErrorR: errorR(status, err), StaleConfigID: missing.ConfigID})
+	case errors.As(err, &limit):
+		status := http.StatusForbidden
+		writeErrorBody(w, r, status, ConfigLimitResponse{ErrorR: errorR(status, err), Count: limit.Count, Limit: limit.Limit})
+	case errors.As(err, &verr):
+		status := http.StatusUnprocessableEntity
+		writeErrorBody(w, r, status, ValidationErrorResponse{ErrorR: errorR(status, err), Errors: verr.Errors})
+	default:
+		status := domainErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			// Storage errors can name hosts and collections, clients only learn that it failed
+			slog.ErrorContext(r.Context(), "request failed", "path", r.URL.Path, "err", err)
+			mserve.WriteError(w, r, status, http.StatusText(status))
+			return
+		}
+		mserve.WriteError(w, r, status, err.Error())
+	}
+}
+
+// errorR builds the mserve error body for err.
+func errorR(status int, err error) mserve.ErrorR {
+	return mserve.ErrorR{Status: status, Error: err.Error(), Timestamp: time.Now().Format(time.RFC3339)}
+}
+
+// writeErrorBody writes an error body that extends mserve.ErrorR.
+func writeErrorBody(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	mserve.WriteBody(w, r, body)
+}
+
+// bodyErrorStatus maps a body read error to its response status.
+func bodyErrorStatus(err error) int {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// shareContext returns the request context, carrying the ?share= link token when present.
+func shareContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if token := mserve.QueryParam(r, "share"); token != "" {
+		ctx = hyprconfig.WithShareToken(ctx, token)
+	}
+	return ctx
+}
+
+// templateValuesFromQuery returns the template variable values given as ?var.<name>=value.
+func templateValuesFromQuery(r *http.Request) map[string]string {
+	values := map[string]string{}
+	for key, vs := range r.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "var."); ok && len(vs) > 0 {
+			values[name] = vs[0]
+		}
+	}
+	return values
+}
+
+// createContext returns the request context, allowing duplicate configs when ?force=true.
+func createContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if force, _ := strconv.ParseBool(mserve.QueryParam(r, "force")); force {
+		ctx = hyprconfig.WithAllowDuplicate(ctx)
+	}
+	return ctx
+}
+
+// readContext returns shareContext, opting into raw file encoding when ?raw_encoding=true.
+func readContext(r *http.Request) context.Context {
+	ctx := shareContext(r)
+	if raw, _ := strconv.ParseBool(mserve.QueryParam(r, "raw_encoding")); raw {
+		ctx = hyprconfig.WithRawEncoding(ctx)
+	}
+	return ctx
+}
+
+func (h *Handler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	// Read incoming updates
+	h.limitBody(w, r)
+	updatesBody, err := mserve.ReadBody[UpdateConfigRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, bodyErrorStatus(err), err.Error())
+		return
+	}
+	if len(updatesBody.ProgramConfigs) > 0 && string(updatesBody.ProgramConfigs) != "null" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "program_configs cannot be updated here, use the program config endpoints")
+		return
+	}
+	switch updatesBody.VersionBump {
+	case "", hyprconfig.VersionBumpPatch, hyprconfig.VersionBumpMinor, hyprconfig.VersionBumpMajor:
+	default:
+		mserve.WriteError(w, r, http.StatusBadRequest, "version_bump must be one of patch, minor, major")
+		return
+	}
+
+	// Fetch the existing config
+	existing, err := h.configManager.GetConfig(r.Context(), configID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	updates := updatesBody.updates(existing)
+	if len(updates) == 0 {
+		mserve.WriteBody(w, r, map[string]string{"status": "no changes"})
+		return
+	}
+
+	opts := hyprconfig.UpdateOptions{
+		VersionBump: updatesBody.VersionBump,
+		Changelog:   updatesBody.ChangelogMessage,
+	}
+	if err := h.configManager.UpdateConfig(r.Context(), configID, updates, opts); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, StatusResponse{Status: "updated", Warnings: h.changeWarnings(r.Context(), configID)})
+}
+
+func (h *Handler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	if err := h.configManager.DeleteConfig(r.Context(), configID); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "deleted"})
+}
+
+func (h *Handler) DeprecateConfig(w http.ResponseWriter, r *http.Request) {
+	configID := mserve.PathParam(r, "config_id")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+		return
+	}
+
+	body, err := mserve.ReadBody[DeprecateConfigRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.configManager.DeprecateConfig(r.Context(), configID, body.SuccessorID, body.Message); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, StatusResponse{Status: "deprecated"})
+}
+
+func (h *Handler) ListConfigs(w http.ResponseWriter, r *http.Request) {
+	r, page, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListConfigs(r.Context(), page, limit, nil)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
 }
 
-func (h *Handler) FavoriteConfig(w http.ResponseWriter, r *http.Request) {
-	configID := r.URL.Query().Get("config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+func (h *Handler) ImportAllowedPrograms(w http.ResponseWriter, r *http.Request) {
+	upsert := false
+	if v := mserve.QueryParam(r, "upsert"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			mserve.WriteError(w, r, http.StatusBadRequest, "upsert must be a boolean")
+			return
+		}
+		upsert = parsed
+	}
+
+	body, err := mserve.ReadBodyArray[hyprconfig.AllowedPrograms](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.configManager.FavoriteConfig(r.Context(), configID); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	programs := make([]hyprconfig.AllowedPrograms, 0, len(body))
+	for _, p := range body {
+		if p != nil {
+			programs = append(programs, *p)
+		}
+	}
+
+	results, err := h.configManager.ImportAllowedPrograms(r.Context(), programs, upsert)
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "favorited"})
+	mserve.WriteBody(w, r, results)
 }
 
-func (h *Handler) UnfavoriteConfig(w http.ResponseWriter, r *http.Request) {
-	configID := r.URL.Query().Get("config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+func (h *Handler) ListAllowedPrograms(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := h.pageParams(w, r, 50)
+	if !ok {
 		return
 	}
 
-	if err := h.configManager.UnfavoriteConfig(r.Context(), configID); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	filters := hyprconfig.AllowedProgramFilters{
+		Prefix:   mserve.QueryParam(r, "prefix"),
+		Category: mserve.QueryParam(r, "category"),
+	}
+
+	result, err := h.configManager.ListAllowedProgramsPaged(r.Context(), page, limit, filters)
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "unfavorited"})
+	mserve.WriteBody(w, r, result)
 }
 
-func (h *Handler) ApplyConfig(w http.ResponseWriter, r *http.Request) {
-	configID := r.URL.Query().Get("config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+func (h *Handler) GetAllowedProgram(w http.ResponseWriter, r *http.Request) {
+	program, err := h.configManager.GetAllowedProgram(r.Context(), mserve.PathParam(r, "name"))
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	if err := h.configManager.ApplyConfig(r.Context(), configID); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	mserve.WriteBody(w, r, program)
+}
+
+func (h *Handler) AddAllowedProgram(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[AddAllowedProgramRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	program, err := h.configManager.AddAllowedProgram(r.Context(), body.ProgramName)
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "applied"})
+	mserve.WriteBody(w, r, program)
 }
 
-func (h *Handler) GetAppliedConfig(w http.ResponseWriter, r *http.Request) {
-	cfg, err := h.configManager.GetAppliedConfig(r.Context())
-	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+func (h *Handler) RemoveAllowedProgram(w http.ResponseWriter, r *http.Request) {
+	if err := h.configManager.RemoveAllowedProgram(r.Context(), mserve.PathParam(r, "name")); err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
+	w.WriteHeader(http.StatusOK)
+}
 
-	mserve.WriteBody(w, r, cfg)
+func (h *Handler) VerifyConfig(w http.ResponseWriter, r *http.Request) {
+	if err := h.configManager.VerifyConfig(r.Context(), mserve.PathParam(r, "config_id")); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	mserve.WriteBody(w, r, StatusResponse{Status: "verified"})
 }
 
-func (h *Handler) AddProgramConfig(w http.ResponseWriter, r *http.Request) {
-	prog, err := mserve.ReadBody[hyprconfig.HyprProgramConfig](r)
+func (h *Handler) UnverifyConfig(w http.ResponseWriter, r *http.Request) {
+	if err := h.configManager.UnverifyConfig(r.Context(), mserve.PathParam(r, "config_id")); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	mserve.WriteBody(w, r, StatusResponse{Status: "unverified"})
+}
+
+func (h *Handler) RequestAllowedProgram(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[hyprconfig.ProgramRequestBody](r)
 	if err != nil {
 		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
+	if body.ProgramName == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "program_name is required")
+		return
+	}
 
-	configID := mserve.PathParam(r, "config_id")
-	parentID := mserve.QueryParam(r, "parent_id")
+	req, err := h.configManager.RequestAllowedProgram(r.Context(), body.ProgramName, body.Reason)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
 
-	var parentPtr *string
-	if parentID != "" {
-		parentPtr = &parentID
+	mserve.WriteBody(w, r, req)
+}
+
+func (h *Handler) ListProgramRequests(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := h.pageParams(w, r, 10)
+	if !ok {
+		return
 	}
 
-	if err := h.configManager.AddProgramConfig(r.Context(), configID, *prog, parentPtr); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	result, err := h.configManager.ListProgramRequests(r.Context(), page, limit, mserve.QueryParam(r, "status"))
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "added"})
+	mserve.WriteBody(w, r, result)
 }
 
-func (h *Handler) RemoveProgramConfig(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ApproveProgramRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := mserve.PathParam(r, "request_id")
+	if requestID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "request_id is required")
+		return
+	}
+
+	program, err := h.configManager.ApproveProgramRequest(r.Context(), requestID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, program)
+}
+
+func (h *Handler) RejectProgramRequest(w http.ResponseWriter, r *http.Request) {
+	requestID := mserve.PathParam(r, "request_id")
+	if requestID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "request_id is required")
+		return
+	}
+
+	if err := h.configManager.RejectProgramRequest(r.Context(), requestID, mserve.QueryParam(r, "note")); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "rejected"})
+}
+
+func (h *Handler) GetChangelog(w http.ResponseWriter, r *http.Request) {
 	configID := mserve.PathParam(r, "config_id")
-	progID := mserve.QueryParam(r, "prog_id")
-	if progID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "prog_id is required")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
 		return
 	}
 
-	if err := h.configManager.RemoveProgramConfig(r.Context(), configID, progID); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	page, limit, ok := h.pageParams(w, r, 10)
+	if !ok {
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "removed"})
+	result, err := h.configManager.GetChangelog(r.Context(), configID, page, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
 }
 
-func (h *Handler) UpdateProgramConfig(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
 	configID := mserve.PathParam(r, "config_id")
-	progID := mserve.QueryParam(r, "prog_id")
-	if progID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "prog_id is required")
+	if configID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
 		return
 	}
 
-	updates, err := mserve.ReadBody[hyprconfig.HyprProgramConfig](r)
+	page, limit, ok := h.pageParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListAuditLog(r.Context(), configID, page, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, result)
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[hyprconfig.WebhookRequest](r)
 	if err != nil {
 		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.configManager.UpdateProgramConfig(r.Context(), configID, progID, *updates); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	hook, err := h.configManager.CreateWebhook(r.Context(), *body)
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "updated"})
+	mserve.WriteBody(w, r, hook)
 }
 
-func (h *Handler) MoveProgramConfig(w http.ResponseWriter, r *http.Request) {
-	configID := mserve.PathParam(r, "config_id")
-	progID := mserve.QueryParam(r, "prog_id")
-	if progID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "prog_id is required")
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.configManager.ListWebhooks(r.Context())
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	newParentID := mserve.QueryParam(r, "new_parent_id")
-	var parentPtr *string
-	if newParentID != "" {
-		parentPtr = &newParentID
+	mserve.WriteBody(w, r, hooks)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := mserve.PathParam(r, "webhook_id")
+	if webhookID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "webhook_id is required")
+		return
 	}
 
-	if err := h.configManager.MoveProgramConfig(r.Context(), configID, progID, parentPtr); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	if err := h.configManager.DeleteWebhook(r.Context(), webhookID); err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "moved"})
+	mserve.WriteBody(w, r, map[string]string{"status": "deleted"})
 }
 
-func (h *Handler) ListFavorites(w http.ResponseWriter, r *http.Request) {
-	page, limit := mserve.QueryParams(r, 10)
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID := mserve.PathParam(r, "webhook_id")
+	if webhookID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "webhook_id is required")
+		return
+	}
 
-	result, err := h.configManager.ListFavorites(r.Context(), page, limit)
+	page, limit, ok := h.pageParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	result, err := h.configManager.ListWebhookDeliveries(r.Context(), webhookID, page, limit)
 	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+		writeDomainError(w, r, err)
 		return
 	}
 
 	mserve.WriteBody(w, r, result)
 }
 
-func (h *Handler) CountUsersUsingConfig(w http.ResponseWriter, r *http.Request) {
-	configID := mserve.PathParam(r, "config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[CreateAPIKeyRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	count, err := h.configManager.CountUsersUsingConfig(r.Context(), configID)
+	expiry := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	key, err := h.configManager.CreateAPIKey(r.Context(), body.Name, body.Scopes, expiry)
 	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]int64{"count": count})
+	mserve.WriteBody(w, r, key)
 }
-func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
-	configID := mserve.PathParam(r, "config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.configManager.ListAPIKeys(r.Context())
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, keys)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := mserve.PathParam(r, "key_id")
+	if keyID == "" {
+		mserve.WriteError(w, r, http.StatusBadRequest, "key_id is required")
+		return
+	}
+
+	if err := h.configManager.RevokeAPIKey(r.Context(), keyID); err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	cfg, err := h.configManager.GetConfig(r.Context(), configID)
+	mserve.WriteBody(w, r, map[string]string{"status": "revoked"})
+}
+
+func (h *Handler) GetQuotaUsage(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.configManager.GetQuotaUsage(r.Context())
 	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, cfg)
+	mserve.WriteBody(w, r, usage)
 }
 
-func (h *Handler) UpdateConfig(w http.ResponseWriter, r *http.Request) {
-	configID := mserve.PathParam(r, "config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+func (h *Handler) GetAuthorStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.configManager.GetAuthorStats(r.Context(), mserve.PathParam(r, "user_id"))
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	// Read incoming updates
-	updatesBody, err := mserve.ReadBody[hyprconfig.HyprConfig](r)
+	mserve.WriteBody(w, r, stats)
+}
+
+// ListUserConfigs lists the public configs of a user along with their author stats. Private
+// configs are left out even for their owner, who lists them with /configs/mine.
+func (h *Handler) ListUserConfigs(w http.ResponseWriter, r *http.Request) {
+	r, page, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
+	}
+
+	userID := mserve.PathParam(r, "user_id")
+	public := false
+	configs, err := h.configManager.ListConfigsWithFilters(r.Context(), page, limit, hyprconfig.ConfigSearchFilters{
+		OwnerID: userID,
+		Private: &public,
+	}, nil)
 	if err != nil {
-		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		writeDomainError(w, r, err)
+		return
+	}
+	stats, err := h.configManager.GetAuthorStats(r.Context(), userID)
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	// Fetch the existing config
-	existing, err := h.configManager.GetConfig(r.Context(), configID)
+	mserve.WriteBody(w, r, UserConfigsResponse{Configs: configs, Stats: stats})
+}
+
+func (h *Handler) FollowAuthor(w http.ResponseWriter, r *http.Request) {
+	if err := h.configManager.FollowAuthor(r.Context(), mserve.PathParam(r, "user_id")); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "following"})
+}
+
+func (h *Handler) UnfollowAuthor(w http.ResponseWriter, r *http.Request) {
+	if err := h.configManager.UnfollowAuthor(r.Context(), mserve.PathParam(r, "user_id")); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, map[string]string{"status": "unfollowed"})
+}
+
+func (h *Handler) ListFollowing(w http.ResponseWriter, r *http.Request) {
+	following, err := h.configManager.ListFollowing(r.Context())
 	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+		writeDomainError(w, r, err)
 		return
 	}
 
-	// Build a bson.M with only changed fields
-	updates := bson.M{}
-	if updatesBody.Title != "" && updatesBody.Title != existing.Title {
-		updates["title"] = updatesBody.Title
+	mserve.WriteBody(w, r, following)
+}
+
+func (h *Handler) ListFollowedFeed(w http.ResponseWriter, r *http.Request) {
+	r, page, limit, ok := h.listParams(w, r, 10)
+	if !ok {
+		return
 	}
-	if updatesBody.Description != "" && updatesBody.Description != existing.Description {
-		updates["description"] = updatesBody.Description
+
+	result, err := h.configManager.ListFollowedFeed(r.Context(), page, limit)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
 	}
-	if len(updatesBody.ProgramConfigs) > 0 {
-		updates["program_configs"] = updatesBody.ProgramConfigs
+
+	mserve.WriteBody(w, r, result)
+}
+
+func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	r, page, limit, ok := h.listParams(w, r, 20)
+	if !ok {
+		return
 	}
-	if updatesBody.Private != existing.Private {
-		updates["private"] = updatesBody.Title
+	unreadOnly := false
+	if v := mserve.QueryParam(r, "unread_only"); v != "" {
+		var err error
+		if unreadOnly, err = strconv.ParseBool(v); err != nil {
+			mserve.WriteError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid unread_only %q: must be true or false", v))
+			return
+		}
 	}
-	if len(updatesBody.Tags) > 0 && !hyprconfig.StringSlicesEqual(updatesBody.Tags, existing.Tags) {
-		updates["tags"] = updatesBody.Tags
+
+	result, err := h.configManager.ListNotifications(r.Context(), page, limit, unreadOnly)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
 	}
-	// add any other fields you want to update here...
 
-	if len(updates) == 0 {
-		mserve.WriteBody(w, r, map[string]string{"status": "no changes"})
+	mserve.WriteBody(w, r, result)
+}
+
+func (h *Handler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[MarkNotificationsReadRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := h.configManager.UpdateConfig(r.Context(), configID, updates); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	marked, err := h.configManager.MarkNotificationsRead(r.Context(), body.IDs)
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "updated"})
+	mserve.WriteBody(w, r, MarkNotificationsReadResponse{Marked: marked})
 }
 
-func (h *Handler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
-	configID := mserve.PathParam(r, "config_id")
-	if configID == "" {
-		mserve.WriteError(w, r, http.StatusBadRequest, "config_id is required")
+func (h *Handler) CountUnreadNotifications(w http.ResponseWriter, r *http.Request) {
+	unread, err := h.configManager.CountUnreadNotifications(r.Context())
+	if err != nil {
+		writeDomainError(w, r, err)
 		return
 	}
 
-	if err := h.configManager.DeleteConfig(r.Context(), configID); err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+	mserve.WriteBody(w, r, UnreadNotificationsResponse{Unread: unread})
+}
+
+func (h *Handler) SetUserQuota(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[SetUserQuotaRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	mserve.WriteBody(w, r, map[string]string{"status": "deleted"})
+	usage, err := h.configManager.SetUserQuota(r.Context(), mserve.PathParam(r, "user_id"), body.QuotaBytes)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+
+	mserve.WriteBody(w, r, usage)
 }
 
-func (h *Handler) ListConfigs(w http.ResponseWriter, r *http.Request) {
-	page, limit := mserve.QueryParams(r, 10)
+func (h *Handler) SetUserConfigLimit(w http.ResponseWriter, r *http.Request) {
+	body, err := mserve.ReadBody[SetUserConfigLimitRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	result, err := h.configManager.ListConfigs(r.Context(), page, limit, nil)
+	limit, err := h.configManager.SetUserConfigLimit(r.Context(), mserve.PathParam(r, "user_id"), body.Limit)
 	if err != nil {
-		mserve.WriteError(w, r, http.StatusInternalServerError, err.Error())
+		writeDomainError(w, r, err)
 		return
 	}
 
-	mserve.WriteBody(w, r, result)
+	mserve.WriteBody(w, r, limit)
+}
+
+// RequestIDHeader carries the id of a request. It is echoed in the response and added to the
+// config manager logs.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds a request id taken from the client.
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware passes the request id of the X-Request-ID header to the config manager
+// through the context, generating one when the header is missing or not a short printable value.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(hyprconfig.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// apiKeysPath prefixes the API key endpoints, which need a real session.
+const apiKeysPath = "/account/api-keys"
+
+// APIKeyMiddleware signs in requests that carry "Authorization: Bearer hcm_..." as the owner of
+// that API key, so the config manager sees them like a session. The key needs the scope of the
+// matched route (see requiredScope) and cannot be used on the API key endpoints themselves.
+// Other requests pass through untouched. It must run after the session middleware, which would
+// otherwise replace the user.
+func (h *Handler) APIKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if len(auth) < len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := strings.TrimSpace(auth[len("Bearer "):])
+		if !strings.HasPrefix(token, hyprconfig.APIKeyPrefix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key, err := h.configManager.AuthenticateAPIKey(r.Context(), token)
+		if err != nil {
+			writeDomainError(w, r, err)
+			return
+		}
+
+		var path string
+		if route := mux.CurrentRoute(r); route != nil {
+			path, _ = route.GetPathTemplate()
+		}
+		if strings.HasPrefix(path, apiKeysPath) {
+			mserve.WriteError(w, r, http.StatusForbidden, "api keys cannot manage api keys, sign in instead")
+			return
+		}
+		if scope := requiredScope(r.Method, path); !key.HasScope(scope) {
+			mserve.WriteError(w, r, http.StatusForbidden, fmt.Sprintf("api key is missing the %s scope", scope))
+			return
+		}
+
+		user := &session.UserSessionData{UserID: key.OwnerID, SignedIn: true}
+		if key.ExpiresAt != nil {
+			user.ExpiresAt = key.ExpiresAt.Unix()
+		}
+		next.ServeHTTP(w, r.WithContext(user.WithContext(r.Context())))
+	})
+}
+
+// requiredScope returns the API key scope needed to call the route with path template path.
+func requiredScope(method, path string) string {
+	switch {
+	case path == "/config/{config_id}/apply":
+		return hyprconfig.APIKeyScopeApply
+	case method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions:
+		return hyprconfig.APIKeyScopeRead
+	}
+	return hyprconfig.APIKeyScopeWrite
 }
@@ -0,0 +1,1604 @@
+package hchandler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/mserve"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// testUserHeader and testRolesHeader stand in for the session middleware in tests.
+const (
+	testUserHeader  = "X-Test-User"
+	testRolesHeader = "X-Test-Roles"
+)
+
+// newTestServer serves the handler's endpoints over an in-memory config manager.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return newTestServerFor(t, hyprconfig.NewInMemoryConfigManager())
+}
+
+// newTestServerFor serves the handler's endpoints over m, for tests that also use m directly.
+// setup configures the handler before its endpoints are registered.
+func newTestServerFor(t *testing.T, m hyprconfig.ConfigManager, setup ...func(h *Handler)) *httptest.Server {
+	t.Helper()
+
+	h, err := NewHandler(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range setup {
+		fn(h)
+	}
+
+	router := mux.NewRouter()
+	for _, ep := range h.GetEndpoints() {
+		router.HandleFunc(ep.Path, ep.Handler).Methods(ep.Methods...)
+	}
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := r.Header.Get(testUserHeader); id != "" {
+				user := &session.UserSessionData{UserID: id, SignedIn: true}
+				if roles := r.Header.Get(testRolesHeader); roles != "" {
+					user.Roles = strings.Split(roles, ",")
+				}
+				r = r.WithContext(user.WithContext(r.Context()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(h.APIKeyMiddleware)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// do sends a request as user (anonymous when empty) and returns the status and body.
+func do(t *testing.T, srv *httptest.Server, method, path, user string, body any) (int, []byte) {
+	t.Helper()
+
+	req := newJSONRequest(t, srv, method, path, body)
+	if user != "" {
+		req.Header.Set(testUserHeader, user)
+		if user == "admin" {
+			req.Header.Set(testRolesHeader, "admin")
+		}
+	}
+	return send(t, req)
+}
+
+// doWithAPIKey sends a request authenticated with an API key token.
+func doWithAPIKey(t *testing.T, srv *httptest.Server, method, path, token string, body any) (int, []byte) {
+	t.Helper()
+
+	req := newJSONRequest(t, srv, method, path, body)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return send(t, req)
+}
+
+func newJSONRequest(t *testing.T, srv *httptest.Server, method, path string, body any) *http.Request {
+	t.Helper()
+
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, srv.URL+path, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func send(t *testing.T, req *http.Request) (int, []byte) {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, out
+}
+
+func decode[T any](t *testing.T, raw []byte) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return v
+}
+
+func createConfig(t *testing.T, srv *httptest.Server, user string, cfg hyprconfig.HyprConfig) hyprconfig.HyprConfig {
+	t.Helper()
+	status, body := do(t, srv, http.MethodPost, "/config/new", user, cfg)
+	if status != http.StatusOK {
+		t.Fatalf("create config: %d %s", status, body)
+	}
+	return decode[hyprconfig.HyprConfig](t, body)
+}
+
+// withTerminal adds the single program config every config needs to validate.
+func withTerminal(cfg hyprconfig.HyprConfig) hyprconfig.HyprConfig {
+	cfg.ProgramConfigs = append(cfg.ProgramConfigs, hyprconfig.HyprProgramConfig{Title: "term", Program: "kitty"})
+	return cfg
+}
+
+// failingManager fails every GetConfig call the way a broken database would.
+type failingManager struct {
+	hyprconfig.ConfigManager
+}
+
+func (failingManager) GetConfig(context.Context, string) (*hyprconfig.HyprConfig, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingManager) GetAppliedConfig(context.Context) (*hyprconfig.HyprConfig, error) {
+	return nil, fmt.Errorf("failed to load applied config state: %w", context.DeadlineExceeded)
+}
+
+func TestDomainErrorStatus(t *testing.T) {
+	srv := newTestServer(t)
+	private := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "secret", Private: true}))
+
+	for _, tc := range []struct {
+		name         string
+		method, path string
+		user         string
+		body         any
+		want         int
+	}{
+		{"unauthorized", http.MethodPost, "/config/new", "", withTerminal(hyprconfig.HyprConfig{Title: "anon"}), http.StatusUnauthorized},
+		{"forbidden", http.MethodGet, "/config/" + private.ID, "bob", nil, http.StatusForbidden},
+		{"not found", http.MethodGet, "/config/missing", "bob", nil, http.StatusNotFound},
+		{"missing program", http.MethodGet, "/config/" + private.ID + "/program/missing", "alice", nil, http.StatusNotFound},
+		{"validation", http.MethodPost, "/config/new", "alice", hyprconfig.HyprConfig{Title: "empty"}, http.StatusUnprocessableEntity},
+		{"bad body", http.MethodPost, "/config/new", "alice", "not a config", http.StatusBadRequest},
+	} {
+		if status, body := do(t, srv, tc.method, tc.path, tc.user, tc.body); status != tc.want {
+			t.Errorf("%s: got %d, want %d (%s)", tc.name, status, tc.want, body)
+		}
+	}
+
+	broken := newTestServerFor(t, failingManager{hyprconfig.NewInMemoryConfigManager()})
+	if status, _ := do(t, broken, http.MethodGet, "/config/any", "", nil); status != http.StatusInternalServerError {
+		t.Errorf("storage failure: got %d, want 500", status)
+	}
+}
+
+func TestConfigLifecycle(t *testing.T) {
+	srv := newTestServer(t)
+
+	if status, _ := do(t, srv, http.MethodPost, "/config/new", "", hyprconfig.HyprConfig{Title: "anon"}); status == http.StatusOK {
+		t.Fatal("anonymous create should fail")
+	}
+
+	created := createConfig(t, srv, "alice", hyprconfig.HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []hyprconfig.HyprProgramConfig{
+			{ID: "term", Title: "term", Program: "Kitty", FileContent: hyprconfig.FileContent{Data: []byte("font_size 12\n"), FileType: hyprconfig.FileTypeConfig}},
+		},
+	})
+	if created.ID == "" || created.OwnerID != "alice" || created.ProgramConfigs[0].Program != "kitty" {
+		t.Fatalf("unexpected created config: %+v", created)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/config/"+created.ID, "", nil)
+	if status != http.StatusOK || decode[hyprconfig.HyprConfig](t, body).Title != "rice" {
+		t.Fatalf("public get: %d %s", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/config/"+created.ID+"/program/term/file", "", nil)
+	if status != http.StatusOK || string(body) != "font_size 12\n" {
+		t.Fatalf("program file: %d %q", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodDelete, "/config/"+created.ID, "bob", nil); status == http.StatusOK {
+		t.Fatal("non-owner delete should fail")
+	}
+	if status, body := do(t, srv, http.MethodDelete, "/config/"+created.ID, "alice", nil); status != http.StatusOK {
+		t.Fatalf("owner delete: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/"+created.ID, "alice", nil); status == http.StatusOK {
+		t.Fatal("deleted config is still readable")
+	}
+}
+
+func TestFavoriteApplyAndMyConfigs(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	createConfig(t, srv, "bob", withTerminal(hyprconfig.HyprConfig{Title: "bobs rice"}))
+	base := "/config/" + cfg.ID
+
+	if status, _ := do(t, srv, http.MethodPost, base+"/favorite", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous favorite: got %d, want 401", status)
+	}
+	if status, body := do(t, srv, http.MethodPost, base+"/favorite", "bob", nil); status != http.StatusOK {
+		t.Fatalf("favorite: %d %s", status, body)
+	}
+	status, body := do(t, srv, http.MethodGet, "/config/favorites", "bob", nil)
+	if favs := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || favs.Total != 1 || favs.Items[0].ID != cfg.ID {
+		t.Errorf("favorites after favorite: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodDelete, base+"/favorite", "bob", nil); status != http.StatusOK {
+		t.Fatalf("unfavorite: %d %s", status, body)
+	}
+	_, body = do(t, srv, http.MethodGet, "/config/favorites", "bob", nil)
+	if favs := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); favs.Total != 0 {
+		t.Errorf("favorites after unfavorite: %s", body)
+	}
+
+	if status, _ := do(t, srv, http.MethodGet, "/config/applied", "bob", nil); status != http.StatusNotFound {
+		t.Errorf("applied config before apply: got %d, want 404", status)
+	}
+	if status, body := do(t, srv, http.MethodPost, base+"/apply", "bob", nil); status != http.StatusOK {
+		t.Fatalf("apply: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/config/applied", "bob", nil)
+	if status != http.StatusOK || decode[hyprconfig.HyprConfig](t, body).ID != cfg.ID {
+		t.Errorf("applied config: %d %s", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/configs/mine", "bob", nil)
+	if mine := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || mine.Total != 1 || mine.Items[0].Title != "bobs rice" {
+		t.Errorf("my configs: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/configs/mine", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous my configs: got %d, want 401", status)
+	}
+}
+
+func TestConfigFavoritersEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	base := "/config/" + cfg.ID
+
+	for _, user := range []string{"bob", "carol"} {
+		if status, body := do(t, srv, http.MethodPost, base+"/favorite", user, nil); status != http.StatusOK {
+			t.Fatalf("favorite: %d %s", status, body)
+		}
+	}
+
+	status, body := do(t, srv, http.MethodGet, base+"/favorites/count", "", nil)
+	if status != http.StatusOK || decode[map[string]int64](t, body)["count"] != 2 {
+		t.Errorf("count: %d %s", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodGet, base+"/favorites?limit=1", "alice", nil)
+	if page := decode[mserve.Page[hyprconfig.ConfigFavoriter]](t, body); status != http.StatusOK || page.Total != 2 || len(page.Items) != 1 {
+		t.Errorf("favoriters: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/favorites", "bob", nil); status != http.StatusForbidden {
+		t.Errorf("non-owner favoriters: got %d, want 403", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/favorites", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("anonymous favoriters: got %d, want 401", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/missing/favorites/count", "", nil); status != http.StatusNotFound {
+		t.Errorf("missing config count: got %d, want 404", status)
+	}
+}
+
+func TestPrivateConfigVisibility(t *testing.T) {
+	srv := newTestServer(t)
+	private := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "secret", Private: true}))
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "public"}))
+
+	for _, user := range []string{"", "bob"} {
+		if status, _ := do(t, srv, http.MethodGet, "/config/"+private.ID, user, nil); status == http.StatusOK {
+			t.Errorf("user %q could read a private config", user)
+		}
+		_, body := do(t, srv, http.MethodGet, "/configs", user, nil)
+		if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); page.Total != 1 {
+			t.Errorf("user %q lists %d configs, want 1", user, page.Total)
+		}
+	}
+	for _, user := range []string{"alice", "admin"} {
+		if status, body := do(t, srv, http.MethodGet, "/config/"+private.ID, user, nil); status != http.StatusOK {
+			t.Errorf("user %q: %d %s", user, status, body)
+		}
+	}
+}
+
+func TestShareLinkEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "secret", Private: true}))
+	base := "/config/" + cfg.ID + "/share-links"
+
+	status, body := do(t, srv, http.MethodPost, base, "alice", CreateShareLinkRequest{ExpiresInHours: 24})
+	if status != http.StatusOK {
+		t.Fatalf("create link: %d %s", status, body)
+	}
+	link := decode[hyprconfig.CreatedShareLink](t, body)
+	if status, _ := do(t, srv, http.MethodPost, base, "bob", nil); status != http.StatusForbidden {
+		t.Errorf("non-owner create: got %d, want 403", status)
+	}
+	if status, _ := do(t, srv, http.MethodPost, base, "alice", CreateShareLinkRequest{ExpiresInHours: 24 * 365}); status != http.StatusUnprocessableEntity {
+		t.Errorf("too long expiry: got %d, want 422", status)
+	}
+
+	for _, path := range []string{link.URL, "/config/" + cfg.ID + "/export?share=" + link.Token} {
+		if status, body := do(t, srv, http.MethodGet, path, "", nil); status != http.StatusOK {
+			t.Errorf("anonymous %s: %d %s", path, status, body)
+		}
+	}
+
+	status, body = do(t, srv, http.MethodGet, base, "alice", nil)
+	if links := decode[[]map[string]any](t, body); status != http.StatusOK || len(links) != 1 || links[0]["token"] != nil {
+		t.Errorf("list links: %d %s", status, body)
+	}
+
+	if status, body := do(t, srv, http.MethodDelete, base+"/"+link.ID, "alice", nil); status != http.StatusOK {
+		t.Fatalf("revoke: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, link.URL, "", nil); status != http.StatusNotFound {
+		t.Errorf("revoked link: got %d, want 404", status)
+	}
+}
+
+func TestQuotaEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	rice := hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "term", Program: "kitty", FileContent: hyprconfig.FileContent{Data: []byte("font_size 12\n"), FileType: hyprconfig.FileTypeConfig}},
+	}}
+
+	if status, _ := do(t, srv, http.MethodPut, "/admin/users/bob/quota", "bob", SetUserQuotaRequest{QuotaBytes: 1 << 30}); status != http.StatusForbidden {
+		t.Errorf("non-admin override: got %d, want 403", status)
+	}
+	if status, body := do(t, srv, http.MethodPut, "/admin/users/bob/quota", "admin", SetUserQuotaRequest{QuotaBytes: 8}); status != http.StatusOK {
+		t.Fatalf("set quota: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodPost, "/config/new", "bob", rice); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("create over quota: got %d, want 413", status)
+	}
+	createConfig(t, srv, "alice", rice)
+
+	status, body := do(t, srv, http.MethodGet, "/account/quota", "bob", nil)
+	if usage := decode[hyprconfig.QuotaUsage](t, body); status != http.StatusOK || usage.QuotaBytes != 8 || usage.UsedBytes != 0 {
+		t.Errorf("bob's quota: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/account/quota", "alice", nil)
+	if usage := decode[hyprconfig.QuotaUsage](t, body); status != http.StatusOK || usage.UsedBytes != 13 || usage.Custom {
+		t.Errorf("alice's quota: %d %s", status, body)
+	}
+}
+
+func TestConfigLimitEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	rice := func(program string) hyprconfig.HyprConfig {
+		return hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{{Title: program, Program: program}}}
+	}
+
+	if status, _ := do(t, srv, http.MethodPut, "/admin/users/bob/config-limit", "bob", SetUserConfigLimitRequest{Limit: 1000}); status != http.StatusForbidden {
+		t.Errorf("non-admin override: got %d, want 403", status)
+	}
+	status, body := do(t, srv, http.MethodPut, "/admin/users/bob/config-limit", "admin", SetUserConfigLimitRequest{Limit: 1})
+	if limit := decode[hyprconfig.ConfigLimit](t, body); status != http.StatusOK || limit.Limit != 1 || !limit.Custom {
+		t.Fatalf("set config limit: %d %s", status, body)
+	}
+
+	createConfig(t, srv, "bob", rice("kitty"))
+	status, body = do(t, srv, http.MethodPost, "/config/new", "bob", rice("waybar"))
+	if resp := decode[ConfigLimitResponse](t, body); status != http.StatusForbidden || resp.Count != 1 || resp.Limit != 1 {
+		t.Errorf("create over limit: %d %s", status, body)
+	}
+}
+
+func TestDuplicateConfigEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	rice := hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "term", Program: "kitty", FileContent: hyprconfig.FileContent{Data: []byte("font_size 12\n"), FileType: hyprconfig.FileTypeConfig}},
+	}}
+	original := createConfig(t, srv, "alice", rice)
+
+	status, body := do(t, srv, http.MethodPost, "/config/new", "alice", rice)
+	if dup := decode[DuplicateConfigResponse](t, body); status != http.StatusConflict || dup.ExistingConfigID != original.ID {
+		t.Errorf("duplicate create: %d %s, want 409 with existing_config_id %s", status, body, original.ID)
+	}
+	if status, body := do(t, srv, http.MethodPost, "/config/new?force=true", "alice", rice); status != http.StatusOK {
+		t.Errorf("forced create: %d %s", status, body)
+	}
+	copied := createConfig(t, srv, "bob", rice)
+
+	status, body = do(t, srv, http.MethodGet, "/configs/similar?config_id="+original.ID, "", nil)
+	if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || len(page.Items) != 2 {
+		t.Errorf("similar configs: %d %s, want the forced copy and %s", status, body, copied.ID)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/configs/similar", "", nil); status != http.StatusBadRequest {
+		t.Errorf("missing config_id: got %d, want 400", status)
+	}
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := hyprconfig.HyprConfig{ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "term", Program: "kitty", EnvVars: map[string]string{"LD_PRELOAD": "/tmp/evil.so"}},
+		{Title: "bar", Program: "notabar"},
+	}}
+
+	status, body := do(t, srv, http.MethodPost, "/config/new", "alice", cfg)
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid config: %d %s", status, body)
+	}
+	resp := decode[ValidationErrorResponse](t, body)
+	var paths []string
+	for _, fe := range resp.Errors {
+		paths = append(paths, fe.Path+" "+fe.Code)
+	}
+	want := []string{"title required", "program_configs[0].env_vars.LD_PRELOAD invalid_env_var", "program_configs[1].program invalid_program"}
+	if !slices.Equal(paths, want) || resp.Status != http.StatusUnprocessableEntity || resp.Error == "" {
+		t.Errorf("errors = %v, want %v (%s)", paths, want, body)
+	}
+
+	created := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	status, body = do(t, srv, http.MethodPost, "/config/"+created.ID+"/program/add", "alice", hyprconfig.HyprProgramConfig{Title: "bar", Program: "notabar"})
+	if resp := decode[ValidationErrorResponse](t, body); status != http.StatusUnprocessableEntity || len(resp.Errors) != 1 || resp.Errors[0].Path != "program" {
+		t.Errorf("invalid program config: %d %s", status, body)
+	}
+}
+
+func TestUpdateConfigFields(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice", Description: "dark", Tags: []string{"nord"}}))
+	base := "/config/" + cfg.ID
+
+	update := func(body any) hyprconfig.HyprConfig {
+		t.Helper()
+		if status, raw := do(t, srv, http.MethodPatch, base, "alice", body); status != http.StatusOK {
+			t.Fatalf("update %v: %d %s", body, status, raw)
+		}
+		_, raw := do(t, srv, http.MethodGet, base, "alice", nil)
+		return decode[hyprconfig.HyprConfig](t, raw)
+	}
+
+	if got := update(map[string]any{"private": true}); !got.Private || got.Title != "rice" {
+		t.Errorf("after making private: private=%v title=%q", got.Private, got.Title)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base, "bob", nil); status != http.StatusForbidden {
+		t.Errorf("private config read by another user: got %d, want 403", status)
+	}
+	if got := update(map[string]any{"private": false}); got.Private {
+		t.Error("config is still private after making it public")
+	}
+
+	got := update(map[string]any{"tags": []string{}, "description": ""})
+	if len(got.Tags) != 0 || got.Description != "" {
+		t.Errorf("after clearing: tags=%v description=%q", got.Tags, got.Description)
+	}
+	if got.Title != "rice" {
+		t.Errorf("absent title was changed to %q", got.Title)
+	}
+
+	status, _ := do(t, srv, http.MethodPut, base, "alice", map[string]any{"program_configs": []hyprconfig.HyprProgramConfig{{Program: "waybar"}}})
+	if status != http.StatusBadRequest {
+		t.Errorf("update with program_configs: got %d, want 400", status)
+	}
+}
+
+func TestListConfigsPagination(t *testing.T) {
+	srv := newTestServer(t)
+	for _, title := range []string{"a", "b", "c"} {
+		createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: title}))
+	}
+
+	seen := map[string]bool{}
+	for page := 1; page <= 3; page++ {
+		_, body := do(t, srv, http.MethodGet, "/configs?limit=1&page="+strconv.Itoa(page), "", nil)
+		result := decode[mserve.Page[hyprconfig.HyprConfig]](t, body)
+		if len(result.Items) != 1 || result.Total != 3 {
+			t.Fatalf("page %d: %+v", page, result)
+		}
+		seen[result.Items[0].ID] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("pages overlapped, saw %d distinct configs", len(seen))
+	}
+}
+
+func TestPageParams(t *testing.T) {
+	srv := newTestServer(t)
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+
+	status, body := do(t, srv, http.MethodGet, "/configs?limit=100000", "", nil)
+	page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body)
+	if status != http.StatusOK || page.Limit != DefaultMaxPageLimit || page.Page != 1 || page.Total != 1 {
+		t.Errorf("oversized limit: %d %s, want limit %d", status, body, DefaultMaxPageLimit)
+	}
+
+	for _, path := range []string{
+		"/configs?page=0",
+		"/configs?limit=-5",
+		"/configs?page=two",
+		"/config/search?limit=0",
+		"/configs/mine?page=-1",
+		"/config/favorites?limit=0",
+	} {
+		if status, _ := do(t, srv, http.MethodGet, path, "alice", nil); status != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", path, status)
+		}
+	}
+}
+
+func TestListConfigsOmitFileContent(t *testing.T) {
+	srv := newTestServer(t)
+
+	// A typical rice: a hyprland config, a waybar stylesheet and a wallpaper.
+	hyprland := bytes.Repeat([]byte("bind = SUPER, Return, exec, kitty\n"), 250)
+	style := bytes.Repeat([]byte("#workspaces button { padding: 0 5px; }\n"), 100)
+	wallpaper := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(wallpaper)
+	wallpaper = append([]byte("\x89PNG\r\n\x1a\n"), wallpaper...)
+	wantSize := int64(len(hyprland) + len(style) + len(wallpaper))
+
+	for i := range 5 {
+		// One owner each, the same content twice for one owner would be a duplicate
+		createConfig(t, srv, "user"+strconv.Itoa(i), hyprconfig.HyprConfig{Title: "rice " + strconv.Itoa(i), ProgramConfigs: []hyprconfig.HyprProgramConfig{
+			{Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{Data: hyprland, FileType: hyprconfig.FileTypeConfig}},
+			{Title: "bar", Program: "waybar", FileContent: hyprconfig.FileContent{Data: style, FileType: hyprconfig.FileTypeConfig}},
+			{Title: "wall", Program: "hyprpaper", FileContent: hyprconfig.FileContent{Data: wallpaper, FileType: hyprconfig.FileTypeImage}},
+		}})
+	}
+
+	_, slim := do(t, srv, http.MethodGet, "/configs?limit=5", "", nil)
+	for _, cfg := range decode[mserve.Page[hyprconfig.HyprConfig]](t, slim).Items {
+		if cfg.TotalSizeBytes != wantSize {
+			t.Errorf("%s: total_size_bytes = %d, want %d", cfg.Title, cfg.TotalSizeBytes, wantSize)
+		}
+		for _, pc := range cfg.ProgramConfigs {
+			if len(pc.FileContent.Data) != 0 || pc.FileContent.Hash == "" {
+				t.Errorf("%s/%s: data should be left out and the hash kept, got %+v", cfg.Title, pc.Program, pc.FileContent)
+			}
+		}
+	}
+
+	_, full := do(t, srv, http.MethodGet, "/configs?limit=5&include_content=true", "", nil)
+	page := decode[mserve.Page[hyprconfig.HyprConfig]](t, full)
+	if len(page.Items) != 5 || !bytes.Equal(page.Items[0].ProgramConfigs[2].FileContent.Data, wallpaper) {
+		t.Fatalf("include_content=true did not return the file data")
+	}
+	t.Logf("list of 5 configs: %d bytes without content, %d bytes with", len(slim), len(full))
+	if len(slim)*100 > len(full) {
+		t.Errorf("list without content is %d bytes, want under 1%% of the %d bytes with content", len(slim), len(full))
+	}
+
+	_, body := do(t, srv, http.MethodGet, "/configs?limit=50&include_content=true", "", nil)
+	if limit := decode[mserve.Page[hyprconfig.HyprConfig]](t, body).Limit; limit != maxContentPageLimit {
+		t.Errorf("include_content limit = %d, want %d", limit, maxContentPageLimit)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/configs?include_content=maybe", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid include_content: got %d, want 400", status)
+	}
+}
+
+func TestSearchConfigsPlatform(t *testing.T) {
+	srv := newTestServer(t)
+	createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "arch only", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "term", Program: "kitty", Platform: []string{"archlinux"}},
+	}})
+	createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "anywhere", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "term", Program: "kitty"},
+	}})
+
+	status, body := do(t, srv, http.MethodPost, "/config/search", "", hyprconfig.ConfigSearchFilters{Platform: "Fedora"})
+	page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body)
+	if status != http.StatusOK || page.Total != 1 || page.Items[0].Title != "anywhere" {
+		t.Errorf("fedora search: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodPost, "/config/search", "", hyprconfig.ConfigSearchFilters{Platform: "beos"}); status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid platform: got %d, want 422", status)
+	}
+}
+
+func TestSearchConfigsLicense(t *testing.T) {
+	srv := newTestServer(t)
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "mit", License: "MIT"}))
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "none"}))
+
+	for query, want := range map[string]string{"license=mit": "mit", "license=unspecified": "none"} {
+		status, body := do(t, srv, http.MethodGet, "/config/search?"+query, "", nil)
+		if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != 1 || page.Items[0].Title != want {
+			t.Errorf("%s: %d %s", query, status, body)
+		}
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?license=beerware", "", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid license: got %d, want 422", status)
+	}
+}
+
+func TestSearchConfigsDisplay(t *testing.T) {
+	srv := newTestServer(t)
+	dual := createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "dual", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{
+			Data: []byte("monitor = DP-1, 2560x1440@144, 0x0, 1\nmonitor = HDMI-A-1, 1920x1080, 2560x0, 1\nworkspace = 1, monitor:DP-1\n"), FileType: hyprconfig.FileTypeConfig,
+		}},
+	}})
+	createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "laptop", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{Data: []byte("monitor = eDP-1, 1920x1080, 0x0, 1\n"), FileType: hyprconfig.FileTypeConfig}},
+	}})
+
+	status, body := do(t, srv, http.MethodGet, "/config/"+dual.ID, "", nil)
+	if cfg := decode[hyprconfig.HyprConfig](t, body); status != http.StatusOK || cfg.Display == nil || len(cfg.Display.Monitors) != 2 || len(cfg.Display.Workspaces) != 1 {
+		t.Errorf("config detail: %d %s", status, body)
+	}
+
+	for query, want := range map[string]int{"monitors=2": 1, "monitors=1&resolution=1920x1080": 1, "resolution=1920x1080": 2, "resolution=3840x2160": 0} {
+		status, body := do(t, srv, http.MethodGet, "/config/search?"+query, "", nil)
+		if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != want {
+			t.Errorf("%s: %d %s, want %d configs", query, status, body, want)
+		}
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?monitors=two", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid monitors: got %d, want 400", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?resolution=4k", "", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid resolution: got %d, want 422", status)
+	}
+}
+
+func TestSearchConfigsPalette(t *testing.T) {
+	srv := newTestServer(t)
+	createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "mocha", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "term", Program: "kitty", FileContent: hyprconfig.FileContent{Data: []byte("background #1e1e2e\nforeground #cdd6f4\ncolor5 #cba6f7\n"), FileType: hyprconfig.FileTypeConfig}},
+	}})
+
+	status, body := do(t, srv, http.MethodGet, "/config/search?theme=dark&palette=c0a0f0,%231e1e2e", "", nil)
+	page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body)
+	if status != http.StatusOK || page.Total != 1 || len(page.Items[0].Palette) != 3 || page.Items[0].Theme != hyprconfig.ThemeDark {
+		t.Errorf("palette search: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/config/search?palette=c0a0f0&color_tolerance=5", "", nil)
+	if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != 0 {
+		t.Errorf("narrow tolerance: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?color_tolerance=close", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid color_tolerance: got %d, want 400", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?theme=dim", "", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid theme: got %d, want 422", status)
+	}
+}
+
+func TestSearchConfigsQueryParams(t *testing.T) {
+	srv := newTestServer(t)
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "Nord", Tags: []string{"dark", "minimal"}}))
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "Gruvbox", Tags: []string{"dark"}}))
+	createConfig(t, srv, "bob", withTerminal(hyprconfig.HyprConfig{Title: "Latte", Tags: []string{"light"}}))
+
+	search := func(query string) []string {
+		t.Helper()
+		status, body := do(t, srv, http.MethodGet, "/config/search?"+query, "", nil)
+		if status != http.StatusOK {
+			t.Fatalf("search %q: %d %s", query, status, body)
+		}
+		var titles []string
+		for _, cfg := range decode[mserve.Page[hyprconfig.HyprConfig]](t, body).Items {
+			titles = append(titles, cfg.Title)
+		}
+		return titles
+	}
+
+	if got := search("tags=dark,+minimal"); !slices.Equal(got, []string{"Nord"}) {
+		t.Errorf("tags = %v, want [Nord]", got)
+	}
+	if got := search("owner_id=alice&sort=title"); !slices.Equal(got, []string{"Gruvbox", "Nord"}) {
+		t.Errorf("owner sorted by title = %v, want [Gruvbox Nord]", got)
+	}
+	if got := search("q=latte&private=false&updated_from=2000-01-01T00:00:00Z"); !slices.Equal(got, []string{"Latte"}) {
+		t.Errorf("query = %v, want [Latte]", got)
+	}
+	if got := search("updated_to=946684800"); len(got) != 0 {
+		t.Errorf("updated before 2000 = %v, want none", got)
+	}
+
+	for _, query := range []string{"private=maybe", "updated_from=yesterday", "updated_to=1.5"} {
+		if status, _ := do(t, srv, http.MethodGet, "/config/search?"+query, "", nil); status != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", query, status)
+		}
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?sort=random", "", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("unknown sort: got %d, want 422", status)
+	}
+
+	// The body takes precedence over the query parameters.
+	_, body := do(t, srv, http.MethodPost, "/config/search?q=latte", "", hyprconfig.ConfigSearchFilters{Query: "nord"})
+	page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body)
+	if page.Total != 1 || page.Items[0].Title != "Nord" {
+		t.Errorf("POST search = %s, want only Nord", body)
+	}
+}
+
+func TestProgramConfigEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{ID: "hypr", Title: "hyprland", Program: "hyprland"},
+	}})
+	base := "/config/" + cfg.ID
+
+	bar := hyprconfig.HyprProgramConfig{ID: "bar", Title: "bar", Program: "waybar"}
+	if status, body := do(t, srv, http.MethodPost, base+"/program/add?parent_id=hypr", "alice", bar); status != http.StatusOK {
+		t.Fatalf("add program: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodPost, base+"/program/add", "bob", bar); status == http.StatusOK {
+		t.Fatal("non-owner add should fail")
+	}
+
+	status, body := do(t, srv, http.MethodGet, base+"/program/bar", "", nil)
+	if status != http.StatusOK || decode[hyprconfig.HyprProgramConfig](t, body).Program != "waybar" {
+		t.Fatalf("get nested program: %d %s", status, body)
+	}
+
+	if status, body := do(t, srv, http.MethodPut, base+"/program/move?prog_id=bar&changelog_message=flatten", "alice", nil); status != http.StatusOK {
+		t.Fatalf("move program: %d %s", status, body)
+	}
+	_, body = do(t, srv, http.MethodGet, base, "", nil)
+	if got := decode[hyprconfig.HyprConfig](t, body); len(got.ProgramConfigs) != 2 {
+		t.Errorf("after move: %d top-level programs, want 2", len(got.ProgramConfigs))
+	}
+
+	_, body = do(t, srv, http.MethodGet, base+"/changelog", "", nil)
+	entries := decode[mserve.Page[hyprconfig.ChangelogEntry]](t, body)
+	if entries.Total != 1 || entries.Items[0].Message != "flatten" {
+		t.Errorf("changelog: %s", body)
+	}
+
+	if status, body := do(t, srv, http.MethodDelete, base+"/program/remove?prog_id=bar", "alice", nil); status != http.StatusOK {
+		t.Fatalf("remove program: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/program/bar", "", nil); status == http.StatusOK {
+		t.Error("removed program is still readable")
+	}
+}
+
+func TestGalleryEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	base := "/config/" + cfg.ID + "/gallery"
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{1}, 64)...)
+
+	upload := func(user string, files ...[]byte) (int, []byte) {
+		t.Helper()
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for i, data := range files {
+			part, err := mw.CreateFormFile("image", "shot"+strconv.Itoa(i)+".png")
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write(data)
+		}
+		mw.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+base, &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set(testUserHeader, user)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	status, body := upload("alice", png, png)
+	added := decode[[]hyprconfig.GalleryImage](t, body)
+	if status != http.StatusOK || len(added) != 2 || added[1].URL != base+"/1" {
+		t.Fatalf("upload: %d %s", status, body)
+	}
+	_, body = do(t, srv, http.MethodGet, "/config/"+cfg.ID, "", nil)
+	if got := decode[hyprconfig.HyprConfig](t, body).GalleryPictures; !slices.Equal(got, []string{base + "/0", base + "/1"}) {
+		t.Errorf("gallery pictures = %v", got)
+	}
+
+	resp, err := http.Get(srv.URL + added[0].URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	served, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "image/png" || !bytes.Equal(served, png) {
+		t.Errorf("get image: %d %s, %d bytes", resp.StatusCode, resp.Header.Get("Content-Type"), len(served))
+	}
+
+	if status, _ := upload("bob", png); status != http.StatusForbidden {
+		t.Errorf("non-owner upload: got %d, want 403", status)
+	}
+	if status, _ := upload("alice", []byte("<svg></svg>")); status != http.StatusUnprocessableEntity {
+		t.Errorf("svg upload: got %d, want 422", status)
+	}
+	if status, _ := upload("alice"); status != http.StatusBadRequest {
+		t.Errorf("upload without files: got %d, want 400", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/first", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid index: got %d, want 400", status)
+	}
+
+	if status, _ := do(t, srv, http.MethodDelete, base+"/0", "bob", nil); status != http.StatusForbidden {
+		t.Errorf("non-owner delete: got %d, want 403", status)
+	}
+	if status, _ := do(t, srv, http.MethodDelete, base+"/0", "alice", nil); status != http.StatusOK {
+		t.Errorf("delete: got %d, want 200", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/0", "", nil); status != http.StatusNotFound {
+		t.Errorf("deleted image: got %d, want 404", status)
+	}
+}
+
+func TestDownloadProgramFile(t *testing.T) {
+	srv := newTestServer(t)
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{
+		Title:   "rice",
+		Private: true,
+		ProgramConfigs: []hyprconfig.HyprProgramConfig{
+			{ID: "term", Title: "term", Program: "kitty", InstallPath: "~/.config/kitty/kitty.conf",
+				FileContent: hyprconfig.FileContent{Data: []byte("font_size 12\n"), FileType: hyprconfig.FileTypeConfig}},
+			{ID: "wall", Title: "wall", Program: "hyprpaper", InstallPath: "~/Pictures/wall.png",
+				FileContent: hyprconfig.FileContent{Data: png, FileType: hyprconfig.FileTypeImage}},
+			{ID: "bar", Title: "bar", Program: "waybar", Files: []hyprconfig.FileEntry{
+				{TargetPath: "style.css", FileContent: hyprconfig.FileContent{Data: []byte("* { border: none; }"), FileType: hyprconfig.FileTypeText}},
+			}},
+			{ID: "launch", Title: "launch", Program: "wofi"},
+		},
+	})
+	base := "/config/" + cfg.ID + "/program/"
+
+	get := func(user, progID string) *http.Response {
+		t.Helper()
+		file := progID + "/file"
+		if prog, filePath, ok := strings.Cut(progID, ":"); ok {
+			file = prog + "/file?path=" + url.QueryEscape(filePath)
+		}
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+base+file, nil)
+		if user != "" {
+			req.Header.Set(testUserHeader, user)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := get("alice", "term")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "font_size 12\n" {
+		t.Fatalf("config file: %d %q", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("config file Content-Type = %q", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != "attachment; filename=kitty.conf" {
+		t.Errorf("config file Content-Disposition = %q", cd)
+	}
+
+	resp = get("alice", "wall")
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "image/png" {
+		t.Errorf("image: %d %q", resp.StatusCode, ct)
+	}
+
+	resp = get("alice", "bar:style.css")
+	body, _ = io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "* { border: none; }" {
+		t.Errorf("file by path: %d %q", resp.StatusCode, body)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != "attachment; filename=style.css" {
+		t.Errorf("file by path Content-Disposition = %q", cd)
+	}
+	if resp := get("alice", "bar:colors.css"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown path: got %d, want 404", resp.StatusCode)
+	}
+
+	if resp := get("alice", "launch"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("program config without data: got %d, want 404", resp.StatusCode)
+	}
+	if resp := get("bob", "term"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("private config file read by another user: got %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestConfigAuditLog(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	base := "/config/" + cfg.ID
+
+	if status, body := do(t, srv, http.MethodPut, base, "alice", map[string]string{"title": "riced"}); status != http.StatusOK {
+		t.Fatalf("update config: %d %s", status, body)
+	}
+
+	status, body := do(t, srv, http.MethodGet, base+"/audit", "alice", nil)
+	if status != http.StatusOK {
+		t.Fatalf("owner audit log: %d %s", status, body)
+	}
+	entries := decode[mserve.Page[hyprconfig.AuditEntry]](t, body)
+	if entries.Total != 2 || entries.Items[0].Action != hyprconfig.AuditUpdateConfig || entries.Items[1].Action != hyprconfig.AuditCreateConfig {
+		t.Errorf("audit log: %s", body)
+	}
+
+	if status, _ := do(t, srv, http.MethodGet, base+"/audit", "bob", nil); status == http.StatusOK {
+		t.Error("non-owner could read the audit log")
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/audit", "admin", nil); status != http.StatusOK {
+		t.Error("admin could not read the audit log")
+	}
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+
+	if status, body := do(t, srv, http.MethodPost, "/webhooks", "bob", hyprconfig.WebhookRequest{URL: "not a url", Secret: "s"}); status != http.StatusUnprocessableEntity {
+		t.Errorf("invalid webhook: %d %s", status, body)
+	}
+
+	status, body := do(t, srv, http.MethodPost, "/webhooks", "bob", hyprconfig.WebhookRequest{URL: "https://example.com/hook", Secret: "s3cret"})
+	if status != http.StatusOK {
+		t.Fatalf("create webhook: %d %s", status, body)
+	}
+	if strings.Contains(string(body), "s3cret") {
+		t.Errorf("webhook secret was returned: %s", body)
+	}
+	hook := decode[hyprconfig.Webhook](t, body)
+
+	status, body = do(t, srv, http.MethodGet, "/webhooks", "bob", nil)
+	if hooks := decode[[]hyprconfig.Webhook](t, body); status != http.StatusOK || len(hooks) != 1 || hooks[0].ID != hook.ID {
+		t.Errorf("list webhooks: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodGet, "/webhooks/"+hook.ID+"/deliveries", "bob", nil); status != http.StatusOK {
+		t.Errorf("list deliveries: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodDelete, "/webhooks/"+hook.ID, "carol", nil); status == http.StatusOK {
+		t.Error("another user could delete the webhook")
+	}
+	if status, body := do(t, srv, http.MethodDelete, "/webhooks/"+hook.ID, "bob", nil); status != http.StatusOK {
+		t.Errorf("delete webhook: %d %s", status, body)
+	}
+}
+
+func TestWatchAppliedConfig(t *testing.T) {
+	interval, heartbeat := hyprconfig.WatchPollInterval, sseHeartbeatInterval
+	hyprconfig.WatchPollInterval, sseHeartbeatInterval = 5*time.Millisecond, 20*time.Millisecond
+	t.Cleanup(func() { hyprconfig.WatchPollInterval, sseHeartbeatInterval = interval, heartbeat })
+
+	m := hyprconfig.NewInMemoryConfigManager()
+	srv := newTestServerFor(t, m)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+
+	if status, _ := do(t, srv, http.MethodGet, "/config/applied/watch", "", nil); status == http.StatusOK {
+		t.Error("anonymous watch was accepted")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/config/applied/watch", nil)
+	req.Header.Set(testUserHeader, "bob")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("watch: %d %s", resp.StatusCode, ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("stream ended: %v", lines.Err())
+		}
+		return lines.Text()
+	}
+	// nextEvent skips heartbeats and returns the name of the next event.
+	nextEvent := func() string {
+		t.Helper()
+		for {
+			if line, ok := strings.CutPrefix(next(), "event: "); ok {
+				next() // data
+				return line
+			}
+		}
+	}
+
+	if e := nextEvent(); e != hyprconfig.AppliedEventSnapshot {
+		t.Errorf("first event = %q", e)
+	}
+	sawHeartbeat := false
+	for !sawHeartbeat {
+		sawHeartbeat = next() == ": heartbeat"
+	}
+
+	bob := (&session.UserSessionData{UserID: "bob", SignedIn: true}).WithContext(context.Background())
+	if err := m.ApplyConfig(bob, cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if e := nextEvent(); e != hyprconfig.AppliedEventApplied {
+		t.Errorf("event after apply = %q", e)
+	}
+}
+
+func TestGetAppliedConfigErrors(t *testing.T) {
+	srv := newTestServer(t)
+	if status, body := do(t, srv, http.MethodGet, "/config/applied", "alice", nil); status != http.StatusNotFound {
+		t.Errorf("nothing applied: %d %s, want 404", status, body)
+	}
+
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	if status, body := do(t, srv, http.MethodPost, "/config/"+cfg.ID+"/apply", "alice", nil); status != http.StatusOK {
+		t.Fatalf("apply: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodDelete, "/config/"+cfg.ID, "alice", nil); status != http.StatusOK {
+		t.Fatalf("delete: %d %s", status, body)
+	}
+	status, body := do(t, srv, http.MethodGet, "/config/applied", "alice", nil)
+	if missing := decode[AppliedConfigMissingResponse](t, body); status != http.StatusNotFound || missing.StaleConfigID != cfg.ID {
+		t.Errorf("deleted applied config: %d %s, want 404 with stale_config_id %s", status, body, cfg.ID)
+	}
+
+	broken := newTestServerFor(t, failingManager{hyprconfig.NewInMemoryConfigManager()})
+	if status, body := do(t, broken, http.MethodGet, "/config/applied", "alice", nil); status != http.StatusInternalServerError {
+		t.Errorf("storage failure: %d %s, want 500", status, body)
+	}
+}
+
+func TestInstallScriptAndExport(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "hyprland", Program: "hyprland", Dependencies: []string{"waybar"}, FileContent: hyprconfig.FileContent{Data: []byte("exec-once = waybar\n"), FileType: hyprconfig.FileTypeConfig}},
+	}})
+	base := "/config/" + cfg.ID
+
+	status, body := do(t, srv, http.MethodGet, base+"/install-script?distro=arch", "", nil)
+	if status != http.StatusOK || !strings.Contains(string(body), "pacman") || !strings.Contains(string(body), "waybar") {
+		t.Errorf("install script: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, base+"/install-script?distro=windows", "", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("unsupported distro: got %d, want 422", status)
+	}
+
+	status, body = do(t, srv, http.MethodGet, base+"/export", "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("export: %d %s", status, body)
+	}
+	if _, err := gzip.NewReader(bytes.NewReader(body)); err != nil {
+		t.Errorf("export is not gzip: %v", err)
+	}
+}
+
+func TestExportTemplateVariables(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{
+		Title:     "rice",
+		Variables: []hyprconfig.TemplateVariable{{Name: "monitor", Required: true}},
+		ProgramConfigs: []hyprconfig.HyprProgramConfig{
+			{Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{Data: []byte("monitor = {{monitor}},preferred,auto,1\n"), FileType: hyprconfig.FileTypeConfig}},
+		},
+	})
+	base := "/config/" + cfg.ID + "/export"
+
+	status, body := do(t, srv, http.MethodGet, base+"?var.monitor=DP-1", "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("export: %d %s", status, body)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive, _ := io.ReadAll(gz); !bytes.Contains(archive, []byte("monitor = DP-1,preferred,auto,1")) {
+		t.Error("exported hyprland.conf should have the monitor filled in")
+	}
+
+	if status, body := do(t, srv, http.MethodGet, base, "", nil); status != http.StatusUnprocessableEntity || !strings.Contains(string(body), "monitor") {
+		t.Errorf("export without the required variable: %d %s", status, body)
+	}
+}
+
+func TestExportHomeManager(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{
+		Title:     "rice",
+		Variables: []hyprconfig.TemplateVariable{{Name: "monitor", Required: true}},
+		ProgramConfigs: []hyprconfig.HyprProgramConfig{
+			{Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{Data: []byte("monitor = {{monitor}},preferred,auto,1\n"), FileType: hyprconfig.FileTypeConfig}},
+		},
+	})
+
+	status, body := do(t, srv, http.MethodGet, "/config/"+cfg.ID+"/export?format=home-manager&var.monitor=DP-1", "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("export: %d %s", status, body)
+	}
+	if !strings.Contains(string(body), "wayland.windowManager.hyprland = {") || !strings.Contains(string(body), `"DP-1,preferred,auto,1"`) {
+		t.Errorf("home-manager module = %s", body)
+	}
+	if status, body := do(t, srv, http.MethodGet, "/config/"+cfg.ID+"/export?format=zip", "", nil); status != http.StatusBadRequest {
+		t.Errorf("unsupported format: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodGet, "/config/"+cfg.ID+"/export?layout=yadm", "", nil); status != http.StatusBadRequest {
+		t.Errorf("unsupported layout: %d %s", status, body)
+	}
+}
+
+func TestAllowedProgramAdmin(t *testing.T) {
+	srv := newTestServer(t)
+	programs := []hyprconfig.AllowedPrograms{{ProgramName: "MyBar"}, {ProgramName: "mybar"}}
+
+	if status, _ := do(t, srv, http.MethodPost, "/admin/programs/import", "bob", programs); status == http.StatusOK {
+		t.Fatal("non-admin import should fail")
+	}
+	status, body := do(t, srv, http.MethodPost, "/admin/programs/import", "admin", programs)
+	if status != http.StatusOK {
+		t.Fatalf("import: %d %s", status, body)
+	}
+	results := decode[[]hyprconfig.ProgramImportResult](t, body)
+	if results[0].Status != hyprconfig.ImportStatusInserted || results[1].Status != hyprconfig.ImportStatusSkipped {
+		t.Errorf("import results: %+v", results)
+	}
+
+	_, body = do(t, srv, http.MethodGet, "/programs?prefix=my", "", nil)
+	if page := decode[mserve.Page[hyprconfig.AllowedPrograms]](t, body); page.Total != 1 || page.Items[0].ProgramName != "mybar" {
+		t.Errorf("programs: %s", body)
+	}
+
+	// the imported program can now be used in configs
+	createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "custom", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{Title: "bar", Program: "mybar"},
+	}})
+}
+
+func TestAllowedProgramEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	add := AddAllowedProgramRequest{ProgramName: "MyBar"}
+
+	if status, _ := do(t, srv, http.MethodPost, "/admin/programs", "bob", add); status != http.StatusForbidden {
+		t.Errorf("non-admin add: got %d, want 403", status)
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/admin/programs", "", add); status != http.StatusUnauthorized {
+		t.Errorf("anonymous add: got %d, want 401", status)
+	}
+	status, body := do(t, srv, http.MethodPost, "/admin/programs", "admin", add)
+	if status != http.StatusOK || decode[hyprconfig.AllowedPrograms](t, body).ProgramName != "mybar" {
+		t.Fatalf("add: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/admin/programs", "admin", add); status != http.StatusUnprocessableEntity {
+		t.Errorf("duplicate add: got %d, want 422", status)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/programs/MyBar", "", nil)
+	if status != http.StatusOK || decode[hyprconfig.AllowedPrograms](t, body).ProgramName != "mybar" {
+		t.Errorf("get: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodDelete, "/admin/programs/mybar", "bob", nil); status != http.StatusForbidden {
+		t.Errorf("non-admin delete: got %d, want 403", status)
+	}
+	if status, body := do(t, srv, http.MethodDelete, "/admin/programs/mybar", "admin", nil); status != http.StatusOK {
+		t.Fatalf("delete: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/programs/mybar", "", nil); status != http.StatusNotFound {
+		t.Errorf("get after delete: got %d, want 404", status)
+	}
+	if status, _ := do(t, srv, http.MethodDelete, "/admin/programs/mybar", "admin", nil); status != http.StatusNotFound {
+		t.Errorf("delete twice: got %d, want 404", status)
+	}
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	m := hyprconfig.NewInMemoryConfigManager()
+	srv := newTestServerFor(t, m)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+
+	status, body := do(t, srv, http.MethodPost, "/account/api-keys", "bob", CreateAPIKeyRequest{
+		Name:   "laptop",
+		Scopes: []string{hyprconfig.APIKeyScopeRead, hyprconfig.APIKeyScopeApply},
+	})
+	if status != http.StatusOK {
+		t.Fatalf("create key: %d %s", status, body)
+	}
+	key := decode[hyprconfig.CreatedAPIKey](t, body)
+	if status, _ := do(t, srv, http.MethodPost, "/account/api-keys", "bob", CreateAPIKeyRequest{Name: "bad", Scopes: []string{"admin"}}); status != http.StatusUnprocessableEntity {
+		t.Errorf("unknown scope: got %d, want 422", status)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/account/api-keys", "bob", nil)
+	if keys := decode[[]map[string]any](t, body); status != http.StatusOK || len(keys) != 1 || keys[0]["token"] != nil {
+		t.Errorf("list keys: %d %s", status, body)
+	}
+
+	if status, body := doWithAPIKey(t, srv, http.MethodGet, "/configs/mine", key.Token, nil); status != http.StatusOK {
+		t.Errorf("read with key: %d %s", status, body)
+	}
+	if status, body := doWithAPIKey(t, srv, http.MethodPost, "/config/"+cfg.ID+"/apply", key.Token, nil); status != http.StatusOK {
+		t.Errorf("apply with key: %d %s", status, body)
+	}
+	if status, _ := doWithAPIKey(t, srv, http.MethodPost, "/config/"+cfg.ID+"/favorite", key.Token, nil); status != http.StatusForbidden {
+		t.Errorf("write without write scope: got %d, want 403", status)
+	}
+	if status, _ := doWithAPIKey(t, srv, http.MethodPost, "/account/api-keys", key.Token, CreateAPIKeyRequest{Name: "more", Scopes: []string{"read"}}); status != http.StatusForbidden {
+		t.Errorf("creating a key with a key: got %d, want 403", status)
+	}
+	if status, _ := doWithAPIKey(t, srv, http.MethodGet, "/configs/mine", key.Token+"x", nil); status != http.StatusUnauthorized {
+		t.Errorf("unknown key: got %d, want 401", status)
+	}
+
+	if status, _ := do(t, srv, http.MethodDelete, "/account/api-keys/"+key.ID, "alice", nil); status != http.StatusForbidden {
+		t.Errorf("revoke by another user: got %d, want 403", status)
+	}
+	if status, body := do(t, srv, http.MethodDelete, "/account/api-keys/"+key.ID, "bob", nil); status != http.StatusOK {
+		t.Fatalf("revoke: %d %s", status, body)
+	}
+	if status, _ := doWithAPIKey(t, srv, http.MethodGet, "/configs/mine", key.Token, nil); status != http.StatusUnauthorized {
+		t.Errorf("revoked key: got %d, want 401", status)
+	}
+
+	bob := (&session.UserSessionData{UserID: "bob", SignedIn: true}).WithContext(context.Background())
+	expired, err := m.CreateAPIKey(bob, "ci", []string{hyprconfig.APIKeyScopeRead}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if status, _ := doWithAPIKey(t, srv, http.MethodGet, "/configs/mine", expired.Token, nil); status != http.StatusUnauthorized {
+		t.Errorf("expired key: got %d, want 401", status)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	rm, err := NewRequestMetrics(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(hyprconfig.NewInMemoryConfigManager())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	for _, ep := range h.GetEndpoints() {
+		router.HandleFunc(ep.Path, ep.Handler).Methods(ep.Methods...)
+	}
+	router.Use(rm.Middleware)
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	if status, _ := do(t, srv, http.MethodGet, "/config/missing", "", nil); status != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", status)
+	}
+	if n := testutil.ToFloat64(rm.requests.WithLabelValues("/config/{config_id}", http.MethodGet, "404")); n != 1 {
+		t.Errorf("requests for /config/{config_id} with 404 = %v, want 1", n)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = hyprconfig.RequestIDFromContext(r.Context())
+	}))
+
+	for _, tc := range []struct {
+		header   string
+		generate bool
+	}{
+		{"req-42", false},
+		{"", true},
+		{"forged\nlog line", true},
+		{strings.Repeat("x", maxRequestIDLength+1), true},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/config/any", nil)
+		if tc.header != "" {
+			r.Header.Set(RequestIDHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		echoed := w.Header().Get(RequestIDHeader)
+		if seen == "" || echoed != seen {
+			t.Errorf("header %q: context id %q, response id %q", tc.header, seen, echoed)
+		}
+		if generated := seen != tc.header; generated != tc.generate {
+			t.Errorf("header %q: request id %q, generated %v, want %v", tc.header, seen, generated, tc.generate)
+		}
+	}
+}
+
+func TestAdminListConfigs(t *testing.T) {
+	srv := newTestServer(t)
+	private := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "secret", Private: true}))
+	public := createConfig(t, srv, "bob", withTerminal(hyprconfig.HyprConfig{Title: "shared"}))
+
+	for _, user := range []string{"", "bob"} {
+		status, body := do(t, srv, http.MethodGet, "/admin/configs", user, nil)
+		if status != http.StatusUnauthorized && status != http.StatusForbidden {
+			t.Errorf("user %q: %d, want 401 or 403", user, status)
+		}
+		if strings.Contains(string(body), private.ID) {
+			t.Errorf("user %q saw the private config: %s", user, body)
+		}
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/admin/configs", "bob", nil); status != http.StatusForbidden {
+		t.Errorf("non-admin: %d, want 403", status)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/admin/configs", "admin", nil)
+	if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != 2 {
+		t.Fatalf("admin: %d %s, want both configs", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/admin/configs?owner_id=alice&private=true", "admin", nil)
+	page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body)
+	if status != http.StatusOK || len(page.Items) != 1 || page.Items[0].ID != private.ID {
+		t.Errorf("admin filtered by owner: %d %s, want only %s", status, body, private.ID)
+	}
+	if strings.Contains(string(body), public.ID) {
+		t.Errorf("owner filter returned bob's config: %s", body)
+	}
+}
+
+func TestUserStatsEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	public := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice", Author: hyprconfig.Author{UserName: "alice_h"}}))
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "secret", Private: true}))
+	if status, body := do(t, srv, http.MethodPost, "/config/"+public.ID+"/favorite", "bob", nil); status != http.StatusOK {
+		t.Fatalf("favorite: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodPost, "/config/"+public.ID+"/apply", "bob", nil); status != http.StatusOK {
+		t.Fatalf("apply: %d %s", status, body)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/users/alice/stats", "", nil)
+	stats := decode[hyprconfig.AuthorStats](t, body)
+	if status != http.StatusOK || stats.PublicConfigs != 1 || stats.TotalLikes != 1 || stats.ActiveUsers != 1 || stats.MemberSince == nil {
+		t.Errorf("stats: %d %s", status, body)
+	}
+
+	// The owner sees only their public configs here too
+	status, body = do(t, srv, http.MethodGet, "/users/alice/configs", "alice", nil)
+	resp := decode[UserConfigsResponse](t, body)
+	if status != http.StatusOK || len(resp.Configs.Items) != 1 || resp.Configs.Items[0].ID != public.ID {
+		t.Errorf("configs: %d %s", status, body)
+	}
+	if resp.Stats == nil || resp.Stats.PublicConfigs != 1 || resp.Stats.Author == nil || resp.Stats.Author.UserName != "alice_h" {
+		t.Errorf("stats in configs: %+v", resp.Stats)
+	}
+}
+
+func TestFollowEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	rice := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice", Author: hyprconfig.Author{UserName: "alice_h"}}))
+
+	if status, _ := do(t, srv, http.MethodPost, "/users/alice/follow", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("signed out: got %d, want 401", status)
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/users/bob/follow", "bob", nil); status != http.StatusUnprocessableEntity {
+		t.Errorf("follow yourself: got %d, want 422", status)
+	}
+	if status, body := do(t, srv, http.MethodPost, "/users/alice/follow", "bob", nil); status != http.StatusOK {
+		t.Fatalf("follow: %d %s", status, body)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/account/following", "bob", nil)
+	following := decode[[]hyprconfig.FollowedAuthor](t, body)
+	if status != http.StatusOK || len(following) != 1 || following[0].Author == nil || following[0].Author.UserName != "alice_h" {
+		t.Errorf("following: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/feed/following", "bob", nil)
+	if feed := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || len(feed.Items) != 1 || feed.Items[0].ID != rice.ID {
+		t.Errorf("feed: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/users/alice/stats", "", nil)
+	if stats := decode[hyprconfig.AuthorStats](t, body); status != http.StatusOK || stats.Followers != 1 {
+		t.Errorf("stats: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodDelete, "/users/alice/follow", "bob", nil); status != http.StatusOK {
+		t.Errorf("unfollow: got %d", status)
+	}
+	status, body = do(t, srv, http.MethodGet, "/feed/following", "bob", nil)
+	if feed := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || len(feed.Items) != 0 {
+		t.Errorf("feed after unfollowing: %d %s", status, body)
+	}
+}
+
+func TestNotificationEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	rice := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+
+	if status, body := do(t, srv, http.MethodPost, "/users/alice/follow", "bob", nil); status != http.StatusOK {
+		t.Fatalf("follow: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodPost, "/config/"+rice.ID+"/favorite", "bob", nil); status != http.StatusOK {
+		t.Fatalf("favorite: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodGet, "/notifications", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("signed out: got %d, want 401", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/notifications?unread_only=maybe", "alice", nil); status != http.StatusBadRequest {
+		t.Errorf("bad unread_only: got %d, want 400", status)
+	}
+	status, body := do(t, srv, http.MethodGet, "/notifications/unread-count", "alice", nil)
+	if count := decode[UnreadNotificationsResponse](t, body); status != http.StatusOK || count.Unread != 2 {
+		t.Errorf("unread count: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/notifications?unread_only=true", "alice", nil)
+	inbox := decode[mserve.Page[hyprconfig.Notification]](t, body)
+	if status != http.StatusOK || len(inbox.Items) != 2 {
+		t.Fatalf("notifications: %d %s", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodPost, "/notifications/read", "alice", MarkNotificationsReadRequest{IDs: []string{inbox.Items[0].ID}})
+	if res := decode[MarkNotificationsReadResponse](t, body); status != http.StatusOK || res.Marked != 1 {
+		t.Errorf("mark read: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodPost, "/notifications/read", "alice", MarkNotificationsReadRequest{})
+	if res := decode[MarkNotificationsReadResponse](t, body); status != http.StatusOK || res.Marked != 1 {
+		t.Errorf("mark all read: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/notifications/unread-count", "alice", nil)
+	if count := decode[UnreadNotificationsResponse](t, body); status != http.StatusOK || count.Unread != 0 {
+		t.Errorf("unread count after marking: %d %s", status, body)
+	}
+}
+
+func TestRelatedConfigsEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+	rice := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice", Tags: []string{"dark"}}))
+	other := createConfig(t, srv, "bob", withTerminal(hyprconfig.HyprConfig{Title: "other", Tags: []string{"dark"}}))
+
+	status, body := do(t, srv, http.MethodGet, "/config/"+rice.ID+"/related", "", nil)
+	if related := decode[[]hyprconfig.HyprConfig](t, body); status != http.StatusOK || len(related) != 1 || related[0].ID != other.ID {
+		t.Errorf("related: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/"+rice.ID+"/related?limit=0", "", nil); status != http.StatusBadRequest {
+		t.Errorf("limit 0: got %d, want 400", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/missing/related", "", nil); status != http.StatusNotFound {
+		t.Errorf("missing config: got %d, want 404", status)
+	}
+}
+
+func TestConfigReadmeEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	base := "/config/" + cfg.ID + "/readme"
+
+	readme := "# Rice <script>alert(1)</script>\n[docs](javascript:alert(1))"
+	if status, body := do(t, srv, http.MethodPatch, "/config/"+cfg.ID, "alice", map[string]string{"readme": readme}); status != http.StatusOK {
+		t.Fatalf("update readme: %d %s", status, body)
+	}
+
+	status, body := do(t, srv, http.MethodGet, base, "", nil)
+	if got := decode[ReadmeResponse](t, body); status != http.StatusOK || got.Format != hyprconfig.ReadmeFormatMarkdown || got.Content != "# Rice alert(1)\n[docs](#)" {
+		t.Errorf("markdown readme: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, base+"?format=html", "", nil)
+	want := "<h1>Rice alert(1)</h1>\n<p><a href=\"#\" rel=\"nofollow noopener noreferrer\">docs</a></p>\n"
+	if got := decode[ReadmeResponse](t, body); status != http.StatusOK || got.Format != hyprconfig.ReadmeFormatHTML || got.Content != want {
+		t.Errorf("html readme: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodGet, base+"?format=pdf", "", nil); status != http.StatusBadRequest {
+		t.Errorf("unsupported format: got %d, want 400", status)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/missing/readme", "", nil); status != http.StatusNotFound {
+		t.Errorf("missing config: got %d, want 404", status)
+	}
+}
+
+func TestConfigKeybindsEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{ID: "hypr", Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{
+			Data: []byte("bind = SUPER, Q, exec, kitty\nbind = SUPER, Q, killactive\nbindd = SUPER, F, Fullscreen, fullscreen\n# bind = SUPER, E, exec, kitty\n"), FileType: hyprconfig.FileTypeConfig,
+		}},
+	}})
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "SUPER+Q") {
+		t.Errorf("create warnings = %q", cfg.Warnings)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/config/"+cfg.ID+"/keybinds", "", nil)
+	report := decode[hyprconfig.KeybindReport](t, body)
+	if status != http.StatusOK || len(report.Keybinds) != 3 || len(report.Conflicts) != 1 || report.Conflicts[0].Bindings[1].Dispatcher != "killactive" {
+		t.Errorf("keybinds: %d %s", status, body)
+	}
+	if report.Keybinds[2].Description != "Fullscreen" {
+		t.Errorf("keybind description = %q, want Fullscreen", report.Keybinds[2].Description)
+	}
+
+	// The binds are stored with the config, and left out of lists
+	status, body = do(t, srv, http.MethodGet, "/config/"+cfg.ID, "", nil)
+	if got := decode[hyprconfig.HyprConfig](t, body); status != http.StatusOK || len(got.Keybinds) != 3 {
+		t.Errorf("config keybinds: %d %+v", status, got.Keybinds)
+	}
+	_, body = do(t, srv, http.MethodGet, "/configs", "", nil)
+	if items := decode[mserve.Page[hyprconfig.HyprConfig]](t, body).Items; len(items) != 1 || items[0].Keybinds != nil {
+		t.Errorf("listed keybinds = %+v", items)
+	}
+
+	status, body = do(t, srv, http.MethodPatch, "/config/"+cfg.ID, "alice", map[string]string{"description": "dark"})
+	if got := decode[StatusResponse](t, body); status != http.StatusOK || got.Status != "updated" || len(got.Warnings) != 1 {
+		t.Errorf("update: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodGet, "/config/missing/keybinds", "", nil); status != http.StatusNotFound {
+		t.Errorf("missing config: got %d, want 404", status)
+	}
+}
+
+func TestConfigLintEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", hyprconfig.HyprConfig{Title: "rice", ProgramConfigs: []hyprconfig.HyprProgramConfig{
+		{ID: "hypr", Title: "hyprland", Program: "hyprland", FileContent: hyprconfig.FileContent{
+			Data: []byte("decoration {\n    drop_shadow = yes\n    rounding = round\n}\n"), FileType: hyprconfig.FileTypeConfig,
+		}},
+	}})
+	if len(cfg.Warnings) != 2 || !strings.Contains(cfg.Warnings[0], "decoration:shadow:enabled") {
+		t.Errorf("create warnings = %q", cfg.Warnings)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/config/"+cfg.ID+"/lint", "", nil)
+	findings := decode[LintResponse](t, body).Findings
+	if status != http.StatusOK || len(findings) != 2 || findings[0].Kind != hyprconfig.LintRemoved || findings[1].Kind != hyprconfig.LintInvalidValue ||
+		findings[1].Line != 3 || findings[1].ProgramID != "hypr" {
+		t.Errorf("lint: %d %s", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodPatch, "/config/"+cfg.ID, "alice", map[string]string{"description": "dark"})
+	if got := decode[StatusResponse](t, body); status != http.StatusOK || len(got.Warnings) != 2 {
+		t.Errorf("update: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodGet, "/config/missing/lint", "", nil); status != http.StatusNotFound {
+		t.Errorf("missing config: got %d, want 404", status)
+	}
+}
+
+func TestRandomConfigEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+	if status, _ := do(t, srv, http.MethodGet, "/configs/random", "", nil); status != http.StatusNotFound {
+		t.Errorf("no configs: got %d, want 404", status)
+	}
+	rice := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice", Tags: []string{"dark"}}))
+
+	status, body := do(t, srv, http.MethodGet, "/configs/random?tags=dark", "", nil)
+	if cfg := decode[hyprconfig.HyprConfig](t, body); status != http.StatusOK || cfg.ID != rice.ID {
+		t.Errorf("random: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/configs/random?tags=light", "", nil); status != http.StatusNotFound {
+		t.Errorf("no match: got %d, want 404", status)
+	}
+}
@@ -0,0 +1,47 @@
+package hchandler
+
+import (
+	"net/http"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/mserve"
+)
+
+// readOnlyRetryAfter is the Retry-After, in seconds, of writes rejected in read-only mode.
+const readOnlyRetryAfter = 60
+
+// ReadOnlyStatus is the body of the read-only mode endpoints.
+type ReadOnlyStatus struct {
+	ReadOnly bool `json:"read_only"`
+}
+
+// SetMaintenance enables the read-only toggle endpoint for m, which should be the config manager
+// the handler was created with or one it wraps.
+func (h *Handler) SetMaintenance(m *hyprconfig.ReadOnlyConfigManager) {
+	h.maintenance = m
+}
+
+// GetReadOnly reports whether writes are currently rejected, so clients can tell users why.
+func (h *Handler) GetReadOnly(w http.ResponseWriter, r *http.Request) {
+	mserve.WriteBody(w, r, ReadOnlyStatus{ReadOnly: h.maintenance != nil && h.maintenance.ReadOnly()})
+}
+
+// SetReadOnly turns read-only mode on or off. Only admins may toggle it.
+func (h *Handler) SetReadOnly(w http.ResponseWriter, r *http.Request) {
+	if _, err := requireAdmin(r); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	if h.maintenance == nil {
+		mserve.WriteError(w, r, http.StatusNotImplemented, "read-only mode cannot be toggled on this server")
+		return
+	}
+	body, err := mserve.ReadBody[ReadOnlyStatus](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.maintenance.SetReadOnly(body.ReadOnly)
+	mserve.WriteBody(w, r, ReadOnlyStatus{ReadOnly: h.maintenance.ReadOnly()})
+}
@@ -0,0 +1,50 @@
+package hchandler
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+func TestReadOnlyMode(t *testing.T) {
+	maintenance := hyprconfig.NewReadOnlyConfigManager(hyprconfig.NewInMemoryConfigManager(), false)
+	srv := newTestServerFor(t, maintenance, func(h *Handler) { h.SetMaintenance(maintenance) })
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+
+	if status, _ := do(t, srv, http.MethodPut, "/admin/read-only", "alice", ReadOnlyStatus{ReadOnly: true}); status != http.StatusForbidden {
+		t.Errorf("non-admin: got %d, want 403", status)
+	}
+	status, body := do(t, srv, http.MethodPut, "/admin/read-only", "admin", ReadOnlyStatus{ReadOnly: true})
+	if status != http.StatusOK || !decode[ReadOnlyStatus](t, body).ReadOnly {
+		t.Fatalf("turn on: %d %s", status, body)
+	}
+	if status, body := do(t, srv, http.MethodGet, "/read-only", "", nil); status != http.StatusOK || !decode[ReadOnlyStatus](t, body).ReadOnly {
+		t.Errorf("status while on: %d %s", status, body)
+	}
+
+	req := newJSONRequest(t, srv, http.MethodPost, "/config/new", withTerminal(hyprconfig.HyprConfig{Title: "another"}))
+	req.Header.Set(testUserHeader, "alice")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != strconv.Itoa(readOnlyRetryAfter) {
+		t.Errorf("create while read-only: %d, Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	if status, body := do(t, srv, http.MethodGet, "/config/"+cfg.ID, "alice", nil); status != http.StatusOK {
+		t.Errorf("read while read-only: %d %s", status, body)
+	}
+
+	if status, _ := do(t, srv, http.MethodPut, "/admin/read-only", "admin", ReadOnlyStatus{ReadOnly: false}); status != http.StatusOK {
+		t.Fatalf("turn off: got %d", status)
+	}
+	createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "another"}))
+
+	disabled := newTestServer(t)
+	if status, _ := do(t, disabled, http.MethodPut, "/admin/read-only", "admin", ReadOnlyStatus{ReadOnly: true}); status != http.StatusNotImplemented {
+		t.Errorf("without a switch: got %d, want 501", status)
+	}
+}
@@ -0,0 +1,127 @@
+package hchandler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Seann-Moser/credentials/session"
+	"github.com/Seann-Moser/credentials/user"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/mserve"
+)
+
+// RoleManager assigns roles to users. *rbac.Manager implements it.
+type RoleManager interface {
+	AssignRoleToUser(ctx context.Context, userID, roleID string) error
+	ListRolesForUser(ctx context.Context, userID string) ([]string, error)
+}
+
+// UserFinder looks users up by id. user.Store implements it.
+type UserFinder interface {
+	GetUserByID(ctx context.Context, userID string) (*user.User, error)
+}
+
+// AssignRoleRequest is the body of the assign role endpoint.
+type AssignRoleRequest struct {
+	Role string `json:"role"` // e.g. admin
+}
+
+// UserRolesResponse lists the roles of a user.
+type UserRolesResponse struct {
+	UserID string   `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
+
+// SetRoleManager enables the assign role endpoint, which fails with 501 without one. Roles are
+// only assigned to users users finds.
+func (h *Handler) SetRoleManager(roles RoleManager, users UserFinder) {
+	h.roles = roles
+	h.users = users
+}
+
+// findUser returns ErrNotFound when no user has userID. The user store reports a missing user
+// only by its message, so other lookup errors are passed on as they are.
+func (h *Handler) findUser(ctx context.Context, userID string) error {
+	u, err := h.users.GetUserByID(ctx, userID)
+	if (err == nil && u == nil) || (err != nil && err.Error() == "user not found") {
+		return fmt.Errorf("user %s %w", userID, hyprconfig.ErrNotFound)
+	}
+	return err
+}
+
+// AssignRole grants a role to a user. Only admins may assign roles, and assigning a role the user
+// already has changes nothing.
+func (h *Handler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	caller, err := requireAdmin(r)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	if h.roles == nil {
+		mserve.WriteError(w, r, http.StatusNotImplemented, "roles cannot be assigned on this server")
+		return
+	}
+
+	body, err := mserve.ReadBody[AssignRoleRequest](r)
+	if err != nil {
+		mserve.WriteError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	role := strings.TrimSpace(body.Role)
+	if role == "" {
+		mserve.WriteError(w, r, http.StatusUnprocessableEntity, "role cannot be empty")
+		return
+	}
+
+	userID := mserve.PathParam(r, "user_id")
+	if err := h.findUser(r.Context(), userID); err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	roles, err := h.roles.ListRolesForUser(r.Context(), userID)
+	if err != nil {
+		writeDomainError(w, r, err)
+		return
+	}
+	if !slices.Contains(roles, role) {
+		if err := h.roles.AssignRoleToUser(r.Context(), userID, role); err != nil {
+			writeDomainError(w, r, err)
+			return
+		}
+		roles = append(roles, role)
+		slog.InfoContext(r.Context(), "role assigned", "role", role, "user_id", userID, "by", caller.UserID)
+	}
+
+	mserve.WriteBody(w, r, UserRolesResponse{UserID: userID, Roles: roles})
+}
+
+// requireAdmin returns the signed-in caller, or ErrUnauthorized or ErrForbidden when the caller
+// is not an admin. Endpoints that do not go through the config manager check it themselves.
+func requireAdmin(r *http.Request) (*session.UserSessionData, error) {
+	caller, err := session.GetSession(r.Context())
+	if err != nil || !caller.SignedIn {
+		return nil, hyprconfig.ErrUnauthorized
+	}
+	if !slices.Contains(caller.Roles, hyprconfig.AdminRole) {
+		return nil, hyprconfig.ErrForbidden
+	}
+	return caller, nil
+}
+
+// BootstrapAdmin grants the admin role to userID when no user has it yet, so a new install has
+// someone who can reach the admin endpoints. Once an admin exists it does nothing and reports
+// false, so leaving it enabled across restarts never promotes anyone else.
+func BootstrapAdmin(ctx context.Context, roles RoleManager, adminExists func(ctx context.Context) (bool, error), userID string) (bool, error) {
+	exists, err := adminExists(ctx)
+	if err != nil || exists {
+		return false, err
+	}
+	if err := roles.AssignRoleToUser(ctx, userID, hyprconfig.AdminRole); err != nil {
+		return false, err
+	}
+	return true, nil
+}
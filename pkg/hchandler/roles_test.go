@@ -0,0 +1,118 @@
+package hchandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Seann-Moser/credentials/user"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+// fakeRoles is an in-memory RoleManager.
+type fakeRoles map[string][]string
+
+func (f fakeRoles) AssignRoleToUser(ctx context.Context, userID, roleID string) error {
+	f[userID] = append(f[userID], roleID)
+	return nil
+}
+
+func (f fakeRoles) ListRolesForUser(ctx context.Context, userID string) ([]string, error) {
+	return f[userID], nil
+}
+
+// fakeUsers is a UserFinder knowing only the listed user ids, failing like user.MongoDBStore
+// for any other.
+type fakeUsers []string
+
+func (f fakeUsers) GetUserByID(ctx context.Context, userID string) (*user.User, error) {
+	if !slices.Contains(f, userID) {
+		return nil, errors.New("user not found")
+	}
+	return &user.User{Username: userID}, nil
+}
+
+// failingRoles is a RoleManager whose storage is down.
+type failingRoles struct{}
+
+func (failingRoles) AssignRoleToUser(ctx context.Context, userID, roleID string) error {
+	return errors.New("connection refused to mongodb://internal:27017")
+}
+
+func (failingRoles) ListRolesForUser(ctx context.Context, userID string) ([]string, error) {
+	return nil, errors.New("connection refused to mongodb://internal:27017")
+}
+
+func TestAssignRole(t *testing.T) {
+	roles := fakeRoles{}
+	srv := newTestServerFor(t, hyprconfig.NewInMemoryConfigManager(), func(h *Handler) { h.SetRoleManager(roles, fakeUsers{"bob"}) })
+
+	if status, _ := do(t, srv, http.MethodPost, "/admin/users/bob/roles", "", AssignRoleRequest{Role: "admin"}); status != http.StatusUnauthorized {
+		t.Errorf("anonymous: got %d, want 401", status)
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/admin/users/bob/roles", "bob", AssignRoleRequest{Role: "admin"}); status != http.StatusForbidden {
+		t.Errorf("non-admin: got %d, want 403", status)
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/admin/users/bob/roles", "admin", AssignRoleRequest{Role: " "}); status != http.StatusUnprocessableEntity {
+		t.Errorf("empty role: got %d, want 422", status)
+	}
+	for range 2 {
+		status, body := do(t, srv, http.MethodPost, "/admin/users/bob/roles", "admin", AssignRoleRequest{Role: "admin"})
+		if resp := decode[UserRolesResponse](t, body); status != http.StatusOK || !slices.Equal(resp.Roles, []string{"admin"}) {
+			t.Errorf("assign admin: %d %s", status, body)
+		}
+	}
+	if !slices.Equal(roles["bob"], []string{"admin"}) {
+		t.Errorf("bob's roles = %v, want the role assigned once", roles["bob"])
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/admin/users/nobody/roles", "admin", AssignRoleRequest{Role: "admin"}); status != http.StatusNotFound {
+		t.Errorf("unknown user: got %d, want 404", status)
+	}
+	if _, ok := roles["nobody"]; ok {
+		t.Errorf("roles were written for an unknown user: %v", roles)
+	}
+
+	failing := newTestServerFor(t, hyprconfig.NewInMemoryConfigManager(), func(h *Handler) { h.SetRoleManager(failingRoles{}, fakeUsers{"bob"}) })
+	status, body := do(t, failing, http.MethodPost, "/admin/users/bob/roles", "admin", AssignRoleRequest{Role: "admin"})
+	if status != http.StatusInternalServerError || strings.Contains(string(body), "mongodb://") {
+		t.Errorf("storage failure: %d %s, want a 500 without the storage error", status, body)
+	}
+
+	disabled := newTestServer(t)
+	if status, _ := do(t, disabled, http.MethodPost, "/admin/users/bob/roles", "admin", AssignRoleRequest{Role: "admin"}); status != http.StatusNotImplemented {
+		t.Errorf("without a role manager: got %d, want 501", status)
+	}
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	roles := fakeRoles{}
+	adminExists := func(ctx context.Context) (bool, error) {
+		for _, r := range roles {
+			if slices.Contains(r, hyprconfig.AdminRole) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if granted, err := BootstrapAdmin(context.Background(), roles, adminExists, "alice"); err != nil || !granted {
+		t.Fatalf("first start: granted %v, %v", granted, err)
+	}
+	// A restart with the flag still set, or pointing at someone else, changes nothing
+	for _, user := range []string{"alice", "mallory"} {
+		if granted, err := BootstrapAdmin(context.Background(), roles, adminExists, user); err != nil || granted {
+			t.Errorf("restart for %s: granted %v, %v", user, granted, err)
+		}
+	}
+	if len(roles) != 1 || len(roles["alice"]) != 1 {
+		t.Errorf("roles = %v, want only alice as admin", roles)
+	}
+
+	down := errors.New("down")
+	if _, err := BootstrapAdmin(context.Background(), fakeRoles{}, func(context.Context) (bool, error) { return false, down }, "alice"); !errors.Is(err, down) {
+		t.Errorf("lookup error: got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package hchandler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/mserve"
+)
+
+func TestVerifyConfigEndpoints(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := createConfig(t, srv, "alice", withTerminal(hyprconfig.HyprConfig{Title: "rice"}))
+	path := "/admin/config/" + cfg.ID + "/verify"
+
+	if status, _ := do(t, srv, http.MethodPost, path, "alice", nil); status != http.StatusForbidden {
+		t.Errorf("non-admin verify: got %d, want 403", status)
+	}
+	if status, _ := do(t, srv, http.MethodPost, "/admin/config/missing/verify", "admin", nil); status != http.StatusNotFound {
+		t.Errorf("verify missing config: got %d, want 404", status)
+	}
+	if status, body := do(t, srv, http.MethodPost, path, "admin", nil); status != http.StatusOK {
+		t.Fatalf("verify: %d %s", status, body)
+	}
+
+	status, body := do(t, srv, http.MethodGet, "/config/search?verified=true", "", nil)
+	if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != 1 || !page.Items[0].Verified {
+		t.Errorf("search verified: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodGet, "/config/search?verified=maybe", "", nil); status != http.StatusBadRequest {
+		t.Errorf("invalid verified: got %d, want 400", status)
+	}
+
+	// Editing the config revokes the badge
+	if status, body := do(t, srv, http.MethodPut, "/config/"+cfg.ID, "alice", map[string]any{"description": "now with more exec lines"}); status != http.StatusOK {
+		t.Fatalf("update: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/config/"+cfg.ID, "alice", nil)
+	if got := decode[hyprconfig.HyprConfig](t, body); status != http.StatusOK || got.Verified || got.VerifiedBy != "" {
+		t.Errorf("after editing: %d %s", status, body)
+	}
+
+	if status, body := do(t, srv, http.MethodPost, path, "admin", nil); status != http.StatusOK {
+		t.Fatalf("verify again: %d %s", status, body)
+	}
+	if status, _ := do(t, srv, http.MethodDelete, path, "alice", nil); status != http.StatusForbidden {
+		t.Errorf("non-admin unverify: got %d, want 403", status)
+	}
+	if status, body := do(t, srv, http.MethodDelete, path, "admin", nil); status != http.StatusOK {
+		t.Fatalf("unverify: %d %s", status, body)
+	}
+	status, body = do(t, srv, http.MethodGet, "/config/search?verified=true", "", nil)
+	if page := decode[mserve.Page[hyprconfig.HyprConfig]](t, body); status != http.StatusOK || page.Total != 0 {
+		t.Errorf("search verified after unverifying: %d %s", status, body)
+	}
+}
@@ -0,0 +1,156 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestMongoManagerWithoutProgramsCollection(t *testing.T) {
+	m := &ConfigManagerMongo{limits: DefaultSizeLimits()}
+	admin := asUser("root", "admin")
+
+	if ok, err := m.registry().IsAllowed(admin, "kitty"); err != nil || !ok {
+		t.Errorf("built-in program: allowed %v, %v", ok, err)
+	}
+	if ok, err := m.registry().IsAllowed(admin, "my-tool"); err != nil || ok {
+		t.Errorf("other program: allowed %v, %v", ok, err)
+	}
+
+	cfg := &HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{{Program: "kitty"}}}
+	if err := cfg.Validate(admin, m.registry().Allowed, m.limits); err != nil {
+		t.Errorf("config of built-in programs: %v", err)
+	}
+	cfg.ProgramConfigs[0].Program = "my-tool"
+	if err := cfg.Validate(admin, m.registry().Allowed, m.limits); !errors.Is(err, ErrValidation) {
+		t.Errorf("config of another program: got %v, want ErrValidation", err)
+	}
+
+	if p, err := m.GetAllowedProgram(admin, "Kitty"); err != nil || p.ProgramName != "kitty" {
+		t.Errorf("GetAllowedProgram(kitty) = %v, %v", p, err)
+	}
+	if _, err := m.GetAllowedProgram(admin, "my-tool"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAllowedProgram(my-tool): got %v, want ErrNotFound", err)
+	}
+	page, err := m.ListAllowedProgramsPaged(admin, 1, 100, AllowedProgramFilters{Prefix: "hypr"})
+	if err != nil || page.Total == 0 {
+		t.Fatalf("ListAllowedProgramsPaged = %+v, %v", page, err)
+	}
+	for _, p := range page.Items {
+		if _, ok := validPrograms[p.ProgramName]; !ok {
+			t.Errorf("listed %s, which is not built in", p.ProgramName)
+		}
+	}
+
+	if _, err := m.AddAllowedProgram(admin, "my-tool"); !errors.Is(err, ErrAllowlistDisabled) {
+		t.Errorf("AddAllowedProgram: got %v, want ErrAllowlistDisabled", err)
+	}
+	if err := m.RemoveAllowedProgram(admin, "kitty"); !errors.Is(err, ErrAllowlistDisabled) {
+		t.Errorf("RemoveAllowedProgram: got %v, want ErrAllowlistDisabled", err)
+	}
+	if _, err := m.ImportAllowedPrograms(admin, []AllowedPrograms{{ProgramName: "my-tool"}}, false); !errors.Is(err, ErrAllowlistDisabled) {
+		t.Errorf("ImportAllowedPrograms: got %v, want ErrAllowlistDisabled", err)
+	}
+	if _, err := m.AddAllowedProgram(asUser("alice"), "my-tool"); !errors.Is(err, ErrForbidden) {
+		t.Errorf("AddAllowedProgram as a user: got %v, want ErrForbidden", err)
+	}
+}
+
+// TestNewConfigManagerProgramsCollection needs a MongoDB server, set HYPR_TEST_MONGO_URI to run it.
+func TestNewConfigManagerProgramsCollection(t *testing.T) {
+	uri := os.Getenv("HYPR_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("HYPR_TEST_MONGO_URI is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database(fmt.Sprintf("hypr_allowlist_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		_ = db.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+	admin := asUser("root", "admin")
+
+	disabled, err := NewConfigManager(db.Collection("configs"), db.Collection("favorites"), db.Collection("state"), nil)
+	if err != nil {
+		t.Fatalf("nil programs collection: %v", err)
+	}
+	if _, err := disabled.AddAllowedProgram(admin, "my-tool"); !errors.Is(err, ErrAllowlistDisabled) {
+		t.Errorf("AddAllowedProgram without programs: got %v, want ErrAllowlistDisabled", err)
+	}
+
+	enabled, err := NewConfigManager(db.Collection("configs"), db.Collection("favorites"), db.Collection("state"), db.Collection("allowed_programs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enabled.AddAllowedProgram(admin, "my-tool"); err != nil {
+		t.Errorf("AddAllowedProgram: %v", err)
+	}
+	if _, err := enabled.GetAllowedProgram(admin, "my-tool"); err != nil {
+		t.Errorf("GetAllowedProgram: %v", err)
+	}
+}
+
+// TestSeedAllowedPrograms needs a MongoDB server, set HYPR_TEST_MONGO_URI to run it.
+func TestSeedAllowedPrograms(t *testing.T) {
+	uri := os.Getenv("HYPR_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("HYPR_TEST_MONGO_URI is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database(fmt.Sprintf("hypr_seed_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		_ = db.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+
+	want := len(DefaultAllowedPrograms())
+	for run, status := range []string{ImportStatusInserted, ImportStatusSkipped} {
+		results, err := SeedAllowedPrograms(ctx, db.Collection("allowed_programs"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != want {
+			t.Fatalf("run %d: %d results, want %d", run, len(results), want)
+		}
+		for _, r := range results {
+			if r.Status != status {
+				t.Errorf("run %d: %s was %s, want %s", run, r.ProgramName, r.Status, status)
+			}
+		}
+	}
+	if n, err := db.Collection("allowed_programs").CountDocuments(ctx, bson.M{}); err != nil || n != int64(want) {
+		t.Errorf("allowed_programs holds %d programs (%v), want %d", n, err, want)
+	}
+}
+
+func TestDefaultAllowedProgramsDetails(t *testing.T) {
+	for _, p := range DefaultAllowedPrograms() {
+		if p.ProgramName != "swaync" {
+			continue
+		}
+		if p.Category != "notifications" || p.Packages[PlatformDebian] != "sway-notification-center" {
+			t.Errorf("swaync = %+v", p)
+		}
+		return
+	}
+	t.Error("swaync is not a default program")
+}
@@ -0,0 +1,219 @@
+package hyprconfig
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeyPrefix starts every API key token, so they can be told apart from OAuth tokens.
+const APIKeyPrefix = "hcm_"
+
+// Scopes an API key can be granted.
+const (
+	APIKeyScopeRead  = "read"  // GET endpoints
+	APIKeyScopeWrite = "write" // endpoints that change data
+	APIKeyScopeApply = "apply" // applying a config
+)
+
+const (
+	maxAPIKeyNameLength = 100
+	tokenBytes          = 32
+	apiKeyPrefixLength  = len(APIKeyPrefix) + 8
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+
+	apiKeyScopes = []string{APIKeyScopeRead, APIKeyScopeWrite, APIKeyScopeApply}
+)
+
+// APIKey lets a user call the API without a browser session, e.g. from the CLI.
+// Only a hash of the token is stored; the token itself is returned once by CreateAPIKey.
+type APIKey struct {
+	ID      string   `json:"id" bson:"_id"`
+	OwnerID string   `json:"owner_id" bson:"owner_id"`
+	Name    string   `json:"name" bson:"name"`
+	Prefix  string   `json:"prefix" bson:"prefix"` // start of the token, to tell keys apart
+	Hash    string   `json:"-" bson:"hash"`        // never returned
+	Scopes  []string `json:"scopes" bson:"scopes"`
+
+	CreatedTimestamp time.Time  `json:"created_timestamp" bson:"created_timestamp"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"` // nil never expires
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// CreatedAPIKey is a new API key together with its token.
+type CreatedAPIKey struct {
+	APIKey
+	Token string `json:"token"`
+}
+
+// HasScope reports whether the key was granted scope.
+func (k *APIKey) HasScope(scope string) bool {
+	return containsExact(k.Scopes, scope)
+}
+
+// active reports whether the key can still be used at now.
+func (k *APIKey) active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// hashToken returns the stored form of an API key or share link token.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns prefix followed by a random url-safe string.
+func newToken(prefix string) (string, error) {
+	raw := make([]byte, tokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// newAPIKey validates the request and generates a key owned by ownerID.
+func newAPIKey(ownerID, name string, scopes []string, expiry time.Duration) (*CreatedAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return nil, fmt.Errorf("%w: name must be between 1 and %d characters", ErrInvalidAPIKey, maxAPIKeyNameLength)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	var granted []string
+	for _, s := range scopes {
+		if !containsExact(apiKeyScopes, s) {
+			return nil, fmt.Errorf("%w: unknown scope %q, expected one of %s", ErrInvalidAPIKey, s, strings.Join(apiKeyScopes, ", "))
+		}
+		if !containsExact(granted, s) {
+			granted = append(granted, s)
+		}
+	}
+	if expiry < 0 {
+		return nil, fmt.Errorf("%w: expiry cannot be negative", ErrInvalidAPIKey)
+	}
+
+	token, err := newToken(APIKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key := APIKey{
+		ID:               uuid.NewString(),
+		OwnerID:          ownerID,
+		Name:             name,
+		Prefix:           token[:apiKeyPrefixLength],
+		Hash:             hashToken(token),
+		Scopes:           granted,
+		CreatedTimestamp: now,
+	}
+	if expiry > 0 {
+		expiresAt := now.Add(expiry)
+		key.ExpiresAt = &expiresAt
+	}
+	return &CreatedAPIKey{APIKey: key, Token: token}, nil
+}
+
+// CreateAPIKey creates an API key for the signed-in user. An expiry of zero never expires.
+func (m *ConfigManagerMongo) CreateAPIKey(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	expiry time.Duration,
+) (_ *CreatedAPIKey, err error) {
+	defer func() {
+		m.logMutation(ctx, "CreateAPIKey", err, slog.String("key_name", name), slog.Any("scopes", scopes))
+	}()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newAPIKey(user.UserID, name, scopes, expiry)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.APIKeysCollection.InsertOne(ctx, key.APIKey); err != nil {
+		return nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns the signed-in user's API keys, oldest first, including revoked and expired ones.
+func (m *ConfigManagerMongo) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.APIKeysCollection.Find(ctx, bson.M{"owner_id": user.UserID},
+		options.Find().SetSort(bson.M{"created_timestamp": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	keys := []APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops an API key from working. Revoking a revoked key does nothing.
+func (m *ConfigManagerMongo) RevokeAPIKey(ctx context.Context, keyID string) (err error) {
+	defer func() { m.logMutation(ctx, "RevokeAPIKey", err, slog.String("key_id", keyID)) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var key APIKey
+	err = m.APIKeysCollection.FindOne(ctx, bson.M{"_id": keyID}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	if key.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+
+	_, err = m.APIKeysCollection.UpdateOne(ctx,
+		bson.M{"_id": keyID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves a token to its key. Unknown, revoked and expired keys are ErrUnauthorized.
+func (m *ConfigManagerMongo) AuthenticateAPIKey(ctx context.Context, token string) (*APIKey, error) {
+	var key APIKey
+	err := m.APIKeysCollection.FindOne(ctx, bson.M{"hash": hashToken(token)}).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUnauthorized
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	if !key.active(time.Now()) {
+		return nil, ErrUnauthorized
+	}
+	return &key, nil
+}
@@ -0,0 +1,97 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManagerAPIKeys(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+
+		key, err := m.CreateAPIKey(ctx, "laptop", []string{APIKeyScopeRead, APIKeyScopeRead}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(key.Token, APIKeyPrefix) || !strings.HasPrefix(key.Token, key.Prefix) || key.ExpiresAt != nil {
+			t.Errorf("created key = %+v", key)
+		}
+		if len(key.Scopes) != 1 {
+			t.Errorf("scopes = %v, want duplicates dropped", key.Scopes)
+		}
+
+		got, err := m.AuthenticateAPIKey(context.Background(), key.Token)
+		if err != nil || got.ID != key.ID || got.OwnerID != "alice" {
+			t.Fatalf("authenticate = %+v, %v", got, err)
+		}
+		if _, err := m.AuthenticateAPIKey(context.Background(), key.Token+"x"); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("unknown token: got %v, want ErrUnauthorized", err)
+		}
+
+		keys, err := m.ListAPIKeys(ctx)
+		if err != nil || len(keys) != 1 || keys[0].Hash == "" {
+			t.Errorf("alice's keys = %+v, %v", keys, err)
+		}
+		if keys, _ := m.ListAPIKeys(asUser("bob")); len(keys) != 0 {
+			t.Errorf("bob sees %d keys", len(keys))
+		}
+
+		if err := m.RevokeAPIKey(asUser("bob"), key.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("revoke by bob: got %v, want ErrForbidden", err)
+		}
+		if err := m.RevokeAPIKey(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("revoke missing: got %v, want ErrNotFound", err)
+		}
+		for range 2 {
+			if err := m.RevokeAPIKey(ctx, key.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := m.AuthenticateAPIKey(context.Background(), key.Token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("revoked key: got %v, want ErrUnauthorized", err)
+		}
+		if keys, _ := m.ListAPIKeys(ctx); len(keys) != 1 || keys[0].RevokedAt == nil {
+			t.Errorf("keys after revoking = %+v", keys)
+		}
+
+		expiring, err := m.CreateAPIKey(ctx, "ci", []string{APIKeyScopeWrite}, time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := m.AuthenticateAPIKey(context.Background(), expiring.Token); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("expired key: got %v, want ErrUnauthorized", err)
+		}
+
+		if _, err := m.CreateAPIKey(context.Background(), "anon", []string{APIKeyScopeRead}, 0); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("anonymous create: got %v, want ErrUnauthorized", err)
+		}
+	})
+}
+
+func TestNewAPIKeyValidation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		name   string
+		scopes []string
+		expiry time.Duration
+	}{
+		"empty name":      {name: " ", scopes: []string{APIKeyScopeRead}},
+		"long name":       {name: strings.Repeat("k", maxAPIKeyNameLength+1), scopes: []string{APIKeyScopeRead}},
+		"no scopes":       {name: "k"},
+		"unknown scope":   {name: "k", scopes: []string{"admin"}},
+		"negative expiry": {name: "k", scopes: []string{APIKeyScopeRead}, expiry: -time.Hour},
+	} {
+		if _, err := newAPIKey("bob", tc.name, tc.scopes, tc.expiry); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("%s: got %v, want ErrInvalidAPIKey", name, err)
+		}
+	}
+
+	a, _ := newAPIKey("bob", "a", []string{APIKeyScopeRead}, 0)
+	b, _ := newAPIKey("bob", "b", []string{APIKeyScopeRead}, 0)
+	if a.Token == b.Token || a.Hash != hashToken(a.Token) {
+		t.Errorf("tokens %q and %q", a.Token, b.Token)
+	}
+}
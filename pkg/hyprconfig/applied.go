@@ -0,0 +1,38 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrAppliedConfigMissing matches an AppliedConfigMissingError.
+var ErrAppliedConfigMissing = errors.New("applied config no longer exists")
+
+// AppliedConfigMissingError is returned by GetAppliedConfig when the config the user applied has
+// been deleted. It matches ErrAppliedConfigMissing and ErrNotFound.
+type AppliedConfigMissingError struct {
+	ConfigID string
+}
+
+func (e *AppliedConfigMissingError) Error() string {
+	return fmt.Sprintf("%s: config %s", ErrAppliedConfigMissing, e.ConfigID)
+}
+
+func (e *AppliedConfigMissingError) Is(target error) bool {
+	return target == ErrAppliedConfigMissing || target == ErrNotFound
+}
+
+// loadAppliedConfig loads the applied config configID with get, reporting a deleted config as an
+// AppliedConfigMissingError.
+func loadAppliedConfig(
+	ctx context.Context,
+	configID string,
+	get func(ctx context.Context, id string) (*HyprConfig, error),
+) (*HyprConfig, error) {
+	cfg, err := get(ctx, configID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, &AppliedConfigMissingError{ConfigID: configID}
+	}
+	return cfg, err
+}
@@ -0,0 +1,49 @@
+package hyprconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestManagerAppliedConfigDeleted(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+		if err := m.ApplyConfig(alice, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.DeleteConfig(alice, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := m.GetAppliedConfig(alice)
+		var missing *AppliedConfigMissingError
+		if !errors.As(err, &missing) || missing.ConfigID != cfg.ID {
+			t.Fatalf("got %v, want AppliedConfigMissingError for %s", err, cfg.ID)
+		}
+		if !errors.Is(err, ErrAppliedConfigMissing) || !errors.Is(err, ErrNotFound) {
+			t.Errorf("%v does not match ErrAppliedConfigMissing and ErrNotFound", err)
+		}
+	})
+}
+
+func TestMongoGetAppliedConfigStorageFailure(t *testing.T) {
+	// Nothing listens on port 1, so every query fails once server selection times out.
+	client, err := mongo.Connect(asUser("alice"), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect(asUser("alice")) })
+
+	m := &ConfigManagerMongo{StateCollection: client.Database("hypr").Collection("user_hypr_state")}
+	_, err = m.GetAppliedConfig(asUser("alice"))
+	if err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("unreachable database: got %v, want a storage error other than ErrNotFound", err)
+	}
+}
@@ -0,0 +1,164 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AuditCreateConfig          = "create_config"
+	AuditUpdateConfig          = "update_config"
+	AuditDeleteConfig          = "delete_config"
+	AuditAddProgramConfig      = "add_program_config"
+	AuditRemoveProgramConfig   = "remove_program_config"
+	AuditMoveProgramConfig     = "move_program_config"
+	AuditUpdateProgramConfig   = "update_program_config"
+	AuditVerifyConfig          = "verify_config"
+	AuditUnverifyConfig        = "unverify_config"
+	AuditDeprecateConfig       = "deprecate_config"
+	AuditAddAllowedProgram     = "add_allowed_program"
+	AuditRemoveAllowedProgram  = "remove_allowed_program"
+	AuditImportAllowedPrograms = "import_allowed_programs"
+)
+
+// AuditEntry records who changed what and when. Entries for allowed program changes have no
+// config ID and list the affected program names in Changes.
+type AuditEntry struct {
+	ID       string   `json:"id" bson:"_id"`
+	ActorID  string   `json:"actor_id" bson:"actor_id"` // user id
+	Action   string   `json:"action" bson:"action"`
+	ConfigID string   `json:"config_id,omitempty" bson:"config_id,omitempty"`
+	ProgID   string   `json:"prog_id,omitempty" bson:"prog_id,omitempty"`
+	Changes  []string `json:"changes,omitempty" bson:"changes,omitempty"` // changed fields
+
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
+func newAuditEntry(actorID, action, configID, progID string, changes []string) AuditEntry {
+	return AuditEntry{
+		ID:        uuid.NewString(),
+		ActorID:   actorID,
+		Action:    action,
+		ConfigID:  configID,
+		ProgID:    progID,
+		Changes:   changes,
+		Timestamp: time.Now(),
+	}
+}
+
+// updatedFields lists the fields set by an UpdateConfig update, leaving out the ones set on every update.
+func updatedFields(updates bson.M) []string {
+	fields := make([]string, 0, len(updates))
+	for k := range updates {
+		if k == "version" || k == "updated_timestamp" {
+			continue
+		}
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// changedProgramFields lists the fields that differ between two versions of a program config.
+// File content is compared by hash, since one side may be compressed or offloaded.
+func changedProgramFields(before, after HyprProgramConfig) []string {
+	comparable := func(pc HyprProgramConfig) bson.M {
+		pc.Files = append([]FileEntry(nil), pc.Files...)
+		pc.eachFile(func(_ string, fc *FileContent) {
+			*fc = FileContent{FileType: fc.FileType, Hash: fc.Hash, Headers: fc.Headers, Mode: fc.Mode}
+		})
+		pc.ID = ""
+		pc.SubConfigs = nil
+		pc.CreatedTimestamp = time.Time{}
+		pc.UpdatedTimestamp = time.Time{}
+
+		var m bson.M
+		if data, err := bson.Marshal(pc); err == nil {
+			_ = bson.Unmarshal(data, &m)
+		}
+		return m
+	}
+	a, b := comparable(before), comparable(after)
+
+	var fields []string
+	for k, v := range a {
+		if !reflect.DeepEqual(v, b[k]) {
+			fields = append(fields, k)
+		}
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// importedProgramNames lists the programs written by an import.
+func importedProgramNames(results []ProgramImportResult) []string {
+	var names []string
+	for _, r := range results {
+		if r.Status == ImportStatusInserted || r.Status == ImportStatusUpdated {
+			names = append(names, r.ProgramName)
+		}
+	}
+	return names
+}
+
+// audit writes an audit log entry. Failures are logged and never fail the operation being audited.
+func (m *ConfigManagerMongo) audit(ctx context.Context, entry AuditEntry) {
+	if m.AuditCollection == nil {
+		return
+	}
+	if _, err := m.AuditCollection.InsertOne(ctx, entry); err != nil {
+		slog.Warn("failed to write audit log entry", "action", entry.Action, "config_id", entry.ConfigID, "err", err)
+	}
+}
+
+// ListAuditLog returns the audit log of a config, newest entry first. Only the config owner and
+// admins may read it; admins can also read the log of a deleted config.
+func (m *ConfigManagerMongo) ListAuditLog(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[AuditEntry], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[AuditEntry]{}, err
+	}
+
+	if !isAdmin(user.Roles) {
+		var cfg HyprConfig
+		err := m.Collection.FindOne(ctx, bson.M{"_id": configID},
+			options.FindOne().SetProjection(bson.M{"owner_id": 1}),
+		).Decode(&cfg)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return mserve.Page[AuditEntry]{}, ErrNotFound
+		} else if err != nil {
+			return mserve.Page[AuditEntry]{}, err
+		}
+		if cfg.OwnerID != user.UserID {
+			return mserve.Page[AuditEntry]{}, ErrForbidden
+		}
+	}
+
+	return mserve.PaginateMongo[AuditEntry](
+		ctx,
+		m.AuditCollection,
+		bson.M{"config_id": configID},
+		page,
+		limit,
+		options.Find().SetSort(bson.D{{Key: "timestamp", Value: -1}}),
+	)
+}
@@ -0,0 +1,187 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuthorStats sums up what a user has published. Only public configs are counted.
+type AuthorStats struct {
+	UserID string `json:"user_id"`
+
+	// Author info of the user's most recently updated public config, nil when they have none.
+	Author *Author `json:"author,omitempty"`
+
+	PublicConfigs int64 `json:"public_configs"`
+	TotalLikes    int64 `json:"total_likes"`
+
+	// Users who have one of the public configs applied.
+	ActiveUsers int64 `json:"active_users"`
+
+	// Users following the author, kept as a denormalized count by FollowAuthor.
+	Followers int64 `json:"followers"`
+
+	// Creation time of the user's first public config, nil when they have none.
+	MemberSince *time.Time `json:"member_since,omitempty"`
+}
+
+// checkAuthorStats validates a GetAuthorStats call.
+func checkAuthorStats(ownerID string) error {
+	if ownerID == "" {
+		return fmt.Errorf("%w: user id is required", ErrValidation)
+	}
+	return nil
+}
+
+// add counts one public config into the stats.
+func (s *AuthorStats) add(likes int64, created time.Time) {
+	s.PublicConfigs++
+	s.TotalLikes += likes
+	if !created.IsZero() && (s.MemberSince == nil || created.Before(*s.MemberSince)) {
+		s.MemberSince = &created
+	}
+}
+
+// setAuthor fills in Author from authors, keyed by user id.
+func (s *AuthorStats) setAuthor(authors map[string]Author) {
+	if a, ok := authors[s.UserID]; ok {
+		s.Author = &a
+	}
+}
+
+// GetAuthorStats returns the public footprint of the user ownerID. Anyone may read it.
+func (m *ConfigManagerMongo) GetAuthorStats(ctx context.Context, ownerID string) (*AuthorStats, error) {
+	if err := checkAuthorStats(ownerID); err != nil {
+		return nil, err
+	}
+	followers, err := m.followers(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follower count: %w", err)
+	}
+	stats := &AuthorStats{UserID: ownerID, Followers: followers}
+
+	cursor, err := m.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"owner_id": ownerID, "private": false}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          nil,
+			"configs":      bson.M{"$sum": 1},
+			"likes":        bson.M{"$sum": "$likes"},
+			"member_since": bson.M{"$min": "$created_timestamp"},
+			"config_ids":   bson.M{"$push": "$_id"},
+		}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate author configs: %w", err)
+	}
+	var groups []struct {
+		Configs     int64     `bson:"configs"`
+		Likes       int64     `bson:"likes"`
+		MemberSince time.Time `bson:"member_since"`
+		ConfigIDs   []string  `bson:"config_ids"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, fmt.Errorf("failed to aggregate author configs: %w", err)
+	}
+	if len(groups) == 0 {
+		return stats, nil
+	}
+
+	g := groups[0]
+	stats.PublicConfigs = g.Configs
+	stats.TotalLikes = g.Likes
+	if !g.MemberSince.IsZero() {
+		stats.MemberSince = &g.MemberSince
+	}
+	// A user has at most one applied config, so state documents are distinct users
+	if stats.ActiveUsers, err = m.StateCollection.CountDocuments(ctx, bson.M{"config_id": bson.M{"$in": g.ConfigIDs}}); err != nil {
+		return nil, fmt.Errorf("failed to count active users: %w", err)
+	}
+	authors, err := m.authorsOf(ctx, []string{ownerID})
+	if err != nil {
+		return nil, err
+	}
+	stats.setAuthor(authors)
+	return stats, nil
+}
+
+func (m *ConfigManagerMemory) GetAuthorStats(ctx context.Context, ownerID string) (*AuthorStats, error) {
+	if err := checkAuthorStats(ownerID); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stats := &AuthorStats{UserID: ownerID, Followers: m.followers[ownerID]}
+
+	public := map[string]bool{}
+	for id, cfg := range m.configs {
+		if cfg.OwnerID == ownerID && !cfg.Private {
+			public[id] = true
+			stats.add(cfg.Likes, cfg.CreatedTimestamp)
+		}
+	}
+	for _, s := range m.state {
+		if public[s.ConfigID] {
+			stats.ActiveUsers++
+		}
+	}
+	stats.setAuthor(m.authorsOf([]string{ownerID}))
+	return stats, nil
+}
+
+func (m *ConfigManagerSQLite) GetAuthorStats(ctx context.Context, ownerID string) (*AuthorStats, error) {
+	if err := checkAuthorStats(ownerID); err != nil {
+		return nil, err
+	}
+	followers, err := m.followers(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	stats := &AuthorStats{UserID: ownerID, Followers: followers}
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT likes, json_extract(doc, '$.created_timestamp') FROM configs WHERE owner_id = ? AND private = 0`,
+		ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var likes int64
+		var created sql.NullString
+		if err := rows.Scan(&likes, &created); err != nil {
+			return nil, err
+		}
+		// Stored as RFC 3339 with varying precision, so the oldest is found here rather than with MIN
+		var at time.Time
+		if created.Valid {
+			if at, err = time.Parse(time.RFC3339Nano, created.String); err != nil {
+				return nil, fmt.Errorf("config created timestamp %q: %w", created.String, err)
+			}
+		}
+		stats.add(likes, at)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	err = m.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM user_state
+		WHERE config_id IN (SELECT id FROM configs WHERE owner_id = ? AND private = 0)`,
+		ownerID).Scan(&stats.ActiveUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	authors, err := m.authorsOf(ctx, []string{ownerID})
+	if err != nil {
+		return nil, err
+	}
+	stats.setAuthor(authors)
+	return stats, nil
+}
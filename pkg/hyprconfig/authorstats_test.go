@@ -0,0 +1,85 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetAuthorStats(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := context.Background()
+		alice := asUser("alice")
+		first, err := m.GetConfig(alice, newTestConfig(t, m, "alice", false).ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := m.CreateConfig(alice, &HyprConfig{
+			Title:          "rice",
+			Author:         Author{UserName: "alice_h"},
+			ProgramConfigs: []HyprProgramConfig{{ID: "bar", Title: "bar", Program: "waybar"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hidden := newTestConfig(t, m, "alice", true)
+		newTestConfig(t, m, "bob", false)
+
+		for _, fav := range []struct{ user, config string }{{"bob", first.ID}, {"carol", first.ID}, {"bob", second.ID}, {"carol", hidden.ID}} {
+			if err := m.FavoriteConfig(asUser(fav.user), fav.config); err != nil && !errors.Is(err, ErrForbidden) {
+				t.Fatal(err)
+			}
+		}
+		for _, applied := range []struct{ user, config string }{{"bob", first.ID}, {"carol", second.ID}, {"alice", hidden.ID}} {
+			if err := m.ApplyConfig(asUser(applied.user), applied.config); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stats, err := m.GetAuthorStats(ctx, "alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.PublicConfigs != 2 || stats.TotalLikes != 3 || stats.ActiveUsers != 2 {
+			t.Errorf("stats = %+v, want 2 public configs, 3 likes and 2 active users", stats)
+		}
+		if stats.MemberSince == nil || !stats.MemberSince.Equal(first.CreatedTimestamp) {
+			t.Errorf("member since = %v, want %v", stats.MemberSince, first.CreatedTimestamp)
+		}
+		if stats.Author == nil || stats.Author.UserName != "alice_h" {
+			t.Errorf("author = %+v, want alice_h", stats.Author)
+		}
+
+		stats, err = m.GetAuthorStats(ctx, "nobody")
+		if err != nil || stats.PublicConfigs != 0 || stats.MemberSince != nil || stats.Author != nil {
+			t.Errorf("user without configs: %+v, %v", stats, err)
+		}
+		if _, err := m.GetAuthorStats(ctx, ""); !errors.Is(err, ErrValidation) {
+			t.Errorf("empty user id: got %v, want ErrValidation", err)
+		}
+	})
+}
+
+func TestCachedAuthorStats(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryConfigManager()
+	c := NewCachedConfigManager(m, 10, time.Minute)
+	newTestConfig(t, m, "alice", false)
+
+	stats, err := c.GetAuthorStats(ctx, "alice")
+	if err != nil || stats.PublicConfigs != 1 {
+		t.Fatalf("first read = %+v, %v", stats, err)
+	}
+	stats.PublicConfigs = 99
+
+	// Served from the cache until the entry expires, without the caller's changes
+	newTestConfig(t, m, "alice", false)
+	stats, err = c.GetAuthorStats(ctx, "alice")
+	if err != nil || stats.PublicConfigs != 1 {
+		t.Errorf("cached read = %+v, %v, want the first count", stats, err)
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("cache stats = %+v", s)
+	}
+}
@@ -0,0 +1,319 @@
+package hyprconfig
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// CacheStats reports how the read-through cache of a CachedConfigManager is doing.
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// CachedConfigManager wraps a ConfigManager with an in-process LRU cache for GetConfig,
+// ListAllowedPrograms and GetAuthorStats. Only public configs are cached, so a cached entry is
+// visible to everybody and an owner's view of a private config is never served to someone else.
+// Entries are dropped when the config or the allowed programs are changed through this manager,
+// and expire after the TTL to pick up changes made by other instances. Author stats are dropped
+// when the author is followed or unfollowed and otherwise only expire.
+type CachedConfigManager struct {
+	ConfigManager
+
+	configs  *lruCache[*HyprConfig]
+	programs *lruCache[[]AllowedPrograms]
+	authors  *lruCache[*AuthorStats]
+	hits     atomic.Uint64
+	misses   atomic.Uint64
+}
+
+// allowedProgramsKey is the single key used in the allowed programs cache.
+const allowedProgramsKey = "all"
+
+// NewCachedConfigManager wraps next with a cache holding up to size configs for ttl.
+func NewCachedConfigManager(next ConfigManager, size int, ttl time.Duration) *CachedConfigManager {
+	return &CachedConfigManager{
+		ConfigManager: next,
+		configs:       newLRUCache[*HyprConfig](size, ttl),
+		programs:      newLRUCache[[]AllowedPrograms](1, ttl),
+		authors:       newLRUCache[*AuthorStats](size, ttl),
+	}
+}
+
+// Stats returns the hit and miss counters since the manager was created.
+func (c *CachedConfigManager) Stats() CacheStats {
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: c.configs.len(),
+	}
+}
+
+func (c *CachedConfigManager) GetConfig(ctx context.Context, id string) (*HyprConfig, error) {
+	if cfg, ok := c.configs.get(id); ok {
+		c.hits.Add(1)
+		return cloneConfig(cfg)
+	}
+	c.misses.Add(1)
+
+	cfg, err := c.ConfigManager.GetConfig(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Private {
+		if cached, err := cloneConfig(cfg); err == nil {
+			c.configs.put(id, cached)
+		}
+	}
+	return cfg, nil
+}
+
+func (c *CachedConfigManager) ListAllowedPrograms(ctx context.Context) ([]AllowedPrograms, error) {
+	if programs, ok := c.programs.get(allowedProgramsKey); ok {
+		c.hits.Add(1)
+		return append([]AllowedPrograms(nil), programs...), nil
+	}
+	c.misses.Add(1)
+
+	programs, err := c.ConfigManager.ListAllowedPrograms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.programs.put(allowedProgramsKey, append([]AllowedPrograms(nil), programs...))
+	return programs, nil
+}
+
+func (c *CachedConfigManager) GetAuthorStats(ctx context.Context, ownerID string) (*AuthorStats, error) {
+	if stats, ok := c.authors.get(ownerID); ok {
+		c.hits.Add(1)
+		copied := *stats
+		return &copied, nil
+	}
+	c.misses.Add(1)
+
+	stats, err := c.ConfigManager.GetAuthorStats(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	cached := *stats
+	c.authors.put(ownerID, &cached)
+	return stats, nil
+}
+
+func (c *CachedConfigManager) FollowAuthor(ctx context.Context, ownerID string) error {
+	defer c.authors.remove(ownerID)
+	return c.ConfigManager.FollowAuthor(ctx, ownerID)
+}
+
+func (c *CachedConfigManager) UnfollowAuthor(ctx context.Context, ownerID string) error {
+	defer c.authors.remove(ownerID)
+	return c.ConfigManager.UnfollowAuthor(ctx, ownerID)
+}
+
+// invalidate drops configID from the cache and passes err through. It is called whether or not
+// the mutation failed, since a failed mutation may still have been partly applied.
+func (c *CachedConfigManager) invalidate(configID string, err error) error {
+	c.configs.remove(configID)
+	return err
+}
+
+// invalidatePrograms drops the cached allowed programs.
+func (c *CachedConfigManager) invalidatePrograms() {
+	c.programs.remove(allowedProgramsKey)
+}
+
+func (c *CachedConfigManager) UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) error {
+	return c.invalidate(id, c.ConfigManager.UpdateConfig(ctx, id, updates, opts))
+}
+
+func (c *CachedConfigManager) DeleteConfig(ctx context.Context, id string) error {
+	return c.invalidate(id, c.ConfigManager.DeleteConfig(ctx, id))
+}
+
+func (c *CachedConfigManager) AddGalleryImage(ctx context.Context, configID string, data []byte) (*GalleryImage, error) {
+	img, err := c.ConfigManager.AddGalleryImage(ctx, configID, data)
+	return img, c.invalidate(configID, err)
+}
+
+func (c *CachedConfigManager) RemoveGalleryImage(ctx context.Context, configID string, index int) error {
+	return c.invalidate(configID, c.ConfigManager.RemoveGalleryImage(ctx, configID, index))
+}
+
+func (c *CachedConfigManager) VerifyConfig(ctx context.Context, configID string) error {
+	return c.invalidate(configID, c.ConfigManager.VerifyConfig(ctx, configID))
+}
+
+func (c *CachedConfigManager) UnverifyConfig(ctx context.Context, configID string) error {
+	return c.invalidate(configID, c.ConfigManager.UnverifyConfig(ctx, configID))
+}
+
+func (c *CachedConfigManager) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) error {
+	return c.invalidate(configID, c.ConfigManager.DeprecateConfig(ctx, configID, successorID, message))
+}
+
+func (c *CachedConfigManager) FavoriteConfig(ctx context.Context, configID string) error {
+	return c.invalidate(configID, c.ConfigManager.FavoriteConfig(ctx, configID))
+}
+
+func (c *CachedConfigManager) UnfavoriteConfig(ctx context.Context, configID string) error {
+	return c.invalidate(configID, c.ConfigManager.UnfavoriteConfig(ctx, configID))
+}
+
+func (c *CachedConfigManager) AddProgramConfig(
+	ctx context.Context,
+	configID string,
+	newProg HyprProgramConfig,
+	parentID *string,
+	changelog string,
+) error {
+	return c.invalidate(configID, c.ConfigManager.AddProgramConfig(ctx, configID, newProg, parentID, changelog))
+}
+
+func (c *CachedConfigManager) RemoveProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	changelog string,
+) error {
+	return c.invalidate(configID, c.ConfigManager.RemoveProgramConfig(ctx, configID, progID, changelog))
+}
+
+func (c *CachedConfigManager) MoveProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	newParentID *string,
+	changelog string,
+) error {
+	return c.invalidate(configID, c.ConfigManager.MoveProgramConfig(ctx, configID, progID, newParentID, changelog))
+}
+
+func (c *CachedConfigManager) UpdateProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	updates HyprProgramConfig,
+	changelog string,
+) error {
+	return c.invalidate(configID, c.ConfigManager.UpdateProgramConfig(ctx, configID, progID, updates, changelog))
+}
+
+func (c *CachedConfigManager) AddAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+	defer c.invalidatePrograms()
+	return c.ConfigManager.AddAllowedProgram(ctx, programName)
+}
+
+func (c *CachedConfigManager) RemoveAllowedProgram(ctx context.Context, programName string) error {
+	defer c.invalidatePrograms()
+	return c.ConfigManager.RemoveAllowedProgram(ctx, programName)
+}
+
+func (c *CachedConfigManager) ImportAllowedPrograms(
+	ctx context.Context,
+	programs []AllowedPrograms,
+	upsert bool,
+) ([]ProgramImportResult, error) {
+	defer c.invalidatePrograms()
+	return c.ConfigManager.ImportAllowedPrograms(ctx, programs, upsert)
+}
+
+func (c *CachedConfigManager) SeedDefaultPrograms(ctx context.Context) ([]ProgramImportResult, error) {
+	defer c.invalidatePrograms()
+	return c.ConfigManager.SeedDefaultPrograms(ctx)
+}
+
+func (c *CachedConfigManager) ApproveProgramRequest(ctx context.Context, requestID string) (*AllowedPrograms, error) {
+	defer c.invalidatePrograms()
+	return c.ConfigManager.ApproveProgramRequest(ctx, requestID)
+}
+
+// lruCache is a fixed size, least recently used cache whose entries expire after ttl.
+// A size below 1 disables caching.
+type lruCache[V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newLRUCache[V any](size int, ttl time.Duration) *lruCache[V] {
+	return &lruCache[V]{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: map[string]*list.Element{},
+		now:   time.Now,
+	}
+}
+
+func (l *lruCache[V]) get(key string) (V, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var zero V
+	el, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*lruEntry[V])
+	if l.ttl > 0 && l.now().After(entry.expires) {
+		l.order.Remove(el)
+		delete(l.items, key)
+		return zero, false
+	}
+	l.order.MoveToFront(el)
+	return entry.value, true
+}
+
+func (l *lruCache[V]) put(key string, value V) {
+	if l.size < 1 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expires := l.now().Add(l.ttl)
+	if el, ok := l.items[key]; ok {
+		entry := el.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expires = expires
+		l.order.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+func (l *lruCache[V]) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		l.order.Remove(el)
+		delete(l.items, key)
+	}
+}
+
+func (l *lruCache[V]) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
@@ -0,0 +1,101 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// countingManager counts the GetConfig calls reaching the wrapped manager.
+type countingManager struct {
+	ConfigManager
+	gets int
+}
+
+func (c *countingManager) GetConfig(ctx context.Context, id string) (*HyprConfig, error) {
+	c.gets++
+	return c.ConfigManager.GetConfig(ctx, id)
+}
+
+func TestCachedConfigManager(t *testing.T) {
+	next := &countingManager{ConfigManager: NewInMemoryConfigManager()}
+	c := NewCachedConfigManager(next, 10, time.Minute)
+	cfg := newTestConfig(t, c, "alice", false)
+	anon := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetConfig(anon, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if next.gets != 1 {
+		t.Errorf("backend reads = %d, want 1", next.gets)
+	}
+	if s := c.Stats(); s.Hits != 2 || s.Misses != 1 || s.Entries != 1 {
+		t.Errorf("stats = %+v", s)
+	}
+
+	got, _ := c.GetConfig(anon, cfg.ID)
+	got.Title = "changed"
+	if again, _ := c.GetConfig(anon, cfg.ID); again.Title != "rice" {
+		t.Error("cached config was mutated through a returned copy")
+	}
+
+	if err := c.UpdateConfig(asUser("alice"), cfg.ID, map[string]any{"title": "riced"}, UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetConfig(anon, cfg.ID); got.Title != "riced" {
+		t.Errorf("title after update = %q, want the updated one", got.Title)
+	}
+	if err := c.FavoriteConfig(asUser("bob"), cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.GetConfig(anon, cfg.ID); got.Likes != 1 {
+		t.Errorf("likes after favorite = %d, want 1", got.Likes)
+	}
+}
+
+func TestCachedConfigManagerSkipsPrivateConfigs(t *testing.T) {
+	next := &countingManager{ConfigManager: NewInMemoryConfigManager()}
+	c := NewCachedConfigManager(next, 10, time.Minute)
+	cfg := newTestConfig(t, c, "alice", true)
+
+	if _, err := c.GetConfig(asUser("alice"), cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetConfig(asUser("bob"), cfg.ID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("other user after owner read: got %v, want ErrForbidden", err)
+	}
+	if next.gets != 2 {
+		t.Errorf("backend reads = %d, want 2", next.gets)
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	now := time.Now()
+	l := newLRUCache[int](2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	l.put("a", 1)
+	l.put("b", 2)
+	l.get("a")
+	l.put("c", 3)
+	if _, ok := l.get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if v, ok := l.get("a"); !ok || v != 1 {
+		t.Errorf("get(a) = %d, %v", v, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := l.get("a"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	disabled := newLRUCache[int](0, time.Minute)
+	disabled.put("a", 1)
+	if _, ok := disabled.get("a"); ok {
+		t.Error("a cache of size 0 should not store anything")
+	}
+}
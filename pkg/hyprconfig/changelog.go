@@ -0,0 +1,79 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxChangelogEntries caps the changelog stored on a config; older entries are rolled off.
+const MaxChangelogEntries = 100
+
+// withChangelog adds a capped $push of a changelog entry to an update document, next to any
+// other $push it already has.
+// Nothing is recorded when message is empty.
+func withChangelog(update bson.M, version, message, actor string) bson.M {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return update
+	}
+
+	push, _ := update["$push"].(bson.M)
+	if push == nil {
+		push = bson.M{}
+		update["$push"] = push
+	}
+	push["changelog"] = bson.M{
+		"$each": []ChangelogEntry{{
+			Version:   version,
+			Message:   message,
+			Actor:     actor,
+			Timestamp: time.Now(),
+		}},
+		"$slice": -MaxChangelogEntries,
+	}
+	return update
+}
+
+// GetChangelog returns the changelog of a config, newest entry first.
+func (m *ConfigManagerMongo) GetChangelog(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[ChangelogEntry], error) {
+	user, _ := getUserFromContext(ctx) // user may be nil for public configs
+
+	var cfg HyprConfig
+	err := m.Collection.FindOne(ctx, bson.M{"_id": configID},
+		options.FindOne().SetProjection(bson.M{
+			"changelog": 1,
+			"private":   1,
+			"owner_id":  1,
+		}),
+	).Decode(&cfg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return mserve.Page[ChangelogEntry]{}, ErrNotFound
+	} else if err != nil {
+		return mserve.Page[ChangelogEntry]{}, err
+	}
+
+	// PRIVATE CONFIG CHECK
+	if cfg.Private {
+		if user == nil || (cfg.OwnerID != user.UserID && !isAdmin(user.Roles)) {
+			return mserve.Page[ChangelogEntry]{}, ErrForbidden
+		}
+	}
+
+	entries := make([]ChangelogEntry, 0, len(cfg.Changelog))
+	for i := len(cfg.Changelog) - 1; i >= 0; i-- {
+		entries = append(entries, cfg.Changelog[i])
+	}
+
+	return mserve.Paginate(entries, page, limit)
+}
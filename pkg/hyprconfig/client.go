@@ -4,7 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
+	"log/slog"
+	"regexp"
 	"strings"
 	"time"
 
@@ -19,31 +20,135 @@ var (
 	ErrUnauthorized = errors.New("unauthorized")
 	ErrForbidden    = errors.New("forbidden")
 	ErrNotFound     = errors.New("not found")
+
+	// ErrValidation matches every error caused by invalid input.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrAllowlistDisabled is returned by allowed program operations of a manager created without
+	// a programs collection.
+	ErrAllowlistDisabled = errors.New("the allowed program list is not configured, only built-in programs are allowed")
 )
 
 type ConfigManagerMongo struct {
-	Collection          *mongo.Collection // configs
-	FavoritesCollection *mongo.Collection // user_favorites
-	StateCollection     *mongo.Collection // user_hypr_state
-	ProgramsCollection  *mongo.Collection // allowed_programs
+	Collection                  *mongo.Collection // configs
+	FavoritesCollection         *mongo.Collection // user_favorites
+	StateCollection             *mongo.Collection // user_hypr_state
+	ProgramsCollection          *mongo.Collection // allowed_programs
+	ProgramRequestsCollection   *mongo.Collection // program_requests
+	AuditCollection             *mongo.Collection // audit_log
+	WebhooksCollection          *mongo.Collection // webhooks
+	WebhookDeliveriesCollection *mongo.Collection // webhook_deliveries
+	APIKeysCollection           *mongo.Collection // api_keys
+	ShareLinksCollection        *mongo.Collection // share_links
+	QuotaCollection             *mongo.Collection // user_quota
+	FollowsCollection           *mongo.Collection // follows
+	FollowerCountsCollection    *mongo.Collection // follower_counts
+	NotificationsCollection     *mongo.Collection // notifications
+
+	limits           SizeLimits
+	files            FileStore // nil disables offloading
+	offloadThreshold int64
+	logger           *slog.Logger
+	programCacheTTL  time.Duration
+	programs         *ProgramRegistry
+}
+
+// Option configures optional behaviour of the config manager.
+type Option func(*ConfigManagerMongo)
+
+// WithSizeLimits overrides DefaultSizeLimits for file content.
+func WithSizeLimits(limits SizeLimits) Option {
+	return func(m *ConfigManagerMongo) {
+		m.limits = limits
+	}
+}
+
+// WithFileStore replaces the default GridFS file store. Passing nil keeps all content inline.
+func WithFileStore(store FileStore) Option {
+	return func(m *ConfigManagerMongo) {
+		m.files = store
+	}
+}
+
+// WithOffloadThreshold sets the size above which file content is moved to the file store.
+func WithOffloadThreshold(n int64) Option {
+	return func(m *ConfigManagerMongo) {
+		m.offloadThreshold = n
+	}
+}
+
+// WithBinaryContent allows or rejects FileTypeBinary content regardless of the configured limits.
+func WithBinaryContent(allow bool) Option {
+	return func(m *ConfigManagerMongo) {
+		m.limits.AllowBinary = allow
+	}
 }
 
+// WithUnsafeCommandPatterns replaces the patterns Args and exec lines of public configs are
+// screened with, DefaultUnsafeCommandPatterns by default. No patterns disables the screening.
+func WithUnsafeCommandPatterns(patterns ...string) Option {
+	return func(m *ConfigManagerMongo) {
+		m.limits.UnsafeCommandPatterns = patterns
+	}
+}
+
+// WithProgramCacheTTL sets how long allowed program lookups are cached, DefaultProgramCacheTTL by
+// default. Zero disables the cache.
+func WithProgramCacheTTL(ttl time.Duration) Option {
+	return func(m *ConfigManagerMongo) {
+		m.programCacheTTL = ttl
+	}
+}
+
+// NewConfigManager creates the Mongo backed ConfigManager and ensures its indexes.
+// A nil programs collection disables the allowed program list: configs may only use the built-in
+// programs and managing allowed programs or program requests fails with ErrAllowlistDisabled.
+// Collections that are not passed in explicitly (program_requests, audit_log,
+// webhooks, webhook_deliveries, api_keys, share_links, user_quota) and the GridFS
+// bucket for large files are created in the same database as configs.
 func NewConfigManager(
 	configs *mongo.Collection,
 	favorites *mongo.Collection,
 	state *mongo.Collection,
-	programs *mongo.Collection, // NEW parameter
+	programs *mongo.Collection, // nil disables the allowed program list
+	opts ...Option,
 ) (ConfigManager, error) {
 
 	if configs == nil || favorites == nil || state == nil {
 		return nil, errors.New("config manager: all collections must be non-nil")
 	}
 
+	db := configs.Database()
+	files, err := NewGridFSStore(db, DefaultFileBucket)
+	if err != nil {
+		return nil, err
+	}
 	m := &ConfigManagerMongo{
-		Collection:          configs,
-		FavoritesCollection: favorites,
-		StateCollection:     state,
-		ProgramsCollection:  programs,
+		Collection:                  configs,
+		FavoritesCollection:         favorites,
+		StateCollection:             state,
+		ProgramsCollection:          programs,
+		ProgramRequestsCollection:   db.Collection("program_requests"),
+		AuditCollection:             db.Collection("audit_log"),
+		WebhooksCollection:          db.Collection("webhooks"),
+		WebhookDeliveriesCollection: db.Collection("webhook_deliveries"),
+		APIKeysCollection:           db.Collection("api_keys"),
+		ShareLinksCollection:        db.Collection("share_links"),
+		QuotaCollection:             db.Collection("user_quota"),
+		FollowsCollection:           db.Collection("follows"),
+		FollowerCountsCollection:    db.Collection("follower_counts"),
+		NotificationsCollection:     db.Collection("notifications"),
+		limits:                      DefaultSizeLimits(),
+		files:                       files,
+		offloadThreshold:            DefaultOffloadThreshold,
+		programCacheTTL:             DefaultProgramCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.programs = NewProgramRegistry(m.lookupAllowedPrograms, m.listAllowedProgramNames, m.programCacheTTL)
+	if err := ValidateCommandPatterns(m.limits.UnsafeCommandPatterns); err != nil {
+		return nil, err
 	}
 
 	// Create all required indexes
@@ -54,122 +159,380 @@ func NewConfigManager(
 	return m, nil
 }
 
+// Server error codes for an index that exists with other options or keys under the same name.
+const (
+	codeIndexOptionsConflict  = 85
+	codeIndexKeySpecsConflict = 86
+	codeIndexAlreadyExists    = 68
+)
+
+// collectionIndexes are the indexes ensureIndexes creates on one collection.
+type collectionIndexes struct {
+	name       string
+	collection *mongo.Collection
+	models     []mongo.IndexModel
+}
+
+// ensureIndexes creates every index the manager relies on. Creating an existing index is a
+// no-op, so it is safe on every startup; an index that exists with different options is
+// logged and left alone rather than failing startup.
 func (m *ConfigManagerMongo) ensureIndexes(ctx context.Context) error {
+	for _, ci := range m.indexes() {
+		if ci.collection == nil {
+			continue
+		}
+		for _, model := range ci.models {
+			if model.Options == nil {
+				model.Options = options.Index()
+			}
+			model.Options.SetBackground(true)
 
-	// ---------------------------
-	// CONFIGS COLLECTION INDEXES
-	// ---------------------------
-	_, err := m.ProgramsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		// Ensure program names are unique
-		{
-			Keys:    bson.D{{"program_name", 1}},
-			Options: options.Index().SetUnique(true).SetName("uid_program_name"),
-		},
-	})
+			_, err := ci.collection.Indexes().CreateOne(ctx, model)
+			if isIndexConflict(err) {
+				slog.Warn("keeping existing index with different options", "collection", ci.name, "index", indexName(model), "err", err)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("%s index %s error: %w", ci.name, indexName(model), err)
+			}
+		}
+	}
+	return nil
+}
 
-	if err != nil {
-		return fmt.Errorf("programs index error: %w", err)
+// isIndexConflict reports whether err means an index with the same name or keys already exists
+// with other options.
+func isIndexConflict(err error) bool {
+	var se mongo.ServerError
+	if !errors.As(err, &se) {
+		return false
 	}
+	return se.HasErrorCode(codeIndexOptionsConflict) ||
+		se.HasErrorCode(codeIndexKeySpecsConflict) ||
+		se.HasErrorCode(codeIndexAlreadyExists)
+}
 
-	_, err = m.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		// Sort by likes
+func indexName(model mongo.IndexModel) string {
+	if model.Options != nil && model.Options.Name != nil {
+		return *model.Options.Name
+	}
+	return fmt.Sprint(model.Keys)
+}
+
+// indexes lists the indexes of every collection the manager uses.
+func (m *ConfigManagerMongo) indexes() []collectionIndexes {
+	return []collectionIndexes{
 		{
-			Keys:    bson.D{{"likes", -1}},
-			Options: options.Index().SetName("idx_likes_desc"),
+			name:       "programs",
+			collection: m.ProgramsCollection,
+			models: []mongo.IndexModel{
+				// Ensure program names are unique
+				{
+					Keys:    bson.D{{Key: "program_name", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("uid_program_name"),
+				},
+			},
 		},
-		// Sort by updated time
 		{
-			Keys:    bson.D{{"updated_timestamp", -1}},
-			Options: options.Index().SetName("idx_updated_desc"),
+			name:       "config",
+			collection: m.Collection,
+			models: []mongo.IndexModel{
+				// Sort by likes
+				{
+					Keys:    bson.D{{Key: "likes", Value: -1}},
+					Options: options.Index().SetName("idx_likes_desc"),
+				},
+				// Sort by updated time
+				{
+					Keys:    bson.D{{Key: "updated_timestamp", Value: -1}},
+					Options: options.Index().SetName("idx_updated_desc"),
+				},
+				// A user's own configs, newest first
+				{
+					Keys:    bson.D{{Key: "owner_id", Value: 1}, {Key: "updated_timestamp", Value: -1}},
+					Options: options.Index().SetName("idx_owner_updated"),
+				},
+				// Public listings, newest first
+				{
+					Keys:    bson.D{{Key: "private", Value: 1}, {Key: "updated_timestamp", Value: -1}},
+					Options: options.Index().SetName("idx_private_updated"),
+				},
+				// Tag filters
+				{
+					Keys:    bson.D{{Key: "tags", Value: 1}},
+					Options: options.Index().SetName("idx_tags"),
+				},
+				// Text search support (title, description, tags)
+				{
+					Keys: bson.D{
+						{Key: "title", Value: "text"},
+						{Key: "description", Value: "text"},
+						{Key: "tags", Value: "text"},
+					},
+					Options: options.Index().SetName("idx_text_search"),
+				},
+				// Duplicate and similar config lookups
+				{
+					Keys:    bson.D{{Key: "fingerprint", Value: 1}, {Key: "owner_id", Value: 1}},
+					Options: options.Index().SetName("idx_fingerprint_owner").SetSparse(true),
+				},
+			},
 		},
-		// Text search support (title, description, tags)
 		{
-			Keys: bson.D{
-				{"title", "text"},
-				{"description", "text"},
-				{"tags", "text"},
+			name:       "favorites",
+			collection: m.FavoritesCollection,
+			models: []mongo.IndexModel{
+				// Prevent duplicate favorites: (user_id, config_id)
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+						{Key: "config_id", Value: 1},
+					},
+					Options: options.Index().
+						SetUnique(true).
+						SetName("uid_config_unique"),
+				},
+				// Lookup favorites by config (for like rebuild)
+				{
+					Keys:    bson.D{{Key: "config_id", Value: 1}},
+					Options: options.Index().SetName("config_id_idx"),
+				},
 			},
-			Options: options.Index().SetName("idx_text_search"),
 		},
-	})
-	if err != nil {
-		return fmt.Errorf("config index error: %w", err)
-	}
-
-	// -------------------------------------
-	// FAVORITES COLLECTION INDEXES
-	// -------------------------------------
-
-	_, err = m.FavoritesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		// Prevent duplicate favorites: (user_id, config_id)
 		{
-			Keys: bson.D{
-				{"user_id", 1},
-				{"config_id", 1},
+			name:       "state",
+			collection: m.StateCollection,
+			models: []mongo.IndexModel{
+				// Each user can have only ONE applied config
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+					},
+					Options: options.Index().
+						SetUnique(true).
+						SetName("user_unique"),
+				},
+				// Lookup who has a config applied
+				{
+					Keys:    bson.D{{Key: "config_id", Value: 1}},
+					Options: options.Index().SetName("config_id_idx"),
+				},
 			},
-			Options: options.Index().
-				SetUnique(true).
-				SetName("uid_config_unique"),
 		},
-		// Lookup favorites by config (for like rebuild)
 		{
-			Keys:    bson.D{{"config_id", 1}},
-			Options: options.Index().SetName("config_id_idx"),
+			name:       "program requests",
+			collection: m.ProgramRequestsCollection,
+			models: []mongo.IndexModel{
+				// Admin review queue: filter by status, newest first
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "created_timestamp", Value: -1},
+					},
+					Options: options.Index().SetName("status_created_idx"),
+				},
+				// Lookup pending requests for a program
+				{
+					Keys:    bson.D{{Key: "program_name", Value: 1}},
+					Options: options.Index().SetName("program_name_idx"),
+				},
+			},
 		},
-	})
-
-	if err != nil {
-		return fmt.Errorf("favorites index error: %w", err)
-	}
-
-	// -------------------------------------
-	// USER STATE COLLECTION INDEXES
-	// -------------------------------------
-
-	_, err = m.StateCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		// Each user can have only ONE applied config
 		{
-			Keys: bson.D{
-				{"user_id", 1},
+			name:       "audit log",
+			collection: m.AuditCollection,
+			models: []mongo.IndexModel{
+				// Audit log of a config, newest first
+				{
+					Keys: bson.D{
+						{Key: "config_id", Value: 1},
+						{Key: "timestamp", Value: -1},
+					},
+					Options: options.Index().SetName("config_timestamp_idx"),
+				},
 			},
-			Options: options.Index().
-				SetUnique(true).
-				SetName("user_unique"),
 		},
-		// Lookup who has a config applied
 		{
-			Keys:    bson.D{{"config_id", 1}},
-			Options: options.Index().SetName("config_id_idx"),
+			name:       "webhooks",
+			collection: m.WebhooksCollection,
+			models: []mongo.IndexModel{
+				// Webhooks of the users subscribed to a config
+				{
+					Keys:    bson.D{{Key: "owner_id", Value: 1}},
+					Options: options.Index().SetName("owner_id_idx"),
+				},
+			},
+		},
+		{
+			name:       "webhook deliveries",
+			collection: m.WebhookDeliveriesCollection,
+			models: []mongo.IndexModel{
+				// Delivery queue: pending deliveries that are due
+				{
+					Keys: bson.D{
+						{Key: "status", Value: 1},
+						{Key: "next_attempt", Value: 1},
+					},
+					Options: options.Index().SetName("status_next_attempt_idx"),
+				},
+				// Delivery log of a webhook, newest first
+				{
+					Keys: bson.D{
+						{Key: "webhook_id", Value: 1},
+						{Key: "created_timestamp", Value: -1},
+					},
+					Options: options.Index().SetName("webhook_created_idx"),
+				},
+			},
+		},
+		{
+			name:       "api keys",
+			collection: m.APIKeysCollection,
+			models: []mongo.IndexModel{
+				// Resolve a presented token by its hash
+				{
+					Keys:    bson.D{{Key: "hash", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("hash_unique"),
+				},
+				// Keys of a user
+				{
+					Keys:    bson.D{{Key: "owner_id", Value: 1}},
+					Options: options.Index().SetName("owner_id_idx"),
+				},
+			},
+		},
+		{
+			name:       "share links",
+			collection: m.ShareLinksCollection,
+			models: []mongo.IndexModel{
+				// Resolve a presented token by its hash
+				{
+					Keys:    bson.D{{Key: "hash", Value: 1}},
+					Options: options.Index().SetUnique(true).SetName("hash_unique"),
+				},
+				// Links of a config
+				{
+					Keys:    bson.D{{Key: "config_id", Value: 1}},
+					Options: options.Index().SetName("config_id_idx"),
+				},
+			},
+		},
+		{
+			name:       "follows",
+			collection: m.FollowsCollection,
+			models: []mongo.IndexModel{
+				// Prevent duplicate follows: (follower_id, followee_id)
+				{
+					Keys: bson.D{
+						{Key: "follower_id", Value: 1},
+						{Key: "followee_id", Value: 1},
+					},
+					Options: options.Index().SetUnique(true).SetName("follower_followee_unique"),
+				},
+				// Followers of a user
+				{
+					Keys:    bson.D{{Key: "followee_id", Value: 1}},
+					Options: options.Index().SetName("followee_id_idx"),
+				},
+			},
+		},
+		{
+			name:       "notifications",
+			collection: m.NotificationsCollection,
+			models: []mongo.IndexModel{
+				// Inbox of a user, newest first
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+						{Key: "created_timestamp", Value: -1},
+					},
+					Options: options.Index().SetName("user_created_idx"),
+				},
+				// Unread count of a user
+				{
+					Keys: bson.D{
+						{Key: "user_id", Value: 1},
+						{Key: "read_at", Value: 1},
+					},
+					Options: options.Index().SetName("user_read_at_idx"),
+				},
+			},
 		},
-	})
-
-	if err != nil {
-		return fmt.Errorf("state index error: %w", err)
 	}
-
-	return nil
 }
 
-func (m *ConfigManagerMongo) CreateConfig(ctx context.Context, cfg *HyprConfig) (*HyprConfig, error) {
+func (m *ConfigManagerMongo) CreateConfig(ctx context.Context, cfg *HyprConfig) (_ *HyprConfig, err error) {
+	defer func() { m.logMutation(ctx, "CreateConfig", err, slog.String("config_id", cfg.ID)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if err := m.checkConfigLimit(ctx, user.UserID, user.Roles); err != nil {
+		return nil, err
+	}
 
 	cfg.ID = uuid.New().String()
 	cfg.OwnerID = user.UserID
+	cfg.SchemaVersion = CurrentSchemaVersion
 	cfg.CreatedTimestamp = time.Now()
 	cfg.UpdatedTimestamp = time.Now()
+	cfg.Changelog = nil
+	cfg.GalleryImages = nil
+	cfg.Deprecation = nil
+	cfg.clearVerification()
+	if cfg.Version == "" {
+		cfg.Version = DefaultConfigVersion
+	}
+	if err := ValidateVersion(cfg.Version); err != nil {
+		return nil, invalidf("config validation failed: %w", err)
+	}
+	// Hashes and validation always work on the uncompressed data
+	if err := cfg.decompressUpload(m.limits); err != nil {
+		return nil, err
+	}
+	for i := range cfg.ProgramConfigs {
+		if err := cfg.ProgramConfigs[i].resolveFileRefs(nil); err != nil {
+			return nil, invalidf("config validation failed: %w", err)
+		}
+		cfg.ProgramConfigs[i].populateHashes()
+	}
 	// --- NEW VALIDATION STEP ---
-	if err := cfg.Validate(m.checkProgramExists); err != nil {
+	if err := cfg.Validate(ctx, m.registry().Allowed, m.limits); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 	// ---------------------------
+	cfg.refreshDerived()
+	if err := m.checkDuplicate(ctx, cfg); err != nil {
+		return nil, err
+	}
+	size := cfg.contentSize()
+	if err := m.checkQuota(ctx, user.UserID, size); err != nil {
+		return nil, err
+	}
+	var uploaded []string
+	for i := range cfg.ProgramConfigs {
+		ids, err := m.offloadFiles(ctx, &cfg.ProgramConfigs[i])
+		uploaded = append(uploaded, ids...)
+		if err != nil {
+			m.deleteFiles(ctx, uploaded)
+			return nil, err
+		}
+	}
+	if err := cfg.compressContent(); err != nil {
+		m.deleteFiles(ctx, uploaded)
+		return nil, err
+	}
 	_, err = m.Collection.InsertOne(ctx, cfg)
 	if err != nil {
+		m.deleteFiles(ctx, uploaded)
 		return nil, err
 	}
+	m.addUsage(ctx, user.UserID, size)
+	m.audit(ctx, newAuditEntry(user.UserID, AuditCreateConfig, cfg.ID, "", nil))
 
+	if err := decodeForRead(ctx, cfg); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -185,15 +548,77 @@ func (m *ConfigManagerMongo) GetConfig(ctx context.Context, id string) (*HyprCon
 	}
 
 	// PRIVATE CONFIG CHECK
-	if cfg.Private {
-		if user == nil || (cfg.OwnerID != user.UserID && !isAdmin(user.Roles)) {
-			return nil, ErrForbidden
+	if !canRead(&cfg, user) {
+		err := sharedRead(ctx, cfg.ID, func(hash string) (*ShareLink, error) {
+			return m.shareLink(ctx, hash)
+		})
+		if err != nil {
+			return nil, err
 		}
 	}
+
+	if err := decodeForRead(ctx, &cfg); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
 
-func (m *ConfigManagerMongo) UpdateConfig(ctx context.Context, id string, updates bson.M) error {
+// SizeLimits returns the file content limits enforced by this manager.
+func (m *ConfigManagerMongo) SizeLimits() SizeLimits {
+	return m.limits
+}
+
+// GetProgramConfig returns a single program config (searching nested sub-configs) from a config
+// the caller is allowed to see.
+func (m *ConfigManagerMongo) GetProgramConfig(ctx context.Context, configID, progID string) (*HyprProgramConfig, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	prog := findProgramConfig(cfg.ProgramConfigs, progID)
+	if prog == nil {
+		return nil, ErrNotFound
+	}
+	if err := m.hydrateFiles(ctx, prog); err != nil {
+		return nil, err
+	}
+	return prog, nil
+}
+
+// findProgramConfig searches the program config tree for progID.
+func findProgramConfig(list []HyprProgramConfig, progID string) *HyprProgramConfig {
+	for i := range list {
+		if list[i].ID == progID {
+			return &list[i]
+		}
+		if found := findNestedProgramConfig(list[i].SubConfigs, progID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findNestedProgramConfig(list []*HyprProgramConfig, progID string) *HyprProgramConfig {
+	for _, pc := range list {
+		if pc == nil {
+			continue
+		}
+		if pc.ID == progID {
+			return pc
+		}
+		if found := findNestedProgramConfig(pc.SubConfigs, progID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func (m *ConfigManagerMongo) UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) (err error) {
+	fields := updateFields(updates)
+	defer func() {
+		m.logMutation(ctx, "UpdateConfig", err, slog.String("config_id", id), slog.Any("fields", fields))
+	}()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
@@ -213,9 +638,15 @@ func (m *ConfigManagerMongo) UpdateConfig(ctx context.Context, id string, update
 	if existing.OwnerID != user.UserID && !isAdmin(user.Roles) {
 		return ErrForbidden
 	}
+	if err := existing.decompressUpload(m.limits); err != nil {
+		return err
+	}
 
 	// Determine semantic version bump
-	newVersion := bumpPatchVersion(existing.Version)
+	newVersion, err := bumpVersion(existing.Version, opts.VersionBump)
+	if err != nil {
+		return fmt.Errorf("failed to bump version: %w", err)
+	}
 	updates["version"] = newVersion
 	updates["updated_timestamp"] = time.Now()
 
@@ -224,8 +655,18 @@ func (m *ConfigManagerMongo) UpdateConfig(ctx context.Context, id string, update
 	delete(updates, "owner_id")
 	delete(updates, "likes")
 	delete(updates, "created_timestamp")
+	delete(updates, "changelog")
 	// WARNING: Assuming program_configs are updated via separate endpoints
 	delete(updates, "program_configs")
+	delete(updates, "gallery_images")
+	delete(updates, "fingerprint")
+	delete(updates, "hyprland_version_key")
+	delete(updates, "deprecation")
+	delete(updates, "deprecated")
+	delete(updates, "schema_version")
+	for _, f := range verificationFields {
+		delete(updates, f)
+	}
 
 	// --- NEW VALIDATION STEP ---
 	// 1. Create a merged config for validation
@@ -257,37 +698,27 @@ func (m *ConfigManagerMongo) UpdateConfig(ctx context.Context, id string, update
 	}
 
 	// 4. Validate the resulting merged struct
-	if err := mergedCfg.Validate(m.checkProgramExists); err != nil {
+	if err := mergedCfg.Validate(ctx, m.registry().Allowed, m.limits); err != nil {
 		return fmt.Errorf("merged config failed validation: %w", err)
 	}
+	setMetadataUpdates(updates, &mergedCfg)
 	// ---------------------------
 
 	// Proceed with the update if validation passes
 	_, err = m.Collection.UpdateOne(ctx,
 		bson.M{"_id": id},
-		bson.M{"$set": updates},
+		withChangelog(withoutVerification(bson.M{"$set": updates}), newVersion, opts.Changelog, user.UserID),
 	)
-	return err
-}
-
-// bumpPatchVersion increases the PATCH number of a semantic version string (e.g., 1.2.3 -> 1.2.4)
-func bumpPatchVersion(v string) string {
-	parts := strings.Split(v, ".")
-	if len(parts) != 3 {
-		// fallback if version is malformed
-		return "0.0.1"
-	}
-
-	patch, err := strconv.Atoi(parts[2])
 	if err != nil {
-		patch = 0
+		return err
 	}
 
-	patch++
-	return fmt.Sprintf("%s.%s.%d", parts[0], parts[1], patch)
+	m.recordMutation(ctx, newAuditEntry(user.UserID, AuditUpdateConfig, id, "", updatedFields(updates)))
+	return nil
 }
 
-func (m *ConfigManagerMongo) DeleteConfig(ctx context.Context, id string) error {
+func (m *ConfigManagerMongo) DeleteConfig(ctx context.Context, id string) (err error) {
+	defer func() { m.logMutation(ctx, "DeleteConfig", err, slog.String("config_id", id)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
@@ -306,8 +737,43 @@ func (m *ConfigManagerMongo) DeleteConfig(ctx context.Context, id string) error
 		return ErrForbidden
 	}
 
+	if err := m.checkQuota(ctx, cfg.OwnerID, 0); err != nil {
+		return err
+	}
 	_, err = m.Collection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	if err != nil {
+		return err
+	}
+	m.addUsage(ctx, cfg.OwnerID, -cfg.contentSize())
+	m.recordMutation(ctx, newAuditEntry(user.UserID, AuditDeleteConfig, id, "", nil))
+
+	m.deleteOrphanedFiles(ctx, storedFiles(cfg.ProgramConfigs), nil)
+	m.deleteFiles(ctx, galleryFileIDs(&cfg))
+	_, _ = m.ShareLinksCollection.DeleteMany(ctx, bson.M{"config_id": id})
+	return nil
+}
+
+// listProjectionDepth is how many levels of sub configs listFindOptions strips file data from.
+// Data nested deeper is still fetched, and dropped by summarizeList.
+const listProjectionDepth = 4
+
+// listFindOptions leaves file data, readmes and keybinds out of listed configs, unless the caller asked for it with
+// WithFileContent or findOpts already has a projection.
+func listFindOptions(ctx context.Context, findOpts *options.FindOptions) *options.FindOptions {
+	if findOpts == nil {
+		findOpts = options.Find()
+	}
+	if wantsFileContent(ctx) || findOpts.Projection != nil {
+		return findOpts
+	}
+	projection := bson.M{"readme": 0, "keybinds": 0}
+	path := "program_configs"
+	for range listProjectionDepth {
+		projection[path+".file_content.data"] = 0
+		projection[path+".files.file_content.data"] = 0
+		path += ".sub_configs"
+	}
+	return findOpts.SetProjection(projection)
 }
 
 func (m *ConfigManagerMongo) ListConfigs(
@@ -340,14 +806,19 @@ func (m *ConfigManagerMongo) ListConfigs(
 	}
 
 	// Use your pagination helper
-	return mserve.PaginateMongo[HyprConfig](
+	result, err := mserve.PaginateMongo[HyprConfig](
 		ctx,
 		m.Collection,
 		filter,
 		page,
 		limit,
-		findOpts,
+		listFindOptions(ctx, findOpts),
 	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return decodeListForRead(ctx, result)
 }
 
 func (m *ConfigManagerMongo) ListMyConfigs(
@@ -370,14 +841,30 @@ func (m *ConfigManagerMongo) ListMyConfigs(
 		findOpts = options.Find().SetSort(bson.M{"updated_timestamp": -1})
 	}
 
-	return mserve.PaginateMongo[HyprConfig](
+	result, err := mserve.PaginateMongo[HyprConfig](
 		ctx,
 		m.Collection,
 		filter,
 		page,
 		limit,
-		findOpts,
+		listFindOptions(ctx, findOpts),
 	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return decodeListForRead(ctx, result)
+}
+
+// mongoSearchSort maps the SearchSort values to sort documents, ties broken by _id. Deprecated
+// configs rank last in every order.
+var mongoSearchSort = map[string]bson.D{
+	SearchSortUpdated: {{Key: "deprecated", Value: 1}, {Key: "updated_timestamp", Value: -1}, {Key: "_id", Value: 1}},
+	SearchSortCreated: {{Key: "deprecated", Value: 1}, {Key: "created_timestamp", Value: -1}, {Key: "_id", Value: 1}},
+	SearchSortLikes:   {{Key: "deprecated", Value: 1}, {Key: "likes", Value: -1}, {Key: "_id", Value: 1}},
+	SearchSortTitle:   {{Key: "deprecated", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}},
+
+	SearchSortVerified: {{Key: "deprecated", Value: 1}, {Key: "verified", Value: -1}, {Key: "likes", Value: -1}, {Key: "_id", Value: 1}},
 }
 
 func (m *ConfigManagerMongo) ListConfigsWithFilters(
@@ -389,23 +876,77 @@ func (m *ConfigManagerMongo) ListConfigsWithFilters(
 
 	user, _ := getUserFromContext(ctx) // user may be nil
 
+	if err := filters.normalize(); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	sortBy, err := searchSort(filters.Sort)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
 	filter := buildSearchFilter(filters, user)
 
 	if findOpts == nil {
-		findOpts = options.Find().SetSort(bson.M{"updated_timestamp": -1})
+		findOpts = options.Find().SetSort(mongoSearchSort[sortBy])
 	}
 
-	return mserve.PaginateMongo[HyprConfig](
+	result, err := mserve.PaginateMongo[HyprConfig](
 		ctx,
 		m.Collection,
 		filter,
 		page,
 		limit,
-		findOpts,
+		listFindOptions(ctx, findOpts),
+	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return decodeListForRead(ctx, result)
+}
+
+// AdminListConfigs lists every config matching filters, private ones of other users included.
+// Only admins may call it.
+func (m *ConfigManagerMongo) AdminListConfigs(
+	ctx context.Context,
+	page, limit int,
+	filters ConfigSearchFilters,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	if !isAdmin(user.Roles) {
+		return mserve.Page[HyprConfig]{}, ErrForbidden
+	}
+
+	if err := filters.normalize(); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	sortBy, err := searchSort(filters.Sort)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	result, err := mserve.PaginateMongo[HyprConfig](
+		ctx,
+		m.Collection,
+		buildAdminSearchFilter(filters),
+		page,
+		limit,
+		listFindOptions(ctx, options.Find().SetSort(mongoSearchSort[sortBy])),
 	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return decodeListForRead(ctx, result)
 }
 
-func (m *ConfigManagerMongo) FavoriteConfig(ctx context.Context, configID string) error {
+func (m *ConfigManagerMongo) FavoriteConfig(ctx context.Context, configID string) (err error) {
+	defer func() { m.logMutation(ctx, "FavoriteConfig", err, slog.String("config_id", configID)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
@@ -431,14 +972,24 @@ func (m *ConfigManagerMongo) FavoriteConfig(ctx context.Context, configID string
 		return err
 	}
 
-	// Increment config's like count
-	_, err = m.Collection.UpdateByID(ctx, configID, bson.M{
+	// Increment config's like count and let its owner know
+	var liked struct {
+		OwnerID string `bson:"owner_id"`
+	}
+	err = m.Collection.FindOneAndUpdate(ctx, bson.M{"_id": configID}, bson.M{
 		"$inc": bson.M{"likes": 1},
-	})
-	return err
+	}, options.FindOneAndUpdate().SetProjection(bson.M{"owner_id": 1})).Decode(&liked)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	m.notify(ctx, NotificationConfigFavorited, user.UserID, configID, []string{liked.OwnerID})
+	return nil
 }
 
-func (m *ConfigManagerMongo) UnfavoriteConfig(ctx context.Context, configID string) error {
+func (m *ConfigManagerMongo) UnfavoriteConfig(ctx context.Context, configID string) (err error) {
+	defer func() { m.logMutation(ctx, "UnfavoriteConfig", err, slog.String("config_id", configID)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
@@ -497,17 +1048,23 @@ func (m *ConfigManagerMongo) ListFavorites(
 
 	filter := bson.M{"_id": bson.M{"$in": ids}}
 
-	return mserve.PaginateMongo[HyprConfig](
+	result, err := mserve.PaginateMongo[HyprConfig](
 		ctx,
 		m.Collection,
 		filter,
 		page,
 		limit,
-		nil,
+		listFindOptions(ctx, nil),
 	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return decodeListForRead(ctx, result)
 }
 
-func (m *ConfigManagerMongo) ApplyConfig(ctx context.Context, configID string) error {
+func (m *ConfigManagerMongo) ApplyConfig(ctx context.Context, configID string) (err error) {
+	defer func() { m.logMutation(ctx, "ApplyConfig", err, slog.String("config_id", configID)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
@@ -541,11 +1098,14 @@ func (m *ConfigManagerMongo) GetAppliedConfig(
 	err = m.StateCollection.FindOne(ctx, bson.M{
 		"user_id": user.UserID,
 	}).Decode(&state)
-	if err != nil {
+	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, ErrNotFound
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied config state: %w", err)
+	}
 
-	return m.GetConfig(ctx, state.ConfigID)
+	return loadAppliedConfig(ctx, state.ConfigID, m.GetConfig)
 }
 
 func (m *ConfigManagerMongo) CountUsersUsingConfig(
@@ -563,67 +1123,94 @@ func (m *ConfigManagerMongo) AddProgramConfig(
 	configID string,
 	newProg HyprProgramConfig,
 	parentID *string, // nil means insert at top-level
-) error {
+	changelog string,
+) (err error) {
+	defer func() {
+		m.logMutation(ctx, "AddProgramConfig", err, slog.String("config_id", configID), slog.String("prog_id", newProg.ID), parentAttr(parentID))
+	}()
 
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Fetch the config to check permissions and modify in memory
-	var cfg HyprConfig
-	if err := m.Collection.FindOne(ctx, bson.M{"_id": configID}).Decode(&cfg); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return ErrNotFound
-		}
-		return err
-	}
-
-	// Owner or Admin required
-	if cfg.OwnerID != user.UserID && !isAdmin(user.Roles) {
-		return ErrForbidden
-	}
-
 	// Ensure ID exists
 	if newProg.ID == "" {
 		newProg.ID = uuid.NewString()
 	}
 
-	now := time.Now()
-	newProg.CreatedTimestamp = now
-	newProg.UpdatedTimestamp = now
+	return retryOnConflict(func() (bool, error) {
+		// Fetch the config to check permissions and modify in memory
+		cfg, err := m.loadForProgramWrite(ctx, configID, user)
+		if err != nil {
+			return false, err
+		}
+		prog, err := cloneProgramConfig(newProg)
+		if err != nil {
+			return false, err
+		}
 
-	// ----------------------
-	// Top-level insert
-	// ----------------------
-	if parentID == nil || *parentID == "" {
-		cfg.ProgramConfigs = append(cfg.ProgramConfigs, newProg)
+		now := time.Now()
+		prog.CreatedTimestamp = now
+		prog.UpdatedTimestamp = now
 
-		_, err = m.Collection.UpdateByID(ctx, configID, bson.M{
-			"$set": bson.M{
-				"program_configs":   cfg.ProgramConfigs,
-				"updated_timestamp": now,
-			},
-		})
-		return err
-	}
+		if err := prog.decompressUpload(m.limits); err != nil {
+			return false, err
+		}
+		if err := prog.resolveFileRefs(storedFiles(cfg.ProgramConfigs)); err != nil {
+			return false, invalidf("program config validation failed: %w", err)
+		}
+		prog.populateHashes()
+		if err := cfg.validateProgram(ctx, &prog, m.registry().Allowed, m.limits); err != nil {
+			return false, fmt.Errorf("program config validation failed: %w", err)
+		}
+		if err := m.limits.checkTotal(cfg.contentSize() + prog.contentSize()); err != nil {
+			return false, invalidf("program config validation failed: %w", err)
+		}
+		added := prog.contentSize()
+		if err := m.checkQuota(ctx, cfg.OwnerID, added); err != nil {
+			return false, err
+		}
+		uploaded, err := m.offloadFiles(ctx, &prog)
+		if err != nil {
+			return false, err
+		}
+		if err := prog.compressContent(); err != nil {
+			m.deleteFiles(ctx, uploaded)
+			return false, err
+		}
 
-	// ----------------------
-	// Insert into a parent sub-config (recursive)
-	// ----------------------
-	inserted := insertIntoSubConfig(cfg.ProgramConfigs, newProg, *parentID)
-	if !inserted {
-		return fmt.Errorf("parent program config with ID %s not found", *parentID)
-	}
+		var update bson.M
+		if parentID == nil || *parentID == "" {
+			// Top-level insert, pushed so the rest of the array is left alone
+			after := &HyprConfig{ProgramConfigs: append(cfg.ProgramConfigs, prog)}
+			set := after.derivedUpdate()
+			set["updated_timestamp"] = now
+			update = withoutVerification(bson.M{
+				"$push": bson.M{"program_configs": prog},
+				"$set":  set,
+			})
+		} else {
+			// Insert into a parent sub-config (recursive) and write the tree back
+			if !insertIntoSubConfig(cfg.ProgramConfigs, prog, *parentID) {
+				m.deleteFiles(ctx, uploaded)
+				return false, fmt.Errorf("parent program config with ID %s %w", *parentID, ErrNotFound)
+			}
+			set := cfg.derivedUpdate()
+			set["program_configs"] = cfg.ProgramConfigs
+			set["updated_timestamp"] = now
+			update = withoutVerification(bson.M{"$set": set})
+		}
 
-	// Write back
-	_, err = m.Collection.UpdateByID(ctx, configID, bson.M{
-		"$set": bson.M{
-			"program_configs":   cfg.ProgramConfigs,
-			"updated_timestamp": now,
-		},
+		written, err := m.writeIfUnchanged(ctx, cfg, withChangelog(update, cfg.Version, changelog, user.UserID))
+		if err != nil || !written {
+			m.deleteFiles(ctx, uploaded)
+			return false, err
+		}
+		m.addUsage(ctx, cfg.OwnerID, added)
+		m.recordMutation(ctx, newAuditEntry(user.UserID, AuditAddProgramConfig, configID, prog.ID, nil))
+		return true, nil
 	})
-	return err
 }
 
 // insertIntoSubConfig recursively searches for parentID and inserts newProg into its SubConfigs.
@@ -679,60 +1266,58 @@ func (m *ConfigManagerMongo) RemoveProgramConfig(
 	ctx context.Context,
 	configID string,
 	progID string,
-) error {
+	changelog string,
+) (err error) {
+	defer func() {
+		m.logMutation(ctx, "RemoveProgramConfig", err, slog.String("config_id", configID), slog.String("prog_id", progID))
+	}()
 
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Load full config (needed for nested removal)
-	var cfg HyprConfig
-	if err := m.Collection.FindOne(ctx, bson.M{"_id": configID}).Decode(&cfg); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return ErrNotFound
+	return retryOnConflict(func() (bool, error) {
+		// Load full config (needed for nested removal)
+		cfg, err := m.loadForProgramWrite(ctx, configID, user)
+		if err != nil {
+			return false, err
+		}
+		if err := m.checkQuota(ctx, cfg.OwnerID, 0); err != nil {
+			return false, err
+		}
+		path := programPath(cfg.ProgramConfigs, progID)
+		if path == nil {
+			return false, fmt.Errorf("program config with ID %s %w", progID, ErrNotFound)
+		}
+		before := cfg.contentSize()
+		files := storedFiles(cfg.ProgramConfigs)
+		remaining := &HyprConfig{ProgramConfigs: removeNestedProgramConfig(cfg.ProgramConfigs, progID)}
+
+		set := remaining.derivedUpdate()
+		set["updated_timestamp"] = time.Now()
+		update := withoutVerification(bson.M{"$set": set})
+		var opts []*options.UpdateOptions
+		if pull, filters, ok := pullProgramUpdate(path); ok {
+			// Pull it from its parent only, so the rest of the tree is left alone
+			update["$pull"] = pull
+			if len(filters) > 0 {
+				opts = append(opts, options.Update().SetArrayFilters(options.ArrayFilters{Filters: filters}))
+			}
+		} else {
+			// Nested deeper than a scoped $pull supports, write the updated tree back
+			set["program_configs"] = remaining.ProgramConfigs
 		}
-		return err
-	}
-
-	// Owner/Admin validation
-	if cfg.OwnerID != user.UserID && !isAdmin(user.Roles) {
-		return ErrForbidden
-	}
-
-	// --------
-	// Attempt top-level removal
-	// --------
-	res, err := m.Collection.UpdateByID(ctx, configID, bson.M{
-		"$pull": bson.M{
-			"program_configs": bson.M{"id": progID},
-		},
-	})
-	if err != nil {
-		return err
-	}
-
-	if res.ModifiedCount > 0 {
-		// Found and removed at top-level, just update timestamp
-		_, _ = m.Collection.UpdateByID(ctx, configID, bson.M{
-			"$set": bson.M{
-				"updated_timestamp": time.Now(),
-			},
-		})
-		return nil
-	}
-
-	// Otherwise, must remove from nested SubConfigs
-	updatedList := removeNestedProgramConfig(cfg.ProgramConfigs, progID)
 
-	// Write updated ProgramConfigs back
-	_, err = m.Collection.UpdateByID(ctx, configID, bson.M{
-		"$set": bson.M{
-			"program_configs":   updatedList,
-			"updated_timestamp": time.Now(),
-		},
+		written, err := m.writeIfUnchanged(ctx, cfg, withChangelog(update, cfg.Version, changelog, user.UserID), opts...)
+		if err != nil || !written {
+			return false, err
+		}
+		m.deleteOrphanedFiles(ctx, files, remaining.ProgramConfigs)
+		m.addUsage(ctx, cfg.OwnerID, remaining.contentSize()-before)
+		m.recordMutation(ctx, newAuditEntry(user.UserID, AuditRemoveProgramConfig, configID, progID, nil))
+		return true, nil
 	})
-	return err
 }
 
 func removeNestedProgramConfig(
@@ -784,58 +1369,63 @@ func (m *ConfigManagerMongo) MoveProgramConfig(
 	configID string,
 	progID string,
 	newParentID *string, // nil = move to top-level
-) error {
+	changelog string,
+) (err error) {
+	defer func() {
+		m.logMutation(ctx, "MoveProgramConfig", err, slog.String("config_id", configID), slog.String("prog_id", progID), parentAttr(newParentID))
+	}()
 
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Load config
-	var cfg HyprConfig
-	if err := m.Collection.FindOne(ctx, bson.M{"_id": configID}).Decode(&cfg); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return ErrNotFound
+	return retryOnConflict(func() (bool, error) {
+		// Load config
+		cfg, err := m.loadForProgramWrite(ctx, configID, user)
+		if err != nil {
+			return false, err
 		}
-		return err
-	}
 
-	// Permission check
-	if cfg.OwnerID != user.UserID && !isAdmin(user.Roles) {
-		return ErrForbidden
-	}
-
-	// 1. Remove program config
-	var removed *HyprProgramConfig
-	cfg.ProgramConfigs, removed = extractProgramConfig(cfg.ProgramConfigs, progID)
-	if removed == nil {
-		return fmt.Errorf("program config with ID %s not found", progID)
-	}
+		// 1. Remove program config
+		var removed *HyprProgramConfig
+		cfg.ProgramConfigs, removed = extractProgramConfig(cfg.ProgramConfigs, progID)
+		if removed == nil {
+			return false, fmt.Errorf("program config with ID %s %w", progID, ErrNotFound)
+		}
 
-	// Cleanup nested timestamps
-	now := time.Now()
-	removed.UpdatedTimestamp = now
+		// Cleanup nested timestamps
+		now := time.Now()
+		removed.UpdatedTimestamp = now
+
+		// 2. Insert program config into new parent or top-level
+		if newParentID == nil || *newParentID == "" {
+			// Move to top-level
+			cfg.ProgramConfigs = append(cfg.ProgramConfigs, *removed)
+		} else {
+			if !insertIntoSubConfig(cfg.ProgramConfigs, *removed, *newParentID) {
+				return false, fmt.Errorf("parent program config with ID %s %w", *newParentID, ErrNotFound)
+			}
+		}
 
-	// 2. Insert program config into new parent or top-level
-	if newParentID == nil || *newParentID == "" {
-		// Move to top-level
-		cfg.ProgramConfigs = append(cfg.ProgramConfigs, *removed)
-	} else {
-		if !insertIntoSubConfig(cfg.ProgramConfigs, *removed, *newParentID) {
-			return fmt.Errorf("parent program config with ID %s not found", *newParentID)
+		// 3. Write changes back to Mongo, unless the tree changed since it was read
+		written, err := m.writeIfUnchanged(ctx, cfg, withChangelog(withoutVerification(bson.M{
+			"$set": bson.M{
+				"program_configs":   cfg.ProgramConfigs,
+				"updated_timestamp": now,
+			},
+		}), cfg.Version, changelog, user.UserID))
+		if err != nil || !written {
+			return false, err
 		}
-	}
 
-	// 3. Write changes back to Mongo
-	_, err = m.Collection.UpdateByID(ctx, configID, bson.M{
-		"$set": bson.M{
-			"program_configs":   cfg.ProgramConfigs,
-			"updated_timestamp": now,
-		},
+		m.recordMutation(ctx, newAuditEntry(user.UserID, AuditMoveProgramConfig, configID, progID, []string{"parent"}))
+		return true, nil
 	})
-	return err
 }
 
+// extractProgramConfig removes progID from list, wherever it is nested, and returns the list
+// without it, keeping every other program config in order, and the removed program config.
 func extractProgramConfig(
 	list []HyprProgramConfig,
 	progID string,
@@ -843,9 +1433,9 @@ func extractProgramConfig(
 
 	newList := make([]HyprProgramConfig, 0, len(list))
 
-	for _, item := range list {
+	for i, item := range list {
 		if item.ID == progID {
-			return newList, &item
+			return append(newList, list[i+1:]...), &item
 		}
 
 		// Search nested subconfigs
@@ -854,7 +1444,7 @@ func extractProgramConfig(
 			if removed != nil {
 				item.SubConfigs = subNew
 				newList = append(newList, item)
-				return newList, removed
+				return append(newList, list[i+1:]...), removed
 			}
 		}
 
@@ -871,9 +1461,9 @@ func extractProgramConfigNested(
 
 	newList := make([]*HyprProgramConfig, 0, len(list))
 
-	for _, sc := range list {
+	for i, sc := range list {
 		if sc.ID == progID {
-			return newList, sc
+			return append(newList, list[i+1:]...), sc
 		}
 
 		if len(sc.SubConfigs) > 0 {
@@ -881,7 +1471,7 @@ func extractProgramConfigNested(
 			if removed != nil {
 				sc.SubConfigs = subNew
 				newList = append(newList, sc)
-				return newList, removed
+				return append(newList, list[i+1:]...), removed
 			}
 		}
 
@@ -896,43 +1486,100 @@ func (m *ConfigManagerMongo) UpdateProgramConfig(
 	configID string,
 	progID string,
 	updates HyprProgramConfig,
-) error {
+	changelog string,
+) (err error) {
+	defer func() {
+		m.logMutation(ctx, "UpdateProgramConfig", err, slog.String("config_id", configID), slog.String("prog_id", progID))
+	}()
 
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Load config
-	var cfg HyprConfig
-	if err := m.Collection.FindOne(ctx, bson.M{"_id": configID}).Decode(&cfg); err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return ErrNotFound
+	return retryOnConflict(func() (bool, error) {
+		// Load config
+		cfg, err := m.loadForProgramWrite(ctx, configID, user)
+		if err != nil {
+			return false, err
+		}
+		upd, err := cloneProgramConfig(updates)
+		if err != nil {
+			return false, err
 		}
-		return err
-	}
 
-	// Check permissions
-	if cfg.OwnerID != user.UserID && !isAdmin(user.Roles) {
-		return ErrForbidden
-	}
+		now := time.Now()
+
+		if err := upd.decompressUpload(m.limits); err != nil {
+			return false, err
+		}
+		files := storedFiles(cfg.ProgramConfigs)
+		if err := upd.resolveFileRefs(files); err != nil {
+			return false, invalidf("program config validation failed: %w", err)
+		}
+		upd.populateHashes()
+		if err := cfg.validateProgram(ctx, &upd, m.registry().Allowed, m.limits); err != nil {
+			return false, fmt.Errorf("program config validation failed: %w", err)
+		}
 
-	now := time.Now()
+		sizeBefore := cfg.contentSize()
+		topLevel := topLevelProgram(cfg.ProgramConfigs, progID)
 
-	// Perform recursive update
-	updated, ok := updateProgramConfigRecursive(cfg.ProgramConfigs, progID, updates, now)
-	if !ok {
-		return fmt.Errorf("program config with ID %s not found", progID)
-	}
+		// Keep the old version around for the audit log, the update replaces it in place
+		var before HyprProgramConfig
+		if existing := findProgramConfig(cfg.ProgramConfigs, progID); existing != nil {
+			before = *existing
+		}
 
-	// Write back
-	_, err = m.Collection.UpdateByID(ctx, configID, bson.M{
-		"$set": bson.M{
-			"program_configs":   updated,
-			"updated_timestamp": now,
-		},
+		// Perform recursive update
+		updated, ok := updateProgramConfigRecursive(cfg.ProgramConfigs, progID, upd, now)
+		if !ok {
+			return false, fmt.Errorf("program config with ID %s %w", progID, ErrNotFound)
+		}
+		updatedCfg := HyprConfig{ProgramConfigs: updated}
+		if err := m.limits.checkTotal(updatedCfg.contentSize()); err != nil {
+			return false, invalidf("program config validation failed: %w", err)
+		}
+		delta := updatedCfg.contentSize() - sizeBefore
+		if err := m.checkQuota(ctx, cfg.OwnerID, delta); err != nil {
+			return false, err
+		}
+
+		// Offload and compress only once the update is known to be valid
+		prog := findProgramConfig(updated, progID)
+		uploaded, err := m.offloadFiles(ctx, prog)
+		if err != nil {
+			return false, err
+		}
+		if err := prog.compressContent(); err != nil {
+			m.deleteFiles(ctx, uploaded)
+			return false, err
+		}
+
+		set := updatedCfg.derivedUpdate()
+		set["updated_timestamp"] = now
+		var opts []*options.UpdateOptions
+		if topLevel {
+			// Replace only the matching element
+			set["program_configs.$[prog]"] = prog
+			opts = append(opts, options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"prog." + programIDKey: progID}},
+			}))
+		} else {
+			set["program_configs"] = updated
+		}
+
+		written, err := m.writeIfUnchanged(ctx, cfg, withChangelog(withoutVerification(bson.M{"$set": set}), cfg.Version, changelog, user.UserID), opts...)
+		if err != nil || !written {
+			m.deleteFiles(ctx, uploaded)
+			return false, err
+		}
+
+		m.deleteOrphanedFiles(ctx, files, updated)
+		m.addUsage(ctx, cfg.OwnerID, delta)
+		m.recordMutation(ctx, newAuditEntry(user.UserID, AuditUpdateProgramConfig, configID, progID, changedProgramFields(before, *prog)))
+		return true, nil
 	})
-	return err
 }
 
 func updateProgramConfigRecursive(
@@ -1007,26 +1654,61 @@ func updateSubConfigRecursive(
 	return list, false
 }
 
-// checkProgramExists queries the database to see if a program name is currently allowed.
-func (m *ConfigManagerMongo) checkProgramExists(ctx context.Context, programName string) error {
-	var allowedProgram AllowedPrograms
-	err := m.ProgramsCollection.FindOne(ctx, bson.M{"program_name": programName}).Decode(&allowedProgram)
+// registry returns the programs configs may use. Managers not built by NewConfigManager get an
+// uncached one.
+func (m *ConfigManagerMongo) registry() *ProgramRegistry {
+	if m.programs == nil {
+		return NewProgramRegistry(m.lookupAllowedPrograms, m.listAllowedProgramNames, 0)
+	}
+	return m.programs
+}
 
-	if errors.Is(err, mongo.ErrNoDocuments) {
-		// Program not found in the AllowedPrograms collection
-		return fmt.Errorf("program '%s' is not in the list of allowed programs", programName)
+// lookupAllowedPrograms looks up which of names are allowed with a single query.
+// Without a programs collection only built-in programs are allowed, which the registry knows already.
+func (m *ConfigManagerMongo) lookupAllowedPrograms(ctx context.Context, names []string) (map[string]struct{}, error) {
+	if m.ProgramsCollection == nil {
+		return map[string]struct{}{}, nil
 	}
+	cursor, err := m.ProgramsCollection.Find(ctx,
+		bson.M{"program_name": bson.M{"$in": names}},
+		options.Find().SetProjection(bson.M{"program_name": 1}),
+	)
 	if err != nil {
-		// Database error during lookup
-		return fmt.Errorf("database error checking program '%s': %w", programName, err)
+		return nil, fmt.Errorf("database error checking programs: %w", err)
+	}
+	var found []AllowedPrograms
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, fmt.Errorf("database error checking programs: %w", err)
 	}
 
-	// Program found
-	return nil
+	allowed := make(map[string]struct{}, len(found))
+	for _, p := range found {
+		allowed[p.ProgramName] = struct{}{}
+	}
+	return allowed, nil
+}
+
+// listAllowedProgramNames returns the names of the programs allowed in the database.
+func (m *ConfigManagerMongo) listAllowedProgramNames(ctx context.Context) ([]string, error) {
+	if m.ProgramsCollection == nil {
+		return nil, nil
+	}
+	values, err := m.ProgramsCollection.Distinct(ctx, "program_name", bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allowed programs: %w", err)
+	}
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		if name, ok := v.(string); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
 }
 
 // AddAllowedProgram inserts a new program name into the allowed list.
-func (m *ConfigManagerMongo) AddAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+func (m *ConfigManagerMongo) AddAllowedProgram(ctx context.Context, programName string) (_ *AllowedPrograms, err error) {
+	defer func() { m.logMutation(ctx, "AddAllowedProgram", err, slog.String("program", programName)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -1036,10 +1718,13 @@ func (m *ConfigManagerMongo) AddAllowedProgram(ctx context.Context, programName
 	if !isAdmin(user.Roles) {
 		return nil, ErrForbidden
 	}
+	if m.ProgramsCollection == nil {
+		return nil, ErrAllowlistDisabled
+	}
 
-	programName = strings.ToLower(strings.TrimSpace(programName))
+	programName = NormalizeProgramName(programName)
 	if programName == "" {
-		return nil, errors.New("program name cannot be empty")
+		return nil, invalidf("program name cannot be empty")
 	}
 
 	newProgram := AllowedPrograms{
@@ -1049,19 +1734,28 @@ func (m *ConfigManagerMongo) AddAllowedProgram(ctx context.Context, programName
 	_, err = m.ProgramsCollection.InsertOne(ctx, newProgram)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
-			return nil, fmt.Errorf("program '%s' is already allowed", programName)
+			return nil, invalidf("program '%s' is already allowed", programName)
 		}
 		return nil, fmt.Errorf("failed to insert allowed program: %w", err)
 	}
 
+	m.registry().Invalidate(programName)
+	m.audit(ctx, newAuditEntry(user.UserID, AuditAddAllowedProgram, "", "", []string{programName}))
 	return &newProgram, nil
 }
 
 // GetAllowedProgram retrieves a single allowed program definition by its name.
 func (m *ConfigManagerMongo) GetAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
-	programName = strings.ToLower(strings.TrimSpace(programName))
+	programName = NormalizeProgramName(programName)
 	if programName == "" {
-		return nil, errors.New("program name cannot be empty")
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	if m.ProgramsCollection == nil {
+		if !builtinProgram(programName) {
+			return nil, ErrNotFound
+		}
+		return &AllowedPrograms{ProgramName: programName}, nil
 	}
 
 	var program AllowedPrograms
@@ -1078,25 +1772,77 @@ func (m *ConfigManagerMongo) GetAllowedProgram(ctx context.Context, programName
 }
 
 // ListAllowedPrograms retrieves all program names in the allowed list.
+// Kept for compatibility; it walks every page of ListAllowedProgramsPaged.
 func (m *ConfigManagerMongo) ListAllowedPrograms(ctx context.Context) ([]AllowedPrograms, error) {
+	var programs []AllowedPrograms
+	for page := 1; ; page++ {
+		result, err := m.ListAllowedProgramsPaged(ctx, page, 500, AllowedProgramFilters{})
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, result.Items...)
+		if page >= result.TotalPages {
+			break
+		}
+	}
+
+	return programs, nil
+}
+
+// ListAllowedProgramsPaged returns one page of allowed programs sorted by name,
+// optionally filtered by a case-insensitive name prefix and a category.
+func (m *ConfigManagerMongo) ListAllowedProgramsPaged(
+	ctx context.Context,
+	page, limit int,
+	filters AllowedProgramFilters,
+) (mserve.Page[AllowedPrograms], error) {
 	// No admin check here, as this list is often public for config creation.
+	if m.ProgramsCollection == nil {
+		return builtinProgramsPage(page, limit, filters)
+	}
+
+	filter := bson.M{}
+	if prefix := strings.ToLower(strings.TrimSpace(filters.Prefix)); prefix != "" {
+		// names are stored lowercased, so an anchored regex can still use the index
+		filter["program_name"] = bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}
+	}
+	if filters.Category != "" {
+		filter["category"] = filters.Category
+	}
 
-	cursor, err := m.ProgramsCollection.Find(ctx, bson.M{})
+	findOpts := options.Find().SetSort(bson.M{"program_name": 1})
+
+	result, err := mserve.PaginateMongo[AllowedPrograms](
+		ctx,
+		m.ProgramsCollection,
+		filter,
+		page,
+		limit,
+		findOpts,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list allowed programs: %w", err)
+		return mserve.Page[AllowedPrograms]{}, fmt.Errorf("failed to list allowed programs: %w", err)
 	}
-	defer cursor.Close(ctx)
 
+	return result, nil
+}
+
+// builtinProgramsPage pages the built-in programs matching filters, which are all a manager
+// without a programs collection allows. Built-in programs have no category.
+func builtinProgramsPage(page, limit int, filters AllowedProgramFilters) (mserve.Page[AllowedPrograms], error) {
+	prefix := strings.ToLower(strings.TrimSpace(filters.Prefix))
 	var programs []AllowedPrograms
-	if err := cursor.All(ctx, &programs); err != nil {
-		return nil, fmt.Errorf("failed to decode allowed programs: %w", err)
+	for _, p := range DefaultAllowedPrograms() {
+		if strings.HasPrefix(p.ProgramName, prefix) && filters.Category == "" {
+			programs = append(programs, p)
+		}
 	}
-
-	return programs, nil
+	return mserve.Paginate(programs, page, limit)
 }
 
 // RemoveAllowedProgram deletes a program name from the allowed list.
-func (m *ConfigManagerMongo) RemoveAllowedProgram(ctx context.Context, programName string) error {
+func (m *ConfigManagerMongo) RemoveAllowedProgram(ctx context.Context, programName string) (err error) {
+	defer func() { m.logMutation(ctx, "RemoveAllowedProgram", err, slog.String("program", programName)) }()
 	user, err := getUserFromContext(ctx)
 	if err != nil {
 		return err
@@ -1106,10 +1852,13 @@ func (m *ConfigManagerMongo) RemoveAllowedProgram(ctx context.Context, programNa
 	if !isAdmin(user.Roles) {
 		return ErrForbidden
 	}
+	if m.ProgramsCollection == nil {
+		return ErrAllowlistDisabled
+	}
 
-	programName = strings.ToLower(strings.TrimSpace(programName))
+	programName = NormalizeProgramName(programName)
 	if programName == "" {
-		return errors.New("program name cannot be empty")
+		return invalidf("program name cannot be empty")
 	}
 
 	res, err := m.ProgramsCollection.DeleteOne(ctx, bson.M{"program_name": programName})
@@ -1120,6 +1869,8 @@ func (m *ConfigManagerMongo) RemoveAllowedProgram(ctx context.Context, programNa
 	if res.DeletedCount == 0 {
 		return ErrNotFound
 	}
+	m.registry().Invalidate(programName)
+	m.audit(ctx, newAuditEntry(user.UserID, AuditRemoveAllowedProgram, "", "", []string{programName}))
 
 	// NOTE: Deleting an allowed program should ideally trigger a warning or cleanup
 	// process for any existing HyprConfigs that rely on this program.
@@ -1127,3 +1878,151 @@ func (m *ConfigManagerMongo) RemoveAllowedProgram(ctx context.Context, programNa
 
 	return nil
 }
+
+// ImportAllowedPrograms inserts many programs into the allowed list using a single unordered bulk write.
+// When upsert is false, programs that are already allowed are reported as skipped; when true they are updated.
+func (m *ConfigManagerMongo) ImportAllowedPrograms(
+	ctx context.Context,
+	programs []AllowedPrograms,
+	upsert bool,
+) (_ []ProgramImportResult, err error) {
+	defer func() {
+		m.logMutation(ctx, "ImportAllowedPrograms", err, slog.Int("programs", len(programs)), slog.Bool("upsert", upsert))
+	}()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	results, err := m.importAllowedPrograms(ctx, programs, upsert)
+	if err != nil {
+		return nil, err
+	}
+
+	m.audit(ctx, newAuditEntry(user.UserID, AuditImportAllowedPrograms, "", "", importedProgramNames(results)))
+	return results, nil
+}
+
+// SeedDefaultPrograms imports the built-in program list into the allowed_programs collection.
+// Programs that already exist are left untouched.
+func (m *ConfigManagerMongo) SeedDefaultPrograms(ctx context.Context) (_ []ProgramImportResult, err error) {
+	defer func() { m.logMutation(ctx, "SeedDefaultPrograms", err) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	results, err := m.importAllowedPrograms(ctx, DefaultAllowedPrograms(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	m.audit(ctx, newAuditEntry(user.UserID, AuditImportAllowedPrograms, "", "", importedProgramNames(results)))
+	return results, nil
+}
+
+// SeedAllowedPrograms imports the built-in program list into programs, for deploy tooling that
+// has no signed-in admin. Programs that are already allowed are skipped, or replaced by their
+// built-in entry when update is set, so it is safe to run on every deploy.
+func SeedAllowedPrograms(ctx context.Context, programs *mongo.Collection, update bool) ([]ProgramImportResult, error) {
+	if programs == nil {
+		return nil, ErrAllowlistDisabled
+	}
+	m := &ConfigManagerMongo{ProgramsCollection: programs}
+	// Skipping existing programs relies on the unique name index
+	if err := m.ensureIndexes(ctx); err != nil {
+		return nil, err
+	}
+	return m.importAllowedPrograms(ctx, DefaultAllowedPrograms(), update)
+}
+
+func (m *ConfigManagerMongo) importAllowedPrograms(
+	ctx context.Context,
+	programs []AllowedPrograms,
+	upsert bool,
+) ([]ProgramImportResult, error) {
+	if m.ProgramsCollection == nil {
+		return nil, ErrAllowlistDisabled
+	}
+	results := make([]ProgramImportResult, len(programs))
+
+	// models[i] is written for results[modelIndex[i]]
+	var models []mongo.WriteModel
+	var modelIndex []int
+	seen := make(map[string]struct{}, len(programs))
+
+	for i, p := range programs {
+		p.ProgramName = NormalizeProgramName(p.ProgramName)
+		results[i].ProgramName = p.ProgramName
+
+		if p.ProgramName == "" {
+			results[i].Status = ImportStatusFailed
+			results[i].Error = "program name cannot be empty"
+			continue
+		}
+		if _, ok := seen[p.ProgramName]; ok {
+			results[i].Status = ImportStatusSkipped
+			results[i].Error = "duplicate entry in request"
+			continue
+		}
+		seen[p.ProgramName] = struct{}{}
+
+		if upsert {
+			models = append(models, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"program_name": p.ProgramName}).
+				SetReplacement(p).
+				SetUpsert(true))
+		} else {
+			models = append(models, mongo.NewInsertOneModel().SetDocument(p))
+		}
+		modelIndex = append(modelIndex, i)
+	}
+
+	if len(models) == 0 {
+		return results, nil
+	}
+
+	// Assume success, then correct the entries the server reports on
+	for _, idx := range modelIndex {
+		if upsert {
+			results[idx].Status = ImportStatusUpdated
+		} else {
+			results[idx].Status = ImportStatusInserted
+		}
+	}
+
+	res, err := m.ProgramsCollection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	m.registry().Invalidate(sortedSet(seen)...)
+
+	var bulkErr mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bulkErr) {
+		return nil, fmt.Errorf("failed to import allowed programs: %w", err)
+	}
+
+	if res != nil && upsert {
+		for modelIdx := range res.UpsertedIDs {
+			results[modelIndex[modelIdx]].Status = ImportStatusInserted
+		}
+	}
+
+	for _, we := range bulkErr.WriteErrors {
+		idx := modelIndex[we.Index]
+		if mongo.IsDuplicateKeyError(we) {
+			results[idx].Status = ImportStatusSkipped
+			results[idx].Error = "program is already allowed"
+			continue
+		}
+		results[idx].Status = ImportStatusFailed
+		results[idx].Error = we.Message
+	}
+
+	return results, nil
+}
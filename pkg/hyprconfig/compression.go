@@ -0,0 +1,276 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Seann-Moser/mserve"
+)
+
+const (
+	EncodingNone = ""
+	EncodingGzip = "gzip"
+)
+
+// CompressionThreshold is the size above which text, config and script content is gzip compressed on write.
+const CompressionThreshold = 4 << 10
+
+type rawEncodingKey struct{}
+
+// WithRawEncoding returns a context in which reads return FileContent exactly as stored,
+// which may be gzip compressed. By default reads decompress transparently.
+func WithRawEncoding(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawEncodingKey{}, true)
+}
+
+func wantsRawEncoding(ctx context.Context) bool {
+	v, _ := ctx.Value(rawEncodingKey{}).(bool)
+	return v
+}
+
+type fileContentKey struct{}
+
+// WithFileContent returns a context in which the list methods return FileContent.Data. By
+// default lists leave the data out and only report each config's TotalSizeBytes.
+func WithFileContent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fileContentKey{}, true)
+}
+
+func wantsFileContent(ctx context.Context) bool {
+	v, _ := ctx.Value(fileContentKey{}).(bool)
+	return v
+}
+
+// Compress gzips Data in place and sets Encoding. Content that is already encoded,
+// or that would not get smaller, is left untouched.
+func (fc *FileContent) Compress() error {
+	if fc.Encoding != EncodingNone || len(fc.Data) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(fc.Data); err != nil {
+		return fmt.Errorf("failed to compress file content: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress file content: %w", err)
+	}
+
+	if buf.Len() >= len(fc.Data) {
+		return nil
+	}
+
+	fc.Data = buf.Bytes()
+	fc.Encoding = EncodingGzip
+	return nil
+}
+
+// Decompress reverses Compress, leaving Data uncompressed and Encoding empty.
+func (fc *FileContent) Decompress() error {
+	return fc.DecompressLimit(0)
+}
+
+// DecompressLimit is Decompress failing with ErrContentTooLarge as soon as more than limit bytes
+// come out, so a small upload can't inflate into gigabytes. A limit of 0 means unlimited.
+func (fc *FileContent) DecompressLimit(limit int64) error {
+	switch fc.Encoding {
+	case EncodingNone:
+		return nil
+	case EncodingGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(fc.Data))
+		if err != nil {
+			return fmt.Errorf("failed to decompress file content: %w", err)
+		}
+		defer zr.Close()
+
+		var r io.Reader = zr
+		if limit > 0 {
+			r = io.LimitReader(zr, limit+1)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to decompress file content: %w", err)
+		}
+		if limit > 0 && int64(len(data)) > limit {
+			return fmt.Errorf("%w: decompresses to more than the %d byte limit for %s files",
+				ErrContentTooLarge, limit, fileTypeName(fc.FileType))
+		}
+		fc.Data = data
+		fc.Encoding = EncodingNone
+		return nil
+	default:
+		return fmt.Errorf("unsupported file content encoding %q", fc.Encoding)
+	}
+}
+
+// shouldCompress reports whether content is a text-like type large enough to be worth compressing.
+func (fc *FileContent) shouldCompress() bool {
+	switch fc.FileType {
+	case FileTypeText, FileTypeConfig, FileTypeScript:
+		return len(fc.Data) > CompressionThreshold
+	default:
+		return false
+	}
+}
+
+// compressContent compresses every eligible file content in the program config tree.
+func (pc *HyprProgramConfig) compressContent() error {
+	var err error
+	pc.eachFile(func(_ string, fc *FileContent) {
+		if err == nil && fc.shouldCompress() {
+			if err = fc.Compress(); err != nil {
+				err = fmt.Errorf("program %s: %w", pc.Program, err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, sub := range pc.SubConfigs {
+		if sub == nil {
+			continue
+		}
+		if err := sub.compressContent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decompressContent decompresses every file content in the program config tree.
+func (pc *HyprProgramConfig) decompressContent() error {
+	return pc.decompressEach((*FileContent).Decompress)
+}
+
+// decompressUpload decompresses every file content of a program config tree sent by a client,
+// failing with ErrContentTooLarge once a file inflates past the limit of its type or all of
+// them past MaxConfigBytes. Nothing is decompressed further than that.
+func (pc *HyprProgramConfig) decompressUpload(limits SizeLimits) error {
+	return pc.decompressEach(newUploadBudget(limits).decompress)
+}
+
+// decompressEach calls decompress with every file content in the program config tree.
+func (pc *HyprProgramConfig) decompressEach(decompress func(*FileContent) error) error {
+	var err error
+	pc.eachFile(func(_ string, fc *FileContent) {
+		if err == nil {
+			if err = decompress(fc); err != nil {
+				err = fmt.Errorf("program %s: %w", pc.Program, err)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, sub := range pc.SubConfigs {
+		if sub == nil {
+			continue
+		}
+		if err := sub.decompressEach(decompress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadBudget bounds the decompression of uploaded file content by SizeLimits.
+type uploadBudget struct {
+	limits SizeLimits
+	total  int64
+}
+
+func newUploadBudget(limits SizeLimits) *uploadBudget {
+	return &uploadBudget{limits: limits}
+}
+
+// decompress decompresses fc within the per-file limit of its type, then checks the total of
+// the content decompressed so far.
+func (b *uploadBudget) decompress(fc *FileContent) error {
+	if err := fc.DecompressLimit(b.limits.maxFileBytes(fc.FileType)); err != nil {
+		return err
+	}
+	b.total += int64(len(fc.Data))
+	return b.limits.checkTotal(b.total)
+}
+
+func (hc *HyprConfig) compressContent() error {
+	for i := range hc.ProgramConfigs {
+		if err := hc.ProgramConfigs[i].compressContent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (hc *HyprConfig) decompressContent() error {
+	for i := range hc.ProgramConfigs {
+		if err := hc.ProgramConfigs[i].decompressContent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decompressUpload is HyprProgramConfig.decompressUpload for a whole config, with
+// MaxConfigBytes counted across all of its program configs.
+func (hc *HyprConfig) decompressUpload(limits SizeLimits) error {
+	budget := newUploadBudget(limits)
+	for i := range hc.ProgramConfigs {
+		if err := hc.ProgramConfigs[i].decompressEach(budget.decompress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeForRead decompresses a config read from the database unless the caller opted into raw encoding.
+func decodeForRead(ctx context.Context, cfg *HyprConfig) error {
+	if wantsRawEncoding(ctx) {
+		return nil
+	}
+	return cfg.decompressContent()
+}
+
+// decodeListForRead applies decodeForRead to every config in a listed page, then summarizes it.
+func decodeListForRead(ctx context.Context, page mserve.Page[HyprConfig]) (mserve.Page[HyprConfig], error) {
+	for i := range page.Items {
+		if err := decodeForRead(ctx, &page.Items[i]); err != nil {
+			return mserve.Page[HyprConfig]{}, err
+		}
+	}
+	return summarizeList(ctx, page), nil
+}
+
+// summarizeList sets the TotalSizeBytes of every config in a listed page and drops the file data
+// and readme, along with the keybinds, unless the caller asked for them with WithFileContent.
+func summarizeList(ctx context.Context, page mserve.Page[HyprConfig]) mserve.Page[HyprConfig] {
+	keep := wantsFileContent(ctx)
+	for i := range page.Items {
+		cfg := &page.Items[i]
+		cfg.TotalSizeBytes = 0
+		if !keep {
+			cfg.Readme = ""
+			cfg.Keybinds = nil
+		}
+		cfg.Walk(func(pc *HyprProgramConfig) {
+			pc.eachFile(func(_ string, fc *FileContent) {
+				if fc.Size > 0 {
+					cfg.TotalSizeBytes += fc.Size
+				} else if fc.Encoding == EncodingNone {
+					cfg.TotalSizeBytes += int64(len(fc.Data))
+				}
+				if !keep {
+					fc.Data = nil
+				}
+			})
+		})
+	}
+	return page
+}
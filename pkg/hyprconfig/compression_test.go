@@ -0,0 +1,248 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// largeConfig returns a realistic hyprland.conf well above CompressionThreshold.
+func largeConfig() []byte {
+	var b strings.Builder
+	b.WriteString("monitor=,preferred,auto,1\nexec-once = waybar\n")
+	for i := 0; b.Len() <= 3*CompressionThreshold; i++ {
+		b.WriteString("bind = $mainMod, code:1")
+		b.WriteString(strings.Repeat("0", i%5))
+		b.WriteString(", workspace, 1\nwindowrulev2 = float, class:^(pavucontrol)$\n")
+		b.WriteString("general {\n    gaps_in = 5\n    gaps_out = 20\n    border_size = 2\n}\n")
+	}
+	return []byte(b.String())
+}
+
+func TestFileContentCompressRoundTrip(t *testing.T) {
+	data := largeConfig()
+	fc := FileContent{Data: append([]byte{}, data...), FileType: FileTypeConfig}
+
+	if err := fc.Compress(); err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if fc.Encoding != EncodingGzip {
+		t.Fatalf("Encoding = %q, want %q", fc.Encoding, EncodingGzip)
+	}
+	if len(fc.Data) >= len(data) {
+		t.Errorf("compressed size %d not smaller than %d", len(fc.Data), len(data))
+	}
+
+	if err := fc.Decompress(); err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	if fc.Encoding != EncodingNone || !bytes.Equal(fc.Data, data) {
+		t.Error("round trip did not restore the original data")
+	}
+}
+
+func TestFileContentDecompressUnknownEncoding(t *testing.T) {
+	fc := FileContent{Data: []byte("x"), Encoding: "br"}
+	if err := fc.Decompress(); err == nil {
+		t.Fatal("expected error for unsupported encoding")
+	}
+}
+
+func TestCompressContentThreshold(t *testing.T) {
+	small := []byte("font_size 12\n")
+	large := largeConfig()
+
+	pc := HyprProgramConfig{
+		Program:     "hyprland",
+		FileContent: FileContent{Data: large, FileType: FileTypeConfig},
+		SubConfigs: []*HyprProgramConfig{
+			{Program: "kitty", FileContent: FileContent{Data: small, FileType: FileTypeConfig}},
+			{Program: "waybar", FileContent: FileContent{Data: large, FileType: FileTypeBinary}},
+		},
+	}
+	if err := pc.compressContent(); err != nil {
+		t.Fatalf("compressContent: %v", err)
+	}
+	if pc.FileContent.Encoding != EncodingGzip {
+		t.Error("large config content was not compressed")
+	}
+	if got := pc.FileContent.size(); got != int64(len(large)) {
+		t.Errorf("size of compressed content = %d, want uncompressed %d", got, len(large))
+	}
+	if pc.SubConfigs[0].FileContent.Encoding != EncodingNone {
+		t.Error("content below the threshold was compressed")
+	}
+	if pc.SubConfigs[1].FileContent.Encoding != EncodingNone {
+		t.Error("binary content was compressed")
+	}
+}
+
+func TestHashCoversUncompressedData(t *testing.T) {
+	data := largeConfig()
+	pc := HyprProgramConfig{Title: "hypr", Program: "hyprland", FileContent: FileContent{Data: data, FileType: FileTypeConfig}}
+	pc.populateHashes()
+	if err := pc.compressContent(); err != nil {
+		t.Fatalf("compressContent: %v", err)
+	}
+
+	if pc.FileContent.Hash != ComputeHash(data) {
+		t.Error("hash changed after compression")
+	}
+	if err := VerifyFileContent(pc.FileContent); err != nil {
+		t.Errorf("compressed content failed verification: %v", err)
+	}
+	if pc.FileContent.Encoding != EncodingGzip {
+		t.Error("VerifyFileContent modified the caller's content")
+	}
+}
+
+func TestDecodeForRead(t *testing.T) {
+	data := largeConfig()
+	cfg := HyprConfig{ProgramConfigs: []HyprProgramConfig{
+		{Program: "hyprland", FileContent: FileContent{Data: data, FileType: FileTypeConfig}},
+	}}
+
+	if err := cfg.compressContent(); err != nil {
+		t.Fatalf("compressContent: %v", err)
+	}
+	if err := decodeForRead(WithRawEncoding(context.Background()), &cfg); err != nil {
+		t.Fatalf("decodeForRead raw: %v", err)
+	}
+	if cfg.ProgramConfigs[0].FileContent.Encoding != EncodingGzip {
+		t.Fatal("raw read decompressed content")
+	}
+
+	if err := decodeForRead(context.Background(), &cfg); err != nil {
+		t.Fatalf("decodeForRead: %v", err)
+	}
+	if !bytes.Equal(cfg.ProgramConfigs[0].FileContent.Data, data) {
+		t.Error("default read did not decompress content")
+	}
+}
+
+func BenchmarkCompressedDocumentSize(b *testing.B) {
+	newConfig := func() *HyprConfig {
+		return &HyprConfig{
+			Title: "rice",
+			ProgramConfigs: []HyprProgramConfig{
+				{Title: "hyprland", Program: "hyprland", FileContent: FileContent{Data: largeConfig(), FileType: FileTypeConfig}},
+				{Title: "waybar", Program: "waybar", FileContent: FileContent{Data: largeConfig(), FileType: FileTypeConfig}},
+			},
+		}
+	}
+
+	raw, err := bson.Marshal(newConfig())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var compressed []byte
+	for i := 0; i < b.N; i++ {
+		cfg := newConfig()
+		if err := cfg.compressContent(); err != nil {
+			b.Fatal(err)
+		}
+		if compressed, err = bson.Marshal(cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(len(raw)), "raw-bytes")
+	b.ReportMetric(float64(len(compressed)), "gzip-bytes")
+}
+
+// gzipBomb returns gzip data that inflates to n zero bytes.
+func gzipBomb(t *testing.T, n int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(make([]byte, n)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestFileContentDecompressLimit(t *testing.T) {
+	bomb := gzipBomb(t, 1<<20)
+
+	fc := FileContent{Data: bomb, Encoding: EncodingGzip, FileType: FileTypeConfig}
+	if err := fc.DecompressLimit(64 << 10); !errors.Is(err, ErrContentTooLarge) {
+		t.Fatalf("DecompressLimit over the limit: got %v, want ErrContentTooLarge", err)
+	}
+	if fc.Encoding != EncodingGzip || !bytes.Equal(fc.Data, bomb) {
+		t.Error("content was changed by a failed decompression")
+	}
+
+	if err := fc.DecompressLimit(1 << 20); err != nil || len(fc.Data) != 1<<20 {
+		t.Errorf("DecompressLimit at the limit = %v with %d bytes", err, len(fc.Data))
+	}
+}
+
+func TestDecompressUploadLimits(t *testing.T) {
+	limits := SizeLimits{MaxTextBytes: 1 << 20, MaxConfigBytes: 3 << 19}
+	gz := func() FileContent {
+		return FileContent{Data: gzipBomb(t, 1<<20), Encoding: EncodingGzip, FileType: FileTypeConfig}
+	}
+
+	pc := HyprProgramConfig{Program: "hyprland", FileContent: gz()}
+	if err := pc.decompressUpload(limits); err != nil {
+		t.Fatalf("decompressUpload within the limits: %v", err)
+	}
+
+	// Each file is within its limit, together they are over MaxConfigBytes
+	cfg := HyprConfig{ProgramConfigs: []HyprProgramConfig{
+		{Program: "hyprland", FileContent: gz()},
+		{Program: "kitty", FileContent: gz()},
+	}}
+	if err := cfg.decompressUpload(limits); !errors.Is(err, ErrContentTooLarge) {
+		t.Errorf("decompressUpload over the config limit: got %v, want ErrContentTooLarge", err)
+	}
+
+	limits.MaxTextBytes = 1 << 10
+	pc = HyprProgramConfig{Program: "hyprland", SubConfigs: []*HyprProgramConfig{{Program: "hyprland", FileContent: gz()}}}
+	if err := pc.decompressUpload(limits); !errors.Is(err, ErrContentTooLarge) {
+		t.Errorf("decompressUpload over the file limit: got %v, want ErrContentTooLarge", err)
+	}
+}
+
+func TestManagerRejectsGzipBomb(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		bomb := FileContent{Data: gzipBomb(t, 64<<20), Encoding: EncodingGzip, FileType: FileTypeConfig}
+		_, err := m.CreateConfig(asUser("alice"), &HyprConfig{
+			Title:          "rice",
+			ProgramConfigs: []HyprProgramConfig{{ID: "hypr", Title: "hypr", Program: "hyprland", FileContent: bomb}},
+		})
+		if !errors.Is(err, ErrContentTooLarge) {
+			t.Fatalf("CreateConfig with a gzip bomb: got %v, want ErrContentTooLarge", err)
+		}
+
+		cfg := newTestConfig(t, m, "alice", false)
+		err = m.AddProgramConfig(asUser("alice"), cfg.ID, HyprProgramConfig{Title: "hypr", Program: "hyprland", FileContent: bomb}, nil, "")
+		if !errors.Is(err, ErrContentTooLarge) {
+			t.Errorf("AddProgramConfig with a gzip bomb: got %v, want ErrContentTooLarge", err)
+		}
+	})
+}
+
+func TestCheckFileIgnoresGzipTrailer(t *testing.T) {
+	bomb := gzipBomb(t, 2<<20)
+	// The trailer claims the data inflates to 16 bytes
+	binary.LittleEndian.PutUint32(bomb[len(bomb)-4:], 16)
+	fc := FileContent{Data: bomb, Encoding: EncodingGzip, FileType: FileTypeConfig}
+
+	if got := fc.size(); got != 2<<20 {
+		t.Errorf("size = %d, want the decompressed %d", got, 2<<20)
+	}
+	if err := DefaultSizeLimits().CheckFile(fc); !errors.Is(err, ErrContentTooLarge) {
+		t.Errorf("CheckFile with a lying trailer: got %v, want ErrContentTooLarge", err)
+	}
+}
@@ -2,6 +2,8 @@ package hyprconfig
 
 import (
 	"context"
+	"io"
+	"time"
 
 	"github.com/Seann-Moser/mserve"
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,7 +13,16 @@ import (
 type ConfigManager interface {
 	CreateConfig(ctx context.Context, cfg *HyprConfig) (*HyprConfig, error)
 	GetConfig(ctx context.Context, id string) (*HyprConfig, error)
-	UpdateConfig(ctx context.Context, id string, updates bson.M) error
+	GetProgramConfig(ctx context.Context, configID, progID string) (*HyprProgramConfig, error)
+	GetProgramFile(ctx context.Context, configID, progID, filePath string) (io.ReadCloser, *FileEntry, error)
+	AddGalleryImage(ctx context.Context, configID string, data []byte) (*GalleryImage, error)
+	GetGalleryImage(ctx context.Context, configID string, index int) (io.ReadCloser, *GalleryImage, error)
+	RemoveGalleryImage(ctx context.Context, configID string, index int) error
+	ExportConfigArchive(ctx context.Context, configID string, w io.Writer) error
+	GetInstallScript(ctx context.Context, configID, distro string, includeOptional bool) (string, error)
+	ExportHomeManager(ctx context.Context, configID string) (string, error)
+	SizeLimits() SizeLimits
+	UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) error
 	DeleteConfig(ctx context.Context, id string) error
 	ListConfigs(
 		ctx context.Context,
@@ -23,51 +34,140 @@ type ConfigManager interface {
 		page, limit int,
 		findOpts *options.FindOptions,
 	) (mserve.Page[HyprConfig], error)
+	ListSimilarConfigs(
+		ctx context.Context,
+		configID string,
+		page, limit int,
+	) (mserve.Page[HyprConfig], error)
 	ListConfigsWithFilters(
 		ctx context.Context,
 		page, limit int,
 		filters ConfigSearchFilters,
 		findOpts *options.FindOptions,
 	) (mserve.Page[HyprConfig], error)
+	AdminListConfigs(
+		ctx context.Context,
+		page, limit int,
+		filters ConfigSearchFilters,
+	) (mserve.Page[HyprConfig], error)
+	VerifyConfig(ctx context.Context, configID string) error
+	UnverifyConfig(ctx context.Context, configID string) error
+	DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) error
 	FavoriteConfig(ctx context.Context, configID string) error
 	UnfavoriteConfig(ctx context.Context, configID string) error
 	ListFavorites(
 		ctx context.Context,
 		page, limit int,
 	) (mserve.Page[HyprConfig], error)
+	ListConfigFavoriters(
+		ctx context.Context,
+		configID string,
+		page, limit int,
+	) (mserve.Page[ConfigFavoriter], error)
+	CountConfigFavorites(ctx context.Context, configID string) (int64, error)
 	ApplyConfig(ctx context.Context, configID string) error
 	GetAppliedConfig(
 		ctx context.Context,
 	) (*HyprConfig, error)
+	WatchAppliedConfig(ctx context.Context) (<-chan AppliedConfigEvent, error)
 	CountUsersUsingConfig(
 		ctx context.Context,
 		configID string,
 	) (int64, error)
+	GetAuthorStats(ctx context.Context, ownerID string) (*AuthorStats, error)
+	ListRelatedConfigs(ctx context.Context, configID string, limit int) ([]HyprConfig, error)
+	GetRandomConfig(ctx context.Context, filters ConfigSearchFilters) (*HyprConfig, error)
+	FollowAuthor(ctx context.Context, ownerID string) error
+	UnfollowAuthor(ctx context.Context, ownerID string) error
+	ListFollowing(ctx context.Context) ([]FollowedAuthor, error)
+	ListFollowedFeed(
+		ctx context.Context,
+		page, limit int,
+	) (mserve.Page[HyprConfig], error)
+	ListNotifications(
+		ctx context.Context,
+		page, limit int,
+		unreadOnly bool,
+	) (mserve.Page[Notification], error)
+	MarkNotificationsRead(ctx context.Context, ids []string) (int64, error) // empty ids marks all read
+	CountUnreadNotifications(ctx context.Context) (int64, error)
 	AddProgramConfig(
 		ctx context.Context,
 		configID string,
 		newProg HyprProgramConfig,
 		parentID *string, // nil means insert at top-level
+		changelog string,
 	) error
 	RemoveProgramConfig(
 		ctx context.Context,
 		configID string,
 		progID string,
+		changelog string,
 	) error
 	MoveProgramConfig(
 		ctx context.Context,
 		configID string,
 		progID string,
 		newParentID *string, // nil = move to top-level
+		changelog string,
 	) error
 	UpdateProgramConfig(
 		ctx context.Context,
 		configID string,
 		progID string,
 		updates HyprProgramConfig,
+		changelog string,
 	) error
+	GetChangelog(
+		ctx context.Context,
+		configID string,
+		page, limit int,
+	) (mserve.Page[ChangelogEntry], error)
+	ListAuditLog(
+		ctx context.Context,
+		configID string,
+		page, limit int,
+	) (mserve.Page[AuditEntry], error)
 	AddAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error)
 	GetAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error)
 	ListAllowedPrograms(ctx context.Context) ([]AllowedPrograms, error)
+	ListAllowedProgramsPaged(
+		ctx context.Context,
+		page, limit int,
+		filters AllowedProgramFilters,
+	) (mserve.Page[AllowedPrograms], error)
 	RemoveAllowedProgram(ctx context.Context, programName string) error
+	ImportAllowedPrograms(
+		ctx context.Context,
+		programs []AllowedPrograms,
+		upsert bool,
+	) ([]ProgramImportResult, error)
+	SeedDefaultPrograms(ctx context.Context) ([]ProgramImportResult, error)
+	RequestAllowedProgram(ctx context.Context, programName string, reason string) (*ProgramRequest, error)
+	ListProgramRequests(
+		ctx context.Context,
+		page, limit int,
+		status string,
+	) (mserve.Page[ProgramRequest], error)
+	ApproveProgramRequest(ctx context.Context, requestID string) (*AllowedPrograms, error)
+	RejectProgramRequest(ctx context.Context, requestID string, note string) error
+	CreateWebhook(ctx context.Context, req WebhookRequest) (*Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID string) error
+	ListWebhookDeliveries(
+		ctx context.Context,
+		webhookID string,
+		page, limit int,
+	) (mserve.Page[WebhookDelivery], error)
+	DeliverPendingWebhooks(ctx context.Context) (int, error)
+	CreateAPIKey(ctx context.Context, name string, scopes []string, expiry time.Duration) (*CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+	AuthenticateAPIKey(ctx context.Context, token string) (*APIKey, error)
+	CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (*CreatedShareLink, error)
+	ListShareLinks(ctx context.Context, configID string) ([]ShareLink, error)
+	RevokeShareLink(ctx context.Context, configID, linkID string) error
+	GetQuotaUsage(ctx context.Context) (*QuotaUsage, error)
+	SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (*QuotaUsage, error)
+	SetUserConfigLimit(ctx context.Context, userID string, limit int64) (*ConfigLimit, error)
 }
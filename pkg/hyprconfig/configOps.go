@@ -0,0 +1,299 @@
+package hyprconfig
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The helpers in this file hold the document level logic shared by the ConfigManager backends
+// that load a whole config, change it in memory and write it back (memory and SQLite).
+
+// prepareNewConfig assigns ownership, timestamps and hashes to a config about to be created and validates it.
+func prepareNewConfig(
+	ctx context.Context,
+	cfg *HyprConfig,
+	ownerID string,
+	checkProgramsExist ProgramsChecker,
+	limits SizeLimits,
+) error {
+	cfg.ID = uuid.New().String()
+	cfg.OwnerID = ownerID
+	cfg.SchemaVersion = CurrentSchemaVersion
+	cfg.CreatedTimestamp = time.Now()
+	cfg.UpdatedTimestamp = time.Now()
+	cfg.Changelog = nil
+	cfg.GalleryImages = nil
+	cfg.Likes = 0
+	cfg.Deprecation = nil
+	cfg.clearVerification()
+	if cfg.Version == "" {
+		cfg.Version = DefaultConfigVersion
+	}
+	if err := ValidateVersion(cfg.Version); err != nil {
+		return invalidf("config validation failed: %w", err)
+	}
+	if err := cfg.decompressUpload(limits); err != nil {
+		return err
+	}
+	for i := range cfg.ProgramConfigs {
+		if err := cfg.ProgramConfigs[i].resolveFileRefs(nil); err != nil {
+			return invalidf("config validation failed: %w", err)
+		}
+		cfg.ProgramConfigs[i].populateHashes()
+	}
+	if err := cfg.Validate(ctx, checkProgramsExist, limits); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.refreshDerived()
+	return nil
+}
+
+// refreshDerived recomputes the fields of hc derived from its program configs: the fingerprint,
+// display layout, palette, theme and keybinds, along with the search key of its minimum Hyprland
+// version.
+func (hc *HyprConfig) refreshDerived() {
+	hc.Fingerprint = hc.fingerprint()
+	hc.Display = hc.displayLayout()
+	hc.Palette, hc.Theme = hc.colorPalette()
+	hc.Keybinds = ConfigKeybinds(hc)
+	hc.HyprlandVersionKey = hyprlandVersionKey(hc.MinHyprlandVersion)
+	hc.Deprecated = hc.Deprecation != nil
+}
+
+// derivedUpdate is refreshDerived as $set fields, for updates that don't replace the document.
+// The Hyprland version key is left to setMetadataUpdates, as program updates can't change it.
+func (hc *HyprConfig) derivedUpdate() bson.M {
+	palette, theme := hc.colorPalette()
+	return bson.M{
+		"fingerprint": hc.fingerprint(),
+		"display":     hc.displayLayout(),
+		"palette":     palette,
+		"theme":       theme,
+		"keybinds":    ConfigKeybinds(hc),
+	}
+}
+
+// mergeConfigUpdates applies UpdateConfig's $set style updates to a copy of existing, bumping the
+// version and recording the changelog. Immutable fields and program configs are never updated here.
+func mergeConfigUpdates(
+	ctx context.Context,
+	existing *HyprConfig,
+	updates bson.M,
+	opts UpdateOptions,
+	actor string,
+	checkProgramsExist ProgramsChecker,
+	limits SizeLimits,
+) (*HyprConfig, error) {
+	newVersion, err := bumpVersion(existing.Version, opts.VersionBump)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bump version: %w", err)
+	}
+	updates["version"] = newVersion
+	updates["updated_timestamp"] = time.Now()
+
+	delete(updates, "_id")
+	delete(updates, "owner_id")
+	delete(updates, "likes")
+	delete(updates, "created_timestamp")
+	delete(updates, "changelog")
+	delete(updates, "program_configs")
+	delete(updates, "gallery_images")
+	delete(updates, "fingerprint")
+	delete(updates, "hyprland_version_key")
+	delete(updates, "deprecation")
+	delete(updates, "deprecated")
+	delete(updates, "schema_version")
+	for _, f := range verificationFields {
+		delete(updates, f)
+	}
+
+	// Merge through BSON exactly like the $set applied by the Mongo manager
+	existingBSON, err := bson.Marshal(existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal existing config: %w", err)
+	}
+	var mergedMap bson.M
+	if err := bson.Unmarshal(existingBSON, &mergedMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal existing BSON: %w", err)
+	}
+	for k, v := range updates {
+		mergedMap[k] = v
+	}
+	mergedBSON, err := bson.Marshal(mergedMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged map: %w", err)
+	}
+	var merged HyprConfig
+	if err := bson.Unmarshal(mergedBSON, &merged); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merged BSON into struct: %w", err)
+	}
+
+	if err := merged.Validate(ctx, checkProgramsExist, limits); err != nil {
+		return nil, fmt.Errorf("merged config failed validation: %w", err)
+	}
+
+	merged.clearVerification()
+	recordChangelog(&merged, newVersion, opts.Changelog, actor)
+	return &merged, nil
+}
+
+// recordChangelog appends a capped changelog entry, mirroring withChangelog.
+func recordChangelog(cfg *HyprConfig, version, message, actor string) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return
+	}
+	cfg.Changelog = append(cfg.Changelog, ChangelogEntry{
+		Version:   version,
+		Message:   message,
+		Actor:     actor,
+		Timestamp: time.Now(),
+	})
+	if n := len(cfg.Changelog); n > MaxChangelogEntries {
+		cfg.Changelog = cfg.Changelog[n-MaxChangelogEntries:]
+	}
+}
+
+// addProgram validates newProg and inserts it at the top level or under parentID.
+func addProgram(
+	ctx context.Context,
+	cfg *HyprConfig,
+	newProg HyprProgramConfig,
+	parentID *string,
+	checkProgramsExist ProgramsChecker,
+	limits SizeLimits,
+) error {
+	if newProg.ID == "" {
+		newProg.ID = uuid.NewString()
+	}
+	now := time.Now()
+	newProg.CreatedTimestamp = now
+	newProg.UpdatedTimestamp = now
+
+	if err := newProg.decompressUpload(limits); err != nil {
+		return err
+	}
+	if err := newProg.resolveFileRefs(storedFiles(cfg.ProgramConfigs)); err != nil {
+		return invalidf("program config validation failed: %w", err)
+	}
+	newProg.populateHashes()
+	if err := cfg.validateProgram(ctx, &newProg, checkProgramsExist, limits); err != nil {
+		return fmt.Errorf("program config validation failed: %w", err)
+	}
+	if err := limits.checkTotal(cfg.contentSize() + newProg.contentSize()); err != nil {
+		return invalidf("program config validation failed: %w", err)
+	}
+
+	if parentID == nil || *parentID == "" {
+		cfg.ProgramConfigs = append(cfg.ProgramConfigs, newProg)
+	} else if !insertIntoSubConfig(cfg.ProgramConfigs, newProg, *parentID) {
+		return fmt.Errorf("parent program config with ID %s %w", *parentID, ErrNotFound)
+	}
+	cfg.UpdatedTimestamp = now
+	return nil
+}
+
+// updateProgram validates updates and replaces program progID with it, keeping its sub-configs.
+func updateProgram(
+	ctx context.Context,
+	cfg *HyprConfig,
+	progID string,
+	updates HyprProgramConfig,
+	checkProgramsExist ProgramsChecker,
+	limits SizeLimits,
+) error {
+	if err := updates.decompressUpload(limits); err != nil {
+		return err
+	}
+	if err := updates.resolveFileRefs(storedFiles(cfg.ProgramConfigs)); err != nil {
+		return invalidf("program config validation failed: %w", err)
+	}
+	updates.populateHashes()
+	if err := cfg.validateProgram(ctx, &updates, checkProgramsExist, limits); err != nil {
+		return fmt.Errorf("program config validation failed: %w", err)
+	}
+
+	now := time.Now()
+	updated, ok := updateProgramConfigRecursive(cfg.ProgramConfigs, progID, updates, now)
+	if !ok {
+		return fmt.Errorf("program config with ID %s %w", progID, ErrNotFound)
+	}
+	cfg.ProgramConfigs = updated
+	if err := limits.checkTotal(cfg.contentSize()); err != nil {
+		return invalidf("program config validation failed: %w", err)
+	}
+	cfg.UpdatedTimestamp = now
+	return nil
+}
+
+// removeProgram removes program progID, wherever it is nested, with its sub configs.
+func removeProgram(cfg *HyprConfig, progID string) error {
+	if programPath(cfg.ProgramConfigs, progID) == nil {
+		return fmt.Errorf("program config with ID %s %w", progID, ErrNotFound)
+	}
+	cfg.ProgramConfigs = removeNestedProgramConfig(cfg.ProgramConfigs, progID)
+	cfg.UpdatedTimestamp = time.Now()
+	return nil
+}
+
+// moveProgram moves program progID to the top level or under newParentID.
+func moveProgram(cfg *HyprConfig, progID string, newParentID *string) error {
+	var removed *HyprProgramConfig
+	cfg.ProgramConfigs, removed = extractProgramConfig(cfg.ProgramConfigs, progID)
+	if removed == nil {
+		return fmt.Errorf("program config with ID %s %w", progID, ErrNotFound)
+	}
+
+	now := time.Now()
+	removed.UpdatedTimestamp = now
+	if newParentID == nil || *newParentID == "" {
+		cfg.ProgramConfigs = append(cfg.ProgramConfigs, *removed)
+	} else if !insertIntoSubConfig(cfg.ProgramConfigs, *removed, *newParentID) {
+		return fmt.Errorf("parent program config with ID %s %w", *newParentID, ErrNotFound)
+	}
+	cfg.UpdatedTimestamp = now
+	return nil
+}
+
+// planProgramImport works out the result of importing programs given the names already allowed,
+// returning the per-entry results and the programs to write.
+func planProgramImport(programs []AllowedPrograms, upsert bool, existing map[string]bool) ([]ProgramImportResult, []AllowedPrograms) {
+	results := make([]ProgramImportResult, len(programs))
+	var writes []AllowedPrograms
+	seen := make(map[string]struct{}, len(programs))
+
+	for i, p := range programs {
+		p.ProgramName = NormalizeProgramName(p.ProgramName)
+		results[i].ProgramName = p.ProgramName
+
+		if p.ProgramName == "" {
+			results[i].Status = ImportStatusFailed
+			results[i].Error = "program name cannot be empty"
+			continue
+		}
+		if _, ok := seen[p.ProgramName]; ok {
+			results[i].Status = ImportStatusSkipped
+			results[i].Error = "duplicate entry in request"
+			continue
+		}
+		seen[p.ProgramName] = struct{}{}
+
+		switch {
+		case existing[p.ProgramName] && !upsert:
+			results[i].Status = ImportStatusSkipped
+			results[i].Error = "program is already allowed"
+			continue
+		case existing[p.ProgramName]:
+			results[i].Status = ImportStatusUpdated
+		default:
+			results[i].Status = ImportStatusInserted
+		}
+		writes = append(writes, p)
+	}
+	return results, writes
+}
@@ -0,0 +1,190 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLimitExceeded matches a ConfigLimitError.
+var ErrLimitExceeded = errors.New("config limit reached")
+
+// ConfigLimitError is returned by CreateConfig when the user already owns as many configs as they
+// are allowed.
+type ConfigLimitError struct {
+	Count int64
+	Limit int64
+}
+
+func (e *ConfigLimitError) Error() string {
+	return fmt.Sprintf("%s: you own %d of the %d configs allowed, delete one or ask an admin to raise your limit",
+		ErrLimitExceeded, e.Count, e.Limit)
+}
+
+func (e *ConfigLimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ConfigLimit is how many configs a user owns against their limit.
+type ConfigLimit struct {
+	UserID  string `json:"user_id"`
+	Configs int64  `json:"configs"`
+	Limit   int64  `json:"limit"`  // 0 is unlimited
+	Custom  bool   `json:"custom"` // the limit was set by an admin instead of the server default
+}
+
+// newConfigLimit resolves the limit of a user owning configs, custom being their admin set limit
+// or 0.
+func newConfigLimit(userID string, configs, custom int64, limits SizeLimits) *ConfigLimit {
+	l := &ConfigLimit{UserID: userID, Configs: configs, Limit: limits.MaxUserConfigs}
+	if custom > 0 {
+		l.Limit = custom
+		l.Custom = true
+	}
+	return l
+}
+
+// check returns a ConfigLimitError when the user cannot create another config.
+func (l *ConfigLimit) check() error {
+	if l.Limit > 0 && l.Configs >= l.Limit {
+		return &ConfigLimitError{Count: l.Configs, Limit: l.Limit}
+	}
+	return nil
+}
+
+// checkSetUserConfigLimit validates a SetUserConfigLimit call.
+func checkSetUserConfigLimit(ctx context.Context, userID string, limit int64) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+	if userID == "" {
+		return invalidf("user id cannot be empty")
+	}
+	if limit < 0 {
+		return invalidf("config limit cannot be negative")
+	}
+	return nil
+}
+
+// configLimit returns the config count and limit of userID.
+func (m *ConfigManagerMongo) configLimit(ctx context.Context, userID string) (*ConfigLimit, error) {
+	count, err := m.Collection.CountDocuments(ctx, bson.M{"owner_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count configs: %w", err)
+	}
+	var q userQuota
+	err = m.QuotaCollection.FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"config_limit": 1})).Decode(&q)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to fetch config limit: %w", err)
+	}
+	return newConfigLimit(userID, count, q.ConfigLimit, m.limits), nil
+}
+
+// checkConfigLimit fails when user may not create another config. Admins have no limit.
+func (m *ConfigManagerMongo) checkConfigLimit(ctx context.Context, userID string, roles []string) error {
+	if isAdmin(roles) {
+		return nil
+	}
+	limit, err := m.configLimit(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return limit.check()
+}
+
+// SetUserConfigLimit overrides how many configs a user may own. Only admins may set limits; a
+// limit of zero restores the server default.
+func (m *ConfigManagerMongo) SetUserConfigLimit(ctx context.Context, userID string, limit int64) (_ *ConfigLimit, err error) {
+	defer func() {
+		m.logMutation(ctx, "SetUserConfigLimit", err, slog.String("target_user_id", userID), slog.Int64("config_limit", limit))
+	}()
+	if err := checkSetUserConfigLimit(ctx, userID, limit); err != nil {
+		return nil, err
+	}
+	// The limit is kept on the quota record, which has to exist before it is updated
+	if _, err := m.quotaUsage(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"config_limit": limit, "updated_timestamp": time.Now()}}
+	if limit == 0 {
+		update = bson.M{"$unset": bson.M{"config_limit": ""}, "$set": bson.M{"updated_timestamp": time.Now()}}
+	}
+	if _, err := m.QuotaCollection.UpdateOne(ctx, bson.M{"_id": userID}, update); err != nil {
+		return nil, fmt.Errorf("failed to set config limit: %w", err)
+	}
+	return m.configLimit(ctx, userID)
+}
+
+// configLimit returns the config count and limit of userID. Callers must hold m.mu.
+func (m *ConfigManagerMemory) configLimit(userID string) *ConfigLimit {
+	var count int64
+	for _, cfg := range m.configs {
+		if cfg.OwnerID == userID {
+			count++
+		}
+	}
+	return newConfigLimit(userID, count, m.quotas[userID].ConfigLimit, m.limits)
+}
+
+func (m *ConfigManagerMemory) SetUserConfigLimit(ctx context.Context, userID string, limit int64) (*ConfigLimit, error) {
+	if err := checkSetUserConfigLimit(ctx, userID, limit); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q := m.quotas[userID]
+	q.UserID = userID
+	q.ConfigLimit = limit
+	q.UpdatedTimestamp = time.Now()
+	m.quotas[userID] = q
+	return m.configLimit(userID), nil
+}
+
+// configLimit returns the config count and limit of userID.
+func (m *ConfigManagerSQLite) configLimit(ctx context.Context, q sqlQuerier, userID string) (*ConfigLimit, error) {
+	var count, custom int64
+	if err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM configs WHERE owner_id = ?`, userID).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to count configs: %w", err)
+	}
+	err := q.QueryRowContext(ctx, `SELECT config_limit FROM user_config_limits WHERE user_id = ?`, userID).Scan(&custom)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch config limit: %w", err)
+	}
+	return newConfigLimit(userID, count, custom, m.limits), nil
+}
+
+func (m *ConfigManagerSQLite) SetUserConfigLimit(ctx context.Context, userID string, limit int64) (*ConfigLimit, error) {
+	if err := checkSetUserConfigLimit(ctx, userID, limit); err != nil {
+		return nil, err
+	}
+
+	var result *ConfigLimit
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO user_config_limits (user_id, config_limit, updated_timestamp) VALUES (?, ?, ?)
+			ON CONFLICT(user_id) DO UPDATE SET config_limit = excluded.config_limit, updated_timestamp = excluded.updated_timestamp`,
+			userID, limit, time.Now().UnixNano())
+		if err != nil {
+			return fmt.Errorf("failed to set config limit: %w", err)
+		}
+		result, err = m.configLimit(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
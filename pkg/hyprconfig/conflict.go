@@ -0,0 +1,137 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Seann-Moser/credentials/session"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrConflict is returned when a config kept changing while a program config mutation was being
+// applied to it.
+var ErrConflict = errors.New("config was modified concurrently")
+
+// programWriteAttempts is how many times a program config mutation is applied to a freshly loaded
+// config before it gives up with ErrConflict.
+const programWriteAttempts = 2
+
+// loadForProgramWrite loads a config the user may modify.
+func (m *ConfigManagerMongo) loadForProgramWrite(ctx context.Context, configID string, user *session.UserSessionData) (*HyprConfig, error) {
+	var cfg HyprConfig
+	if err := m.Collection.FindOne(ctx, bson.M{"_id": configID}).Decode(&cfg); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if cfg.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+	return &cfg, nil
+}
+
+// unchangedFilter matches cfg only while its updated_timestamp is still the one it was read with.
+func unchangedFilter(cfg *HyprConfig) bson.M {
+	if cfg.UpdatedTimestamp.IsZero() {
+		// Old documents may not have the field at all
+		return bson.M{"_id": cfg.ID, "updated_timestamp": bson.M{"$in": bson.A{cfg.UpdatedTimestamp, nil}}}
+	}
+	return bson.M{"_id": cfg.ID, "updated_timestamp": cfg.UpdatedTimestamp}
+}
+
+// writeIfUnchanged applies update to cfg unless another write changed it since it was read, in
+// which case nothing is written and it reports false.
+func (m *ConfigManagerMongo) writeIfUnchanged(ctx context.Context, cfg *HyprConfig, update bson.M, opts ...*options.UpdateOptions) (bool, error) {
+	res, err := m.Collection.UpdateOne(ctx, unchangedFilter(cfg), update, opts...)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// retryOnConflict runs attempt, which loads the config and writes it with writeIfUnchanged, until
+// it has written or lost programWriteAttempts races.
+func retryOnConflict(attempt func() (bool, error)) error {
+	for range programWriteAttempts {
+		written, err := attempt()
+		if err != nil || written {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+// cloneProgramConfig deep copies pc, so a retried mutation starts from the caller's input again.
+func cloneProgramConfig(pc HyprProgramConfig) (HyprProgramConfig, error) {
+	cfg, err := cloneConfig(&HyprConfig{ProgramConfigs: []HyprProgramConfig{pc}})
+	if err != nil {
+		return HyprProgramConfig{}, err
+	}
+	return cfg.ProgramConfigs[0], nil
+}
+
+// topLevelProgram reports whether progID is one of the top-level program configs.
+func topLevelProgram(list []HyprProgramConfig, progID string) bool {
+	for i := range list {
+		if list[i].ID == progID {
+			return true
+		}
+	}
+	return false
+}
+
+// programIDKey is the bson key of HyprProgramConfig.ID, which updates match program configs on.
+const programIDKey = "id"
+
+// maxPullDepth is the deepest nesting, counting the top level as 1, that a program config is
+// removed from with a $pull scoped to its parent. Deeper ones are removed by rewriting the tree.
+const maxPullDepth = 4
+
+// programPath returns the IDs of the program configs from the top level down to progID, or nil
+// when it is not in list.
+func programPath(list []HyprProgramConfig, progID string) []string {
+	for i := range list {
+		if list[i].ID == progID {
+			return []string{progID}
+		}
+		if rest := nestedProgramPath(list[i].SubConfigs, progID); rest != nil {
+			return append([]string{list[i].ID}, rest...)
+		}
+	}
+	return nil
+}
+
+func nestedProgramPath(list []*HyprProgramConfig, progID string) []string {
+	for _, pc := range list {
+		if pc == nil {
+			continue
+		}
+		if pc.ID == progID {
+			return []string{progID}
+		}
+		if rest := nestedProgramPath(pc.SubConfigs, progID); rest != nil {
+			return append([]string{pc.ID}, rest...)
+		}
+	}
+	return nil
+}
+
+// pullProgramUpdate returns the $pull removing the last program config of path from its
+// parent's array and the array filters locating that parent. ok is false for an empty path
+// or one deeper than maxPullDepth.
+func pullProgramUpdate(path []string) (pull bson.M, filters []interface{}, ok bool) {
+	if len(path) == 0 || len(path) > maxPullDepth {
+		return nil, nil, false
+	}
+	field := "program_configs"
+	for i, id := range path[:len(path)-1] {
+		name := fmt.Sprintf("p%d", i)
+		field += ".$[" + name + "].sub_configs"
+		filters = append(filters, bson.M{name + "." + programIDKey: id})
+	}
+	return bson.M{field: bson.M{programIDKey: path[len(path)-1]}}, filters, true
+}
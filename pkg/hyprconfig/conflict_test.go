@@ -0,0 +1,189 @@
+package hyprconfig
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestManagerConcurrentProgramAdds(t *testing.T) {
+	forEachManagerAndMongo(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, prog := range []HyprProgramConfig{
+			{ID: "bar", Title: "bar", Program: "waybar"},
+			{ID: "launcher", Title: "launcher", Program: "wofi"},
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = m.AddProgramConfig(ctx, cfg.ID, prog, nil, "")
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, id := range []string{"term", "bar", "launcher"} {
+			if _, err := m.GetProgramConfig(ctx, cfg.ID, id); err != nil {
+				t.Errorf("program %s after concurrent adds: %v", id, err)
+			}
+		}
+	})
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	calls := 0
+	err := retryOnConflict(func() (bool, error) {
+		calls++
+		return calls == 2, nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("lost one race: err %v after %d calls, want success after 2", err, calls)
+	}
+
+	calls = 0
+	err = retryOnConflict(func() (bool, error) {
+		calls++
+		return false, nil
+	})
+	if !errors.Is(err, ErrConflict) || calls != programWriteAttempts {
+		t.Errorf("lost every race: err %v after %d calls, want ErrConflict after %d", err, calls, programWriteAttempts)
+	}
+}
+
+func TestWithChangelogKeepsOtherPushes(t *testing.T) {
+	update := withChangelog(bson.M{"$push": bson.M{"program_configs": HyprProgramConfig{ID: "bar"}}}, "1.0.0", "add bar", "alice")
+	push := update["$push"].(bson.M)
+	if _, ok := push["program_configs"]; !ok {
+		t.Error("program_configs $push was dropped")
+	}
+	if _, ok := push["changelog"]; !ok {
+		t.Error("changelog $push is missing")
+	}
+}
+
+func TestManagerNestedRemoveKeepsConcurrentAdd(t *testing.T) {
+	forEachManagerAndMongo(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+		parent := "term"
+		for _, id := range []string{"theme", "keys"} {
+			if err := m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{ID: id, Title: id, Program: "kitty"}, &parent, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var wg sync.WaitGroup
+		var addErr, removeErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			addErr = m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{ID: "bar", Title: "bar", Program: "waybar"}, nil, "")
+		}()
+		go func() {
+			defer wg.Done()
+			removeErr = m.RemoveProgramConfig(ctx, cfg.ID, "theme", "")
+		}()
+		wg.Wait()
+		if addErr != nil || removeErr != nil {
+			t.Fatalf("add: %v, remove: %v", addErr, removeErr)
+		}
+
+		got, err := m.GetConfig(ctx, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path := programPath(got.ProgramConfigs, "bar"); !reflect.DeepEqual(path, []string{"bar"}) {
+			t.Errorf("top-level add was lost, path to bar = %v", path)
+		}
+		if path := programPath(got.ProgramConfigs, "theme"); path != nil {
+			t.Errorf("nested remove was lost, path to theme = %v", path)
+		}
+		if path := programPath(got.ProgramConfigs, "keys"); !reflect.DeepEqual(path, []string{"term", "keys"}) {
+			t.Errorf("sibling of the removed config: path to keys = %v", path)
+		}
+	})
+}
+
+func TestUnchangedFilter(t *testing.T) {
+	read := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	got := unchangedFilter(&HyprConfig{ID: "cfg", UpdatedTimestamp: read})
+	if want := (bson.M{"_id": "cfg", "updated_timestamp": read}); !reflect.DeepEqual(got, want) {
+		t.Errorf("unchangedFilter = %v, want %v", got, want)
+	}
+
+	// Documents written before the field existed match while it is still missing
+	got = unchangedFilter(&HyprConfig{ID: "cfg"})
+	want := bson.M{"_id": "cfg", "updated_timestamp": bson.M{"$in": bson.A{time.Time{}, nil}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unchangedFilter without a timestamp = %v, want %v", got, want)
+	}
+}
+
+func TestPullProgramUpdate(t *testing.T) {
+	pull, filters, ok := pullProgramUpdate([]string{"term"})
+	if !ok || len(filters) != 0 || !reflect.DeepEqual(pull, bson.M{"program_configs": bson.M{"id": "term"}}) {
+		t.Errorf("top level: %v %v %v", pull, filters, ok)
+	}
+
+	pull, filters, ok = pullProgramUpdate([]string{"term", "theme", "colors"})
+	wantPull := bson.M{"program_configs.$[p0].sub_configs.$[p1].sub_configs": bson.M{"id": "colors"}}
+	wantFilters := []interface{}{bson.M{"p0.id": "term"}, bson.M{"p1.id": "theme"}}
+	if !ok || !reflect.DeepEqual(pull, wantPull) || !reflect.DeepEqual(filters, wantFilters) {
+		t.Errorf("nested: %v %v %v", pull, filters, ok)
+	}
+
+	if _, _, ok := pullProgramUpdate(make([]string, maxPullDepth+1)); ok {
+		t.Error("a path deeper than maxPullDepth got a scoped $pull")
+	}
+	if _, _, ok := pullProgramUpdate(nil); ok {
+		t.Error("a missing program config got a $pull")
+	}
+}
+
+func TestProgramIDKeyMatchesBSONTag(t *testing.T) {
+	raw, err := bson.Marshal(HyprProgramConfig{ID: "term"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := bson.Raw(raw).Lookup(programIDKey).StringValueOK(); !ok || id != "term" {
+		t.Errorf("HyprProgramConfig.ID is not stored under %q", programIDKey)
+	}
+}
+
+func TestManagerRemoveMissingProgram(t *testing.T) {
+	forEachManagerAndMongo(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+		if err := m.VerifyConfig(asUser("mod", "admin"), cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		before, err := m.GetConfig(alice, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := m.RemoveProgramConfig(alice, cfg.ID, "missing", "remove nothing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("removing a missing program config: got %v, want ErrNotFound", err)
+		}
+		after, err := m.GetConfig(alice, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !after.Verified || !after.UpdatedTimestamp.Equal(before.UpdatedTimestamp) || len(after.Changelog) != len(before.Changelog) {
+			t.Errorf("config was written: verified %v, updated %v -> %v, changelog %d -> %d",
+				after.Verified, before.UpdatedTimestamp, after.UpdatedTimestamp, len(before.Changelog), len(after.Changelog))
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxDeprecationMessageLength caps the note an owner leaves when deprecating a config.
+const MaxDeprecationMessageLength = 1000
+
+// ConfigDeprecation marks a config its owner no longer maintains, optionally pointing at the
+// config that replaces it.
+type ConfigDeprecation struct {
+	SuccessorID  string    `json:"successor_id,omitempty" bson:"successor_id,omitempty"`
+	Message      string    `json:"message,omitempty" bson:"message,omitempty"`
+	DeprecatedBy string    `json:"deprecated_by" bson:"deprecated_by"`
+	DeprecatedAt time.Time `json:"deprecated_at" bson:"deprecated_at"`
+}
+
+// newDeprecation validates a DeprecateConfig request. The successor must be another public config
+// the caller can read, looked up with get.
+func newDeprecation(
+	ctx context.Context,
+	configID string,
+	successorID *string,
+	message string,
+	actorID string,
+	get func(ctx context.Context, id string) (*HyprConfig, error),
+) (*ConfigDeprecation, error) {
+	d := &ConfigDeprecation{
+		Message:      strings.TrimSpace(message),
+		DeprecatedBy: actorID,
+		DeprecatedAt: time.Now(),
+	}
+	if len(d.Message) > MaxDeprecationMessageLength {
+		return nil, invalidf("deprecation message is longer than %d bytes", MaxDeprecationMessageLength)
+	}
+	if successorID == nil || strings.TrimSpace(*successorID) == "" {
+		return d, nil
+	}
+
+	d.SuccessorID = strings.TrimSpace(*successorID)
+	if d.SuccessorID == configID {
+		return nil, invalidf("a config can't be its own successor")
+	}
+	successor, err := get(ctx, d.SuccessorID)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+		return nil, invalidf("successor config %q not found", d.SuccessorID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if successor.Private {
+		return nil, invalidf("successor config %q is private", d.SuccessorID)
+	}
+	return d, nil
+}
+
+// notifications builds the NotificationConfigDeprecated sent to the users applying configID.
+func (d *ConfigDeprecation) notifications(configID string, appliers []string) []Notification {
+	notifications := newNotifications(appliers, NotificationConfigDeprecated, d.DeprecatedBy, configID)
+	for i := range notifications {
+		notifications[i].SuccessorID = d.SuccessorID
+		notifications[i].Message = d.Message
+	}
+	return notifications
+}
+
+// DeprecateConfig marks a config as deprecated, optionally pointing at its successor. The config
+// stays readable and appliable but ranks last in searches, and the users currently applying it
+// are notified. Only the owner or an admin may deprecate a config; deprecating it again replaces
+// the deprecation.
+func (m *ConfigManagerMongo) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) (err error) {
+	defer func() { m.logMutation(ctx, "DeprecateConfig", err, slog.String("config_id", configID)) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := m.loadForProgramWrite(ctx, configID, user); err != nil {
+		return err
+	}
+	d, err := newDeprecation(ctx, configID, successorID, message, user.UserID, m.GetConfig)
+	if err != nil {
+		return err
+	}
+
+	res, err := m.Collection.UpdateOne(ctx, bson.M{"_id": configID}, bson.M{"$set": bson.M{
+		"deprecation": d,
+		"deprecated":  true,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to deprecate config: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	m.recordMutation(ctx, newAuditEntry(user.UserID, AuditDeprecateConfig, configID, "", nil))
+
+	appliers, err := m.appliers(ctx, configID)
+	if err != nil {
+		slog.Warn("failed to find users to notify", "type", NotificationConfigDeprecated, "config_id", configID, "err", err)
+		return nil
+	}
+	m.insertNotifications(ctx, d.notifications(configID, appliers))
+	return nil
+}
+
+// appliers returns up to MaxNotificationFanOut users currently applying configID.
+func (m *ConfigManagerMongo) appliers(ctx context.Context, configID string) ([]string, error) {
+	cursor, err := m.StateCollection.Find(ctx, bson.M{"config_id": configID},
+		options.Find().SetProjection(bson.M{"_id": 0, "user_id": 1}).SetLimit(MaxNotificationFanOut))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		UserID string `bson:"user_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	users := make([]string, len(docs))
+	for i, d := range docs {
+		users[i] = d.UserID
+	}
+	return users, nil
+}
+
+func (m *ConfigManagerMemory) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := newDeprecation(ctx, configID, successorID, message, user.UserID, m.GetConfig)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return err
+	}
+	cfg.Deprecation = d
+	if err := m.storeAudited(cfg, newAuditEntry(user.UserID, AuditDeprecateConfig, configID, "", nil)); err != nil {
+		return err
+	}
+
+	var appliers []string
+	for userID, s := range m.state {
+		if s.ConfigID == configID {
+			appliers = append(appliers, userID)
+		}
+	}
+	sort.Strings(appliers)
+	for _, n := range d.notifications(configID, appliers) {
+		m.notifications[n.ID] = n
+	}
+	return nil
+}
+
+func (m *ConfigManagerSQLite) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	d, err := newDeprecation(ctx, configID, successorID, message, user.UserID, m.GetConfig)
+	if err != nil {
+		return err
+	}
+
+	return m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		cfg.Deprecation = d
+		appliers, err := appliers(ctx, tx, configID)
+		if err != nil {
+			return AuditEntry{}, err
+		}
+		if err := insertNotifications(ctx, tx, d.notifications(configID, appliers)); err != nil {
+			return AuditEntry{}, err
+		}
+		return newAuditEntry(user.UserID, AuditDeprecateConfig, configID, "", nil), nil
+	})
+}
+
+// appliers returns up to MaxNotificationFanOut users currently applying configID.
+func appliers(ctx context.Context, q sqlQuerier, configID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT user_id FROM user_state WHERE config_id = ? ORDER BY user_id LIMIT ?`,
+		configID, MaxNotificationFanOut)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,91 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeprecateConfig(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice, bob := asUser("alice"), asUser("bob")
+		old := newTestConfig(t, m, "alice", false)
+		successor, err := m.CreateConfig(alice, hyprlandConfig("rice v2", "", "general {\n    gaps_in = 8\n}\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hidden := hyprlandConfig("drafts", "", "general {\n    gaps_in = 2\n}\n")
+		hidden.Private = true
+		if hidden, err = m.CreateConfig(alice, hidden); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.ApplyConfig(bob, old.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		successorID := successor.ID
+		if err := m.DeprecateConfig(bob, old.ID, &successorID, ""); !errors.Is(err, ErrForbidden) {
+			t.Errorf("deprecate as non-owner: got %v, want ErrForbidden", err)
+		}
+		for _, id := range []string{"missing", old.ID, hidden.ID} {
+			if err := m.DeprecateConfig(alice, old.ID, &id, ""); !errors.Is(err, ErrValidation) {
+				t.Errorf("successor %q: got %v, want ErrValidation", id, err)
+			}
+		}
+		if err := m.DeprecateConfig(alice, old.ID, nil, strings.Repeat("x", MaxDeprecationMessageLength+1)); !errors.Is(err, ErrValidation) {
+			t.Errorf("long message: got %v, want ErrValidation", err)
+		}
+		if err := m.DeprecateConfig(alice, old.ID, &successorID, " moved to v2 "); err != nil {
+			t.Fatal(err)
+		}
+
+		applied, err := m.GetAppliedConfig(bob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := applied.Deprecation; d == nil || d.SuccessorID != successor.ID || d.Message != "moved to v2" || d.DeprecatedBy != "alice" {
+			t.Errorf("applied config deprecation = %+v", d)
+		}
+
+		inbox, err := m.ListNotifications(bob, 1, 10, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inbox.Total != 1 {
+			t.Fatalf("bob's inbox = %+v, want the deprecation", inbox.Items)
+		}
+		if n := inbox.Items[0]; n.Type != NotificationConfigDeprecated || n.ConfigID != old.ID || n.SuccessorID != successor.ID || n.Message != "moved to v2" {
+			t.Errorf("notification = %+v", n)
+		}
+
+		// old is the most liked, but deprecated configs rank last
+		if err := m.FavoriteConfig(bob, old.ID); err != nil {
+			t.Fatal(err)
+		}
+		search := func(filters ConfigSearchFilters) string {
+			t.Helper()
+			filters.OwnerID = "alice"
+			filters.Private = new(bool)
+			page, err := m.ListConfigsWithFilters(alice, 1, 10, filters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return strings.Join(configIDs(page.Items), ",")
+		}
+		if got, want := search(ConfigSearchFilters{Sort: SearchSortLikes}), successor.ID+","+old.ID; got != want {
+			t.Errorf("sort=likes: %q, want %q", got, want)
+		}
+		deprecated := false
+		if got := search(ConfigSearchFilters{Deprecated: &deprecated}); got != successor.ID {
+			t.Errorf("deprecated=false matched %q, want %q", got, successor.ID)
+		}
+
+		// Edits keep the deprecation, users can't drop it through an update
+		if err := m.UpdateConfig(alice, old.ID, map[string]any{"description": "see v2", "deprecation": nil, "deprecated": false}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := m.GetConfig(alice, old.ID); err != nil || got.Deprecation == nil || !got.Deprecated {
+			t.Errorf("after updating: deprecation %+v, %v", got.Deprecation, err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package hyprconfig
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxLayoutEntries bounds the monitors and workspace rules kept in a DisplayLayout, so a file
+// of generated rules can't bloat the config document.
+const maxLayoutEntries = 100
+
+// resolutionRe is what a fixed monitor resolution looks like, e.g. 2560x1440.
+var resolutionRe = regexp.MustCompile(`^[0-9]+x[0-9]+$`)
+
+// Monitor is a monitor= line of a Hyprland config.
+type Monitor struct {
+	Name        string `json:"name" bson:"name"`                                     // empty for the rule matching any monitor
+	Resolution  string `json:"resolution,omitempty" bson:"resolution,omitempty"`     // e.g. 2560x1440, or preferred
+	RefreshRate string `json:"refresh_rate,omitempty" bson:"refresh_rate,omitempty"` // e.g. 144, from 2560x1440@144
+	Position    string `json:"position,omitempty" bson:"position,omitempty"`         // e.g. 0x0 or auto
+	Scale       string `json:"scale,omitempty" bson:"scale,omitempty"`               // e.g. 1.5 or auto
+	Disabled    bool   `json:"disabled,omitempty" bson:"disabled,omitempty"`
+}
+
+// WorkspaceRule is a workspace= line of a Hyprland config.
+type WorkspaceRule struct {
+	Workspace string   `json:"workspace" bson:"workspace"` // e.g. 1, name:web or special:scratch
+	Monitor   string   `json:"monitor,omitempty" bson:"monitor,omitempty"`
+	Default   bool     `json:"default,omitempty" bson:"default,omitempty"`
+	Rules     []string `json:"rules,omitempty" bson:"rules,omitempty"` // any other rule, e.g. gapsout:0
+}
+
+// DisplayLayout summarizes the monitors and workspace rules of a config, so it can be searched
+// by the hardware it is laid out for and previewed. Stored on write, see HyprConfig.Display.
+type DisplayLayout struct {
+	Monitors   []Monitor       `json:"monitors,omitempty" bson:"monitors,omitempty"`
+	Workspaces []WorkspaceRule `json:"workspaces,omitempty" bson:"workspaces,omitempty"`
+
+	// Named monitors that aren't disabled. Zero means the config fits any number of monitors.
+	MonitorCount int `json:"monitor_count" bson:"monitor_count"`
+
+	// Fixed resolutions of the monitors that aren't disabled, sorted.
+	Resolutions []string `json:"resolutions,omitempty" bson:"resolutions,omitempty"`
+}
+
+// hyprLines calls fn with the keyword and value of every keyword = value line of a Hyprland
+// config, with comments stripped.
+func hyprLines(data []byte, fn func(line int, keyword, value string)) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		keyword, value, ok := strings.Cut(stripHyprComment(scanner.Text()), "=")
+		if ok {
+			fn(line, strings.TrimSpace(keyword), strings.TrimSpace(value))
+		}
+	}
+}
+
+// splitFields splits a comma separated Hyprland value, trimming each field.
+func splitFields(value string) []string {
+	fields := strings.Split(value, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// ParseMonitors returns the monitor= lines of a Hyprland config. A later line for the same
+// monitor replaces an earlier one, as in Hyprland; lines reserving space instead of defining a
+// monitor are skipped.
+func ParseMonitors(data []byte) []Monitor {
+	var monitors []Monitor
+	index := map[string]int{}
+	hyprLines(data, func(_ int, keyword, value string) {
+		if keyword != "monitor" {
+			return
+		}
+		fields := splitFields(value)
+		if len(fields) < 2 || fields[1] == "addreserved" {
+			return
+		}
+		m := Monitor{Name: fields[0]}
+		if fields[1] == "disable" || fields[1] == "disabled" {
+			m.Disabled = true
+		} else {
+			m.Resolution, m.RefreshRate, _ = strings.Cut(strings.ToLower(fields[1]), "@")
+			m.RefreshRate = strings.TrimSuffix(m.RefreshRate, "hz")
+			if len(fields) > 2 {
+				m.Position = fields[2]
+			}
+			if len(fields) > 3 {
+				m.Scale = fields[3]
+			}
+		}
+		if i, ok := index[m.Name]; ok {
+			monitors[i] = m
+			return
+		}
+		index[m.Name] = len(monitors)
+		monitors = append(monitors, m)
+	})
+	return monitors
+}
+
+// ParseWorkspaces returns the workspace= lines of a Hyprland config.
+func ParseWorkspaces(data []byte) []WorkspaceRule {
+	var rules []WorkspaceRule
+	hyprLines(data, func(_ int, keyword, value string) {
+		if keyword != "workspace" {
+			return
+		}
+		fields := splitFields(value)
+		if fields[0] == "" {
+			return
+		}
+		rule := WorkspaceRule{Workspace: fields[0]}
+		for _, field := range fields[1:] {
+			name, arg, _ := strings.Cut(field, ":")
+			switch {
+			case field == "":
+			case name == "monitor":
+				rule.Monitor = arg
+			case name == "default":
+				rule.Default = arg == "true" || arg == "1" || arg == "yes"
+			default:
+				rule.Rules = append(rule.Rules, field)
+			}
+		}
+		rules = append(rules, rule)
+	})
+	return rules
+}
+
+// displayLayout parses the monitors and workspace rules of every Hyprland file of hc, nil when
+// there are none. Files may be compressed, as in stored documents; offloaded files are too
+// large to be a hand-written monitor setup and are skipped.
+func (hc *HyprConfig) displayLayout() *DisplayLayout {
+	layout := &DisplayLayout{}
+	index := map[string]int{}
+	eachHyprlandFile(hc, func(_ *HyprProgramConfig, _ FileEntry, data []byte) {
+		for _, m := range ParseMonitors(data) {
+			if i, ok := index[m.Name]; ok {
+				layout.Monitors[i] = m
+			} else if len(layout.Monitors) < maxLayoutEntries {
+				index[m.Name] = len(layout.Monitors)
+				layout.Monitors = append(layout.Monitors, m)
+			}
+		}
+		for _, rule := range ParseWorkspaces(data) {
+			if len(layout.Workspaces) < maxLayoutEntries {
+				layout.Workspaces = append(layout.Workspaces, rule)
+			}
+		}
+	})
+	if len(layout.Monitors) == 0 && len(layout.Workspaces) == 0 {
+		return nil
+	}
+
+	resolutions := map[string]struct{}{}
+	for _, m := range layout.Monitors {
+		if m.Disabled {
+			continue
+		}
+		if m.Name != "" {
+			layout.MonitorCount++
+		}
+		if resolutionRe.MatchString(m.Resolution) {
+			resolutions[m.Resolution] = struct{}{}
+		}
+	}
+	layout.Resolutions = sortedSet(resolutions)
+	if len(layout.Resolutions) == 0 {
+		layout.Resolutions = nil
+	}
+	return layout
+}
+
+// normalizeResolution canonicalizes a resolution search filter such as 2560X1440.
+func normalizeResolution(r string) (string, error) {
+	res := strings.ToLower(strings.ReplaceAll(r, " ", ""))
+	if !resolutionRe.MatchString(res) {
+		return "", invalidf("invalid resolution %q: use WIDTHxHEIGHT, e.g. 2560x1440", r)
+	}
+	return res, nil
+}
+
+// fitsMonitors reports whether a config with layout works with n monitors: it is laid out for
+// exactly n, or doesn't name any.
+func (l *DisplayLayout) fitsMonitors(n int) bool {
+	return l == nil || l.MonitorCount == 0 || l.MonitorCount == n
+}
+
+// hasResolution reports whether a monitor of layout runs at res.
+func (l *DisplayLayout) hasResolution(res string) bool {
+	if l == nil {
+		return false
+	}
+	i := sort.SearchStrings(l.Resolutions, res)
+	return i < len(l.Resolutions) && l.Resolutions[i] == res
+}
@@ -0,0 +1,119 @@
+package hyprconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const dualMonitors = `monitor = DP-1, 2560x1440@144, 0x0, 1
+monitor = HDMI-A-1, 1920x1080, 2560x0, 1 # right of DP-1
+monitor = , preferred, auto, 1
+monitor = eDP-1, disable
+monitor = DP-1, 2560x1440@165Hz, 0x0, 1.25
+monitor = DP-1, addreserved, 10, 0, 0, 0
+# monitor = DP-2, 3840x2160, auto, 2
+workspace = 1, monitor:DP-1, default:true
+workspace = special:scratch, monitor:HDMI-A-1, gapsout:0
+`
+
+func TestParseMonitors(t *testing.T) {
+	want := []Monitor{
+		{Name: "DP-1", Resolution: "2560x1440", RefreshRate: "165", Position: "0x0", Scale: "1.25"},
+		{Name: "HDMI-A-1", Resolution: "1920x1080", Position: "2560x0", Scale: "1"},
+		{Name: "", Resolution: "preferred", Position: "auto", Scale: "1"},
+		{Name: "eDP-1", Disabled: true},
+	}
+	if got := ParseMonitors([]byte(dualMonitors)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMonitors =\n%+v\nwant\n%+v", got, want)
+	}
+
+	wantRules := []WorkspaceRule{
+		{Workspace: "1", Monitor: "DP-1", Default: true},
+		{Workspace: "special:scratch", Monitor: "HDMI-A-1", Rules: []string{"gapsout:0"}},
+	}
+	if got := ParseWorkspaces([]byte(dualMonitors)); !reflect.DeepEqual(got, wantRules) {
+		t.Errorf("ParseWorkspaces =\n%+v\nwant\n%+v", got, wantRules)
+	}
+}
+
+func TestDisplayLayout(t *testing.T) {
+	cfg := &HyprConfig{ProgramConfigs: []HyprProgramConfig{{
+		Program: "hyprland", FileContent: FileContent{Data: []byte("monitor = , preferred, auto, 1\n"), FileType: FileTypeConfig},
+		SubConfigs: []*HyprProgramConfig{{
+			Program: "hyprland", InstallPath: "~/.config/hypr/monitors.conf",
+			FileContent: FileContent{Data: []byte(dualMonitors), FileType: FileTypeConfig},
+		}},
+	}}}
+	// Stored documents may be compressed
+	if err := cfg.ProgramConfigs[0].SubConfigs[0].FileContent.Compress(); err != nil {
+		t.Fatal(err)
+	}
+
+	layout := cfg.displayLayout()
+	if layout == nil || layout.MonitorCount != 2 || len(layout.Monitors) != 4 || len(layout.Workspaces) != 2 ||
+		!reflect.DeepEqual(layout.Resolutions, []string{"1920x1080", "2560x1440"}) {
+		t.Fatalf("layout = %+v", layout)
+	}
+	if !layout.fitsMonitors(2) || layout.fitsMonitors(1) || !layout.hasResolution("1920x1080") || layout.hasResolution("3840x2160") {
+		t.Errorf("filters don't match layout %+v", layout)
+	}
+	if layout := (&HyprConfig{ProgramConfigs: []HyprProgramConfig{{Program: "kitty"}}}).displayLayout(); layout != nil {
+		t.Errorf("config without Hyprland files has layout %+v", layout)
+	}
+}
+
+func TestSearchDisplay(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		create := func(title, hyprland string) string {
+			t.Helper()
+			cfg, err := m.CreateConfig(alice, &HyprConfig{Title: title, ProgramConfigs: []HyprProgramConfig{
+				{Title: "hyprland", Program: "hyprland", FileContent: FileContent{Data: []byte(hyprland), FileType: FileTypeConfig}},
+			}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return cfg.ID
+		}
+		dual := create("dual", dualMonitors)
+		laptop := create("laptop", "monitor = eDP-1, 1920x1080, 0x0, 1\n")
+		generic := create("any", "monitor = , preferred, auto, 1\n")
+
+		got, err := m.GetConfig(alice, dual)
+		if err != nil || got.Display == nil || got.Display.MonitorCount != 2 {
+			t.Fatalf("stored display = %+v, %v", got.Display, err)
+		}
+
+		for _, tt := range []struct {
+			filters ConfigSearchFilters
+			want    []string
+		}{
+			{ConfigSearchFilters{Monitors: 2}, []string{dual, generic}},
+			{ConfigSearchFilters{Monitors: 1}, []string{laptop, generic}},
+			{ConfigSearchFilters{Resolution: "1920X1080"}, []string{dual, laptop}},
+			{ConfigSearchFilters{Monitors: 1, Resolution: "2560x1440"}, nil},
+		} {
+			res, err := m.ListConfigsWithFilters(alice, 1, 10, tt.filters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, cfg := range res.Items {
+				ids = append(ids, cfg.ID)
+			}
+			if len(ids) != len(tt.want) {
+				t.Errorf("%+v = %v, want %v", tt.filters, ids, tt.want)
+				continue
+			}
+			for _, id := range tt.want {
+				if !containsExact(ids, id) {
+					t.Errorf("%+v = %v, want %v", tt.filters, ids, tt.want)
+				}
+			}
+		}
+		if _, err := m.ListConfigsWithFilters(alice, 1, 10, ConfigSearchFilters{Resolution: "1080p"}, nil); !errors.Is(err, ErrValidation) {
+			t.Errorf("invalid resolution: got %v, want ErrValidation", err)
+		}
+	})
+}
@@ -0,0 +1,1105 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DumpSchemaVersion is the version of the ExportAll format. ImportAll reads dumps up to it.
+// Version 2 added follow records.
+const DumpSchemaVersion = 2
+
+// Kinds of the records in a dump. The header comes first, then the records of each kind in
+// this order, so allowed programs exist before the configs using them are validated.
+const (
+	DumpRecordHeader   = "header"
+	DumpRecordProgram  = "allowed_program"
+	DumpRecordConfig   = "config"
+	DumpRecordFavorite = "favorite"
+	DumpRecordState    = "state"
+	DumpRecordFollow   = "follow"
+)
+
+// Conflict policies of ImportAll for records whose key already exists.
+const (
+	ImportConflictSkip      = "skip"
+	ImportConflictOverwrite = "overwrite"
+)
+
+// importBatchSize is how many consecutive records of one kind ImportAll writes together.
+const importBatchSize = 500
+
+// ErrDumpFormat is returned when a dump cannot be read, before or while it is imported.
+var ErrDumpFormat = errors.New("invalid database dump")
+
+// DatabaseDumper is implemented by the config managers that can copy their whole database,
+// to move a deployment between clusters or backends.
+type DatabaseDumper interface {
+	// ExportAll writes every allowed program, config, favorite, applied config state and follow
+	// as newline-delimited JSON, after a DumpHeader record. File content, offloaded or not, and
+	// gallery images are inlined so the dump does not depend on the source's file store.
+	ExportAll(ctx context.Context, w io.Writer) error
+
+	// ImportAll validates and writes the records of a dump written by ExportAll, keeping their
+	// ids, owners and timestamps. Invalid records are reported in the result and skipped; an
+	// unreadable dump or a failing database stops the import.
+	ImportAll(ctx context.Context, r io.Reader, opts ImportAllOptions) (*ImportAllResult, error)
+}
+
+// DumpHeader is the first record of a dump.
+type DumpHeader struct {
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	Backend       string    `json:"backend"` // mongo, sqlite or memory
+}
+
+// ImportAllOptions configures ImportAll.
+type ImportAllOptions struct {
+	// OnConflict is ImportConflictSkip, the default, or ImportConflictOverwrite.
+	OnConflict string
+}
+
+// ImportAllResult reports what ImportAll did with each kind of record.
+type ImportAllResult struct {
+	Header   DumpHeader                `json:"header"`
+	Counts   map[string]map[string]int `json:"counts"` // record kind -> import status -> records
+	Failures []ImportFailure           `json:"failures,omitempty"`
+}
+
+// ImportFailure is a record ImportAll could not import.
+type ImportFailure struct {
+	Kind  string `json:"kind"`
+	Key   string `json:"key"` // program name, config id, user_id/config_id, user id or follower_id/followee_id
+	Error string `json:"error"`
+}
+
+// record counts one record of kind, keeping err when it failed.
+func (r *ImportAllResult) record(kind, key, status string, err error) {
+	if r.Counts[kind] == nil {
+		r.Counts[kind] = map[string]int{}
+	}
+	r.Counts[kind][status]++
+	if status == ImportStatusFailed && err != nil {
+		r.Failures = append(r.Failures, ImportFailure{Kind: kind, Key: key, Error: err.Error()})
+	}
+}
+
+// dumpRecord is one line of a dump.
+type dumpRecord struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// dumpWriter writes the records of a dump.
+type dumpWriter struct {
+	enc *json.Encoder
+}
+
+// newDumpWriter starts a dump of backend on w with its header.
+func newDumpWriter(w io.Writer, backend string) (*dumpWriter, error) {
+	d := &dumpWriter{enc: json.NewEncoder(w)}
+	header := DumpHeader{SchemaVersion: DumpSchemaVersion, ExportedAt: time.Now().UTC(), Backend: backend}
+	return d, d.write(DumpRecordHeader, header)
+}
+
+func (d *dumpWriter) write(kind string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", kind, err)
+	}
+	if err := d.enc.Encode(dumpRecord{Kind: kind, Data: data}); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+	return nil
+}
+
+// config writes cfg with its file content and gallery images read from files and decompressed.
+func (d *dumpWriter) config(ctx context.Context, files FileStore, cfg *HyprConfig) error {
+	var err error
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		pc.eachFile(func(_ string, fc *FileContent) {
+			if err == nil {
+				err = inlineFile(ctx, files, fc)
+			}
+		})
+	})
+	for i := range cfg.GalleryImages {
+		if err == nil {
+			err = inlineFile(ctx, files, &cfg.GalleryImages[i].FileContent)
+		}
+	}
+	if err == nil {
+		err = cfg.decompressContent()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to export config %s: %w", cfg.ID, err)
+	}
+	return d.write(DumpRecordConfig, cfg)
+}
+
+// inlineFile loads content offloaded to files back into fc.
+func inlineFile(ctx context.Context, files FileStore, fc *FileContent) error {
+	if fc.FileID == "" || len(fc.Data) > 0 {
+		fc.FileID = ""
+		return nil
+	}
+	if files == nil {
+		return ErrFileStoreDisabled
+	}
+	rc, err := files.Open(ctx, fc.FileID)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if fc.Data, err = io.ReadAll(rc); err != nil {
+		return err
+	}
+	fc.FileID = ""
+	return nil
+}
+
+// importOutcome is what happened to one record a backend was asked to write.
+type importOutcome struct {
+	status string
+	err    error
+}
+
+func importFailed(err error) importOutcome {
+	return importOutcome{status: ImportStatusFailed, err: err}
+}
+
+// newOrUpdated is the outcome of writing a record that did or did not exist before.
+func newOrUpdated(existed bool) importOutcome {
+	if existed {
+		return importOutcome{status: ImportStatusUpdated}
+	}
+	return importOutcome{status: ImportStatusInserted}
+}
+
+// dumpImporter writes batches of dump records to one backend. Each returns one outcome per
+// record and an error only when the batch could not be written at all.
+type dumpImporter interface {
+	importPrograms(ctx context.Context, programs []AllowedPrograms, overwrite bool) ([]importOutcome, error)
+	importConfigs(ctx context.Context, configs []HyprConfig, overwrite bool) ([]importOutcome, error)
+	importFavorites(ctx context.Context, favorites []UserFavorite, overwrite bool) ([]importOutcome, error)
+	importStates(ctx context.Context, states []UserHyprState, overwrite bool) ([]importOutcome, error)
+	importFollows(ctx context.Context, follows []Follow, overwrite bool) ([]importOutcome, error)
+}
+
+// importAll reads the dump in r and writes it to dst in batches of consecutive records of a kind.
+func importAll(ctx context.Context, r io.Reader, opts ImportAllOptions, dst dumpImporter) (*ImportAllResult, error) {
+	var overwrite bool
+	switch opts.OnConflict {
+	case "", ImportConflictSkip:
+	case ImportConflictOverwrite:
+		overwrite = true
+	default:
+		return nil, invalidf("unknown conflict policy %q, expected %s or %s", opts.OnConflict, ImportConflictSkip, ImportConflictOverwrite)
+	}
+
+	dec := json.NewDecoder(r)
+	var first dumpRecord
+	if err := dec.Decode(&first); err != nil {
+		return nil, fmt.Errorf("%w: failed to read the header: %v", ErrDumpFormat, err)
+	}
+	res := &ImportAllResult{Counts: map[string]map[string]int{}}
+	if first.Kind != DumpRecordHeader || json.Unmarshal(first.Data, &res.Header) != nil {
+		return nil, fmt.Errorf("%w: the first record is not a header", ErrDumpFormat)
+	}
+	if v := res.Header.SchemaVersion; v < 1 || v > DumpSchemaVersion {
+		return nil, fmt.Errorf("%w: schema version %d is not supported, this build reads up to %d", ErrDumpFormat, v, DumpSchemaVersion)
+	}
+
+	var kind string
+	var pending []json.RawMessage
+	flush := func() error {
+		defer func() { pending = nil }()
+		if len(pending) == 0 {
+			return nil
+		}
+		switch kind {
+		case DumpRecordProgram:
+			return importRecords(ctx, res, kind, pending, overwrite, validateDumpProgram, dst.importPrograms)
+		case DumpRecordConfig:
+			return importRecords(ctx, res, kind, pending, overwrite, validateDumpConfig, dst.importConfigs)
+		case DumpRecordFavorite:
+			return importRecords(ctx, res, kind, pending, overwrite, validateDumpFavorite, dst.importFavorites)
+		case DumpRecordFollow:
+			return importRecords(ctx, res, kind, pending, overwrite, validateDumpFollow, dst.importFollows)
+		default:
+			return importRecords(ctx, res, kind, pending, overwrite, validateDumpState, dst.importStates)
+		}
+	}
+
+	for n := 2; ; n++ {
+		var rec dumpRecord
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return res, fmt.Errorf("%w: record %d: %v", ErrDumpFormat, n, err)
+		}
+		switch rec.Kind {
+		case DumpRecordProgram, DumpRecordConfig, DumpRecordFavorite, DumpRecordState, DumpRecordFollow:
+		default:
+			return res, fmt.Errorf("%w: record %d has unknown kind %q", ErrDumpFormat, n, rec.Kind)
+		}
+		if rec.Kind != kind || len(pending) == importBatchSize {
+			if err := flush(); err != nil {
+				return res, err
+			}
+			kind = rec.Kind
+		}
+		pending = append(pending, rec.Data)
+	}
+	return res, flush()
+}
+
+// importRecords decodes and checks a batch of records, writes the valid ones with put and
+// counts every record in res.
+func importRecords[T any](
+	ctx context.Context,
+	res *ImportAllResult,
+	kind string,
+	raw []json.RawMessage,
+	overwrite bool,
+	validate func(v *T) (key string, err error),
+	put func(ctx context.Context, batch []T, overwrite bool) ([]importOutcome, error),
+) error {
+	batch := make([]T, 0, len(raw))
+	keys := make([]string, 0, len(raw))
+	for _, data := range raw {
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("%w: %s record: %v", ErrDumpFormat, kind, err)
+		}
+		key, err := validate(&v)
+		if err != nil {
+			res.record(kind, key, ImportStatusFailed, err)
+			continue
+		}
+		batch = append(batch, v)
+		keys = append(keys, key)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	outcomes, err := put(ctx, batch, overwrite)
+	if err != nil {
+		return fmt.Errorf("failed to import %s records: %w", kind, err)
+	}
+	for i, o := range outcomes {
+		res.record(kind, keys[i], o.status, o.err)
+	}
+	return nil
+}
+
+func validateDumpProgram(p *AllowedPrograms) (string, error) {
+	p.ProgramName = NormalizeProgramName(p.ProgramName)
+	if p.ProgramName == "" {
+		return "", invalidf("program name cannot be empty")
+	}
+	return p.ProgramName, nil
+}
+
+// validateDumpConfig checks what a config needs before it is looked at by a backend, which
+// validates the rest with prepareImportedConfig.
+func validateDumpConfig(cfg *HyprConfig) (string, error) {
+	if cfg.ID == "" || cfg.OwnerID == "" {
+		return cfg.ID, invalidf("config id and owner id cannot be empty")
+	}
+	return cfg.ID, nil
+}
+
+func validateDumpFavorite(f *UserFavorite) (string, error) {
+	key := f.UserID + "/" + f.ConfigID
+	if f.UserID == "" || f.ConfigID == "" {
+		return key, invalidf("favorite user id and config id cannot be empty")
+	}
+	return key, nil
+}
+
+func validateDumpState(s *UserHyprState) (string, error) {
+	if s.UserID == "" || s.ConfigID == "" {
+		return s.UserID, invalidf("state user id and config id cannot be empty")
+	}
+	return s.UserID, nil
+}
+
+func validateDumpFollow(f *Follow) (string, error) {
+	key := f.FollowerID + "/" + f.FolloweeID
+	if f.FollowerID == "" || f.FolloweeID == "" {
+		return key, invalidf("follower id and followee id cannot be empty")
+	}
+	if f.FollowerID == f.FolloweeID {
+		return key, invalidf("users cannot follow themselves")
+	}
+	return key, nil
+}
+
+// prepareImportedConfig migrates and validates a config read from a dump the way CreateConfig
+// does, keeping its id, owner, timestamps and likes. Its gallery images must carry their data.
+func prepareImportedConfig(ctx context.Context, cfg *HyprConfig, checkProgramsExist ProgramsChecker, limits SizeLimits) error {
+	if err := migrateConfig(cfg); err != nil {
+		return err
+	}
+	if err := ValidateVersion(cfg.Version); err != nil {
+		return invalidf("config validation failed: %w", err)
+	}
+	if err := cfg.decompressUpload(limits); err != nil {
+		return err
+	}
+	for i := range cfg.ProgramConfigs {
+		if err := cfg.ProgramConfigs[i].resolveFileRefs(nil); err != nil {
+			return invalidf("config validation failed: %w", err)
+		}
+		cfg.ProgramConfigs[i].populateHashes()
+	}
+	for _, img := range cfg.GalleryImages {
+		if len(img.FileContent.Data) == 0 {
+			return invalidf("gallery image %d: %w", img.Index, ErrUnknownFile)
+		}
+	}
+	if err := cfg.Validate(ctx, checkProgramsExist, limits); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+	cfg.Warnings = nil
+	cfg.refreshDerived()
+	return nil
+}
+
+// storeGalleryImages moves the gallery image data of an imported config into files. It returns
+// the ids of the stored files so they can be cleaned up if the write fails.
+func storeGalleryImages(ctx context.Context, files FileStore, cfg *HyprConfig) ([]string, error) {
+	var stored []string
+	for i := range cfg.GalleryImages {
+		img := &cfg.GalleryImages[i]
+		if files == nil {
+			return stored, ErrFileStoreDisabled
+		}
+		id, err := files.Put(ctx, fmt.Sprintf("%s-gallery-%d", cfg.ID, img.Index), img.FileContent.Data)
+		if err != nil {
+			return stored, err
+		}
+		stored = append(stored, id)
+		img.FileContent.FileID = id
+		img.FileContent.Data = nil
+	}
+	return stored, nil
+}
+
+// --- Mongo ---
+
+func (m *ConfigManagerMongo) ExportAll(ctx context.Context, w io.Writer) error {
+	d, err := newDumpWriter(w, "mongo")
+	if err != nil {
+		return err
+	}
+	if m.ProgramsCollection != nil {
+		err := exportCollection(ctx, m.ProgramsCollection, bson.D{{Key: "program_name", Value: 1}}, func(p *AllowedPrograms) error {
+			return d.write(DumpRecordProgram, p)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err = exportCollection(ctx, m.Collection, bson.D{{Key: "_id", Value: 1}}, func(cfg *HyprConfig) error {
+		return d.config(ctx, m.files, cfg)
+	})
+	if err != nil {
+		return err
+	}
+	err = exportCollection(ctx, m.FavoritesCollection, bson.D{{Key: "user_id", Value: 1}, {Key: "config_id", Value: 1}}, func(f *UserFavorite) error {
+		return d.write(DumpRecordFavorite, f)
+	})
+	if err != nil {
+		return err
+	}
+	err = exportCollection(ctx, m.StateCollection, bson.D{{Key: "user_id", Value: 1}}, func(s *UserHyprState) error {
+		return d.write(DumpRecordState, s)
+	})
+	if err != nil {
+		return err
+	}
+	return exportCollection(ctx, m.FollowsCollection, bson.D{{Key: "follower_id", Value: 1}, {Key: "followee_id", Value: 1}}, func(f *Follow) error {
+		return d.write(DumpRecordFollow, f)
+	})
+}
+
+// exportCollection calls fn with every document of coll in sort order.
+func exportCollection[T any](ctx context.Context, coll *mongo.Collection, sort bson.D, fn func(v *T) error) error {
+	cursor, err := coll.Find(ctx, bson.M{}, options.Find().SetSort(sort))
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", coll.Name(), err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var v T
+		if err := cursor.Decode(&v); err != nil {
+			return fmt.Errorf("failed to export %s: %w", coll.Name(), err)
+		}
+		if err := fn(&v); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to export %s: %w", coll.Name(), err)
+	}
+	return nil
+}
+
+func (m *ConfigManagerMongo) ImportAll(ctx context.Context, r io.Reader, opts ImportAllOptions) (*ImportAllResult, error) {
+	return importAll(ctx, r, opts, m)
+}
+
+func (m *ConfigManagerMongo) importPrograms(ctx context.Context, programs []AllowedPrograms, overwrite bool) ([]importOutcome, error) {
+	if m.ProgramsCollection == nil {
+		return nil, ErrAllowlistDisabled
+	}
+	filters := make([]bson.M, len(programs))
+	docs := make([]any, len(programs))
+	names := make([]string, len(programs))
+	for i, p := range programs {
+		filters[i] = bson.M{"program_name": p.ProgramName}
+		docs[i] = p
+		names[i] = p.ProgramName
+	}
+	defer m.registry().Invalidate(names...)
+	return bulkUpsert(ctx, m.ProgramsCollection, filters, docs, overwrite)
+}
+
+func (m *ConfigManagerMongo) importFavorites(ctx context.Context, favorites []UserFavorite, overwrite bool) ([]importOutcome, error) {
+	filters := make([]bson.M, len(favorites))
+	docs := make([]any, len(favorites))
+	for i, f := range favorites {
+		filters[i] = bson.M{"user_id": f.UserID, "config_id": f.ConfigID}
+		docs[i] = f
+	}
+	return bulkUpsert(ctx, m.FavoritesCollection, filters, docs, overwrite)
+}
+
+func (m *ConfigManagerMongo) importStates(ctx context.Context, states []UserHyprState, overwrite bool) ([]importOutcome, error) {
+	filters := make([]bson.M, len(states))
+	docs := make([]any, len(states))
+	for i, s := range states {
+		filters[i] = bson.M{"user_id": s.UserID}
+		docs[i] = s
+	}
+	return bulkUpsert(ctx, m.StateCollection, filters, docs, overwrite)
+}
+
+// importFollows writes follows and counts the new ones in the followers of their followees.
+func (m *ConfigManagerMongo) importFollows(ctx context.Context, follows []Follow, overwrite bool) ([]importOutcome, error) {
+	filters := make([]bson.M, len(follows))
+	docs := make([]any, len(follows))
+	for i, f := range follows {
+		filters[i] = bson.M{"follower_id": f.FollowerID, "followee_id": f.FolloweeID}
+		docs[i] = f
+	}
+	outcomes, err := bulkUpsert(ctx, m.FollowsCollection, filters, docs, overwrite)
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range outcomes {
+		if o.status != ImportStatusInserted {
+			continue
+		}
+		if err := m.addFollowers(ctx, follows[i].FolloweeID, 1); err != nil {
+			outcomes[i] = importFailed(fmt.Errorf("failed to count follower: %w", err))
+		}
+	}
+	return outcomes, nil
+}
+
+// bulkUpsert writes docs matched by filters in one unordered bulk write, replacing the ones
+// that exist when overwrite is set and leaving them alone otherwise.
+func bulkUpsert(ctx context.Context, coll *mongo.Collection, filters []bson.M, docs []any, overwrite bool) ([]importOutcome, error) {
+	models := make([]mongo.WriteModel, len(docs))
+	for i, doc := range docs {
+		if overwrite {
+			models[i] = mongo.NewReplaceOneModel().SetFilter(filters[i]).SetReplacement(doc).SetUpsert(true)
+		} else {
+			models[i] = mongo.NewUpdateOneModel().SetFilter(filters[i]).SetUpdate(bson.M{"$setOnInsert": doc}).SetUpsert(true)
+		}
+	}
+	res, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if err != nil && !errors.As(err, &bulkErr) {
+		return nil, err
+	}
+
+	outcomes := make([]importOutcome, len(docs))
+	for i := range outcomes {
+		if overwrite {
+			outcomes[i] = newOrUpdated(true)
+		} else {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+		}
+	}
+	if res != nil {
+		for i := range res.UpsertedIDs {
+			outcomes[i] = newOrUpdated(false)
+		}
+	}
+	for _, we := range bulkErr.WriteErrors {
+		if mongo.IsDuplicateKeyError(we) && !overwrite {
+			outcomes[we.Index] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		outcomes[we.Index] = importFailed(errors.New(we.Message))
+	}
+	return outcomes, nil
+}
+
+func (m *ConfigManagerMongo) importConfigs(ctx context.Context, configs []HyprConfig, overwrite bool) ([]importOutcome, error) {
+	ids := make([]string, len(configs))
+	for i := range configs {
+		ids[i] = configs[i].ID
+	}
+	cursor, err := m.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"owner_id": 1, "program_configs": 1, "gallery_images": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []HyprConfig
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*HyprConfig, len(found))
+	for i := range found {
+		existing[found[i].ID] = &found[i]
+	}
+
+	outcomes := make([]importOutcome, len(configs))
+	var models []mongo.WriteModel
+	var modelIndex []int
+	uploaded := map[int][]string{}
+	for i := range configs {
+		cfg := &configs[i]
+		prev, exists := existing[cfg.ID]
+		if exists && !overwrite {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		if err := prepareImportedConfig(ctx, cfg, m.registry().Allowed, m.limits); err != nil {
+			outcomes[i] = importFailed(err)
+			continue
+		}
+		// Make sure the owners have a usage record measured before the write
+		for _, owner := range []string{cfg.OwnerID, ownerOf(prev)} {
+			if owner == "" {
+				continue
+			}
+			if _, err := m.quotaUsage(ctx, owner); err != nil {
+				return nil, err
+			}
+		}
+		files, err := m.storeImportedFiles(ctx, cfg)
+		uploaded[i] = files
+		if err != nil {
+			outcomes[i] = importFailed(err)
+			continue
+		}
+		if exists {
+			models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{"_id": cfg.ID}).SetReplacement(cfg))
+		} else {
+			models = append(models, mongo.NewInsertOneModel().SetDocument(cfg))
+		}
+		modelIndex = append(modelIndex, i)
+		outcomes[i] = newOrUpdated(exists)
+	}
+	if len(models) > 0 {
+		_, err = m.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		var bulkErr mongo.BulkWriteException
+		if err != nil && !errors.As(err, &bulkErr) {
+			for _, i := range modelIndex {
+				m.deleteFiles(ctx, uploaded[i])
+			}
+			return nil, err
+		}
+		for _, we := range bulkErr.WriteErrors {
+			i := modelIndex[we.Index]
+			if mongo.IsDuplicateKeyError(we) && !overwrite {
+				outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			} else {
+				outcomes[i] = importFailed(errors.New(we.Message))
+			}
+		}
+	}
+
+	for i := range configs {
+		switch outcomes[i].status {
+		case ImportStatusInserted, ImportStatusUpdated:
+			cfg := &configs[i]
+			m.addUsage(ctx, cfg.OwnerID, cfg.contentSize())
+			if prev := existing[cfg.ID]; prev != nil {
+				m.addUsage(ctx, prev.OwnerID, -prev.contentSize())
+				m.deleteOrphanedFiles(ctx, storedFiles(prev.ProgramConfigs), cfg.ProgramConfigs)
+				m.deleteFiles(ctx, galleryFileIDs(prev))
+			}
+		default:
+			m.deleteFiles(ctx, uploaded[i])
+		}
+	}
+	return outcomes, nil
+}
+
+// storeImportedFiles offloads and compresses an imported config's content for storage and stores
+// its gallery images, returning the ids of the files it stored.
+func (m *ConfigManagerMongo) storeImportedFiles(ctx context.Context, cfg *HyprConfig) ([]string, error) {
+	stored, err := storeGalleryImages(ctx, m.files, cfg)
+	if err != nil {
+		return stored, err
+	}
+	for i := range cfg.ProgramConfigs {
+		ids, err := m.offloadFiles(ctx, &cfg.ProgramConfigs[i])
+		stored = append(stored, ids...)
+		if err != nil {
+			return stored, err
+		}
+	}
+	return stored, cfg.compressContent()
+}
+
+// ownerOf returns the owner of cfg, which may be nil.
+func ownerOf(cfg *HyprConfig) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.OwnerID
+}
+
+// --- Memory ---
+
+func (m *ConfigManagerMemory) ExportAll(ctx context.Context, w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	d, err := newDumpWriter(w, "memory")
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(m.programs) {
+		if err := d.write(DumpRecordProgram, m.programs[name]); err != nil {
+			return err
+		}
+	}
+	for _, id := range sortedKeys(m.configs) {
+		cfg, err := cloneConfig(m.configs[id])
+		if err != nil {
+			return err
+		}
+		if err := d.config(ctx, m.files, cfg); err != nil {
+			return err
+		}
+	}
+	for _, userID := range sortedKeys(m.favorites) {
+		for _, configID := range sortedKeys(m.favorites[userID]) {
+			f := UserFavorite{UserID: userID, ConfigID: configID, FavoritedAt: m.favorites[userID][configID]}
+			if err := d.write(DumpRecordFavorite, f); err != nil {
+				return err
+			}
+		}
+	}
+	for _, userID := range sortedKeys(m.state) {
+		if err := d.write(DumpRecordState, m.state[userID]); err != nil {
+			return err
+		}
+	}
+	for _, followerID := range sortedKeys(m.follows) {
+		for _, followeeID := range sortedKeys(m.follows[followerID]) {
+			f := Follow{FollowerID: followerID, FolloweeID: followeeID, FollowedAt: m.follows[followerID][followeeID]}
+			if err := d.write(DumpRecordFollow, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map in order, for reproducible dumps.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (m *ConfigManagerMemory) ImportAll(ctx context.Context, r io.Reader, opts ImportAllOptions) (*ImportAllResult, error) {
+	return importAll(ctx, r, opts, m)
+}
+
+func (m *ConfigManagerMemory) importPrograms(ctx context.Context, programs []AllowedPrograms, overwrite bool) ([]importOutcome, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]importOutcome, len(programs))
+	for i, p := range programs {
+		_, exists := m.programs[p.ProgramName]
+		if exists && !overwrite {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		m.programs[p.ProgramName] = p
+		outcomes[i] = newOrUpdated(exists)
+	}
+	return outcomes, nil
+}
+
+func (m *ConfigManagerMemory) importConfigs(ctx context.Context, configs []HyprConfig, overwrite bool) ([]importOutcome, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]importOutcome, len(configs))
+	for i := range configs {
+		cfg := &configs[i]
+		prev, exists := m.configs[cfg.ID]
+		if exists && !overwrite {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		if err := prepareImportedConfig(ctx, cfg, m.registry().Allowed, m.limits); err != nil {
+			outcomes[i] = importFailed(err)
+			continue
+		}
+		if _, err := storeGalleryImages(ctx, m.files, cfg); err != nil {
+			outcomes[i] = importFailed(err)
+			continue
+		}
+		stored, err := cloneConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			m.addUsage(prev.OwnerID, -prev.contentSize())
+			for _, id := range galleryFileIDs(prev) {
+				_ = m.files.Delete(ctx, id)
+			}
+		}
+		m.addUsage(stored.OwnerID, stored.contentSize())
+		m.configs[stored.ID] = stored
+		outcomes[i] = newOrUpdated(exists)
+	}
+	return outcomes, nil
+}
+
+func (m *ConfigManagerMemory) importFavorites(ctx context.Context, favorites []UserFavorite, overwrite bool) ([]importOutcome, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]importOutcome, len(favorites))
+	for i, f := range favorites {
+		_, exists := m.favorites[f.UserID][f.ConfigID]
+		if exists && !overwrite {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		if m.favorites[f.UserID] == nil {
+			m.favorites[f.UserID] = map[string]time.Time{}
+		}
+		m.favorites[f.UserID][f.ConfigID] = f.FavoritedAt
+		outcomes[i] = newOrUpdated(exists)
+	}
+	return outcomes, nil
+}
+
+func (m *ConfigManagerMemory) importStates(ctx context.Context, states []UserHyprState, overwrite bool) ([]importOutcome, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]importOutcome, len(states))
+	for i, s := range states {
+		_, exists := m.state[s.UserID]
+		if exists && !overwrite {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		m.state[s.UserID] = s
+		outcomes[i] = newOrUpdated(exists)
+	}
+	return outcomes, nil
+}
+
+func (m *ConfigManagerMemory) importFollows(ctx context.Context, follows []Follow, overwrite bool) ([]importOutcome, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]importOutcome, len(follows))
+	for i, f := range follows {
+		_, exists := m.follows[f.FollowerID][f.FolloweeID]
+		if exists && !overwrite {
+			outcomes[i] = importOutcome{status: ImportStatusSkipped}
+			continue
+		}
+		if m.follows[f.FollowerID] == nil {
+			m.follows[f.FollowerID] = map[string]time.Time{}
+		}
+		m.follows[f.FollowerID][f.FolloweeID] = f.FollowedAt
+		if !exists {
+			m.followers[f.FolloweeID]++
+		}
+		outcomes[i] = newOrUpdated(exists)
+	}
+	return outcomes, nil
+}
+
+// --- SQLite ---
+
+func (m *ConfigManagerSQLite) ExportAll(ctx context.Context, w io.Writer) error {
+	d, err := newDumpWriter(w, "sqlite")
+	if err != nil {
+		return err
+	}
+	programs, err := m.queryAllowedPrograms(ctx, "1 = 1", nil)
+	if err != nil {
+		return err
+	}
+	for _, p := range programs {
+		if err := d.write(DumpRecordProgram, p); err != nil {
+			return err
+		}
+	}
+
+	// Configs are loaded one at a time after their ids, as reading their files needs the single
+	// connection an open result set would hold
+	var ids []string
+	err = exportRows(ctx, m.db, `SELECT id FROM configs ORDER BY id`, func(rows *sql.Rows) error {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		cfg, err := m.getConfig(ctx, m.db, id)
+		if err != nil {
+			return err
+		}
+		if err := d.config(ctx, sqliteFileStore{m.db}, cfg); err != nil {
+			return err
+		}
+	}
+	err = exportRows(ctx, m.db, `SELECT user_id, config_id, favorited_at FROM favorites ORDER BY user_id, config_id`, func(rows *sql.Rows) error {
+		var f UserFavorite
+		var at int64
+		if err := rows.Scan(&f.UserID, &f.ConfigID, &at); err != nil {
+			return err
+		}
+		f.FavoritedAt = time.Unix(0, at)
+		return d.write(DumpRecordFavorite, f)
+	})
+	if err != nil {
+		return err
+	}
+	err = exportRows(ctx, m.db, `SELECT user_id, config_id, applied_at FROM user_state ORDER BY user_id`, func(rows *sql.Rows) error {
+		var s UserHyprState
+		var at int64
+		if err := rows.Scan(&s.UserID, &s.ConfigID, &at); err != nil {
+			return err
+		}
+		s.AppliedAt = time.Unix(0, at)
+		return d.write(DumpRecordState, s)
+	})
+	if err != nil {
+		return err
+	}
+	return exportRows(ctx, m.db, `SELECT follower_id, followee_id, followed_at FROM follows ORDER BY follower_id, followee_id`, func(rows *sql.Rows) error {
+		var f Follow
+		var at int64
+		if err := rows.Scan(&f.FollowerID, &f.FolloweeID, &at); err != nil {
+			return err
+		}
+		f.FollowedAt = time.Unix(0, at)
+		return d.write(DumpRecordFollow, f)
+	})
+}
+
+// exportRows calls fn for every row of query. fn must not query the database itself.
+func exportRows(ctx context.Context, q sqlQuerier, query string, fn func(rows *sql.Rows) error) error {
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to export: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (m *ConfigManagerSQLite) ImportAll(ctx context.Context, r io.Reader, opts ImportAllOptions) (*ImportAllResult, error) {
+	return importAll(ctx, r, opts, m)
+}
+
+// rowExists reports whether query, a SELECT 1, returns a row.
+func rowExists(ctx context.Context, q sqlQuerier, query string, args ...any) (bool, error) {
+	var one int
+	err := q.QueryRowContext(ctx, query, args...).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// importRows writes one batch of records in a transaction. exists and put are called per record.
+func importRows[T any](
+	ctx context.Context,
+	m *ConfigManagerSQLite,
+	batch []T,
+	overwrite bool,
+	exists func(tx *sql.Tx, v *T) (bool, error),
+	put func(tx *sql.Tx, v *T) error,
+) ([]importOutcome, error) {
+	outcomes := make([]importOutcome, len(batch))
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		for i := range batch {
+			found, err := exists(tx, &batch[i])
+			if err != nil {
+				return err
+			}
+			if found && !overwrite {
+				outcomes[i] = importOutcome{status: ImportStatusSkipped}
+				continue
+			}
+			if err := put(tx, &batch[i]); err != nil {
+				return err
+			}
+			outcomes[i] = newOrUpdated(found)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
+
+func (m *ConfigManagerSQLite) importPrograms(ctx context.Context, programs []AllowedPrograms, overwrite bool) ([]importOutcome, error) {
+	return importRows(ctx, m, programs, overwrite,
+		func(tx *sql.Tx, p *AllowedPrograms) (bool, error) {
+			return rowExists(ctx, tx, `SELECT 1 FROM allowed_programs WHERE program_name = ?`, p.ProgramName)
+		},
+		func(tx *sql.Tx, p *AllowedPrograms) error {
+			return putAllowedProgram(ctx, tx, *p)
+		})
+}
+
+func (m *ConfigManagerSQLite) importFavorites(ctx context.Context, favorites []UserFavorite, overwrite bool) ([]importOutcome, error) {
+	return importRows(ctx, m, favorites, overwrite,
+		func(tx *sql.Tx, f *UserFavorite) (bool, error) {
+			return rowExists(ctx, tx, `SELECT 1 FROM favorites WHERE user_id = ? AND config_id = ?`, f.UserID, f.ConfigID)
+		},
+		func(tx *sql.Tx, f *UserFavorite) error {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO favorites (user_id, config_id, favorited_at) VALUES (?, ?, ?)
+				ON CONFLICT(user_id, config_id) DO UPDATE SET favorited_at = excluded.favorited_at`,
+				f.UserID, f.ConfigID, f.FavoritedAt.UnixNano())
+			return err
+		})
+}
+
+func (m *ConfigManagerSQLite) importStates(ctx context.Context, states []UserHyprState, overwrite bool) ([]importOutcome, error) {
+	return importRows(ctx, m, states, overwrite,
+		func(tx *sql.Tx, s *UserHyprState) (bool, error) {
+			return rowExists(ctx, tx, `SELECT 1 FROM user_state WHERE user_id = ?`, s.UserID)
+		},
+		func(tx *sql.Tx, s *UserHyprState) error {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO user_state (user_id, config_id, applied_at) VALUES (?, ?, ?)
+				ON CONFLICT(user_id) DO UPDATE SET config_id = excluded.config_id, applied_at = excluded.applied_at`,
+				s.UserID, s.ConfigID, s.AppliedAt.UnixNano())
+			return err
+		})
+}
+
+func (m *ConfigManagerSQLite) importFollows(ctx context.Context, follows []Follow, overwrite bool) ([]importOutcome, error) {
+	return importRows(ctx, m, follows, overwrite,
+		func(tx *sql.Tx, f *Follow) (bool, error) {
+			return rowExists(ctx, tx, `SELECT 1 FROM follows WHERE follower_id = ? AND followee_id = ?`, f.FollowerID, f.FolloweeID)
+		},
+		func(tx *sql.Tx, f *Follow) error {
+			res, err := tx.ExecContext(ctx,
+				`INSERT OR IGNORE INTO follows (follower_id, followee_id, followed_at) VALUES (?, ?, ?)`,
+				f.FollowerID, f.FolloweeID, f.FollowedAt.UnixNano())
+			if err != nil {
+				return err
+			}
+			if n, _ := res.RowsAffected(); n == 1 {
+				return addFollowers(ctx, tx, f.FolloweeID, 1)
+			}
+			_, err = tx.ExecContext(ctx, `UPDATE follows SET followed_at = ? WHERE follower_id = ? AND followee_id = ?`,
+				f.FollowedAt.UnixNano(), f.FollowerID, f.FolloweeID)
+			return err
+		})
+}
+
+func (m *ConfigManagerSQLite) importConfigs(ctx context.Context, configs []HyprConfig, overwrite bool) ([]importOutcome, error) {
+	outcomes := make([]importOutcome, len(configs))
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		for i := range configs {
+			cfg := &configs[i]
+			prev, err := m.getConfig(ctx, tx, cfg.ID)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			exists := prev != nil
+			if exists && !overwrite {
+				outcomes[i] = importOutcome{status: ImportStatusSkipped}
+				continue
+			}
+			if err := prepareImportedConfig(ctx, cfg, m.registry(tx).Allowed, m.limits); err != nil {
+				outcomes[i] = importFailed(err)
+				continue
+			}
+			// Make sure the owners have a usage record measured before the write
+			for _, owner := range []string{cfg.OwnerID, ownerOf(prev)} {
+				if owner == "" {
+					continue
+				}
+				if _, err := m.quotaUsage(ctx, tx, owner); err != nil {
+					return err
+				}
+			}
+			if _, err := storeGalleryImages(ctx, sqliteFileStore{tx}, cfg); err != nil {
+				return err
+			}
+			if err := m.putConfig(ctx, tx, cfg); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE configs SET likes = ? WHERE id = ?`, cfg.Likes, cfg.ID); err != nil {
+				return err
+			}
+			if exists {
+				if err := addQuotaUsage(ctx, tx, prev.OwnerID, -prev.contentSize()); err != nil {
+					return err
+				}
+				for _, fileID := range galleryFileIDs(prev) {
+					if err := (sqliteFileStore{tx}).Delete(ctx, fileID); err != nil {
+						return err
+					}
+				}
+			}
+			if err := addQuotaUsage(ctx, tx, cfg.OwnerID, cfg.contentSize()); err != nil {
+				return err
+			}
+			outcomes[i] = newOrUpdated(exists)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,171 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newDumpSource fills an in-memory manager with one of everything a dump carries.
+func newDumpSource(t *testing.T) (ConfigManager, *HyprConfig) {
+	t.Helper()
+	m := NewInMemoryConfigManager()
+	alice := asUser("alice")
+	if _, err := m.AddAllowedProgram(asUser("root", AdminRole), "my-tool"); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := m.CreateConfig(alice, &HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{
+		{ID: "term", Title: "term", Program: "kitty", FileContent: FileContent{Data: []byte("font_size 12\n"), FileType: FileTypeConfig}},
+		{ID: "tool", Title: "tool", Program: "my-tool"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AddGalleryImage(alice, cfg.ID, testPNG); err != nil {
+		t.Fatal(err)
+	}
+	newTestConfig(t, m, "bob", true)
+	if err := m.FavoriteConfig(asUser("bob"), cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ApplyConfig(asUser("bob"), cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.FollowAuthor(asUser("bob"), "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = m.GetConfig(alice, cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	return m, cfg
+}
+
+func exportAll(t *testing.T, m ConfigManager) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := m.(DatabaseDumper).ExportAll(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestManagerExportImportRoundTrip(t *testing.T) {
+	src, cfg := newDumpSource(t)
+	dump := exportAll(t, src)
+
+	forEachManager(t, func(t *testing.T, dst ConfigManager) {
+		ctx := context.Background()
+		res, err := dst.(DatabaseDumper).ImportAll(ctx, bytes.NewReader(dump), ImportAllOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]map[string]int{
+			DumpRecordProgram:  {ImportStatusInserted: 1},
+			DumpRecordConfig:   {ImportStatusInserted: 2},
+			DumpRecordFavorite: {ImportStatusInserted: 1},
+			DumpRecordState:    {ImportStatusInserted: 1},
+			DumpRecordFollow:   {ImportStatusInserted: 1},
+		}
+		if !reflect.DeepEqual(res.Counts, want) || len(res.Failures) != 0 || res.Header.SchemaVersion != DumpSchemaVersion {
+			t.Fatalf("import = %+v, want %v", res, want)
+		}
+
+		got, err := dst.GetConfig(asUser("alice"), cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.OwnerID != "alice" || got.Likes != 1 || !got.CreatedTimestamp.Equal(cfg.CreatedTimestamp) || got.Fingerprint != cfg.Fingerprint {
+			t.Errorf("imported config = %+v, want the exported one", got)
+		}
+		if !bytes.Equal(got.ProgramConfigs[0].FileContent.Data, cfg.ProgramConfigs[0].FileContent.Data) {
+			t.Errorf("file content = %q", got.ProgramConfigs[0].FileContent.Data)
+		}
+		rc, _, err := dst.GetGalleryImage(ctx, cfg.ID, cfg.GalleryImages[0].Index)
+		if err != nil {
+			t.Fatalf("gallery image: %v", err)
+		}
+		image, _ := io.ReadAll(rc)
+		rc.Close()
+		if !bytes.Equal(image, testPNG) {
+			t.Error("gallery image data changed")
+		}
+
+		favorites, err := dst.ListFavorites(asUser("bob"), 1, 10)
+		if err != nil || len(favorites.Items) != 1 || favorites.Items[0].ID != cfg.ID {
+			t.Errorf("bob's favorites = %+v, %v", favorites.Items, err)
+		}
+		if applied, err := dst.GetAppliedConfig(asUser("bob")); err != nil || applied.ID != cfg.ID {
+			t.Errorf("bob's applied config = %v, %v", applied, err)
+		}
+		usage, err := dst.GetQuotaUsage(asUser("alice"))
+		if want, _ := src.GetQuotaUsage(asUser("alice")); err != nil || usage.UsedBytes != want.UsedBytes {
+			t.Errorf("alice's usage = %+v, %v, want %+v", usage, err, want)
+		}
+
+		// The dump of the copy is the same dump, up to the header
+		lines := func(dump []byte) []string { return strings.Split(string(dump), "\n")[1:] }
+		if again := exportAll(t, dst); !reflect.DeepEqual(lines(again), lines(dump)) {
+			t.Errorf("re-export differs:\n%s\nwant\n%s", again, dump)
+		}
+
+		res, err = dst.(DatabaseDumper).ImportAll(ctx, bytes.NewReader(dump), ImportAllOptions{OnConflict: ImportConflictSkip})
+		if err != nil || res.Counts[DumpRecordConfig][ImportStatusSkipped] != 2 {
+			t.Errorf("import again, skipping: %+v, %v", res, err)
+		}
+		res, err = dst.(DatabaseDumper).ImportAll(ctx, bytes.NewReader(dump), ImportAllOptions{OnConflict: ImportConflictOverwrite})
+		if err != nil || res.Counts[DumpRecordConfig][ImportStatusUpdated] != 2 || res.Counts[DumpRecordState][ImportStatusUpdated] != 1 {
+			t.Errorf("import again, overwriting: %+v, %v", res, err)
+		}
+		if usage, _ := dst.GetQuotaUsage(asUser("alice")); usage.UsedBytes != got.contentSize() {
+			t.Errorf("alice's usage after overwriting = %d, want %d", usage.UsedBytes, got.contentSize())
+		}
+		if stats, err := dst.GetAuthorStats(ctx, "alice"); err != nil || stats.Followers != 1 {
+			t.Errorf("alice's followers after importing twice = %+v, %v, want 1", stats, err)
+		}
+	})
+}
+
+func TestImportAllRejectsBadDumps(t *testing.T) {
+	m := NewInMemoryConfigManager().(DatabaseDumper)
+	ctx := context.Background()
+	for name, dump := range map[string]string{
+		"empty":          "",
+		"no header":      `{"kind":"state","data":{"user_id":"bob","config_id":"c1"}}`,
+		"newer version":  `{"kind":"header","data":{"schema_version":99}}`,
+		"unknown kind":   `{"kind":"header","data":{"schema_version":1}}` + "\n" + `{"kind":"user","data":{}}`,
+		"truncated line": `{"kind":"header","data":{"schema_version":1}}` + "\n" + `{"kind":"state","data":`,
+	} {
+		if _, err := m.ImportAll(ctx, strings.NewReader(dump), ImportAllOptions{}); !errors.Is(err, ErrDumpFormat) {
+			t.Errorf("%s: got %v, want ErrDumpFormat", name, err)
+		}
+	}
+	if _, err := m.ImportAll(ctx, strings.NewReader(""), ImportAllOptions{OnConflict: "merge"}); !errors.Is(err, ErrValidation) {
+		t.Errorf("unknown conflict policy: got %v, want ErrValidation", err)
+	}
+
+	// Invalid records are reported and the rest is imported
+	dump := strings.Join([]string{
+		`{"kind":"header","data":{"schema_version":1}}`,
+		`{"kind":"config","data":{"id":"c1","owner_id":"alice","title":"rice","program_configs":[{"title":"x","program":"not-allowed"}]}}`,
+		`{"kind":"config","data":{"id":"c2","owner_id":"","title":"rice"}}`,
+		`{"kind":"state","data":{"user_id":"bob","config_id":"c1"}}`,
+	}, "\n")
+	res, err := m.ImportAll(ctx, strings.NewReader(dump), ImportAllOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Counts[DumpRecordConfig][ImportStatusFailed] != 2 || res.Counts[DumpRecordState][ImportStatusInserted] != 1 {
+		t.Errorf("counts = %v", res.Counts)
+	}
+	failed := map[string]string{}
+	for _, f := range res.Failures {
+		failed[f.Key] = f.Error
+	}
+	if len(failed) != 2 || !strings.Contains(failed["c1"], "not-allowed") || failed["c2"] == "" {
+		t.Errorf("failures = %+v", res.Failures)
+	}
+}
@@ -0,0 +1,173 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDuplicateConfig = errors.New("duplicate config")
+
+// DuplicateConfigError is returned by CreateConfig when the caller already owns a config with
+// the same fingerprint. It matches ErrDuplicateConfig.
+type DuplicateConfigError struct {
+	ExistingID string
+}
+
+func (e *DuplicateConfigError) Error() string {
+	return fmt.Sprintf("%s: same programs and files as config %s", ErrDuplicateConfig, e.ExistingID)
+}
+
+func (e *DuplicateConfigError) Is(target error) bool {
+	return target == ErrDuplicateConfig
+}
+
+type allowDuplicateKey struct{}
+
+// WithAllowDuplicate returns a context in which CreateConfig stores configs even when the
+// caller already owns one with the same content.
+func WithAllowDuplicate(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allowDuplicateKey{}, true)
+}
+
+func allowsDuplicate(ctx context.Context) bool {
+	v, _ := ctx.Value(allowDuplicateKey{}).(bool)
+	return v
+}
+
+// fingerprint identifies the content of a config by the sorted program names and file hashes
+// of every program config, wherever it sits in the tree, and the target paths and hashes of
+// their Files. Configs without any file content have no fingerprint, so they are never
+// duplicates of each other.
+func (hc *HyprConfig) fingerprint() string {
+	var entries []string
+	hasContent := false
+	hc.Walk(func(pc *HyprProgramConfig) {
+		entries = append(entries, NormalizeProgramName(pc.Program)+"\x00"+pc.FileContent.Hash)
+		hasContent = hasContent || pc.FileContent.Hash != ""
+		for _, e := range pc.Files {
+			entries = append(entries, NormalizeProgramName(pc.Program)+"\x00"+e.TargetPath+"\x00"+e.FileContent.Hash)
+			hasContent = hasContent || e.FileContent.Hash != ""
+		}
+	})
+	if !hasContent {
+		return ""
+	}
+	sort.Strings(entries)
+	return ComputeHash([]byte(strings.Join(entries, "\n")))
+}
+
+// checkDuplicate returns a DuplicateConfigError when the owner of cfg already has a config
+// with its fingerprint, unless ctx allows duplicates.
+func (m *ConfigManagerMongo) checkDuplicate(ctx context.Context, cfg *HyprConfig) error {
+	if cfg.Fingerprint == "" || allowsDuplicate(ctx) {
+		return nil
+	}
+
+	var existing HyprConfig
+	err := m.Collection.FindOne(ctx,
+		bson.M{"owner_id": cfg.OwnerID, "fingerprint": cfg.Fingerprint},
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to look for duplicate configs: %w", err)
+	}
+	return &DuplicateConfigError{ExistingID: existing.ID}
+}
+
+// ListSimilarConfigs returns the public configs, most liked first, that have the same
+// fingerprint as a config the caller can read.
+func (m *ConfigManagerMongo) ListSimilarConfigs(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[HyprConfig], error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	result, err := mserve.PaginateMongo[HyprConfig](
+		ctx,
+		m.Collection,
+		bson.M{"fingerprint": cfg.fingerprint(), "private": false, "_id": bson.M{"$ne": configID}},
+		page,
+		limit,
+		listFindOptions(ctx, options.Find().SetSort(mongoSearchSort[SearchSortLikes])),
+	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	return decodeListForRead(ctx, result)
+}
+
+// checkDuplicate is ConfigManagerMongo.checkDuplicate. Callers must hold m.mu.
+func (m *ConfigManagerMemory) checkDuplicate(ctx context.Context, cfg *HyprConfig) error {
+	if cfg.Fingerprint == "" || allowsDuplicate(ctx) {
+		return nil
+	}
+	for _, existing := range m.configs {
+		if existing.OwnerID == cfg.OwnerID && existing.Fingerprint == cfg.Fingerprint {
+			return &DuplicateConfigError{ExistingID: existing.ID}
+		}
+	}
+	return nil
+}
+
+func (m *ConfigManagerMemory) ListSimilarConfigs(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[HyprConfig], error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	fingerprint := cfg.fingerprint()
+	return m.pageSorted(ctx, page, limit, SearchSortLikes, func(c *HyprConfig) bool {
+		return !c.Private && c.ID != configID && fingerprint != "" && c.Fingerprint == fingerprint
+	})
+}
+
+// checkDuplicate is ConfigManagerMongo.checkDuplicate, run in tx.
+func (m *ConfigManagerSQLite) checkDuplicate(ctx context.Context, tx *sql.Tx, cfg *HyprConfig) error {
+	if cfg.Fingerprint == "" || allowsDuplicate(ctx) {
+		return nil
+	}
+
+	var existingID string
+	err := tx.QueryRowContext(ctx,
+		`SELECT id FROM configs WHERE owner_id = ? AND json_extract(doc, '$.fingerprint') = ? LIMIT 1`,
+		cfg.OwnerID, cfg.Fingerprint).Scan(&existingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to look for duplicate configs: %w", err)
+	}
+	return &DuplicateConfigError{ExistingID: existingID}
+}
+
+func (m *ConfigManagerSQLite) ListSimilarConfigs(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[HyprConfig], error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	return m.listConfigsSorted(ctx,
+		`private = 0 AND id != ? AND json_extract(doc, '$.fingerprint') = ?`,
+		[]any{configID, cfg.fingerprint()},
+		page, limit, SearchSortLikes)
+}
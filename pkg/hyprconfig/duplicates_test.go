@@ -0,0 +1,83 @@
+package hyprconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestManagerDuplicateConfigs(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		rice := func(title, style string, private bool) *HyprConfig {
+			return &HyprConfig{Title: title, Private: private, ProgramConfigs: []HyprProgramConfig{
+				{Title: "term", Program: "kitty", FileContent: FileContent{Data: []byte("font_size 11\n"), FileType: FileTypeConfig}},
+				{Title: "bar", Program: "waybar", FileContent: FileContent{Data: []byte(style), FileType: FileTypeConfig}},
+			}}
+		}
+
+		original, err := m.CreateConfig(alice, rice("rice", "#clock {}\n", false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if original.Fingerprint == "" {
+			t.Fatal("config with file content has no fingerprint")
+		}
+
+		// Same programs and files in another order is still a duplicate.
+		again := rice("rice again", "#clock {}\n", true)
+		again.ProgramConfigs[0], again.ProgramConfigs[1] = again.ProgramConfigs[1], again.ProgramConfigs[0]
+		_, err = m.CreateConfig(alice, again)
+		var dup *DuplicateConfigError
+		if !errors.As(err, &dup) || !errors.Is(err, ErrDuplicateConfig) || dup.ExistingID != original.ID {
+			t.Fatalf("exact duplicate: got %v, want DuplicateConfigError for %s", err, original.ID)
+		}
+
+		forced, err := m.CreateConfig(WithAllowDuplicate(alice), rice("forced", "#clock {}\n", true))
+		if err != nil {
+			t.Fatalf("forced duplicate: %v", err)
+		}
+		if forced.Fingerprint != original.Fingerprint {
+			t.Errorf("forced copy fingerprint = %q, want %q", forced.Fingerprint, original.Fingerprint)
+		}
+		copied, err := m.CreateConfig(asUser("bob"), rice("bob's copy", "#clock {}\n", false))
+		if err != nil {
+			t.Fatalf("same content by another owner: %v", err)
+		}
+		if _, err := m.CreateConfig(alice, rice("changed", "#clock { color: red; }\n", false)); err != nil {
+			t.Fatalf("different content: %v", err)
+		}
+
+		// Configs without file content have nothing to compare.
+		newTestConfig(t, m, "alice", false)
+		newTestConfig(t, m, "alice", false)
+
+		similar, err := m.ListSimilarConfigs(alice, original.ID, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(similar.Items) != 1 || similar.Items[0].ID != copied.ID {
+			t.Errorf("similar configs = %+v, want only bob's public copy %s", similar.Items, copied.ID)
+		}
+		if _, err := m.ListSimilarConfigs(asUser("bob"), forced.ID, 1, 10); !errors.Is(err, ErrForbidden) {
+			t.Errorf("similar to another user's private config: got %v, want ErrForbidden", err)
+		}
+
+		// Changing a file changes the fingerprint, so the copy is no longer similar.
+		err = m.UpdateProgramConfig(alice, original.ID, original.ProgramConfigs[1].ID, HyprProgramConfig{
+			Title: "bar", Program: "waybar", FileContent: FileContent{Data: []byte("#clock { color: blue; }\n"), FileType: FileTypeConfig},
+		}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated, err := m.GetConfig(alice, original.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated.Fingerprint == original.Fingerprint {
+			t.Error("fingerprint did not change with the file content")
+		}
+		if similar, err = m.ListSimilarConfigs(alice, original.ID, 1, 10); err != nil || len(similar.Items) != 0 {
+			t.Errorf("similar configs after update = %+v, %v, want none", similar.Items, err)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	MaxEnvVars        = 64
+	MaxEnvNameLength  = 128
+	MaxEnvValueLength = 4096
+)
+
+var ErrInvalidEnvVar = errors.New("invalid environment variable")
+
+var (
+	// envNameRe is the POSIX portable environment variable name.
+	envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// pathListRe is what path-like variables such as PATH may contain.
+	pathListRe = regexp.MustCompile(`^[A-Za-z0-9_.:/~$+-]*$`)
+
+	secretNameRe  = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSW(OR)?D|API_?KEY|PRIVATE_?KEY|CREDENTIAL)`)
+	secretValueRe = regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|sk-[A-Za-z0-9-]{20,}|AKIA[0-9A-Z]{16}|xox[abpr]-[A-Za-z0-9-]{10,})$|-----BEGIN `)
+)
+
+// forbiddenEnvVars can change what code the user's session loads and are never accepted from a config.
+var forbiddenEnvVars = map[string]struct{}{
+	"LD_PRELOAD":      {},
+	"LD_AUDIT":        {},
+	"LD_LIBRARY_PATH": {},
+}
+
+// pathEnvVars must hold a plain colon separated list of paths.
+var pathEnvVars = map[string]struct{}{
+	"PATH":            {},
+	"XDG_DATA_DIRS":   {},
+	"XDG_CONFIG_DIRS": {},
+}
+
+// validateEnvVars checks names against POSIX rules and values against injection patterns,
+// recording each bad variable in verr.
+func (pc *HyprProgramConfig) validateEnvVars(verr *ValidationError) {
+	if len(pc.EnvVars) > MaxEnvVars {
+		verr.add("env_vars", fmt.Errorf("program config %q: %w: %d variables, at most %d allowed", pc.Title, ErrInvalidEnvVar, len(pc.EnvVars), MaxEnvVars))
+		return
+	}
+
+	for _, key := range sortedEnvKeys(pc.EnvVars) {
+		if reason := envVarProblem(key, pc.EnvVars[key]); reason != "" {
+			verr.add("env_vars."+key, fmt.Errorf("program config %q: %w %s: %s", pc.Title, ErrInvalidEnvVar, key, reason))
+		}
+	}
+}
+
+func envVarProblem(key, value string) string {
+	switch {
+	case len(key) > MaxEnvNameLength:
+		return fmt.Sprintf("name is longer than %d characters", MaxEnvNameLength)
+	case !envNameRe.MatchString(key):
+		return "name must start with a letter or underscore and contain only letters, digits and underscores"
+	case len(value) > MaxEnvValueLength:
+		return fmt.Sprintf("value is longer than %d characters", MaxEnvValueLength)
+	case strings.ContainsAny(value, "\n\r\x00"):
+		return "value must not contain newlines"
+	case strings.Contains(value, "$(") || strings.Contains(value, "`"):
+		return "value must not contain command substitution"
+	}
+
+	if _, ok := forbiddenEnvVars[key]; ok {
+		return "variable cannot be set by a config"
+	}
+	if _, ok := pathEnvVars[key]; ok && !pathListRe.MatchString(value) {
+		return "value must be a colon separated list of paths"
+	}
+	return ""
+}
+
+// envVarWarnings returns a warning for each variable that looks like it holds a secret.
+func (pc *HyprProgramConfig) envVarWarnings() []string {
+	var warnings []string
+	for _, key := range sortedEnvKeys(pc.EnvVars) {
+		if secretNameRe.MatchString(key) || secretValueRe.MatchString(pc.EnvVars[key]) {
+			warnings = append(warnings, fmt.Sprintf("program config %q: env var %s looks like a secret and will be visible to anyone who can see this config", pc.Title, key))
+		}
+	}
+	return warnings
+}
+
+func sortedEnvKeys(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,197 @@
+package hyprconfig
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ExecWrapper is a program that launches the command in its arguments, like uwsm in
+// exec-once = uwsm app -- waybar. ExtractExecCommands reports the command it launches instead.
+type ExecWrapper struct {
+	Program string
+
+	// Args are the arguments that make Program a wrapper, like app for uwsm, before its options.
+	Args []string
+
+	// ValueFlags are the options of the wrapper that take the next argument as their value, like
+	// -p for systemd-run. Other options, NAME=value assignments and -- are skipped on their own.
+	ValueFlags []string
+
+	// Shell marks a shell, whose command is the script after its -c option. The script is split
+	// into commands again, each of which may be wrapped itself.
+	Shell bool
+}
+
+// ExecWrappers are the wrappers ExtractExecCommands looks through. Append to it to recognize
+// more.
+var ExecWrappers = []ExecWrapper{
+	{Program: "uwsm", Args: []string{"app"}, ValueFlags: []string{"-s", "-a", "-u", "-d", "-t"}},
+	{Program: "uwsm-app", ValueFlags: []string{"-s", "-a", "-u", "-d", "-t"}},
+	{Program: "env", ValueFlags: []string{"-u", "--unset", "-C", "--chdir"}},
+	{Program: "dbus-launch"},
+	{Program: "systemd-run", ValueFlags: []string{
+		"-p", "--property", "-u", "--unit", "-E", "--setenv", "--slice", "--description",
+		"-M", "--machine", "--uid", "--gid", "--nice", "--working-directory",
+	}},
+	{Program: "sh", Shell: true},
+	{Program: "bash", Shell: true},
+	{Program: "dash", Shell: true},
+	{Program: "zsh", Shell: true},
+	{Program: "fish", Shell: true},
+}
+
+// maxWrapperDepth bounds how many wrappers, shell scripts included, are looked through for a
+// command, like sh -c "sh -c '...'".
+const maxWrapperDepth = 8
+
+// envAssignmentRe matches a NAME=value argument, as env and shells take before a command.
+var envAssignmentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// execPrograms returns the programs a command launches: its own program, or those of the command
+// it wraps. Programs given by absolute path are reduced to their name.
+func execPrograms(words []string, depth int) []string {
+	for len(words) > 0 && envAssignmentRe.MatchString(words[0]) {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return nil
+	}
+	program := words[0]
+	if strings.HasPrefix(program, "/") {
+		program = path.Base(program)
+	}
+	if depth >= maxWrapperDepth {
+		return []string{program}
+	}
+
+	for _, w := range ExecWrappers {
+		if w.Program != program || len(words) <= len(w.Args) || !slices.Equal(words[1:1+len(w.Args)], w.Args) {
+			continue
+		}
+		rest := words[1+len(w.Args):]
+		if w.Shell {
+			script, ok := shellScript(rest)
+			if !ok {
+				break // runs a script file
+			}
+			var programs []string
+			for _, cmd := range shellCommands(script) {
+				programs = append(programs, execPrograms(cmd, depth+1)...)
+			}
+			return programs
+		}
+		if wrapped := skipWrapperOptions(rest, w.ValueFlags); len(wrapped) > 0 {
+			return execPrograms(wrapped, depth+1)
+		}
+		break // nothing to wrap, e.g. a bare env
+	}
+	return []string{program}
+}
+
+// skipWrapperOptions returns args from the wrapped command on, after the wrapper's options and
+// NAME=value assignments.
+func skipWrapperOptions(args []string, valueFlags []string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		switch {
+		case arg == "--":
+			return args[1:]
+		case slices.Contains(valueFlags, arg):
+			args = args[min(2, len(args)):]
+		case strings.HasPrefix(arg, "-") || envAssignmentRe.MatchString(arg):
+			args = args[1:]
+		default:
+			return args
+		}
+	}
+	return nil
+}
+
+// shellScript returns the script a shell runs with its arguments: the first one that is not an
+// option when one of the options is -c, as in sh -c or bash -lc.
+func shellScript(args []string) (string, bool) {
+	command := false
+	for _, arg := range args {
+		if arg == "--" {
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") {
+			command = command || strings.Contains(arg, "c")
+			continue
+		}
+		if strings.HasPrefix(arg, "--") {
+			continue
+		}
+		return arg, command
+	}
+	return "", false
+}
+
+// shellCommands splits a command line into its commands, separated by unquoted &, &&, ; or
+// newlines, and each command into its words, with quotes and backslash escapes removed as a shell
+// would. Single quotes keep everything as written, double quotes only let \ escape ", \, $ and `.
+func shellCommands(line string) [][]string {
+	var (
+		commands [][]string
+		words    []string
+		word     strings.Builder
+		inWord   bool
+		quote    rune
+	)
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+		}
+		words = nil
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+		case r == '&' || r == ';' || r == '\n':
+			endCommand()
+		case r == ' ' || r == '\t':
+			endWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands
+}
@@ -0,0 +1,75 @@
+package hyprconfig
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestExtractExecCommandsWrappers(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"exec-once = uwsm app -- waybar", []string{"waybar"}},
+		{"exec-once = uwsm app -s b -a bar -- waybar -c ~/.config/waybar/config", []string{"waybar"}},
+		{"exec-once = uwsm-app -- nm-applet --indicator", []string{"nm-applet"}},
+		{"exec-once = uwsm finalize", []string{"uwsm"}},
+		{`exec-once = sh -c "sleep 1 && swww init"`, []string{"sleep", "swww"}},
+		{`exec-once = bash -lc 'sleep 2; foot --server'`, []string{"sleep", "foot"}},
+		{`exec-once = sh -c "uwsm app -- 'mako' & dbus-launch hyprpaper"`, []string{"mako", "hyprpaper"}},
+		{"exec-once = sh ~/.config/hypr/scripts/start.sh", []string{"sh"}},
+		{"exec-once = env FOO=bar kitty", []string{"kitty"}},
+		{"exec-once = env -u WAYLAND_DISPLAY XDG_CURRENT_DESKTOP=Hyprland -- swaync", []string{"swaync"}},
+		{"exec-once = env", []string{"env"}},
+		{"exec-once = QT_QPA_PLATFORM=wayland telegram-desktop", []string{"telegram-desktop"}},
+		{"exec-once = dbus-launch --exit-with-session fcitx5 -d", []string{"fcitx5"}},
+		{"exec-once = systemd-run --user --scope -p MemoryMax=1G --unit=bar waybar", []string{"waybar"}},
+		{"exec-once = /usr/bin/waybar", []string{"waybar"}},
+		{"exec-once = /usr/bin/env FOO=1 /usr/lib/polkit-kde-authentication-agent-1", []string{"polkit-kde-authentication-agent-1"}},
+		{"exec-once = ./scripts/run.sh", []string{"./scripts/run.sh"}},
+		{`exec-once = notify-send "a & b; c" && hyprctl setcursor Bibata 24`, []string{"notify-send", "hyprctl"}},
+		{`bind = SUPER, E, exec, sh -c 'thunar "$HOME"'`, []string{"thunar"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, cmd := range ExtractExecCommands(tt.line) {
+			got = append(got, cmd.Program)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: programs = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestShellCommands(t *testing.T) {
+	tests := []struct {
+		line string
+		want [][]string
+	}{
+		{"waybar", [][]string{{"waybar"}}},
+		{"  a  b\tc ", [][]string{{"a", "b", "c"}}},
+		{`a "b c" 'd "e"' f\ g`, [][]string{{"a", "b c", `d "e"`, "f g"}}},
+		{`echo "x \"y\" \n" ''`, [][]string{{"echo", `x "y" \n`, ""}}},
+		{"a & b && c; d\ne", [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}},
+		{`a "&;" 'b;c'`, [][]string{{"a", "&;", "b;c"}}},
+		{"a 'unterminated", [][]string{{"a", "unterminated"}}},
+		{"&& ;", nil},
+	}
+	for _, tt := range tests {
+		if got := shellCommands(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("shellCommands(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestExecWrappersConfigurable(t *testing.T) {
+	saved := ExecWrappers
+	t.Cleanup(func() { ExecWrappers = saved })
+	ExecWrappers = append(ExecWrappers[:len(ExecWrappers):len(ExecWrappers)], ExecWrapper{Program: "app2unit", Args: []string{"--"}})
+
+	got := ExtractExecOnceCommands("exec-once = app2unit -- waybar\nexec-once = app2unit waybar")
+	if want := []string{"waybar", "app2unit"}; !slices.Equal(got, want) {
+		t.Errorf("programs = %q, want %q", got, want)
+	}
+}
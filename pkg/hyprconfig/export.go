@@ -0,0 +1,198 @@
+package hyprconfig
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// ExportFormatTarGz is the only archive format currently supported by ExportConfigArchive.
+const ExportFormatTarGz = "tar.gz"
+
+// ManifestFile is the name of the config metadata file at the root of an export archive.
+const ManifestFile = "manifest.json"
+
+// ReadmeFile is the name of the config's markdown README at the root of an export archive.
+const ReadmeFile = "README.md"
+
+// defaultInstallPaths covers programs whose files don't live at ~/.config/<program>/<program>.conf.
+var defaultInstallPaths = map[string]string{
+	"hyprland":  "~/.config/hypr/hyprland.conf",
+	"hyprpaper": "~/.config/hypr/hyprpaper.conf",
+	"hypridle":  "~/.config/hypr/hypridle.conf",
+	"hyprlock":  "~/.config/hypr/hyprlock.conf",
+	"waybar":    "~/.config/waybar/config",
+}
+
+// DefaultInstallPath is where a program's file is placed when its config has no InstallPath.
+func DefaultInstallPath(program string) string {
+	if p, ok := defaultInstallPaths[program]; ok {
+		return p
+	}
+	return fmt.Sprintf("~/.config/%s/%s.conf", program, program)
+}
+
+type archiveEntry struct {
+	name    string
+	content FileContent
+	mode    int64
+}
+
+// ExportConfigArchive writes a tar.gz of the config's files laid out relative to $HOME, or as
+// set by WithExportLayout, plus a manifest.json with the config metadata and layout and the
+// README.md and LICENSE, if any. Entries without data are skipped. Template variables are
+// filled in from WithTemplateValues, except in the chezmoi layout, which keeps them as
+// templates.
+func (m *ConfigManagerMongo) ExportConfigArchive(ctx context.Context, configID string, w io.Writer) error {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return err
+	}
+	return writeConfigArchive(ctx, m.files, cfg, w, m.limits.ExtraInstallPrefixes)
+}
+
+// WriteConfigArchive writes the export archive of a config that isn't stored, e.g. one built
+// from a directory, like ExportConfigArchive. All of its files must be inline; cfg is left as it
+// is.
+func WriteConfigArchive(ctx context.Context, cfg *HyprConfig, w io.Writer, extraPrefixes ...string) error {
+	cfg, err := cloneConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return writeConfigArchive(ctx, nil, cfg, w, extraPrefixes)
+}
+
+// writeConfigArchive writes the archive for an already loaded (and decompressed) config.
+// Inline files come from RenderConfig; offloaded files are streamed from store. Install paths
+// must be inside DefaultInstallPrefixes or extraPrefixes.
+func writeConfigArchive(ctx context.Context, store FileStore, cfg *HyprConfig, w io.Writer, extraPrefixes []string) error {
+	layout, err := exportLayout(ctx)
+	if err != nil {
+		return err
+	}
+	var files, rootFiles map[string][]byte
+	templates := map[string]bool{}
+	if layout == ExportLayoutChezmoi {
+		if rootFiles, err = chezmoiRootFiles(cfg, templateValues(ctx)); err != nil {
+			return err
+		}
+		files, err = renderConfig(cfg, chezmoiTemplates(cfg, templates), extraPrefixes)
+	} else {
+		files, err = RenderConfig(cfg, templateValues(ctx), extraPrefixes...)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Collect offloaded files, then strip the data so the manifest only has metadata
+	modes := FileModes(cfg, extraPrefixes...)
+	programs := filePrograms(cfg, extraPrefixes)
+	var offloaded []archiveEntry
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		for _, e := range pc.FileEntries() {
+			if err != nil || len(e.FileContent.Data) > 0 || e.FileContent.FileID == "" {
+				continue
+			}
+			var name string
+			if name, err = pc.FilePath(e, extraPrefixes...); err == nil {
+				offloaded = append(offloaded, archiveEntry{name: name, content: e.FileContent, mode: int64(modes[name])})
+			}
+		}
+		pc.eachFile(func(_ string, fc *FileContent) {
+			fc.Data = nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	manifest, err := json.MarshalIndent(archiveManifest{HyprConfig: cfg, Layout: layout}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	if err := writeArchiveFile(tw, ManifestFile, 0o644, now, int64(len(manifest)), bytes.NewReader(manifest)); err != nil {
+		return err
+	}
+	if cfg.Readme != "" {
+		if err := writeArchiveFile(tw, ReadmeFile, 0o644, now, int64(len(cfg.Readme)), strings.NewReader(cfg.Readme)); err != nil {
+			return err
+		}
+	}
+	if cfg.License != "" {
+		license := licenseFile(cfg)
+		if err := writeArchiveFile(tw, LicenseFile, 0o644, now, int64(len(license)), strings.NewReader(license)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(rootFiles) {
+		data := rootFiles[name]
+		if err := writeArchiveFile(tw, name, 0o644, now, int64(len(data)), bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range sortedKeys(files) {
+		mode, ok := modes[name]
+		if !ok {
+			mode = 0o644
+		}
+		data := files[name]
+		archived := layoutPath(layout, name, programs[name], mode, templates[name])
+		if err := writeArchiveFile(tw, archived, int64(mode), now, int64(len(data)), bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+	for _, e := range offloaded {
+		e.name = layoutPath(layout, e.name, programs[e.name], fs.FileMode(e.mode), false)
+		if err := writeArchiveEntry(ctx, store, tw, e, now); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// writeArchiveEntry streams an offloaded file from the file store into the archive.
+func writeArchiveEntry(ctx context.Context, store FileStore, tw *tar.Writer, e archiveEntry, modTime time.Time) error {
+	if store == nil {
+		return ErrFileStoreDisabled
+	}
+	rc, err := store.Open(ctx, e.content.FileID)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return writeArchiveFile(tw, e.name, e.mode, modTime, e.content.Size, rc)
+}
+
+func writeArchiveFile(tw *tar.Writer, name string, mode int64, modTime time.Time, size int64, r io.Reader) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    size,
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write archive header for %s: %w", name, err)
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return fmt.Errorf("failed to write %s to archive: %w", name, err)
+	}
+	return nil
+}
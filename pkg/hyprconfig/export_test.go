@@ -0,0 +1,169 @@
+package hyprconfig
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestHomeRelativePath(t *testing.T) {
+	tests := []struct {
+		pc   HyprProgramConfig
+		want string
+	}{
+		{HyprProgramConfig{Program: "kitty"}, ".config/kitty/kitty.conf"},
+		{HyprProgramConfig{Program: "hyprland"}, ".config/hypr/hyprland.conf"},
+		{HyprProgramConfig{Program: "waybar", InstallPath: "~/.config/waybar/config"}, ".config/waybar/config"},
+		{HyprProgramConfig{Program: "waybar", InstallPath: "$HOME/.config/waybar/"}, ".config/waybar/waybar.conf"},
+		{HyprProgramConfig{Program: "wofi", InstallPath: "~/.config/wofi/./style.css"}, ".config/wofi/style.css"},
+	}
+	for _, tt := range tests {
+		got, err := homeRelativePath(&tt.pc)
+		if err != nil || got != tt.want {
+			t.Errorf("homeRelativePath(%q) = %q, %v; want %q", tt.pc.InstallPath, got, err, tt.want)
+		}
+	}
+
+	if _, err := homeRelativePath(&HyprProgramConfig{Program: "kitty", InstallPath: "~/../../etc/passwd"}); err == nil {
+		t.Error("expected path escaping $HOME to be rejected")
+	}
+}
+
+func TestWriteConfigArchive(t *testing.T) {
+	cfg := &HyprConfig{
+		ID:      "cfg1",
+		Title:   "rice",
+		Readme:  "# rice\n",
+		License: "MIT",
+		ProgramConfigs: []HyprProgramConfig{
+			{ID: "p1", Program: "hyprland", InstallPath: "~/.config/hypr/hyprland.conf",
+				FileContent: FileContent{Data: []byte("exec-once = waybar\n"), FileType: FileTypeConfig},
+				SubConfigs: []*HyprProgramConfig{
+					{ID: "p2", Program: "waybar", FileContent: FileContent{Data: []byte("{}"), FileType: FileTypeConfig}},
+					{ID: "p3", Program: "wofi"}, // no data, skipped
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeConfigArchive(context.Background(), nil, cfg, &buf, nil); err != nil {
+		t.Fatalf("writeConfigArchive: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name], _ = io.ReadAll(tr)
+	}
+
+	if len(files) != 5 {
+		t.Fatalf("archive has %d entries, want 5: %v", len(files), files)
+	}
+	if readme := string(files[ReadmeFile]); readme != "# rice\n" {
+		t.Errorf("README.md = %q", readme)
+	}
+	if license := string(files[LicenseFile]); !strings.HasPrefix(license, "SPDX-License-Identifier: MIT\n") {
+		t.Errorf("LICENSE = %q", license)
+	}
+	if hypr := string(files[".config/hypr/hyprland.conf"]); !strings.HasPrefix(hypr, "exec-once = waybar\n") ||
+		strings.Contains(hypr, "source = ~/.config/waybar/config") {
+		t.Errorf("hyprland.conf = %q", hypr)
+	}
+	if string(files[".config/waybar/config"]) != "{}" {
+		t.Errorf("waybar config = %q", files[".config/waybar/config"])
+	}
+
+	var manifest HyprConfig
+	if err := json.Unmarshal(files[ManifestFile], &manifest); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if manifest.ID != "cfg1" || len(manifest.ProgramConfigs[0].FileContent.Data) != 0 {
+		t.Error("manifest should carry config metadata without file data")
+	}
+}
+
+func TestWriteConfigArchiveModes(t *testing.T) {
+	cfg := &HyprConfig{
+		ID: "cfg1",
+		ProgramConfigs: []HyprProgramConfig{
+			{ID: "p1", Program: "hyprland", FileContent: FileContent{Data: []byte("exec-once = ~/.config/hypr/scripts/bar.sh\n"), FileType: FileTypeConfig},
+				SubConfigs: []*HyprProgramConfig{
+					{ID: "p2", Program: "waybar", InstallPath: "~/.config/hypr/scripts/bar.sh", FileContent: FileContent{Data: []byte("waybar &\n"), FileType: FileTypeScript}},
+				},
+			},
+			{ID: "p3", Program: "kitty", FileContent: FileContent{Data: []byte("font_size 12\n"), FileType: FileTypeConfig, Mode: "0600"},
+				Files: []FileEntry{
+					{TargetPath: "open.sh", FileContent: FileContent{Data: []byte("kitty @ launch\n"), FileType: FileTypeScript}, Mode: "0700"},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeConfigArchive(context.Background(), nil, cfg, &buf, nil); err != nil {
+		t.Fatalf("writeConfigArchive: %v", err)
+	}
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modes := map[string]int64{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		modes[hdr.Name] = hdr.Mode
+	}
+
+	for name, want := range map[string]int64{
+		ManifestFile:                  0o644,
+		".config/hypr/hyprland.conf":  0o644,
+		".config/hypr/scripts/bar.sh": 0o755,
+		".config/kitty/kitty.conf":    0o600,
+		".config/kitty/open.sh":       0o700,
+	} {
+		if got, ok := modes[name]; !ok || got != want {
+			t.Errorf("%s has mode %o, want %o", name, got, want)
+		}
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	for s, want := range map[string]fs.FileMode{"644": 0o644, "0600": 0o600, "0755": 0o755, "700": 0o700} {
+		if got, err := parseFileMode(s); err != nil || got != want {
+			t.Errorf("parseFileMode(%q) = %o, %v; want %o", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"4755", "2755", "1777", "0777", "0666", "0o755", "rwxr-xr-x", "-1"} {
+		if _, err := parseFileMode(s); !errors.Is(err, ErrInvalidFileMode) {
+			t.Errorf("parseFileMode(%q): got %v, want ErrInvalidFileMode", s, err)
+		}
+	}
+	if _, err := parseFileMode("4755"); err == nil || !strings.Contains(err.Error(), "setuid") {
+		t.Errorf("setuid mode: got %v, want the setuid bit named", err)
+	}
+}
@@ -0,0 +1,357 @@
+package hyprconfig
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ConfigFavoriter is a user who favorited a config.
+type ConfigFavoriter struct {
+	UserID      string    `json:"user_id"`
+	FavoritedAt time.Time `json:"favorited_at"`
+
+	// Author info of the user's most recently updated public config, nil when they have none.
+	Author *Author `json:"author,omitempty"`
+}
+
+// sortFavoriters orders favoriters newest first.
+func sortFavoriters(favoriters []ConfigFavoriter) {
+	slices.SortFunc(favoriters, func(a, b ConfigFavoriter) int {
+		if c := b.FavoritedAt.Compare(a.FavoritedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+}
+
+// setFavoriterAuthors fills in Author from authors, keyed by user id.
+func setFavoriterAuthors(favoriters []ConfigFavoriter, authors map[string]Author) {
+	for i := range favoriters {
+		if a, ok := authors[favoriters[i].UserID]; ok {
+			favoriters[i].Author = &a
+		}
+	}
+}
+
+func favoriterIDs(favoriters []ConfigFavoriter) []string {
+	ids := make([]string, 0, len(favoriters))
+	for _, f := range favoriters {
+		ids = append(ids, f.UserID)
+	}
+	return ids
+}
+
+// ListConfigFavoriters returns the users who favorited a config, newest first.
+// Only the config owner and admins may list them.
+func (m *ConfigManagerMongo) ListConfigFavoriters(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[ConfigFavoriter], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+
+	var cfg HyprConfig
+	err = m.Collection.FindOne(ctx, bson.M{"_id": configID},
+		options.FindOne().SetProjection(bson.M{"owner_id": 1}),
+	).Decode(&cfg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return mserve.Page[ConfigFavoriter]{}, ErrNotFound
+	} else if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	if !canWrite(&cfg, user) {
+		return mserve.Page[ConfigFavoriter]{}, ErrForbidden
+	}
+
+	favs, err := mserve.PaginateMongo[UserFavorite](
+		ctx,
+		m.FavoritesCollection,
+		bson.M{"config_id": configID},
+		page,
+		limit,
+		options.Find().SetSort(bson.D{{Key: "favorited_at", Value: -1}, {Key: "user_id", Value: 1}}),
+	)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+
+	favoriters := make([]ConfigFavoriter, 0, len(favs.Items))
+	for _, f := range favs.Items {
+		favoriters = append(favoriters, ConfigFavoriter{UserID: f.UserID, FavoritedAt: f.FavoritedAt})
+	}
+
+	authors, err := m.authorsOf(ctx, favoriterIDs(favoriters))
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	setFavoriterAuthors(favoriters, authors)
+
+	return mserve.Page[ConfigFavoriter]{
+		Items:      favoriters,
+		Page:       favs.Page,
+		Limit:      favs.Limit,
+		Total:      favs.Total,
+		TotalPages: favs.TotalPages,
+	}, nil
+}
+
+// authorsOf returns the author info of each user's most recently updated public config.
+func (m *ConfigManagerMongo) authorsOf(ctx context.Context, userIDs []string) (map[string]Author, error) {
+	authors := map[string]Author{}
+	if len(userIDs) == 0 {
+		return authors, nil
+	}
+
+	cursor, err := m.Collection.Find(ctx,
+		bson.M{
+			"owner_id":        bson.M{"$in": userIDs},
+			"private":         false,
+			"author.username": bson.M{"$nin": bson.A{"", nil}},
+		},
+		options.Find().
+			SetProjection(bson.M{"owner_id": 1, "author": 1}).
+			SetSort(bson.D{{Key: "updated_timestamp", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []HyprConfig
+	if err := cursor.All(ctx, &configs); err != nil {
+		return nil, err
+	}
+	for _, cfg := range configs {
+		if _, ok := authors[cfg.OwnerID]; !ok {
+			authors[cfg.OwnerID] = cfg.Author
+		}
+	}
+	return authors, nil
+}
+
+// CountConfigFavorites counts the favorites of a config from the favorites collection,
+// rather than trusting the denormalized likes field.
+func (m *ConfigManagerMongo) CountConfigFavorites(ctx context.Context, configID string) (int64, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil for public configs
+
+	var cfg HyprConfig
+	err := m.Collection.FindOne(ctx, bson.M{"_id": configID},
+		options.FindOne().SetProjection(bson.M{"private": 1, "owner_id": 1}),
+	).Decode(&cfg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if !canRead(&cfg, user) {
+		return 0, ErrForbidden
+	}
+
+	return m.FavoritesCollection.CountDocuments(ctx, bson.M{"config_id": configID})
+}
+
+func (m *ConfigManagerMemory) ListConfigFavoriters(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[ConfigFavoriter], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cfg, ok := m.configs[configID]
+	if !ok {
+		return mserve.Page[ConfigFavoriter]{}, ErrNotFound
+	}
+	if !canWrite(cfg, user) {
+		return mserve.Page[ConfigFavoriter]{}, ErrForbidden
+	}
+
+	var favoriters []ConfigFavoriter
+	for userID, favs := range m.favorites {
+		if at, ok := favs[configID]; ok {
+			favoriters = append(favoriters, ConfigFavoriter{UserID: userID, FavoritedAt: at})
+		}
+	}
+	sortFavoriters(favoriters)
+
+	result, err := mserve.Paginate(favoriters, page, limit)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	setFavoriterAuthors(result.Items, m.authorsOf(favoriterIDs(result.Items)))
+	return result, nil
+}
+
+// authorsOf returns the author info of each user's most recently updated public config.
+// The caller must hold m.mu.
+func (m *ConfigManagerMemory) authorsOf(userIDs []string) map[string]Author {
+	latest := map[string]*HyprConfig{}
+	for _, cfg := range m.configs {
+		if cfg.Private || cfg.Author.UserName == "" || !slices.Contains(userIDs, cfg.OwnerID) {
+			continue
+		}
+		if prev, ok := latest[cfg.OwnerID]; !ok || cfg.UpdatedTimestamp.After(prev.UpdatedTimestamp) {
+			latest[cfg.OwnerID] = cfg
+		}
+	}
+
+	authors := make(map[string]Author, len(latest))
+	for id, cfg := range latest {
+		authors[id] = cfg.Author
+	}
+	return authors
+}
+
+func (m *ConfigManagerMemory) CountConfigFavorites(ctx context.Context, configID string) (int64, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil for public configs
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cfg, ok := m.configs[configID]
+	if !ok {
+		return 0, ErrNotFound
+	}
+	if !canRead(cfg, user) {
+		return 0, ErrForbidden
+	}
+
+	var n int64
+	for _, favs := range m.favorites {
+		if _, ok := favs[configID]; ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *ConfigManagerSQLite) ListConfigFavoriters(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[ConfigFavoriter], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+
+	var cfg HyprConfig
+	err = m.db.QueryRowContext(ctx, `SELECT owner_id FROM configs WHERE id = ?`, configID).Scan(&cfg.OwnerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return mserve.Page[ConfigFavoriter]{}, ErrNotFound
+	} else if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	if !canWrite(&cfg, user) {
+		return mserve.Page[ConfigFavoriter]{}, ErrForbidden
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT user_id, favorited_at FROM favorites WHERE config_id = ? ORDER BY favorited_at DESC, user_id ASC`,
+		configID)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	defer rows.Close()
+
+	var favoriters []ConfigFavoriter
+	for rows.Next() {
+		var f ConfigFavoriter
+		var at int64
+		if err := rows.Scan(&f.UserID, &at); err != nil {
+			return mserve.Page[ConfigFavoriter]{}, err
+		}
+		f.FavoritedAt = time.Unix(0, at)
+		favoriters = append(favoriters, f)
+	}
+	if err := rows.Err(); err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+
+	result, err := mserve.Paginate(favoriters, page, limit)
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	authors, err := m.authorsOf(ctx, favoriterIDs(result.Items))
+	if err != nil {
+		return mserve.Page[ConfigFavoriter]{}, err
+	}
+	setFavoriterAuthors(result.Items, authors)
+	return result, nil
+}
+
+// authorsOf returns the author info of each user's most recently updated public config.
+func (m *ConfigManagerSQLite) authorsOf(ctx context.Context, userIDs []string) (map[string]Author, error) {
+	authors := map[string]Author{}
+	if len(userIDs) == 0 {
+		return authors, nil
+	}
+
+	args := make([]any, 0, len(userIDs))
+	for _, id := range userIDs {
+		args = append(args, id)
+	}
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT owner_id, json_extract(doc, '$.author') FROM configs
+		WHERE private = 0 AND owner_id IN (?`+strings.Repeat(", ?", len(userIDs)-1)+`)
+		AND COALESCE(json_extract(doc, '$.author.username'), '') != ''
+		ORDER BY updated_timestamp DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ownerID, doc string
+		if err := rows.Scan(&ownerID, &doc); err != nil {
+			return nil, err
+		}
+		if _, ok := authors[ownerID]; ok {
+			continue
+		}
+		var a Author
+		if err := json.Unmarshal([]byte(doc), &a); err != nil {
+			return nil, err
+		}
+		authors[ownerID] = a
+	}
+	return authors, rows.Err()
+}
+
+func (m *ConfigManagerSQLite) CountConfigFavorites(ctx context.Context, configID string) (int64, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil for public configs
+
+	var cfg HyprConfig
+	err := m.db.QueryRowContext(ctx, `SELECT owner_id, private FROM configs WHERE id = ?`, configID).
+		Scan(&cfg.OwnerID, &cfg.Private)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, err
+	}
+	if !canRead(&cfg, user) {
+		return 0, ErrForbidden
+	}
+
+	var n int64
+	err = m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM favorites WHERE config_id = ?`, configID).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,181 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidFileMode = errors.New("invalid file mode")
+
+// MaxProgramFiles bounds the Files of a single program config.
+const MaxProgramFiles = 32
+
+// FileEntry is one of several files a program config installs, e.g. the style.css next to
+// waybar's config.jsonc.
+type FileEntry struct {
+	// Where the file is installed: ~/... or $HOME/..., or a path relative to the directory of
+	// the program's install path such as style.css.
+	TargetPath  string      `json:"target_path" bson:"target_path"`
+	FileContent FileContent `json:"file_content" bson:"file_content"`
+
+	// Permissions the file is installed with, one of AllowedFileModes. Empty means the default for
+	// its FileType, see FileEntry.FileMode.
+	Mode string `json:"mode,omitempty" bson:"mode,omitempty"`
+}
+
+// AllowedFileModes are the permissions a file may be installed with.
+var AllowedFileModes = []string{"0644", "0600", "0755", "0700"}
+
+// hasContent reports whether fc holds a file, inline or offloaded.
+func (fc *FileContent) hasContent() bool {
+	return len(fc.Data) > 0 || fc.FileID != ""
+}
+
+// FileEntries returns every file of pc, not of its sub configs. Program configs written before
+// Files existed have their FileContent as a single entry at InstallPath; it comes first when a
+// program config has both, with the install path written as a TargetPath below ~/.
+func (pc *HyprProgramConfig) FileEntries() []FileEntry {
+	entries := make([]FileEntry, 0, len(pc.Files)+1)
+	if pc.FileContent.hasContent() {
+		entries = append(entries, FileEntry{TargetPath: installTarget(pc), FileContent: pc.FileContent, Mode: pc.FileContent.Mode})
+	}
+	return append(entries, pc.Files...)
+}
+
+// eachFile calls fn with the FileContent and every entry of Files of pc, along with the JSON
+// path of the content below pc. The FileContent is visited even when it is empty.
+func (pc *HyprProgramConfig) eachFile(fn func(field string, fc *FileContent)) {
+	fn("file_content", &pc.FileContent)
+	for i := range pc.Files {
+		fn(fmt.Sprintf("files[%d].file_content", i), &pc.Files[i].FileContent)
+	}
+}
+
+// FilePath returns where the entry of pc is installed, relative to $HOME. An entry without a
+// TargetPath is installed at the program's install path.
+func (pc *HyprProgramConfig) FilePath(e FileEntry, extraPrefixes ...string) (string, error) {
+	if e.TargetPath == "" {
+		return homeRelativePath(pc, extraPrefixes...)
+	}
+	if strings.HasSuffix(e.TargetPath, "/") {
+		return "", invalidf("program %s: %w %q: must name a file, not a directory", pc.Program, ErrInvalidInstallPath, e.TargetPath)
+	}
+	dir, err := homeRelativePath(pc, extraPrefixes...)
+	if err != nil {
+		return "", err
+	}
+	return resolveInstallPath(pc.Program, e.TargetPath, e.TargetPath, path.Dir(dir), extraPrefixes)
+}
+
+// findFile returns the entry of pc installed at p, which is either a TargetPath as written or
+// the path it resolves to relative to $HOME. An empty p is the first entry.
+func (pc *HyprProgramConfig) findFile(p string, extraPrefixes ...string) (FileEntry, bool) {
+	entries := pc.FileEntries()
+	if p == "" {
+		if len(entries) == 0 {
+			return FileEntry{}, false
+		}
+		return entries[0], true
+	}
+	for _, e := range entries {
+		if e.TargetPath != "" && e.TargetPath == p {
+			return e, true
+		}
+	}
+	want := path.Clean(strings.TrimPrefix(strings.TrimPrefix(p, "~/"), "$HOME/"))
+	for _, e := range entries {
+		if resolved, err := pc.FilePath(e, extraPrefixes...); err == nil && resolved == want {
+			return e, true
+		}
+	}
+	return FileEntry{}, false
+}
+
+// FileMode returns the permissions the entry is installed with: its Mode, else 0755 for scripts
+// and 0644 for any other file.
+func (e FileEntry) FileMode() fs.FileMode {
+	if e.Mode != "" {
+		if mode, err := parseFileMode(e.Mode); err == nil {
+			return mode
+		}
+	}
+	if e.FileContent.FileType == FileTypeScript {
+		return 0o755
+	}
+	return 0o644
+}
+
+// parseFileMode parses octal permissions, which must be one of AllowedFileModes.
+func parseFileMode(s string) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode&^0o7777 != 0 {
+		return 0, fmt.Errorf("%w %q, use one of %s", ErrInvalidFileMode, s, strings.Join(AllowedFileModes, ", "))
+	}
+	if mode&0o7000 != 0 {
+		return 0, fmt.Errorf("%w %q: setuid, setgid and sticky bits are not allowed", ErrInvalidFileMode, s)
+	}
+	if !slices.Contains(AllowedFileModes, fmt.Sprintf("%04o", mode)) {
+		return 0, fmt.Errorf("%w %q, use one of %s", ErrInvalidFileMode, s, strings.Join(AllowedFileModes, ", "))
+	}
+	return fs.FileMode(mode), nil
+}
+
+// normalizeMode checks the mode at field, storing it back as four octal digits.
+func normalizeMode(mode *string, field, program string, verr *ValidationError) {
+	if *mode == "" {
+		return
+	}
+	m, err := parseFileMode(*mode)
+	if err != nil {
+		verr.add(field, fmt.Errorf("program %s: %w", program, err))
+		return
+	}
+	*mode = fmt.Sprintf("%04o", uint32(m))
+}
+
+// validateFiles records in verr every problem with the target paths and modes of the files of
+// pc: missing, invalid or shared paths, and modes that aren't one of AllowedFileModes. Modes
+// are stored back as four octal digits.
+func (pc *HyprProgramConfig) validateFiles(verr *ValidationError, extraPrefixes []string) {
+	if len(pc.Files) > MaxProgramFiles {
+		verr.addf("files", CodeTooMany, "%d files, at most %d allowed", len(pc.Files), MaxProgramFiles)
+	}
+
+	normalizeMode(&pc.FileContent.Mode, "file_content.mode", pc.Program, verr)
+	owners := map[string]string{}
+	if pc.FileContent.hasContent() {
+		if p, err := homeRelativePath(pc, extraPrefixes...); err == nil {
+			owners[p] = "file_content"
+		}
+	}
+	for i := range pc.Files {
+		e := &pc.Files[i]
+		field := fmt.Sprintf("files[%d]", i)
+		// An entry has a single mode, set on either the entry or its content
+		if e.Mode == "" {
+			e.Mode = e.FileContent.Mode
+		}
+		e.FileContent.Mode = ""
+		normalizeMode(&e.Mode, field+".mode", pc.Program, verr)
+
+		if e.TargetPath == "" {
+			verr.addf(field+".target_path", CodeRequired, "program %s: every file needs a target_path", pc.Program)
+			continue
+		}
+		p, err := pc.FilePath(*e, extraPrefixes...)
+		if err != nil {
+			verr.add(field+".target_path", err)
+			continue
+		}
+		if owner, dup := owners[p]; dup {
+			verr.addf(field+".target_path", CodeInvalid, "program %s: %s and %s both install to ~/%s", pc.Program, owner, field, p)
+			continue
+		}
+		owners[p] = field
+	}
+}
@@ -0,0 +1,167 @@
+package hyprconfig
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func waybarConfig() *HyprConfig {
+	return &HyprConfig{
+		Title: "bar",
+		ProgramConfigs: []HyprProgramConfig{{
+			ID: "bar", Title: "waybar", Program: "waybar",
+			Files: []FileEntry{
+				{TargetPath: "config.jsonc", FileContent: FileContent{Data: []byte(`{"layer": "top"}`), FileType: FileTypeConfig}},
+				{TargetPath: "style.css", FileContent: FileContent{Data: []byte("* { font-size: 13px; }"), FileType: FileTypeText}},
+				{TargetPath: "~/.config/waybar/scripts/mpris.sh", Mode: "700", FileContent: FileContent{Data: []byte("playerctl status\n"), FileType: FileTypeScript}},
+			},
+		}},
+	}
+}
+
+func TestFileEntries(t *testing.T) {
+	legacy := &HyprProgramConfig{Program: "kitty", FileContent: FileContent{Data: []byte("font_size 12")}}
+	entries := legacy.FileEntries()
+	if len(entries) != 1 || entries[0].TargetPath != "~/.config/kitty/kitty.conf" || string(entries[0].FileContent.Data) != "font_size 12" {
+		t.Errorf("legacy entries = %+v", entries)
+	}
+	if entries := (&HyprProgramConfig{Program: "kitty"}).FileEntries(); len(entries) != 0 {
+		t.Errorf("a program config without content has entries %+v", entries)
+	}
+
+	pc := &waybarConfig().ProgramConfigs[0]
+	for target, want := range map[string]string{
+		"style.css":                 ".config/waybar/style.css",
+		"themes/../style.css":       "",
+		"./themes/dark.css":         ".config/waybar/themes/dark.css",
+		"$HOME/.config/waybar/x.sh": ".config/waybar/x.sh",
+		"~/.bashrc":                 "",
+		"/etc/passwd":               "",
+		"themes/":                   "",
+	} {
+		got, err := pc.FilePath(FileEntry{TargetPath: target})
+		if got != want || (want == "") != errors.Is(err, ErrInvalidInstallPath) {
+			t.Errorf("FilePath(%q) = %q, %v; want %q", target, got, err, want)
+		}
+	}
+
+	for _, tt := range []struct {
+		e    FileEntry
+		want fs.FileMode
+	}{
+		{pc.Files[0], 0o644},
+		{pc.Files[2], 0o700},
+		{FileEntry{FileContent: FileContent{FileType: FileTypeScript}}, 0o755},
+	} {
+		if got := tt.e.FileMode(); got != tt.want {
+			t.Errorf("FileMode(%+v) = %o, want %o", tt.e, got, tt.want)
+		}
+	}
+}
+
+func TestProgramFiles(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+
+		bad := waybarConfig()
+		bad.ProgramConfigs[0].Files = append(bad.ProgramConfigs[0].Files,
+			FileEntry{TargetPath: "~/.config/waybar/style.css"},
+			FileEntry{FileContent: FileContent{Data: []byte("x")}},
+			FileEntry{TargetPath: "../../.ssh/authorized_keys"},
+			FileEntry{TargetPath: "colors.css", Mode: "4755"},
+		)
+		_, err := m.CreateConfig(alice, bad)
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("got %v, want a *ValidationError", err)
+		}
+		var problems []string
+		for _, fe := range verr.Errors {
+			problems = append(problems, fe.Path+" "+fe.Code)
+		}
+		want := "program_configs[0].files[3].target_path invalid,program_configs[0].files[4].target_path required," +
+			"program_configs[0].files[5].target_path invalid_install_path,program_configs[0].files[6].mode invalid_file_mode"
+		if got := strings.Join(problems, ","); got != want {
+			t.Errorf("problems = %s, want %s", got, want)
+		}
+
+		cfg, err := m.CreateConfig(alice, waybarConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cfg.ProgramConfigs[0].Files[2]; got.Mode != "0700" || got.FileContent.Hash == "" {
+			t.Errorf("stored entry = %+v, want mode 0700 and a hash", got)
+		}
+
+		for _, p := range []string{"style.css", "~/.config/waybar/style.css", ".config/waybar/style.css"} {
+			rc, file, err := m.GetProgramFile(alice, cfg.ID, "bar", p)
+			if err != nil {
+				t.Errorf("GetProgramFile(%q): %v", p, err)
+				continue
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			if string(data) != "* { font-size: 13px; }" || file.TargetPath != "~/.config/waybar/style.css" || file.FileContent.Size != int64(len(data)) {
+				t.Errorf("GetProgramFile(%q) = %q, %+v", p, data, file)
+			}
+		}
+		if rc, _, err := m.GetProgramFile(alice, cfg.ID, "bar", ""); err != nil {
+			t.Errorf("first file: %v", err)
+		} else if data, _ := io.ReadAll(rc); string(data) != `{"layer": "top"}` {
+			t.Errorf("first file = %q", data)
+		}
+		if _, _, err := m.GetProgramFile(alice, cfg.ID, "bar", "missing.css"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing file: got %v, want ErrNotFound", err)
+		}
+
+		var buf bytes.Buffer
+		if err := m.ExportConfigArchive(alice, cfg.ID, &buf); err != nil {
+			t.Fatal(err)
+		}
+		gz, err := gzip.NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		modes := map[string]int64{}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			modes[hdr.Name] = hdr.Mode
+		}
+		for name, mode := range map[string]int64{
+			".config/waybar/config.jsonc":     0o644,
+			".config/waybar/style.css":        0o644,
+			".config/waybar/scripts/mpris.sh": 0o700,
+		} {
+			if modes[name] != mode {
+				t.Errorf("archive entry %s has mode %o, want %o (entries %v)", name, modes[name], mode, modes)
+			}
+		}
+	})
+}
+
+func TestProgramFilesSize(t *testing.T) {
+	limits := DefaultSizeLimits()
+	limits.MaxConfigBytes = 40
+	cfg := waybarConfig()
+	err := cfg.Validate(context.Background(), func(context.Context, []string) (map[string]struct{}, error) { return nil, nil }, limits)
+	if !errors.Is(err, ErrContentTooLarge) {
+		t.Errorf("got %v, want the files counted towards the config size", err)
+	}
+	if got, want := cfg.contentSize(), int64(16+22+17); got != want {
+		t.Errorf("contentSize() = %d, want %d", got, want)
+	}
+}
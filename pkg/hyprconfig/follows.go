@@ -0,0 +1,371 @@
+package hyprconfig
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Follow is a user following the configs of an author.
+type Follow struct {
+	FollowerID string    `json:"follower_id" bson:"follower_id"`
+	FolloweeID string    `json:"followee_id" bson:"followee_id"`
+	FollowedAt time.Time `json:"followed_at" bson:"followed_at"`
+}
+
+// FollowedAuthor is an author the signed-in user follows.
+type FollowedAuthor struct {
+	UserID     string    `json:"user_id"`
+	FollowedAt time.Time `json:"followed_at"`
+
+	// Author info of the user's most recently updated public config, nil when they have none.
+	Author *Author `json:"author,omitempty"`
+}
+
+// followerCount is the denormalized number of followers of a user.
+type followerCount struct {
+	UserID    string `bson:"_id"`
+	Followers int64  `bson:"followers"`
+}
+
+// checkFollow returns the signed-in user after checking they may follow ownerID.
+func checkFollow(ctx context.Context, ownerID string) (string, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ownerID == "" {
+		return "", invalidf("user id is required")
+	}
+	if ownerID == user.UserID {
+		return "", invalidf("users cannot follow themselves")
+	}
+	return user.UserID, nil
+}
+
+// sortFollowing orders followed authors, most recently followed first.
+func sortFollowing(following []FollowedAuthor) {
+	slices.SortFunc(following, func(a, b FollowedAuthor) int {
+		if c := b.FollowedAt.Compare(a.FollowedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+}
+
+// setFollowedAuthors fills in Author from authors, keyed by user id.
+func setFollowedAuthors(following []FollowedAuthor, authors map[string]Author) {
+	for i := range following {
+		if a, ok := authors[following[i].UserID]; ok {
+			following[i].Author = &a
+		}
+	}
+}
+
+func followedIDs(following []FollowedAuthor) []string {
+	ids := make([]string, 0, len(following))
+	for _, f := range following {
+		ids = append(ids, f.UserID)
+	}
+	return ids
+}
+
+// FollowAuthor makes the signed-in user follow ownerID. Following someone twice is a no-op.
+func (m *ConfigManagerMongo) FollowAuthor(ctx context.Context, ownerID string) (err error) {
+	defer func() { m.logMutation(ctx, "FollowAuthor", err, slog.String("followee_id", ownerID)) }()
+	userID, err := checkFollow(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.FollowsCollection.InsertOne(ctx, Follow{FollowerID: userID, FolloweeID: ownerID, FollowedAt: time.Now()})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil // already following, ignore
+	} else if err != nil {
+		return err
+	}
+	if err := m.addFollowers(ctx, ownerID, 1); err != nil {
+		return err
+	}
+	m.notify(ctx, NotificationNewFollower, userID, "", []string{ownerID})
+	return nil
+}
+
+func (m *ConfigManagerMongo) UnfollowAuthor(ctx context.Context, ownerID string) (err error) {
+	defer func() { m.logMutation(ctx, "UnfollowAuthor", err, slog.String("followee_id", ownerID)) }()
+	userID, err := checkFollow(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	res, err := m.FollowsCollection.DeleteOne(ctx, bson.M{"follower_id": userID, "followee_id": ownerID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return nil // not following, nothing to do
+	}
+	return m.addFollowers(ctx, ownerID, -1)
+}
+
+// addFollowers changes the denormalized follower count of userID by delta.
+func (m *ConfigManagerMongo) addFollowers(ctx context.Context, userID string, delta int64) error {
+	_, err := m.FollowerCountsCollection.UpdateByID(ctx, userID,
+		bson.M{"$inc": bson.M{"followers": delta}},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// followers returns the denormalized follower count of userID.
+func (m *ConfigManagerMongo) followers(ctx context.Context, userID string) (int64, error) {
+	var count followerCount
+	err := m.FollowerCountsCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&count)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return count.Followers, err
+}
+
+// ListFollowing returns the authors the signed-in user follows, most recently followed first.
+func (m *ConfigManagerMongo) ListFollowing(ctx context.Context) ([]FollowedAuthor, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.FollowsCollection.Find(ctx, bson.M{"follower_id": user.UserID},
+		options.Find().SetSort(bson.D{{Key: "followed_at", Value: -1}, {Key: "followee_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var follows []Follow
+	if err := cursor.All(ctx, &follows); err != nil {
+		return nil, err
+	}
+
+	following := make([]FollowedAuthor, 0, len(follows))
+	for _, f := range follows {
+		following = append(following, FollowedAuthor{UserID: f.FolloweeID, FollowedAt: f.FollowedAt})
+	}
+	authors, err := m.authorsOf(ctx, followedIDs(following))
+	if err != nil {
+		return nil, err
+	}
+	setFollowedAuthors(following, authors)
+	return following, nil
+}
+
+// ListFollowedFeed lists the public configs of the authors the signed-in user follows, most
+// recently updated first.
+func (m *ConfigManagerMongo) ListFollowedFeed(ctx context.Context, page, limit int) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	followees, err := m.FollowsCollection.Distinct(ctx, "followee_id", bson.M{"follower_id": user.UserID})
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	result, err := mserve.PaginateMongo[HyprConfig](
+		ctx,
+		m.Collection,
+		bson.M{"owner_id": bson.M{"$in": followees}, "private": false},
+		page,
+		limit,
+		listFindOptions(ctx, options.Find().SetSort(bson.D{{Key: "updated_timestamp", Value: -1}, {Key: "_id", Value: 1}})),
+	)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	return decodeListForRead(ctx, result)
+}
+
+func (m *ConfigManagerMemory) FollowAuthor(ctx context.Context, ownerID string) error {
+	userID, err := checkFollow(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.follows[userID][ownerID]; ok {
+		return nil // already following, ignore
+	}
+	if m.follows[userID] == nil {
+		m.follows[userID] = map[string]time.Time{}
+	}
+	m.follows[userID][ownerID] = time.Now()
+	m.followers[ownerID]++
+	m.notify(NotificationNewFollower, userID, "", []string{ownerID})
+	return nil
+}
+
+func (m *ConfigManagerMemory) UnfollowAuthor(ctx context.Context, ownerID string) error {
+	userID, err := checkFollow(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.follows[userID][ownerID]; !ok {
+		return nil // not following, nothing to do
+	}
+	delete(m.follows[userID], ownerID)
+	m.followers[ownerID]--
+	return nil
+}
+
+func (m *ConfigManagerMemory) ListFollowing(ctx context.Context) ([]FollowedAuthor, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	following := make([]FollowedAuthor, 0, len(m.follows[user.UserID]))
+	for id, at := range m.follows[user.UserID] {
+		following = append(following, FollowedAuthor{UserID: id, FollowedAt: at})
+	}
+	sortFollowing(following)
+	setFollowedAuthors(following, m.authorsOf(followedIDs(following)))
+	return following, nil
+}
+
+func (m *ConfigManagerMemory) ListFollowedFeed(ctx context.Context, page, limit int) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	m.mu.RLock()
+	followees := make(map[string]struct{}, len(m.follows[user.UserID]))
+	for id := range m.follows[user.UserID] {
+		followees[id] = struct{}{}
+	}
+	m.mu.RUnlock()
+
+	return m.page(ctx, page, limit, func(cfg *HyprConfig) bool {
+		_, ok := followees[cfg.OwnerID]
+		return ok && !cfg.Private
+	})
+}
+
+func (m *ConfigManagerSQLite) FollowAuthor(ctx context.Context, ownerID string) error {
+	userID, err := checkFollow(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO follows (follower_id, followee_id, followed_at) VALUES (?, ?, ?)`,
+			userID, ownerID, time.Now().UnixNano())
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil // already following, ignore
+		}
+		if err := addFollowers(ctx, tx, ownerID, 1); err != nil {
+			return err
+		}
+		return notify(ctx, tx, NotificationNewFollower, userID, "", []string{ownerID})
+	})
+}
+
+func (m *ConfigManagerSQLite) UnfollowAuthor(ctx context.Context, ownerID string) error {
+	userID, err := checkFollow(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM follows WHERE follower_id = ? AND followee_id = ?`, userID, ownerID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil // not following, nothing to do
+		}
+		return addFollowers(ctx, tx, ownerID, -1)
+	})
+}
+
+// addFollowers changes the denormalized follower count of userID by delta.
+func addFollowers(ctx context.Context, q sqlQuerier, userID string, delta int64) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO follower_counts (user_id, followers) VALUES (?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET followers = followers + excluded.followers`,
+		userID, delta)
+	return err
+}
+
+// followers returns the denormalized follower count of userID.
+func (m *ConfigManagerSQLite) followers(ctx context.Context, userID string) (int64, error) {
+	var n int64
+	err := m.db.QueryRowContext(ctx, `SELECT followers FROM follower_counts WHERE user_id = ?`, userID).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (m *ConfigManagerSQLite) ListFollowing(ctx context.Context) ([]FollowedAuthor, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT followee_id, followed_at FROM follows WHERE follower_id = ? ORDER BY followed_at DESC, followee_id ASC`,
+		user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	following := []FollowedAuthor{}
+	for rows.Next() {
+		var f FollowedAuthor
+		var at int64
+		if err := rows.Scan(&f.UserID, &at); err != nil {
+			return nil, err
+		}
+		f.FollowedAt = time.Unix(0, at)
+		following = append(following, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	authors, err := m.authorsOf(ctx, followedIDs(following))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load followed authors: %w", err)
+	}
+	setFollowedAuthors(following, authors)
+	return following, nil
+}
+
+func (m *ConfigManagerSQLite) ListFollowedFeed(ctx context.Context, page, limit int) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return m.listConfigs(ctx, "private = 0 AND owner_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)", []any{user.UserID}, page, limit)
+}
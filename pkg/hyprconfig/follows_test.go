@@ -0,0 +1,72 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFollowAuthors(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := context.Background()
+		bob := asUser("bob")
+		alicePublic := newTestConfig(t, m, "alice", false)
+		newTestConfig(t, m, "alice", true)
+		carolPublic := newTestConfig(t, m, "carol", false)
+		newTestConfig(t, m, "dave", false)
+
+		for _, owner := range []string{"alice", "carol", "alice"} {
+			if err := m.FollowAuthor(bob, owner); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := m.FollowAuthor(bob, "bob"); !errors.Is(err, ErrValidation) {
+			t.Errorf("following yourself: got %v, want ErrValidation", err)
+		}
+		if err := m.FollowAuthor(context.Background(), "alice"); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("signed out: got %v, want ErrUnauthorized", err)
+		}
+
+		following, err := m.ListFollowing(bob)
+		if err != nil || len(following) != 2 {
+			t.Fatalf("following = %+v, %v", following, err)
+		}
+		if following[0].UserID != "carol" || following[1].UserID != "alice" {
+			t.Errorf("following = %+v, want carol then alice", following)
+		}
+
+		// Private configs and authors bob does not follow stay out of the feed
+		feed, err := m.ListFollowedFeed(bob, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := configIDs(feed.Items)
+		if feed.Total != 2 || !slices.Contains(ids, carolPublic.ID) || !slices.Contains(ids, alicePublic.ID) {
+			t.Errorf("feed = %d configs %v, want carol's and alice's public configs", feed.Total, ids)
+		}
+
+		if stats, err := m.GetAuthorStats(ctx, "alice"); err != nil || stats.Followers != 1 {
+			t.Errorf("alice's followers = %+v, %v, want 1", stats, err)
+		}
+		for range 2 {
+			if err := m.UnfollowAuthor(bob, "alice"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if stats, err := m.GetAuthorStats(ctx, "alice"); err != nil || stats.Followers != 0 {
+			t.Errorf("alice's followers after unfollowing = %+v, %v, want 0", stats, err)
+		}
+		if feed, err := m.ListFollowedFeed(bob, 1, 10); err != nil || feed.Total != 1 {
+			t.Errorf("feed after unfollowing = %+v, %v", feed, err)
+		}
+	})
+}
+
+func configIDs(configs []HyprConfig) []string {
+	ids := make([]string, 0, len(configs))
+	for _, cfg := range configs {
+		ids = append(ids, cfg.ID)
+	}
+	return ids
+}
@@ -0,0 +1,340 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxGalleryPictures is how many gallery pictures, uploaded or linked, a config may have.
+const MaxGalleryPictures = 12
+
+var (
+	ErrInvalidImage = errors.New("gallery images must be png, jpeg or webp")
+	ErrGalleryFull  = errors.New("gallery is full")
+)
+
+// galleryContentTypes are the accepted upload formats, as sniffed from the first bytes of the data.
+var galleryContentTypes = []string{"image/png", "image/jpeg", "image/webp"}
+
+// GalleryImage is a picture uploaded to a config's gallery. Its bytes live in the file store.
+type GalleryImage struct {
+	Index       int         `json:"index" bson:"index"` // used in URL, unique within the config
+	URL         string      `json:"url" bson:"url"`
+	ContentType string      `json:"content_type" bson:"content_type"`
+	FileContent FileContent `json:"file_content" bson:"file_content"` // FileID, Hash and Size, never Data
+}
+
+// GalleryImageURL is the path an uploaded gallery image is served from.
+func GalleryImageURL(configID string, index int) string {
+	return fmt.Sprintf("/config/%s/gallery/%d", configID, index)
+}
+
+// newGalleryImage validates data as the next gallery image of cfg. The returned image has no
+// FileID yet; the caller stores the data and then calls addGalleryImage.
+func newGalleryImage(cfg *HyprConfig, data []byte, limits SizeLimits) (GalleryImage, error) {
+	if len(cfg.GalleryPictures) >= MaxGalleryPictures {
+		return GalleryImage{}, fmt.Errorf("%w: a config can have at most %d pictures", ErrGalleryFull, MaxGalleryPictures)
+	}
+	if len(data) == 0 {
+		return GalleryImage{}, fmt.Errorf("%w, got an empty upload", ErrInvalidImage)
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(galleryContentTypes, contentType) {
+		return GalleryImage{}, fmt.Errorf("%w, got %s", ErrInvalidImage, contentType)
+	}
+	if err := limits.CheckFile(FileContent{Data: data, FileType: FileTypeImage}); err != nil {
+		return GalleryImage{}, err
+	}
+
+	index := 0
+	for _, img := range cfg.GalleryImages {
+		index = max(index, img.Index+1)
+	}
+	return GalleryImage{
+		Index:       index,
+		URL:         GalleryImageURL(cfg.ID, index),
+		ContentType: contentType,
+		FileContent: FileContent{FileType: FileTypeImage, Hash: ComputeHash(data), Size: int64(len(data))},
+	}, nil
+}
+
+// addGalleryImage appends a stored image to cfg's gallery.
+func addGalleryImage(cfg *HyprConfig, img GalleryImage) {
+	cfg.GalleryImages = append(cfg.GalleryImages, img)
+	cfg.GalleryPictures = append(cfg.GalleryPictures, img.URL)
+	cfg.UpdatedTimestamp = time.Now()
+}
+
+// findGalleryImage returns the uploaded image of cfg with the given index, or nil.
+func findGalleryImage(cfg *HyprConfig, index int) *GalleryImage {
+	for i := range cfg.GalleryImages {
+		if cfg.GalleryImages[i].Index == index {
+			return &cfg.GalleryImages[i]
+		}
+	}
+	return nil
+}
+
+// removeGalleryImage drops the uploaded image with the given index and its URL from cfg.
+func removeGalleryImage(cfg *HyprConfig, index int) (GalleryImage, error) {
+	img := findGalleryImage(cfg, index)
+	if img == nil {
+		return GalleryImage{}, fmt.Errorf("gallery image %d %w", index, ErrNotFound)
+	}
+	removed := *img
+	cfg.GalleryImages = slices.DeleteFunc(cfg.GalleryImages, func(g GalleryImage) bool { return g.Index == index })
+	cfg.GalleryPictures = slices.DeleteFunc(cfg.GalleryPictures, func(url string) bool { return url == removed.URL })
+	cfg.UpdatedTimestamp = time.Now()
+	return removed, nil
+}
+
+// galleryFileIDs returns the file store ids of every uploaded image of cfg.
+func galleryFileIDs(cfg *HyprConfig) []string {
+	ids := make([]string, 0, len(cfg.GalleryImages))
+	for _, img := range cfg.GalleryImages {
+		ids = append(ids, img.FileContent.FileID)
+	}
+	return ids
+}
+
+// openGalleryImage reads an uploaded image of cfg from files and verifies its hash.
+func openGalleryImage(ctx context.Context, files FileStore, cfg *HyprConfig, index int) (io.ReadCloser, *GalleryImage, error) {
+	img := findGalleryImage(cfg, index)
+	if img == nil {
+		return nil, nil, fmt.Errorf("gallery image %d %w", index, ErrNotFound)
+	}
+	if files == nil {
+		return nil, nil, ErrFileStoreDisabled
+	}
+	rc, err := files.Open(ctx, img.FileContent.FileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read stored gallery image: %w", err)
+	}
+	// A mismatch means the stored data is corrupt, which is not the caller's fault
+	if ComputeHash(data) != img.FileContent.Hash {
+		return nil, nil, fmt.Errorf("stored gallery image %d of config %s does not match its hash", index, cfg.ID)
+	}
+	out := *img
+	return io.NopCloser(bytes.NewReader(data)), &out, nil
+}
+
+func galleryAuditEntry(user *session.UserSessionData, configID string) AuditEntry {
+	return newAuditEntry(user.UserID, AuditUpdateConfig, configID, "", []string{"gallery_pictures"})
+}
+
+// loadGallery loads the gallery of a config the signed-in user may modify.
+func (m *ConfigManagerMongo) loadGallery(ctx context.Context, configID string) (*HyprConfig, *session.UserSessionData, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cfg HyprConfig
+	err = m.Collection.FindOne(ctx, bson.M{"_id": configID}, options.FindOne().SetProjection(bson.M{
+		"owner_id":         1,
+		"gallery_pictures": 1,
+		"gallery_images":   1,
+	})).Decode(&cfg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if !canWrite(&cfg, user) {
+		return nil, nil, ErrForbidden
+	}
+	return &cfg, user, nil
+}
+
+// AddGalleryImage stores an uploaded png, jpeg or webp image and appends its URL to the
+// config's GalleryPictures.
+func (m *ConfigManagerMongo) AddGalleryImage(ctx context.Context, configID string, data []byte) (_ *GalleryImage, err error) {
+	defer func() {
+		m.logMutation(ctx, "AddGalleryImage", err, slog.String("config_id", configID), slog.Int("bytes", len(data)))
+	}()
+	cfg, user, err := m.loadGallery(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	img, err := newGalleryImage(cfg, data, m.limits)
+	if err != nil {
+		return nil, err
+	}
+	if m.files == nil {
+		return nil, ErrFileStoreDisabled
+	}
+	if img.FileContent.FileID, err = m.files.Put(ctx, fmt.Sprintf("%s-gallery-%d", configID, img.Index), data); err != nil {
+		return nil, err
+	}
+
+	// The index filter keeps a concurrent upload from claiming the same index
+	res, err := m.Collection.UpdateOne(ctx,
+		bson.M{"_id": configID, "gallery_images.index": bson.M{"$ne": img.Index}},
+		bson.M{
+			"$push": bson.M{"gallery_images": img, "gallery_pictures": img.URL},
+			"$set":  bson.M{"updated_timestamp": time.Now()},
+		},
+	)
+	if err == nil && res.MatchedCount == 0 {
+		err = fmt.Errorf("gallery of config %s changed during the upload, try again", configID)
+	}
+	if err != nil {
+		m.deleteFiles(ctx, []string{img.FileContent.FileID})
+		return nil, err
+	}
+	m.recordMutation(ctx, galleryAuditEntry(user, configID))
+	return &img, nil
+}
+
+// GetGalleryImage streams an uploaded gallery image of a config the caller may read.
+func (m *ConfigManagerMongo) GetGalleryImage(ctx context.Context, configID string, index int) (io.ReadCloser, *GalleryImage, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openGalleryImage(ctx, m.files, cfg, index)
+}
+
+// RemoveGalleryImage removes an uploaded gallery image, its URL and its stored data.
+func (m *ConfigManagerMongo) RemoveGalleryImage(ctx context.Context, configID string, index int) (err error) {
+	defer func() {
+		m.logMutation(ctx, "RemoveGalleryImage", err, slog.String("config_id", configID), slog.Int("index", index))
+	}()
+	cfg, user, err := m.loadGallery(ctx, configID)
+	if err != nil {
+		return err
+	}
+	img, err := removeGalleryImage(cfg, index)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.Collection.UpdateOne(ctx,
+		bson.M{"_id": configID},
+		bson.M{
+			"$pull": bson.M{"gallery_images": bson.M{"index": index}, "gallery_pictures": img.URL},
+			"$set":  bson.M{"updated_timestamp": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	m.recordMutation(ctx, galleryAuditEntry(user, configID))
+	m.deleteFiles(ctx, []string{img.FileContent.FileID})
+	return nil
+}
+
+// AddGalleryImage stores an uploaded png, jpeg or webp image and appends its URL to the
+// config's GalleryPictures.
+func (m *ConfigManagerMemory) AddGalleryImage(ctx context.Context, configID string, data []byte) (*GalleryImage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	img, err := newGalleryImage(cfg, data, m.limits)
+	if err != nil {
+		return nil, err
+	}
+	if img.FileContent.FileID, err = m.files.Put(ctx, fmt.Sprintf("%s-gallery-%d", configID, img.Index), data); err != nil {
+		return nil, err
+	}
+	addGalleryImage(cfg, img)
+	if err := m.storeAudited(cfg, galleryAuditEntry(user, configID)); err != nil {
+		_ = m.files.Delete(ctx, img.FileContent.FileID)
+		return nil, err
+	}
+	return &img, nil
+}
+
+// GetGalleryImage streams an uploaded gallery image of a config the caller may read.
+func (m *ConfigManagerMemory) GetGalleryImage(ctx context.Context, configID string, index int) (io.ReadCloser, *GalleryImage, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openGalleryImage(ctx, m.files, cfg, index)
+}
+
+// RemoveGalleryImage removes an uploaded gallery image, its URL and its stored data.
+func (m *ConfigManagerMemory) RemoveGalleryImage(ctx context.Context, configID string, index int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return err
+	}
+	img, err := removeGalleryImage(cfg, index)
+	if err != nil {
+		return err
+	}
+	if err := m.storeAudited(cfg, galleryAuditEntry(user, configID)); err != nil {
+		return err
+	}
+	return m.files.Delete(ctx, img.FileContent.FileID)
+}
+
+// AddGalleryImage stores an uploaded png, jpeg or webp image and appends its URL to the
+// config's GalleryPictures.
+func (m *ConfigManagerSQLite) AddGalleryImage(ctx context.Context, configID string, data []byte) (*GalleryImage, error) {
+	var img GalleryImage
+	err := m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		var err error
+		if img, err = newGalleryImage(cfg, data, m.limits); err != nil {
+			return AuditEntry{}, err
+		}
+		files := sqliteFileStore{tx}
+		if img.FileContent.FileID, err = files.Put(ctx, fmt.Sprintf("%s-gallery-%d", configID, img.Index), data); err != nil {
+			return AuditEntry{}, err
+		}
+		addGalleryImage(cfg, img)
+		return galleryAuditEntry(user, configID), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &img, nil
+}
+
+// GetGalleryImage streams an uploaded gallery image of a config the caller may read.
+func (m *ConfigManagerSQLite) GetGalleryImage(ctx context.Context, configID string, index int) (io.ReadCloser, *GalleryImage, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openGalleryImage(ctx, sqliteFileStore{m.db}, cfg, index)
+}
+
+// RemoveGalleryImage removes an uploaded gallery image, its URL and its stored data.
+func (m *ConfigManagerSQLite) RemoveGalleryImage(ctx context.Context, configID string, index int) error {
+	return m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		img, err := removeGalleryImage(cfg, index)
+		if err != nil {
+			return AuditEntry{}, err
+		}
+		if err := (sqliteFileStore{tx}).Delete(ctx, img.FileContent.FileID); err != nil {
+			return AuditEntry{}, err
+		}
+		return galleryAuditEntry(user, configID), nil
+	})
+}
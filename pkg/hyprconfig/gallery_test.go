@@ -0,0 +1,143 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+var (
+	testPNG  = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{1}, 64)...)
+	testJPEG = append([]byte("\xff\xd8\xff\xe0"), bytes.Repeat([]byte{2}, 64)...)
+	testWebP = append([]byte("RIFF\x00\x00\x00\x00WEBPVP8 "), bytes.Repeat([]byte{3}, 64)...)
+)
+
+// storedFileCount returns how many files the backend of m holds.
+func storedFileCount(t *testing.T, m ConfigManager) int {
+	t.Helper()
+	switch m := m.(type) {
+	case *ConfigManagerMemory:
+		return len(m.files.(*memFileStore).files)
+	case *ConfigManagerSQLite:
+		var n int
+		if err := m.db.QueryRow(`SELECT COUNT(*) FROM files`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	t.Fatalf("unknown manager %T", m)
+	return 0
+}
+
+func TestManagerGallery(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", false)
+		ctx := asUser("alice")
+
+		var added []*GalleryImage
+		for _, data := range [][]byte{testPNG, testJPEG, testWebP} {
+			img, err := m.AddGalleryImage(ctx, cfg.ID, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			added = append(added, img)
+		}
+		if added[2].Index != 2 || added[2].URL != GalleryImageURL(cfg.ID, 2) || added[2].ContentType != "image/webp" {
+			t.Errorf("third image = %+v", added[2])
+		}
+
+		got, err := m.GetConfig(context.Background(), cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.GalleryPictures) != 3 || got.GalleryPictures[1] != added[1].URL {
+			t.Errorf("gallery pictures = %v", got.GalleryPictures)
+		}
+
+		rc, img, err := m.GetGalleryImage(context.Background(), cfg.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if !bytes.Equal(data, testJPEG) || img.ContentType != "image/jpeg" {
+			t.Errorf("image 1 = %s, %d bytes", img.ContentType, len(data))
+		}
+
+		if _, err := m.AddGalleryImage(asUser("bob"), cfg.ID, testPNG); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner upload: got %v, want ErrForbidden", err)
+		}
+		if err := m.RemoveGalleryImage(asUser("bob"), cfg.ID, 0); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner delete: got %v, want ErrForbidden", err)
+		}
+		for name, data := range map[string][]byte{
+			"text":  []byte("not an image"),
+			"gif":   []byte("GIF89a..."),
+			"empty": nil,
+		} {
+			if _, err := m.AddGalleryImage(ctx, cfg.ID, data); !errors.Is(err, ErrInvalidImage) {
+				t.Errorf("%s upload: got %v, want ErrInvalidImage", name, err)
+			}
+		}
+		huge := append(append([]byte{}, testPNG...), make([]byte, m.SizeLimits().MaxImageBytes)...)
+		if _, err := m.AddGalleryImage(ctx, cfg.ID, huge); !errors.Is(err, ErrContentTooLarge) {
+			t.Errorf("oversized upload: got %v, want ErrContentTooLarge", err)
+		}
+
+		if err := m.RemoveGalleryImage(ctx, cfg.ID, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := m.GetGalleryImage(ctx, cfg.ID, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("removed image: got %v, want ErrNotFound", err)
+		}
+		if err := m.RemoveGalleryImage(ctx, cfg.ID, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("removing twice: got %v, want ErrNotFound", err)
+		}
+		if got, _ := m.GetConfig(ctx, cfg.ID); len(got.GalleryPictures) != 2 || len(got.GalleryImages) != 2 {
+			t.Errorf("after removal: %v", got.GalleryPictures)
+		}
+		if n := storedFileCount(t, m); n != 2 {
+			t.Errorf("stored files after removal = %d, want 2", n)
+		}
+
+		if err := m.DeleteConfig(ctx, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if n := storedFileCount(t, m); n != 0 {
+			t.Errorf("stored files after deleting the config = %d, want 0", n)
+		}
+	})
+}
+
+func TestGalleryFull(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", false)
+		ctx := asUser("alice")
+		for i := range MaxGalleryPictures {
+			if _, err := m.AddGalleryImage(ctx, cfg.ID, testPNG); err != nil {
+				t.Fatalf("image %d: %v", i, err)
+			}
+		}
+		if _, err := m.AddGalleryImage(ctx, cfg.ID, testPNG); !errors.Is(err, ErrGalleryFull) {
+			t.Errorf("got %v, want ErrGalleryFull", err)
+		}
+	})
+}
+
+func TestCreateConfigIgnoresGalleryImages(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg, err := m.CreateConfig(asUser("alice"), &HyprConfig{
+			Title:          "rice",
+			ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty"}},
+			GalleryImages:  []GalleryImage{{Index: 0, FileContent: FileContent{FileID: "someone-elses-file"}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cfg.GalleryImages) != 0 {
+			t.Errorf("gallery images taken from the request: %+v", cfg.GalleryImages)
+		}
+	})
+}
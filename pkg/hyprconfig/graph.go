@@ -0,0 +1,157 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// UnknownDependency is a dependency that is neither a program in the config nor an allowed program.
+type UnknownDependency struct {
+	Program    string `json:"program"`
+	Dependency string `json:"dependency"`
+}
+
+// GraphReport is the result of ValidateGraph.
+type GraphReport struct {
+	Cycles              [][]string          `json:"cycles,omitempty"`
+	UnknownDependencies []UnknownDependency `json:"unknown_dependencies,omitempty"`
+}
+
+// dependencyGraph maps a program to the programs it requires. Edges come from Dependencies and
+// from parent programs to the programs of their SubConfigs.
+func (hc *HyprConfig) dependencyGraph() map[string]map[string]struct{} {
+	graph := map[string]map[string]struct{}{}
+	addEdge := func(from, to string) {
+		if graph[from] == nil {
+			graph[from] = map[string]struct{}{}
+		}
+		if to != "" && to != from {
+			graph[from][to] = struct{}{}
+		}
+	}
+
+	var visit func(pc *HyprProgramConfig)
+	visit = func(pc *HyprProgramConfig) {
+		from := NormalizeProgramName(pc.Program)
+		addEdge(from, "")
+		for _, dep := range pc.Dependencies {
+			addEdge(from, NormalizeProgramName(dep))
+		}
+		for _, sub := range pc.SubConfigs {
+			if sub == nil {
+				continue
+			}
+			addEdge(from, NormalizeProgramName(sub.Program))
+			visit(sub)
+		}
+	}
+	for i := range hc.ProgramConfigs {
+		visit(&hc.ProgramConfigs[i])
+	}
+	return graph
+}
+
+// ValidateGraph checks the dependency graph of the config. Cycles are returned as an error
+// (and listed in the report); dependencies that are neither programs in the config nor on the
+// built-in allowlist are reported as unknown.
+func (hc *HyprConfig) ValidateGraph() (GraphReport, error) {
+	graph := hc.dependencyGraph()
+	var report GraphReport
+
+	nodes := make([]string, 0, len(graph))
+	for n := range graph {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	// Depth first search; a grey node reached again closes a cycle
+	const (
+		white = iota
+		grey
+		black
+	)
+	color := map[string]int{}
+	var stack []string
+	var dfs func(n string)
+	dfs = func(n string) {
+		color[n] = grey
+		stack = append(stack, n)
+		for _, next := range sortedSet(graph[n]) {
+			switch color[next] {
+			case white:
+				dfs(next)
+			case grey:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == next {
+						cycle := append(append([]string{}, stack[i:]...), next)
+						report.Cycles = append(report.Cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[n] = black
+	}
+	for _, n := range nodes {
+		if color[n] == white {
+			dfs(n)
+		}
+	}
+
+	hc.Walk(func(pc *HyprProgramConfig) {
+		for _, dep := range pc.Dependencies {
+			dep = NormalizeProgramName(dep)
+			if _, inConfig := graph[dep]; inConfig {
+				continue
+			}
+			if builtinProgram(dep) {
+				continue
+			}
+			report.UnknownDependencies = append(report.UnknownDependencies, UnknownDependency{Program: pc.Program, Dependency: dep})
+		}
+	})
+
+	if len(report.Cycles) > 0 {
+		return report, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(report.Cycles[0], " -> "))
+	}
+	return report, nil
+}
+
+// validateGraph runs ValidateGraph, drops unknown dependencies that are allowed in the database,
+// and records the rest as warnings.
+func (hc *HyprConfig) validateGraph(programs programSet) error {
+	report, err := hc.ValidateGraph()
+	if err != nil {
+		return err
+	}
+
+	unknown := report.UnknownDependencies[:0]
+	for _, u := range report.UnknownDependencies {
+		if programs.has(u.Dependency) {
+			continue
+		}
+		unknown = append(unknown, u)
+		hc.Warnings = append(hc.Warnings, fmt.Sprintf("program %s depends on %s, which is not an allowed program or part of this config", u.Program, u.Dependency))
+	}
+	report.UnknownDependencies = unknown
+
+	hc.DependencyReport = nil
+	if len(unknown) > 0 {
+		hc.DependencyReport = &report
+	}
+	return nil
+}
+
+func sortedSet(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
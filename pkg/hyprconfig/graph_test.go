@@ -0,0 +1,78 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateGraphCycle(t *testing.T) {
+	cfg := HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "bar", Program: "waybar", Dependencies: []string{"wofi"}},
+			{Title: "launcher", Program: "wofi", Dependencies: []string{"Waybar"}},
+		},
+	}
+	report, err := cfg.ValidateGraph()
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("got %v, want ErrDependencyCycle", err)
+	}
+	want := [][]string{{"waybar", "wofi", "waybar"}}
+	if !reflect.DeepEqual(report.Cycles, want) {
+		t.Errorf("cycles = %v, want %v", report.Cycles, want)
+	}
+	if err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{}); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Validate: got %v, want ErrDependencyCycle", err)
+	}
+}
+
+func TestValidateGraphSubConfigEdges(t *testing.T) {
+	cfg := HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "hyprland", Program: "hyprland", SubConfigs: []*HyprProgramConfig{
+				{Title: "binds", Program: "hyprland"},
+				{Title: "bar", Program: "waybar", Dependencies: []string{"hyprland"}},
+			}},
+		},
+	}
+	if _, err := cfg.ValidateGraph(); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("child depending on its parent: got %v, want ErrDependencyCycle", err)
+	}
+
+	cfg.ProgramConfigs[0].SubConfigs[1].Dependencies = nil
+	if _, err := cfg.ValidateGraph(); err != nil {
+		t.Errorf("same-program sub-config should not be a cycle, got %v", err)
+	}
+}
+
+func TestValidateGraphUnknownDependencies(t *testing.T) {
+	cfg := HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "bar", Program: "waybar", Dependencies: []string{"kitty", "wofi", "mytool", "dbtool"}},
+			{Title: "launcher", Program: "wofi"},
+		},
+	}
+	report, err := cfg.ValidateGraph()
+	if err != nil {
+		t.Fatalf("ValidateGraph returned error: %v", err)
+	}
+	want := []UnknownDependency{{Program: "waybar", Dependency: "mytool"}, {Program: "waybar", Dependency: "dbtool"}}
+	if !reflect.DeepEqual(report.UnknownDependencies, want) {
+		t.Errorf("unknown = %v, want %v", report.UnknownDependencies, want)
+	}
+
+	if err := cfg.Validate(context.Background(), allowOnly("dbtool"), SizeLimits{}); err != nil {
+		t.Fatalf("unknown dependencies should not fail validation, got %v", err)
+	}
+	if cfg.DependencyReport == nil || len(cfg.DependencyReport.UnknownDependencies) != 1 {
+		t.Fatalf("dependency report = %+v, want only mytool", cfg.DependencyReport)
+	}
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], "mytool") {
+		t.Errorf("warnings = %v", cfg.Warnings)
+	}
+}
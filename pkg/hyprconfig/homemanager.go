@@ -0,0 +1,314 @@
+package hyprconfig
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExportFormatHomeManager exports a config as a NixOS home-manager module, see ExportHomeManager.
+const ExportFormatHomeManager = "home-manager"
+
+// hmSettingsPath is the only Hyprland config home-manager generates from its settings.
+const hmSettingsPath = ".config/hypr/hyprland.conf"
+
+var (
+	// nixIdentifierRe matches attribute names that don't need quoting in Nix.
+	nixIdentifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_'-]*$`)
+
+	// nixPackageRe matches package names that can be written as an attribute path below pkgs.
+	nixPackageRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_'-]*(\.[A-Za-z_][A-Za-z0-9_'-]*)*$`)
+
+	// hyprListKeywordRe matches the Hyprland keywords that may be repeated, which home-manager
+	// takes as lists even when a config sets them once.
+	hyprListKeywordRe = regexp.MustCompile(`^(bind[a-z]*|unbind|exec(-once|-shutdown)?|execr(-once)?|monitor|workspace|windowrule(v2)?|layerrule|env|source|bezier|animation|gesture|plugin|permission)$`)
+)
+
+// nixKeywords are the Nix keywords that have to be quoted as attribute names.
+var nixKeywords = map[string]struct{}{
+	"assert": {}, "else": {}, "if": {}, "in": {}, "inherit": {}, "let": {}, "or": {}, "rec": {}, "then": {}, "with": {},
+}
+
+// nixAttrs is an attribute set keeping the order its attributes were set in. Values are
+// strings, lists of strings or nested sets.
+type nixAttrs struct {
+	keys   []string
+	values map[string]any
+}
+
+func newNixAttrs() *nixAttrs {
+	return &nixAttrs{values: map[string]any{}}
+}
+
+// section returns the nested set name, creating it. ok is false when name holds a value.
+func (a *nixAttrs) section(name string) (*nixAttrs, bool) {
+	switch v := a.values[name].(type) {
+	case nil:
+		child := newNixAttrs()
+		a.keys = append(a.keys, name)
+		a.values[name] = child
+		return child, true
+	case *nixAttrs:
+		return v, true
+	default:
+		return nil, false
+	}
+}
+
+// set adds value to key, turning repeated and list keywords into lists. ok is false when key
+// is a nested set.
+func (a *nixAttrs) set(key, value string) bool {
+	switch v := a.values[key].(type) {
+	case nil:
+		a.keys = append(a.keys, key)
+		if hyprListKeywordRe.MatchString(key) {
+			a.values[key] = []string{value}
+		} else {
+			a.values[key] = value
+		}
+	case string:
+		a.values[key] = []string{v, value}
+	case []string:
+		a.values[key] = append(v, value)
+	default:
+		return false
+	}
+	return true
+}
+
+// hyprlandSettings parses a Hyprland config into home-manager settings. ok is false for
+// constructs that settings can't express, such as lines that are neither keyword = value nor
+// a section, unbalanced braces or a keyword used both as a value and a section. Comments are
+// dropped.
+func hyprlandSettings(data []byte) (settings *nixAttrs, ok bool) {
+	stack := []*nixAttrs{newNixAttrs()}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") {
+			line = strings.TrimSpace(stripHyprComment(line))
+		}
+		current := stack[len(stack)-1]
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case line == "}":
+			if len(stack) == 1 {
+				return nil, false
+			}
+			stack = stack[:len(stack)-1]
+		case strings.HasSuffix(line, "{"):
+			name := strings.TrimSpace(strings.TrimSuffix(line, "{"))
+			if name == "" || strings.ContainsAny(name, "={}") {
+				return nil, false
+			}
+			child, ok := current.section(name)
+			if !ok {
+				return nil, false
+			}
+			stack = append(stack, child)
+		default:
+			key, value, found := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !found || key == "" || strings.ContainsAny(key, "{}") || !current.set(key, strings.TrimSpace(value)) {
+				return nil, false
+			}
+		}
+	}
+	if len(stack) != 1 {
+		return nil, false
+	}
+	return stack[0], true
+}
+
+// nixAttrName writes an attribute name, quoted unless it is a plain identifier.
+func nixAttrName(name string) string {
+	if _, keyword := nixKeywords[name]; nixIdentifierRe.MatchString(name) && !keyword {
+		return name
+	}
+	return nixString(name)
+}
+
+// nixString writes s as a double-quoted Nix string.
+func nixString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// nixText writes file contents as an indented Nix string, or a double-quoted one when Nix
+// would change the text by stripping indentation, adding a final newline or dropping carriage
+// returns.
+func nixText(data, indent string) string {
+	indented := strings.HasSuffix(data, "\n") && !strings.Contains(data, "\r")
+	if indented {
+		// Nix strips the indentation common to every line, which has to be only the one added here
+		common := true
+		for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") {
+				common = false
+				break
+			}
+		}
+		indented = !common
+	}
+	if !indented {
+		return nixString(data)
+	}
+
+	escape := strings.NewReplacer("''", "'''", "${", "''${")
+	var b strings.Builder
+	b.WriteString("''\n")
+	for _, line := range strings.Split(strings.TrimSuffix(data, "\n"), "\n") {
+		if line != "" {
+			b.WriteString(indent + "  " + escape.Replace(line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + "''")
+	return b.String()
+}
+
+// writeNixAttrs writes the attributes of a as indented Nix bindings.
+func writeNixAttrs(b *strings.Builder, a *nixAttrs, indent string) {
+	for _, key := range a.keys {
+		fmt.Fprintf(b, "%s%s = ", indent, nixAttrName(key))
+		switch v := a.values[key].(type) {
+		case string:
+			b.WriteString(nixString(v))
+		case []string:
+			b.WriteString("[\n")
+			for _, item := range v {
+				b.WriteString(indent + "  " + nixString(item) + "\n")
+			}
+			b.WriteString(indent + "]")
+		case *nixAttrs:
+			b.WriteString("{\n")
+			writeNixAttrs(b, v, indent+"  ")
+			b.WriteString(indent + "}")
+		}
+		b.WriteString(";\n")
+	}
+}
+
+// ExportHomeManager returns cfg as a NixOS home-manager module. The Hyprland config becomes
+// wayland.windowManager.hyprland.settings, every other file an xdg.configFile entry, or a
+// home.file entry outside ~/.config, and the programs and dependencies home.packages. Files
+// home-manager can't take as text, such as images and offloaded files, and Hyprland configs
+// settings can't express are left as comments or installed verbatim with one.
+// WithPackageNames maps programs to their nixpkgs attribute names, on top of the built-in
+// ones, and IncludeOptional adds the packages of optional program configs.
+func ExportHomeManager(cfg *HyprConfig, opts ...InstallScriptOption) (string, error) {
+	return exportHomeManager(cfg, nil, nil, opts...)
+}
+
+// exportHomeManager is ExportHomeManager with template values and extra install prefixes, as
+// for RenderConfig.
+func exportHomeManager(cfg *HyprConfig, values map[string]string, extraPrefixes []string, opts ...InstallScriptOption) (string, error) {
+	files, err := RenderConfig(cfg, values, extraPrefixes...)
+	if err != nil {
+		return "", err
+	}
+
+	o := &installScriptOptions{programs: map[string]AllowedPrograms{}}
+	for name, packages := range builtinProgramPackages {
+		o.programs[name] = AllowedPrograms{ProgramName: name, Packages: packages}
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	pkgs, err := installPackages(cfg, DistroNixOS, o)
+	if err != nil {
+		return "", err
+	}
+
+	settings, hasSettings := hyprlandSettings(files[hmSettingsPath])
+	hasSettings = hasSettings && len(files[hmSettingsPath]) > 0
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# home-manager module for %q, generated by hypr-config-manager\n", cfg.Title)
+	b.WriteString("{ pkgs, ... }:\n\n{\n")
+
+	var packages []string
+	for _, p := range pkgs {
+		// home-manager installs Hyprland itself when its settings are used
+		if hasSettings && p == hyprlandProgram {
+			continue
+		}
+		if !nixPackageRe.MatchString(p) {
+			p = "pkgs." + nixString(p)
+		}
+		packages = append(packages, p)
+	}
+	if len(packages) > 0 {
+		b.WriteString("  home.packages = with pkgs; [\n")
+		for _, p := range packages {
+			b.WriteString("    " + p + "\n")
+		}
+		b.WriteString("  ];\n")
+	}
+
+	if hasSettings {
+		b.WriteString("\n  wayland.windowManager.hyprland = {\n    enable = true;\n    settings = {\n")
+		writeNixAttrs(&b, settings, "      ")
+		b.WriteString("    };\n  };\n")
+	}
+
+	modes := FileModes(cfg, extraPrefixes...)
+	seen := map[string]bool{}
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		for _, e := range pc.FileEntries() {
+			p, err := pc.FilePath(e, extraPrefixes...)
+			data, inline := files[p]
+			if err != nil || seen[p] || (p == hmSettingsPath && hasSettings) || (!inline && e.FileContent.FileID == "") {
+				continue
+			}
+			seen[p] = true
+
+			attr := "home.file." + nixString(p)
+			if rest, ok := strings.CutPrefix(p, ".config/"); ok {
+				attr = "xdg.configFile." + nixString(rest)
+			}
+			b.WriteString("\n")
+			switch {
+			case !inline:
+				fmt.Fprintf(&b, "  # %s is stored separately; download it next to this module and uncomment:\n", p)
+				fmt.Fprintf(&b, "  # %s.source = ./%s;\n", attr, p)
+			case e.FileContent.FileType == FileTypeImage || e.FileContent.FileType == FileTypeBinary || !utf8.Valid(data):
+				fmt.Fprintf(&b, "  # %s isn't text; copy it next to this module and uncomment:\n", p)
+				fmt.Fprintf(&b, "  # %s.source = ./%s;\n", attr, p)
+			default:
+				if p == hmSettingsPath {
+					b.WriteString("  # Installed verbatim: this Hyprland config can't be expressed as home-manager settings\n")
+				}
+				if modes[p]&0o111 != 0 {
+					fmt.Fprintf(&b, "  %s = {\n    text = %s;\n    executable = true;\n  };\n", attr, nixText(string(data), "    "))
+				} else {
+					fmt.Fprintf(&b, "  %s.text = %s;\n", attr, nixText(string(data), "  "))
+				}
+			}
+		}
+	})
+
+	if commands := PostInstallCommands(cfg); len(commands) > 0 {
+		b.WriteString("\n  # Post-install commands aren't run by home-manager:\n")
+		for _, c := range commands {
+			fmt.Fprintf(&b, "  #   %s\n", c.Command)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// ExportHomeManager returns the home-manager module of a config the caller is allowed to see,
+// using the nixpkgs names from the allowed programs collection.
+func (m *ConfigManagerMongo) ExportHomeManager(ctx context.Context, configID string) (string, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return "", err
+	}
+	programs, err := m.packagePrograms(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	return exportHomeManager(cfg, templateValues(ctx), m.limits.ExtraInstallPrefixes, WithPackageNames(programs))
+}
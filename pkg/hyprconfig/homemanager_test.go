@@ -0,0 +1,81 @@
+package hyprconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkGolden compares got with the golden file name below testdata, rewriting it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s:\n%s", golden, got)
+	}
+}
+
+func TestExportHomeManagerGolden(t *testing.T) {
+	cfg := representativeConfig()
+	hypr := &cfg.ProgramConfigs[0]
+	hypr.Dependencies = []string{"swaync"}
+	hypr.FileContent.Data = append([]byte("$mod = SUPER\n# a comment\nbind = $mod, Return, exec, kitty\nbind = $mod, Q, killactive\n"), hypr.FileContent.Data...)
+	hypr.Files = []FileEntry{
+		{TargetPath: "~/Pictures/wall.png", FileContent: FileContent{FileType: FileTypeImage, Data: []byte("\x89PNG\r\n\x1a\n")}},
+		{TargetPath: "scripts/volume.sh", FileContent: FileContent{FileType: FileTypeScript, Data: []byte("#!/bin/sh\nwpctl set-volume @DEFAULT_SINK@ \"${1:-5%+}\"\n")}},
+	}
+	cfg.ProgramConfigs[1].PostInstall = []string{"kitty +kitten themes --reload-in=all"}
+
+	module, err := ExportHomeManager(cfg)
+	if err != nil {
+		t.Fatalf("ExportHomeManager: %v", err)
+	}
+	checkGolden(t, filepath.Join("homemanager", "representative.golden"), module)
+}
+
+func TestExportHomeManagerVerbatim(t *testing.T) {
+	cfg := &HyprConfig{Title: "odd", ProgramConfigs: []HyprProgramConfig{{
+		Program:     "hyprland",
+		FileContent: FileContent{FileType: FileTypeConfig, Data: []byte("general {\n    gaps_in = 5\n")},
+	}}}
+	module, err := ExportHomeManager(cfg)
+	if err != nil {
+		t.Fatalf("ExportHomeManager: %v", err)
+	}
+	for _, want := range []string{
+		"    hyprland\n",
+		"can't be expressed as home-manager settings",
+		"xdg.configFile.\"hypr/hyprland.conf\".text = ''\n    general {\n        gaps_in = 5\n",
+	} {
+		if !strings.Contains(module, want) {
+			t.Errorf("module misses %q:\n%s", want, module)
+		}
+	}
+	if strings.Contains(module, "wayland.windowManager.hyprland") {
+		t.Errorf("a config settings can't express still uses them:\n%s", module)
+	}
+
+	for data, want := range map[string]string{
+		"a\n  b\n":   "''\n    a\n      b\n  ''",
+		"  a\n":      `"  a\n"`,
+		"no eol":     `"no eol"`,
+		"x ${y}\n":   "''\n    x ''${y}\n  ''",
+		"'' and\n":   "''\n    ''' and\n  ''",
+		"a\r\nb\n":   `"a\r\nb\n"`,
+		"\tx\n\ny\n": "''\n    \tx\n\n    y\n  ''",
+	} {
+		if got := nixText(data, "  "); got != want {
+			t.Errorf("nixText(%q) = %q, want %q", data, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Limits on the post-install commands of a program config.
+const (
+	MaxPostInstallCommands      = 10
+	MaxPostInstallCommandLength = 512
+)
+
+// postInstallBinaries are the commands post-install hooks of public configs may run besides
+// allowed programs: reloading running programs and refreshing caches.
+var postInstallBinaries = map[string]struct{}{
+	"hyprctl":                 {},
+	"fc-cache":                {},
+	"gsettings":               {},
+	"gtk-update-icon-cache":   {},
+	"update-desktop-database": {},
+	"makoctl":                 {},
+	"swaync-client":           {},
+	"notify-send":             {},
+	"pkill":                   {},
+	"killall":                 {},
+}
+
+// PostInstallBinaries returns the commands post-install hooks may run besides allowed programs.
+func PostInstallBinaries() []string {
+	names := make([]string, 0, len(postInstallBinaries))
+	for name := range postInstallBinaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PostInstallCommand is a command a program config asks to run once its files are written.
+type PostInstallCommand struct {
+	ProgramID string `json:"program_id"`
+	Program   string `json:"program"`
+	Command   string `json:"command"`
+}
+
+// PostInstallCommands returns the post-install commands of cfg, parents before their sub
+// configs. They are kept apart from the files of RenderConfig so they can be confirmed before
+// anything runs.
+func PostInstallCommands(cfg *HyprConfig) []PostInstallCommand {
+	var commands []PostInstallCommand
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		for _, command := range pc.PostInstall {
+			commands = append(commands, PostInstallCommand{ProgramID: pc.ID, Program: pc.Program, Command: command})
+		}
+	})
+	return commands
+}
+
+// hookBinary returns the program a post-install command runs, normalized like a program name.
+func hookBinary(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return NormalizeProgramName(fields[0])
+}
+
+// hyprctlExecRe matches the hyprctl arguments that have Hyprland run a command of their own:
+// "dispatch exec", "keyword exec-once" and the like.
+var hyprctlExecRe = regexp.MustCompile(`(?i)[\s'";]exec`)
+
+// validatePostInstall trims the post-install commands of pc and records in verr every one that
+// is empty, too long, runs something other than an allowed program or one of
+// PostInstallBinaries by name, or has hyprctl exec another command. The latter two only fail
+// public configs.
+func (pc *HyprProgramConfig) validatePostInstall(verr *ValidationError, programs programSet) {
+	if len(pc.PostInstall) > MaxPostInstallCommands {
+		verr.addf("post_install", CodeTooMany, "%d post-install commands, at most %d allowed", len(pc.PostInstall), MaxPostInstallCommands)
+	}
+	for i := range pc.PostInstall {
+		path := fmt.Sprintf("post_install[%d]", i)
+		command := strings.TrimSpace(pc.PostInstall[i])
+		pc.PostInstall[i] = command
+		switch {
+		case command == "":
+			verr.addf(path, CodeRequired, "program %s: post-install command cannot be empty", pc.Program)
+			continue
+		case utf8.RuneCountInString(command) > MaxPostInstallCommandLength:
+			verr.addf(path, CodeTooLong, "program %s: post-install command is %d characters, at most %d allowed",
+				pc.Program, utf8.RuneCountInString(command), MaxPostInstallCommandLength)
+			continue
+		case strings.ContainsFunc(command, unicode.IsControl):
+			verr.addf(path, CodeInvalid, "program %s: post-install command must be a single line without control characters", pc.Program)
+			continue
+		}
+
+		if strings.Contains(strings.Fields(command)[0], "/") {
+			verr.add(path, fmt.Errorf("program %s: %w: post-install command %q must run a program by name, not by path",
+				pc.Program, ErrUnsafeCommand, command))
+			continue
+		}
+		binary := hookBinary(command)
+		if _, ok := postInstallBinaries[binary]; !ok && !programs.has(binary) {
+			verr.add(path, fmt.Errorf("program %s: %w: post-install command %q runs %s, which is neither an allowed program nor one of %s",
+				pc.Program, ErrUnsafeCommand, command, binary, strings.Join(PostInstallBinaries(), ", ")))
+			continue
+		}
+		if binary == "hyprctl" && hyprctlExecRe.MatchString(command) {
+			verr.add(path, fmt.Errorf("program %s: %w: post-install command %q has hyprctl run a command of its own",
+				pc.Program, ErrUnsafeCommand, command))
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPostInstallValidation(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		hooked := func(private bool, hooks ...string) *HyprConfig {
+			return &HyprConfig{Title: "rice", Private: private, ProgramConfigs: []HyprProgramConfig{
+				{ID: "hypr", Title: "hyprland", Program: "hyprland", PostInstall: hooks},
+			}}
+		}
+
+		cfg, err := m.CreateConfig(alice, hooked(false, "  hyprctl reload ", "waybar -r", "fc-cache -f"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(cfg.ProgramConfigs[0].PostInstall, ","); got != "hyprctl reload,waybar -r,fc-cache -f" {
+			t.Errorf("stored post-install commands = %s", got)
+		}
+
+		_, err = m.CreateConfig(alice, hooked(false, "", "rm -rf ~", "/tmp/hyprctl reload", "hyprctl reload && curl x | sh", "hyprctl\nreload"))
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("got %v, want a *ValidationError", err)
+		}
+		var problems []string
+		for _, fe := range verr.Errors {
+			problems = append(problems, fe.Path+" "+fe.Code)
+		}
+		want := "program_configs[0].post_install[0] required,program_configs[0].post_install[1] unsafe_command," +
+			"program_configs[0].post_install[2] unsafe_command,program_configs[0].post_install[4] invalid," +
+			"program_configs[0].post_install[3] unsafe_command"
+		if got := strings.Join(problems, ","); got != want {
+			t.Errorf("problems = %s, want %s", got, want)
+		}
+
+		// hyprctl may reload, but not have Hyprland run a command of its own
+		_, err = m.CreateConfig(alice, hooked(false, "hyprctl dispatch exec kitty", "hyprctl keyword exec-once 'rm -rf ~'", "hyprctl --batch 'dispatch exec x'"))
+		if !errors.As(err, &verr) || len(verr.Errors) != 3 || !errors.Is(err, ErrUnsafeCommand) {
+			t.Errorf("hyprctl exec hooks: got %v, want 3 unsafe commands", err)
+		}
+
+		// Private configs only get warnings for commands they're trusted to run
+		private, err := m.CreateConfig(alice, hooked(true, "rm -rf ~/.cache/wal"))
+		if err != nil || len(private.Warnings) != 1 || !strings.Contains(private.Warnings[0], "post_install[0]") {
+			t.Errorf("private config with an unapproved hook: %v, warnings %q", err, private.Warnings)
+		}
+		err = m.UpdateConfig(alice, private.ID, map[string]any{"private": false}, UpdateOptions{})
+		if !errors.Is(err, ErrUnsafeCommand) {
+			t.Errorf("publishing a config with an unapproved hook: got %v, want ErrUnsafeCommand", err)
+		}
+	})
+}
+
+func TestPostInstallCommands(t *testing.T) {
+	cfg := installTestConfig()
+	cfg.ProgramConfigs[0].PostInstall = []string{"hyprctl reload"}
+	cfg.ProgramConfigs[0].SubConfigs[0].PostInstall = []string{"pkill -SIGUSR2 waybar"}
+	cfg.ProgramConfigs[0].SubConfigs[1].PostInstall = []string{"pkill wofi"} // optional
+
+	hooks := PostInstallCommands(cfg)
+	if len(hooks) != 3 || hooks[0].Command != "hyprctl reload" || hooks[1].Program != "waybar" {
+		t.Errorf("PostInstallCommands = %+v", hooks)
+	}
+
+	script, err := GenerateInstallScript(cfg, DistroArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "yay\n\n# Post-install commands, run once the config files are in place\nhyprctl reload\npkill -SIGUSR2 waybar\n"
+	if !strings.HasSuffix(script, want) {
+		t.Errorf("script =\n%s\nwant it to end with\n%s", script, want)
+	}
+	// The script runs the same argv as apply, which runs hooks without a shell
+	cfg.ProgramConfigs[0].PostInstall = []string{"waybar > /tmp/x | sh $HOME `id` it's"}
+	script, err = GenerateInstallScript(cfg, DistroArch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\nwaybar '>' /tmp/x '|' sh '$HOME' '`id`' 'it'\\''s'\n"; !strings.Contains(script, want) {
+		t.Errorf("script =\n%s\nwant it to contain\n%s", script, want)
+	}
+}
@@ -0,0 +1,297 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of HyprNode.
+const (
+	HyprNodeBlank   = "blank"
+	HyprNodeComment = "comment" // a line holding only a comment
+	HyprNodeKeyword = "keyword" // key = value, including $variable = value
+	HyprNodeSection = "section" // name { ... }
+	HyprNodeRaw     = "raw"     // a line that is none of the above, kept as it is
+)
+
+// hyprIndent indents the nodes Bytes writes itself, per section level.
+const hyprIndent = "    "
+
+// HyprDoc is a Hyprland config parsed into a tree of nodes. Bytes writes it back out, with the
+// original text of every node that wasn't changed, so a parsed document is written back byte for
+// byte.
+type HyprDoc struct {
+	Nodes []*HyprNode
+}
+
+// HyprNode is a line of a Hyprland config, or several for a section or a value continued with a
+// trailing backslash.
+type HyprNode struct {
+	Kind string
+
+	// Key is the keyword of a keyword node, like gaps_in, exec-once or $mainMod, or the name of a
+	// section, like general or device:epic-mouse.
+	Key string
+
+	// Value is the value of a keyword node with its comment cut off and continuation lines
+	// joined by a space, or the text of a raw node. ## escapes are kept as written.
+	Value string
+
+	// Comment is the text of a comment, after the #: a comment node's, or one trailing a
+	// keyword line or the line opening a section.
+	Comment string
+
+	Line     int         // 1-based line the node starts on
+	Children []*HyprNode // the nodes of a section
+
+	raw    string // the node's original lines; a section's opening line only
+	close  string // a section's original closing line, empty when it was never closed
+	inline bool   // a section written on one line, like general { gaps_in = 5 }
+	parsed bool   // read by ParseHyprDoc rather than added
+	orig   struct{ key, value, comment string }
+}
+
+// ParseHyprDoc parses a Hyprland config. It never fails: lines it can't make sense of become
+// raw nodes, a } without a section is a raw node and sections left open end with the file.
+func ParseHyprDoc(data []byte) *HyprDoc {
+	root := &HyprNode{Kind: HyprNodeSection}
+	stack := []*HyprNode{root}
+	lines := strings.SplitAfter(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		parent := stack[len(stack)-1]
+		text := strings.TrimRight(line, "\r\n")
+		code, comment, _ := cutHyprComment(text)
+		trimmed := strings.TrimSpace(code)
+		n := &HyprNode{Line: i + 1, raw: line, Comment: strings.TrimSpace(comment)}
+
+		switch {
+		case strings.TrimSpace(text) == "":
+			n.Kind = HyprNodeBlank
+		case trimmed == "":
+			n.Kind = HyprNodeComment
+		case trimmed == "}" && len(stack) > 1:
+			parent.close = line
+			stack = stack[:len(stack)-1]
+			continue
+		case strings.HasSuffix(trimmed, "{") && sectionName(strings.TrimSuffix(trimmed, "{")) != "":
+			n.Kind, n.Key = HyprNodeSection, sectionName(strings.TrimSuffix(trimmed, "{"))
+			n.setOrig()
+			parent.Children = append(parent.Children, n)
+			stack = append(stack, n)
+			continue
+		case strings.HasSuffix(trimmed, "}") && strings.Contains(trimmed, "{"):
+			name, inner, _ := strings.Cut(strings.TrimSuffix(trimmed, "}"), "{")
+			key, value, ok := strings.Cut(inner, "=")
+			if sectionName(name) == "" || !ok || strings.TrimSpace(key) == "" {
+				n.Kind, n.Value = HyprNodeRaw, trimmed
+				break
+			}
+			n.Kind, n.Key, n.inline = HyprNodeSection, sectionName(name), true
+			child := &HyprNode{Kind: HyprNodeKeyword, Key: strings.TrimSpace(key), Value: strings.TrimSpace(value), Line: n.Line}
+			child.setOrig()
+			n.Children = []*HyprNode{child}
+		case strings.Contains(trimmed, "="):
+			key, value, _ := strings.Cut(trimmed, "=")
+			n.Kind, n.Key, n.Value = HyprNodeKeyword, strings.TrimSpace(key), strings.TrimSpace(value)
+			// A trailing backslash continues the value on the next line
+			for strings.HasSuffix(n.Value, `\`) && i+1 < len(lines) && lines[i+1] != "" {
+				i++
+				n.raw += lines[i]
+				next, c, _ := cutHyprComment(strings.TrimRight(lines[i], "\r\n"))
+				n.Value = strings.TrimSpace(strings.TrimSuffix(n.Value, `\`)) + " " + strings.TrimSpace(next)
+				if c = strings.TrimSpace(c); c != "" {
+					n.Comment = c
+				}
+			}
+		default:
+			n.Kind, n.Value = HyprNodeRaw, trimmed
+		}
+		n.setOrig()
+		parent.Children = append(parent.Children, n)
+	}
+	return &HyprDoc{Nodes: root.Children}
+}
+
+// sectionName returns the trimmed name of a section opened by name {, or "" when it can't be one.
+func sectionName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.ContainsAny(name, "={}") {
+		return ""
+	}
+	return name
+}
+
+func (n *HyprNode) setOrig() {
+	n.parsed = true
+	n.orig.key, n.orig.value, n.orig.comment = n.Key, n.Value, n.Comment
+}
+
+// changed reports whether n itself was added or edited since it was parsed, not counting its
+// children.
+func (n *HyprNode) changed() bool {
+	return !n.parsed || n.Key != n.orig.key || n.Value != n.orig.value || n.Comment != n.orig.comment
+}
+
+// treeChanged reports whether n or any node below it was added or changed, or an inline
+// section lost its keyword.
+func (n *HyprNode) treeChanged() bool {
+	if n.changed() || n.inline && len(n.Children) != 1 {
+		return true
+	}
+	for _, c := range n.Children {
+		if c.treeChanged() {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk calls fn with every node of d in file order and its path: the names of the sections it is
+// in and its own key joined by colons, like decoration:blur:size. Sections come before their
+// children.
+func (d *HyprDoc) Walk(fn func(path string, n *HyprNode)) {
+	walkHyprNodes(d.Nodes, "", fn)
+}
+
+func walkHyprNodes(nodes []*HyprNode, prefix string, fn func(path string, n *HyprNode)) {
+	for _, n := range nodes {
+		path := n.Key
+		if prefix != "" && n.Key != "" {
+			path = prefix + ":" + n.Key
+		} else if n.Key == "" {
+			path = prefix
+		}
+		fn(path, n)
+		if n.Kind == HyprNodeSection {
+			walkHyprNodes(n.Children, path, fn)
+		}
+	}
+}
+
+// Keywords returns the keyword nodes at path, like general:gaps_in or exec-once, in file order.
+func (d *HyprDoc) Keywords(path string) []*HyprNode {
+	var nodes []*HyprNode
+	d.Walk(func(p string, n *HyprNode) {
+		if n.Kind == HyprNodeKeyword && p == path {
+			nodes = append(nodes, n)
+		}
+	})
+	return nodes
+}
+
+// Variables returns the $variables declared in d, keyed with their $, like $mainMod, each with
+// its whole value as written. A variable declared twice keeps its last value.
+func (d *HyprDoc) Variables() map[string]string {
+	vars := map[string]string{}
+	d.Walk(func(_ string, n *HyprNode) {
+		if n.Kind == HyprNodeKeyword && hyprVariableRe.MatchString(n.Key) {
+			vars[n.Key] = n.Value
+		}
+	})
+	return vars
+}
+
+// Bytes writes d out as a Hyprland config. Nodes that weren't changed keep their original text,
+// formatting and comments included; new and changed ones are written one per line, indented by
+// section.
+func (d *HyprDoc) Bytes() []byte {
+	var b strings.Builder
+	writeHyprNodes(&b, d.Nodes, 0)
+	return []byte(b.String())
+}
+
+func writeHyprNodes(b *strings.Builder, nodes []*HyprNode, depth int) {
+	for _, n := range nodes {
+		// A node after the unterminated last line of the original starts on a line of its own
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		writeHyprNode(b, n, depth)
+	}
+}
+
+func writeHyprNode(b *strings.Builder, n *HyprNode, depth int) {
+	indent := strings.Repeat(hyprIndent, depth)
+	comment := ""
+	if n.Comment != "" {
+		comment = " # " + n.Comment
+	}
+
+	if n.Kind != HyprNodeSection {
+		switch {
+		case !n.changed() && n.raw != "":
+			b.WriteString(n.raw)
+		case n.Kind == HyprNodeBlank:
+			b.WriteString("\n")
+		case n.Kind == HyprNodeComment:
+			b.WriteString(indent + "#" + strings.TrimPrefix(comment, " #") + "\n")
+		case n.Kind == HyprNodeRaw:
+			b.WriteString(indent + n.Value + comment + "\n")
+		default:
+			b.WriteString(indent + n.Key + " = " + n.Value + comment + "\n")
+		}
+		return
+	}
+
+	if n.inline && !n.treeChanged() {
+		b.WriteString(n.raw)
+		return
+	}
+	if n.changed() || n.inline {
+		b.WriteString(indent + n.Key + " {" + comment + "\n")
+	} else {
+		b.WriteString(n.raw)
+	}
+	writeHyprNodes(b, n.Children, depth+1)
+	switch {
+	case n.close != "" && !n.inline:
+		if !strings.HasSuffix(b.String(), "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString(n.close)
+	case !n.parsed || n.inline:
+		b.WriteString(indent + "}\n")
+	}
+}
+
+// LintFinding is a problem found on a line of a Hyprland config.
+type LintFinding struct {
+	Line        int    `json:"line"`
+	Keyword     string `json:"keyword"`               // the keyword's path, e.g. decoration:drop_shadow
+	Kind        string `json:"kind"`                  // LintDeprecated, LintRemoved or LintInvalidValue
+	Replacement string `json:"replacement,omitempty"` // the keyword to use instead
+	Message     string `json:"message"`
+
+	// Where the finding is, set by LintConfig.
+	ProgramID string `json:"program_id,omitempty"`
+	File      string `json:"file,omitempty"`
+}
+
+// String describes the finding for a validation warning.
+func (f LintFinding) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("line %d: %s", f.Line, f.Message)
+	}
+	return fmt.Sprintf("%s line %d: %s", f.File, f.Line, f.Message)
+}
+
+// KeywordLinter checks a keyword node of a Hyprland config, given with its path as by Walk.
+type KeywordLinter func(path string, n *HyprNode) []LintFinding
+
+// Lint runs linters on every keyword node of d and returns their findings in file order.
+func (d *HyprDoc) Lint(linters ...KeywordLinter) []LintFinding {
+	var findings []LintFinding
+	d.Walk(func(path string, n *HyprNode) {
+		if n.Kind != HyprNodeKeyword {
+			return
+		}
+		for _, lint := range linters {
+			findings = append(findings, lint(path, n)...)
+		}
+	})
+	return findings
+}
@@ -0,0 +1,167 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func readHyprDocFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "hyprdoc", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestHyprDocRoundTrip(t *testing.T) {
+	for _, name := range []string{"hyprland.conf", "default.conf", "unclosed.conf", "crlf.conf"} {
+		data := readHyprDocFixture(t, name)
+		if got := ParseHyprDoc(data).Bytes(); string(got) != string(data) {
+			t.Errorf("%s: unchanged document written as\n%s", name, got)
+		}
+	}
+}
+
+func TestParseHyprDoc(t *testing.T) {
+	doc := ParseHyprDoc(readHyprDocFixture(t, "hyprland.conf"))
+
+	var got []string
+	doc.Walk(func(path string, n *HyprNode) {
+		switch n.Kind {
+		case HyprNodeKeyword, HyprNodeRaw:
+			got = append(got, fmt.Sprintf("%d %s %s=%s #%s", n.Line, n.Kind, path, n.Value, n.Comment))
+		case HyprNodeSection:
+			got = append(got, fmt.Sprintf("%d section %s #%s", n.Line, path, n.Comment))
+		}
+	})
+	want := []string{
+		"2 keyword source=~/.config/hypr/monitors.conf #",
+		"4 keyword $mainMod=SUPER #the windows key",
+		"5 keyword $terminal=kitty #",
+		"6 keyword $menu=wofi --show drun #",
+		"8 keyword monitor=,preferred,auto,1 #",
+		"10 keyword exec-once=waybar & hyprpaper #",
+		"11 keyword exec-once=uwsm app -- nm-applet --indicator #tray icon",
+		`14 keyword exec=notify-send "reloaded ##1" #`,
+		"16 section general #",
+		"17 keyword general:gaps_in=5 #",
+		"18 keyword general:gaps_out=20 #tabs and trailing spaces are kept",
+		"19 keyword general:col.active_border=rgba(33ccffee) rgba(00ff99ee) 45deg #",
+		"21 keyword general:layout=dwindle #",
+		"24 section decoration #",
+		"25 keyword decoration:rounding=10 #",
+		"26 section decoration:blur #",
+		"27 keyword decoration:blur:enabled=true #",
+		"28 keyword decoration:blur:size=3 #",
+		"30 keyword decoration:drop_shadow=yes #",
+		"33 section device:epic-mouse-v1 #",
+		"34 keyword device:epic-mouse-v1:sensitivity=-0.5 #",
+		"37 section input #",
+		"37 keyword input:kb_layout=us #",
+		"39 section animations #",
+		"40 keyword animations:bezier=myBezier, 0.05, 0.9, 0.1, 1.05 #",
+		"41 keyword animations:animation=windows, 1, 7, myBezier #",
+		"44 keyword bind=$mainMod, Q, exec, $terminal #",
+		"45 keyword bindd=$mainMod, R, Open the launcher, exec, $menu #",
+		"46 keyword bindm=$mainMod, mouse:272, movewindow #",
+		"47 keyword bind=$mainMod SHIFT, E, exit, #",
+		"49 keyword windowrulev2=float, class:^(pavucontrol)$ #",
+		"50 raw =this line is not hyprland syntax #",
+		"51 raw =} #",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("nodes:\n%q\nwant\n%q", got, want)
+	}
+
+	if n := doc.Keywords("decoration:blur:size"); len(n) != 1 || n[0].Value != "3" {
+		t.Errorf("Keywords(decoration:blur:size) = %+v", n)
+	}
+	if n := doc.Keywords("exec-once"); len(n) != 2 {
+		t.Errorf("Keywords(exec-once) returned %d nodes, want 2", len(n))
+	}
+}
+
+func TestHyprDocEdit(t *testing.T) {
+	doc := ParseHyprDoc(readHyprDocFixture(t, "hyprland.conf"))
+
+	doc.Keywords("general:gaps_in")[0].Value = "8"
+	doc.Keywords("general:gaps_out")[0].Comment = ""
+	doc.Keywords("input:kb_layout")[0].Value = "us,de"
+	doc.Walk(func(path string, n *HyprNode) {
+		switch path {
+		case "decoration":
+			n.Children = slices.DeleteFunc(n.Children, func(c *HyprNode) bool { return c.Key == "drop_shadow" })
+			n.Children = append(n.Children, &HyprNode{Kind: HyprNodeSection, Key: "shadow", Children: []*HyprNode{
+				{Kind: HyprNodeKeyword, Key: "enabled", Value: "true"},
+			}})
+		case "decoration:blur":
+			n.Children = append(n.Children, &HyprNode{Kind: HyprNodeKeyword, Key: "passes", Value: "2", Comment: "added"})
+		}
+	})
+	doc.Nodes = append(doc.Nodes, &HyprNode{Kind: HyprNodeComment, Comment: "added by the tests"})
+
+	checkGolden(t, filepath.Join("hyprdoc", "edited.golden"), string(doc.Bytes()))
+
+	// Edits survive another parse
+	again := ParseHyprDoc(doc.Bytes())
+	if n := again.Keywords("decoration:shadow:enabled"); len(n) != 1 {
+		t.Errorf("added section not parsed back: %q", doc.Bytes())
+	}
+}
+
+func TestHyprDocLint(t *testing.T) {
+	doc := ParseHyprDoc(readHyprDocFixture(t, "hyprland.conf"))
+	var paths []string
+	findings := doc.Lint(func(path string, n *HyprNode) []LintFinding {
+		paths = append(paths, path)
+		if path == "decoration:drop_shadow" {
+			return []LintFinding{{Line: n.Line, Keyword: path, Message: "removed"}}
+		}
+		return nil
+	})
+	want := []LintFinding{{Line: 30, Keyword: "decoration:drop_shadow", Message: "removed"}}
+	if !reflect.DeepEqual(findings, want) {
+		t.Errorf("findings = %+v, want %+v", findings, want)
+	}
+	if len(paths) != 25 {
+		t.Errorf("linter called for %d keywords, want 25: %q", len(paths), paths)
+	}
+}
+
+func TestExtractExecCommandsHyprDoc(t *testing.T) {
+	got := ExtractExecCommands(string(readHyprDocFixture(t, "hyprland.conf")))
+	want := []ExecCommand{
+		{Program: "waybar", Line: "waybar & hyprpaper"},
+		{Program: "hyprpaper", Line: "waybar & hyprpaper"},
+		{Program: "nm-applet", Line: "uwsm app -- nm-applet --indicator"},
+		{Program: "notify-send", Line: `notify-send "reloaded ##1"`},
+		{Program: "kitty", Line: "$terminal"},
+		{Program: "wofi", Line: "$menu"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractExecCommands = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestHyprDocVariables(t *testing.T) {
+	data := readHyprDocFixture(t, "default.conf")
+	want := map[string]string{
+		"$terminal":    "kitty",
+		"$fileManager": "dolphin",
+		"$menu":        "wofi --show drun",
+		"$mainMod":     "SUPER",
+	}
+	if got := ParseHyprDoc(data).Variables(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables = %q, want %q", got, want)
+	}
+
+	wantPrograms := []string{"waybar", "hyprpaper", "firefox", "kitty", "dolphin", "wofi", "wpctl", "playerctl"}
+	if got := ExtractExecOnceCommands(string(data)); !slices.Equal(got, wantPrograms) {
+		t.Errorf("ExtractExecOnceCommands = %q, want %q", got, wantPrograms)
+	}
+}
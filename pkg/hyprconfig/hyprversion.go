@@ -0,0 +1,117 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hyprlandVersionRe is what a Hyprland version may look like: major.minor with an optional
+// patch and leading v, e.g. 0.41, 0.41.2 or v0.45.0.
+var hyprlandVersionRe = regexp.MustCompile(`^v?(\d{1,3})\.(\d{1,3})(?:\.(\d{1,3}))?$`)
+
+// hyprlandSectionRe matches the line opening a section of a Hyprland config, e.g. render {.
+var hyprlandSectionRe = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_-]*)\s*\{`)
+
+// hyprlandFeature is config syntax that needs at least version of Hyprland.
+type hyprlandFeature struct {
+	keyword string // a keyword, or the section or category of a variable
+	version string
+}
+
+// hyprlandFeatures are keywords and sections added in recent Hyprland releases, used to guess
+// the minimum version a config needs. The list is a heuristic, not a full changelog.
+var hyprlandFeatures = []hyprlandFeature{
+	{keyword: "render", version: "0.42.0"},
+	{keyword: "ecosystem", version: "0.46.0"},
+	{keyword: "permission", version: "0.49.0"},
+	{keyword: "gesture", version: "0.51.0"},
+}
+
+// NormalizeHyprlandVersion returns a Hyprland version as major.minor.patch, e.g. v0.41 as 0.41.0.
+// Anything else is an ErrValidation.
+func NormalizeHyprlandVersion(v string) (string, error) {
+	m := hyprlandVersionRe.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return "", invalidf("invalid Hyprland version %q: use major.minor or major.minor.patch, e.g. 0.41.2", v)
+	}
+	if m[3] == "" {
+		m[3] = "0"
+	}
+	parts := make([]string, 3)
+	for i, s := range m[1:] {
+		n, _ := strconv.Atoi(s)
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// hyprlandVersionKey turns a normalized Hyprland version into a number that sorts like it, 0 for
+// none, so configs can be filtered by version in a query.
+func hyprlandVersionKey(v string) int64 {
+	if v == "" {
+		return 0
+	}
+	var key int64
+	for _, s := range strings.SplitN(v, ".", 3) {
+		n, _ := strconv.ParseInt(s, 10, 64)
+		key = key*1000 + n
+	}
+	return key
+}
+
+// normalizeHyprlandVersion canonicalizes MinHyprlandVersion and records an invalid one in verr.
+func (hc *HyprConfig) normalizeHyprlandVersion(verr *ValidationError) {
+	hc.MinHyprlandVersion = strings.TrimSpace(hc.MinHyprlandVersion)
+	if hc.MinHyprlandVersion == "" {
+		return
+	}
+	v, err := NormalizeHyprlandVersion(hc.MinHyprlandVersion)
+	if err != nil {
+		verr.add("min_hyprland_version", err)
+		return
+	}
+	hc.MinHyprlandVersion = v
+}
+
+// DetectMinHyprlandVersion guesses the oldest Hyprland release the Hyprland files of cfg work
+// with from the keywords and sections they use, see hyprlandFeatures. It returns "" when nothing
+// recent is used, and otherwise the version along with the keyword needing it.
+func DetectMinHyprlandVersion(cfg *HyprConfig) (version, keyword string) {
+	used := map[string]bool{}
+	eachHyprlandFile(cfg, func(_ *HyprProgramConfig, _ FileEntry, data []byte) {
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := hyprlandSectionRe.FindStringSubmatch(stripHyprComment(line)); m != nil {
+				used[m[1]] = true
+			}
+		}
+		hyprLines(data, func(_ int, kw, _ string) {
+			category, _, _ := strings.Cut(kw, ":")
+			used[category] = true
+		})
+	})
+	for _, f := range hyprlandFeatures {
+		if used[f.keyword] && hyprlandVersionKey(f.version) > hyprlandVersionKey(version) {
+			version, keyword = f.version, f.keyword
+		}
+	}
+	return version, keyword
+}
+
+// hyprlandVersionWarnings warns when the Hyprland files of hc use syntax newer than its declared
+// MinHyprlandVersion.
+func (hc *HyprConfig) hyprlandVersionWarnings() []string {
+	if hc.MinHyprlandVersion == "" {
+		return nil
+	}
+	declared, err := NormalizeHyprlandVersion(hc.MinHyprlandVersion)
+	if err != nil {
+		return nil
+	}
+	detected, keyword := DetectMinHyprlandVersion(hc)
+	if hyprlandVersionKey(detected) <= hyprlandVersionKey(declared) {
+		return nil
+	}
+	return []string{fmt.Sprintf("min_hyprland_version %s looks too low: %s needs Hyprland %s or newer", declared, keyword, detected)}
+}
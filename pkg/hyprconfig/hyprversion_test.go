@@ -0,0 +1,119 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func hyprlandConfig(title, minVersion, data string) *HyprConfig {
+	return &HyprConfig{
+		Title:              title,
+		MinHyprlandVersion: minVersion,
+		ProgramConfigs: []HyprProgramConfig{{
+			Title: "hyprland", Program: "hyprland", FileContent: FileContent{Data: []byte(data), FileType: FileTypeConfig},
+		}},
+	}
+}
+
+func TestNormalizeHyprlandVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		"0.41":      "0.41.0",
+		" v0.45.2 ": "0.45.2",
+		"0.040.01":  "0.40.1",
+		"1.0.0":     "1.0.0",
+	} {
+		if got, err := NormalizeHyprlandVersion(in); err != nil || got != want {
+			t.Errorf("NormalizeHyprlandVersion(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "0", "latest", "0.41.2-git", "0.41.2.1"} {
+		if _, err := NormalizeHyprlandVersion(in); !errors.Is(err, ErrValidation) {
+			t.Errorf("NormalizeHyprlandVersion(%q): got %v, want ErrValidation", in, err)
+		}
+	}
+
+	if !(hyprlandVersionKey("0.9.0") < hyprlandVersionKey("0.41.0") && hyprlandVersionKey("0.41.2") < hyprlandVersionKey("1.0.0")) {
+		t.Error("version keys don't sort like the versions")
+	}
+}
+
+func TestDetectMinHyprlandVersion(t *testing.T) {
+	for data, want := range map[string]string{
+		"general {\n    gaps_in = 5\n}\nbind = SUPER, Q, exec, kitty\n":         "",
+		"render {\n    explicit_sync = 1\n}\n":                                  "0.42.0",
+		"render:explicit_sync = 1\necosystem {\n    no_update_news = true\n}\n": "0.46.0",
+		"# gesture = 3, horizontal, workspace\n":                                "",
+		"gesture = 3, horizontal, workspace\n":                                  "0.51.0",
+	} {
+		if got, _ := DetectMinHyprlandVersion(hyprlandConfig("rice", "", data)); got != want {
+			t.Errorf("DetectMinHyprlandVersion(%q) = %q, want %q", data, got, want)
+		}
+	}
+}
+
+func TestMinHyprlandVersion(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		old, err := m.CreateConfig(alice, hyprlandConfig("old", "v0.40", "render {\n    explicit_sync = 1\n}\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if old.MinHyprlandVersion != "0.40.0" {
+			t.Errorf("min_hyprland_version = %q, want 0.40.0", old.MinHyprlandVersion)
+		}
+		if !strings.Contains(strings.Join(old.Warnings, "\n"), "min_hyprland_version 0.40.0 looks too low: render needs Hyprland 0.42.0") {
+			t.Errorf("warnings = %q, want the declared minimum flagged as too low", old.Warnings)
+		}
+		recent, err := m.CreateConfig(alice, hyprlandConfig("recent", "0.45.1", "general {\n    gaps_in = 5\n}\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		unknown, err := m.CreateConfig(alice, hyprlandConfig("unknown", "", "general {\n    gaps_out = 10\n}\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = m.CreateConfig(alice, hyprlandConfig("bad", "latest", ""))
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "min_hyprland_version" {
+			t.Errorf("invalid version: got %v, want a validation error on min_hyprland_version", err)
+		}
+
+		search := func(version string) string {
+			t.Helper()
+			page, err := m.ListConfigsWithFilters(alice, 1, 10, ConfigSearchFilters{HyprlandVersion: version, Sort: SearchSortTitle}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return strings.Join(configIDs(page.Items), ",")
+		}
+		for version, want := range map[string]string{
+			"0.39":    unknown.ID,
+			"0.40":    old.ID + "," + unknown.ID,
+			"0.45.0":  old.ID + "," + unknown.ID,
+			"v0.45.1": old.ID + "," + recent.ID + "," + unknown.ID,
+		} {
+			if got := search(version); got != want {
+				t.Errorf("hyprland_version=%s matched %q, want %q", version, got, want)
+			}
+		}
+		if _, err := m.ListConfigsWithFilters(alice, 1, 10, ConfigSearchFilters{HyprlandVersion: "next"}, nil); !errors.Is(err, ErrValidation) {
+			t.Errorf("invalid version filter: got %v, want ErrValidation", err)
+		}
+
+		// Raising the minimum moves the config out of older searches, clearing it brings it back
+		if err := m.UpdateConfig(alice, unknown.ID, map[string]any{"min_hyprland_version": "0.50"}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := search("0.45.1"); got != old.ID+","+recent.ID {
+			t.Errorf("after raising the minimum: matched %q", got)
+		}
+		if err := m.UpdateConfig(alice, unknown.ID, map[string]any{"min_hyprland_version": ""}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := search("0.39"); got != unknown.ID {
+			t.Errorf("after clearing the minimum: matched %q", got)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestIsIndexConflict(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{mongo.CommandError{Code: codeIndexOptionsConflict}, true},
+		{fmt.Errorf("wrapped: %w", mongo.CommandError{Code: codeIndexKeySpecsConflict}), true},
+		{mongo.CommandError{Code: 13}, false},
+		{errors.New("connection refused"), false},
+		{nil, false},
+	} {
+		if got := isIndexConflict(tt.err); got != tt.want {
+			t.Errorf("isIndexConflict(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestNewConfigManagerIndexes needs a MongoDB server, set HYPR_TEST_MONGO_URI to run it.
+func TestNewConfigManagerIndexes(t *testing.T) {
+	uri := os.Getenv("HYPR_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("HYPR_TEST_MONGO_URI is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := client.Database(fmt.Sprintf("hypr_index_test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() {
+		_ = db.Drop(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+
+	// A pre-existing index with other options must not fail startup
+	_, err = db.Collection("configs").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tags", Value: 1}},
+		Options: options.Index().SetName("idx_tags").SetSparse(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newManager := func() {
+		t.Helper()
+		_, err := NewConfigManager(db.Collection("configs"), db.Collection("favorites"), db.Collection("state"), db.Collection("allowed_programs"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	newManager()
+	newManager() // idempotent
+
+	specs, err := db.Collection("configs").Indexes().ListSpecifications(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]bool{}
+	for _, spec := range specs {
+		names[spec.Name] = true
+	}
+	for _, want := range []string{"idx_likes_desc", "idx_updated_desc", "idx_owner_updated", "idx_private_updated", "idx_tags", "idx_text_search", "idx_fingerprint_owner"} {
+		if !names[want] {
+			t.Errorf("index %s is missing, have %v", want, names)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DistroArch   = PlatformArch
+	DistroDebian = PlatformDebian
+	DistroFedora = PlatformFedora
+	DistroNixOS  = PlatformNixOS
+)
+
+var ErrUnsupportedDistro = errors.New("unsupported distro")
+
+// installCommands is the package manager invocation for each supported distro. The -- ends
+// the options, so no package is taken for one.
+var installCommands = map[string]string{
+	DistroArch:   "sudo pacman -S --needed --noconfirm --",
+	DistroDebian: "sudo apt-get install -y --",
+	DistroFedora: "sudo dnf install -y --",
+	DistroNixOS:  "nix-env -iA",
+}
+
+// packageNameRe keeps user supplied dependency names from injecting shell or passing for an
+// option of the package manager.
+var packageNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._+-]*$`)
+
+// shellSafeRe matches the words the shell takes literally without quoting.
+var shellSafeRe = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+// shellQuote quotes word for a POSIX shell, leaving it as is when nothing in it needs quoting.
+func shellQuote(word string) string {
+	if shellSafeRe.MatchString(word) {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// shellCommand returns a post-install command as a shell line running the same argv as apply,
+// which runs it without a shell: every word is quoted, so redirections, pipes and expansions in
+// it stay literal arguments.
+func shellCommand(command string) string {
+	words := strings.Fields(command)
+	for i, w := range words {
+		words[i] = shellQuote(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// SupportedDistros returns the distros GenerateInstallScript can target.
+func SupportedDistros() []string {
+	distros := make([]string, 0, len(installCommands))
+	for d := range installCommands {
+		distros = append(distros, d)
+	}
+	sort.Strings(distros)
+	return distros
+}
+
+type installScriptOptions struct {
+	includeOptional bool
+	programs        map[string]AllowedPrograms
+}
+
+// InstallScriptOption customises GenerateInstallScript.
+type InstallScriptOption func(*installScriptOptions)
+
+// IncludeOptional also installs program configs marked Optional.
+func IncludeOptional(include bool) InstallScriptOption {
+	return func(o *installScriptOptions) {
+		o.includeOptional = include
+	}
+}
+
+// WithPackageNames maps programs through their per-distro package names.
+func WithPackageNames(programs []AllowedPrograms) InstallScriptOption {
+	return func(o *installScriptOptions) {
+		for _, p := range programs {
+			o.programs[p.ProgramName] = p
+		}
+	}
+}
+
+// skips reports whether the install script for distro leaves pc out: program configs limited
+// to other platforms are, as are optional ones unless requested.
+func (o *installScriptOptions) skips(pc *HyprProgramConfig, distro string) bool {
+	if pc.Optional && !o.includeOptional {
+		return true
+	}
+	return len(pc.Platform) > 0 && !containsString(pc.Platform, distro)
+}
+
+// installPackages collects the deduplicated, sorted packages the config needs on distro.
+func installPackages(cfg *HyprConfig, distro string, o *installScriptOptions) ([]string, error) {
+	seen := map[string]struct{}{}
+	var err error
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		if err != nil || o.skips(pc, distro) {
+			return
+		}
+
+		for _, name := range append([]string{pc.Program}, pc.Dependencies...) {
+			name = NormalizeProgramName(name)
+			if name == "" {
+				continue
+			}
+			if pkg, ok := o.programs[name].Packages[distro]; ok {
+				name = pkg
+			}
+			if !packageNameRe.MatchString(name) {
+				err = invalidf("program %s: invalid package name %q", pc.Program, name)
+				return
+			}
+			seen[name] = struct{}{}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]string, 0, len(seen))
+	for p := range seen {
+		pkgs = append(pkgs, p)
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
+
+// GenerateInstallScript returns a shell script installing the programs and dependencies of cfg on
+// distro, followed by the post-install commands of the program configs it covers.
+func GenerateInstallScript(cfg *HyprConfig, distro string, opts ...InstallScriptOption) (string, error) {
+	if platform, err := NormalizePlatform(distro); err == nil {
+		distro = platform
+	}
+	command, ok := installCommands[distro]
+	if !ok {
+		return "", fmt.Errorf("%w %q, supported values are: %s", ErrUnsupportedDistro, distro, strings.Join(SupportedDistros(), ", "))
+	}
+
+	o := &installScriptOptions{programs: map[string]AllowedPrograms{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	pkgs, err := installPackages(cfg, distro, o)
+	if err != nil {
+		return "", err
+	}
+
+	var hooks []string
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		if !o.skips(pc, distro) {
+			hooks = append(hooks, pc.PostInstall...)
+		}
+	})
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Install script for %q on %s, generated by hypr-config-manager\n", cfg.Title, distro)
+	b.WriteString("set -e\n\n")
+	if len(pkgs) == 0 && len(hooks) == 0 {
+		b.WriteString("echo \"nothing to install\"\n")
+		return b.String(), nil
+	}
+
+	if len(pkgs) > 0 {
+		if distro == DistroNixOS {
+			for i, p := range pkgs {
+				pkgs[i] = "nixpkgs." + p
+			}
+		}
+		fmt.Fprintf(&b, "%s %s\n", command, strings.Join(pkgs, " "))
+	}
+	if len(hooks) > 0 {
+		if len(pkgs) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("# Post-install commands, run once the config files are in place\n")
+		for _, hook := range hooks {
+			b.WriteString(shellCommand(hook) + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// GetInstallScript generates the install script for a config the caller is allowed to see,
+// using the per-distro package names from the allowed programs collection.
+func (m *ConfigManagerMongo) GetInstallScript(ctx context.Context, configID, distro string, includeOptional bool) (string, error) {
+	if platform, err := NormalizePlatform(distro); err == nil {
+		distro = platform
+	}
+	if _, ok := installCommands[distro]; !ok {
+		return GenerateInstallScript(&HyprConfig{}, distro)
+	}
+
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return "", err
+	}
+	programs, err := m.packagePrograms(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	return GenerateInstallScript(cfg, distro, IncludeOptional(includeOptional), WithPackageNames(programs))
+}
+
+// packagePrograms loads the allowed programs of the programs and dependencies of cfg, for
+// their package names.
+func (m *ConfigManagerMongo) packagePrograms(ctx context.Context, cfg *HyprConfig) ([]AllowedPrograms, error) {
+	var names []string
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		names = append(names, NormalizeProgramName(pc.Program))
+		for _, d := range pc.Dependencies {
+			names = append(names, NormalizeProgramName(d))
+		}
+	})
+
+	// Built-in programs have no package names of their own
+	var programs []AllowedPrograms
+	if m.ProgramsCollection != nil {
+		cursor, err := m.ProgramsCollection.Find(ctx, bson.M{"program_name": bson.M{"$in": names}})
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &programs); err != nil {
+			return nil, err
+		}
+	}
+	return programs, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
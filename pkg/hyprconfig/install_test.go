@@ -0,0 +1,68 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func installTestConfig() *HyprConfig {
+	return &HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Program: "hyprland", Dependencies: []string{"xdg-desktop-portal-hyprland", "noto-fonts"},
+				SubConfigs: []*HyprProgramConfig{
+					{Program: "waybar", Dependencies: []string{"noto-fonts"}},
+					{Program: "wofi", Optional: true},
+					{Program: "yay", Platform: []string{"arch"}},
+				},
+			},
+		},
+	}
+}
+
+func TestGenerateInstallScript(t *testing.T) {
+	script, err := GenerateInstallScript(installTestConfig(), DistroArch)
+	if err != nil {
+		t.Fatalf("GenerateInstallScript: %v", err)
+	}
+	want := "sudo pacman -S --needed --noconfirm -- hyprland noto-fonts waybar xdg-desktop-portal-hyprland yay\n"
+	if !strings.HasSuffix(script, want) {
+		t.Errorf("script =\n%s\nwant it to end with\n%s", script, want)
+	}
+}
+
+func TestGenerateInstallScriptOptionsAndMapping(t *testing.T) {
+	script, err := GenerateInstallScript(installTestConfig(), DistroDebian,
+		IncludeOptional(true),
+		WithPackageNames([]AllowedPrograms{{ProgramName: "noto-fonts", Packages: map[string]string{DistroDebian: "fonts-noto"}}}),
+	)
+	if err != nil {
+		t.Fatalf("GenerateInstallScript: %v", err)
+	}
+	want := "sudo apt-get install -y -- fonts-noto hyprland waybar wofi xdg-desktop-portal-hyprland\n"
+	if !strings.HasSuffix(script, want) {
+		t.Errorf("script =\n%s\nwant it to end with\n%s", script, want)
+	}
+
+	nix, err := GenerateInstallScript(&HyprConfig{ProgramConfigs: []HyprProgramConfig{{Program: "kitty"}}}, DistroNixOS)
+	if err != nil || !strings.Contains(nix, "nix-env -iA nixpkgs.kitty\n") {
+		t.Errorf("nix script = %q, %v", nix, err)
+	}
+}
+
+func TestGenerateInstallScriptErrors(t *testing.T) {
+	_, err := GenerateInstallScript(installTestConfig(), "gentoo")
+	if !errors.Is(err, ErrUnsupportedDistro) || !strings.Contains(err.Error(), "arch, debian, fedora, nixos") {
+		t.Errorf("unknown distro: got %v", err)
+	}
+
+	cfg := &HyprConfig{ProgramConfigs: []HyprProgramConfig{{Program: "kitty", Dependencies: []string{"foo; rm -rf ~"}}}}
+	if _, err := GenerateInstallScript(cfg, DistroArch); err == nil {
+		t.Error("expected shell metacharacters in a dependency to be rejected")
+	}
+	cfg.ProgramConfigs[0].Dependencies = []string{"--asdeps"}
+	if _, err := GenerateInstallScript(cfg, DistroArch); err == nil {
+		t.Error("expected a dependency passing for an option to be rejected")
+	}
+}
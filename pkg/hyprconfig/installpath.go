@@ -0,0 +1,110 @@
+package hyprconfig
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidInstallPath = errors.New("invalid install path")
+
+// DefaultInstallPrefixes are the directories below $HOME that program files may be installed
+// into. SizeLimits.ExtraInstallPrefixes adds to them.
+var DefaultInstallPrefixes = []string{
+	".config",
+	".local/share",
+	".themes",
+	".icons",
+	".fonts",
+	"Pictures",
+}
+
+// installPrefixes returns DefaultInstallPrefixes followed by extra, as clean paths relative to $HOME.
+func installPrefixes(extra []string) []string {
+	prefixes := append([]string{}, DefaultInstallPrefixes...)
+	for _, p := range extra {
+		for _, home := range []string{"~/", "$HOME/"} {
+			p = strings.TrimPrefix(p, home)
+		}
+		if p = path.Clean(strings.Trim(p, "/")); p != "." && p != ".." && !strings.HasPrefix(p, "../") {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}
+
+// installPathProblem checks an install path before it is cleaned and returns why it is unsafe,
+// or "" when it is fine. Paths are relative to $HOME, written as ~/..., $HOME/... or bare.
+func installPathProblem(p string) string {
+	switch {
+	case strings.ContainsFunc(p, unicode.IsControl):
+		return "must not contain control characters"
+	case strings.Contains(p, `\`):
+		return "must use forward slashes"
+	case strings.HasPrefix(p, "/"):
+		return "absolute paths are not allowed, use a path below ~/"
+	case strings.HasPrefix(p, "~") && !strings.HasPrefix(p, "~/"):
+		return "only the current user's home directory (~/) is supported"
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(p, "~/"), "$HOME/")
+	if strings.Contains(rest, "$") {
+		return "must not contain variables other than a leading $HOME"
+	}
+	for _, segment := range strings.Split(rest, "/") {
+		if segment == ".." {
+			return "must not contain .. segments"
+		}
+		if strings.HasPrefix(segment, "~") {
+			return "must not contain ~ after the start"
+		}
+	}
+	return ""
+}
+
+// homeRelativePath turns an install path into a clean path relative to $HOME. It fails unless the
+// path stays inside one of DefaultInstallPrefixes or extraPrefixes.
+func homeRelativePath(pc *HyprProgramConfig, extraPrefixes ...string) (string, error) {
+	return resolveInstallPath(pc.Program, pc.InstallPath, installTarget(pc), "", extraPrefixes)
+}
+
+// installTarget returns the install path of pc as a TargetPath: the default one when it has
+// none, with the file name filled in for a directory and bare paths written below ~/.
+func installTarget(pc *HyprProgramConfig) string {
+	p := pc.InstallPath
+	if p == "" {
+		p = DefaultInstallPath(pc.Program)
+	}
+	if strings.HasSuffix(p, "/") {
+		p += pc.Program + ".conf"
+	}
+	if !strings.HasPrefix(p, "~") && !strings.HasPrefix(p, "$HOME/") && !strings.HasPrefix(p, "/") {
+		p = "~/" + p
+	}
+	return p
+}
+
+// resolveInstallPath turns the path p a file of program is installed at into a clean path
+// relative to $HOME. Bare paths are relative to dir, or to $HOME when dir is empty. Errors quote
+// the path as the user gave it.
+func resolveInstallPath(program, given, p, dir string, extraPrefixes []string) (string, error) {
+	if problem := installPathProblem(p); problem != "" {
+		return "", invalidf("program %s: %w %q: %s", program, ErrInvalidInstallPath, given, problem)
+	}
+	rel := p
+	for _, prefix := range []string{"~/", "$HOME/"} {
+		rel = strings.TrimPrefix(rel, prefix)
+	}
+	if rel == p && dir != "" {
+		rel = path.Join(dir, rel)
+	}
+
+	rel = path.Clean(rel)
+	for _, prefix := range installPrefixes(extraPrefixes) {
+		if strings.HasPrefix(rel, prefix+"/") {
+			return rel, nil
+		}
+	}
+	return "", invalidf("program %s: %w %q: must be inside one of ~/%s",
+		program, ErrInvalidInstallPath, given, strings.Join(installPrefixes(extraPrefixes), ", ~/"))
+}
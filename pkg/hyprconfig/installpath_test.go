@@ -0,0 +1,88 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHomeRelativePathRejectsUnsafePaths(t *testing.T) {
+	for _, p := range []string{
+		"../../.ssh/authorized_keys",
+		"~/../.ssh/authorized_keys",
+		"~/.config/../.ssh/authorized_keys",
+		"$HOME/.config/kitty/../../.bashrc",
+		"/etc/sudoers.d/evil",
+		"/root/.config/kitty/kitty.conf",
+		"//etc/passwd",
+		"~root/.config/kitty/kitty.conf",
+		"~/.config/~/kitty.conf",
+		"$XDG_CONFIG_HOME/kitty/kitty.conf",
+		"~/.config/$USER/kitty.conf",
+		"${HOME}/.config/kitty/kitty.conf",
+		`~\.config\kitty\kitty.conf`,
+		"~/.config/kitty/kitty.conf\x00.png",
+		"~/.config/kitty/\nkitty.conf",
+		"~/.config",
+		"~/.bashrc",
+		"~/.ssh/authorized_keys",
+		"~/.local/bin/kitty",
+		"~/.configx/kitty.conf",
+		".",
+	} {
+		_, err := homeRelativePath(&HyprProgramConfig{Program: "kitty", InstallPath: p})
+		if !errors.Is(err, ErrInvalidInstallPath) || !errors.Is(err, ErrValidation) {
+			t.Errorf("homeRelativePath(%q) = %v, want ErrInvalidInstallPath", p, err)
+		}
+	}
+}
+
+func TestHomeRelativePathExtraPrefixes(t *testing.T) {
+	pc := &HyprProgramConfig{Program: "kitty", InstallPath: "~/.local/bin/kitty-launch"}
+	if _, err := homeRelativePath(pc); err == nil {
+		t.Fatal("~/.local/bin allowed without an extra prefix")
+	}
+	for _, prefix := range []string{"~/.local/bin", "$HOME/.local/bin/", ".local/bin"} {
+		got, err := homeRelativePath(pc, prefix)
+		if err != nil || got != ".local/bin/kitty-launch" {
+			t.Errorf("with prefix %q: got %q, %v", prefix, got, err)
+		}
+	}
+
+	// Extra prefixes can't widen the allowlist to all of $HOME or beyond.
+	for _, prefix := range []string{"~/", "/", "..", "~/../etc"} {
+		if _, err := homeRelativePath(&HyprProgramConfig{Program: "kitty", InstallPath: "~/.bashrc"}, prefix); err == nil {
+			t.Errorf("prefix %q allowed ~/.bashrc", prefix)
+		}
+	}
+}
+
+func TestProgramConfigValidateInstallPath(t *testing.T) {
+	limits := DefaultSizeLimits()
+	pc := HyprProgramConfig{Program: "kitty", InstallPath: "~/.config/../.ssh/authorized_keys"}
+	err := pc.Validate(context.Background(), allowOnly("kitty"), limits)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 {
+		t.Fatalf("Validate = %v, want one problem", err)
+	}
+	if fe := verr.Errors[0]; fe.Path != "install_path" || fe.Code != CodeInvalidInstallPath {
+		t.Errorf("problem = %s %s, want install_path %s", fe.Path, fe.Code, CodeInvalidInstallPath)
+	}
+
+	pc.InstallPath = "~/.local/bin/kitty-launch"
+	limits.ExtraInstallPrefixes = []string{"~/.local/bin"}
+	if err := pc.Validate(context.Background(), allowOnly("kitty"), limits); err != nil {
+		t.Errorf("Validate with an extra prefix: %v", err)
+	}
+}
+
+func TestRenderConfigRejectsUnsafeInstallPath(t *testing.T) {
+	cfg := &HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{{
+		Program:     "kitty",
+		InstallPath: "/etc/sudoers.d/evil",
+		FileContent: FileContent{Data: []byte("ALL ALL=(ALL) NOPASSWD: ALL\n"), FileType: FileTypeConfig},
+	}}}
+	if _, err := RenderConfig(cfg, nil); !errors.Is(err, ErrInvalidInstallPath) {
+		t.Errorf("RenderConfig = %v, want ErrInvalidInstallPath", err)
+	}
+}
@@ -0,0 +1,256 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// bindLineRe matches the keyword of a Hyprland bind line: bind followed by its flags, such as
+// bindm for mouse binds or binde for binds that repeat while held.
+var bindLineRe = regexp.MustCompile(`^bind([a-z]*)$`)
+
+// modifierAliases maps the names Hyprland accepts for a modifier to a single one.
+var modifierAliases = map[string]string{
+	"WIN":     "SUPER",
+	"LOGO":    "SUPER",
+	"MOD4":    "SUPER",
+	"META":    "SUPER",
+	"CONTROL": "CTRL",
+	"MOD1":    "ALT",
+}
+
+// Keybind is one bind line of a Hyprland config.
+type Keybind struct {
+	Flags       string   `json:"flags,omitempty" bson:"flags,omitempty"` // the letters after bind, e.g. m for bindm
+	Mods        []string `json:"mods" bson:"mods"`                       // normalized and sorted, e.g. [SHIFT SUPER]
+	Key         string   `json:"key" bson:"key"`
+	Dispatcher  string   `json:"dispatcher" bson:"dispatcher"`
+	Args        string   `json:"args,omitempty" bson:"args,omitempty"`
+	Description string   `json:"description,omitempty" bson:"description,omitempty"` // of a bindd bind
+	Submap      string   `json:"submap,omitempty" bson:"submap,omitempty"`           // empty for the global binds
+	Line        int      `json:"line" bson:"line"`                                   // 1-based line number in the file
+
+	// Where the bind was found, set by DetectKeybindConflicts.
+	ProgramID string `json:"program_id,omitempty" bson:"program_id,omitempty"`
+	File      string `json:"file,omitempty" bson:"file,omitempty"`
+}
+
+// Combo returns the keys pressed for the bind, such as SHIFT+SUPER+Q.
+func (k Keybind) Combo() string {
+	return strings.Join(append(append([]string{}, k.Mods...), k.Key), "+")
+}
+
+// comboKey identifies the keys of a bind. Keys are compared case-insensitively, and binds that
+// fire on release don't clash with those that fire on press, nor binds of different submaps.
+func (k Keybind) comboKey() string {
+	id := k.Submap + "/" + strings.ToLower(k.Combo())
+	if strings.Contains(k.Flags, "r") {
+		id += "/release"
+	}
+	return id
+}
+
+// KeybindConflict is a key combination bound more than once.
+type KeybindConflict struct {
+	Combo    string    `json:"combo"`
+	Bindings []Keybind `json:"bindings"`
+}
+
+// String describes the conflict for a validation warning.
+func (c KeybindConflict) String() string {
+	places := make([]string, len(c.Bindings))
+	for i, b := range c.Bindings {
+		places[i] = fmt.Sprintf("%s line %d", b.File, b.Line)
+	}
+	combo := c.Combo
+	if submap := c.Bindings[0].Submap; submap != "" {
+		combo += " in submap " + submap
+	}
+	return fmt.Sprintf("keybind %s is bound %d times: %s", combo, len(c.Bindings), strings.Join(places, ", "))
+}
+
+// KeybindReport lists every bind of a config and the key combinations bound more than once.
+type KeybindReport struct {
+	Keybinds  []Keybind         `json:"keybinds"`
+	Conflicts []KeybindConflict `json:"conflicts"`
+}
+
+// ExtractKeybinds returns the bind lines of a Hyprland config, skipping comments and lines too
+// short to be a bind. $variables are resolved with the variables declared in data. Binds between
+// submap = name and submap = reset are in that submap.
+func ExtractKeybinds(data []byte) []Keybind {
+	return parseKeybinds(data, ParseKeyValuePairs(string(data)))
+}
+
+// parseKeybinds is ExtractKeybinds with the $variables resolved from vars, which may be declared
+// in another file of the config.
+func parseKeybinds(data []byte, vars map[string]string) []Keybind {
+	var binds []Keybind
+	submap := ""
+	for _, n := range ParseHyprDoc(data).Nodes {
+		if n.Kind != HyprNodeKeyword {
+			continue
+		}
+		if n.Key == "submap" {
+			submap = splitFields(n.Value)[0]
+			if submap == "reset" {
+				submap = ""
+			}
+			continue
+		}
+		match := bindLineRe.FindStringSubmatch(n.Key)
+		if match == nil {
+			continue
+		}
+		fields := strings.SplitN(expandHyprVariables(n.Value, vars, nil), ",", 4)
+		description := ""
+		// binds with a description (the d flag) have it before the dispatcher
+		if strings.Contains(match[1], "d") && len(fields) == 4 {
+			description = strings.TrimSpace(fields[2])
+			fields = append(fields[:2], strings.SplitN(fields[3], ",", 2)...)
+		}
+		if len(fields) < 3 {
+			continue
+		}
+		bind := Keybind{
+			Flags:       match[1],
+			Mods:        parseModifiers(fields[0], vars),
+			Key:         strings.TrimSpace(fields[1]),
+			Dispatcher:  strings.TrimSpace(fields[2]),
+			Description: description,
+			Submap:      submap,
+			Line:        n.Line,
+		}
+		if len(fields) == 4 {
+			bind.Args = strings.TrimSpace(fields[3])
+		}
+		if bind.Key == "" {
+			continue
+		}
+		binds = append(binds, bind)
+	}
+	return binds
+}
+
+// parseModifiers splits the modifiers of a bind, which Hyprland accepts separated by spaces, _
+// or +, and returns them upper-cased, deduplicated and sorted.
+func parseModifiers(s string, vars map[string]string) []string {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\t' || r == '_' || r == '+' })
+	}
+	seen := map[string]bool{}
+	mods := []string{}
+	for _, token := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\t' || r == '+' }) {
+		parts := []string{token}
+		if value, ok := vars[token]; ok && strings.HasPrefix(token, "$") {
+			parts = split(value)
+		} else if !strings.HasPrefix(token, "$") {
+			parts = split(token)
+		}
+		for _, mod := range parts {
+			mod = strings.ToUpper(mod)
+			if alias, ok := modifierAliases[mod]; ok {
+				mod = alias
+			}
+			if !seen[mod] {
+				seen[mod] = true
+				mods = append(mods, mod)
+			}
+		}
+	}
+	sort.Strings(mods)
+	return mods
+}
+
+// DetectKeybindConflicts parses the binds of every Hyprland text and config file of cfg,
+// including sub configs, and reports the key combinations bound more than once.
+func DetectKeybindConflicts(cfg *HyprConfig) KeybindReport {
+	return NewKeybindReport(ConfigKeybinds(cfg))
+}
+
+// ConfigKeybinds returns the binds of every Hyprland text and config file of cfg, including sub
+// configs, noting the program config and file each is in. Variables declared in any of those
+// files resolve $variables in all of them, as Hyprland sources them into one config.
+func ConfigKeybinds(cfg *HyprConfig) []Keybind {
+	type source struct {
+		programID string
+		file      string
+		data      []byte
+	}
+	var sources []source
+	declared := map[string]string{}
+	eachHyprlandFile(cfg, func(pc *HyprProgramConfig, e FileEntry, data []byte) {
+		sources = append(sources, source{programID: pc.ID, file: e.TargetPath, data: data})
+		for k, v := range ParseHyprDoc(data).Variables() {
+			declared[k] = v
+		}
+	})
+	vars := resolveHyprVariables(declared)
+
+	binds := []Keybind{}
+	for _, src := range sources {
+		for _, bind := range parseKeybinds(src.data, vars) {
+			bind.ProgramID = src.programID
+			bind.File = src.file
+			binds = append(binds, bind)
+		}
+	}
+	return binds
+}
+
+// NewKeybindReport reports the key combinations of binds bound more than once, within the same
+// submap.
+func NewKeybindReport(binds []Keybind) KeybindReport {
+	report := KeybindReport{Keybinds: binds, Conflicts: []KeybindConflict{}}
+	if report.Keybinds == nil {
+		report.Keybinds = []Keybind{}
+	}
+	byCombo := map[string][]Keybind{}
+	var order []string
+	for _, bind := range binds {
+		id := bind.comboKey()
+		if _, ok := byCombo[id]; !ok {
+			order = append(order, id)
+		}
+		byCombo[id] = append(byCombo[id], bind)
+	}
+	for _, id := range order {
+		if bound := byCombo[id]; len(bound) > 1 {
+			report.Conflicts = append(report.Conflicts, KeybindConflict{Combo: bound[0].Combo(), Bindings: bound})
+		}
+	}
+	return report
+}
+
+// eachHyprlandFile calls fn with the uncompressed data of every text and config file of the
+// Hyprland program configs of cfg, sub configs included. Offloaded files are skipped.
+func eachHyprlandFile(cfg *HyprConfig, fn func(pc *HyprProgramConfig, e FileEntry, data []byte)) {
+	eachProgramFile(cfg, map[string]struct{}{hyprlandProgram: {}}, fn)
+}
+
+// eachProgramFile is eachHyprlandFile for the program configs of any of programs.
+func eachProgramFile(cfg *HyprConfig, programs map[string]struct{}, fn func(pc *HyprProgramConfig, e FileEntry, data []byte)) {
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		if _, ok := programs[NormalizeProgramName(pc.Program)]; !ok {
+			return
+		}
+		for _, e := range pc.FileEntries() {
+			fc := e.FileContent
+			if !templated(&fc) || len(fc.Data) == 0 || fc.Decompress() != nil {
+				continue
+			}
+			fn(pc, e, fc.Data)
+		}
+	})
+}
+
+// KeybindWarnings returns a validation warning for each keybind conflict of cfg.
+func KeybindWarnings(cfg *HyprConfig) []string {
+	var warnings []string
+	for _, c := range DetectKeybindConflicts(cfg).Conflicts {
+		warnings = append(warnings, c.String())
+	}
+	return warnings
+}
@@ -0,0 +1,112 @@
+package hyprconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractKeybinds(t *testing.T) {
+	data := []byte(`$mainMod = SUPER
+bind = $mainMod, Q, exec, kitty
+bind = $mainMod SHIFT, Q, killactive, # close
+# bind = $mainMod, E, exec, dolphin
+bindm = SUPER, mouse:272, movewindow
+binde = CONTROL_ALT, right, resizeactive, 10 0
+bindd = SUPER, F, Toggle fullscreen, fullscreen, 0
+unbind = SUPER, Q
+bind = SUPER
+`)
+	var got []string
+	for _, b := range ExtractKeybinds(data) {
+		got = append(got, strings.Join([]string{b.Flags, b.Combo(), b.Dispatcher, b.Args}, "|"))
+	}
+	want := []string{
+		"|SUPER+Q|exec|kitty",
+		"|SHIFT+SUPER+Q|killactive|",
+		"m|SUPER+mouse:272|movewindow|",
+		"e|ALT+CTRL+right|resizeactive|10 0",
+		"d|SUPER+F|fullscreen|0",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ExtractKeybinds =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestExtractKeybindsSubmaps(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "keybinds", "submaps.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	binds := ExtractKeybinds(data)
+	for _, b := range binds {
+		got = append(got, fmt.Sprintf("%d %s|%s|%s|%s|%s|%s", b.Line, b.Flags, b.Submap, b.Combo(), b.Dispatcher, b.Args, b.Description))
+	}
+	want := []string{
+		"6 ||SUPER+Return|exec|kitty --single-instance|",
+		"7 d||SUPER+R|exec|wofi --show drun|Open the app launcher",
+		"10 ||SUPER+B|exec|firefox|",
+		"13 ||ALT+R|submap|resize|",
+		"15 e|resize|right|resizeactive|10 0|",
+		"16 e|resize|left|resizeactive|-10 0|",
+		"17 d|resize|escape|submap|reset|Leave resize mode",
+		"22 |launch|F|exec|firefox|",
+		"23 |launch|escape|submap|reset|",
+		"26 l||XF86AudioMute|exec|wpctl set-mute @DEFAULT_AUDIO_SINK@ toggle|",
+		"27 m||SUPER+mouse:272|movewindow||",
+		"28 r||SUPER+SUPER_L|exec|pkill wofi || wofi|",
+		"29 ||escape|exec|makoctl dismiss|",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExtractKeybinds =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// escape is bound once in each submap and globally
+	if report := NewKeybindReport(binds); len(report.Conflicts) != 0 {
+		t.Errorf("conflicts = %+v, want none across submaps", report.Conflicts)
+	}
+	report := NewKeybindReport(ExtractKeybinds([]byte("submap = resize\nbind = , escape, submap, reset\nbind = , ESCAPE, exec, foo\n")))
+	if len(report.Conflicts) != 1 || !strings.Contains(report.Conflicts[0].String(), "escape in submap resize") {
+		t.Errorf("conflicts = %+v, want escape bound twice in resize", report.Conflicts)
+	}
+}
+
+func TestDetectKeybindConflicts(t *testing.T) {
+	cfg := &HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{{
+			ID: "hypr", Title: "hyprland", Program: "hyprland",
+			FileContent: FileContent{Data: []byte("$mod = SUPER\nbind = $mod, Q, exec, kitty\nbindr = SUPER, Q, exec, wofi\n"), FileType: FileTypeConfig},
+			SubConfigs: []*HyprProgramConfig{{
+				ID: "keys", Title: "keys", Program: "hyprland", InstallPath: "~/.config/hypr/keys.conf",
+				FileContent: FileContent{Data: []byte("bind = SUPER, q, killactive\nbind = SUPER SHIFT, Q, exit\n"), FileType: FileTypeConfig},
+			}},
+		}, {
+			// Only Hyprland files are parsed
+			ID: "term", Title: "kitty", Program: "kitty",
+			FileContent: FileContent{Data: []byte("bind = SUPER, Q, exec, kitty\n"), FileType: FileTypeConfig},
+		}},
+	}
+
+	report := DetectKeybindConflicts(cfg)
+	if len(report.Keybinds) != 4 {
+		t.Errorf("keybinds = %+v, want 4", report.Keybinds)
+	}
+	if len(report.Conflicts) != 1 || report.Conflicts[0].Combo != "SUPER+Q" || len(report.Conflicts[0].Bindings) != 2 ||
+		report.Conflicts[0].Bindings[0].ProgramID != "hypr" || report.Conflicts[0].Bindings[1].ProgramID != "keys" {
+		t.Fatalf("conflicts = %+v", report.Conflicts)
+	}
+
+	if err := cfg.Validate(context.Background(), allowOnly("hyprland", "kitty", "wofi"), SizeLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "keybind SUPER+Q is bound 2 times: ~/.config/hypr/hyprland.conf line 2, ~/.config/hypr/keys.conf line 1"
+	if len(cfg.Warnings) != 1 || cfg.Warnings[0] != want {
+		t.Errorf("warnings = %q, want %q", cfg.Warnings, want)
+	}
+}
@@ -0,0 +1,188 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"strings"
+)
+
+// Layouts of the files in an export archive.
+const (
+	// ExportLayoutHome lays files out relative to $HOME, to be unpacked into it.
+	ExportLayoutHome = "home"
+
+	// ExportLayoutStow puts the files of every program into a GNU Stow package named after it,
+	// e.g. waybar/.config/waybar/config, for stow -t ~ waybar.
+	ExportLayoutStow = "stow"
+
+	// ExportLayoutChezmoi lays files out as a chezmoi source directory: dot_ for leading dots,
+	// private_ and executable_ for modes, and files with template variables as .tmpl templates
+	// reading the variables from .chezmoidata.json.
+	ExportLayoutChezmoi = "chezmoi"
+)
+
+// Files the chezmoi layout adds to the root of an archive.
+const (
+	chezmoiIgnoreFile = ".chezmoiignore"
+	chezmoiDataFile   = ".chezmoidata.json"
+)
+
+// chezmoiPrefixes are the attribute prefixes of chezmoi source names. Names starting with one
+// get literal_ so chezmoi doesn't take them as an attribute.
+var chezmoiPrefixes = []string{
+	"after_", "before_", "create_", "dot_", "empty_", "encrypted_", "exact_", "executable_", "external_",
+	"literal_", "modify_", "once_", "onchange_", "private_", "readonly_", "remove_", "run_", "symlink_",
+}
+
+// ExportLayouts returns the layouts ExportConfigArchive supports.
+func ExportLayouts() []string {
+	return []string{ExportLayoutHome, ExportLayoutStow, ExportLayoutChezmoi}
+}
+
+type exportLayoutKey struct{}
+
+// WithExportLayout returns a context whose export archives use layout, one of ExportLayouts.
+func WithExportLayout(ctx context.Context, layout string) context.Context {
+	return context.WithValue(ctx, exportLayoutKey{}, layout)
+}
+
+// exportLayout returns the layout set by WithExportLayout, ExportLayoutHome by default. Unknown
+// layouts are an ErrValidation.
+func exportLayout(ctx context.Context) (string, error) {
+	layout, _ := ctx.Value(exportLayoutKey{}).(string)
+	layout = strings.ToLower(strings.TrimSpace(layout))
+	if layout == "" {
+		return ExportLayoutHome, nil
+	}
+	for _, l := range ExportLayouts() {
+		if layout == l {
+			return layout, nil
+		}
+	}
+	return "", invalidf("unsupported export layout %q, supported values are: %s", layout, strings.Join(ExportLayouts(), ", "))
+}
+
+// archiveManifest is the manifest of an export archive: the config and the layout of its files.
+type archiveManifest struct {
+	*HyprConfig
+	Layout string `json:"layout"`
+}
+
+// layoutPath returns where a file installed at name, relative to $HOME, goes in an archive with
+// layout. program is the program installing it; template marks chezmoi templates.
+func layoutPath(layout, name, program string, mode fs.FileMode, template bool) string {
+	switch layout {
+	case ExportLayoutStow:
+		return program + "/" + name
+	case ExportLayoutChezmoi:
+		return chezmoiPath(name, mode, template)
+	default:
+		return name
+	}
+}
+
+// chezmoiPath returns the chezmoi source path of a file installed at name, relative to $HOME.
+func chezmoiPath(name string, mode fs.FileMode, template bool) string {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		var attrs string
+		if i == len(parts)-1 {
+			if mode&0o077 == 0 {
+				attrs += "private_"
+			}
+			if mode&0o111 != 0 {
+				attrs += "executable_"
+			}
+		}
+		for _, prefix := range chezmoiPrefixes {
+			if strings.HasPrefix(part, prefix) {
+				part = "literal_" + part
+				break
+			}
+		}
+		if rest, ok := strings.CutPrefix(part, "."); ok {
+			part = "dot_" + rest
+		}
+		if i == len(parts)-1 {
+			if template {
+				part += ".tmpl"
+			} else if strings.HasSuffix(part, ".tmpl") || strings.HasSuffix(part, ".literal") {
+				part += ".literal"
+			}
+		}
+		parts[i] = attrs + part
+	}
+	return strings.Join(parts, "/")
+}
+
+// chezmoiTemplates returns an expand function for renderConfig turning the {{name}}
+// placeholders of a file into chezmoi's {{ .name }} and recording its path in templates, or nil
+// when cfg has no variables.
+func chezmoiTemplates(cfg *HyprConfig, templates map[string]bool) func(p string, data []byte) ([]byte, error) {
+	if len(cfg.Variables) == 0 {
+		return nil
+	}
+	fields := make(map[string]string, len(cfg.Variables))
+	for _, v := range cfg.Variables {
+		fields[v.Name] = "{{ ." + v.Name + " }}"
+	}
+	return func(p string, data []byte) ([]byte, error) {
+		if !bytes.Contains(data, []byte("{{")) {
+			return data, nil
+		}
+		templates[p] = true
+		return expandTemplateLiteral(data, fields, `{{ "{{" }}`)
+	}
+}
+
+// chezmoiRootFiles returns the files the chezmoi layout adds to the root of an archive: an
+// ignore file keeping the archive's own files out of $HOME, and the values of the template
+// variables, given or default, as template data.
+func chezmoiRootFiles(cfg *HyprConfig, values map[string]string) (map[string][]byte, error) {
+	ignore := []string{ManifestFile}
+	if cfg.Readme != "" {
+		ignore = append(ignore, ReadmeFile)
+	}
+	if cfg.License != "" {
+		ignore = append(ignore, LicenseFile)
+	}
+	files := map[string][]byte{chezmoiIgnoreFile: []byte(strings.Join(ignore, "\n") + "\n")}
+	if len(cfg.Variables) == 0 {
+		return files, nil
+	}
+
+	data := map[string]string{}
+	for _, v := range cfg.Variables {
+		value := values[v.Name]
+		if value == "" {
+			value = v.Default
+		}
+		if value != "" {
+			data[v.Name] = value
+		}
+	}
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[chezmoiDataFile] = append(encoded, '\n')
+	return files, nil
+}
+
+// filePrograms returns the program installing every file of cfg, keyed by path relative to
+// $HOME like the files of RenderConfig.
+func filePrograms(cfg *HyprConfig, extraPrefixes []string) map[string]string {
+	programs := map[string]string{}
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		for _, e := range pc.FileEntries() {
+			if p, err := pc.FilePath(e, extraPrefixes...); err == nil {
+				if _, ok := programs[p]; !ok {
+					programs[p] = pc.Program
+				}
+			}
+		}
+	})
+	return programs
+}
@@ -0,0 +1,142 @@
+package hyprconfig
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// layoutConfig has a template variable, a script and files of two programs.
+func layoutConfig() *HyprConfig {
+	cfg := waybarConfig()
+	cfg.Readme = "# bar\n"
+	cfg.Variables = []TemplateVariable{{Name: "font_size", Default: "13"}}
+	cfg.ProgramConfigs[0].Files[1].FileContent.Data = []byte("* { font-size: {{font_size}}px; } /* \\{{ */")
+	cfg.ProgramConfigs = append(cfg.ProgramConfigs, HyprProgramConfig{
+		ID: "term", Program: "kitty",
+		FileContent: FileContent{Data: []byte("font_size 12\n"), FileType: FileTypeConfig, Mode: "0600"},
+	})
+	return cfg
+}
+
+// readArchive returns the contents of the entries of a tar.gz by name.
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(tr)
+		entries[hdr.Name] = string(content)
+	}
+}
+
+func TestExportLayouts(t *testing.T) {
+	for _, tt := range []struct {
+		layout string
+		want   []string
+	}{
+		{"", []string{
+			".config/kitty/kitty.conf",
+			".config/waybar/config.jsonc",
+			".config/waybar/scripts/mpris.sh",
+			".config/waybar/style.css",
+			ReadmeFile,
+			ManifestFile,
+		}},
+		{ExportLayoutStow, []string{
+			ReadmeFile,
+			"kitty/.config/kitty/kitty.conf",
+			ManifestFile,
+			"waybar/.config/waybar/config.jsonc",
+			"waybar/.config/waybar/scripts/mpris.sh",
+			"waybar/.config/waybar/style.css",
+		}},
+		{ExportLayoutChezmoi, []string{
+			chezmoiDataFile,
+			chezmoiIgnoreFile,
+			ReadmeFile,
+			"dot_config/kitty/private_kitty.conf",
+			"dot_config/waybar/config.jsonc",
+			"dot_config/waybar/scripts/private_executable_mpris.sh",
+			"dot_config/waybar/style.css.tmpl",
+			ManifestFile,
+		}},
+	} {
+		t.Run(tt.layout, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx := WithTemplateValues(WithExportLayout(context.Background(), tt.layout), map[string]string{"font_size": "15"})
+			if err := writeConfigArchive(ctx, nil, layoutConfig(), &buf, nil); err != nil {
+				t.Fatal(err)
+			}
+			entries := readArchive(t, buf.Bytes())
+			names := make([]string, 0, len(entries))
+			for name := range entries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("entries = %q, want %q", names, tt.want)
+			}
+
+			var manifest struct {
+				Title  string `json:"title"`
+				Layout string `json:"layout"`
+			}
+			if err := json.Unmarshal([]byte(entries[ManifestFile]), &manifest); err != nil {
+				t.Fatal(err)
+			}
+			want := tt.layout
+			if want == "" {
+				want = ExportLayoutHome
+			}
+			if manifest.Title != "bar" || manifest.Layout != want {
+				t.Errorf("manifest = %+v, want layout %s", manifest, want)
+			}
+
+			if tt.layout != ExportLayoutChezmoi {
+				return
+			}
+			if got, want := entries["dot_config/waybar/style.css.tmpl"], `* { font-size: {{ .font_size }}px; } /* {{ "{{" }} */`; got != want {
+				t.Errorf("template = %q, want %q", got, want)
+			}
+			if got, want := entries[chezmoiDataFile], "{\n  \"font_size\": \"15\"\n}\n"; got != want {
+				t.Errorf("%s = %q, want %q", chezmoiDataFile, got, want)
+			}
+			if got, want := entries[chezmoiIgnoreFile], "manifest.json\nREADME.md\n"; got != want {
+				t.Errorf("%s = %q, want %q", chezmoiIgnoreFile, got, want)
+			}
+		})
+	}
+
+	err := writeConfigArchive(WithExportLayout(context.Background(), "yadm"), nil, layoutConfig(), io.Discard, nil)
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("unknown layout: got %v, want ErrValidation", err)
+	}
+
+	for name, want := range map[string]string{
+		".config/dot_files/x.tmpl": "dot_config/literal_dot_files/x.tmpl.literal",
+		".bashrc":                  "dot_bashrc",
+	} {
+		if got := chezmoiPath(name, 0o644, false); got != want {
+			t.Errorf("chezmoiPath(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// LicenseCustom is the License of a config under its own terms, given in LicenseText.
+	LicenseCustom = "custom"
+
+	// LicenseUnspecified is the ConfigSearchFilters.License matching configs without a license.
+	LicenseUnspecified = "unspecified"
+
+	// MaxLicenseTextLength bounds LicenseText, in characters.
+	MaxLicenseTextLength = 20000
+)
+
+// LicenseFile is the name of the config's license at the root of an export archive.
+const LicenseFile = "LICENSE"
+
+var ErrInvalidLicense = errors.New("invalid license")
+
+// commonLicenses are suggested when a license isn't recognized.
+var commonLicenses = []string{"MIT", "Apache-2.0", "GPL-3.0-or-later", "BSD-3-Clause", "MPL-2.0", "CC-BY-SA-4.0", "Unlicense"}
+
+// spdxLicenses are the SPDX identifiers a config may be licensed under: the OSI approved and
+// FSF free licenses people commonly pick, plus the Creative Commons licenses used for artwork.
+var spdxLicenses = []string{
+	"0BSD", "AFL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later", "Apache-1.1", "Apache-2.0",
+	"Artistic-2.0", "BlueOak-1.0.0", "BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-Patent",
+	"BSD-3-Clause", "BSD-3-Clause-Clear", "BSD-4-Clause", "BSL-1.0", "CC-BY-3.0", "CC-BY-4.0",
+	"CC-BY-NC-4.0", "CC-BY-NC-ND-4.0", "CC-BY-NC-SA-4.0", "CC-BY-ND-4.0", "CC-BY-SA-3.0",
+	"CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0", "CDDL-1.1", "CECILL-2.1", "ECL-2.0", "EPL-1.0",
+	"EPL-2.0", "EUPL-1.1", "EUPL-1.2", "GFDL-1.3-only", "GFDL-1.3-or-later", "GPL-2.0-only",
+	"GPL-2.0-or-later", "GPL-3.0-only", "GPL-3.0-or-later", "ISC", "LGPL-2.0-only",
+	"LGPL-2.0-or-later", "LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0-only",
+	"LGPL-3.0-or-later", "LPPL-1.3c", "MIT", "MIT-0", "MPL-1.1", "MPL-2.0", "MS-PL", "MS-RL",
+	"MulanPSL-2.0", "NCSA", "ODbL-1.0", "OFL-1.1", "OSL-3.0", "PostgreSQL", "Python-2.0",
+	"UPL-1.0", "Unlicense", "Vim", "W3C", "WTFPL", "X11", "Zlib", "ZPL-2.1",
+}
+
+// licenseAliases maps deprecated SPDX identifiers to the current ones.
+var licenseAliases = map[string]string{
+	"agpl-3.0":  "AGPL-3.0-only",
+	"agpl-3.0+": "AGPL-3.0-or-later",
+	"gpl-2.0":   "GPL-2.0-only",
+	"gpl-2.0+":  "GPL-2.0-or-later",
+	"gpl-3.0":   "GPL-3.0-only",
+	"gpl-3.0+":  "GPL-3.0-or-later",
+	"lgpl-2.1":  "LGPL-2.1-only",
+	"lgpl-2.1+": "LGPL-2.1-or-later",
+	"lgpl-3.0":  "LGPL-3.0-only",
+	"lgpl-3.0+": "LGPL-3.0-or-later",
+}
+
+// Licenses returns the SPDX identifiers accepted as a config's License, besides LicenseCustom.
+func Licenses() []string {
+	return append([]string(nil), spdxLicenses...)
+}
+
+// NormalizeLicense returns the SPDX identifier for id as SPDX spells it, accepting any case and
+// deprecated identifiers, or LicenseCustom.
+func NormalizeLicense(id string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(id))
+	if name == LicenseCustom {
+		return LicenseCustom, nil
+	}
+	if canonical, ok := licenseAliases[name]; ok {
+		return canonical, nil
+	}
+	for _, canonical := range spdxLicenses {
+		if name == strings.ToLower(canonical) {
+			return canonical, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q, use an SPDX identifier such as %s, or %q with a license_text",
+		ErrInvalidLicense, id, strings.Join(commonLicenses, ", "), LicenseCustom)
+}
+
+// normalizeLicense canonicalizes hc.License, recording an unknown one in verr. LicenseText is
+// required by, and only kept for, LicenseCustom.
+func (hc *HyprConfig) normalizeLicense(verr *ValidationError) {
+	hc.LicenseText = strings.TrimSpace(stripControl(hc.LicenseText, true))
+	if strings.TrimSpace(hc.License) == "" {
+		hc.License, hc.LicenseText = "", ""
+		return
+	}
+
+	license, err := NormalizeLicense(hc.License)
+	if err != nil {
+		verr.add("license", err)
+		return
+	}
+	hc.License = license
+	if license != LicenseCustom {
+		hc.LicenseText = ""
+		return
+	}
+	if hc.LicenseText == "" {
+		verr.addf("license_text", CodeRequired, "license_text is required with the %s license", LicenseCustom)
+	} else if n := utf8.RuneCountInString(hc.LicenseText); n > MaxLicenseTextLength {
+		verr.addf("license_text", CodeTooLong, "license_text is %d characters, at most %d allowed", n, MaxLicenseTextLength)
+	}
+}
+
+// licenseFile returns the LICENSE of an export archive: the custom text, or a pointer to the
+// SPDX license.
+func licenseFile(cfg *HyprConfig) string {
+	if cfg.License == LicenseCustom {
+		return cfg.LicenseText + "\n"
+	}
+	return fmt.Sprintf("SPDX-License-Identifier: %s\n\nThe full text is at https://spdx.org/licenses/%s.html\n", cfg.License, cfg.License)
+}
@@ -0,0 +1,94 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLicense(t *testing.T) {
+	for in, want := range map[string]string{
+		"MIT":          "MIT",
+		" apache-2.0 ": "Apache-2.0",
+		"gpl-3.0":      "GPL-3.0-only",
+		"GPL-2.0+":     "GPL-2.0-or-later",
+		"cc-by-sa-4.0": "CC-BY-SA-4.0",
+		"Custom":       LicenseCustom,
+	} {
+		if got, err := NormalizeLicense(in); err != nil || got != want {
+			t.Errorf("NormalizeLicense(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	_, err := NormalizeLicense("Proprietary")
+	if !errors.Is(err, ErrInvalidLicense) || !strings.Contains(err.Error(), "MIT, Apache-2.0") {
+		t.Errorf("unknown license: got %v, want ErrInvalidLicense suggesting common licenses", err)
+	}
+}
+
+func TestConfigLicense(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		create := func(license, text string) (*HyprConfig, error) {
+			return m.CreateConfig(alice, &HyprConfig{
+				Title:          "rice",
+				License:        license,
+				LicenseText:    text,
+				ProgramConfigs: []HyprProgramConfig{{ID: "term", Title: "term", Program: "kitty"}},
+			})
+		}
+
+		mit, err := create("mit", "ignored")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mit.License != "MIT" || mit.LicenseText != "" {
+			t.Errorf("license = %q, %q; want MIT without text", mit.License, mit.LicenseText)
+		}
+		custom, err := create(LicenseCustom, "  Do what you want, but credit me.  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		unlicensed := newTestConfig(t, m, "alice", false)
+
+		for _, tt := range []struct {
+			license, text, path, code string
+		}{
+			{"Proprietary", "", "license", CodeInvalidLicense},
+			{LicenseCustom, " ", "license_text", CodeRequired},
+			{LicenseCustom, strings.Repeat("x", MaxLicenseTextLength+1), "license_text", CodeTooLong},
+		} {
+			_, err := create(tt.license, tt.text)
+			var verr *ValidationError
+			if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != tt.path || verr.Errors[0].Code != tt.code {
+				t.Errorf("license %q: got %v, want a %s error on %s", tt.license, err, tt.code, tt.path)
+			}
+		}
+
+		for license, want := range map[string]string{
+			"Mit":              mit.ID,
+			LicenseCustom:      custom.ID,
+			"Unspecified":      unlicensed.ID,
+			"GPL-3.0-or-later": "",
+		} {
+			page, err := m.ListConfigsWithFilters(alice, 1, 10, ConfigSearchFilters{License: license}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(configIDs(page.Items), ","); got != want {
+				t.Errorf("license=%s matched %q, want %q", license, got, want)
+			}
+		}
+		if _, err := m.ListConfigsWithFilters(alice, 1, 10, ConfigSearchFilters{License: "beerware"}, nil); !errors.Is(err, ErrInvalidLicense) {
+			t.Errorf("unknown license filter: got %v, want ErrInvalidLicense", err)
+		}
+
+		// Leaving the custom license drops its text
+		if err := m.UpdateConfig(alice, custom.ID, map[string]any{"license": "0bsd"}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := m.GetConfig(alice, custom.ID); err != nil || got.License != "0BSD" || got.LicenseText != "" {
+			t.Errorf("after changing the license: %q, %q, %v", got.License, got.LicenseText, err)
+		}
+	})
+}
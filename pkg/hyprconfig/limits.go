@@ -0,0 +1,159 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrContentTooLarge  = errors.New("file content too large")
+	ErrBinaryNotAllowed = errors.New("binary file content is not allowed")
+)
+
+// SizeLimits bounds how much FileContent a config may carry. A zero limit means unlimited.
+// Sizes are measured on uncompressed data.
+type SizeLimits struct {
+	MaxTextBytes   int64 `usage:"max bytes per text, config or script file"`
+	MaxImageBytes  int64 `usage:"max bytes per image file"`
+	MaxBinaryBytes int64 `usage:"max bytes per binary file"`
+	MaxConfigBytes int64 `usage:"max total file bytes per config"`
+	MaxUserBytes   int64 `usage:"max total file bytes per user across their configs, unless an admin set their quota"`
+	AllowBinary    bool  `usage:"allow FileTypeBinary content to be uploaded"`
+	MaxUserConfigs int64 `usage:"max configs a user may own, unless an admin set their limit; admins have none"`
+
+	// ExtraInstallPrefixes are directories below $HOME allowed as install paths on top of
+	// DefaultInstallPrefixes, e.g. ~/.local/bin.
+	ExtraInstallPrefixes []string `usage:"extra directories below ~/ that program files may be installed into"`
+
+	// UnsafeCommandPatterns are regular expressions that Args and exec lines of public configs
+	// must not match. Empty disables the screening.
+	UnsafeCommandPatterns []string `usage:"regular expressions for commands public configs may not run"`
+}
+
+// DefaultSizeLimits keeps a whole config comfortably under Mongo's 16 MB document limit.
+func DefaultSizeLimits() SizeLimits {
+	return SizeLimits{
+		MaxTextBytes:   1 << 20,
+		MaxImageBytes:  4 << 20,
+		MaxBinaryBytes: 8 << 20,
+		MaxConfigBytes: 12 << 20,
+		MaxUserBytes:   100 << 20,
+		AllowBinary:    false,
+		MaxUserConfigs: 100,
+
+		UnsafeCommandPatterns: append([]string{}, DefaultUnsafeCommandPatterns...),
+	}
+}
+
+// MaxRequestBytes is the largest request body worth reading for a config, allowing for
+// base64 encoding of Data and some JSON overhead.
+func (l SizeLimits) MaxRequestBytes() int64 {
+	if l.MaxConfigBytes <= 0 {
+		return 0
+	}
+	return l.MaxConfigBytes*4/3 + 1<<20
+}
+
+// maxFileBytes returns the per-file ceiling for a file type.
+func (l SizeLimits) maxFileBytes(fileType string) int64 {
+	switch fileType {
+	case FileTypeImage:
+		return l.MaxImageBytes
+	case FileTypeBinary:
+		return l.MaxBinaryBytes
+	default:
+		return l.MaxTextBytes
+	}
+}
+
+// CheckFile enforces the per-file limits on a single FileContent.
+func (l SizeLimits) CheckFile(fc FileContent) error {
+	limit := l.maxFileBytes(fc.FileType)
+	size := fc.sizeUpTo(limit)
+	if size == 0 {
+		return nil
+	}
+	if fc.FileType == FileTypeBinary && !l.AllowBinary {
+		return ErrBinaryNotAllowed
+	}
+	if limit > 0 && size > limit && fc.Encoding == EncodingGzip {
+		// Measuring stopped past the limit
+		return fmt.Errorf("%w: decompresses to more than the %d byte limit for %s files",
+			ErrContentTooLarge, limit, fileTypeName(fc.FileType))
+	}
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %d bytes is %d over the %d byte limit for %s files",
+			ErrContentTooLarge, size, size-limit, limit, fileTypeName(fc.FileType))
+	}
+	return nil
+}
+
+// checkTotal enforces the per-config limit on the combined size of all file content.
+func (l SizeLimits) checkTotal(size int64) error {
+	if l.MaxConfigBytes > 0 && size > l.MaxConfigBytes {
+		return fmt.Errorf("%w: config file content totals %d bytes, %d over the %d byte limit",
+			ErrContentTooLarge, size, size-l.MaxConfigBytes, l.MaxConfigBytes)
+	}
+	return nil
+}
+
+func fileTypeName(fileType string) string {
+	if fileType == "" {
+		return FileTypeText
+	}
+	return fileType
+}
+
+// size returns the uncompressed size of the content.
+func (fc FileContent) size() int64 {
+	return fc.sizeUpTo(0)
+}
+
+// sizeUpTo returns the uncompressed size of the content. Gzip data is measured by decompressing
+// it, without keeping the result, rather than trusting its trailer, which whoever compressed it
+// controls. With a limit over 0 measuring stops past it, so at most limit+1 is returned.
+func (fc FileContent) sizeUpTo(limit int64) int64 {
+	if fc.FileID != "" && len(fc.Data) == 0 {
+		return fc.Size
+	}
+	if fc.Encoding != EncodingGzip {
+		return int64(len(fc.Data))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(fc.Data))
+	if err != nil {
+		return int64(len(fc.Data))
+	}
+	defer zr.Close()
+	var r io.Reader = zr
+	if limit > 0 {
+		r = io.LimitReader(zr, limit+1)
+	}
+	n, _ := io.Copy(io.Discard, r)
+	return n
+}
+
+// contentSize returns the number of bytes of file content in the program config tree.
+func (pc *HyprProgramConfig) contentSize() int64 {
+	var size int64
+	pc.eachFile(func(_ string, fc *FileContent) {
+		size += fc.size()
+	})
+	for _, sub := range pc.SubConfigs {
+		if sub != nil {
+			size += sub.contentSize()
+		}
+	}
+	return size
+}
+
+// contentSize returns the number of bytes of file content in the config.
+func (hc *HyprConfig) contentSize() int64 {
+	var size int64
+	for i := range hc.ProgramConfigs {
+		size += hc.ProgramConfigs[i].contentSize()
+	}
+	return size
+}
@@ -0,0 +1,184 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of LintFinding.
+const (
+	LintDeprecated   = "deprecated"    // still read by Hyprland, but going away
+	LintRemoved      = "removed"       // no longer read by Hyprland, which reports a config error
+	LintInvalidValue = "invalid_value" // a value Hyprland can't parse for the keyword
+)
+
+// hyprLintRule is a check of a keyword of a Hyprland config.
+type hyprLintRule struct {
+	keyword     string // the keyword's path, e.g. decoration:drop_shadow
+	kind        string
+	version     string // the Hyprland release that deprecated or removed the keyword, if known
+	replacement string // the keyword to use instead of a deprecated or removed one
+	valueType   string // the type of the value for LintInvalidValue, a key of hyprValueTypes
+	note        string // added to the message, e.g. how the replacement differs
+}
+
+// hyprLintRules are the checks LintHyprConfig runs. New checks are entries here; only a new value
+// type needs code, in hyprValueTypes.
+var hyprLintRules = []hyprLintRule{
+	// The shadow options moved to their own category
+	{keyword: "decoration:drop_shadow", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:enabled"},
+	{keyword: "decoration:shadow_range", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:range"},
+	{keyword: "decoration:shadow_render_power", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:render_power"},
+	{keyword: "decoration:shadow_ignore_window", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:ignore_window"},
+	{keyword: "decoration:shadow_offset", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:offset"},
+	{keyword: "decoration:shadow_scale", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:scale"},
+	{keyword: "decoration:col.shadow", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:color"},
+	{keyword: "decoration:col.shadow_inactive", kind: LintRemoved, version: "0.45.0", replacement: "decoration:shadow:color_inactive"},
+
+	// So did the blur options, long before
+	{keyword: "decoration:blur", kind: LintRemoved, replacement: "decoration:blur:enabled", note: "blur is a category now"},
+	{keyword: "decoration:blur_size", kind: LintRemoved, replacement: "decoration:blur:size"},
+	{keyword: "decoration:blur_passes", kind: LintRemoved, replacement: "decoration:blur:passes"},
+	{keyword: "decoration:blur_new_optimizations", kind: LintRemoved, replacement: "decoration:blur:new_optimizations"},
+
+	// And the cursor and rendering options
+	{keyword: "general:no_cursor_warps", kind: LintRemoved, version: "0.41.0", replacement: "cursor:no_warps"},
+	{keyword: "general:cursor_inactive_timeout", kind: LintRemoved, version: "0.41.0", replacement: "cursor:inactive_timeout"},
+	{keyword: "misc:no_direct_scanout", kind: LintRemoved, version: "0.42.0", replacement: "render:direct_scanout", note: "its value is inverted"},
+	{keyword: "general:sensitivity", kind: LintRemoved, replacement: "input:sensitivity"},
+
+	{keyword: "dwindle:no_gaps_when_only", kind: LintRemoved, version: "0.45.0", note: "use workspace rules for smart gaps"},
+	{keyword: "master:no_gaps_when_only", kind: LintRemoved, version: "0.45.0", note: "use workspace rules for smart gaps"},
+	{keyword: "master:new_is_master", kind: LintRemoved, version: "0.41.0", replacement: "master:new_status", note: "set it to master"},
+
+	// Touchpad gestures are gesture = lines now
+	{keyword: "gestures:workspace_swipe", kind: LintDeprecated, version: "0.51.0", replacement: "gesture", note: "e.g. gesture = 3, horizontal, workspace"},
+	{keyword: "gestures:workspace_swipe_fingers", kind: LintDeprecated, version: "0.51.0", replacement: "gesture"},
+
+	{keyword: "general:border_size", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "general:resize_on_border", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "general:allow_tearing", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "decoration:rounding", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "decoration:active_opacity", kind: LintInvalidValue, valueType: "float"},
+	{keyword: "decoration:inactive_opacity", kind: LintInvalidValue, valueType: "float"},
+	{keyword: "decoration:shadow:enabled", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "decoration:shadow:range", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "decoration:blur:enabled", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "decoration:blur:size", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "decoration:blur:passes", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "decoration:blur:vibrancy", kind: LintInvalidValue, valueType: "float"},
+	{keyword: "animations:enabled", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "input:follow_mouse", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "input:sensitivity", kind: LintInvalidValue, valueType: "float"},
+	{keyword: "input:touchpad:natural_scroll", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "dwindle:pseudotile", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "dwindle:preserve_split", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "misc:force_default_wallpaper", kind: LintInvalidValue, valueType: "int"},
+	{keyword: "misc:disable_hyprland_logo", kind: LintInvalidValue, valueType: "bool"},
+	{keyword: "misc:vfr", kind: LintInvalidValue, valueType: "bool"},
+}
+
+// hyprValueTypes report whether a value parses as the type Hyprland reads it as.
+var hyprValueTypes = map[string]func(string) bool{
+	// Hyprland only looks at how a bool starts, so the default config's yes, please :) is true
+	"bool": func(v string) bool {
+		v = strings.ToLower(v)
+		for _, word := range []string{"true", "false", "yes", "no", "on", "off"} {
+			if strings.HasPrefix(v, word) {
+				return true
+			}
+		}
+		_, err := strconv.ParseInt(v, 0, 64)
+		return err == nil
+	},
+	"int": func(v string) bool {
+		_, err := strconv.ParseInt(v, 0, 64)
+		return err == nil
+	},
+	"float": func(v string) bool {
+		_, err := strconv.ParseFloat(v, 64)
+		return err == nil
+	},
+}
+
+// LintHyprConfig checks the keywords of a Hyprland config against hyprLintRules and returns what
+// they find in file order. $variables are resolved before values are checked; values using
+// variables the config doesn't declare aren't.
+func LintHyprConfig(data []byte) []LintFinding {
+	doc := ParseHyprDoc(data)
+	return doc.Lint(hyprRuleLinter(resolveHyprVariables(doc.Variables())))
+}
+
+// hyprRuleLinter returns a KeywordLinter running hyprLintRules, with the variables of vars.
+func hyprRuleLinter(vars map[string]string) KeywordLinter {
+	return func(path string, n *HyprNode) []LintFinding {
+		var findings []LintFinding
+		for _, rule := range hyprLintRules {
+			if rule.keyword != path {
+				continue
+			}
+			value := expandHyprVariables(n.Value, vars, nil)
+			if rule.kind == LintInvalidValue && (strings.Contains(value, "$") || hyprValueTypes[rule.valueType](value)) {
+				continue
+			}
+			findings = append(findings, LintFinding{
+				Line:        n.Line,
+				Keyword:     path,
+				Kind:        rule.kind,
+				Replacement: rule.replacement,
+				Message:     rule.message(value),
+			})
+		}
+		return findings
+	}
+}
+
+// message describes a finding of r for a keyword set to value.
+func (r hyprLintRule) message(value string) string {
+	var msg string
+	switch r.kind {
+	case LintDeprecated:
+		msg = r.keyword + " is deprecated"
+		if r.version != "" {
+			msg += " since Hyprland " + r.version
+		}
+	case LintRemoved:
+		msg = r.keyword + " was removed"
+		if r.version != "" {
+			msg += " in Hyprland " + r.version
+		}
+	default:
+		msg = fmt.Sprintf("%s = %s is not a valid %s", r.keyword, value, r.valueType)
+	}
+	if r.replacement != "" {
+		msg += ", use " + r.replacement + " instead"
+	}
+	if r.note != "" {
+		msg += ": " + r.note
+	}
+	return msg
+}
+
+// LintConfig runs LintHyprConfig on every Hyprland text and config file of cfg, including sub
+// configs, noting the program config and file of each finding.
+func LintConfig(cfg *HyprConfig) []LintFinding {
+	findings := []LintFinding{}
+	eachHyprlandFile(cfg, func(pc *HyprProgramConfig, e FileEntry, data []byte) {
+		for _, f := range LintHyprConfig(data) {
+			f.ProgramID = pc.ID
+			f.File = e.TargetPath
+			findings = append(findings, f)
+		}
+	})
+	return findings
+}
+
+// LintWarnings returns a validation warning for each lint finding of cfg.
+func LintWarnings(cfg *HyprConfig) []string {
+	var warnings []string
+	for _, f := range LintConfig(cfg) {
+		warnings = append(warnings, f.String())
+	}
+	return warnings
+}
@@ -0,0 +1,155 @@
+package hyprconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// lintRuleTests has a line for each rule of hyprLintRules, and the finding it makes. Values are
+// checked only when they don't parse.
+var lintRuleTests = []struct {
+	line string
+	kind string // "" when the line is fine
+}{
+	{"decoration:drop_shadow = yes", LintRemoved},
+	{"decoration:shadow_range = 4", LintRemoved},
+	{"decoration:shadow_render_power = 3", LintRemoved},
+	{"decoration:shadow_ignore_window = true", LintRemoved},
+	{"decoration:shadow_offset = 0 0", LintRemoved},
+	{"decoration:shadow_scale = 1.0", LintRemoved},
+	{"decoration:col.shadow = rgba(1a1a1aee)", LintRemoved},
+	{"decoration:col.shadow_inactive = rgba(1a1a1a00)", LintRemoved},
+	{"decoration:blur = yes", LintRemoved},
+	{"decoration:blur_size = 3", LintRemoved},
+	{"decoration:blur_passes = 1", LintRemoved},
+	{"decoration:blur_new_optimizations = on", LintRemoved},
+	{"general:no_cursor_warps = true", LintRemoved},
+	{"general:cursor_inactive_timeout = 5", LintRemoved},
+	{"misc:no_direct_scanout = true", LintRemoved},
+	{"general:sensitivity = 1.0", LintRemoved},
+	{"dwindle:no_gaps_when_only = 1", LintRemoved},
+	{"master:no_gaps_when_only = 1", LintRemoved},
+	{"master:new_is_master = true", LintRemoved},
+	{"gestures:workspace_swipe = on", LintDeprecated},
+	{"gestures:workspace_swipe_fingers = 3", LintDeprecated},
+	{"general:border_size = 2px", LintInvalidValue},
+	{"general:border_size = 2", ""},
+	{"general:resize_on_border = sometimes", LintInvalidValue},
+	{"general:allow_tearing = false", ""},
+	{"general:allow_tearing = nein", LintInvalidValue},
+	{"decoration:rounding = 10.5", LintInvalidValue},
+	{"decoration:active_opacity = 90%", LintInvalidValue},
+	{"decoration:inactive_opacity = 0.8", ""},
+	{"decoration:inactive_opacity = .8f", LintInvalidValue},
+	{"decoration:shadow:enabled = enable", LintInvalidValue},
+	{"decoration:shadow:range = far", LintInvalidValue},
+	{"decoration:blur:enabled = YES", ""},
+	{"decoration:blur:enabled = maybe", LintInvalidValue},
+	{"decoration:blur:size = big", LintInvalidValue},
+	{"decoration:blur:passes = 1.5", LintInvalidValue},
+	{"decoration:blur:vibrancy = high", LintInvalidValue},
+	{"animations:enabled = yes, please :)", ""},
+	{"animations:enabled = enable", LintInvalidValue},
+	{"input:follow_mouse = always", LintInvalidValue},
+	{"input:sensitivity = -0.5", ""},
+	{"input:sensitivity = slow", LintInvalidValue},
+	{"input:touchpad:natural_scroll = natural", LintInvalidValue},
+	{"dwindle:pseudotile = enabled", LintInvalidValue},
+	{"dwindle:preserve_split = keep", LintInvalidValue},
+	{"misc:force_default_wallpaper = 0x1", ""},
+	{"misc:force_default_wallpaper = none", LintInvalidValue},
+	{"misc:disable_hyprland_logo = ja", LintInvalidValue},
+	{"misc:vfr = off", ""},
+	{"misc:vfr = sometimes", LintInvalidValue},
+}
+
+func TestLintHyprConfigRules(t *testing.T) {
+	tested := map[string]bool{}
+	for _, tt := range lintRuleTests {
+		findings := LintHyprConfig([]byte(tt.line))
+		if tt.kind == "" {
+			if len(findings) != 0 {
+				t.Errorf("%s: findings = %+v, want none", tt.line, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 || findings[0].Kind != tt.kind || findings[0].Line != 1 {
+			t.Errorf("%s: findings = %+v, want one %s", tt.line, findings, tt.kind)
+			continue
+		}
+		tested[findings[0].Keyword+"/"+findings[0].Kind] = true
+	}
+	for _, rule := range hyprLintRules {
+		if !tested[rule.keyword+"/"+rule.kind] {
+			t.Errorf("rule %s (%s) has no test in lintRuleTests", rule.keyword, rule.kind)
+		}
+		if rule.kind == LintInvalidValue && hyprValueTypes[rule.valueType] == nil {
+			t.Errorf("rule %s has unknown value type %q", rule.keyword, rule.valueType)
+		}
+	}
+}
+
+func TestLintHyprConfig(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "lint", "outdated.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range LintHyprConfig(data) {
+		got = append(got, fmt.Sprintf("%d %s %s: %s", f.Line, f.Kind, f.Replacement, f.Message))
+	}
+	want := []string{
+		"6 removed decoration:shadow:enabled: decoration:drop_shadow was removed in Hyprland 0.45.0, use decoration:shadow:enabled instead",
+		"7 removed decoration:shadow:range: decoration:shadow_range was removed in Hyprland 0.45.0, use decoration:shadow:range instead",
+		"8 removed decoration:shadow:color: decoration:col.shadow was removed in Hyprland 0.45.0, use decoration:shadow:color instead",
+		"10 invalid_value : decoration:blur:enabled = maybe is not a valid bool",
+		"11 invalid_value : decoration:blur:size = big is not a valid int",
+		"17 removed cursor:no_warps: general:no_cursor_warps was removed in Hyprland 0.41.0, use cursor:no_warps instead",
+		"21 removed render:direct_scanout: misc:no_direct_scanout was removed in Hyprland 0.42.0, use render:direct_scanout instead: its value is inverted",
+		"24 deprecated gesture: gestures:workspace_swipe is deprecated since Hyprland 0.51.0, use gesture instead: e.g. gesture = 3, horizontal, workspace",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("LintHyprConfig =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintHyprConfigDefaultConfig(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "hyprdoc", "default.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LintFinding{{Line: 119, Keyword: "gestures:workspace_swipe", Kind: LintDeprecated, Replacement: "gesture",
+		Message: "gestures:workspace_swipe is deprecated since Hyprland 0.51.0, use gesture instead: e.g. gesture = 3, horizontal, workspace"}}
+	if got := LintHyprConfig(data); !slices.Equal(got, want) {
+		t.Errorf("LintHyprConfig = %+v, want %+v", got, want)
+	}
+}
+
+func TestConfigValidateLintWarnings(t *testing.T) {
+	cfg := &HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{{
+			ID: "hypr", Title: "hyprland", Program: "hyprland",
+			FileContent: FileContent{Data: []byte("decoration {\n    drop_shadow = yes\n}\n"), FileType: FileTypeConfig},
+		}, {
+			// Only Hyprland files are linted
+			ID: "term", Title: "kitty", Program: "kitty",
+			FileContent: FileContent{Data: []byte("decoration:drop_shadow = yes\n"), FileType: FileTypeConfig},
+		}},
+	}
+	if err := cfg.Validate(context.Background(), allowOnly("hyprland", "kitty"), SizeLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "~/.config/hypr/hyprland.conf line 2: decoration:drop_shadow was removed in Hyprland 0.45.0, use decoration:shadow:enabled instead"
+	if len(cfg.Warnings) != 1 || cfg.Warnings[0] != want {
+		t.Errorf("warnings = %q, want %q", cfg.Warnings, want)
+	}
+	if findings := LintConfig(cfg); len(findings) != 1 || findings[0].ProgramID != "hypr" {
+		t.Errorf("LintConfig = %+v", findings)
+	}
+}
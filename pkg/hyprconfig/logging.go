@@ -0,0 +1,98 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+
+	"github.com/Seann-Moser/credentials/session"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns ctx carrying the id of the request it serves, which the config
+// manager adds to its logs.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id set by ContextWithRequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithLogger sets the logger of the config manager, slog.Default() when not set.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *ConfigManagerMongo) {
+		m.logger = logger
+	}
+}
+
+func (m *ConfigManagerMongo) log() *slog.Logger {
+	if m.logger == nil {
+		return slog.Default()
+	}
+	return m.logger
+}
+
+// logMutation logs the outcome of the mutation op with the user and request it was made for.
+// Successful mutations are logged at info level, denied and invalid ones at warn level, other
+// domain errors at debug level and storage failures at error level. attrs identify what was
+// changed: never pass file content, env var values or secrets. Validation failures are logged by
+// field path and code only, since their messages may quote the rejected values.
+func (m *ConfigManagerMongo) logMutation(ctx context.Context, op string, err error, attrs ...slog.Attr) {
+	attrs = append(attrs, slog.String("op", op))
+	if user, sessErr := session.GetSession(ctx); sessErr == nil && user != nil {
+		attrs = append(attrs, slog.String("user_id", user.UserID))
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	level := slog.LevelInfo
+	outcome := ErrorType(err)
+	switch outcome {
+	case "":
+		outcome = "ok"
+	case "forbidden", "unauthorized":
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("err", err.Error()))
+	case "validation":
+		level = slog.LevelWarn
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			problems := make([]string, 0, len(verr.Errors))
+			for _, fe := range verr.Errors {
+				problems = append(problems, fe.Path+": "+fe.Code)
+			}
+			attrs = append(attrs, slog.Any("problems", problems))
+		}
+	case "internal":
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("err", err.Error()))
+	default:
+		level = slog.LevelDebug
+	}
+	attrs = append(attrs, slog.String("outcome", outcome))
+	m.log().LogAttrs(ctx, level, "config mutation", attrs...)
+}
+
+// parentAttr is the parent_id attribute of a program config mutation, empty at the top level.
+func parentAttr(parentID *string) slog.Attr {
+	if parentID == nil {
+		return slog.Attr{}
+	}
+	return slog.String("parent_id", *parentID)
+}
+
+// updateFields returns the sorted names of the fields an update sets, without their values.
+func updateFields(updates map[string]any) []string {
+	fields := make([]string, 0, len(updates))
+	for k := range updates {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,70 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogMutation(t *testing.T) {
+	var buf bytes.Buffer
+	m := &ConfigManagerMongo{logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+	ctx := ContextWithRequestID(asUser("alice"), "req-1")
+
+	verr := &ValidationError{}
+	verr.add("program_configs[0].env", invalidf("variable TOKEN: %w: value %q", ErrInvalidEnvVar, "hunter2"))
+	for _, tc := range []struct {
+		err     error
+		level   string
+		outcome string
+	}{
+		{nil, "INFO", "ok"},
+		{ErrForbidden, "WARN", "forbidden"},
+		{verr, "WARN", "validation"},
+		{ErrNotFound, "DEBUG", "not_found"},
+		{errors.New("connection refused"), "ERROR", "internal"},
+	} {
+		buf.Reset()
+		m.logMutation(ctx, "UpdateProgramConfig", tc.err, slog.String("config_id", "cfg"), slog.String("prog_id", "term"), parentAttr(nil))
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("%v: %v in %s", tc.err, err, buf.String())
+		}
+		want := map[string]any{
+			"level":      tc.level,
+			"outcome":    tc.outcome,
+			"op":         "UpdateProgramConfig",
+			"user_id":    "alice",
+			"request_id": "req-1",
+			"config_id":  "cfg",
+			"prog_id":    "term",
+		}
+		for k, v := range want {
+			if entry[k] != v {
+				t.Errorf("%v: %s = %v, want %v", tc.err, k, entry[k], v)
+			}
+		}
+		if _, ok := entry["parent_id"]; ok {
+			t.Errorf("%v: parent_id logged for a top-level program config", tc.err)
+		}
+	}
+
+	buf.Reset()
+	m.logMutation(ctx, "UpdateProgramConfig", verr)
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("validation failure logged a rejected value: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "program_configs[0].env: "+CodeInvalidEnvVar) {
+		t.Errorf("validation failure did not log the problem path and code: %s", buf.String())
+	}
+
+	buf.Reset()
+	m.logMutation(context.Background(), "SeedDefaultPrograms", nil)
+	if strings.Contains(buf.String(), "user_id") || strings.Contains(buf.String(), "request_id") {
+		t.Errorf("logged a user or request without one in the context: %s", buf.String())
+	}
+}
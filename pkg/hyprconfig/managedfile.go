@@ -0,0 +1,220 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	CustomStart = "### CUSTOM START"
+	CustomEnd   = "### CUSTOM END"
+)
+
+// ManagedFile is a config file, usually hyprland.conf, that apply writes a managed block into:
+// the user's own lines, their custom sections, which apply keeps as they are, and the block
+// between ManagedStart and ManagedEnd that apply regenerates.
+type ManagedFile struct {
+	// Sources are the paths of the source = lines outside the managed block, as written.
+	Sources []string
+
+	// Custom are the sections between CustomStart and CustomEnd, in file order.
+	Custom []CustomSection
+
+	// Body is the file without its custom sections and managed block.
+	Body string
+
+	// Managed is the content of the managed block without its markers, empty when there is none.
+	// Setting it is the only change Bytes writes back.
+	Managed string
+
+	parts      []managedPart
+	oldManaged string
+}
+
+// CustomSection is a block of user lines that survives applies. Several can be told apart by the
+// label after the start marker, like "### CUSTOM START keybinds".
+type CustomSection struct {
+	Label   string
+	Content string // the lines between the markers, byte for byte
+}
+
+// managedPart is a run of lines of a ManagedFile in file order: body text, a custom section or
+// the managed block.
+type managedPart struct {
+	text    string // the raw lines, markers included
+	managed bool
+}
+
+// ParseManagedFile reads the file at path into a ManagedFile. Markers inside a custom section
+// are part of its content. A section missing its end marker runs to the end of the file.
+func ParseManagedFile(path string) (*ManagedFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseManagedFile(string(data)), nil
+}
+
+func parseManagedFile(text string) *ManagedFile {
+	f := &ManagedFile{}
+	var body, current strings.Builder
+	inCustom, inManaged := false, false
+	var label, startLine string
+
+	flush := func(part managedPart) {
+		part.text = current.String()
+		if part.text != "" {
+			f.parts = append(f.parts, part)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inCustom:
+			current.WriteString(line)
+			if _, ok := markerLabel(trimmed, CustomEnd); ok {
+				content := current.String()[len(startLine) : current.Len()-len(line)]
+				f.Custom = append(f.Custom, CustomSection{Label: label, Content: content})
+				flush(managedPart{})
+				inCustom = false
+			}
+		case inManaged:
+			current.WriteString(line)
+			if _, ok := markerLabel(trimmed, ManagedEnd); ok {
+				flush(managedPart{managed: true})
+				inManaged = false
+			} else {
+				f.Managed += line
+			}
+		default:
+			if l, ok := markerLabel(trimmed, CustomStart); ok {
+				flush(managedPart{})
+				current.WriteString(line)
+				inCustom, label, startLine = true, l, line
+				continue
+			}
+			if _, ok := markerLabel(trimmed, ManagedStart); ok && !f.hasManaged() {
+				flush(managedPart{})
+				current.WriteString(line)
+				inManaged = true
+				continue
+			}
+			current.WriteString(line)
+			body.WriteString(line)
+			if m := hyprSourceRe.FindStringSubmatch(stripHyprComment(strings.TrimRight(line, "\n"))); m != nil {
+				f.Sources = append(f.Sources, strings.TrimSpace(m[1]))
+			}
+		}
+	}
+
+	switch {
+	case inCustom:
+		f.Custom = append(f.Custom, CustomSection{Label: label, Content: current.String()[len(startLine):]})
+		flush(managedPart{})
+	case inManaged:
+		flush(managedPart{managed: true})
+	default:
+		flush(managedPart{})
+	}
+	f.Body = body.String()
+	f.oldManaged = f.Managed
+	return f
+}
+
+// hasManaged reports whether f has a managed block.
+func (f *ManagedFile) hasManaged() bool {
+	for _, p := range f.parts {
+		if p.managed {
+			return true
+		}
+	}
+	return false
+}
+
+// markerLabel reports whether line is the marker, optionally followed by a label separated by a
+// space or colon, and returns the label.
+func markerLabel(line, marker string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, marker)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != ':') {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rest, ":")), true
+}
+
+// Bytes returns the file with its managed block holding Managed. Everything else, custom
+// sections included, is returned byte for byte as it was read; a file without a managed block
+// gets one appended when Managed is set.
+func (f *ManagedFile) Bytes() []byte {
+	var b strings.Builder
+	block := ""
+	if f.Managed != "" {
+		managed := f.Managed
+		if !strings.HasSuffix(managed, "\n") {
+			managed += "\n"
+		}
+		block = ManagedStart + "\n" + managed + ManagedEnd + "\n"
+	}
+
+	written := false
+	for _, p := range f.parts {
+		if !p.managed {
+			b.WriteString(p.text)
+			continue
+		}
+		written = true
+		if f.Managed == f.oldManaged {
+			b.WriteString(p.text)
+		} else {
+			b.WriteString(block)
+		}
+	}
+	if !written && block != "" {
+		text := b.String()
+		if text != "" && !strings.HasSuffix(text, "\n") {
+			b.WriteString("\n")
+		}
+		if text != "" {
+			b.WriteString("\n")
+		}
+		b.WriteString(block)
+	}
+	return []byte(b.String())
+}
+
+// WriteManagedFile writes f to path, keeping the file's permissions, by replacing the file so
+// it is never left half written.
+func WriteManagedFile(path string, f *ManagedFile) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(f.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,95 @@
+package hyprconfig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseManagedFile(t *testing.T) {
+	f, err := ParseManagedFile(filepath.Join("testdata", "managed", "user.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"~/.config/hypr/monitors.conf"}; !slices.Equal(f.Sources, want) {
+		t.Errorf("sources = %q, want %q", f.Sources, want)
+	}
+	want := []CustomSection{
+		{Label: "keybinds", Content: "bind = $mainMod, Return, exec, kitty   \nbind = $mainMod, Q, killactive\n\t# tabs and trailing spaces are kept\n### MANAGED START\n"},
+		{Label: "machine", Content: "source = ~/.config/hypr/desk.conf\nmonitor = DP-1, 2560x1440@144, 0x0, 1\n"},
+		{Label: "", Content: "windowrulev2 = float, class:^(pavucontrol)$\n"}, // missing its end marker
+	}
+	if !slices.Equal(f.Custom, want) {
+		t.Errorf("custom sections = %q\nwant %q", f.Custom, want)
+	}
+	if f.Managed != "source = ~/.config/hypr/old-colors.conf\nexec-once = waybar\n" {
+		t.Errorf("managed = %q", f.Managed)
+	}
+	wantBody := "# Hyprland config, applied by hypr-config-manager\nsource = ~/.config/hypr/monitors.conf\n$mainMod = SUPER\n\n\n\ngeneral {\n    gaps_in = 5\n}\n\n"
+	if f.Body != wantBody {
+		t.Errorf("body = %q\nwant %q", f.Body, wantBody)
+	}
+}
+
+func TestManagedFileRoundTrip(t *testing.T) {
+	for _, name := range []string{"user", "plain"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "managed", name+".conf"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := parseManagedFile(string(data))
+		if got := string(f.Bytes()); got != string(data) {
+			t.Errorf("%s: unchanged file written as\n%s", name, got)
+		}
+
+		f.Managed = "source = ~/.config/hypr/colors.conf\nenv = XCURSOR_SIZE,24\nexec-once = waybar"
+		checkGolden(t, filepath.Join("managed", name+".golden"), string(f.Bytes()))
+
+		// Applying again only replaces the block
+		again := parseManagedFile(string(f.Bytes()))
+		again.Managed = "exec-once = swaync\n"
+		f.Managed = "exec-once = swaync\n"
+		if string(again.Bytes()) != string(f.Bytes()) {
+			t.Errorf("%s: second apply differs:\n%s", name, again.Bytes())
+		}
+	}
+
+	f := parseManagedFile("### MANAGED START\nexec-once = waybar\n### MANAGED END\nmonitor = , preferred, auto, 1\n")
+	f.Managed = ""
+	if got := string(f.Bytes()); got != "monitor = , preferred, auto, 1\n" {
+		t.Errorf("cleared managed block written as %q", got)
+	}
+}
+
+func TestWriteManagedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hyprland.conf")
+	if err := os.WriteFile(path, []byte("### CUSTOM START\nbind = SUPER, Q, killactive\n### CUSTOM END\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ParseManagedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Managed = "exec-once = waybar\n"
+	if err := WriteManagedFile(path, f); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "### CUSTOM START\nbind = SUPER, Q, killactive\n### CUSTOM END\n\n### MANAGED START\nexec-once = waybar\n### MANAGED END\n"; string(data) != want {
+		t.Errorf("wrote %q, want %q", data, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("mode not kept: %v %v", info.Mode(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+	if _, err := ParseManagedFile(filepath.Join(t.TempDir(), "missing.conf")); err == nil {
+		t.Error("missing file: got nil error")
+	}
+}
@@ -0,0 +1,523 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func asUser(id string, roles ...string) context.Context {
+	user := &session.UserSessionData{UserID: id, Roles: roles, SignedIn: true}
+	return user.WithContext(context.Background())
+}
+
+// forEachManager runs fn against a fresh instance of every ConfigManager backend that needs no
+// external service.
+func forEachManager(t *testing.T, fn func(t *testing.T, m ConfigManager)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewInMemoryConfigManager())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		m, err := NewConfigManagerSQLite(filepath.Join(t.TempDir(), "configs.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = m.Close() })
+		fn(t, m)
+	})
+}
+
+// forEachManagerAndMongo runs fn like forEachManager and against ConfigManagerMongo as well,
+// for behaviour that depends on how the backend writes. The Mongo run needs a MongoDB server,
+// set HYPR_TEST_MONGO_URI to run it.
+func forEachManagerAndMongo(t *testing.T, fn func(t *testing.T, m ConfigManager)) {
+	forEachManager(t, fn)
+	t.Run("mongo", func(t *testing.T) {
+		uri := os.Getenv("HYPR_TEST_MONGO_URI")
+		if uri == "" {
+			t.Skip("HYPR_TEST_MONGO_URI is not set")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := client.Database(fmt.Sprintf("hypr_manager_test_%d", time.Now().UnixNano()))
+		t.Cleanup(func() {
+			_ = db.Drop(context.Background())
+			_ = client.Disconnect(context.Background())
+		})
+		m, err := NewConfigManager(db.Collection("configs"), db.Collection("favorites"), db.Collection("state"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, m)
+	})
+}
+
+func newTestConfig(t *testing.T, m ConfigManager, owner string, private bool) *HyprConfig {
+	t.Helper()
+	cfg, err := m.CreateConfig(asUser(owner), &HyprConfig{
+		Title:          "rice",
+		Private:        private,
+		ProgramConfigs: []HyprProgramConfig{{ID: "term", Title: "term", Program: "kitty"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestManagerErrors(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", true)
+
+		if _, err := m.CreateConfig(context.Background(), &HyprConfig{}); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("anonymous create: got %v, want ErrUnauthorized", err)
+		}
+		if _, err := m.GetConfig(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing config: got %v, want ErrNotFound", err)
+		}
+		if _, err := m.GetConfig(asUser("bob"), cfg.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("private config: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.GetConfig(asUser("root", "admin"), cfg.ID); err != nil {
+			t.Errorf("admin read: %v", err)
+		}
+		if err := m.DeleteConfig(asUser("bob"), cfg.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner delete: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.AddAllowedProgram(asUser("bob"), "mybar"); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-admin add program: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.CreateConfig(asUser("alice"), &HyprConfig{}); !errors.Is(err, ErrValidation) {
+			t.Errorf("invalid config: got %v, want ErrValidation", err)
+		}
+		if err := m.MoveProgramConfig(asUser("alice"), cfg.ID, "missing", nil, ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing program config: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestManagerReturnsCopies(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", false)
+
+		got, err := m.GetConfig(context.Background(), cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.Title = "changed"
+		got.ProgramConfigs[0].Program = "changed"
+
+		again, _ := m.GetConfig(context.Background(), cfg.ID)
+		if again.Title != "rice" || again.ProgramConfigs[0].Program != "kitty" {
+			t.Errorf("stored config was mutated through a returned copy: %+v", again)
+		}
+	})
+}
+
+func TestManagerFavoritesAndState(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", false)
+		ctx := asUser("bob")
+
+		for i := 0; i < 2; i++ {
+			if err := m.FavoriteConfig(ctx, cfg.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, _ := m.GetConfig(ctx, cfg.ID)
+		if got.Likes != 1 {
+			t.Errorf("likes = %d after favoriting twice, want 1", got.Likes)
+		}
+		favs, err := m.ListFavorites(ctx, 1, 10)
+		if err != nil || favs.Total != 1 {
+			t.Errorf("favorites = %+v, %v", favs, err)
+		}
+
+		if _, err := m.GetAppliedConfig(ctx); !errors.Is(err, ErrNotFound) {
+			t.Errorf("no applied config: got %v, want ErrNotFound", err)
+		}
+		if err := m.ApplyConfig(ctx, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if applied, err := m.GetAppliedConfig(ctx); err != nil || applied.ID != cfg.ID {
+			t.Errorf("applied config = %v, %v", applied, err)
+		}
+		if n, _ := m.CountUsersUsingConfig(ctx, cfg.ID); n != 1 {
+			t.Errorf("users using config = %d, want 1", n)
+		}
+	})
+}
+
+func TestManagerConfigFavoriters(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", false)
+		if _, err := m.CreateConfig(asUser("bob"), &HyprConfig{
+			Title:          "bobs rice",
+			Author:         Author{UserName: "bobby"},
+			ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty"}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		for _, user := range []string{"bob", "carol"} {
+			if err := m.FavoriteConfig(asUser(user), cfg.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if n, err := m.CountConfigFavorites(context.Background(), cfg.ID); err != nil || n != 2 {
+			t.Errorf("favorite count = %d, %v, want 2", n, err)
+		}
+
+		favoriters, err := m.ListConfigFavoriters(asUser("alice"), cfg.ID, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if favoriters.Total != 2 || favoriters.Items[0].UserID != "carol" || favoriters.Items[0].Author != nil {
+			t.Errorf("favoriters = %+v", favoriters.Items)
+		}
+		if bob := favoriters.Items[1]; bob.UserID != "bob" || bob.Author == nil || bob.Author.UserName != "bobby" {
+			t.Errorf("bob = %+v", bob)
+		}
+		if _, err := m.ListConfigFavoriters(asUser("admin", "admin"), cfg.ID, 1, 10); err != nil {
+			t.Errorf("admin: %v", err)
+		}
+
+		if _, err := m.ListConfigFavoriters(asUser("bob"), cfg.ID, 1, 10); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.ListConfigFavoriters(context.Background(), cfg.ID, 1, 10); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("anonymous: got %v, want ErrUnauthorized", err)
+		}
+		if _, err := m.ListConfigFavoriters(asUser("alice"), "missing", 1, 10); !errors.Is(err, ErrNotFound) {
+			t.Errorf("missing config: got %v, want ErrNotFound", err)
+		}
+
+		private := newTestConfig(t, m, "alice", true)
+		if _, err := m.CountConfigFavorites(asUser("bob"), private.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("private count: got %v, want ErrForbidden", err)
+		}
+	})
+}
+
+func TestManagerProgramRequests(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		admin := asUser("root", "admin")
+
+		req, err := m.RequestAllowedProgram(asUser("bob"), "MyBar", "a bar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dup, _ := m.RequestAllowedProgram(asUser("carol"), "mybar", ""); dup.ID != req.ID {
+			t.Error("a second request for a pending program should return the existing request")
+		}
+		if _, err := m.ApproveProgramRequest(admin, req.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.GetAllowedProgram(context.Background(), "mybar"); err != nil {
+			t.Errorf("approved program not allowed: %v", err)
+		}
+		if err := m.RejectProgramRequest(admin, req.ID, ""); err == nil {
+			t.Error("rejecting a reviewed request should fail")
+		}
+	})
+}
+
+func TestManagerSearch(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		create := func(title string, tags []string, private bool, platform ...string) string {
+			t.Helper()
+			cfg, err := m.CreateConfig(ctx, &HyprConfig{
+				Title:   title,
+				Tags:    tags,
+				Private: private,
+				ProgramConfigs: []HyprProgramConfig{
+					{Title: "term", Program: "kitty", Platform: platform},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return cfg.ID
+		}
+		nord := create("Nord rice", []string{"dark", "minimal"}, false)
+		gruvbox := create("Gruvbox", []string{"dark"}, false, PlatformArch)
+		create("Secret nord", nil, true)
+
+		search := func(ctx context.Context, filters ConfigSearchFilters) []string {
+			t.Helper()
+			res, err := m.ListConfigsWithFilters(ctx, 1, 10, filters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, cfg := range res.Items {
+				ids = append(ids, cfg.ID)
+			}
+			return ids
+		}
+
+		if got := search(context.Background(), ConfigSearchFilters{Query: "nord"}); len(got) != 1 || got[0] != nord {
+			t.Errorf("anonymous query = %v, want only %s", got, nord)
+		}
+		if got := search(ctx, ConfigSearchFilters{Query: "nord"}); len(got) != 2 {
+			t.Errorf("owner query = %v, want the public and the private config", got)
+		}
+		if got := search(ctx, ConfigSearchFilters{Tags: []string{"dark", "minimal"}}); len(got) != 1 || got[0] != nord {
+			t.Errorf("tags = %v, want only %s", got, nord)
+		}
+		if got := search(ctx, ConfigSearchFilters{Program: "kitty"}); len(got) != 3 {
+			t.Errorf("program = %v, want 3 configs", got)
+		}
+		if got := search(ctx, ConfigSearchFilters{Platform: "debian"}); len(got) != 2 || containsExact(got, gruvbox) {
+			t.Errorf("platform = %v, want every config but the arch only one", got)
+		}
+
+		if got := search(context.Background(), ConfigSearchFilters{Sort: SearchSortTitle}); len(got) != 2 || got[0] != gruvbox {
+			t.Errorf("title sort = %v, want %s first", got, gruvbox)
+		}
+		if _, err := m.ListConfigsWithFilters(ctx, 1, 10, ConfigSearchFilters{Sort: "random"}, nil); !errors.Is(err, ErrValidation) {
+			t.Errorf("unknown sort: got %v, want ErrValidation", err)
+		}
+
+		res, err := m.ListConfigs(context.Background(), 2, 1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 2 || res.TotalPages != 2 || len(res.Items) != 1 {
+			t.Errorf("page 2 of public configs = %+v", res)
+		}
+		if _, err := m.ListConfigs(context.Background(), 0, 10, nil); err == nil {
+			t.Error("page 0 should fail")
+		}
+	})
+}
+
+func TestManagerListOmitsFileContent(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		data := []byte(strings.Repeat("font_size 12\n", 1000))
+		ctx := asUser("alice")
+		_, err := m.CreateConfig(ctx, &HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{{
+			Title: "term", Program: "kitty", FileContent: FileContent{Data: data, FileType: FileTypeConfig},
+			SubConfigs: []*HyprProgramConfig{{Title: "theme", Program: "kitty", FileContent: FileContent{Data: []byte("include nord.conf\n")}}},
+		}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantSize := int64(len(data) + len("include nord.conf\n"))
+
+		lists := map[string]func(ctx context.Context) (mserve.Page[HyprConfig], error){
+			"ListConfigs":   func(ctx context.Context) (mserve.Page[HyprConfig], error) { return m.ListConfigs(ctx, 1, 10, nil) },
+			"ListMyConfigs": func(ctx context.Context) (mserve.Page[HyprConfig], error) { return m.ListMyConfigs(ctx, 1, 10, nil) },
+			"ListConfigsWithFilters": func(ctx context.Context) (mserve.Page[HyprConfig], error) {
+				return m.ListConfigsWithFilters(ctx, 1, 10, ConfigSearchFilters{}, nil)
+			},
+		}
+		for name, list := range lists {
+			res, err := list(ctx)
+			if err != nil || len(res.Items) != 1 {
+				t.Fatalf("%s: %v %+v", name, err, res)
+			}
+			cfg := res.Items[0]
+			if cfg.TotalSizeBytes != wantSize {
+				t.Errorf("%s: total size = %d, want %d", name, cfg.TotalSizeBytes, wantSize)
+			}
+			pc := cfg.ProgramConfigs[0]
+			if len(pc.FileContent.Data) != 0 || len(pc.SubConfigs[0].FileContent.Data) != 0 || pc.FileContent.Hash == "" {
+				t.Errorf("%s: file data should be left out and the hash kept", name)
+			}
+
+			res, err = list(WithFileContent(ctx))
+			if err != nil || string(res.Items[0].ProgramConfigs[0].FileContent.Data) != string(data) {
+				t.Errorf("%s with file content: %v", name, err)
+			}
+		}
+	})
+}
+
+func TestManagerUpdates(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+
+		err := m.UpdateConfig(ctx, cfg.ID, map[string]any{"title": "riced", "likes": 99}, UpdateOptions{Changelog: "rename"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{ID: "bar", Title: "bar", Program: "waybar"}, nil, "add bar"); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.MoveProgramConfig(ctx, cfg.ID, "bar", &[]string{"term"}[0], ""); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := m.GetConfig(ctx, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != "riced" || got.Likes != 0 || got.Version == cfg.Version {
+			t.Errorf("updated config = title %q, likes %d, version %s", got.Title, got.Likes, got.Version)
+		}
+		if len(got.ProgramConfigs) != 1 || len(got.ProgramConfigs[0].SubConfigs) != 1 {
+			t.Errorf("bar was not moved under term: %+v", got.ProgramConfigs)
+		}
+
+		log, err := m.GetChangelog(ctx, cfg.ID, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if log.Total != 2 || log.Items[0].Message != "add bar" {
+			t.Errorf("changelog = %+v", log.Items)
+		}
+
+		if err := m.RemoveProgramConfig(ctx, cfg.ID, "bar", ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.GetProgramConfig(ctx, cfg.ID, "bar"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("removed program: got %v, want ErrNotFound", err)
+		}
+		if err := m.DeleteConfig(ctx, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.GetConfig(ctx, cfg.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("deleted config: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestManagerMoveKeepsSiblings(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+		for _, id := range []string{"bar", "launcher"} {
+			if err := m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{ID: id, Title: id, Program: "kitty"}, nil, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+		parent := "launcher"
+		for _, id := range []string{"a", "b", "c"} {
+			if err := m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{ID: id, Title: id, Program: "kitty"}, &parent, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The first of three top-level configs, then the first of three nested ones
+		if err := m.MoveProgramConfig(ctx, cfg.ID, "term", nil, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.MoveProgramConfig(ctx, cfg.ID, "a", nil, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := m.GetConfig(ctx, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var top []string
+		for _, pc := range got.ProgramConfigs {
+			top = append(top, pc.ID)
+		}
+		if strings.Join(top, ",") != "bar,launcher,term,a" {
+			t.Errorf("top-level program configs = %v, want bar,launcher,term,a", top)
+		}
+		for _, id := range []string{"b", "c"} {
+			if path := programPath(got.ProgramConfigs, id); strings.Join(path, "/") != "launcher/"+id {
+				t.Errorf("path to %s = %v, want launcher/%s", id, path, id)
+			}
+		}
+	})
+}
+
+func TestManagerAuditLog(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		cfg := newTestConfig(t, m, "alice", false)
+
+		if err := m.UpdateConfig(ctx, cfg.ID, map[string]any{"title": "riced", "tags": []string{"dark"}}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		update := HyprProgramConfig{Title: "term", Program: "kitty", Args: []string{"--single-instance"}}
+		if err := m.UpdateProgramConfig(ctx, cfg.ID, "term", update, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.UpdateConfig(ctx, cfg.ID, map[string]any{"title": ""}, UpdateOptions{}); err == nil {
+			t.Fatal("invalid update should fail")
+		}
+
+		log, err := m.ListAuditLog(ctx, cfg.ID, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if log.Total != 3 {
+			t.Fatalf("audit log has %d entries, want 3 (failed updates are not recorded): %+v", log.Total, log.Items)
+		}
+		want := []struct {
+			action, progID string
+			changes        []string
+		}{
+			{AuditUpdateProgramConfig, "term", []string{"args"}},
+			{AuditUpdateConfig, "", []string{"tags", "title"}},
+			{AuditCreateConfig, "", nil},
+		}
+		for i, w := range want {
+			got := log.Items[i]
+			if got.Action != w.action || got.ProgID != w.progID || got.ActorID != "alice" || strings.Join(got.Changes, ",") != strings.Join(w.changes, ",") {
+				t.Errorf("entry %d = %+v, want %s %s %v", i, got, w.action, w.progID, w.changes)
+			}
+		}
+
+		if _, err := m.ListAuditLog(asUser("bob"), cfg.ID, 1, 10); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner: got %v, want ErrForbidden", err)
+		}
+		if err := m.DeleteConfig(ctx, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if log, err := m.ListAuditLog(asUser("root", "admin"), cfg.ID, 1, 10); err != nil || log.Total != 4 {
+			t.Errorf("admin reading a deleted config's log = %d entries, %v", log.Total, err)
+		}
+	})
+}
+
+func TestManagerAdminListConfigs(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		private := newTestConfig(t, m, "alice", true)
+		newTestConfig(t, m, "bob", false)
+
+		if _, err := m.AdminListConfigs(asUser("bob"), 1, 10, ConfigSearchFilters{}); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-admin: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.AdminListConfigs(context.Background(), 1, 10, ConfigSearchFilters{}); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("anonymous: got %v, want ErrUnauthorized", err)
+		}
+
+		all, err := m.AdminListConfigs(asUser("root", "admin"), 1, 10, ConfigSearchFilters{})
+		if err != nil || all.Total != 2 {
+			t.Fatalf("admin: %+v, %v, want both configs", all, err)
+		}
+		mine, err := m.AdminListConfigs(asUser("root", "admin"), 1, 10, ConfigSearchFilters{OwnerID: "alice"})
+		if err != nil || len(mine.Items) != 1 || mine.Items[0].ID != private.ID {
+			t.Errorf("admin filtered by owner: %+v, %v", mine, err)
+		}
+
+		// The regular search still hides it from admins
+		search, err := m.ListConfigsWithFilters(asUser("root", "admin"), 1, 10, ConfigSearchFilters{}, nil)
+		if err != nil || search.Total != 1 {
+			t.Errorf("search as admin: %+v, %v, want only the public config", search, err)
+		}
+	})
+}
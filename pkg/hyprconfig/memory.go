@@ -0,0 +1,1387 @@
+package hyprconfig
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ConfigManagerMemory is a map backed ConfigManager for tests and local use. It follows the
+// visibility, ownership and error semantics of ConfigManagerMongo. File content is always kept
+// inline and uncompressed, and findOpts are ignored: lists are sorted newest first, ties by id.
+type ConfigManagerMemory struct {
+	mu sync.RWMutex
+
+	configs    map[string]*HyprConfig
+	favorites  map[string]map[string]time.Time // user id -> config id -> favorited at
+	state      map[string]UserHyprState        // user id -> applied config
+	programs   map[string]AllowedPrograms
+	requests   map[string]ProgramRequest
+	audit      []AuditEntry // oldest first
+	webhooks   map[string]Webhook
+	deliveries map[string]WebhookDelivery
+	apiKeys    map[string]APIKey
+	shareLinks map[string]ShareLink
+	quotas     map[string]userQuota            // user id -> usage
+	follows    map[string]map[string]time.Time // follower id -> followee id -> followed at
+	followers  map[string]int64                // user id -> followers
+
+	notifications map[string]Notification
+
+	files  FileStore // uploaded gallery images
+	limits SizeLimits
+}
+
+// NewInMemoryConfigManager creates an empty in-memory ConfigManager with DefaultSizeLimits.
+func NewInMemoryConfigManager() ConfigManager {
+	return &ConfigManagerMemory{
+		configs:    map[string]*HyprConfig{},
+		favorites:  map[string]map[string]time.Time{},
+		state:      map[string]UserHyprState{},
+		programs:   map[string]AllowedPrograms{},
+		requests:   map[string]ProgramRequest{},
+		webhooks:   map[string]Webhook{},
+		deliveries: map[string]WebhookDelivery{},
+		apiKeys:    map[string]APIKey{},
+		shareLinks: map[string]ShareLink{},
+		quotas:     map[string]userQuota{},
+		follows:    map[string]map[string]time.Time{},
+		followers:  map[string]int64{},
+		files:      newMemFileStore(),
+		limits:     DefaultSizeLimits(),
+
+		notifications: map[string]Notification{},
+	}
+}
+
+// cloneConfig deep copies a config the same way a round trip through Mongo would.
+func cloneConfig(cfg *HyprConfig) (*HyprConfig, error) {
+	raw, err := bson.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	var out HyprConfig
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	return &out, nil
+}
+
+// canRead reports whether user may see cfg; user may be nil.
+func canRead(cfg *HyprConfig, user *session.UserSessionData) bool {
+	if !cfg.Private {
+		return true
+	}
+	return user != nil && (cfg.OwnerID == user.UserID || isAdmin(user.Roles))
+}
+
+// canWrite reports whether user may modify cfg.
+func canWrite(cfg *HyprConfig, user *session.UserSessionData) bool {
+	return cfg.OwnerID == user.UserID || isAdmin(user.Roles)
+}
+
+// registry returns the programs configs may use. Lookups are cheap, so nothing is cached.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) registry() *ProgramRegistry {
+	return NewProgramRegistry(m.lookupAllowedPrograms, m.listAllowedProgramNames, 0)
+}
+
+// lookupAllowedPrograms returns the names, out of names, that are allowed. Callers must hold m.mu.
+func (m *ConfigManagerMemory) lookupAllowedPrograms(ctx context.Context, names []string) (map[string]struct{}, error) {
+	allowed := map[string]struct{}{}
+	for _, name := range names {
+		if _, ok := m.programs[name]; ok {
+			allowed[name] = struct{}{}
+		}
+	}
+	return allowed, nil
+}
+
+// listAllowedProgramNames returns the names of the allowed programs. Callers must hold m.mu.
+func (m *ConfigManagerMemory) listAllowedProgramNames(ctx context.Context) ([]string, error) {
+	names := make([]string, 0, len(m.programs))
+	for name := range m.programs {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// loadWritable returns a copy of a config the signed-in user may modify.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) loadWritable(ctx context.Context, id string) (*HyprConfig, *session.UserSessionData, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	stored, ok := m.configs[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	if !canWrite(stored, user) {
+		return nil, nil, ErrForbidden
+	}
+	cfg, err := cloneConfig(stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, user, nil
+}
+
+// storeAudited saves a copy of cfg and records entry once it is stored. Callers must hold m.mu.
+func (m *ConfigManagerMemory) storeAudited(cfg *HyprConfig, entry AuditEntry) error {
+	if err := m.store(cfg); err != nil {
+		return err
+	}
+	m.recordMutation(entry)
+	return nil
+}
+
+// recordMutation appends entry to the audit log and queues the webhook deliveries it triggers.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) recordMutation(entry AuditEntry) {
+	m.audit = append(m.audit, entry)
+
+	if typ := notificationTypeFor(entry.Action); typ != "" {
+		m.notify(typ, entry.ActorID, entry.ConfigID, m.configAudience(entry.ConfigID))
+	}
+	event := webhookEventFor(entry.Action)
+	if event == "" {
+		return
+	}
+	var hooks []Webhook
+	for _, hook := range m.webhooks {
+		_, favorited := m.favorites[hook.OwnerID][entry.ConfigID]
+		if favorited || m.state[hook.OwnerID].ConfigID == entry.ConfigID {
+			hooks = append(hooks, hook)
+		}
+	}
+	for _, d := range newWebhookDeliveries(hooks, event, entry.ConfigID) {
+		m.deliveries[d.ID] = d
+	}
+}
+
+// store saves a copy of cfg with a fresh fingerprint, charging any change in its content size
+// to the owner's quota.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) store(cfg *HyprConfig) error {
+	stored, err := cloneConfig(cfg)
+	if err != nil {
+		return err
+	}
+	stored.refreshDerived()
+	delta := stored.contentSize()
+	if prev, ok := m.configs[stored.ID]; ok {
+		delta -= prev.contentSize()
+	}
+	if err := m.charge(stored.OwnerID, delta); err != nil {
+		return err
+	}
+	m.configs[stored.ID] = stored
+	return nil
+}
+
+// page returns a copy of one page of the configs matching keep, newest first.
+func (m *ConfigManagerMemory) page(ctx context.Context, page, limit int, keep func(cfg *HyprConfig) bool) (mserve.Page[HyprConfig], error) {
+	return m.pageSorted(ctx, page, limit, SearchSortUpdated, keep)
+}
+
+// pageSorted is page with the order given by one of the SearchSort values.
+func (m *ConfigManagerMemory) pageSorted(
+	ctx context.Context,
+	page, limit int,
+	sortBy string,
+	keep func(cfg *HyprConfig) bool,
+) (mserve.Page[HyprConfig], error) {
+	m.mu.RLock()
+	var items []HyprConfig
+	for _, cfg := range m.configs {
+		if !keep(cfg) {
+			continue
+		}
+		c, err := cloneConfig(cfg)
+		if err != nil {
+			m.mu.RUnlock()
+			return mserve.Page[HyprConfig]{}, err
+		}
+		items = append(items, *c)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := &items[i], &items[j]
+		switch {
+		case a.Deprecated != b.Deprecated:
+			return b.Deprecated // deprecated configs rank last in every order
+		case sortBy == SearchSortCreated && !a.CreatedTimestamp.Equal(b.CreatedTimestamp):
+			return a.CreatedTimestamp.After(b.CreatedTimestamp)
+		case sortBy == SearchSortVerified && a.Verified != b.Verified:
+			return a.Verified
+		case (sortBy == SearchSortLikes || sortBy == SearchSortVerified) && a.Likes != b.Likes:
+			return a.Likes > b.Likes
+		case sortBy == SearchSortTitle && !strings.EqualFold(a.Title, b.Title):
+			return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+		case sortBy == SearchSortUpdated && !a.UpdatedTimestamp.Equal(b.UpdatedTimestamp):
+			return a.UpdatedTimestamp.After(b.UpdatedTimestamp)
+		}
+		return a.ID < b.ID
+	})
+	result, err := mserve.Paginate(items, page, limit)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	return summarizeList(ctx, result), nil
+}
+
+func (m *ConfigManagerMemory) CreateConfig(ctx context.Context, cfg *HyprConfig) (*HyprConfig, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !isAdmin(user.Roles) {
+		if err := m.configLimit(user.UserID).check(); err != nil {
+			return nil, err
+		}
+	}
+	if err := prepareNewConfig(ctx, cfg, user.UserID, m.registry().Allowed, m.limits); err != nil {
+		return nil, err
+	}
+	if err := m.checkDuplicate(ctx, cfg); err != nil {
+		return nil, err
+	}
+	if err := m.store(cfg); err != nil {
+		return nil, err
+	}
+	m.audit = append(m.audit, newAuditEntry(user.UserID, AuditCreateConfig, cfg.ID, "", nil))
+	return cfg, nil
+}
+
+func (m *ConfigManagerMemory) GetConfig(ctx context.Context, id string) (*HyprConfig, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil for public configs
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stored, ok := m.configs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !canRead(stored, user) {
+		if err := sharedRead(ctx, id, m.shareLink); err != nil {
+			return nil, err
+		}
+	}
+	return cloneConfig(stored)
+}
+
+func (m *ConfigManagerMemory) GetProgramConfig(ctx context.Context, configID, progID string) (*HyprProgramConfig, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	prog := findProgramConfig(cfg.ProgramConfigs, progID)
+	if prog == nil {
+		return nil, ErrNotFound
+	}
+	return prog, nil
+}
+
+func (m *ConfigManagerMemory) GetProgramFile(ctx context.Context, configID, progID, filePath string) (io.ReadCloser, *FileEntry, error) {
+	prog, err := m.GetProgramConfig(ctx, configID, progID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openProgramFile(ctx, nil, prog, filePath, m.limits.ExtraInstallPrefixes)
+}
+
+func (m *ConfigManagerMemory) ExportConfigArchive(ctx context.Context, configID string, w io.Writer) error {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return err
+	}
+	return writeConfigArchive(ctx, nil, cfg, w, m.limits.ExtraInstallPrefixes)
+}
+
+func (m *ConfigManagerMemory) GetInstallScript(ctx context.Context, configID, distro string, includeOptional bool) (string, error) {
+	if platform, err := NormalizePlatform(distro); err == nil {
+		distro = platform
+	}
+	if _, ok := installCommands[distro]; !ok {
+		return GenerateInstallScript(&HyprConfig{}, distro)
+	}
+
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	programs := make([]AllowedPrograms, 0, len(m.programs))
+	for _, p := range m.programs {
+		programs = append(programs, p)
+	}
+	m.mu.RUnlock()
+
+	return GenerateInstallScript(cfg, distro, IncludeOptional(includeOptional), WithPackageNames(programs))
+}
+
+func (m *ConfigManagerMemory) ExportHomeManager(ctx context.Context, configID string) (string, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.RLock()
+	programs := make([]AllowedPrograms, 0, len(m.programs))
+	for _, p := range m.programs {
+		programs = append(programs, p)
+	}
+	m.mu.RUnlock()
+
+	return exportHomeManager(cfg, templateValues(ctx), m.limits.ExtraInstallPrefixes, WithPackageNames(programs))
+}
+
+func (m *ConfigManagerMemory) SizeLimits() SizeLimits {
+	return m.limits
+}
+
+func (m *ConfigManagerMemory) UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, user, err := m.loadWritable(ctx, id)
+	if err != nil {
+		return err
+	}
+	merged, err := mergeConfigUpdates(ctx, existing, updates, opts, user.UserID, m.registry().Allowed, m.limits)
+	if err != nil {
+		return err
+	}
+	if err := m.store(merged); err != nil {
+		return err
+	}
+	m.recordMutation(newAuditEntry(user.UserID, AuditUpdateConfig, id, "", updatedFields(updates)))
+	return nil
+}
+
+func (m *ConfigManagerMemory) DeleteConfig(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, id)
+	if err != nil {
+		return err
+	}
+	delete(m.configs, id)
+	_ = m.charge(cfg.OwnerID, -cfg.contentSize()) // freeing space never fails
+	for linkID, link := range m.shareLinks {
+		if link.ConfigID == id {
+			delete(m.shareLinks, linkID)
+		}
+	}
+	m.recordMutation(newAuditEntry(user.UserID, AuditDeleteConfig, id, "", nil))
+	for _, fileID := range galleryFileIDs(cfg) {
+		_ = m.files.Delete(ctx, fileID)
+	}
+	return nil
+}
+
+func (m *ConfigManagerMemory) ListConfigs(
+	ctx context.Context,
+	page, limit int,
+	findOpts *options.FindOptions,
+) (mserve.Page[HyprConfig], error) {
+	user, _ := getUserFromContext(ctx) // user may be nil
+
+	return m.page(ctx, page, limit, func(cfg *HyprConfig) bool {
+		return !cfg.Private || (user != nil && cfg.OwnerID == user.UserID)
+	})
+}
+
+func (m *ConfigManagerMemory) ListMyConfigs(
+	ctx context.Context,
+	page, limit int,
+	findOpts *options.FindOptions,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return m.page(ctx, page, limit, func(cfg *HyprConfig) bool {
+		return cfg.OwnerID == user.UserID
+	})
+}
+
+func (m *ConfigManagerMemory) ListConfigsWithFilters(
+	ctx context.Context,
+	page, limit int,
+	filters ConfigSearchFilters,
+	findOpts *options.FindOptions,
+) (mserve.Page[HyprConfig], error) {
+	sortBy, err := searchSort(filters.Sort)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	keep, err := visibleSearch(ctx, filters)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return m.pageSorted(ctx, page, limit, sortBy, keep)
+}
+
+// visibleSearch returns whether a config matches filters and is visible to the signed-in user.
+func visibleSearch(ctx context.Context, filters ConfigSearchFilters) (func(cfg *HyprConfig) bool, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil
+
+	if err := filters.normalize(); err != nil {
+		return nil, err
+	}
+
+	var query *regexp.Regexp
+	if filters.Query != "" {
+		var err error
+		if query, err = regexp.Compile("(?i)" + filters.Query); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(cfg *HyprConfig) bool {
+		if cfg.Private && (user == nil || cfg.OwnerID != user.UserID) {
+			return false
+		}
+		return matchesSearchFilters(cfg, filters, query)
+	}, nil
+}
+
+func (m *ConfigManagerMemory) AdminListConfigs(
+	ctx context.Context,
+	page, limit int,
+	filters ConfigSearchFilters,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	if !isAdmin(user.Roles) {
+		return mserve.Page[HyprConfig]{}, ErrForbidden
+	}
+
+	if err := filters.normalize(); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	sortBy, err := searchSort(filters.Sort)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	var query *regexp.Regexp
+	if filters.Query != "" {
+		if query, err = regexp.Compile("(?i)" + filters.Query); err != nil {
+			return mserve.Page[HyprConfig]{}, err
+		}
+	}
+
+	return m.pageSorted(ctx, page, limit, sortBy, func(cfg *HyprConfig) bool {
+		return matchesSearchFilters(cfg, filters, query)
+	})
+}
+
+// matchesSearchFilters is the in-memory equivalent of buildSearchFilter, minus visibility.
+func matchesSearchFilters(cfg *HyprConfig, filters ConfigSearchFilters, query *regexp.Regexp) bool {
+	if query != nil {
+		matched := query.MatchString(cfg.Title) || query.MatchString(cfg.Description)
+		for _, tag := range cfg.Tags {
+			matched = matched || query.MatchString(tag)
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, tag := range filters.Tags {
+		if !containsExact(cfg.Tags, tag) {
+			return false
+		}
+	}
+	if filters.Program != "" {
+		found := false
+		for _, pc := range cfg.ProgramConfigs {
+			found = found || pc.Program == filters.Program
+		}
+		if !found {
+			return false
+		}
+	}
+	if filters.Monitors > 0 && !cfg.Display.fitsMonitors(filters.Monitors) {
+		return false
+	}
+	if filters.Resolution != "" && !cfg.Display.hasResolution(filters.Resolution) {
+		return false
+	}
+	if filters.Theme != "" && cfg.Theme != filters.Theme {
+		return false
+	}
+	if len(filters.Palette) > 0 && !matchesPalette(cfg.Palette, filters.paletteColors(), filters.ColorTolerance) {
+		return false
+	}
+	if filters.License != "" && cfg.License != licenseFilterValue(filters.License) {
+		return false
+	}
+	if filters.HyprlandVersion != "" && cfg.HyprlandVersionKey > hyprlandVersionKey(filters.HyprlandVersion) {
+		return false
+	}
+	if filters.OwnerID != "" && cfg.OwnerID != filters.OwnerID {
+		return false
+	}
+	if filters.Private != nil && cfg.Private != *filters.Private {
+		return false
+	}
+	if filters.Verified != nil && cfg.Verified != *filters.Verified {
+		return false
+	}
+	if filters.Deprecated != nil && cfg.Deprecated != *filters.Deprecated {
+		return false
+	}
+	if filters.Platform != "" {
+		for _, pc := range cfg.ProgramConfigs {
+			if !pc.Optional && len(pc.Platform) > 0 && !containsExact(pc.Platform, filters.Platform) {
+				return false
+			}
+		}
+	}
+	if filters.UpdatedFrom != nil && cfg.UpdatedTimestamp.Before(time.Unix(*filters.UpdatedFrom, 0)) {
+		return false
+	}
+	if filters.UpdatedTo != nil && cfg.UpdatedTimestamp.After(time.Unix(*filters.UpdatedTo, 0)) {
+		return false
+	}
+	return true
+}
+
+func containsExact(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ConfigManagerMemory) FavoriteConfig(ctx context.Context, configID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.favorites[user.UserID][configID]; ok {
+		return nil // already favorited, ignore
+	}
+	if m.favorites[user.UserID] == nil {
+		m.favorites[user.UserID] = map[string]time.Time{}
+	}
+	m.favorites[user.UserID][configID] = time.Now()
+	if cfg, ok := m.configs[configID]; ok {
+		cfg.Likes++
+		m.notify(NotificationConfigFavorited, user.UserID, configID, []string{cfg.OwnerID})
+	}
+	return nil
+}
+
+func (m *ConfigManagerMemory) UnfavoriteConfig(ctx context.Context, configID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.favorites[user.UserID][configID]; !ok {
+		return nil // not favorited before, nothing to do
+	}
+	delete(m.favorites[user.UserID], configID)
+	if cfg, ok := m.configs[configID]; ok {
+		cfg.Likes--
+	}
+	return nil
+}
+
+func (m *ConfigManagerMemory) ListFavorites(
+	ctx context.Context,
+	page, limit int,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	m.mu.RLock()
+	favs := make(map[string]struct{}, len(m.favorites[user.UserID]))
+	for id := range m.favorites[user.UserID] {
+		favs[id] = struct{}{}
+	}
+	m.mu.RUnlock()
+
+	return m.page(ctx, page, limit, func(cfg *HyprConfig) bool {
+		_, ok := favs[cfg.ID]
+		return ok
+	})
+}
+
+func (m *ConfigManagerMemory) ApplyConfig(ctx context.Context, configID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state[user.UserID] = UserHyprState{
+		UserID:    user.UserID,
+		ConfigID:  configID,
+		AppliedAt: time.Now(),
+	}
+	return nil
+}
+
+func (m *ConfigManagerMemory) GetAppliedConfig(ctx context.Context) (*HyprConfig, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	state, ok := m.state[user.UserID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return loadAppliedConfig(ctx, state.ConfigID, m.GetConfig)
+}
+
+func (m *ConfigManagerMemory) CountUsersUsingConfig(ctx context.Context, configID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var n int64
+	for _, s := range m.state {
+		if s.ConfigID == configID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *ConfigManagerMemory) AddProgramConfig(
+	ctx context.Context,
+	configID string,
+	newProg HyprProgramConfig,
+	parentID *string,
+	changelog string,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return err
+	}
+	if newProg.ID == "" {
+		newProg.ID = uuid.NewString()
+	}
+	if err := addProgram(ctx, cfg, newProg, parentID, m.registry().Allowed, m.limits); err != nil {
+		return err
+	}
+	cfg.clearVerification()
+	recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+	return m.storeAudited(cfg, newAuditEntry(user.UserID, AuditAddProgramConfig, configID, newProg.ID, nil))
+}
+
+func (m *ConfigManagerMemory) RemoveProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	changelog string,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return err
+	}
+
+	if err := removeProgram(cfg, progID); err != nil {
+		return err
+	}
+	cfg.clearVerification()
+	recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+	return m.storeAudited(cfg, newAuditEntry(user.UserID, AuditRemoveProgramConfig, configID, progID, nil))
+}
+
+func (m *ConfigManagerMemory) MoveProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	newParentID *string,
+	changelog string,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return err
+	}
+	if err := moveProgram(cfg, progID, newParentID); err != nil {
+		return err
+	}
+	cfg.clearVerification()
+	recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+	return m.storeAudited(cfg, newAuditEntry(user.UserID, AuditMoveProgramConfig, configID, progID, []string{"parent"}))
+}
+
+func (m *ConfigManagerMemory) UpdateProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	updates HyprProgramConfig,
+	changelog string,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return err
+	}
+	var before HyprProgramConfig
+	if existing := findProgramConfig(cfg.ProgramConfigs, progID); existing != nil {
+		before = *existing
+	}
+	if err := updateProgram(ctx, cfg, progID, updates, m.registry().Allowed, m.limits); err != nil {
+		return err
+	}
+	cfg.clearVerification()
+	recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+	changes := changedProgramFields(before, *findProgramConfig(cfg.ProgramConfigs, progID))
+	return m.storeAudited(cfg, newAuditEntry(user.UserID, AuditUpdateProgramConfig, configID, progID, changes))
+}
+
+func (m *ConfigManagerMemory) ListAuditLog(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[AuditEntry], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[AuditEntry]{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !isAdmin(user.Roles) {
+		cfg, ok := m.configs[configID]
+		if !ok {
+			return mserve.Page[AuditEntry]{}, ErrNotFound
+		}
+		if cfg.OwnerID != user.UserID {
+			return mserve.Page[AuditEntry]{}, ErrForbidden
+		}
+	}
+
+	var entries []AuditEntry
+	for i := len(m.audit) - 1; i >= 0; i-- {
+		if m.audit[i].ConfigID == configID {
+			entries = append(entries, m.audit[i])
+		}
+	}
+	return mserve.Paginate(entries, page, limit)
+}
+
+func (m *ConfigManagerMemory) GetChangelog(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[ChangelogEntry], error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return mserve.Page[ChangelogEntry]{}, err
+	}
+
+	entries := make([]ChangelogEntry, 0, len(cfg.Changelog))
+	for i := len(cfg.Changelog) - 1; i >= 0; i-- {
+		entries = append(entries, cfg.Changelog[i])
+	}
+	return mserve.Paginate(entries, page, limit)
+}
+
+func (m *ConfigManagerMemory) AddAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.programs[programName]; ok {
+		return nil, invalidf("program '%s' is already allowed", programName)
+	}
+	program := AllowedPrograms{ProgramName: programName}
+	m.programs[programName] = program
+	m.audit = append(m.audit, newAuditEntry(user.UserID, AuditAddAllowedProgram, "", "", []string{programName}))
+	return &program, nil
+}
+
+func (m *ConfigManagerMemory) GetAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	program, ok := m.programs[programName]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &program, nil
+}
+
+func (m *ConfigManagerMemory) ListAllowedPrograms(ctx context.Context) ([]AllowedPrograms, error) {
+	return m.allowedPrograms(AllowedProgramFilters{}), nil
+}
+
+func (m *ConfigManagerMemory) ListAllowedProgramsPaged(
+	ctx context.Context,
+	page, limit int,
+	filters AllowedProgramFilters,
+) (mserve.Page[AllowedPrograms], error) {
+	return mserve.Paginate(m.allowedPrograms(filters), page, limit)
+}
+
+// allowedPrograms returns the programs matching filters sorted by name.
+func (m *ConfigManagerMemory) allowedPrograms(filters AllowedProgramFilters) []AllowedPrograms {
+	prefix := strings.ToLower(strings.TrimSpace(filters.Prefix))
+
+	m.mu.RLock()
+	var programs []AllowedPrograms
+	for name, p := range m.programs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if filters.Category != "" && p.Category != filters.Category {
+			continue
+		}
+		programs = append(programs, p)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(programs, func(i, j int) bool {
+		return programs[i].ProgramName < programs[j].ProgramName
+	})
+	return programs
+}
+
+func (m *ConfigManagerMemory) RemoveAllowedProgram(ctx context.Context, programName string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return invalidf("program name cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.programs[programName]; !ok {
+		return ErrNotFound
+	}
+	delete(m.programs, programName)
+	m.audit = append(m.audit, newAuditEntry(user.UserID, AuditRemoveAllowedProgram, "", "", []string{programName}))
+	return nil
+}
+
+func (m *ConfigManagerMemory) ImportAllowedPrograms(
+	ctx context.Context,
+	programs []AllowedPrograms,
+	upsert bool,
+) ([]ProgramImportResult, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	return m.importAllowedPrograms(user.UserID, programs, upsert), nil
+}
+
+func (m *ConfigManagerMemory) SeedDefaultPrograms(ctx context.Context) ([]ProgramImportResult, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	return m.importAllowedPrograms(user.UserID, DefaultAllowedPrograms(), false), nil
+}
+
+func (m *ConfigManagerMemory) importAllowedPrograms(actorID string, programs []AllowedPrograms, upsert bool) []ProgramImportResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing := make(map[string]bool, len(m.programs))
+	for name := range m.programs {
+		existing[name] = true
+	}
+	results, writes := planProgramImport(programs, upsert, existing)
+	for _, p := range writes {
+		m.programs[p.ProgramName] = p
+	}
+	m.audit = append(m.audit, newAuditEntry(actorID, AuditImportAllowedPrograms, "", "", importedProgramNames(results)))
+	return results
+}
+
+func (m *ConfigManagerMemory) RequestAllowedProgram(ctx context.Context, programName string, reason string) (*ProgramRequest, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if allowed, _ := m.registry().IsAllowed(ctx, programName); allowed {
+		return nil, invalidf("program '%s' is already allowed", programName)
+	}
+	for _, existing := range m.requests {
+		if existing.ProgramName == programName && existing.Status == ProgramRequestPending {
+			return &existing, nil
+		}
+	}
+
+	now := time.Now()
+	req := ProgramRequest{
+		ID:               uuid.NewString(),
+		ProgramName:      programName,
+		Reason:           strings.TrimSpace(reason),
+		RequestedBy:      user.UserID,
+		Status:           ProgramRequestPending,
+		CreatedTimestamp: now,
+		UpdatedTimestamp: now,
+	}
+	m.requests[req.ID] = req
+	return &req, nil
+}
+
+func (m *ConfigManagerMemory) ListProgramRequests(
+	ctx context.Context,
+	page, limit int,
+	status string,
+) (mserve.Page[ProgramRequest], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[ProgramRequest]{}, err
+	}
+	if !isAdmin(user.Roles) {
+		return mserve.Page[ProgramRequest]{}, ErrForbidden
+	}
+
+	m.mu.RLock()
+	var requests []ProgramRequest
+	for _, req := range m.requests {
+		if status == "" || req.Status == status {
+			requests = append(requests, req)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].CreatedTimestamp.Equal(requests[j].CreatedTimestamp) {
+			return requests[i].CreatedTimestamp.After(requests[j].CreatedTimestamp)
+		}
+		return requests[i].ID < requests[j].ID
+	})
+	return mserve.Paginate(requests, page, limit)
+}
+
+func (m *ConfigManagerMemory) ApproveProgramRequest(ctx context.Context, requestID string) (*AllowedPrograms, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, err := m.pendingProgramRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	program := AllowedPrograms{ProgramName: req.ProgramName}
+	if _, ok := m.programs[program.ProgramName]; !ok {
+		m.programs[program.ProgramName] = program
+	}
+	m.reviewProgramRequest(req, ProgramRequestApproved, user.UserID, "")
+	m.audit = append(m.audit, newAuditEntry(user.UserID, AuditAddAllowedProgram, "", "", []string{program.ProgramName}))
+	return &program, nil
+}
+
+func (m *ConfigManagerMemory) RejectProgramRequest(ctx context.Context, requestID string, note string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, err := m.pendingProgramRequest(requestID)
+	if err != nil {
+		return err
+	}
+	m.reviewProgramRequest(req, ProgramRequestRejected, user.UserID, strings.TrimSpace(note))
+	return nil
+}
+
+// pendingProgramRequest returns a request that is still pending. Callers must hold m.mu.
+func (m *ConfigManagerMemory) pendingProgramRequest(requestID string) (ProgramRequest, error) {
+	req, ok := m.requests[requestID]
+	if !ok {
+		return ProgramRequest{}, ErrNotFound
+	}
+	if req.Status != ProgramRequestPending {
+		return ProgramRequest{}, invalidf("program request %s has already been %s", requestID, req.Status)
+	}
+	return req, nil
+}
+
+// reviewProgramRequest records the review decision. Callers must hold m.mu.
+func (m *ConfigManagerMemory) reviewProgramRequest(req ProgramRequest, status, reviewerID, note string) {
+	req.Status = status
+	req.ReviewedBy = reviewerID
+	req.ReviewNote = note
+	req.UpdatedTimestamp = time.Now()
+	m.requests[req.ID] = req
+}
+
+func (m *ConfigManagerMemory) CreateWebhook(ctx context.Context, req WebhookRequest) (*Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := newWebhook(req, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.webhooks[hook.ID] = *hook
+	return hook, nil
+}
+
+func (m *ConfigManagerMemory) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	hooks := []Webhook{}
+	for _, hook := range m.webhooks {
+		if hook.OwnerID == user.UserID {
+			hooks = append(hooks, hook)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].CreatedTimestamp.Before(hooks[j].CreatedTimestamp)
+	})
+	return hooks, nil
+}
+
+// ownedWebhook returns a webhook the signed-in user owns, or any webhook for admins. Callers must hold m.mu.
+func (m *ConfigManagerMemory) ownedWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hook, ok := m.webhooks[webhookID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if hook.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+	return &hook, nil
+}
+
+func (m *ConfigManagerMemory) DeleteWebhook(ctx context.Context, webhookID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.ownedWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+	delete(m.webhooks, webhookID)
+	return nil
+}
+
+func (m *ConfigManagerMemory) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID string,
+	page, limit int,
+) (mserve.Page[WebhookDelivery], error) {
+	m.mu.RLock()
+	if _, err := m.ownedWebhook(ctx, webhookID); err != nil {
+		m.mu.RUnlock()
+		return mserve.Page[WebhookDelivery]{}, err
+	}
+	var deliveries []WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedTimestamp.Equal(deliveries[j].CreatedTimestamp) {
+			return deliveries[i].CreatedTimestamp.After(deliveries[j].CreatedTimestamp)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	return mserve.Paginate(deliveries, page, limit)
+}
+
+func (m *ConfigManagerMemory) DeliverPendingWebhooks(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	m.mu.RLock()
+	var due []WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.Status == WebhookDeliveryPending && !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttempt.Before(due[j].NextAttempt)
+	})
+	if len(due) > webhookBatchSize {
+		due = due[:webhookBatchSize]
+	}
+
+	// The lock is not held while posting
+	lookup := func(ctx context.Context, webhookID string) (*Webhook, error) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		hook, ok := m.webhooks[webhookID]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		return &hook, nil
+	}
+	save := func(ctx context.Context, d WebhookDelivery) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.deliveries[d.ID] = d
+		return nil
+	}
+	return deliverWebhooks(ctx, due, lookup, save)
+}
+
+func (m *ConfigManagerMemory) CreateAPIKey(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	expiry time.Duration,
+) (*CreatedAPIKey, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newAPIKey(user.UserID, name, scopes, expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiKeys[key.ID] = key.APIKey
+	return key, nil
+}
+
+func (m *ConfigManagerMemory) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	keys := []APIKey{}
+	for _, key := range m.apiKeys {
+		if key.OwnerID == user.UserID {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedTimestamp.Before(keys[j].CreatedTimestamp)
+	})
+	return keys, nil
+}
+
+func (m *ConfigManagerMemory) RevokeAPIKey(ctx context.Context, keyID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.apiKeys[keyID]
+	if !ok {
+		return ErrNotFound
+	}
+	if key.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		m.apiKeys[keyID] = key
+	}
+	return nil
+}
+
+func (m *ConfigManagerMemory) AuthenticateAPIKey(ctx context.Context, token string) (*APIKey, error) {
+	hash := hashToken(token)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, key := range m.apiKeys {
+		if key.Hash == hash {
+			if !key.active(time.Now()) {
+				return nil, ErrUnauthorized
+			}
+			return &key, nil
+		}
+	}
+	return nil, ErrUnauthorized
+}
+
+// shareLink returns the link with the given token hash. Callers must hold m.mu.
+func (m *ConfigManagerMemory) shareLink(hash string) (*ShareLink, error) {
+	for _, link := range m.shareLinks {
+		if link.Hash == hash {
+			return &link, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *ConfigManagerMemory) CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (*CreatedShareLink, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, user, err := m.loadWritable(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	link, err := newShareLink(configID, user.UserID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	m.shareLinks[link.ID] = link.ShareLink
+	return link, nil
+}
+
+func (m *ConfigManagerMemory) ListShareLinks(ctx context.Context, configID string) ([]ShareLink, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, _, err := m.loadWritable(ctx, configID); err != nil {
+		return nil, err
+	}
+	links := []ShareLink{}
+	for _, link := range m.shareLinks {
+		if link.ConfigID == configID {
+			links = append(links, link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].CreatedTimestamp.Before(links[j].CreatedTimestamp)
+	})
+	return links, nil
+}
+
+func (m *ConfigManagerMemory) RevokeShareLink(ctx context.Context, configID, linkID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, _, err := m.loadWritable(ctx, configID); err != nil {
+		return err
+	}
+	link, ok := m.shareLinks[linkID]
+	if !ok || link.ConfigID != configID {
+		return ErrNotFound
+	}
+	if link.RevokedAt == nil {
+		now := time.Now()
+		link.RevokedAt = &now
+		m.shareLinks[linkID] = link
+	}
+	return nil
+}
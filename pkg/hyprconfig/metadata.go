@@ -0,0 +1,120 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Limits on the text shown with a config, in characters.
+const (
+	MaxTitleLength       = 120
+	MaxDescriptionLength = 5000
+	MaxTagLength         = 40
+	MaxTags              = 20
+)
+
+// MaxReadmeBytes bounds the markdown source of a config's README.
+const MaxReadmeBytes = 64 << 10
+
+// Codes of a FieldError for text fields.
+const (
+	CodeTooLong = "too_long"
+	CodeTooMany = "too_many"
+)
+
+// tagRe is what a normalized tag may contain, so tags are safe to show as-is in a web UI.
+var tagRe = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} ._+#-]*$`)
+
+// stripControl removes control characters from s. Multiline text keeps its newlines and tabs.
+func stripControl(s string, multiline bool) string {
+	return strings.Map(func(r rune) rune {
+		if multiline && (r == '\n' || r == '\t') {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// normalizeMetadata trims and strips control characters from the title, description, readme and
+// tags, strips raw HTML and script URLs from the readme, lowercases and dedupes the tags, and
+// records every field over its limit in verr.
+func (hc *HyprConfig) normalizeMetadata(verr *ValidationError) {
+	hc.Title = strings.TrimSpace(stripControl(hc.Title, false))
+	if hc.Title == "" {
+		verr.addf("title", CodeRequired, "config title cannot be empty")
+	} else if n := utf8.RuneCountInString(hc.Title); n > MaxTitleLength {
+		verr.addf("title", CodeTooLong, "title is %d characters, at most %d allowed", n, MaxTitleLength)
+	}
+
+	hc.Description = strings.TrimSpace(stripControl(hc.Description, true))
+	if n := utf8.RuneCountInString(hc.Description); n > MaxDescriptionLength {
+		verr.addf("description", CodeTooLong, "description is %d characters, at most %d allowed", n, MaxDescriptionLength)
+	}
+
+	hc.Readme = sanitizeReadme(strings.TrimSpace(stripControl(hc.Readme, true)))
+	if n := len(hc.Readme); n > MaxReadmeBytes {
+		verr.addf("readme", CodeTooLong, "readme is %d bytes, at most %d allowed", n, MaxReadmeBytes)
+	}
+
+	if hc.Tags == nil {
+		return
+	}
+	tags := make([]string, 0, len(hc.Tags))
+	for i, tag := range hc.Tags {
+		tag = strings.Join(strings.Fields(strings.ToLower(stripControl(tag, false))), " ")
+		switch {
+		case tag == "":
+			continue
+		case utf8.RuneCountInString(tag) > MaxTagLength:
+			verr.addf(fmt.Sprintf("tags[%d]", i), CodeTooLong, "tag %q is longer than %d characters", tag, MaxTagLength)
+		case !tagRe.MatchString(tag):
+			verr.addf(fmt.Sprintf("tags[%d]", i), CodeInvalid, "tag %q may only contain letters, digits, spaces and . _ + # -", tag)
+		}
+		if !containsExact(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxTags {
+		verr.addf("tags", CodeTooMany, "%d tags, at most %d allowed", len(tags), MaxTags)
+	}
+	hc.Tags = tags
+}
+
+// setMetadataUpdates replaces the title, description, readme, tags and license in updates with
+// their values in merged, which normalizeMetadata and normalizeLicense already cleaned up.
+func setMetadataUpdates(updates bson.M, merged *HyprConfig) {
+	if _, ok := updates["title"]; ok {
+		updates["title"] = merged.Title
+	}
+	if _, ok := updates["description"]; ok {
+		updates["description"] = merged.Description
+	}
+	if _, ok := updates["readme"]; ok {
+		updates["readme"] = merged.Readme
+	}
+	// Changing the license away from custom drops its text
+	_, license := updates["license"]
+	if _, text := updates["license_text"]; license || text {
+		updates["license"] = merged.License
+		updates["license_text"] = merged.LicenseText
+	}
+	if _, ok := updates["min_hyprland_version"]; ok {
+		updates["min_hyprland_version"] = merged.MinHyprlandVersion
+		updates["hyprland_version_key"] = hyprlandVersionKey(merged.MinHyprlandVersion)
+	}
+	if _, ok := updates["tags"]; ok {
+		tags := merged.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		updates["tags"] = tags
+	}
+}
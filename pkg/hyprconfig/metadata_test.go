@@ -0,0 +1,98 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidateNormalizesMetadata(t *testing.T) {
+	cfg := HyprConfig{
+		Title:          "  Nord\x00 rice\t ",
+		Description:    "\r\nline one\r\nline\ttwo\x1b[31m\n",
+		Tags:           []string{" Nord ", "nord", "Dark  Theme", "", "c++", "\x07tiling"},
+		ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty"}},
+	}
+	if err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{}); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	if cfg.Title != "Nord rice" {
+		t.Errorf("title = %q", cfg.Title)
+	}
+	if cfg.Description != "line one\nline\ttwo[31m" {
+		t.Errorf("description = %q", cfg.Description)
+	}
+	if want := []string{"nord", "dark theme", "c++", "tiling"}; !reflect.DeepEqual(cfg.Tags, want) {
+		t.Errorf("tags = %q, want %q", cfg.Tags, want)
+	}
+}
+
+func TestConfigValidateMetadataLimits(t *testing.T) {
+	tags := []string{"<script>alert(1)</script>", strings.Repeat("t", MaxTagLength+1)}
+	for i := 0; i < MaxTags; i++ {
+		tags = append(tags, "tag"+strings.Repeat("x", i))
+	}
+	cfg := HyprConfig{
+		Title:          strings.Repeat("é", MaxTitleLength+1),
+		Description:    strings.Repeat("d", MaxDescriptionLength+1),
+		Tags:           tags,
+		ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty"}},
+	}
+
+	err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+	var got []string
+	for _, fe := range verr.Errors {
+		got = append(got, fe.Path+" "+fe.Code)
+	}
+	want := []string{"title too_long", "description too_long", "tags[0] invalid", "tags[1] too_long", "tags too_many"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %v, want %v", got, want)
+	}
+
+	cfg.Title = strings.Repeat("é", MaxTitleLength)
+	cfg.Description = strings.Repeat("d", MaxDescriptionLength)
+	cfg.Tags = tags[2:]
+	if err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{}); err != nil {
+		t.Errorf("text at the limits returned error: %v", err)
+	}
+}
+
+func TestManagerNormalizesMetadataOnWrite(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		cfg, err := m.CreateConfig(ctx, &HyprConfig{
+			Title:          " rice ",
+			Tags:           []string{"Nord", "NORD"},
+			ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Title != "rice" || !reflect.DeepEqual(cfg.Tags, []string{"nord"}) {
+			t.Errorf("created title %q and tags %q", cfg.Title, cfg.Tags)
+		}
+
+		err = m.UpdateConfig(ctx, cfg.ID, map[string]any{"description": " dark\x00 ", "tags": []string{"Dark", " dark"}}, UpdateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := m.GetConfig(ctx, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Description != "dark" || !reflect.DeepEqual(got.Tags, []string{"dark"}) {
+			t.Errorf("updated description %q and tags %q", got.Description, got.Tags)
+		}
+
+		err = m.UpdateConfig(ctx, cfg.ID, map[string]any{"tags": []string{"<b>"}}, UpdateOptions{})
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("invalid tag update: got %v, want ErrValidation", err)
+		}
+	})
+}
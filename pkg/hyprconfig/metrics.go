@@ -0,0 +1,488 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InstrumentedConfigManager wraps a ConfigManager and records Prometheus metrics for every call:
+// a call counter and a latency histogram per method, and an error counter per method and domain
+// error (see ErrorType).
+type InstrumentedConfigManager struct {
+	next ConfigManager
+
+	calls    *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+var _ ConfigManager = (*InstrumentedConfigManager)(nil)
+
+// NewInstrumentedConfigManager wraps next and registers its metrics with reg. It fails when reg
+// already has them, so wrap a manager only once per registry.
+func NewInstrumentedConfigManager(next ConfigManager, reg prometheus.Registerer) (*InstrumentedConfigManager, error) {
+	m := &InstrumentedConfigManager{
+		next: next,
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hypr",
+			Subsystem: "config_manager",
+			Name:      "calls_total",
+			Help:      "ConfigManager calls by method.",
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "hypr",
+			Subsystem: "config_manager",
+			Name:      "errors_total",
+			Help:      "ConfigManager calls that failed, by method and domain error.",
+		}, []string{"method", "error"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "hypr",
+			Subsystem: "config_manager",
+			Name:      "call_duration_seconds",
+			Help:      "ConfigManager call latency by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+	}
+	for _, c := range []prometheus.Collector{m.calls, m.errors, m.duration} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ErrorType names the domain error err wraps, for use as a metric label. It returns "" for nil
+// and "internal" for errors that are not domain errors.
+func ErrorType(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrQuotaExceeded):
+		return "quota_exceeded"
+	case errors.Is(err, ErrLimitExceeded):
+		return "limit_exceeded"
+	case errors.Is(err, ErrReadOnly):
+		return "read_only"
+	case errors.Is(err, ErrDuplicateConfig):
+		return "duplicate"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	case errors.Is(err, ErrAllowlistDisabled):
+		return "allowlist_disabled"
+	}
+	for _, target := range invalidInputErrors {
+		if errors.Is(err, target) {
+			return "validation"
+		}
+	}
+	return "internal"
+}
+
+// invalidInputErrors are the errors reported for input the caller has to fix.
+var invalidInputErrors = []error{
+	ErrValidation,
+	ErrInvalidPlatform,
+	ErrInvalidLicense,
+	ErrInvalidPlaceholder,
+	ErrMissingVariable,
+	ErrDependencyCycle,
+	ErrInvalidEnvVar,
+	ErrContentTooLarge,
+	ErrBinaryNotAllowed,
+	ErrMissingHash,
+	ErrHashMismatch,
+	ErrInvalidInstallPath,
+	ErrInvalidFileMode,
+	ErrUnsafeCommand,
+	ErrUnknownFile,
+	ErrUnsupportedDistro,
+	ErrInvalidWebhook,
+	ErrInvalidAPIKey,
+	ErrInvalidImage,
+	ErrGalleryFull,
+}
+
+// observe records a call to method that started at start and failed with *err, if it did.
+func (m *InstrumentedConfigManager) observe(method string, start time.Time, err *error) {
+	m.calls.WithLabelValues(method).Inc()
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		m.errors.WithLabelValues(method, ErrorType(*err)).Inc()
+	}
+}
+
+func (m *InstrumentedConfigManager) SizeLimits() SizeLimits {
+	return m.next.SizeLimits()
+}
+
+func (m *InstrumentedConfigManager) CreateConfig(ctx context.Context, cfg *HyprConfig) (_ *HyprConfig, err error) {
+	defer m.observe("CreateConfig", time.Now(), &err)
+	return m.next.CreateConfig(ctx, cfg)
+}
+
+func (m *InstrumentedConfigManager) GetConfig(ctx context.Context, id string) (_ *HyprConfig, err error) {
+	defer m.observe("GetConfig", time.Now(), &err)
+	return m.next.GetConfig(ctx, id)
+}
+
+func (m *InstrumentedConfigManager) GetProgramConfig(ctx context.Context, configID, progID string) (_ *HyprProgramConfig, err error) {
+	defer m.observe("GetProgramConfig", time.Now(), &err)
+	return m.next.GetProgramConfig(ctx, configID, progID)
+}
+
+func (m *InstrumentedConfigManager) GetProgramFile(ctx context.Context, configID, progID, filePath string) (_ io.ReadCloser, _ *FileEntry, err error) {
+	defer m.observe("GetProgramFile", time.Now(), &err)
+	return m.next.GetProgramFile(ctx, configID, progID, filePath)
+}
+
+func (m *InstrumentedConfigManager) AddGalleryImage(ctx context.Context, configID string, data []byte) (_ *GalleryImage, err error) {
+	defer m.observe("AddGalleryImage", time.Now(), &err)
+	return m.next.AddGalleryImage(ctx, configID, data)
+}
+
+func (m *InstrumentedConfigManager) GetGalleryImage(ctx context.Context, configID string, index int) (_ io.ReadCloser, _ *GalleryImage, err error) {
+	defer m.observe("GetGalleryImage", time.Now(), &err)
+	return m.next.GetGalleryImage(ctx, configID, index)
+}
+
+func (m *InstrumentedConfigManager) RemoveGalleryImage(ctx context.Context, configID string, index int) (err error) {
+	defer m.observe("RemoveGalleryImage", time.Now(), &err)
+	return m.next.RemoveGalleryImage(ctx, configID, index)
+}
+
+func (m *InstrumentedConfigManager) ExportConfigArchive(ctx context.Context, configID string, w io.Writer) (err error) {
+	defer m.observe("ExportConfigArchive", time.Now(), &err)
+	return m.next.ExportConfigArchive(ctx, configID, w)
+}
+
+func (m *InstrumentedConfigManager) GetInstallScript(ctx context.Context, configID, distro string, includeOptional bool) (_ string, err error) {
+	defer m.observe("GetInstallScript", time.Now(), &err)
+	return m.next.GetInstallScript(ctx, configID, distro, includeOptional)
+}
+
+func (m *InstrumentedConfigManager) ExportHomeManager(ctx context.Context, configID string) (_ string, err error) {
+	defer m.observe("ExportHomeManager", time.Now(), &err)
+	return m.next.ExportHomeManager(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) (err error) {
+	defer m.observe("UpdateConfig", time.Now(), &err)
+	return m.next.UpdateConfig(ctx, id, updates, opts)
+}
+
+func (m *InstrumentedConfigManager) DeleteConfig(ctx context.Context, id string) (err error) {
+	defer m.observe("DeleteConfig", time.Now(), &err)
+	return m.next.DeleteConfig(ctx, id)
+}
+
+func (m *InstrumentedConfigManager) ListConfigs(ctx context.Context, page, limit int, findOpts *options.FindOptions) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("ListConfigs", time.Now(), &err)
+	return m.next.ListConfigs(ctx, page, limit, findOpts)
+}
+
+func (m *InstrumentedConfigManager) ListMyConfigs(ctx context.Context, page, limit int, findOpts *options.FindOptions) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("ListMyConfigs", time.Now(), &err)
+	return m.next.ListMyConfigs(ctx, page, limit, findOpts)
+}
+
+func (m *InstrumentedConfigManager) ListSimilarConfigs(ctx context.Context, configID string, page, limit int) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("ListSimilarConfigs", time.Now(), &err)
+	return m.next.ListSimilarConfigs(ctx, configID, page, limit)
+}
+
+func (m *InstrumentedConfigManager) ListConfigsWithFilters(ctx context.Context, page, limit int, filters ConfigSearchFilters, findOpts *options.FindOptions) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("ListConfigsWithFilters", time.Now(), &err)
+	return m.next.ListConfigsWithFilters(ctx, page, limit, filters, findOpts)
+}
+
+func (m *InstrumentedConfigManager) AdminListConfigs(ctx context.Context, page, limit int, filters ConfigSearchFilters) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("AdminListConfigs", time.Now(), &err)
+	return m.next.AdminListConfigs(ctx, page, limit, filters)
+}
+
+func (m *InstrumentedConfigManager) FavoriteConfig(ctx context.Context, configID string) (err error) {
+	defer m.observe("FavoriteConfig", time.Now(), &err)
+	return m.next.FavoriteConfig(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) VerifyConfig(ctx context.Context, configID string) (err error) {
+	defer m.observe("VerifyConfig", time.Now(), &err)
+	return m.next.VerifyConfig(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) UnverifyConfig(ctx context.Context, configID string) (err error) {
+	defer m.observe("UnverifyConfig", time.Now(), &err)
+	return m.next.UnverifyConfig(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) (err error) {
+	defer m.observe("DeprecateConfig", time.Now(), &err)
+	return m.next.DeprecateConfig(ctx, configID, successorID, message)
+}
+
+func (m *InstrumentedConfigManager) UnfavoriteConfig(ctx context.Context, configID string) (err error) {
+	defer m.observe("UnfavoriteConfig", time.Now(), &err)
+	return m.next.UnfavoriteConfig(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) ListFavorites(ctx context.Context, page, limit int) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("ListFavorites", time.Now(), &err)
+	return m.next.ListFavorites(ctx, page, limit)
+}
+
+func (m *InstrumentedConfigManager) ListConfigFavoriters(ctx context.Context, configID string, page, limit int) (_ mserve.Page[ConfigFavoriter], err error) {
+	defer m.observe("ListConfigFavoriters", time.Now(), &err)
+	return m.next.ListConfigFavoriters(ctx, configID, page, limit)
+}
+
+func (m *InstrumentedConfigManager) CountConfigFavorites(ctx context.Context, configID string) (_ int64, err error) {
+	defer m.observe("CountConfigFavorites", time.Now(), &err)
+	return m.next.CountConfigFavorites(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) ApplyConfig(ctx context.Context, configID string) (err error) {
+	defer m.observe("ApplyConfig", time.Now(), &err)
+	return m.next.ApplyConfig(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) GetAppliedConfig(ctx context.Context) (_ *HyprConfig, err error) {
+	defer m.observe("GetAppliedConfig", time.Now(), &err)
+	return m.next.GetAppliedConfig(ctx)
+}
+
+func (m *InstrumentedConfigManager) WatchAppliedConfig(ctx context.Context) (_ <-chan AppliedConfigEvent, err error) {
+	defer m.observe("WatchAppliedConfig", time.Now(), &err)
+	return m.next.WatchAppliedConfig(ctx)
+}
+
+func (m *InstrumentedConfigManager) CountUsersUsingConfig(ctx context.Context, configID string) (_ int64, err error) {
+	defer m.observe("CountUsersUsingConfig", time.Now(), &err)
+	return m.next.CountUsersUsingConfig(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) GetAuthorStats(ctx context.Context, ownerID string) (_ *AuthorStats, err error) {
+	defer m.observe("GetAuthorStats", time.Now(), &err)
+	return m.next.GetAuthorStats(ctx, ownerID)
+}
+
+func (m *InstrumentedConfigManager) ListRelatedConfigs(ctx context.Context, configID string, limit int) (_ []HyprConfig, err error) {
+	defer m.observe("ListRelatedConfigs", time.Now(), &err)
+	return m.next.ListRelatedConfigs(ctx, configID, limit)
+}
+
+func (m *InstrumentedConfigManager) GetRandomConfig(ctx context.Context, filters ConfigSearchFilters) (_ *HyprConfig, err error) {
+	defer m.observe("GetRandomConfig", time.Now(), &err)
+	return m.next.GetRandomConfig(ctx, filters)
+}
+
+func (m *InstrumentedConfigManager) FollowAuthor(ctx context.Context, ownerID string) (err error) {
+	defer m.observe("FollowAuthor", time.Now(), &err)
+	return m.next.FollowAuthor(ctx, ownerID)
+}
+
+func (m *InstrumentedConfigManager) UnfollowAuthor(ctx context.Context, ownerID string) (err error) {
+	defer m.observe("UnfollowAuthor", time.Now(), &err)
+	return m.next.UnfollowAuthor(ctx, ownerID)
+}
+
+func (m *InstrumentedConfigManager) ListFollowing(ctx context.Context) (_ []FollowedAuthor, err error) {
+	defer m.observe("ListFollowing", time.Now(), &err)
+	return m.next.ListFollowing(ctx)
+}
+
+func (m *InstrumentedConfigManager) ListFollowedFeed(ctx context.Context, page, limit int) (_ mserve.Page[HyprConfig], err error) {
+	defer m.observe("ListFollowedFeed", time.Now(), &err)
+	return m.next.ListFollowedFeed(ctx, page, limit)
+}
+
+func (m *InstrumentedConfigManager) ListNotifications(ctx context.Context, page, limit int, unreadOnly bool) (_ mserve.Page[Notification], err error) {
+	defer m.observe("ListNotifications", time.Now(), &err)
+	return m.next.ListNotifications(ctx, page, limit, unreadOnly)
+}
+
+func (m *InstrumentedConfigManager) MarkNotificationsRead(ctx context.Context, ids []string) (_ int64, err error) {
+	defer m.observe("MarkNotificationsRead", time.Now(), &err)
+	return m.next.MarkNotificationsRead(ctx, ids)
+}
+
+func (m *InstrumentedConfigManager) CountUnreadNotifications(ctx context.Context) (_ int64, err error) {
+	defer m.observe("CountUnreadNotifications", time.Now(), &err)
+	return m.next.CountUnreadNotifications(ctx)
+}
+
+func (m *InstrumentedConfigManager) AddProgramConfig(ctx context.Context, configID string, newProg HyprProgramConfig, parentID *string, changelog string) (err error) {
+	defer m.observe("AddProgramConfig", time.Now(), &err)
+	return m.next.AddProgramConfig(ctx, configID, newProg, parentID, changelog)
+}
+
+func (m *InstrumentedConfigManager) RemoveProgramConfig(ctx context.Context, configID string, progID string, changelog string) (err error) {
+	defer m.observe("RemoveProgramConfig", time.Now(), &err)
+	return m.next.RemoveProgramConfig(ctx, configID, progID, changelog)
+}
+
+func (m *InstrumentedConfigManager) MoveProgramConfig(ctx context.Context, configID string, progID string, newParentID *string, changelog string) (err error) {
+	defer m.observe("MoveProgramConfig", time.Now(), &err)
+	return m.next.MoveProgramConfig(ctx, configID, progID, newParentID, changelog)
+}
+
+func (m *InstrumentedConfigManager) UpdateProgramConfig(ctx context.Context, configID string, progID string, updates HyprProgramConfig, changelog string) (err error) {
+	defer m.observe("UpdateProgramConfig", time.Now(), &err)
+	return m.next.UpdateProgramConfig(ctx, configID, progID, updates, changelog)
+}
+
+func (m *InstrumentedConfigManager) GetChangelog(ctx context.Context, configID string, page, limit int) (_ mserve.Page[ChangelogEntry], err error) {
+	defer m.observe("GetChangelog", time.Now(), &err)
+	return m.next.GetChangelog(ctx, configID, page, limit)
+}
+
+func (m *InstrumentedConfigManager) ListAuditLog(ctx context.Context, configID string, page, limit int) (_ mserve.Page[AuditEntry], err error) {
+	defer m.observe("ListAuditLog", time.Now(), &err)
+	return m.next.ListAuditLog(ctx, configID, page, limit)
+}
+
+func (m *InstrumentedConfigManager) AddAllowedProgram(ctx context.Context, programName string) (_ *AllowedPrograms, err error) {
+	defer m.observe("AddAllowedProgram", time.Now(), &err)
+	return m.next.AddAllowedProgram(ctx, programName)
+}
+
+func (m *InstrumentedConfigManager) GetAllowedProgram(ctx context.Context, programName string) (_ *AllowedPrograms, err error) {
+	defer m.observe("GetAllowedProgram", time.Now(), &err)
+	return m.next.GetAllowedProgram(ctx, programName)
+}
+
+func (m *InstrumentedConfigManager) ListAllowedPrograms(ctx context.Context) (_ []AllowedPrograms, err error) {
+	defer m.observe("ListAllowedPrograms", time.Now(), &err)
+	return m.next.ListAllowedPrograms(ctx)
+}
+
+func (m *InstrumentedConfigManager) ListAllowedProgramsPaged(ctx context.Context, page, limit int, filters AllowedProgramFilters) (_ mserve.Page[AllowedPrograms], err error) {
+	defer m.observe("ListAllowedProgramsPaged", time.Now(), &err)
+	return m.next.ListAllowedProgramsPaged(ctx, page, limit, filters)
+}
+
+func (m *InstrumentedConfigManager) RemoveAllowedProgram(ctx context.Context, programName string) (err error) {
+	defer m.observe("RemoveAllowedProgram", time.Now(), &err)
+	return m.next.RemoveAllowedProgram(ctx, programName)
+}
+
+func (m *InstrumentedConfigManager) ImportAllowedPrograms(ctx context.Context, programs []AllowedPrograms, upsert bool) (_ []ProgramImportResult, err error) {
+	defer m.observe("ImportAllowedPrograms", time.Now(), &err)
+	return m.next.ImportAllowedPrograms(ctx, programs, upsert)
+}
+
+func (m *InstrumentedConfigManager) SeedDefaultPrograms(ctx context.Context) (_ []ProgramImportResult, err error) {
+	defer m.observe("SeedDefaultPrograms", time.Now(), &err)
+	return m.next.SeedDefaultPrograms(ctx)
+}
+
+func (m *InstrumentedConfigManager) RequestAllowedProgram(ctx context.Context, programName string, reason string) (_ *ProgramRequest, err error) {
+	defer m.observe("RequestAllowedProgram", time.Now(), &err)
+	return m.next.RequestAllowedProgram(ctx, programName, reason)
+}
+
+func (m *InstrumentedConfigManager) ListProgramRequests(ctx context.Context, page, limit int, status string) (_ mserve.Page[ProgramRequest], err error) {
+	defer m.observe("ListProgramRequests", time.Now(), &err)
+	return m.next.ListProgramRequests(ctx, page, limit, status)
+}
+
+func (m *InstrumentedConfigManager) ApproveProgramRequest(ctx context.Context, requestID string) (_ *AllowedPrograms, err error) {
+	defer m.observe("ApproveProgramRequest", time.Now(), &err)
+	return m.next.ApproveProgramRequest(ctx, requestID)
+}
+
+func (m *InstrumentedConfigManager) RejectProgramRequest(ctx context.Context, requestID string, note string) (err error) {
+	defer m.observe("RejectProgramRequest", time.Now(), &err)
+	return m.next.RejectProgramRequest(ctx, requestID, note)
+}
+
+func (m *InstrumentedConfigManager) CreateWebhook(ctx context.Context, req WebhookRequest) (_ *Webhook, err error) {
+	defer m.observe("CreateWebhook", time.Now(), &err)
+	return m.next.CreateWebhook(ctx, req)
+}
+
+func (m *InstrumentedConfigManager) ListWebhooks(ctx context.Context) (_ []Webhook, err error) {
+	defer m.observe("ListWebhooks", time.Now(), &err)
+	return m.next.ListWebhooks(ctx)
+}
+
+func (m *InstrumentedConfigManager) DeleteWebhook(ctx context.Context, webhookID string) (err error) {
+	defer m.observe("DeleteWebhook", time.Now(), &err)
+	return m.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (m *InstrumentedConfigManager) ListWebhookDeliveries(ctx context.Context, webhookID string, page, limit int) (_ mserve.Page[WebhookDelivery], err error) {
+	defer m.observe("ListWebhookDeliveries", time.Now(), &err)
+	return m.next.ListWebhookDeliveries(ctx, webhookID, page, limit)
+}
+
+func (m *InstrumentedConfigManager) DeliverPendingWebhooks(ctx context.Context) (_ int, err error) {
+	defer m.observe("DeliverPendingWebhooks", time.Now(), &err)
+	return m.next.DeliverPendingWebhooks(ctx)
+}
+
+func (m *InstrumentedConfigManager) CreateAPIKey(ctx context.Context, name string, scopes []string, expiry time.Duration) (_ *CreatedAPIKey, err error) {
+	defer m.observe("CreateAPIKey", time.Now(), &err)
+	return m.next.CreateAPIKey(ctx, name, scopes, expiry)
+}
+
+func (m *InstrumentedConfigManager) ListAPIKeys(ctx context.Context) (_ []APIKey, err error) {
+	defer m.observe("ListAPIKeys", time.Now(), &err)
+	return m.next.ListAPIKeys(ctx)
+}
+
+func (m *InstrumentedConfigManager) RevokeAPIKey(ctx context.Context, keyID string) (err error) {
+	defer m.observe("RevokeAPIKey", time.Now(), &err)
+	return m.next.RevokeAPIKey(ctx, keyID)
+}
+
+func (m *InstrumentedConfigManager) AuthenticateAPIKey(ctx context.Context, token string) (_ *APIKey, err error) {
+	defer m.observe("AuthenticateAPIKey", time.Now(), &err)
+	return m.next.AuthenticateAPIKey(ctx, token)
+}
+
+func (m *InstrumentedConfigManager) CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (_ *CreatedShareLink, err error) {
+	defer m.observe("CreateShareLink", time.Now(), &err)
+	return m.next.CreateShareLink(ctx, configID, ttl)
+}
+
+func (m *InstrumentedConfigManager) ListShareLinks(ctx context.Context, configID string) (_ []ShareLink, err error) {
+	defer m.observe("ListShareLinks", time.Now(), &err)
+	return m.next.ListShareLinks(ctx, configID)
+}
+
+func (m *InstrumentedConfigManager) RevokeShareLink(ctx context.Context, configID, linkID string) (err error) {
+	defer m.observe("RevokeShareLink", time.Now(), &err)
+	return m.next.RevokeShareLink(ctx, configID, linkID)
+}
+
+func (m *InstrumentedConfigManager) GetQuotaUsage(ctx context.Context) (_ *QuotaUsage, err error) {
+	defer m.observe("GetQuotaUsage", time.Now(), &err)
+	return m.next.GetQuotaUsage(ctx)
+}
+
+func (m *InstrumentedConfigManager) SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (_ *QuotaUsage, err error) {
+	defer m.observe("SetUserQuota", time.Now(), &err)
+	return m.next.SetUserQuota(ctx, userID, quotaBytes)
+}
+
+func (m *InstrumentedConfigManager) SetUserConfigLimit(ctx context.Context, userID string, limit int64) (_ *ConfigLimit, err error) {
+	defer m.observe("SetUserConfigLimit", time.Now(), &err)
+	return m.next.SetUserConfigLimit(ctx, userID, limit)
+}
@@ -0,0 +1,61 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentedConfigManager(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := NewInstrumentedConfigManager(NewInMemoryConfigManager(), reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig(t, m, "alice", true)
+	if err := m.DeleteConfig(asUser("bob"), cfg.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("deleting someone else's config: got %v, want ErrForbidden", err)
+	}
+
+	if n := testutil.ToFloat64(m.calls.WithLabelValues("CreateConfig")); n != 1 {
+		t.Errorf("CreateConfig calls = %v, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.calls.WithLabelValues("DeleteConfig")); n != 1 {
+		t.Errorf("DeleteConfig calls = %v, want 1", n)
+	}
+	if n := testutil.ToFloat64(m.errors.WithLabelValues("DeleteConfig", "forbidden")); n != 1 {
+		t.Errorf("DeleteConfig forbidden errors = %v, want 1", n)
+	}
+	if n := testutil.CollectAndCount(m.errors); n != 1 {
+		t.Errorf("%d error series, want only the forbidden delete", n)
+	}
+	if n := testutil.CollectAndCount(m.duration); n != 2 {
+		t.Errorf("%d latency series, want 2", n)
+	}
+
+	if _, err := NewInstrumentedConfigManager(m, reg); err == nil {
+		t.Error("registering the metrics twice succeeded")
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{ErrNotFound, "not_found"},
+		{&AppliedConfigMissingError{ConfigID: "gone"}, "not_found"},
+		{&DuplicateConfigError{ExistingID: "x"}, "duplicate"},
+		{fmt.Errorf("program kitty: %w", ErrUnsafeCommand), "validation"},
+		{errors.New("connection refused"), "internal"},
+	} {
+		if got := ErrorType(tt.err); got != tt.want {
+			t.Errorf("ErrorType(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
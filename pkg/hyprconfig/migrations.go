@@ -0,0 +1,227 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// configMigration brings a config document from schema version Version-1 to Version. Migrations
+// work on documents as stored: file content may be compressed or offloaded.
+type configMigration struct {
+	Version     int
+	Description string
+	Migrate     func(cfg *HyprConfig) error
+}
+
+// configMigrations are applied in order to documents below their version. Append new migrations
+// at the end and never change one that has shipped.
+var configMigrations = []configMigration{
+	{Version: 1, Description: "fill in version and updated timestamp", Migrate: migrateDefaults},
+	{Version: 2, Description: "compute duplicate fingerprints", Migrate: migrateFingerprint},
+	{Version: 3, Description: "parse monitor and workspace layouts", Migrate: migrateDisplay},
+	{Version: 4, Description: "extract color palettes", Migrate: migratePalette},
+	{Version: 5, Description: "extract keybinds", Migrate: migrateKeybinds},
+	{Version: 6, Description: "reset invalid versions", Migrate: migrateVersion},
+}
+
+// CurrentSchemaVersion is the schema version of configs written by this build.
+var CurrentSchemaVersion = configMigrations[len(configMigrations)-1].Version
+
+// DefaultMigrationBatchSize is how many configs MigrateConfigs writes at once by default.
+const DefaultMigrationBatchSize = 500
+
+// ConfigMigrator is implemented by the config managers whose stored documents can be older than
+// this build.
+type ConfigMigrator interface {
+	// MigrateConfigs applies the pending migrations to every config below CurrentSchemaVersion,
+	// batchSize at a time, and returns how many it wrote. A config changed by someone else while
+	// it was migrated is left for the next run.
+	MigrateConfigs(ctx context.Context, batchSize int) (int, error)
+}
+
+// migrateConfig applies the migrations cfg is missing and sets its SchemaVersion.
+func migrateConfig(cfg *HyprConfig) error {
+	for _, mig := range configMigrations {
+		if mig.Version <= cfg.SchemaVersion {
+			continue
+		}
+		if err := mig.Migrate(cfg); err != nil {
+			return fmt.Errorf("config %s: migration %d (%s): %w", cfg.ID, mig.Version, mig.Description, err)
+		}
+		cfg.SchemaVersion = mig.Version
+	}
+	return nil
+}
+
+// migrateDefaults fills in the fields documents written before they were required lack.
+func migrateDefaults(cfg *HyprConfig) error {
+	if cfg.Version == "" {
+		cfg.Version = DefaultConfigVersion
+	}
+	if cfg.UpdatedTimestamp.IsZero() {
+		cfg.UpdatedTimestamp = cfg.CreatedTimestamp
+	}
+	return nil
+}
+
+// migrateVersion resets the versions of documents written before versions were validated that
+// aren't semantic versions, which bumpVersion would reject on every update.
+func migrateVersion(cfg *HyprConfig) error {
+	if ValidateVersion(cfg.Version) != nil {
+		cfg.Version = DefaultConfigVersion
+	}
+	return nil
+}
+
+// migrateFingerprint computes the fingerprint of documents written before duplicate detection.
+func migrateFingerprint(cfg *HyprConfig) error {
+	if cfg.Fingerprint == "" {
+		cfg.Fingerprint = cfg.fingerprint()
+	}
+	return nil
+}
+
+// migrateDisplay parses the display layout of documents written before it was stored.
+func migrateDisplay(cfg *HyprConfig) error {
+	cfg.Display = cfg.displayLayout()
+	return nil
+}
+
+// migratePalette extracts the palette and theme of documents written before they were stored.
+func migratePalette(cfg *HyprConfig) error {
+	cfg.Palette, cfg.Theme = cfg.colorPalette()
+	return nil
+}
+
+// migrateKeybinds extracts the keybinds of documents written before they were stored.
+func migrateKeybinds(cfg *HyprConfig) error {
+	cfg.Keybinds = ConfigKeybinds(cfg)
+	return nil
+}
+
+// migrationProgress logs how far MigrateConfigs got.
+func migrationProgress(migrated, total int) {
+	slog.Info("migrating configs", "migrated", migrated, "total", total, "schema_version", CurrentSchemaVersion)
+}
+
+func (m *ConfigManagerMongo) MigrateConfigs(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultMigrationBatchSize
+	}
+	// Documents without the field match too
+	filter := bson.M{"schema_version": bson.M{"$not": bson.M{"$gte": CurrentSchemaVersion}}}
+	total, err := m.Collection.CountDocuments(ctx, filter)
+	if err != nil || total == 0 {
+		return 0, err
+	}
+
+	cursor, err := m.Collection.Find(ctx, filter, options.Find().SetBatchSize(int32(batchSize)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find configs to migrate: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	var models []mongo.WriteModel
+	write := func() error {
+		if len(models) == 0 {
+			return nil
+		}
+		res, err := m.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return fmt.Errorf("failed to write migrated configs: %w", err)
+		}
+		migrated += int(res.ModifiedCount)
+		models = models[:0]
+		migrationProgress(migrated, int(total))
+		return nil
+	}
+	for cursor.Next(ctx) {
+		var cfg HyprConfig
+		if err := cursor.Decode(&cfg); err != nil {
+			return migrated, fmt.Errorf("failed to decode config: %w", err)
+		}
+		// Only replace the document as it was read
+		unchanged := unchangedFilter(&cfg)
+		if err := migrateConfig(&cfg); err != nil {
+			return migrated, err
+		}
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(unchanged).SetReplacement(&cfg))
+		if len(models) == batchSize {
+			if err := write(); err != nil {
+				return migrated, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return migrated, fmt.Errorf("failed to find configs to migrate: %w", err)
+	}
+	return migrated, write()
+}
+
+func (m *ConfigManagerSQLite) MigrateConfigs(ctx context.Context, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultMigrationBatchSize
+	}
+	const pending = `COALESCE(json_extract(doc, '$.schema_version'), 0) < ?`
+	var total int
+	if err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM configs WHERE `+pending, CurrentSchemaVersion).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count configs to migrate: %w", err)
+	}
+
+	migrated := 0
+	for migrated < total {
+		n := 0
+		err := m.withTx(ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx, `SELECT doc, likes FROM configs WHERE `+pending+` ORDER BY id LIMIT ?`, CurrentSchemaVersion, batchSize)
+			if err != nil {
+				return err
+			}
+			var batch []*HyprConfig
+			for rows.Next() {
+				var doc string
+				var likes int64
+				if err := rows.Scan(&doc, &likes); err != nil {
+					rows.Close()
+					return err
+				}
+				cfg, err := decodeConfigRow(doc, likes)
+				if err != nil {
+					rows.Close()
+					return err
+				}
+				batch = append(batch, cfg)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			for _, cfg := range batch {
+				if err := migrateConfig(cfg); err != nil {
+					return err
+				}
+				if err := m.putConfig(ctx, tx, cfg); err != nil {
+					return err
+				}
+			}
+			n = len(batch)
+			return nil
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate configs: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+		migrated += n
+		migrationProgress(migrated, total)
+	}
+	return migrated, nil
+}
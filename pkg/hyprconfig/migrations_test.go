@@ -0,0 +1,156 @@
+package hyprconfig
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// loadFixtureConfig reads a config document as an older version stored it.
+func loadFixtureConfig(t *testing.T, name string) *HyprConfig {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "migrations", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg HyprConfig
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+func TestConfigMigrationsAreOrdered(t *testing.T) {
+	for i, mig := range configMigrations {
+		if mig.Version != i+1 {
+			t.Errorf("migration %d (%s) has version %d, want %d", i, mig.Description, mig.Version, i+1)
+		}
+	}
+	if CurrentSchemaVersion != len(configMigrations) {
+		t.Errorf("CurrentSchemaVersion = %d, want %d", CurrentSchemaVersion, len(configMigrations))
+	}
+}
+
+func TestMigrateDefaults(t *testing.T) {
+	cfg := loadFixtureConfig(t, "v0.json")
+	if err := migrateDefaults(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != DefaultConfigVersion {
+		t.Errorf("version = %q, want %q", cfg.Version, DefaultConfigVersion)
+	}
+	if !cfg.UpdatedTimestamp.Equal(cfg.CreatedTimestamp) {
+		t.Errorf("updated timestamp = %v, want the created timestamp %v", cfg.UpdatedTimestamp, cfg.CreatedTimestamp)
+	}
+
+	// Fields that are set are kept
+	cfg = loadFixtureConfig(t, "v1.json")
+	if err := migrateDefaults(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != "1.2.0" || !cfg.UpdatedTimestamp.Equal(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("set fields changed: version %q, updated %v", cfg.Version, cfg.UpdatedTimestamp)
+	}
+}
+
+func TestMigrateFingerprint(t *testing.T) {
+	cfg := loadFixtureConfig(t, "v1.json")
+	if err := migrateFingerprint(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Fingerprint == "" || cfg.Fingerprint != cfg.fingerprint() {
+		t.Errorf("fingerprint = %q, want %q", cfg.Fingerprint, cfg.fingerprint())
+	}
+
+	cfg.Fingerprint = "kept"
+	if err := migrateFingerprint(cfg); err != nil || cfg.Fingerprint != "kept" {
+		t.Errorf("existing fingerprint replaced with %q", cfg.Fingerprint)
+	}
+}
+
+func TestMigrateKeybinds(t *testing.T) {
+	cfg := &HyprConfig{ProgramConfigs: []HyprProgramConfig{{
+		ID: "hypr", Title: "hyprland", Program: "hyprland",
+		FileContent: FileContent{Data: []byte("bindd = SUPER, Q, Close window, killactive\n"), FileType: FileTypeConfig},
+	}}}
+	if err := migrateKeybinds(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Keybinds) != 1 || cfg.Keybinds[0].Description != "Close window" || cfg.Keybinds[0].ProgramID != "hypr" {
+		t.Errorf("keybinds = %+v", cfg.Keybinds)
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	for _, name := range []string{"v0.json", "v1.json"} {
+		cfg := loadFixtureConfig(t, name)
+		if err := migrateConfig(cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.SchemaVersion != CurrentSchemaVersion || cfg.Version == "" || cfg.Fingerprint == "" {
+			t.Errorf("%s: migrated to %+v", name, cfg)
+		}
+	}
+
+	// Migrations a config already has are not applied again
+	cfg := loadFixtureConfig(t, "v1.json")
+	cfg.UpdatedTimestamp = time.Time{}
+	if err := migrateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.UpdatedTimestamp.IsZero() {
+		t.Errorf("migration 1 was applied to a version 1 config")
+	}
+}
+
+func TestMigrateVersion(t *testing.T) {
+	for v, want := range map[string]string{"": DefaultConfigVersion, "latest": DefaultConfigVersion, "v1.2": DefaultConfigVersion, "1.2.0-rc.1": "1.2.0-rc.1"} {
+		cfg := &HyprConfig{Version: v}
+		if err := migrateVersion(cfg); err != nil || cfg.Version != want {
+			t.Errorf("migrateVersion(%q) = %q, %v, want %q", v, cfg.Version, err, want)
+		}
+	}
+}
+
+func TestSQLiteMigrateConfigs(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewConfigManagerSQLite(filepath.Join(t.TempDir(), "configs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = m.Close() })
+
+	current := newTestConfig(t, m, "alice", false)
+	for _, name := range []string{"v0.json", "v1.json"} {
+		raw, err := os.ReadFile(filepath.Join("testdata", "migrations", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := loadFixtureConfig(t, name)
+		_, err = m.db.ExecContext(ctx, `INSERT INTO configs (id, owner_id, private, likes, updated_timestamp, doc) VALUES (?, ?, 0, ?, 0, ?)`,
+			cfg.ID, cfg.OwnerID, cfg.Likes, string(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrated, err := m.MigrateConfigs(ctx, 1)
+	if err != nil || migrated != 2 {
+		t.Fatalf("MigrateConfigs = %d, %v, want 2 migrated", migrated, err)
+	}
+	for _, id := range []string{"legacy-0", "legacy-1", current.ID} {
+		cfg, err := m.getConfig(ctx, m.db, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.SchemaVersion != CurrentSchemaVersion || cfg.Fingerprint != cfg.fingerprint() {
+			t.Errorf("%s after migrating: schema version %d, fingerprint %q", id, cfg.SchemaVersion, cfg.Fingerprint)
+		}
+	}
+	if migrated, err := m.MigrateConfigs(ctx, 1); err != nil || migrated != 0 {
+		t.Errorf("second run = %d, %v, want nothing to migrate", migrated, err)
+	}
+}
@@ -2,7 +2,11 @@ package hyprconfig
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -14,6 +18,14 @@ const (
 	FileTypeScript string = "script" // Specifically for scripts
 )
 
+var (
+	ErrMissingHash  = errors.New("file content has data but no hash")
+	ErrHashMismatch = errors.New("file content hash mismatch")
+)
+
+// programRequestHint is appended to allowlist validation errors so users know how to get a program added.
+const programRequestHint = "request it to be allowed via POST /programs/request"
+
 var validPrograms = map[string]struct{}{
 	// --- Core Hyprland Components ---
 	"hyprland":  {},
@@ -54,6 +66,46 @@ var validPrograms = map[string]struct{}{
 	"walker":   {}, // Specific program
 }
 
+// builtinProgramCategories are the categories the built-in programs are seeded with.
+var builtinProgramCategories = map[string]string{
+	"hyprland":          "compositor",
+	"hypridle":          "idle",
+	"hyprpaper":         "wallpaper",
+	"hyprlock":          "lockscreen",
+	"kitty":             "terminal",
+	"alacritty":         "terminal",
+	"foot":              "terminal",
+	"wezterm":           "terminal",
+	"wofi":              "launcher",
+	"rofi":              "launcher",
+	"walker":            "launcher",
+	"waybar":            "bar",
+	"eww":               "bar",
+	"swaync":            "notifications",
+	"mako":              "notifications",
+	"dunst":             "notifications",
+	"sway":              "compositor",
+	"clipse":            "clipboard",
+	"grim":              "screenshot",
+	"slurp":             "screenshot",
+	"swappy":            "screenshot",
+	"pipewire":          "audio",
+	"pulseaudio":        "audio",
+	"wayland-protocols": "library",
+}
+
+// builtinProgramPackages are the per-distro package names of built-in programs whose package is
+// not named after the program.
+var builtinProgramPackages = map[string]map[string]string{
+	"swaync":            {PlatformDebian: "sway-notification-center", PlatformUbuntu: "sway-notification-center", PlatformNixOS: "swaynotificationcenter"},
+	"wayland-protocols": {PlatformFedora: "wayland-protocols-devel"},
+}
+
+// builtinProgramFlatpakIDs are the Flatpak app ids of built-in programs published on Flathub.
+var builtinProgramFlatpakIDs = map[string]string{
+	"wezterm": "org.wezfurlong.wezterm",
+}
+
 // --- NEW STRUCT FOR FILE STORAGE ---
 
 // FileContent represents the actual content of a file/config and its metadata.
@@ -67,8 +119,25 @@ type FileContent struct {
 	// Optional: Headers for text/config files (e.g., an include directive, file-specific metadata).
 	Headers map[string]string `json:"headers,omitempty" bson:"headers,omitempty"`
 
-	// For integrity checking (e.g., SHA-256 hash of the Data).
+	// For integrity checking (e.g., SHA-256 hash of the Data). Always computed over the uncompressed data.
 	Hash string `json:"hash,omitempty" bson:"hash,omitempty"`
+
+	// How Data is encoded at rest (EncodingNone or EncodingGzip).
+	Encoding string `json:"encoding,omitempty" bson:"encoding,omitempty"`
+
+	// Set when Data has been offloaded to the file store, in which case Data is empty.
+	FileID string `json:"file_id,omitempty" bson:"file_id,omitempty"`
+
+	// Uncompressed size of the content, whether inline or offloaded.
+	Size int64 `json:"size,omitempty" bson:"size,omitempty"`
+
+	// Permissions the file is installed with, one of AllowedFileModes such as "0755". Empty
+	// means 0755 for scripts and 0644 for anything else. Files entries keep theirs in
+	// FileEntry.Mode.
+	Mode string `json:"mode,omitempty" bson:"mode,omitempty"`
+
+	// Where offloaded content can be downloaded from. Filled in on read, never stored.
+	DownloadURL string `json:"download_url,omitempty" bson:"-"`
 }
 
 // --- UPDATED HYPRCONFIG STRUCT ---
@@ -79,12 +148,27 @@ type HyprConfig struct {
 	Title       string `json:"title" bson:"title"`
 	Description string `json:"description,omitempty" bson:"description,omitempty"`
 
+	// Markdown shown on the config page, see RenderReadme. Raw HTML is stripped on write.
+	Readme string `json:"readme,omitempty" bson:"readme,omitempty"`
+
+	// An SPDX identifier from Licenses, or LicenseCustom with its terms in LicenseText. Empty
+	// when the author didn't pick one.
+	License     string `json:"license,omitempty" bson:"license,omitempty"`
+	LicenseText string `json:"license_text,omitempty" bson:"license_text,omitempty"`
+
+	// Values that differ per machine, written as {{name}} in the files of the config. Configs
+	// without variables are rendered as-is.
+	Variables []TemplateVariable `json:"variables,omitempty" bson:"variables,omitempty"`
+
 	Author         Author              `json:"author" bson:"author"`
 	ProgramConfigs []HyprProgramConfig `json:"program_configs" bson:"program_configs"`
 
 	// NEW: Optional URLs/paths for gallery images to showcase the config.
 	GalleryPictures []string `json:"gallery_pictures,omitempty" bson:"gallery_pictures,omitempty"`
 
+	// Images uploaded with AddGalleryImage. Their URLs are also in GalleryPictures.
+	GalleryImages []GalleryImage `json:"gallery_images,omitempty" bson:"gallery_images,omitempty"`
+
 	OwnerID string `json:"owner_id" bson:"owner_id"` // who created it
 	Private bool   `json:"private" bson:"private"`   // private or public
 	Likes   int64  `json:"likes" bson:"likes"`
@@ -92,10 +176,68 @@ type HyprConfig struct {
 	Version string   `json:"version" bson:"version"`
 	Tags    []string `json:"tags,omitempty" bson:"tags,omitempty"`
 
+	// Set by an admin with VerifyConfig after reviewing the config, e.g. for sketchy exec lines.
+	// Any later edit of the config or its program configs drops the badge.
+	Verified   bool       `json:"verified" bson:"verified,omitempty"`
+	VerifiedBy string     `json:"verified_by,omitempty" bson:"verified_by,omitempty"` // admin user id
+	VerifiedAt *time.Time `json:"verified_at,omitempty" bson:"verified_at,omitempty"`
+
+	// Set by the owner with DeprecateConfig, pointing users at a successor. Deprecated mirrors
+	// whether it is set, so searches can rank deprecated configs last. Kept up to date on write.
+	Deprecation *ConfigDeprecation `json:"deprecation,omitempty" bson:"deprecation,omitempty"`
+	Deprecated  bool               `json:"deprecated" bson:"deprecated,omitempty"`
+
+	// Oldest Hyprland release the config works with, as major.minor.patch. Empty when unknown.
+	MinHyprlandVersion string `json:"min_hyprland_version,omitempty" bson:"min_hyprland_version,omitempty"`
+
+	// Hash of the program names and file hashes, used to find duplicate configs. Kept up to date on write.
+	Fingerprint string `json:"fingerprint,omitempty" bson:"fingerprint,omitempty"`
+
+	// MinHyprlandVersion as a number that sorts like it, for the HyprlandVersion search filter.
+	// Kept up to date on write.
+	HyprlandVersionKey int64 `json:"hyprland_version_key,omitempty" bson:"hyprland_version_key,omitempty"`
+
+	// Monitors and workspace rules parsed from the Hyprland files, for search and layout
+	// previews. Kept up to date on write.
+	Display *DisplayLayout `json:"display,omitempty" bson:"display,omitempty"`
+
+	// Dominant colors of the text and config files, most used first, and whether they make a
+	// dark or light theme. Kept up to date on write.
+	Palette []PaletteColor `json:"palette,omitempty" bson:"palette,omitempty"`
+	Theme   string         `json:"theme,omitempty" bson:"theme,omitempty"` // ThemeDark or ThemeLight
+
+	// Binds of the Hyprland files, for showing a config's keybindings without its files. Kept up
+	// to date on write.
+	Keybinds []Keybind `json:"keybinds,omitempty" bson:"keybinds,omitempty"`
+
+	// Version of the document layout, CurrentSchemaVersion for new configs. Older documents are
+	// brought up to date by MigrateConfigs.
+	SchemaVersion int `json:"schema_version" bson:"schema_version"`
+
+	// Oldest to newest, capped at MaxChangelogEntries.
+	Changelog []ChangelogEntry `json:"changelog,omitempty" bson:"changelog,omitempty"`
+
+	// Non-fatal validation findings, returned on write and never stored.
+	Warnings []string `json:"warnings,omitempty" bson:"-"`
+
+	// Unknown dependencies found by ValidateGraph, returned on write and never stored.
+	DependencyReport *GraphReport `json:"dependency_report,omitempty" bson:"-"`
+
+	// Uncompressed size of every file in the config, computed when listing and never stored.
+	TotalSizeBytes int64 `json:"total_size_bytes,omitempty" bson:"-"`
+
 	CreatedTimestamp time.Time `json:"created_timestamp" bson:"created_timestamp"`
 	UpdatedTimestamp time.Time `json:"updated_timestamp" bson:"updated_timestamp"`
 }
 
+// ChangelogEntry records why a config changed.
+type ChangelogEntry struct {
+	Version   string    `json:"version" bson:"version"`
+	Message   string    `json:"message" bson:"message"`
+	Actor     string    `json:"actor" bson:"actor"` // user id
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
 // --- UPDATED HYPRPROGRAMCONFIG STRUCT ---
 
 // Represents the configuration and installation data for a single program.
@@ -109,13 +251,19 @@ type HyprProgramConfig struct {
 	Args    []string          `json:"args,omitempty" bson:"args,omitempty"`
 	EnvVars map[string]string `json:"env_vars,omitempty" bson:"env_vars,omitempty"` // environment variables
 
+	// Commands run once the files are written, e.g. "hyprctl reload". See PostInstallCommands.
+	PostInstall []string `json:"post_install,omitempty" bson:"post_install,omitempty"`
+
 	// NEW: Structured way to store file content and metadata.
 	FileContent FileContent `json:"file_content,omitempty" bson:"file_content,omitempty"`
 
+	// Further files installed alongside FileContent, e.g. a stylesheet. FileEntries lists both.
+	Files []FileEntry `json:"files,omitempty" bson:"files,omitempty"`
+
 	Dependencies []string             `json:"dependencies,omitempty" bson:"dependencies,omitempty"` // e.g. apt/pacman packages
 	SubConfigs   []*HyprProgramConfig `json:"sub_configs,omitempty" bson:"sub_configs,omitempty"`
 
-	Platform []string `json:"platform,omitempty" bson:"platform,omitempty"` // ["arch", "debian", "fedora", "nixos"] etc.
+	Platform []string `json:"platform,omitempty" bson:"platform,omitempty"` // canonical names from Platforms(), empty means any
 	Optional bool     `json:"optional" bson:"optional"`                     // Should this program be installed or skipped?
 
 	UpdatedTimestamp time.Time `json:"updated_timestamp" bson:"updated_timestamp"`
@@ -126,6 +274,79 @@ type HyprProgramConfig struct {
 
 type AllowedPrograms struct {
 	ProgramName string `json:"program_name" bson:"program_name"`
+	Category    string `json:"category,omitempty" bson:"category,omitempty"` // e.g. "terminal", "bar", "launcher"
+
+	// Package names per distro when they differ from the program name, e.g. {"debian": "fonts-noto"}.
+	Packages map[string]string `json:"packages,omitempty" bson:"packages,omitempty"`
+
+	// FlatpakID is the app id of the program's Flatpak, e.g. "org.wezfurlong.wezterm", whose
+	// config lives in ~/.var/app/<id>/config.
+	FlatpakID string `json:"flatpak_id,omitempty" bson:"flatpak_id,omitempty"`
+}
+
+const (
+	ProgramRequestPending  = "pending"
+	ProgramRequestApproved = "approved"
+	ProgramRequestRejected = "rejected"
+)
+
+// ProgramRequest is a user's suggestion to add a program to the allowed list.
+type ProgramRequest struct {
+	ID          string `json:"id" bson:"_id"`
+	ProgramName string `json:"program_name" bson:"program_name"`
+	Reason      string `json:"reason,omitempty" bson:"reason,omitempty"`
+	RequestedBy string `json:"requested_by" bson:"requested_by"` // user id
+	Status      string `json:"status" bson:"status"`             // pending, approved, rejected
+	ReviewedBy  string `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewNote  string `json:"review_note,omitempty" bson:"review_note,omitempty"`
+
+	CreatedTimestamp time.Time `json:"created_timestamp" bson:"created_timestamp"`
+	UpdatedTimestamp time.Time `json:"updated_timestamp" bson:"updated_timestamp"`
+}
+
+type ProgramRequestBody struct {
+	ProgramName string `json:"program_name"`
+	Reason      string `json:"reason"`
+}
+
+type AllowedProgramFilters struct {
+	Prefix   string `json:"prefix"`   // case-insensitive program name prefix
+	Category string `json:"category"` // exact category match
+}
+
+const (
+	ImportStatusInserted = "inserted"
+	ImportStatusUpdated  = "updated"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusFailed   = "failed"
+)
+
+// ProgramImportResult reports what happened to a single entry of a bulk allowed-program import.
+type ProgramImportResult struct {
+	ProgramName string `json:"program_name"`
+	Status      string `json:"status"` // inserted, updated, skipped, failed
+	Error       string `json:"error,omitempty"`
+}
+
+// DefaultAllowedPrograms returns the built-in program list, sorted by name, with the categories,
+// package names and Flatpak app ids that are known.
+func DefaultAllowedPrograms() []AllowedPrograms {
+	names := make([]string, 0, len(validPrograms))
+	for name := range validPrograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	programs := make([]AllowedPrograms, 0, len(names))
+	for _, name := range names {
+		programs = append(programs, AllowedPrograms{
+			ProgramName: name,
+			Category:    builtinProgramCategories[name],
+			Packages:    builtinProgramPackages[name],
+			FlatpakID:   builtinProgramFlatpakIDs[name],
+		})
+	}
+	return programs
 }
 
 // Represents the creator/uploader of the config.
@@ -136,13 +357,40 @@ type Author struct {
 }
 
 type ConfigSearchFilters struct {
-	Query       string   `json:"query"`        // text search on title, description, tags
-	Tags        []string `json:"tags"`         // must contain all tags
-	Program     string   `json:"program"`      // match program inside ProgramConfigs
-	OwnerID     string   `json:"owner_id"`     // optional
-	Private     *bool    `json:"private"`      // nil = any, true/false filter
-	UpdatedFrom *int64   `json:"updated_from"` // unix timestamp
-	UpdatedTo   *int64   `json:"updated_to"`
+	Query           string   `json:"query"`            // text search on title, description, tags
+	Tags            []string `json:"tags"`             // must contain all tags
+	Program         string   `json:"program"`          // match program inside ProgramConfigs
+	OwnerID         string   `json:"owner_id"`         // optional
+	Private         *bool    `json:"private"`          // nil = any, true/false filter
+	Platform        string   `json:"platform"`         // every non-optional program must support it
+	Monitors        int      `json:"monitors"`         // laid out for this many monitors, or for any number; 0 = any
+	Resolution      string   `json:"resolution"`       // a monitor runs at this resolution, e.g. 2560x1440
+	Theme           string   `json:"theme"`            // ThemeDark or ThemeLight
+	Palette         []string `json:"palette"`          // hex colors, each close to a color of the config's palette
+	ColorTolerance  int      `json:"color_tolerance"`  // per channel, DefaultColorTolerance when 0
+	License         string   `json:"license"`          // SPDX identifier, LicenseCustom or LicenseUnspecified
+	HyprlandVersion string   `json:"hyprland_version"` // works on this Hyprland version: no MinHyprlandVersion above it
+	Verified        *bool    `json:"verified"`         // nil = any, true/false filter
+	Deprecated      *bool    `json:"deprecated"`       // nil = any, false excludes deprecated configs
+	UpdatedFrom     *int64   `json:"updated_from"`     // unix timestamp
+	UpdatedTo       *int64   `json:"updated_to"`
+	Sort            string   `json:"sort,omitempty"` // one of the SearchSort values, default SearchSortUpdated
+}
+
+// Sort orders accepted by ConfigSearchFilters.Sort. Deprecated configs come last in all of them.
+const (
+	SearchSortUpdated = "updated" // most recently updated first
+	SearchSortCreated = "created" // most recently created first
+	SearchSortLikes   = "likes"   // most liked first
+	SearchSortTitle   = "title"   // alphabetical by title
+
+	SearchSortVerified = "verified" // verified first, then most liked
+)
+
+// UpdateOptions carries optional behaviour for UpdateConfig.
+type UpdateOptions struct {
+	VersionBump string `json:"version_bump,omitempty"`      // patch (default), minor or major
+	Changelog   string `json:"changelog_message,omitempty"` // optional, recorded in the config's changelog
 }
 
 type UserHyprState struct {
@@ -159,67 +407,256 @@ type UserFavorite struct {
 
 // --- VALIDATION LOGIC STUB ---
 
+// ProgramsChecker returns the names, out of names, that are allowed programs in the database.
+// A ProgramRegistry's Allowed is the one the config managers validate with.
+type ProgramsChecker func(ctx context.Context, names []string) (map[string]struct{}, error)
+
+// programSet is the allowed programs resolved for one Validate call.
+type programSet map[string]struct{}
+
+// has reports whether name is built in or was found allowed.
+func (s programSet) has(name string) bool {
+	if builtinProgram(name) {
+		return true
+	}
+	_, ok := s[name]
+	return ok
+}
+
+// resolvePrograms looks up every program name the program configs visited by walk refer to,
+// their programs, dependencies and the programs launched from their file content, with a
+// single call to checkProgramsExist. Built in programs are never looked up.
+func resolvePrograms(
+	ctx context.Context,
+	checkProgramsExist ProgramsChecker,
+	walk func(fn func(pc *HyprProgramConfig)),
+	limits SizeLimits,
+) (programSet, error) {
+	seen := map[string]struct{}{}
+	var names []string
+	add := func(name string) {
+		name = NormalizeProgramName(name)
+		if builtinProgram(name) {
+			return
+		}
+		if _, dup := seen[name]; dup {
+			return
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	walk(func(pc *HyprProgramConfig) {
+		add(pc.Program)
+		for _, dep := range pc.Dependencies {
+			add(dep)
+		}
+		for _, command := range pc.PostInstall {
+			if binary := hookBinary(command); binary != "" {
+				if _, safe := postInstallBinaries[binary]; !safe {
+					add(binary)
+				}
+			}
+		}
+		// Oversized content fails validation anyway, it is not worth parsing
+		pc.eachFile(func(_ string, fc *FileContent) {
+			if len(fc.Data) > 0 && limits.CheckFile(*fc) == nil {
+				for _, cmd := range ExtractExecOnceCommands(string(fc.Data)) {
+					add(cmd)
+				}
+			}
+		})
+	})
+	if len(names) == 0 {
+		return programSet{}, nil
+	}
+
+	allowed, err := checkProgramsExist(ctx, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check allowed programs: %w", err)
+	}
+	return allowed, nil
+}
+
 // Validate checks a HyprConfig and all its HyprProgramConfigs for required data,
 // valid program names, and file content integrity.
-func (hc *HyprConfig) Validate(checkProgramExists func(ctx context.Context, programName string) error) error {
-	if hc.Title == "" {
-		return fmt.Errorf("config title cannot be empty")
+// File content is checked against limits, both per file and for the config as a whole, and
+// the title, description and tags are normalized and checked against their limits.
+// Every problem found is returned in a *ValidationError; other errors come from checkProgramsExist.
+func (hc *HyprConfig) Validate(ctx context.Context, checkProgramsExist ProgramsChecker, limits SizeLimits) error {
+	programs, err := resolvePrograms(ctx, checkProgramsExist, hc.Walk, limits)
+	if err != nil {
+		return err
 	}
+
+	verr := &ValidationError{}
+	hc.normalizeMetadata(verr)
+	hc.normalizeLicense(verr)
+	hc.normalizeHyprlandVersion(verr)
+	hc.validateVariables(verr)
 	if len(hc.ProgramConfigs) == 0 {
-		return fmt.Errorf("config must contain at least one program configuration")
+		verr.addf("program_configs", CodeRequired, "config must contain at least one program configuration")
 	}
 
-	for i, pc := range hc.ProgramConfigs {
-		if err := pc.Validate(checkProgramExists); err != nil {
-			return fmt.Errorf("program config #%d (%s) failed validation: %w", i+1, pc.Title, err)
+	for i := range hc.ProgramConfigs {
+		pc := &hc.ProgramConfigs[i]
+		if err := pc.validate(programs, limits); err != nil {
+			verr.nest(fmt.Sprintf("program_configs[%d]", i), err)
 		}
+		hc.checkPlaceholders(pc, fmt.Sprintf("program_configs[%d].", i), verr)
 	}
 
-	return nil
+	hc.Warnings = nil
+	if hc.Private {
+		hc.Warnings = append(hc.Warnings, verr.demote(CodeUnsafeCommand)...)
+	}
+	hc.Walk(func(pc *HyprProgramConfig) {
+		hc.Warnings = append(hc.Warnings, pc.envVarWarnings()...)
+	})
+	hc.Warnings = append(hc.Warnings, KeybindWarnings(hc)...)
+	hc.Warnings = append(hc.Warnings, LintWarnings(hc)...)
+	hc.Warnings = append(hc.Warnings, WallpaperWarnings(hc, limits.ExtraInstallPrefixes...)...)
+	hc.Warnings = append(hc.Warnings, hc.hyprlandVersionWarnings()...)
+	if err := hc.validateGraph(programs); err != nil {
+		verr.add("program_configs", err)
+	}
+	if err := limits.checkTotal(hc.contentSize()); err != nil {
+		verr.add("program_configs", err)
+	}
+
+	return verr.errOrNil()
+}
+
+// Validate checks a single HyprProgramConfig for required fields and integrity. Paths in the
+// returned *ValidationError are relative to the program config.
+func (pc *HyprProgramConfig) Validate(ctx context.Context, checkProgramsExist ProgramsChecker, limits SizeLimits) error {
+	programs, err := resolvePrograms(ctx, checkProgramsExist, pc.Walk, limits)
+	if err != nil {
+		return err
+	}
+	return pc.validate(programs, limits)
 }
 
-// Validate checks a single HyprProgramConfig for required fields and integrity.
-func (pc *HyprProgramConfig) Validate(checkProgramExists func(ctx context.Context, programName string) error) error {
-	// 1. Validate Program Name
-	if _, ok := validPrograms[pc.Program]; !ok {
-		if err := checkProgramExists(context.Background(), pc.Program); err != nil {
-			return fmt.Errorf("invalid or unsupported program name: %s", pc.Program)
+// validate is Validate with the allowed programs already resolved.
+func (pc *HyprProgramConfig) validate(programs programSet, limits SizeLimits) error {
+	verr := &ValidationError{}
+
+	// 1. Validate Program Name (stored back normalized so documents are consistent)
+	pc.Program = NormalizeProgramName(pc.Program)
+	if !programs.has(pc.Program) {
+		verr.addf("program", CodeInvalidProgram, "invalid or unsupported program name: %s (%s)", pc.Program, programRequestHint)
+	}
+
+	// 2. Validate environment variables, which end up in rendered configs
+	pc.validateEnvVars(verr)
+
+	// 3. Validate and normalize the supported platforms
+	pc.normalizePlatforms(verr)
+
+	// 4. Validate the install path, which apply and export write to verbatim
+	if pc.InstallPath != "" {
+		if _, err := homeRelativePath(pc, limits.ExtraInstallPrefixes...); err != nil {
+			verr.add("install_path", err)
 		}
 	}
 
-	// 2. Validate File Content Integrity (Hash Check)
-	content := pc.FileContent
-	if len(content.Data) > 0 && content.Hash != "" {
-		commands := ExtractExecOnceCommands(string(content.Data))
-		for _, cmd := range commands {
-			if _, ok := validPrograms[cmd]; !ok {
-				if err := checkProgramExists(context.Background(), cmd); err != nil {
-					return fmt.Errorf("invalid or unsupported program name: %s", cmd)
-				}
+	// 5. Validate the target paths and modes of the files
+	pc.validateFiles(verr, limits.ExtraInstallPrefixes)
+
+	// 6. Validate the size and type of every file before doing any work on its data
+	pc.eachFile(func(field string, content *FileContent) {
+		if err := limits.CheckFile(*content); err != nil {
+			verr.add(field+".data", fmt.Errorf("program %s: %w", pc.Program, err))
+			return
+		}
+
+		// 7. Validate File Content Integrity (Hash Check)
+		if content.Hash != "" {
+			if err := VerifyFileContent(*content); err != nil {
+				verr.add(field+".hash", fmt.Errorf("program %s: %w", pc.Program, err))
 			}
 		}
+	})
 
-		// In a real application, you would calculate the hash of content.Data
-		// here and compare it to content.Hash to ensure integrity.
-		// Example check (place actual hash function here):
-		// calculatedHash := CalculateHash(content.Data)
-		// if calculatedHash != content.Hash {
-		//     return fmt.Errorf("file content hash mismatch for program %s", pc.Program)
-		// }
-	}
+	// 8. Screen the programs exec-once lines start against the allowed programs
+	pc.screenExecPrograms(verr, programs, limits)
+
+	// 9. Validate the post-install commands
+	pc.validatePostInstall(verr, programs)
 
-	// 3. Recursively validate SubConfigs
+	// 10. Screen Args, post-install commands and exec lines for shell injection
+	pc.screenCommands(verr, limits)
+
+	// 11. Recursively validate SubConfigs
 	for i, subConfig := range pc.SubConfigs {
-		if err := subConfig.Validate(checkProgramExists); err != nil {
-			return fmt.Errorf("sub-config #%d failed validation: %w", i+1, err)
+		if err := subConfig.validate(programs, limits); err != nil {
+			verr.nest(fmt.Sprintf("sub_configs[%d]", i), err)
 		}
 	}
 
+	return verr.errOrNil()
+}
+
+// Walk calls fn for every program config in the config, parents before their sub-configs.
+func (hc *HyprConfig) Walk(fn func(pc *HyprProgramConfig)) {
+	for i := range hc.ProgramConfigs {
+		hc.ProgramConfigs[i].Walk(fn)
+	}
+}
+
+// Walk calls fn for pc and then every sub-config below it.
+func (pc *HyprProgramConfig) Walk(fn func(pc *HyprProgramConfig)) {
+	fn(pc)
+	for _, sub := range pc.SubConfigs {
+		if sub != nil {
+			sub.Walk(fn)
+		}
+	}
+}
+
+// ComputeHash returns the hex encoded SHA-256 hash of data.
+func ComputeHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyFileContent checks that fc.Hash matches its Data.
+// Empty content without a hash is considered valid; content with data but no hash is not.
+func VerifyFileContent(fc FileContent) error {
+	// Offloaded content was verified before it was written to the file store
+	if fc.FileID != "" && len(fc.Data) == 0 {
+		return nil
+	}
+	if fc.Hash == "" {
+		if len(fc.Data) == 0 {
+			return nil
+		}
+		return ErrMissingHash
+	}
+
+	// The hash always covers the uncompressed data
+	if err := fc.Decompress(); err != nil {
+		return err
+	}
+	if calculated := ComputeHash(fc.Data); calculated != fc.Hash {
+		return fmt.Errorf("%w: expected %s, calculated %s", ErrHashMismatch, fc.Hash, calculated)
+	}
 	return nil
 }
 
-// // TODO: Implement this using a secure hash algorithm like SHA-256
-// func CalculateHash(data []byte) string {
-// 	// Placeholder: Replace with real hash calculation (e.g., using crypto/sha256)
-// 	return fmt.Sprintf("stub-hash-of-length-%d", len(data))
-// }
+// populateHashes fills in the Hash of every file content in the tree that has data but no hash,
+// and sets its Size. Hashes supplied by the client are left alone so Validate can detect tampering.
+func (pc *HyprProgramConfig) populateHashes() {
+	pc.eachFile(func(_ string, fc *FileContent) {
+		if len(fc.Data) > 0 && fc.Hash == "" {
+			fc.Hash = ComputeHash(fc.Data)
+		}
+		if len(fc.Data) > 0 {
+			fc.Size = int64(len(fc.Data))
+		}
+	})
+	for _, sub := range pc.SubConfigs {
+		if sub != nil {
+			sub.populateHashes()
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// allowOnly returns a ProgramsChecker stub that accepts only the given names.
+func allowOnly(names ...string) ProgramsChecker {
+	return func(ctx context.Context, lookup []string) (map[string]struct{}, error) {
+		found := map[string]struct{}{}
+		for _, name := range lookup {
+			if slices.Contains(names, name) {
+				found[name] = struct{}{}
+			}
+		}
+		return found, nil
+	}
+}
+
+func TestNormalizeProgramName(t *testing.T) {
+	tests := map[string]string{
+		"kitty":            "kitty",
+		"Kitty":            "kitty",
+		"kitty ":           "kitty",
+		"  WayBar\t":       "waybar",
+		"/usr/bin/waybar":  "waybar",
+		"/usr/bin/Waybar ": "waybar",
+		"./scripts/foo":    "foo",
+		"":                 "",
+	}
+	for in, want := range tests {
+		if got := NormalizeProgramName(in); got != want {
+			t.Errorf("NormalizeProgramName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestProgramConfigValidateNormalizesProgram(t *testing.T) {
+	for _, name := range []string{"Kitty", "kitty ", " KITTY", "/usr/bin/kitty"} {
+		pc := HyprProgramConfig{Title: "term", Program: name}
+		if err := pc.Validate(context.Background(), allowOnly(), SizeLimits{}); err != nil {
+			t.Fatalf("Validate(%q) returned error: %v", name, err)
+		}
+		if pc.Program != "kitty" {
+			t.Errorf("Validate(%q) stored %q, want %q", name, pc.Program, "kitty")
+		}
+	}
+}
+
+func TestProgramConfigValidateNormalizesDatabaseLookup(t *testing.T) {
+	pc := HyprProgramConfig{Title: "custom", Program: "  MyBar "}
+	if err := pc.Validate(context.Background(), allowOnly("mybar"), SizeLimits{}); err != nil {
+		t.Fatalf("expected mixed-case db program to validate, got %v", err)
+	}
+	if pc.Program != "mybar" {
+		t.Errorf("stored program = %q, want %q", pc.Program, "mybar")
+	}
+}
+
+func TestProgramConfigValidateExecOnceAbsolutePaths(t *testing.T) {
+	data := []byte("exec-once = /usr/bin/waybar\nexec-once = /usr/local/bin/MyDaemon --flag\n")
+	pc := HyprProgramConfig{
+		Title:   "hyprland",
+		Program: "Hyprland",
+		FileContent: FileContent{
+			Data:     data,
+			FileType: FileTypeConfig,
+		},
+	}
+	if err := pc.Validate(context.Background(), allowOnly("mydaemon"), SizeLimits{}); err != nil {
+		t.Fatalf("expected absolute exec-once paths to resolve, got %v", err)
+	}
+
+	pc.Program = "hyprland"
+	if err := pc.Validate(context.Background(), allowOnly(), SizeLimits{}); err == nil {
+		t.Fatal("expected unknown exec-once program to fail validation")
+	}
+}
+
+func TestConfigValidateStoresNormalizedPrograms(t *testing.T) {
+	cfg := HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "bar", Program: "WAYBAR"},
+			{Title: "term", Program: "/usr/bin/Kitty", SubConfigs: []*HyprProgramConfig{
+				{Title: "launcher", Program: " Wofi "},
+			}},
+		},
+	}
+	if err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{}); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	if cfg.ProgramConfigs[0].Program != "waybar" || cfg.ProgramConfigs[1].Program != "kitty" {
+		t.Errorf("programs not normalized: %q, %q", cfg.ProgramConfigs[0].Program, cfg.ProgramConfigs[1].Program)
+	}
+	if got := cfg.ProgramConfigs[1].SubConfigs[0].Program; got != "wofi" {
+		t.Errorf("sub-config program = %q, want %q", got, "wofi")
+	}
+}
+
+func TestComputeHash(t *testing.T) {
+	// sha256 of the empty string
+	if got := ComputeHash(nil); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("ComputeHash(nil) = %s", got)
+	}
+	if ComputeHash([]byte("a")) == ComputeHash([]byte("b")) {
+		t.Error("different data produced the same hash")
+	}
+}
+
+func TestVerifyFileContent(t *testing.T) {
+	data := []byte("general {\n  gaps_in = 5\n}\n")
+
+	if err := VerifyFileContent(FileContent{Data: data, Hash: ComputeHash(data)}); err != nil {
+		t.Errorf("valid content returned error: %v", err)
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[0] = 'G'
+	if err := VerifyFileContent(FileContent{Data: tampered, Hash: ComputeHash(data)}); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("tampered content: got %v, want ErrHashMismatch", err)
+	}
+
+	if err := VerifyFileContent(FileContent{Data: data}); !errors.Is(err, ErrMissingHash) {
+		t.Errorf("missing hash: got %v, want ErrMissingHash", err)
+	}
+
+	if err := VerifyFileContent(FileContent{}); err != nil {
+		t.Errorf("empty content returned error: %v", err)
+	}
+	if err := VerifyFileContent(FileContent{Hash: ComputeHash(data)}); !errors.Is(err, ErrHashMismatch) {
+		t.Errorf("empty data with hash: got %v, want ErrHashMismatch", err)
+	}
+}
+
+func TestProgramConfigValidateHash(t *testing.T) {
+	data := []byte("font_size 12\n")
+
+	pc := HyprProgramConfig{Title: "term", Program: "kitty", FileContent: FileContent{Data: data, Hash: "deadbeef"}}
+	err := pc.Validate(context.Background(), allowOnly(), SizeLimits{})
+	if !errors.Is(err, ErrHashMismatch) {
+		t.Fatalf("client supplied wrong hash: got %v, want ErrHashMismatch", err)
+	}
+
+	pc.FileContent.Hash = ""
+	pc.populateHashes()
+	if pc.FileContent.Hash != ComputeHash(data) {
+		t.Fatalf("populateHashes set %q", pc.FileContent.Hash)
+	}
+	if err := pc.Validate(context.Background(), allowOnly(), SizeLimits{}); err != nil {
+		t.Fatalf("populated hash failed validation: %v", err)
+	}
+
+	empty := HyprProgramConfig{Title: "empty", Program: "kitty"}
+	empty.populateHashes()
+	if empty.FileContent.Hash != "" {
+		t.Errorf("empty data should not get a hash, got %q", empty.FileContent.Hash)
+	}
+}
+
+func TestProgramConfigValidateSizeLimits(t *testing.T) {
+	limits := SizeLimits{MaxTextBytes: 10, MaxImageBytes: 20, MaxBinaryBytes: 30}
+
+	pc := HyprProgramConfig{Title: "term", Program: "kitty", FileContent: FileContent{Data: make([]byte, 15), FileType: FileTypeConfig}}
+	err := pc.Validate(context.Background(), allowOnly(), limits)
+	if !errors.Is(err, ErrContentTooLarge) {
+		t.Fatalf("oversized config: got %v, want ErrContentTooLarge", err)
+	}
+	if !strings.Contains(err.Error(), "kitty") || !strings.Contains(err.Error(), "5 over") {
+		t.Errorf("error should name the program and the overage, got %q", err)
+	}
+
+	pc.FileContent.FileType = FileTypeImage
+	pc.FileContent.Hash = ComputeHash(pc.FileContent.Data)
+	if err := pc.Validate(context.Background(), allowOnly(), limits); err != nil {
+		t.Errorf("image within its limit returned error: %v", err)
+	}
+
+	pc.FileContent.FileType = FileTypeBinary
+	if err := pc.Validate(context.Background(), allowOnly(), limits); !errors.Is(err, ErrBinaryNotAllowed) {
+		t.Errorf("binary without flag: got %v, want ErrBinaryNotAllowed", err)
+	}
+	limits.AllowBinary = true
+	if err := pc.Validate(context.Background(), allowOnly(), limits); err != nil {
+		t.Errorf("binary with flag returned error: %v", err)
+	}
+}
+
+func TestConfigValidateTotalSizeLimit(t *testing.T) {
+	data := make([]byte, 8)
+	fc := FileContent{Data: data, FileType: FileTypeText, Hash: ComputeHash(data)}
+	cfg := HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "term", Program: "kitty", FileContent: fc},
+			{Title: "bar", Program: "waybar", FileContent: fc, SubConfigs: []*HyprProgramConfig{
+				{Title: "style", Program: "waybar", FileContent: fc},
+			}},
+		},
+	}
+
+	if err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{MaxConfigBytes: 24}); err != nil {
+		t.Fatalf("config at the limit returned error: %v", err)
+	}
+	err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{MaxConfigBytes: 20})
+	if !errors.Is(err, ErrContentTooLarge) || !strings.Contains(err.Error(), "4 over") {
+		t.Errorf("oversized config: got %v", err)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "program_configs" || verr.Errors[0].Code != CodeTooLarge {
+		t.Errorf("oversized config: got %#v, want one too_large problem on program_configs", err)
+	}
+}
+
+func TestDetectFileType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"hyprland.conf", []byte("monitor=,preferred,auto,1\n"), FileTypeConfig},
+		{"style.css", []byte("* {}"), FileTypeConfig},
+		{"config", []byte("{\"layer\": \"top\"}"), FileTypeConfig},
+		{"launch", []byte("#!/bin/sh\nwaybar &\n"), FileTypeScript},
+		{"toggle.sh", []byte("waybar &\n"), FileTypeScript},
+		{"wall.png", png, FileTypeImage},
+		{"README", []byte("hello\n"), FileTypeText},
+		{"blob", []byte{0x00, 0x01, 0xff, 0xfe}, FileTypeBinary},
+	}
+	for _, tt := range tests {
+		if got := DetectFileType(tt.name, tt.data); got != tt.want {
+			t.Errorf("DetectFileType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidateBatchesProgramLookups(t *testing.T) {
+	cfg := HyprConfig{
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "bar", Program: "MyBar", Dependencies: []string{"mydaemon"}},
+			{Title: "hyprland", Program: "hyprland", FileContent: FileContent{
+				Data: []byte("exec-once = mydaemon\nexec-once = /usr/bin/mybar\n"), FileType: FileTypeConfig,
+			}, SubConfigs: []*HyprProgramConfig{
+				{Title: "launcher", Program: "mylauncher"},
+			}},
+		},
+	}
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	var calls [][]string
+	check := func(got context.Context, names []string) (map[string]struct{}, error) {
+		if got.Value(ctxKey{}) != "request" {
+			t.Error("lookup did not get the validation context")
+		}
+		calls = append(calls, names)
+		return allowOnly("mybar", "mydaemon", "mylauncher")(got, names)
+	}
+
+	if err := cfg.Validate(ctx, check, SizeLimits{}); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	if want := [][]string{{"mybar", "mydaemon", "mylauncher"}}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("lookups = %v, want %v", calls, want)
+	}
+}
+
+func TestConfigValidateLookupFailure(t *testing.T) {
+	cfg := HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{{Title: "bar", Program: "mybar"}}}
+	down := errors.New("database down")
+	err := cfg.Validate(context.Background(), func(context.Context, []string) (map[string]struct{}, error) {
+		return nil, down
+	}, SizeLimits{})
+	if !errors.Is(err, down) || errors.Is(err, ErrValidation) {
+		t.Errorf("got %v, want the lookup error without ErrValidation", err)
+	}
+}
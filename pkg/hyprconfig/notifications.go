@@ -0,0 +1,414 @@
+package hyprconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	NotificationConfigFavorited = "config.favorited" // someone favorited a config of yours
+	NotificationConfigUpdated   = "config.updated"   // a config you applied or favorited changed
+	NotificationConfigDeleted   = "config.deleted"   // a config you applied or favorited was deleted
+	NotificationNewFollower     = "user.followed"    // someone followed you
+
+	NotificationConfigDeprecated = "config.deprecated" // a config you applied was deprecated
+)
+
+// MaxNotificationFanOut caps how many users one event notifies, so a config with thousands of
+// favoriters does not hold up the update that triggered it. Users past the cap miss the event.
+const MaxNotificationFanOut = 500
+
+// Notification is an entry in a user's inbox.
+type Notification struct {
+	ID     string `json:"id" bson:"_id"`
+	UserID string `json:"user_id" bson:"user_id"` // recipient
+	Type   string `json:"type" bson:"type"`
+
+	ActorID  string `json:"actor_id,omitempty" bson:"actor_id,omitempty"` // who caused it
+	ConfigID string `json:"config_id,omitempty" bson:"config_id,omitempty"`
+
+	// For NotificationConfigDeprecated, the config to move to and the owner's note.
+	SuccessorID string `json:"successor_id,omitempty" bson:"successor_id,omitempty"`
+	Message     string `json:"message,omitempty" bson:"message,omitempty"`
+
+	ReadAt *time.Time `json:"read_at,omitempty" bson:"read_at,omitempty"` // nil while unread
+
+	CreatedTimestamp time.Time `json:"created_timestamp" bson:"created_timestamp"`
+}
+
+// notificationTypeFor returns the notification sent to the audience of a config for an audited
+// action, or "" if none is sent.
+func notificationTypeFor(action string) string {
+	switch webhookEventFor(action) {
+	case WebhookEventConfigUpdated:
+		return NotificationConfigUpdated
+	case WebhookEventConfigDeleted:
+		return NotificationConfigDeleted
+	}
+	return ""
+}
+
+// newNotifications builds one notification of typ for each recipient, skipping the actor and
+// duplicates, and at most MaxNotificationFanOut of them.
+func newNotifications(recipients []string, typ, actorID, configID string) []Notification {
+	now := time.Now()
+	seen := map[string]bool{actorID: true, "": true}
+
+	var notifications []Notification
+	for _, userID := range recipients {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if len(notifications) == MaxNotificationFanOut {
+			slog.Warn("notification fan-out capped", "type", typ, "config_id", configID, "max", MaxNotificationFanOut)
+			break
+		}
+		notifications = append(notifications, Notification{
+			ID:               uuid.NewString(),
+			UserID:           userID,
+			Type:             typ,
+			ActorID:          actorID,
+			ConfigID:         configID,
+			CreatedTimestamp: now,
+		})
+	}
+	return notifications
+}
+
+// sortNotifications orders notifications newest first, ties by id.
+func sortNotifications(notifications []Notification) {
+	sort.Slice(notifications, func(i, j int) bool {
+		if !notifications[i].CreatedTimestamp.Equal(notifications[j].CreatedTimestamp) {
+			return notifications[i].CreatedTimestamp.After(notifications[j].CreatedTimestamp)
+		}
+		return notifications[i].ID < notifications[j].ID
+	})
+}
+
+// notify adds a notification of typ to the inbox of every recipient. Notifications may not fail
+// the mutation that sent them, so errors are only logged.
+func (m *ConfigManagerMongo) notify(ctx context.Context, typ, actorID, configID string, recipients []string) {
+	m.insertNotifications(ctx, newNotifications(recipients, typ, actorID, configID))
+}
+
+// insertNotifications adds notifications to their recipients' inboxes, logging errors.
+func (m *ConfigManagerMongo) insertNotifications(ctx context.Context, notifications []Notification) {
+	if len(notifications) == 0 || m.NotificationsCollection == nil {
+		return
+	}
+	docs := make([]any, len(notifications))
+	for i := range notifications {
+		docs[i] = notifications[i]
+	}
+	if _, err := m.NotificationsCollection.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false)); err != nil {
+		slog.Warn("failed to write notifications", "type", notifications[0].Type, "config_id", notifications[0].ConfigID, "err", err)
+	}
+}
+
+// configAudience returns up to MaxNotificationFanOut users who favorited configID and as many
+// who applied it.
+func (m *ConfigManagerMongo) configAudience(ctx context.Context, configID string) ([]string, error) {
+	var users []string
+	for _, coll := range []*mongo.Collection{m.FavoritesCollection, m.StateCollection} {
+		cursor, err := coll.Find(ctx, bson.M{"config_id": configID},
+			options.Find().SetProjection(bson.M{"_id": 0, "user_id": 1}).SetLimit(MaxNotificationFanOut))
+		if err != nil {
+			return nil, err
+		}
+		var docs []struct {
+			UserID string `bson:"user_id"`
+		}
+		if err := cursor.All(ctx, &docs); err != nil {
+			return nil, err
+		}
+		for _, d := range docs {
+			users = append(users, d.UserID)
+		}
+	}
+	return users, nil
+}
+
+// notifyConfigAudience sends typ to the users who applied or favorited the config of entry.
+func (m *ConfigManagerMongo) notifyConfigAudience(ctx context.Context, typ string, entry AuditEntry) {
+	users, err := m.configAudience(ctx, entry.ConfigID)
+	if err != nil {
+		slog.Warn("failed to find users to notify", "type", typ, "config_id", entry.ConfigID, "err", err)
+		return
+	}
+	m.notify(ctx, typ, entry.ActorID, entry.ConfigID, users)
+}
+
+// unreadFilter matches the signed-in user's unread notifications, limited to ids unless empty.
+func unreadFilter(userID string, ids []string) bson.M {
+	filter := bson.M{"user_id": userID, "read_at": nil}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	return filter
+}
+
+// ListNotifications returns the signed-in user's notifications, newest first.
+func (m *ConfigManagerMongo) ListNotifications(ctx context.Context, page, limit int, unreadOnly bool) (mserve.Page[Notification], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[Notification]{}, err
+	}
+
+	filter := bson.M{"user_id": user.UserID}
+	if unreadOnly {
+		filter = unreadFilter(user.UserID, nil)
+	}
+	return mserve.PaginateMongo[Notification](
+		ctx,
+		m.NotificationsCollection,
+		filter,
+		page,
+		limit,
+		options.Find().SetSort(bson.D{{Key: "created_timestamp", Value: -1}, {Key: "_id", Value: 1}}),
+	)
+}
+
+// MarkNotificationsRead marks the given notifications of the signed-in user read, or all of them
+// when ids is empty, and returns how many were unread. Unknown ids are ignored.
+func (m *ConfigManagerMongo) MarkNotificationsRead(ctx context.Context, ids []string) (_ int64, err error) {
+	defer func() { m.logMutation(ctx, "MarkNotificationsRead", err, slog.Int("ids", len(ids))) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := m.NotificationsCollection.UpdateMany(ctx, unreadFilter(user.UserID, ids),
+		bson.M{"$set": bson.M{"read_at": time.Now()}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return res.ModifiedCount, nil
+}
+
+// CountUnreadNotifications returns how many unread notifications the signed-in user has.
+func (m *ConfigManagerMongo) CountUnreadNotifications(ctx context.Context) (int64, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return m.NotificationsCollection.CountDocuments(ctx, unreadFilter(user.UserID, nil))
+}
+
+// notify adds a notification of typ to the inbox of every recipient.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) notify(typ, actorID, configID string, recipients []string) {
+	for _, n := range newNotifications(recipients, typ, actorID, configID) {
+		m.notifications[n.ID] = n
+	}
+}
+
+// configAudience returns the users who applied or favorited configID.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) configAudience(configID string) []string {
+	var users []string
+	for userID, favorites := range m.favorites {
+		if _, ok := favorites[configID]; ok {
+			users = append(users, userID)
+		}
+	}
+	for userID, s := range m.state {
+		if s.ConfigID == configID {
+			users = append(users, userID)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+func (m *ConfigManagerMemory) ListNotifications(ctx context.Context, page, limit int, unreadOnly bool) (mserve.Page[Notification], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[Notification]{}, err
+	}
+
+	m.mu.RLock()
+	notifications := []Notification{}
+	for _, n := range m.notifications {
+		if n.UserID == user.UserID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, n)
+		}
+	}
+	m.mu.RUnlock()
+
+	sortNotifications(notifications)
+	return mserve.Paginate(notifications, page, limit)
+}
+
+func (m *ConfigManagerMemory) MarkNotificationsRead(ctx context.Context, ids []string) (int64, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var marked int64
+	for id, n := range m.notifications {
+		if n.UserID != user.UserID || n.ReadAt != nil || (len(ids) > 0 && !containsExact(ids, id)) {
+			continue
+		}
+		n.ReadAt = &now
+		m.notifications[id] = n
+		marked++
+	}
+	return marked, nil
+}
+
+func (m *ConfigManagerMemory) CountUnreadNotifications(ctx context.Context) (int64, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var unread int64
+	for _, n := range m.notifications {
+		if n.UserID == user.UserID && n.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+// notify adds a notification of typ to the inbox of every recipient.
+func notify(ctx context.Context, q sqlQuerier, typ, actorID, configID string, recipients []string) error {
+	return insertNotifications(ctx, q, newNotifications(recipients, typ, actorID, configID))
+}
+
+// insertNotifications adds notifications to their recipients' inboxes.
+func insertNotifications(ctx context.Context, q sqlQuerier, notifications []Notification) error {
+	for _, n := range notifications {
+		doc, err := json.Marshal(n)
+		if err != nil {
+			return fmt.Errorf("failed to encode notification: %w", err)
+		}
+		_, err = q.ExecContext(ctx, `INSERT INTO notifications (id, user_id, read_at, created_timestamp, doc) VALUES (?, ?, 0, ?, ?)`,
+			n.ID, n.UserID, n.CreatedTimestamp.UnixNano(), string(doc))
+		if err != nil {
+			return fmt.Errorf("failed to write notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// configAudience returns up to MaxNotificationFanOut users who applied or favorited configID.
+func configAudience(ctx context.Context, q sqlQuerier, configID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT user_id FROM favorites WHERE config_id = ?
+		UNION SELECT user_id FROM user_state WHERE config_id = ?
+		LIMIT ?`, configID, configID, MaxNotificationFanOut)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+// sqliteUnread matches the unread notifications of a user, limited to ids unless empty.
+func sqliteUnread(userID string, ids []string) (string, []any) {
+	where := `user_id = ? AND read_at = 0`
+	args := []any{userID}
+	if len(ids) > 0 {
+		where += ` AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)`
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	return where, args
+}
+
+func (m *ConfigManagerSQLite) ListNotifications(ctx context.Context, page, limit int, unreadOnly bool) (mserve.Page[Notification], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[Notification]{}, err
+	}
+
+	where, args := `user_id = ?`, []any{user.UserID}
+	if unreadOnly {
+		where, args = sqliteUnread(user.UserID, nil)
+	}
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT doc, read_at FROM notifications WHERE `+where+` ORDER BY created_timestamp DESC, id ASC`, args...)
+	if err != nil {
+		return mserve.Page[Notification]{}, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var doc string
+		var readAt int64
+		if err := rows.Scan(&doc, &readAt); err != nil {
+			return mserve.Page[Notification]{}, err
+		}
+		var n Notification
+		if err := json.Unmarshal([]byte(doc), &n); err != nil {
+			return mserve.Page[Notification]{}, fmt.Errorf("failed to decode notification: %w", err)
+		}
+		// The read state lives in its own column only
+		if readAt != 0 {
+			at := time.Unix(0, readAt)
+			n.ReadAt = &at
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return mserve.Page[Notification]{}, err
+	}
+	return mserve.Paginate(notifications, page, limit)
+}
+
+func (m *ConfigManagerSQLite) MarkNotificationsRead(ctx context.Context, ids []string) (int64, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := sqliteUnread(user.UserID, ids)
+	res, err := m.db.ExecContext(ctx, `UPDATE notifications SET read_at = ? WHERE `+where,
+		append([]any{time.Now().UnixNano()}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (m *ConfigManagerSQLite) CountUnreadNotifications(ctx context.Context) (int64, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := sqliteUnread(user.UserID, nil)
+	var unread int64
+	err = m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE `+where, args...).Scan(&unread)
+	return unread, err
+}
@@ -0,0 +1,106 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNotifications(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice, bob, carol := asUser("alice"), asUser("bob"), asUser("carol")
+		cfg := newTestConfig(t, m, "alice", false)
+
+		// Favoriting your own config notifies nobody, favoriting twice notifies once
+		for _, ctx := range []context.Context{alice, bob, bob} {
+			if err := m.FavoriteConfig(ctx, cfg.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := m.ApplyConfig(carol, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.FollowAuthor(carol, "alice"); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.UpdateConfig(alice, cfg.ID, map[string]any{"title": "riced"}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		inbox, err := m.ListNotifications(alice, 1, 10, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		types := map[string]int{}
+		for _, n := range inbox.Items {
+			types[n.Type]++
+		}
+		if inbox.Total != 2 || types[NotificationConfigFavorited] != 1 || types[NotificationNewFollower] != 1 {
+			t.Errorf("alice's inbox = %+v, want one favorite and one follower", inbox.Items)
+		}
+
+		// The owner's update reaches the favoriter and the user who applied it
+		for _, ctx := range []context.Context{bob, carol} {
+			inbox, err := m.ListNotifications(ctx, 1, 10, true)
+			if err != nil || inbox.Total != 1 {
+				t.Fatalf("inbox = %+v, %v", inbox, err)
+			}
+			if n := inbox.Items[0]; n.Type != NotificationConfigUpdated || n.ConfigID != cfg.ID || n.ActorID != "alice" || n.ReadAt != nil {
+				t.Errorf("notification = %+v", n)
+			}
+		}
+
+		if unread, err := m.CountUnreadNotifications(alice); err != nil || unread != 2 {
+			t.Errorf("alice's unread = %d, %v, want 2", unread, err)
+		}
+		marked, err := m.MarkNotificationsRead(alice, []string{inbox.Items[0].ID, "unknown"})
+		if err != nil || marked != 1 {
+			t.Errorf("marked = %d, %v, want 1", marked, err)
+		}
+		// Other users' notifications cannot be marked
+		bobs, _ := m.ListNotifications(bob, 1, 10, false)
+		if marked, err := m.MarkNotificationsRead(alice, []string{bobs.Items[0].ID}); err != nil || marked != 0 {
+			t.Errorf("marking bob's notification = %d, %v, want 0", marked, err)
+		}
+		if unread, err := m.ListNotifications(alice, 1, 10, true); err != nil || unread.Total != 1 || unread.Items[0].ID == inbox.Items[0].ID {
+			t.Errorf("alice's unread inbox = %+v, %v", unread, err)
+		}
+		if marked, err := m.MarkNotificationsRead(alice, nil); err != nil || marked != 1 {
+			t.Errorf("marking all = %d, %v, want 1", marked, err)
+		}
+		if unread, err := m.CountUnreadNotifications(alice); err != nil || unread != 0 {
+			t.Errorf("alice's unread after marking all = %d, %v, want 0", unread, err)
+		}
+		inbox, err = m.ListNotifications(alice, 1, 10, false)
+		if err != nil || inbox.Total != 2 || inbox.Items[0].ReadAt == nil {
+			t.Errorf("alice's inbox after marking all = %+v, %v", inbox, err)
+		}
+
+		if err := m.DeleteConfig(alice, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if inbox, err := m.ListNotifications(bob, 1, 10, true); err != nil || inbox.Total != 2 || inbox.Items[0].Type != NotificationConfigDeleted {
+			t.Errorf("bob's inbox after the delete = %+v, %v", inbox, err)
+		}
+
+		if _, err := m.ListNotifications(context.Background(), 1, 10, false); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("signed out: got %v, want ErrUnauthorized", err)
+		}
+	})
+}
+
+func TestNewNotificationsFanOut(t *testing.T) {
+	recipients := []string{"alice", "bob", "bob", ""}
+	for i := range MaxNotificationFanOut + 10 {
+		recipients = append(recipients, fmt.Sprintf("user-%d", i))
+	}
+
+	notifications := newNotifications(recipients, NotificationConfigUpdated, "alice", "c1")
+	if len(notifications) != MaxNotificationFanOut {
+		t.Fatalf("got %d notifications, want %d", len(notifications), MaxNotificationFanOut)
+	}
+	if notifications[0].UserID != "bob" || notifications[1].UserID != "user-0" {
+		t.Errorf("recipients start with %s, %s, want bob then user-0", notifications[0].UserID, notifications[1].UserID)
+	}
+}
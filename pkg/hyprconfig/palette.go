@@ -0,0 +1,270 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Themes a config is classified as by the lightness of its palette.
+const (
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+)
+
+const (
+	// MaxPaletteColors is how many dominant colors are kept for a config.
+	MaxPaletteColors = 8
+
+	// DefaultColorTolerance is how far, per red, green and blue channel, a palette color may be
+	// from a searched color to match it.
+	DefaultColorTolerance = 40
+
+	// clusterDistance is how close two colors have to be to count as one palette color.
+	clusterDistance = 48
+)
+
+// Colors as they are written in the files of a config: CSS and kitty hex colors (#rgb,
+// #rrggbb, #rrggbbaa), Hyprland rgb(rrggbb) and rgba(rrggbbaa), legacy Hyprland 0xaarrggbb and
+// CSS rgb(r, g, b) and rgba(r, g, b, a).
+var (
+	hashColorRe   = regexp.MustCompile(`#([0-9a-fA-F]{8}|[0-9a-fA-F]{6}|[0-9a-fA-F]{3})\b`)
+	hexFuncRe     = regexp.MustCompile(`rgba?\(\s*([0-9a-fA-F]{8}|[0-9a-fA-F]{6})\s*\)`)
+	argbColorRe   = regexp.MustCompile(`\b0x([0-9a-fA-F]{8})\b`)
+	decimalFuncRe = regexp.MustCompile(`rgba?\(\s*(\d{1,3})\s*,\s*(\d{1,3})\s*,\s*(\d{1,3})\s*(?:,\s*([0-9.]+)\s*)?\)`)
+)
+
+// PaletteColor is one of the dominant colors of a config.
+type PaletteColor struct {
+	Hex   string `json:"hex" bson:"hex"` // #rrggbb
+	R     int    `json:"r" bson:"r"`
+	G     int    `json:"g" bson:"g"`
+	B     int    `json:"b" bson:"b"`
+	Count int    `json:"count,omitempty" bson:"count,omitempty"` // occurrences of this and close colors
+}
+
+func newPaletteColor(r, g, b int) PaletteColor {
+	c := PaletteColor{R: r, G: g, B: b}
+	c.Hex = "#" + hexByte(r) + hexByte(g) + hexByte(b)
+	return c
+}
+
+func hexByte(v int) string {
+	s := strconv.FormatInt(int64(v), 16)
+	if len(s) == 1 {
+		s = "0" + s
+	}
+	return s
+}
+
+// ParseColor parses a hex color such as #cba6f7, cba6f7 or #fff.
+func ParseColor(s string) (PaletteColor, error) {
+	hex := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return PaletteColor{}, invalidf("invalid color %q: use a hex color such as #cba6f7", s)
+	}
+	return newPaletteColor(int(v>>16), int(v>>8&0xff), int(v&0xff)), nil
+}
+
+// near reports whether every channel of c is within tolerance of o.
+func (c PaletteColor) near(o PaletteColor, tolerance int) bool {
+	return abs(c.R-o.R) <= tolerance && abs(c.G-o.G) <= tolerance && abs(c.B-o.B) <= tolerance
+}
+
+// distance is the squared euclidean distance between c and o.
+func (c PaletteColor) distance(o PaletteColor) int {
+	dr, dg, db := c.R-o.R, c.G-o.G, c.B-o.B
+	return dr*dr + dg*dg + db*db
+}
+
+// luminance is the relative luminance of c, from 0 for black to 1 for white.
+func (c PaletteColor) luminance() float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// ExtractColors returns every opaque color written in data, in order.
+func ExtractColors(data []byte) []PaletteColor {
+	var colors []PaletteColor
+	add := func(hex string, alphaFirst bool) {
+		if len(hex) == 8 {
+			alpha := hex[6:]
+			if alphaFirst {
+				alpha, hex = hex[:2], hex[2:]
+			}
+			if alpha == "00" {
+				return
+			}
+		}
+		if c, err := ParseColor(hex[:min(len(hex), 6)]); err == nil {
+			colors = append(colors, c)
+		}
+	}
+	text := string(data)
+	for _, m := range hashColorRe.FindAllStringSubmatch(text, -1) {
+		add(m[1], false)
+	}
+	for _, m := range hexFuncRe.FindAllStringSubmatch(text, -1) {
+		add(m[1], false)
+	}
+	for _, m := range argbColorRe.FindAllStringSubmatch(text, -1) {
+		add(m[1], true)
+	}
+	for _, m := range decimalFuncRe.FindAllStringSubmatch(text, -1) {
+		r, _ := strconv.Atoi(m[1])
+		g, _ := strconv.Atoi(m[2])
+		b, _ := strconv.Atoi(m[3])
+		if r > 255 || g > 255 || b > 255 {
+			continue
+		}
+		if alpha, err := strconv.ParseFloat(m[4], 64); m[4] != "" && (err != nil || alpha == 0) {
+			continue
+		}
+		colors = append(colors, newPaletteColor(r, g, b))
+	}
+	return colors
+}
+
+// backgroundRe matches the lines of a file that set a background color.
+var backgroundRe = regexp.MustCompile(`(?i)background|\bbg\b`)
+
+// colorPalette clusters the colors of the text and config files of hc into its dominant
+// colors, most used first, and classifies it as dark or light by the luminance of its
+// background colors, or of all its colors when no line sets a background.
+// Files may be compressed, as in stored documents.
+func (hc *HyprConfig) colorPalette() ([]PaletteColor, string) {
+	counts := map[string]int{}
+	byHex := map[string]PaletteColor{}
+	var bgLum float64
+	var bgCount int
+	hc.Walk(func(pc *HyprProgramConfig) {
+		pc.eachFile(func(_ string, fc *FileContent) {
+			file := *fc
+			if !templated(&file) || len(file.Data) == 0 || file.Decompress() != nil {
+				return
+			}
+			for _, line := range bytes.Split(file.Data, []byte("\n")) {
+				colors := ExtractColors(line)
+				background := backgroundRe.Match(line)
+				for _, c := range colors {
+					counts[c.Hex]++
+					byHex[c.Hex] = c
+					if background {
+						bgLum += c.luminance()
+						bgCount++
+					}
+				}
+			}
+		})
+	})
+	if len(counts) == 0 {
+		return nil, ""
+	}
+
+	colors := make([]PaletteColor, 0, len(counts))
+	for hex, n := range counts {
+		c := byHex[hex]
+		c.Count = n
+		colors = append(colors, c)
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		if colors[i].Count != colors[j].Count {
+			return colors[i].Count > colors[j].Count
+		}
+		return colors[i].Hex < colors[j].Hex
+	})
+
+	// Greedily fold every color into the most used one close to it
+	var clusters []PaletteColor
+	for _, c := range colors {
+		merged := false
+		for i := range clusters {
+			if clusters[i].distance(c) <= clusterDistance*clusterDistance {
+				clusters[i].Count += c.Count
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			clusters = append(clusters, c)
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].Count > clusters[j].Count })
+
+	lum, total := bgLum, bgCount
+	if bgCount == 0 {
+		for _, c := range clusters {
+			lum += c.luminance() * float64(c.Count)
+			total += c.Count
+		}
+	}
+	theme := ThemeLight
+	if lum/float64(total) < 0.5 {
+		theme = ThemeDark
+	}
+	if len(clusters) > MaxPaletteColors {
+		clusters = clusters[:MaxPaletteColors]
+	}
+	return clusters, theme
+}
+
+// normalizeColorFilters canonicalizes the theme, palette and tolerance of search filters.
+func (f *ConfigSearchFilters) normalizeColorFilters() error {
+	if f.Theme != "" {
+		f.Theme = strings.ToLower(strings.TrimSpace(f.Theme))
+		if f.Theme != ThemeDark && f.Theme != ThemeLight {
+			return invalidf("invalid theme %q: use %s or %s", f.Theme, ThemeDark, ThemeLight)
+		}
+	}
+	if f.ColorTolerance < 0 || f.ColorTolerance > 255 {
+		return invalidf("invalid color tolerance %d: must be between 0 and 255", f.ColorTolerance)
+	}
+	if f.ColorTolerance == 0 {
+		f.ColorTolerance = DefaultColorTolerance
+	}
+	for i, s := range f.Palette {
+		c, err := ParseColor(s)
+		if err != nil {
+			return err
+		}
+		f.Palette[i] = c.Hex
+	}
+	return nil
+}
+
+// paletteColors returns the searched colors of normalized filters.
+func (f ConfigSearchFilters) paletteColors() []PaletteColor {
+	colors := make([]PaletteColor, 0, len(f.Palette))
+	for _, s := range f.Palette {
+		if c, err := ParseColor(s); err == nil {
+			colors = append(colors, c)
+		}
+	}
+	return colors
+}
+
+// matchesPalette reports whether every searched color is near a color of palette.
+func matchesPalette(palette []PaletteColor, searched []PaletteColor, tolerance int) bool {
+	for _, want := range searched {
+		found := false
+		for _, c := range palette {
+			found = found || c.near(want, tolerance)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mocha is a dark Catppuccin rice: a Hyprland border, waybar CSS and kitty colors.
+var mocha = map[string]string{
+	"hyprland": "general {\n    col.active_border = rgba(cba6f7ee) rgba(89b4faee) 45deg\n    col.inactive_border = 0xff1e1e2e\n}\n",
+	"waybar":   "* { background: #1e1e2e; color: #cdd6f4; }\n#workspaces button.active { color: #cba6f7; }\nwindow#waybar { background: rgba(30, 30, 46, 0.9); }\n",
+	"kitty":    "background #1e1e2e\nforeground #cdd6f4\nselection_background #cba6f7\ncolor0 #1e1e2e\ncolor8 #1f1f2f\n",
+}
+
+func paletteConfig(title string, files map[string]string) *HyprConfig {
+	cfg := &HyprConfig{Title: title}
+	for _, program := range []string{"hyprland", "waybar", "kitty"} {
+		if data, ok := files[program]; ok {
+			cfg.ProgramConfigs = append(cfg.ProgramConfigs, HyprProgramConfig{
+				Title: program, Program: program, FileContent: FileContent{Data: []byte(data), FileType: FileTypeConfig},
+			})
+		}
+	}
+	return cfg
+}
+
+func TestExtractColors(t *testing.T) {
+	var got []string
+	for _, c := range ExtractColors([]byte(`#fff #11223344 rgba(aabbccdd) rgb(010203) 0xff445566 rgba(1, 2, 3, 0.5) rgba(9, 9, 9, 0) #workspaces rgba(00000000) rgb(300, 0, 0)`)) {
+		got = append(got, c.Hex)
+	}
+	want := "#ffffff #112233 #aabbcc #010203 #445566 #010203"
+	if strings.Join(got, " ") != want {
+		t.Errorf("ExtractColors = %s, want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestColorPalette(t *testing.T) {
+	palette, theme := paletteConfig("mocha", mocha).colorPalette()
+	if theme != ThemeDark || len(palette) != 4 {
+		t.Fatalf("palette = %+v, theme %q", palette, theme)
+	}
+	// #1f1f2f is folded into #1e1e2e, the most used color
+	if palette[0].Hex != "#1e1e2e" || palette[0].Count != 6 || palette[1].Hex != "#cba6f7" {
+		t.Errorf("palette = %+v", palette)
+	}
+
+	_, theme = paletteConfig("latte", map[string]string{"kitty": "background #eff1f5\nforeground #4c4f69\ncolor0 #eff1f5\n"}).colorPalette()
+	if theme != ThemeLight {
+		t.Errorf("latte theme = %q, want light", theme)
+	}
+	if palette, theme := paletteConfig("plain", map[string]string{"kitty": "font_size 12\n"}).colorPalette(); palette != nil || theme != "" {
+		t.Errorf("config without colors has palette %+v, theme %q", palette, theme)
+	}
+}
+
+func TestSearchPalette(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		create := func(cfg *HyprConfig) string {
+			t.Helper()
+			created, err := m.CreateConfig(alice, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return created.ID
+		}
+		dark := create(paletteConfig("mocha", mocha))
+		light := create(paletteConfig("latte", map[string]string{"kitty": "background #eff1f5\nforeground #4c4f69\nselection_background #8839ef\n"}))
+
+		got, err := m.GetConfig(alice, dark)
+		if err != nil || got.Theme != ThemeDark || len(got.Palette) == 0 {
+			t.Fatalf("stored palette = %+v, theme %q, %v", got.Palette, got.Theme, err)
+		}
+
+		search := func(filters ConfigSearchFilters) []string {
+			t.Helper()
+			res, err := m.ListConfigsWithFilters(alice, 1, 10, filters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, cfg := range res.Items {
+				if len(cfg.Palette) == 0 {
+					t.Errorf("listed config %s has no palette", cfg.Title)
+				}
+				ids = append(ids, cfg.ID)
+			}
+			return ids
+		}
+		if got := search(ConfigSearchFilters{Theme: "Dark"}); len(got) != 1 || got[0] != dark {
+			t.Errorf("dark = %v, want %s", got, dark)
+		}
+		// A purple close to Mocha's mauve, but too far from Latte's
+		if got := search(ConfigSearchFilters{Palette: []string{"c0a0f0"}}); len(got) != 1 || got[0] != dark {
+			t.Errorf("purple = %v, want %s", got, dark)
+		}
+		if got := search(ConfigSearchFilters{Palette: []string{"#c0a0f0"}, ColorTolerance: 120}); len(got) != 2 {
+			t.Errorf("purple with a wide tolerance = %v, want both", got)
+		}
+		if got := search(ConfigSearchFilters{Palette: []string{"#cba6f7", "#4c4f69"}}); len(got) != 0 {
+			t.Errorf("colors of both configs = %v, want none", got)
+		}
+		if got := search(ConfigSearchFilters{Theme: ThemeLight, Palette: []string{"#8839ef"}}); len(got) != 1 || got[0] != light {
+			t.Errorf("light purple = %v, want %s", got, light)
+		}
+
+		for _, filters := range []ConfigSearchFilters{{Theme: "dim"}, {Palette: []string{"purple"}}, {Palette: []string{"#fff"}, ColorTolerance: 300}} {
+			if _, err := m.ListConfigsWithFilters(alice, 1, 10, filters, nil); !errors.Is(err, ErrValidation) {
+				t.Errorf("%+v: got %v, want ErrValidation", filters, err)
+			}
+		}
+	})
+}
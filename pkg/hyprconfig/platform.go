@@ -0,0 +1,100 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	PlatformArch     = "arch"
+	PlatformDebian   = "debian"
+	PlatformUbuntu   = "ubuntu"
+	PlatformFedora   = "fedora"
+	PlatformNixOS    = "nixos"
+	PlatformOpenSUSE = "opensuse"
+	PlatformVoid     = "void"
+	PlatformGentoo   = "gentoo"
+)
+
+var ErrInvalidPlatform = errors.New("invalid platform")
+
+// platforms is the canonical platform list, in the order reported to clients.
+var platforms = []string{
+	PlatformArch,
+	PlatformDebian,
+	PlatformUbuntu,
+	PlatformFedora,
+	PlatformNixOS,
+	PlatformOpenSUSE,
+	PlatformVoid,
+	PlatformGentoo,
+}
+
+// platformAliases maps common spellings found in older documents to the canonical name.
+var platformAliases = map[string]string{
+	"archlinux":           PlatformArch,
+	"arch-linux":          PlatformArch,
+	"arch linux":          PlatformArch,
+	"debian-linux":        PlatformDebian,
+	"ubuntu-linux":        PlatformUbuntu,
+	"fedora-linux":        PlatformFedora,
+	"fedora linux":        PlatformFedora,
+	"nix":                 PlatformNixOS,
+	"nix-os":              PlatformNixOS,
+	"suse":                PlatformOpenSUSE,
+	"open-suse":           PlatformOpenSUSE,
+	"opensuse-tumbleweed": PlatformOpenSUSE,
+	"opensuse-leap":       PlatformOpenSUSE,
+	"tumbleweed":          PlatformOpenSUSE,
+	"voidlinux":           PlatformVoid,
+	"void-linux":          PlatformVoid,
+	"void linux":          PlatformVoid,
+	"gentoo-linux":        PlatformGentoo,
+}
+
+// Platforms returns the canonical platform names.
+func Platforms() []string {
+	return append([]string(nil), platforms...)
+}
+
+// NormalizePlatform returns the canonical name for p, accepting any case and the known aliases.
+func NormalizePlatform(p string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(p))
+	if canonical, ok := platformAliases[name]; ok {
+		return canonical, nil
+	}
+	for _, canonical := range platforms {
+		if name == canonical {
+			return canonical, nil
+		}
+	}
+	return "", fmt.Errorf("%w %q, accepted values are: %s", ErrInvalidPlatform, p, strings.Join(platforms, ", "))
+}
+
+// normalizePlatforms validates pc.Platform, recording each unknown platform in verr, and stores
+// it back canonical and deduplicated when all are known.
+func (pc *HyprProgramConfig) normalizePlatforms(verr *ValidationError) {
+	if len(pc.Platform) == 0 {
+		return
+	}
+	seen := map[string]struct{}{}
+	normalized := make([]string, 0, len(pc.Platform))
+	valid := true
+	for i, p := range pc.Platform {
+		canonical, err := NormalizePlatform(p)
+		if err != nil {
+			verr.add(fmt.Sprintf("platform[%d]", i), fmt.Errorf("program config %q: platform: %w", pc.Title, err))
+			valid = false
+			continue
+		}
+		if _, dup := seen[canonical]; dup {
+			continue
+		}
+		seen[canonical] = struct{}{}
+		normalized = append(normalized, canonical)
+	}
+	if valid {
+		pc.Platform = normalized
+	}
+}
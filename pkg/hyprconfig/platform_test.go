@@ -0,0 +1,69 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestNormalizePlatform(t *testing.T) {
+	tests := map[string]string{
+		"arch":        PlatformArch,
+		"Arch":        PlatformArch,
+		"archlinux":   PlatformArch,
+		" ArchLinux ": PlatformArch,
+		"NixOS":       PlatformNixOS,
+		"tumbleweed":  PlatformOpenSUSE,
+		"void-linux":  PlatformVoid,
+	}
+	for in, want := range tests {
+		got, err := NormalizePlatform(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizePlatform(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+
+	_, err := NormalizePlatform("windows")
+	if !errors.Is(err, ErrInvalidPlatform) {
+		t.Fatalf("got %v, want ErrInvalidPlatform", err)
+	}
+	if !strings.Contains(err.Error(), strings.Join(Platforms(), ", ")) {
+		t.Errorf("error should list the accepted values, got %q", err)
+	}
+}
+
+func TestProgramConfigValidateNormalizesPlatform(t *testing.T) {
+	pc := HyprProgramConfig{Title: "term", Program: "kitty", Platform: []string{"Arch", "archlinux", "Fedora"}}
+	if err := pc.Validate(context.Background(), allowOnly(), SizeLimits{}); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+	if want := []string{PlatformArch, PlatformFedora}; !reflect.DeepEqual(pc.Platform, want) {
+		t.Errorf("platform = %v, want %v", pc.Platform, want)
+	}
+
+	pc.Platform = []string{"arch", "beos"}
+	err := pc.Validate(context.Background(), allowOnly(), SizeLimits{})
+	if !errors.Is(err, ErrInvalidPlatform) || !strings.Contains(err.Error(), "term") {
+		t.Errorf("invalid platform: got %v", err)
+	}
+}
+
+func TestBuildSearchFilterPlatform(t *testing.T) {
+	filter := buildSearchFilter(ConfigSearchFilters{Platform: PlatformArch}, nil)
+	parts, ok := filter["$and"].([]bson.M)
+	if !ok || len(parts) != 1 {
+		t.Fatalf("expected one $and clause, got %v", filter["$and"])
+	}
+	want := bson.M{"program_configs": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+		"optional":   bson.M{"$ne": true},
+		"platform.0": bson.M{"$exists": true},
+		"platform":   bson.M{"$nin": []string{PlatformArch}},
+	}}}}
+	if !reflect.DeepEqual(parts[0], want) {
+		t.Errorf("platform clause = %v, want %v", parts[0], want)
+	}
+}
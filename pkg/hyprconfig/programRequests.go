@@ -0,0 +1,196 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RequestAllowedProgram lets any signed-in user ask for a program to be added to the allowed list.
+// If a pending request for the same program already exists it is returned instead of creating a new one.
+func (m *ConfigManagerMongo) RequestAllowedProgram(
+	ctx context.Context,
+	programName string,
+	reason string,
+) (_ *ProgramRequest, err error) {
+	defer func() { m.logMutation(ctx, "RequestAllowedProgram", err, slog.String("program", programName)) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+	if m.ProgramsCollection == nil {
+		return nil, ErrAllowlistDisabled
+	}
+
+	allowed, err := m.registry().IsAllowed(ctx, programName)
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
+		return nil, invalidf("program '%s' is already allowed", programName)
+	}
+
+	var existing ProgramRequest
+	err = m.ProgramRequestsCollection.FindOne(ctx, bson.M{
+		"program_name": programName,
+		"status":       ProgramRequestPending,
+	}).Decode(&existing)
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to look up program requests: %w", err)
+	}
+
+	now := time.Now()
+	req := ProgramRequest{
+		ID:               uuid.NewString(),
+		ProgramName:      programName,
+		Reason:           strings.TrimSpace(reason),
+		RequestedBy:      user.UserID,
+		Status:           ProgramRequestPending,
+		CreatedTimestamp: now,
+		UpdatedTimestamp: now,
+	}
+
+	if _, err := m.ProgramRequestsCollection.InsertOne(ctx, req); err != nil {
+		return nil, fmt.Errorf("failed to insert program request: %w", err)
+	}
+
+	return &req, nil
+}
+
+// ListProgramRequests returns program requests, newest first. An empty status lists every request.
+func (m *ConfigManagerMongo) ListProgramRequests(
+	ctx context.Context,
+	page, limit int,
+	status string,
+) (mserve.Page[ProgramRequest], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[ProgramRequest]{}, err
+	}
+
+	if !isAdmin(user.Roles) {
+		return mserve.Page[ProgramRequest]{}, ErrForbidden
+	}
+
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	return mserve.PaginateMongo[ProgramRequest](
+		ctx,
+		m.ProgramRequestsCollection,
+		filter,
+		page,
+		limit,
+		options.Find().SetSort(bson.M{"created_timestamp": -1}),
+	)
+}
+
+// ApproveProgramRequest adds the requested program to the allowed list and marks the request approved.
+func (m *ConfigManagerMongo) ApproveProgramRequest(ctx context.Context, requestID string) (_ *AllowedPrograms, err error) {
+	defer func() { m.logMutation(ctx, "ApproveProgramRequest", err, slog.String("request_id", requestID)) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	if m.ProgramsCollection == nil {
+		return nil, ErrAllowlistDisabled
+	}
+
+	req, err := m.getPendingProgramRequest(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	program := AllowedPrograms{ProgramName: req.ProgramName}
+	_, err = m.ProgramsCollection.InsertOne(ctx, program)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to insert allowed program: %w", err)
+	}
+
+	m.registry().Invalidate(program.ProgramName)
+
+	if err := m.reviewProgramRequest(ctx, requestID, ProgramRequestApproved, user.UserID, ""); err != nil {
+		return nil, err
+	}
+	m.audit(ctx, newAuditEntry(user.UserID, AuditAddAllowedProgram, "", "", []string{program.ProgramName}))
+
+	return &program, nil
+}
+
+// RejectProgramRequest marks a pending request as rejected with an optional note for the requester.
+func (m *ConfigManagerMongo) RejectProgramRequest(ctx context.Context, requestID string, note string) (err error) {
+	defer func() { m.logMutation(ctx, "RejectProgramRequest", err, slog.String("request_id", requestID)) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+
+	if _, err := m.getPendingProgramRequest(ctx, requestID); err != nil {
+		return err
+	}
+
+	return m.reviewProgramRequest(ctx, requestID, ProgramRequestRejected, user.UserID, strings.TrimSpace(note))
+}
+
+func (m *ConfigManagerMongo) getPendingProgramRequest(ctx context.Context, requestID string) (*ProgramRequest, error) {
+	var req ProgramRequest
+	err := m.ProgramRequestsCollection.FindOne(ctx, bson.M{"_id": requestID}).Decode(&req)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch program request: %w", err)
+	}
+
+	if req.Status != ProgramRequestPending {
+		return nil, invalidf("program request %s has already been %s", requestID, req.Status)
+	}
+
+	return &req, nil
+}
+
+func (m *ConfigManagerMongo) reviewProgramRequest(
+	ctx context.Context,
+	requestID, status, reviewerID, note string,
+) error {
+	_, err := m.ProgramRequestsCollection.UpdateByID(ctx, requestID, bson.M{
+		"$set": bson.M{
+			"status":            status,
+			"reviewed_by":       reviewerID,
+			"review_note":       note,
+			"updated_timestamp": time.Now(),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update program request: %w", err)
+	}
+	return nil
+}
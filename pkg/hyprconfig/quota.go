@@ -0,0 +1,325 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// userQuota is the stored usage record of a user. QuotaBytes and ConfigLimit are only set when
+// an admin overrode SizeLimits.MaxUserBytes or SizeLimits.MaxUserConfigs for them.
+type userQuota struct {
+	UserID           string    `bson:"_id"`
+	UsedBytes        int64     `bson:"used_bytes"`
+	QuotaBytes       int64     `bson:"quota_bytes,omitempty"`
+	ConfigLimit      int64     `bson:"config_limit,omitempty"`
+	UpdatedTimestamp time.Time `bson:"updated_timestamp"`
+}
+
+// QuotaUsage is how much file content a user stores against their quota.
+type QuotaUsage struct {
+	UserID     string `json:"user_id"`
+	UsedBytes  int64  `json:"used_bytes"`
+	QuotaBytes int64  `json:"quota_bytes"` // 0 is unlimited
+	Custom     bool   `json:"custom"`      // the quota was set by an admin instead of the server default
+}
+
+// usage resolves the quota of q against the server default.
+func (q userQuota) usage(limits SizeLimits) *QuotaUsage {
+	u := &QuotaUsage{UserID: q.UserID, UsedBytes: q.UsedBytes, QuotaBytes: limits.MaxUserBytes}
+	if q.QuotaBytes > 0 {
+		u.QuotaBytes = q.QuotaBytes
+		u.Custom = true
+	}
+	return u
+}
+
+// check returns ErrQuotaExceeded when storing delta more bytes would go over the quota.
+// Writes that do not grow the usage always pass, so users over their quota can still clean up.
+func (u *QuotaUsage) check(delta int64) error {
+	if delta <= 0 || u.QuotaBytes <= 0 || u.UsedBytes+delta <= u.QuotaBytes {
+		return nil
+	}
+	return fmt.Errorf("%w: storing %d more bytes would use %d of the %d bytes allowed",
+		ErrQuotaExceeded, delta, u.UsedBytes+delta, u.QuotaBytes)
+}
+
+// contentBytes sums the file content of configs, for users whose usage was never recorded.
+func contentBytes(configs []HyprConfig) int64 {
+	var n int64
+	for i := range configs {
+		n += configs[i].contentSize()
+	}
+	return n
+}
+
+// quotaUsage returns the usage of userID. Users without a usage record yet, e.g. from before
+// quotas were tracked, are measured from their configs once.
+func (m *ConfigManagerMongo) quotaUsage(ctx context.Context, userID string) (*QuotaUsage, error) {
+	var q userQuota
+	err := m.QuotaCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&q)
+	if err == nil {
+		return q.usage(m.limits), nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to fetch quota usage: %w", err)
+	}
+
+	cursor, err := m.Collection.Find(ctx, bson.M{"owner_id": userID},
+		options.Find().SetProjection(bson.M{"program_configs": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure quota usage: %w", err)
+	}
+	var configs []HyprConfig
+	if err := cursor.All(ctx, &configs); err != nil {
+		return nil, fmt.Errorf("failed to measure quota usage: %w", err)
+	}
+
+	q = userQuota{UserID: userID, UsedBytes: contentBytes(configs), UpdatedTimestamp: time.Now()}
+	_, err = m.QuotaCollection.UpdateOne(ctx, bson.M{"_id": userID},
+		bson.M{"$setOnInsert": bson.M{"used_bytes": q.UsedBytes, "updated_timestamp": q.UpdatedTimestamp}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record quota usage: %w", err)
+	}
+	return q.usage(m.limits), nil
+}
+
+// checkQuota makes sure userID has a usage record and room to store delta more bytes.
+func (m *ConfigManagerMongo) checkQuota(ctx context.Context, userID string, delta int64) error {
+	usage, err := m.quotaUsage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return usage.check(delta)
+}
+
+// addUsage records delta bytes against userID once a write succeeded.
+func (m *ConfigManagerMongo) addUsage(ctx context.Context, userID string, delta int64) {
+	if delta == 0 {
+		return
+	}
+	_, err := m.QuotaCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$inc": bson.M{"used_bytes": delta},
+		"$set": bson.M{"updated_timestamp": time.Now()},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		slog.Warn("failed to record quota usage", "user_id", userID, "delta", delta, "err", err)
+	}
+}
+
+// GetQuotaUsage returns how much of their storage quota the signed-in user uses.
+func (m *ConfigManagerMongo) GetQuotaUsage(ctx context.Context) (*QuotaUsage, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.quotaUsage(ctx, user.UserID)
+}
+
+// SetUserQuota overrides the storage quota of a user. Only admins may set quotas; a quota of
+// zero restores the server default.
+func (m *ConfigManagerMongo) SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (_ *QuotaUsage, err error) {
+	defer func() {
+		m.logMutation(ctx, "SetUserQuota", err, slog.String("target_user_id", userID), slog.Int64("quota_bytes", quotaBytes))
+	}()
+	if err := checkSetUserQuota(ctx, userID, quotaBytes); err != nil {
+		return nil, err
+	}
+	if _, err := m.quotaUsage(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"quota_bytes": quotaBytes, "updated_timestamp": time.Now()}}
+	if quotaBytes == 0 {
+		update = bson.M{"$unset": bson.M{"quota_bytes": ""}, "$set": bson.M{"updated_timestamp": time.Now()}}
+	}
+	if _, err := m.QuotaCollection.UpdateOne(ctx, bson.M{"_id": userID}, update); err != nil {
+		return nil, fmt.Errorf("failed to set quota: %w", err)
+	}
+	return m.quotaUsage(ctx, userID)
+}
+
+// checkSetUserQuota validates a SetUserQuota call.
+func checkSetUserQuota(ctx context.Context, userID string, quotaBytes int64) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+	if userID == "" {
+		return invalidf("user id cannot be empty")
+	}
+	if quotaBytes < 0 {
+		return invalidf("quota cannot be negative")
+	}
+	return nil
+}
+
+// quotaUsage returns the usage of userID. Callers must hold m.mu.
+func (m *ConfigManagerMemory) quotaUsage(userID string) *QuotaUsage {
+	q := m.quotas[userID]
+	q.UserID = userID
+	return q.usage(m.limits)
+}
+
+// charge records delta bytes against userID, failing when that goes over their quota.
+// Callers must hold m.mu.
+func (m *ConfigManagerMemory) charge(userID string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	if err := m.quotaUsage(userID).check(delta); err != nil {
+		return err
+	}
+	m.addUsage(userID, delta)
+	return nil
+}
+
+// addUsage records delta bytes against userID without checking their quota. Callers must hold m.mu.
+func (m *ConfigManagerMemory) addUsage(userID string, delta int64) {
+	q := m.quotas[userID]
+	q.UserID = userID
+	q.UsedBytes += delta
+	q.UpdatedTimestamp = time.Now()
+	m.quotas[userID] = q
+}
+
+func (m *ConfigManagerMemory) GetQuotaUsage(ctx context.Context) (*QuotaUsage, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.quotaUsage(user.UserID), nil
+}
+
+func (m *ConfigManagerMemory) SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (*QuotaUsage, error) {
+	if err := checkSetUserQuota(ctx, userID, quotaBytes); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q := m.quotas[userID]
+	q.UserID = userID
+	q.QuotaBytes = quotaBytes
+	q.UpdatedTimestamp = time.Now()
+	m.quotas[userID] = q
+	return m.quotaUsage(userID), nil
+}
+
+// quotaUsage returns the usage of userID. Users without a usage record yet, e.g. from before
+// quotas were tracked, are measured from their configs once.
+func (m *ConfigManagerSQLite) quotaUsage(ctx context.Context, q sqlQuerier, userID string) (*QuotaUsage, error) {
+	quota := userQuota{UserID: userID}
+	err := q.QueryRowContext(ctx, `SELECT used_bytes, quota_bytes FROM user_quota WHERE user_id = ?`, userID).
+		Scan(&quota.UsedBytes, &quota.QuotaBytes)
+	if err == nil {
+		return quota.usage(m.limits), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to fetch quota usage: %w", err)
+	}
+
+	rows, err := q.QueryContext(ctx, `SELECT doc, likes FROM configs WHERE owner_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure quota usage: %w", err)
+	}
+	defer rows.Close()
+	var configs []HyprConfig
+	for rows.Next() {
+		var doc string
+		var likes int64
+		if err := rows.Scan(&doc, &likes); err != nil {
+			return nil, err
+		}
+		cfg, err := decodeConfigRow(doc, likes)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, *cfg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	quota.UsedBytes = contentBytes(configs)
+	_, err = q.ExecContext(ctx, `INSERT OR IGNORE INTO user_quota (user_id, used_bytes, updated_timestamp) VALUES (?, ?, ?)`,
+		userID, quota.UsedBytes, time.Now().UnixNano())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record quota usage: %w", err)
+	}
+	return quota.usage(m.limits), nil
+}
+
+// chargeQuota records delta bytes against userID in tx, failing when that goes over their quota.
+func (m *ConfigManagerSQLite) chargeQuota(ctx context.Context, tx *sql.Tx, userID string, delta int64) error {
+	usage, err := m.quotaUsage(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+	if err := usage.check(delta); err != nil {
+		return err
+	}
+	return addQuotaUsage(ctx, tx, userID, delta)
+}
+
+// addQuotaUsage records delta bytes against userID without checking their quota. The user's
+// usage row must exist, which quotaUsage makes sure of.
+func addQuotaUsage(ctx context.Context, tx *sql.Tx, userID string, delta int64) error {
+	if delta == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `UPDATE user_quota SET used_bytes = used_bytes + ?, updated_timestamp = ? WHERE user_id = ?`,
+		delta, time.Now().UnixNano(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to record quota usage: %w", err)
+	}
+	return nil
+}
+
+func (m *ConfigManagerSQLite) GetQuotaUsage(ctx context.Context) (*QuotaUsage, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.quotaUsage(ctx, m.db, user.UserID)
+}
+
+func (m *ConfigManagerSQLite) SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (*QuotaUsage, error) {
+	if err := checkSetUserQuota(ctx, userID, quotaBytes); err != nil {
+		return nil, err
+	}
+
+	var usage *QuotaUsage
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := m.quotaUsage(ctx, tx, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `UPDATE user_quota SET quota_bytes = ?, updated_timestamp = ? WHERE user_id = ?`,
+			quotaBytes, time.Now().UnixNano(), userID)
+		if err != nil {
+			return fmt.Errorf("failed to set quota: %w", err)
+		}
+		usage, err = m.quotaUsage(ctx, tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
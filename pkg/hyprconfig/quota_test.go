@@ -0,0 +1,153 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestManagerQuota(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := asUser("alice")
+		admin := asUser("root", "admin")
+		file := func(n int) FileContent {
+			return FileContent{Data: bytes.Repeat([]byte("x"), n), FileType: FileTypeConfig}
+		}
+		used := func() int64 {
+			t.Helper()
+			usage, err := m.GetQuotaUsage(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return usage.UsedBytes
+		}
+
+		usage, err := m.SetUserQuota(admin, "alice", 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usage.QuotaBytes != 100 || !usage.Custom || usage.UsedBytes != 0 {
+			t.Errorf("usage after override = %+v", usage)
+		}
+
+		cfg, err := m.CreateConfig(ctx, &HyprConfig{
+			Title:          "rice",
+			ProgramConfigs: []HyprProgramConfig{{ID: "term", Title: "term", Program: "kitty", FileContent: file(60)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := used(); n != 60 {
+			t.Errorf("used after create = %d, want 60", n)
+		}
+
+		err = m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{Title: "bar", Program: "waybar", FileContent: file(60)}, nil, "")
+		if !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("add over quota: got %v, want ErrQuotaExceeded", err)
+		}
+		_, err = m.CreateConfig(ctx, &HyprConfig{
+			Title:          "other",
+			ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty", FileContent: file(50)}},
+		})
+		if !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("create over quota: got %v, want ErrQuotaExceeded", err)
+		}
+
+		err = m.UpdateProgramConfig(ctx, cfg.ID, "term", HyprProgramConfig{Title: "term", Program: "kitty", FileContent: file(20)}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := used(); n != 20 {
+			t.Errorf("used after shrinking = %d, want 20", n)
+		}
+		if err := m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{ID: "bar", Title: "bar", Program: "waybar", FileContent: file(60)}, nil, ""); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.RemoveProgramConfig(ctx, cfg.ID, "bar", ""); err != nil {
+			t.Fatal(err)
+		}
+		if n := used(); n != 20 {
+			t.Errorf("used after add and remove = %d, want 20", n)
+		}
+
+		if err := m.DeleteConfig(ctx, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if n := used(); n != 0 {
+			t.Errorf("used after delete = %d, want 0", n)
+		}
+
+		if _, err := m.SetUserQuota(ctx, "alice", 1<<30); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-admin override: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.SetUserQuota(admin, "alice", -1); !errors.Is(err, ErrValidation) {
+			t.Errorf("negative quota: got %v, want ErrValidation", err)
+		}
+		usage, err = m.SetUserQuota(admin, "alice", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if usage.QuotaBytes != m.SizeLimits().MaxUserBytes || usage.Custom {
+			t.Errorf("usage after reset = %+v", usage)
+		}
+	})
+}
+
+func TestManagerConfigLimit(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		admin := asUser("root", "admin")
+		create := func(ctx context.Context, program string) error {
+			_, err := m.CreateConfig(ctx, &HyprConfig{
+				Title:          program,
+				ProgramConfigs: []HyprProgramConfig{{Title: program, Program: program}},
+			})
+			return err
+		}
+
+		limit, err := m.SetUserConfigLimit(admin, "alice", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limit.Limit != 1 || !limit.Custom || limit.Configs != 0 {
+			t.Errorf("limit after override = %+v", limit)
+		}
+		if err := create(asUser("alice"), "kitty"); err != nil {
+			t.Fatal(err)
+		}
+		err = create(asUser("alice"), "waybar")
+		var lerr *ConfigLimitError
+		if !errors.As(err, &lerr) || !errors.Is(err, ErrLimitExceeded) || lerr.Count != 1 || lerr.Limit != 1 {
+			t.Errorf("create over limit: got %v, want ConfigLimitError 1 of 1", err)
+		}
+		// Other users keep the default, and admins have no limit
+		if err := create(asUser("bob"), "waybar"); err != nil {
+			t.Errorf("bob: %v", err)
+		}
+		if _, err := m.SetUserConfigLimit(admin, "root", 1); err != nil {
+			t.Fatal(err)
+		}
+		for _, program := range []string{"kitty", "waybar"} {
+			if err := create(admin, program); err != nil {
+				t.Errorf("admin: %v", err)
+			}
+		}
+
+		if _, err := m.SetUserConfigLimit(asUser("alice"), "alice", 10); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-admin override: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.SetUserConfigLimit(admin, "alice", -1); !errors.Is(err, ErrValidation) {
+			t.Errorf("negative limit: got %v, want ErrValidation", err)
+		}
+		limit, err = m.SetUserConfigLimit(admin, "alice", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limit.Limit != m.SizeLimits().MaxUserConfigs || limit.Custom || limit.Configs != 1 {
+			t.Errorf("limit after reset = %+v", limit)
+		}
+		if err := create(asUser("alice"), "waybar"); err != nil {
+			t.Errorf("create after reset: %v", err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetRandomConfig returns a config picked at random out of those matching filters that the
+// caller may see, or ErrNotFound when none match. filters.Sort is ignored.
+func (m *ConfigManagerMongo) GetRandomConfig(ctx context.Context, filters ConfigSearchFilters) (*HyprConfig, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil
+
+	if err := filters.normalize(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: buildSearchFilter(filters, user)}},
+		{{Key: "$sample", Value: bson.M{"size": 1}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample configs: %w", err)
+	}
+	var sampled []HyprConfig
+	if err := cursor.All(ctx, &sampled); err != nil {
+		return nil, fmt.Errorf("failed to sample configs: %w", err)
+	}
+	if len(sampled) == 0 {
+		return nil, ErrNotFound
+	}
+
+	cfg := &sampled[0]
+	if err := decodeForRead(ctx, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (m *ConfigManagerMemory) GetRandomConfig(ctx context.Context, filters ConfigSearchFilters) (*HyprConfig, error) {
+	keep, err := visibleSearch(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var matches []*HyprConfig
+	for _, cfg := range m.configs {
+		if keep(cfg) {
+			matches = append(matches, cfg)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, ErrNotFound
+	}
+	return cloneConfig(matches[rand.IntN(len(matches))])
+}
+
+func (m *ConfigManagerSQLite) GetRandomConfig(ctx context.Context, filters ConfigSearchFilters) (*HyprConfig, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil
+
+	if err := filters.normalize(); err != nil {
+		return nil, err
+	}
+
+	where, args := m.searchWhere(filters, user)
+	var id string
+	err := m.db.QueryRowContext(ctx, `SELECT id FROM configs WHERE `+where+` ORDER BY RANDOM() LIMIT 1`, args...).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to sample configs: %w", err)
+	}
+	return m.getConfig(ctx, m.db, id)
+}
@@ -0,0 +1,66 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetRandomConfig(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		ctx := context.Background()
+		if _, err := m.GetRandomConfig(ctx, ConfigSearchFilters{}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("empty database: got %v, want ErrNotFound", err)
+		}
+
+		var dark []string
+		for range 2 {
+			cfg, err := m.CreateConfig(asUser("alice"), &HyprConfig{
+				Title:          "rice",
+				Tags:           []string{"dark"},
+				ProgramConfigs: []HyprProgramConfig{{ID: "term", Title: "term", Program: "kitty"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			dark = append(dark, cfg.ID)
+		}
+		newTestConfig(t, m, "alice", false) // untagged
+		private, err := m.CreateConfig(asUser("bob"), &HyprConfig{
+			Title:          "rice",
+			Private:        true,
+			Tags:           []string{"dark"},
+			ProgramConfigs: []HyprProgramConfig{{ID: "term", Title: "term", Program: "kitty"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		seen := map[string]bool{}
+		for range 50 {
+			cfg, err := m.GetRandomConfig(ctx, ConfigSearchFilters{Tags: []string{"dark"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.ID != dark[0] && cfg.ID != dark[1] {
+				t.Fatalf("got config %s, want one of the public dark configs %v", cfg.ID, dark)
+			}
+			seen[cfg.ID] = true
+		}
+		if len(seen) != 2 {
+			t.Errorf("50 picks only returned %v", seen)
+		}
+
+		// Owners see their own private configs
+		cfg, err := m.GetRandomConfig(asUser("bob"), ConfigSearchFilters{OwnerID: "bob"})
+		if err != nil || cfg.ID != private.ID {
+			t.Errorf("bob's random config = %v, %v, want his private one", cfg, err)
+		}
+		if _, err := m.GetRandomConfig(ctx, ConfigSearchFilters{OwnerID: "bob"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("bob's configs signed out: got %v, want ErrNotFound", err)
+		}
+		if _, err := m.GetRandomConfig(ctx, ConfigSearchFilters{Tags: []string{"light"}}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("no match: got %v, want ErrNotFound", err)
+		}
+	})
+}
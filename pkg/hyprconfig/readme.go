@@ -0,0 +1,596 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Formats a config's README can be fetched in.
+const (
+	ReadmeFormatMarkdown = "markdown"
+	ReadmeFormatHTML     = "html"
+)
+
+const (
+	// maxReadmeNesting bounds how deep block quotes and inline markup nest, deeper markup is
+	// rendered as text.
+	maxReadmeNesting = 16
+
+	// maxReadmeLinkLength bounds the text, destination and title of a link, longer ones are
+	// rendered as text.
+	maxReadmeLinkLength = 2048
+)
+
+// readmeEscapable are the characters a backslash makes literal.
+const readmeEscapable = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+var (
+	// readmeCommentRe matches HTML comments, including one left open.
+	readmeCommentRe = regexp.MustCompile(`(?s)<!--.*?(?:-->|$)`)
+	// readmeTagRe matches raw HTML opening, closing and self-closing tags.
+	readmeTagRe = regexp.MustCompile(`</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`)
+	// readmeLinkDestRe matches the destination of an inline link or image, which may have
+	// one level of balanced parentheses.
+	readmeLinkDestRe = regexp.MustCompile(`(\]\(\s*)(<[^<>\n]*>|(?:[^\s()<>]|\([^\s()<>]*\))*)`)
+	// readmeAutolinkRe matches an autolink such as <https://hypr.land>.
+	readmeAutolinkRe      = regexp.MustCompile(`<([A-Za-z][A-Za-z0-9+.-]*:[^<>\s]*)>`)
+	readmeAutolinkStartRe = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]*:[^<>\s]*)>`)
+	// readmeLanguageRe is what the language of a fenced code block may be to become a class.
+	readmeLanguageRe = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,32}$`)
+	// readmeTableSepRe matches the line under a table header, such as | --- | :-: |.
+	readmeTableSepRe = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// sanitizeReadme strips raw HTML tags and comments from README markdown, and replaces link
+// destinations and autolinks with unsafe schemes such as javascript: or data:. Fenced code
+// blocks are left as-is, RenderReadme escapes them.
+func sanitizeReadme(src string) string {
+	var out, prose []string
+	flush := func() {
+		if len(prose) > 0 {
+			out = append(out, sanitizeReadmeProse(strings.Join(prose, "\n")))
+			prose = prose[:0]
+		}
+	}
+	fence := ""
+	for _, line := range strings.Split(src, "\n") {
+		switch {
+		case fence != "":
+			out = append(out, line)
+			if readmeFenceCloses(line, fence) {
+				fence = ""
+			}
+		case readmeFence(line) != "":
+			flush()
+			fence = readmeFence(line)
+			out = append(out, line)
+		default:
+			prose = append(prose, line)
+		}
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+func sanitizeReadmeProse(s string) string {
+	// Stripping a tag can join the pieces of another, so repeat until nothing changes
+	for {
+		next := readmeTagRe.ReplaceAllString(readmeCommentRe.ReplaceAllString(s, ""), "")
+		if next == s {
+			break
+		}
+		s = next
+	}
+	s = readmeLinkDestRe.ReplaceAllStringFunc(s, func(link string) string {
+		m := readmeLinkDestRe.FindStringSubmatch(link)
+		dest := strings.TrimSuffix(strings.TrimPrefix(m[2], "<"), ">")
+		if dest == "" || safeReadmeURL(dest, false) {
+			return link
+		}
+		return m[1] + "#"
+	})
+	return readmeAutolinkRe.ReplaceAllStringFunc(s, func(link string) string {
+		if safeReadmeURL(link[1:len(link)-1], false) {
+			return link
+		}
+		return ""
+	})
+}
+
+// unescapeReadmeURL returns raw as a markdown renderer reads it: without backslash escapes
+// and with HTML entities decoded, repeatedly so that double encoding doesn't hide anything.
+func unescapeReadmeURL(raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) && strings.IndexByte(readmeEscapable, raw[i+1]) >= 0 {
+			i++
+		}
+		b.WriteByte(raw[i])
+	}
+	s := b.String()
+	for {
+		next := html.UnescapeString(s)
+		if next == s {
+			return s
+		}
+		s = next
+	}
+}
+
+// safeReadmeURL reports whether raw may be linked to from a README: relative, http or https
+// URLs, and mailto links for anything but images. Escapes and entities are decoded first, so
+// javascript&colon; is no more allowed than javascript:.
+func safeReadmeURL(raw string, image bool) bool {
+	raw = unescapeReadmeURL(raw)
+	if raw == "" || strings.IndexFunc(raw, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return false
+	}
+	u, err := url.Parse(raw) // lowercases the scheme
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "", "http", "https":
+		return true
+	case "mailto":
+		return !image
+	}
+	return false
+}
+
+// RenderReadme renders README markdown to HTML. It supports ATX headings, paragraphs, emphasis,
+// code spans, fenced code blocks, block quotes, flat lists, pipe tables, rules, links, images
+// and autolinks. All text is escaped and raw HTML is shown as text, and links and images keep
+// only the URLs allowed by safeReadmeURL, so the output is safe to embed in a page as-is.
+func RenderReadme(src string) string {
+	var b strings.Builder
+	renderReadmeBlocks(&b, strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n"), 0)
+	return b.String()
+}
+
+func renderReadmeBlocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		level, heading, isHeading := readmeHeading(line)
+		_, ordered, start, isItem := readmeListItem(line)
+
+		switch {
+		case trimmed == "":
+			i++
+
+		case readmeFence(line) != "":
+			fence := readmeFence(line)
+			lang := strings.Fields(strings.TrimLeft(trimmed, fence[:1]) + " ")
+			var code []string
+			for i++; i < len(lines) && !readmeFenceCloses(lines[i], fence); i++ {
+				code = append(code, lines[i])
+			}
+			i++ // the closing fence
+			b.WriteString("<pre><code")
+			if len(lang) > 0 && readmeLanguageRe.MatchString(lang[0]) {
+				fmt.Fprintf(b, ` class="language-%s"`, html.EscapeString(lang[0]))
+			}
+			b.WriteString(">")
+			for _, l := range code {
+				b.WriteString(html.EscapeString(l) + "\n")
+			}
+			b.WriteString("</code></pre>\n")
+
+		case isHeading:
+			fmt.Fprintf(b, "<h%d>", level)
+			renderReadmeInline(b, heading, 0, false)
+			fmt.Fprintf(b, "</h%d>\n", level)
+			i++
+
+		case readmeRule(line):
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">") && depth < maxReadmeNesting:
+			var quoted []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					break
+				}
+				quoted = append(quoted, strings.TrimPrefix(t[1:], " "))
+			}
+			b.WriteString("<blockquote>\n")
+			renderReadmeBlocks(b, quoted, depth+1)
+			b.WriteString("</blockquote>\n")
+
+		case isItem:
+			var items []string
+			for i < len(lines) {
+				text, o, _, ok := readmeListItem(lines[i])
+				if ok && o == ordered {
+					items = append(items, text)
+					i++
+					continue
+				}
+				// A line that starts nothing else continues the item
+				if ok || readmeStartsBlock(lines, i, depth) {
+					break
+				}
+				items[len(items)-1] += "\n" + strings.TrimSpace(lines[i])
+				i++
+			}
+			tag := "ul"
+			if ordered {
+				tag = "ol"
+			}
+			if ordered && start != 1 {
+				fmt.Fprintf(b, "<ol start=\"%d\">\n", start)
+			} else {
+				b.WriteString("<" + tag + ">\n")
+			}
+			for _, item := range items {
+				b.WriteString("<li>")
+				renderReadmeInline(b, item, 0, false)
+				b.WriteString("</li>\n")
+			}
+			b.WriteString("</" + tag + ">\n")
+
+		case readmeTableStarts(lines, i):
+			header := splitReadmeRow(line)
+			aligns := make([]string, len(header))
+			for c, sep := range splitReadmeRow(lines[i+1]) {
+				if c >= len(aligns) {
+					break
+				}
+				left, right := strings.HasPrefix(sep, ":"), strings.HasSuffix(sep, ":")
+				switch {
+				case left && right:
+					aligns[c] = "center"
+				case left:
+					aligns[c] = "left"
+				case right:
+					aligns[c] = "right"
+				}
+			}
+			writeRow := func(cells []string, tag string) {
+				b.WriteString("<tr>")
+				for c, align := range aligns {
+					if align != "" {
+						fmt.Fprintf(b, `<%s align="%s">`, tag, align)
+					} else {
+						b.WriteString("<" + tag + ">")
+					}
+					if c < len(cells) {
+						renderReadmeInline(b, cells[c], 0, false)
+					}
+					b.WriteString("</" + tag + ">")
+				}
+				b.WriteString("</tr>\n")
+			}
+
+			b.WriteString("<table>\n<thead>\n")
+			writeRow(header, "th")
+			b.WriteString("</thead>\n")
+			i += 2
+			if i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+				b.WriteString("<tbody>\n")
+				for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+					writeRow(splitReadmeRow(lines[i]), "td")
+				}
+				b.WriteString("</tbody>\n")
+			}
+			b.WriteString("</table>\n")
+
+		default:
+			paragraph := []string{trimmed}
+			for i++; i < len(lines) && !readmeStartsBlock(lines, i, depth); i++ {
+				paragraph = append(paragraph, strings.TrimSpace(lines[i]))
+			}
+			b.WriteString("<p>")
+			renderReadmeInline(b, strings.Join(paragraph, "\n"), 0, false)
+			b.WriteString("</p>\n")
+		}
+	}
+}
+
+// readmeStartsBlock reports whether lines[i] is blank or starts a block other than a paragraph.
+func readmeStartsBlock(lines []string, i, depth int) bool {
+	line := lines[i]
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || readmeFence(line) != "" || readmeRule(line) || readmeTableStarts(lines, i) {
+		return true
+	}
+	if _, _, ok := readmeHeading(line); ok {
+		return true
+	}
+	if _, _, _, ok := readmeListItem(line); ok {
+		return true
+	}
+	return strings.HasPrefix(trimmed, ">") && depth < maxReadmeNesting
+}
+
+// readmeFence returns the run of three or more backticks or tildes opening a fenced code block
+// on line, or "" when line doesn't open one.
+func readmeFence(line string) string {
+	t := strings.TrimSpace(line)
+	if !strings.HasPrefix(t, "```") && !strings.HasPrefix(t, "~~~") {
+		return ""
+	}
+	n := len(t) - len(strings.TrimLeft(t, t[:1]))
+	if t[0] == '`' && strings.Contains(t[n:], "`") {
+		return "" // an inline code span
+	}
+	return t[:n]
+}
+
+// readmeFenceCloses reports whether line closes the code block opened with fence.
+func readmeFenceCloses(line, fence string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == ""
+}
+
+func readmeHeading(line string) (level int, text string, ok bool) {
+	t := strings.TrimSpace(line)
+	for level < len(t) && t[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(t) && t[level] != ' ' && t[level] != '\t') {
+		return 0, "", false
+	}
+	text = strings.TrimSpace(t[level:])
+	// Drop a closing sequence of #s, as in "## Keybinds ##"
+	if closed := strings.TrimRight(text, "#"); closed == "" || strings.HasSuffix(closed, " ") {
+		text = strings.TrimSpace(closed)
+	}
+	return level, text, true
+}
+
+func readmeRule(line string) bool {
+	t := strings.Join(strings.Fields(line), "")
+	return len(t) >= 3 && (strings.Trim(t, "-") == "" || strings.Trim(t, "*") == "" || strings.Trim(t, "_") == "")
+}
+
+// readmeListItem reports whether line is a list item, and returns its text, whether the list
+// is ordered and the number of an ordered item.
+func readmeListItem(line string) (text string, ordered bool, number int, ok bool) {
+	t := strings.TrimLeft(line, " \t")
+	if len(t) >= 2 && strings.IndexByte("-*+", t[0]) >= 0 && (t[1] == ' ' || t[1] == '\t') {
+		return strings.TrimSpace(t[2:]), false, 0, true
+	}
+	n := 0
+	for n < len(t) && n < 9 && t[n] >= '0' && t[n] <= '9' {
+		n++
+	}
+	if n > 0 && n+1 < len(t) && (t[n] == '.' || t[n] == ')') && (t[n+1] == ' ' || t[n+1] == '\t') {
+		number, _ = strconv.Atoi(t[:n])
+		return strings.TrimSpace(t[n+2:]), true, number, true
+	}
+	return "", false, 0, false
+}
+
+func readmeTableStarts(lines []string, i int) bool {
+	return i+1 < len(lines) && strings.Contains(lines[i], "|") && strings.Contains(lines[i+1], "|") &&
+		readmeTableSepRe.MatchString(lines[i+1])
+}
+
+// splitReadmeRow splits a table row into its trimmed cells. \| is a | inside a cell.
+func splitReadmeRow(line string) []string {
+	t := strings.TrimPrefix(strings.TrimSpace(line), "|")
+	if strings.HasSuffix(t, "|") && !strings.HasSuffix(t, `\|`) {
+		t = t[:len(t)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(t); i++ {
+		switch {
+		case t[i] == '\\' && i+1 < len(t) && t[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case t[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(t[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// renderReadmeInline writes the escaped text of s with its inline markup. Links aren't parsed
+// inside a link's text.
+func renderReadmeInline(b *strings.Builder, s string, depth int, inLink bool) {
+	text := 0 // start of the text not written yet
+	flush := func(end int) {
+		b.WriteString(html.EscapeString(s[text:end]))
+	}
+	markup := depth < maxReadmeNesting
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte(readmeEscapable, s[i+1]) >= 0:
+			flush(i)
+			text = i + 1 // the escaped character is written as text
+			i += 2
+			continue
+
+		case c == '`':
+			n := len(s[i:]) - len(strings.TrimLeft(s[i:], "`"))
+			end := strings.Index(s[i+n:], s[i:i+n])
+			if end < 0 {
+				i += n
+				continue
+			}
+			code := strings.ReplaceAll(s[i+n:i+n+end], "\n", " ")
+			if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+				code = code[1 : len(code)-1]
+			}
+			flush(i)
+			b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+			i += n + end + n
+			text = i
+			continue
+
+		case (c == '*' || c == '_') && markup:
+			if end, inner, tag, ok := readmeEmphasis(s, i); ok {
+				flush(i)
+				b.WriteString("<" + tag + ">")
+				renderReadmeInline(b, inner, depth+1, inLink)
+				b.WriteString("</" + tag + ">")
+				i, text = end, end
+				continue
+			}
+
+		case c == '!' && markup && !inLink && i+1 < len(s) && s[i+1] == '[':
+			if alt, dest, end, ok := readmeLink(s, i+1); ok {
+				flush(i)
+				if safeReadmeURL(dest, true) {
+					fmt.Fprintf(b, `<img src="%s" alt="%s">`, html.EscapeString(dest), html.EscapeString(alt))
+				} else {
+					b.WriteString(html.EscapeString(alt))
+				}
+				i, text = end, end
+				continue
+			}
+
+		case c == '[' && markup && !inLink:
+			if label, dest, end, ok := readmeLink(s, i); ok {
+				flush(i)
+				safe := safeReadmeURL(dest, false)
+				if safe {
+					fmt.Fprintf(b, `<a href="%s" rel="nofollow noopener noreferrer">`, html.EscapeString(dest))
+				}
+				renderReadmeInline(b, label, depth+1, true)
+				if safe {
+					b.WriteString("</a>")
+				}
+				i, text = end, end
+				continue
+			}
+
+		case c == '<' && !inLink:
+			if m := readmeAutolinkStartRe.FindStringSubmatch(s[i:]); m != nil && safeReadmeURL(m[1], false) {
+				flush(i)
+				link := html.EscapeString(m[1])
+				fmt.Fprintf(b, `<a href="%s" rel="nofollow noopener noreferrer">%s</a>`, link, link)
+				i += len(m[0])
+				text = i
+				continue
+			}
+		}
+		i++
+	}
+	flush(len(s))
+}
+
+// readmeEmphasis parses the emphasis opening at s[i], returning where it ends, the text inside
+// and the tag to wrap it in. Like in CommonMark, _ doesn't emphasize inside words.
+func readmeEmphasis(s string, i int) (end int, inner, tag string, ok bool) {
+	c := s[i]
+	if c == '_' && i > 0 && isReadmeWordByte(s[i-1]) {
+		return 0, "", "", false
+	}
+	delim, tag := s[i:i+1], "em"
+	if i+1 < len(s) && s[i+1] == c {
+		delim, tag = s[i:i+2], "strong"
+	}
+	rest := s[i+len(delim):]
+	j := strings.Index(rest, delim)
+	if j <= 0 {
+		return 0, "", "", false
+	}
+	inner = rest[:j]
+	if unicode.IsSpace(rune(inner[0])) || unicode.IsSpace(rune(inner[len(inner)-1])) {
+		return 0, "", "", false
+	}
+	end = i + len(delim) + j + len(delim)
+	if c == '_' && end < len(s) && isReadmeWordByte(s[end]) {
+		return 0, "", "", false
+	}
+	return end, inner, tag, true
+}
+
+func isReadmeWordByte(c byte) bool {
+	return c >= 0x80 || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// readmeLink parses the [text](destination "title") at s[i], returning the text, the destination
+// and where the link ends.
+func readmeLink(s string, i int) (label, dest string, end int, ok bool) {
+	depth := 0
+	for j := i; j < len(s) && j-i <= maxReadmeLinkLength; j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '[':
+			depth++
+		case ']':
+			if depth--; depth > 0 {
+				continue
+			}
+			if j+1 >= len(s) || s[j+1] != '(' {
+				return "", "", 0, false
+			}
+			dest, end, ok = readmeLinkDest(s, j+2)
+			return s[i+1 : j], dest, end, ok
+		}
+	}
+	return "", "", 0, false
+}
+
+// readmeLinkDest parses the destination and optional title of a link starting at s[i], up to
+// and including the closing parenthesis.
+func readmeLinkDest(s string, i int) (dest string, end int, ok bool) {
+	limit := min(len(s), i+maxReadmeLinkLength)
+	skipSpaces := func(j int) int {
+		for j < limit && (s[j] == ' ' || s[j] == '\t' || s[j] == '\n') {
+			j++
+		}
+		return j
+	}
+
+	j := skipSpaces(i)
+	if j < limit && s[j] == '<' {
+		k := strings.IndexAny(s[j+1:limit], "<>\n")
+		if k < 0 || s[j+1+k] != '>' {
+			return "", 0, false
+		}
+		dest, j = s[j+1:j+1+k], j+k+2
+	} else {
+		start, depth := j, 0
+	dest:
+		for ; j < limit; j++ {
+			switch s[j] {
+			case ' ', '\t', '\n':
+				break dest
+			case '(':
+				depth++
+			case ')':
+				if depth == 0 {
+					break dest
+				}
+				depth--
+			}
+		}
+		dest = s[start:j]
+	}
+
+	j = skipSpaces(j)
+	if j < limit && (s[j] == '"' || s[j] == '\'' || s[j] == '(') {
+		closer := s[j]
+		if closer == '(' {
+			closer = ')'
+		}
+		k := strings.IndexByte(s[j+1:limit], closer)
+		if k < 0 {
+			return "", 0, false
+		}
+		j = skipSpaces(j + k + 2)
+	}
+	if j >= limit || s[j] != ')' {
+		return "", 0, false
+	}
+	return dest, j + 1, true
+}
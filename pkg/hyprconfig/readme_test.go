@@ -0,0 +1,180 @@
+package hyprconfig
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRenderReadme(t *testing.T) {
+	for _, tt := range []struct {
+		name, md, want string
+	}{
+		{"heading", "## Keybinds ##", "<h2>Keybinds</h2>\n"},
+		{"not a heading", "#hashtag", "<p>#hashtag</p>\n"},
+		{"paragraph", "Dark *and* **minimal**,\n`kitty` + _waybar_ snake_case_name",
+			"<p>Dark <em>and</em> <strong>minimal</strong>,\n<code>kitty</code> + <em>waybar</em> snake_case_name</p>\n"},
+		{"escapes", `\*not em\* & 1 < 2`, "<p>*not em* &amp; 1 &lt; 2</p>\n"},
+		{"link", `[site](https://hypr.land "Hyprland") and <https://wiki.hypr.land>`,
+			`<p><a href="https://hypr.land" rel="nofollow noopener noreferrer">site</a> and <a href="https://wiki.hypr.land" rel="nofollow noopener noreferrer">https://wiki.hypr.land</a></p>` + "\n"},
+		{"image", "![shot](screenshots/desktop.png)", `<p><img src="screenshots/desktop.png" alt="shot"></p>` + "\n"},
+		{"fenced code", "```conf\nbind = SUPER, Q, exec, kitty\n```", `<pre><code class="language-conf">bind = SUPER, Q, exec, kitty` + "\n</code></pre>\n"},
+		{"lists", "- one\n- two\n  continued\n\n3. three\n4. four",
+			"<ul>\n<li>one</li>\n<li>two\ncontinued</li>\n</ul>\n<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"quote and rule", "> quoted\n> > nested\n\n---", "<blockquote>\n<p>quoted</p>\n<blockquote>\n<p>nested</p>\n</blockquote>\n</blockquote>\n<hr>\n"},
+		{"table", "| Key | Action |\n|:---|---:|\n| `SUPER+Q` | kitty \\| foot |",
+			"<table>\n<thead>\n<tr><th align=\"left\">Key</th><th align=\"right\">Action</th></tr>\n</thead>\n<tbody>\n" +
+				"<tr><td align=\"left\"><code>SUPER+Q</code></td><td align=\"right\">kitty | foot</td></tr>\n</tbody>\n</table>\n"},
+	} {
+		if got := RenderReadme(tt.md); got != tt.want {
+			t.Errorf("%s: RenderReadme(%q) =\n%s\nwant\n%s", tt.name, tt.md, got, tt.want)
+		}
+	}
+}
+
+var (
+	renderedTagRe  = regexp.MustCompile(`<(/?)([^\s>/]*)([^>]*)>`)
+	renderedAttrRe = regexp.MustCompile(`\s([a-z]+)="([^"<>]*)"`)
+)
+
+// assertSafeHTML fails unless every tag in out is one RenderReadme writes, with only its attributes,
+// and every URL in them is one a README may link to.
+func assertSafeHTML(t *testing.T, md, out string) {
+	t.Helper()
+	allowed := map[string]map[string]bool{
+		"a": {"href": true, "rel": true}, "img": {"src": true, "alt": true},
+		"ol": {"start": true}, "th": {"align": true}, "td": {"align": true}, "code": {"class": true},
+	}
+	for _, tag := range strings.Fields("h1 h2 h3 h4 h5 h6 p em strong pre blockquote ul li hr table thead tbody tr") {
+		allowed[tag] = map[string]bool{}
+	}
+
+	for _, m := range renderedTagRe.FindAllStringSubmatch(out, -1) {
+		attrs, ok := allowed[m[2]]
+		if !ok {
+			t.Errorf("RenderReadme(%q) wrote a <%s> tag: %s", md, m[2], out)
+			continue
+		}
+		if rest := renderedAttrRe.ReplaceAllString(m[3], ""); rest != "" {
+			t.Errorf("RenderReadme(%q) wrote a <%s> tag with %q: %s", md, m[2], rest, out)
+		}
+		for _, attr := range renderedAttrRe.FindAllStringSubmatch(m[3], -1) {
+			value := strings.ToLower(html.UnescapeString(attr[2]))
+			switch {
+			case !attrs[attr[1]]:
+				t.Errorf("RenderReadme(%q) wrote a <%s> tag with %s: %s", md, m[2], attr[1], out)
+			case attr[1] == "href" || attr[1] == "src":
+				scheme := ""
+				if i := strings.IndexAny(value, ":/?#"); i >= 0 && value[i] == ':' {
+					scheme = value[:i]
+				}
+				if scheme != "" && scheme != "http" && scheme != "https" && (scheme != "mailto" || attr[1] == "src") {
+					t.Errorf("RenderReadme(%q) links to %q: %s", md, value, out)
+				}
+			}
+		}
+	}
+}
+
+func TestRenderReadmeXSS(t *testing.T) {
+	for _, md := range []string{
+		`<script>alert(1)</script>`,
+		`<img src=x onerror=alert(1)>`,
+		`<scr<script>ipt>alert(1)</script>`,
+		`<!-- <script>alert(1)</script> -->`,
+		`<a href="javascript:alert(1)">x</a>`,
+		`[x](javascript:alert(1))`,
+		`[x](JaVaScRiPt:alert(1))`,
+		`[x](  javascript:alert(1) "t")`,
+		`[x](<javascript:alert(1)>)`,
+		"[x](java\tscript:alert(1))",
+		`[x](javascript&#58;alert(1))`,
+		`[x](&#106;avascript:alert(1))`,
+		`[x](javascript&colon;alert(1))`,
+		`[x](javascript\:alert(1))`,
+		`[x](&amp;#106;avascript:alert(1))`,
+		`<&#106;avascript:alert(1)>`,
+		`[x](vbscript:msgbox(1))`,
+		`[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`,
+		`![x](data:image/svg+xml,<svg onload=alert(1)>)`,
+		`![x](mailto:a@b.c)`,
+		`<javascript:alert(1)>`,
+		`<data:text/html,<script>alert(1)</script>>`,
+		`[x](https://a.b/" onmouseover="alert(1))`,
+		`[x](https://a.b/'onmouseover='alert(1)')`,
+		`![" onerror="alert(1)](https://a.b/x.png)`,
+		`[<img src=x onerror=alert(1)>](https://a.b)`,
+		`[[x](javascript:alert(1))](https://a.b)`,
+		"```\"><script>alert(1)</script>\n</code></pre><script>alert(1)</script>\n```",
+		"`</code><script>alert(1)</script>`",
+		"# <svg onload=alert(1)>",
+		"| <b onclick=alert(1)>a</b> | b |\n|---|---|\n| [x](javascript:alert(1)) | <iframe src=//evil> |",
+		"> <style>body{display:none}</style>",
+		"- <object data=x></object>",
+		"**<script>**alert(1)**</script>**",
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		strings.Repeat("> ", 100) + "<script>alert(1)</script>",
+		strings.Repeat("*", 200) + "<script>" + strings.Repeat("[", 3000),
+	} {
+		assertSafeHTML(t, md, RenderReadme(md))
+		assertSafeHTML(t, md, RenderReadme(sanitizeReadme(md)))
+	}
+}
+
+func TestSanitizeReadme(t *testing.T) {
+	for _, tt := range []struct {
+		md, want string
+	}{
+		{"# Rice\n<div align=\"center\"><img src=x onerror=alert(1)></div>\n\nText", "# Rice\n\n\nText"},
+		{"<scr<script>ipt>alert(1)</script>", "alert(1)"},
+		{"a <!-- hidden --> b <!-- open", "a  b "},
+		{"[x](javascript:alert(1)) [y](https://hypr.land) [z](<JAVASCRIPT:x>)", "[x](#) [y](https://hypr.land) [z](#)"},
+		{"![x](data:image/png;base64,AAAA \"title\")", "![x](# \"title\")"},
+		{"<javascript:alert(1)> <https://hypr.land>", " <https://hypr.land>"},
+		// Markdown renderers decode entities and escapes in destinations
+		{"[x](&#106;avascript:alert(1)) [y](javascript&colon;alert(1)) [z](java\\script\\:x)", "[x](#) [y](#) [z](#)"},
+		{"[x](https://hypr.land/?a=1&amp;b=2) [y](docs/a\\_b.md)", "[x](https://hypr.land/?a=1&amp;b=2) [y](docs/a\\_b.md)"},
+		{"1 < 2 and 3 > 2", "1 < 2 and 3 > 2"},
+		// Code blocks are kept as written
+		{"```html\n<div>panel</div>\n```\n<b>bold</b>", "```html\n<div>panel</div>\n```\nbold"},
+	} {
+		if got := sanitizeReadme(tt.md); got != tt.want {
+			t.Errorf("sanitizeReadme(%q) = %q, want %q", tt.md, got, tt.want)
+		}
+	}
+}
+
+func TestReadmeSanitizedOnWrite(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		cfg, err := m.CreateConfig(alice, &HyprConfig{
+			Title:          "rice",
+			Readme:         "# Rice <script>alert(1)</script>\n[docs](javascript:alert(1))",
+			ProgramConfigs: []HyprProgramConfig{{ID: "term", Title: "term", Program: "kitty"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "# Rice alert(1)\n[docs](#)"; cfg.Readme != want {
+			t.Errorf("created readme = %q, want %q", cfg.Readme, want)
+		}
+
+		if err := m.UpdateConfig(alice, cfg.ID, map[string]any{"readme": "<b>bold</b> text"}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := m.GetConfig(alice, cfg.ID); err != nil || got.Readme != "bold text" {
+			t.Errorf("updated readme = %q, %v", got.Readme, err)
+		}
+		if page, err := m.ListConfigs(alice, 1, 10, nil); err != nil || len(page.Items) != 1 || page.Items[0].Readme != "" {
+			t.Errorf("listed configs should leave out the readme: %+v, %v", page.Items, err)
+		}
+
+		err = m.UpdateConfig(alice, cfg.ID, map[string]any{"readme": strings.Repeat("a", MaxReadmeBytes+1)}, UpdateOptions{})
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "readme" || verr.Errors[0].Code != CodeTooLong {
+			t.Errorf("oversized readme: got %v, want a too_long readme error", err)
+		}
+	})
+}
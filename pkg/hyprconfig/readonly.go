@@ -0,0 +1,287 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrReadOnly is returned by every mutating method of a ReadOnlyConfigManager while read-only
+// mode is on.
+var ErrReadOnly = errors.New("server is in read-only maintenance mode, try again later")
+
+// ReadOnlyConfigManager wraps a ConfigManager with a maintenance switch. While it is on, reads
+// keep working and writes fail with ErrReadOnly; webhook deliveries are paused.
+type ReadOnlyConfigManager struct {
+	ConfigManager
+
+	readOnly atomic.Bool
+}
+
+// NewReadOnlyConfigManager wraps next, starting in read-only mode when readOnly is set.
+func NewReadOnlyConfigManager(next ConfigManager, readOnly bool) *ReadOnlyConfigManager {
+	m := &ReadOnlyConfigManager{ConfigManager: next}
+	m.readOnly.Store(readOnly)
+	return m
+}
+
+// ReadOnly reports whether writes are rejected.
+func (m *ReadOnlyConfigManager) ReadOnly() bool {
+	return m.readOnly.Load()
+}
+
+// SetReadOnly turns read-only mode on or off.
+func (m *ReadOnlyConfigManager) SetReadOnly(readOnly bool) {
+	m.readOnly.Store(readOnly)
+}
+
+// check returns ErrReadOnly while read-only mode is on.
+func (m *ReadOnlyConfigManager) check() error {
+	if m.readOnly.Load() {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+func (m *ReadOnlyConfigManager) CreateConfig(ctx context.Context, cfg *HyprConfig) (*HyprConfig, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.CreateConfig(ctx, cfg)
+}
+
+func (m *ReadOnlyConfigManager) AddGalleryImage(ctx context.Context, configID string, data []byte) (*GalleryImage, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.AddGalleryImage(ctx, configID, data)
+}
+
+func (m *ReadOnlyConfigManager) RemoveGalleryImage(ctx context.Context, configID string, index int) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.RemoveGalleryImage(ctx, configID, index)
+}
+
+func (m *ReadOnlyConfigManager) UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.UpdateConfig(ctx, id, updates, opts)
+}
+
+func (m *ReadOnlyConfigManager) DeleteConfig(ctx context.Context, id string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.DeleteConfig(ctx, id)
+}
+
+func (m *ReadOnlyConfigManager) VerifyConfig(ctx context.Context, configID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.VerifyConfig(ctx, configID)
+}
+
+func (m *ReadOnlyConfigManager) UnverifyConfig(ctx context.Context, configID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.UnverifyConfig(ctx, configID)
+}
+
+func (m *ReadOnlyConfigManager) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.DeprecateConfig(ctx, configID, successorID, message)
+}
+
+func (m *ReadOnlyConfigManager) FavoriteConfig(ctx context.Context, configID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.FavoriteConfig(ctx, configID)
+}
+
+func (m *ReadOnlyConfigManager) FollowAuthor(ctx context.Context, ownerID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.FollowAuthor(ctx, ownerID)
+}
+
+func (m *ReadOnlyConfigManager) UnfollowAuthor(ctx context.Context, ownerID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.UnfollowAuthor(ctx, ownerID)
+}
+
+func (m *ReadOnlyConfigManager) MarkNotificationsRead(ctx context.Context, ids []string) (int64, error) {
+	if err := m.check(); err != nil {
+		return 0, err
+	}
+	return m.ConfigManager.MarkNotificationsRead(ctx, ids)
+}
+
+func (m *ReadOnlyConfigManager) UnfavoriteConfig(ctx context.Context, configID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.UnfavoriteConfig(ctx, configID)
+}
+
+func (m *ReadOnlyConfigManager) ApplyConfig(ctx context.Context, configID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.ApplyConfig(ctx, configID)
+}
+
+func (m *ReadOnlyConfigManager) AddProgramConfig(ctx context.Context, configID string, newProg HyprProgramConfig, parentID *string, changelog string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.AddProgramConfig(ctx, configID, newProg, parentID, changelog)
+}
+
+func (m *ReadOnlyConfigManager) RemoveProgramConfig(ctx context.Context, configID string, progID string, changelog string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.RemoveProgramConfig(ctx, configID, progID, changelog)
+}
+
+func (m *ReadOnlyConfigManager) MoveProgramConfig(ctx context.Context, configID string, progID string, newParentID *string, changelog string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.MoveProgramConfig(ctx, configID, progID, newParentID, changelog)
+}
+
+func (m *ReadOnlyConfigManager) UpdateProgramConfig(ctx context.Context, configID string, progID string, updates HyprProgramConfig, changelog string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.UpdateProgramConfig(ctx, configID, progID, updates, changelog)
+}
+
+func (m *ReadOnlyConfigManager) AddAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.AddAllowedProgram(ctx, programName)
+}
+
+func (m *ReadOnlyConfigManager) RemoveAllowedProgram(ctx context.Context, programName string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.RemoveAllowedProgram(ctx, programName)
+}
+
+func (m *ReadOnlyConfigManager) ImportAllowedPrograms(ctx context.Context, programs []AllowedPrograms, upsert bool) ([]ProgramImportResult, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.ImportAllowedPrograms(ctx, programs, upsert)
+}
+
+func (m *ReadOnlyConfigManager) SeedDefaultPrograms(ctx context.Context) ([]ProgramImportResult, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.SeedDefaultPrograms(ctx)
+}
+
+func (m *ReadOnlyConfigManager) RequestAllowedProgram(ctx context.Context, programName string, reason string) (*ProgramRequest, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.RequestAllowedProgram(ctx, programName, reason)
+}
+
+func (m *ReadOnlyConfigManager) ApproveProgramRequest(ctx context.Context, requestID string) (*AllowedPrograms, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.ApproveProgramRequest(ctx, requestID)
+}
+
+func (m *ReadOnlyConfigManager) RejectProgramRequest(ctx context.Context, requestID string, note string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.RejectProgramRequest(ctx, requestID, note)
+}
+
+func (m *ReadOnlyConfigManager) CreateWebhook(ctx context.Context, req WebhookRequest) (*Webhook, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.CreateWebhook(ctx, req)
+}
+
+func (m *ReadOnlyConfigManager) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.DeleteWebhook(ctx, webhookID)
+}
+
+// DeliverPendingWebhooks attempts nothing while read-only mode is on, as recording a delivery
+// is a write. Deliveries that came due resume once it is off.
+func (m *ReadOnlyConfigManager) DeliverPendingWebhooks(ctx context.Context) (int, error) {
+	if m.readOnly.Load() {
+		return 0, nil
+	}
+	return m.ConfigManager.DeliverPendingWebhooks(ctx)
+}
+
+func (m *ReadOnlyConfigManager) CreateAPIKey(ctx context.Context, name string, scopes []string, expiry time.Duration) (*CreatedAPIKey, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.CreateAPIKey(ctx, name, scopes, expiry)
+}
+
+func (m *ReadOnlyConfigManager) RevokeAPIKey(ctx context.Context, keyID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.RevokeAPIKey(ctx, keyID)
+}
+
+func (m *ReadOnlyConfigManager) CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (*CreatedShareLink, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.CreateShareLink(ctx, configID, ttl)
+}
+
+func (m *ReadOnlyConfigManager) RevokeShareLink(ctx context.Context, configID, linkID string) error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	return m.ConfigManager.RevokeShareLink(ctx, configID, linkID)
+}
+
+func (m *ReadOnlyConfigManager) SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (*QuotaUsage, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.SetUserQuota(ctx, userID, quotaBytes)
+}
+
+func (m *ReadOnlyConfigManager) SetUserConfigLimit(ctx context.Context, userID string, limit int64) (*ConfigLimit, error) {
+	if err := m.check(); err != nil {
+		return nil, err
+	}
+	return m.ConfigManager.SetUserConfigLimit(ctx, userID, limit)
+}
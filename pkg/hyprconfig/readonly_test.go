@@ -0,0 +1,40 @@
+package hyprconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnlyConfigManager(t *testing.T) {
+	forEachManager(t, func(t *testing.T, next ConfigManager) {
+		ctx := asUser("alice")
+		m := NewReadOnlyConfigManager(next, false)
+		cfg := newTestConfig(t, m, "alice", false)
+
+		m.SetReadOnly(true)
+		if _, err := m.CreateConfig(ctx, &HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{{Title: "term", Program: "kitty"}}}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("create: got %v, want ErrReadOnly", err)
+		}
+		if err := m.UpdateConfig(ctx, cfg.ID, map[string]any{"title": "renamed"}, UpdateOptions{}); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("update: got %v, want ErrReadOnly", err)
+		}
+		if err := m.AddProgramConfig(ctx, cfg.ID, HyprProgramConfig{Title: "bar", Program: "waybar"}, nil, ""); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("add program: got %v, want ErrReadOnly", err)
+		}
+		if err := m.DeleteConfig(ctx, cfg.ID); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("delete: got %v, want ErrReadOnly", err)
+		}
+		if n, err := m.DeliverPendingWebhooks(ctx); n != 0 || err != nil {
+			t.Errorf("webhook deliveries: got %d, %v, want them paused", n, err)
+		}
+		got, err := m.GetConfig(ctx, cfg.ID)
+		if err != nil || got.Title != "rice" {
+			t.Fatalf("read while read-only: %v, %v", got, err)
+		}
+
+		m.SetReadOnly(false)
+		if err := m.UpdateConfig(ctx, cfg.ID, map[string]any{"title": "renamed"}, UpdateOptions{}); err != nil {
+			t.Errorf("update after turning read-only off: %v", err)
+		}
+	})
+}
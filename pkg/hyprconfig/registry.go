@@ -0,0 +1,137 @@
+package hyprconfig
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultProgramCacheTTL is how long the Mongo config manager trusts what the database said about
+// a program name before it asks again.
+const DefaultProgramCacheTTL = 30 * time.Second
+
+// ProgramRegistry decides which programs configs may use: the built-in programs, extended by the
+// ones allowed in the database. Database answers, allowed or not, are cached for the TTL so
+// validating a config does not query the database for every program it names.
+type ProgramRegistry struct {
+	lookup ProgramsChecker                             // nil allows only the built-in programs
+	list   func(ctx context.Context) ([]string, error) // nil lists only the built-in programs
+	ttl    time.Duration                               // 0 disables the cache
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]programCacheEntry
+}
+
+type programCacheEntry struct {
+	allowed bool
+	expires time.Time
+}
+
+// NewProgramRegistry creates a registry looking names up with lookup and listing the database
+// programs with list. Either may be nil when there is no database allowlist.
+func NewProgramRegistry(lookup ProgramsChecker, list func(ctx context.Context) ([]string, error), ttl time.Duration) *ProgramRegistry {
+	return &ProgramRegistry{
+		lookup: lookup,
+		list:   list,
+		ttl:    ttl,
+		now:    time.Now,
+		cache:  map[string]programCacheEntry{},
+	}
+}
+
+// builtinProgram reports whether name is one of the programs every deployment allows.
+func builtinProgram(name string) bool {
+	_, ok := validPrograms[name]
+	return ok
+}
+
+// IsAllowed reports whether configs may use the program name.
+func (r *ProgramRegistry) IsAllowed(ctx context.Context, name string) (bool, error) {
+	name = NormalizeProgramName(name)
+	allowed, err := r.Allowed(ctx, []string{name})
+	if err != nil {
+		return false, err
+	}
+	_, ok := allowed[name]
+	return ok, nil
+}
+
+// Allowed returns the names, out of names, that configs may use. Names missing from the cache
+// are looked up together. It is the ProgramsChecker Validate is called with.
+func (r *ProgramRegistry) Allowed(ctx context.Context, names []string) (map[string]struct{}, error) {
+	allowed := map[string]struct{}{}
+	var missing []string
+
+	r.mu.Lock()
+	now := r.now()
+	for _, name := range names {
+		if builtinProgram(name) {
+			allowed[name] = struct{}{}
+			continue
+		}
+		if e, ok := r.cache[name]; ok && now.Before(e.expires) {
+			if e.allowed {
+				allowed[name] = struct{}{}
+			}
+			continue
+		}
+		missing = append(missing, name)
+	}
+	r.mu.Unlock()
+
+	if len(missing) == 0 || r.lookup == nil {
+		return allowed, nil
+	}
+	found, err := r.lookup(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for name := range found {
+		allowed[name] = struct{}{}
+	}
+
+	if r.ttl > 0 {
+		r.mu.Lock()
+		expires := r.now().Add(r.ttl)
+		for _, name := range missing {
+			_, ok := found[name]
+			r.cache[name] = programCacheEntry{allowed: ok, expires: expires}
+		}
+		r.mu.Unlock()
+	}
+	return allowed, nil
+}
+
+// List returns every program configs may use, sorted by name.
+func (r *ProgramRegistry) List(ctx context.Context) ([]string, error) {
+	set := make(map[string]struct{}, len(validPrograms))
+	for name := range validPrograms {
+		set[name] = struct{}{}
+	}
+	if r.list != nil {
+		names, err := r.list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			set[name] = struct{}{}
+		}
+	}
+	return sortedSet(set), nil
+}
+
+// Invalidate drops the cached answers for names, or the whole cache when none are given. The
+// allowlist mutations call it so their own instance sees a change at once; other instances
+// see it once the TTL has passed.
+func (r *ProgramRegistry) Invalidate(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(names) == 0 {
+		r.cache = map[string]programCacheEntry{}
+		return
+	}
+	for _, name := range names {
+		delete(r.cache, NormalizeProgramName(name))
+	}
+}
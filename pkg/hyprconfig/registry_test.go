@@ -0,0 +1,98 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProgramRegistryCache(t *testing.T) {
+	ctx := context.Background()
+	db := map[string]struct{}{"my-tool": {}}
+	lookups := 0
+	lookup := func(ctx context.Context, names []string) (map[string]struct{}, error) {
+		lookups++
+		found := map[string]struct{}{}
+		for _, name := range names {
+			if _, ok := db[name]; ok {
+				found[name] = struct{}{}
+			}
+		}
+		return found, nil
+	}
+	now := time.Unix(0, 0)
+	r := NewProgramRegistry(lookup, nil, time.Minute)
+	r.now = func() time.Time { return now }
+
+	got, err := r.Allowed(ctx, []string{"kitty", "my-tool", "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, map[string]struct{}{"kitty": {}, "my-tool": {}}) {
+		t.Errorf("Allowed = %v, want the built-in kitty and my-tool from the database", got)
+	}
+	if ok, _ := r.IsAllowed(ctx, "My-Tool"); !ok || lookups != 1 {
+		t.Errorf("cached my-tool: allowed %v after %d lookups, want true after 1", ok, lookups)
+	}
+
+	// Removed from the database, but the cached answer holds until it expires
+	delete(db, "my-tool")
+	if ok, _ := r.IsAllowed(ctx, "my-tool"); !ok {
+		t.Error("my-tool was looked up again before the cache expired")
+	}
+	now = now.Add(time.Minute)
+	if ok, _ := r.IsAllowed(ctx, "my-tool"); ok || lookups != 2 {
+		t.Errorf("expired my-tool: allowed %v after %d lookups, want false after 2", ok, lookups)
+	}
+
+	db["other"] = struct{}{}
+	r.Invalidate("other")
+	if ok, _ := r.IsAllowed(ctx, "other"); !ok {
+		t.Error("other is still denied after Invalidate")
+	}
+
+	if _, err := NewProgramRegistry(func(context.Context, []string) (map[string]struct{}, error) {
+		return nil, errors.New("down")
+	}, nil, time.Minute).IsAllowed(ctx, "my-tool"); err == nil {
+		t.Error("lookup error was swallowed")
+	}
+}
+
+func TestProgramRegistryList(t *testing.T) {
+	r := NewProgramRegistry(nil, func(context.Context) ([]string, error) {
+		return []string{"my-tool", "kitty"}, nil
+	}, 0)
+	names, err := r.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != len(validPrograms)+1 {
+		t.Errorf("List returned %d names, want the %d built-in ones and my-tool", len(names), len(validPrograms))
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i-1] >= names[i] {
+			t.Fatalf("List is not sorted and unique: %v", names)
+		}
+	}
+}
+
+func TestManagerProgramOnlyInDatabase(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		admin := asUser("root", "admin")
+		rice := &HyprConfig{Title: "rice", ProgramConfigs: []HyprProgramConfig{{Title: "tool", Program: "my-tool"}}}
+		if _, err := m.CreateConfig(asUser("alice"), rice); !errors.Is(err, ErrValidation) {
+			t.Fatalf("before allowing: got %v, want ErrValidation", err)
+		}
+		if _, err := m.AddAllowedProgram(admin, "my-tool"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.CreateConfig(asUser("alice"), rice); err != nil {
+			t.Errorf("after allowing: %v", err)
+		}
+		if _, err := m.RequestAllowedProgram(asUser("alice"), "my-tool", ""); !errors.Is(err, ErrValidation) {
+			t.Errorf("requesting an allowed program: got %v, want ErrValidation", err)
+		}
+	})
+}
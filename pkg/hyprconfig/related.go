@@ -0,0 +1,199 @@
+package hyprconfig
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultRelatedConfigs = 10
+	MaxRelatedConfigs     = 50
+
+	// relatedCandidates bounds how many configs ListRelatedConfigs ranks, the most liked first.
+	relatedCandidates = 500
+)
+
+// relatedKeys are the lowercased tags and normalized program names of a config.
+type relatedKeys struct {
+	tags     map[string]bool
+	programs map[string]bool
+}
+
+func relatedKeysOf(cfg *HyprConfig) relatedKeys {
+	k := relatedKeys{tags: map[string]bool{}, programs: map[string]bool{}}
+	for _, tag := range cfg.Tags {
+		k.tags[strings.ToLower(tag)] = true
+	}
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		if pc.Program != "" {
+			k.programs[NormalizeProgramName(pc.Program)] = true
+		}
+	})
+	return k
+}
+
+// relatedFilter returns the tags and top-level program names a candidate must share one of.
+func relatedFilter(cfg *HyprConfig) (tags, programs []string) {
+	tags = append(tags, cfg.Tags...)
+	for _, pc := range cfg.ProgramConfigs {
+		if pc.Program != "" {
+			programs = append(programs, pc.Program)
+		}
+	}
+	return tags, programs
+}
+
+// jaccard is the size of the intersection of a and b over that of their union, 0 when both are empty.
+func jaccard(a, b map[string]bool) float64 {
+	shared := 0
+	for key := range a {
+		if b[key] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// rankRelated scores candidates against src by the Jaccard similarity of their tags plus that of
+// their program names, and returns the best limit of them. Ties go to the most liked, then by id.
+func rankRelated(src *HyprConfig, candidates []HyprConfig, limit int) []HyprConfig {
+	srcKeys := relatedKeysOf(src)
+	type scored struct {
+		cfg   HyprConfig
+		score float64
+	}
+	var ranked []scored
+	for _, cfg := range candidates {
+		if cfg.ID == src.ID || cfg.Private {
+			continue
+		}
+		keys := relatedKeysOf(&cfg)
+		score := jaccard(srcKeys.tags, keys.tags) + jaccard(srcKeys.programs, keys.programs)
+		if score > 0 {
+			ranked = append(ranked, scored{cfg, score})
+		}
+	}
+	slices.SortFunc(ranked, func(a, b scored) int {
+		if c := cmp.Compare(b.score, a.score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.cfg.Likes, a.cfg.Likes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.cfg.ID, b.cfg.ID)
+	})
+
+	related := make([]HyprConfig, 0, min(limit, len(ranked)))
+	for _, r := range ranked[:min(limit, len(ranked))] {
+		related = append(related, r.cfg)
+	}
+	return related
+}
+
+// relatedLimit applies the default and maximum to the limit of ListRelatedConfigs.
+func relatedLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultRelatedConfigs
+	}
+	return min(limit, MaxRelatedConfigs)
+}
+
+// ListRelatedConfigs returns the public configs most similar to configID by tags and programs,
+// best match first. The caller must be able to read configID.
+func (m *ConfigManagerMongo) ListRelatedConfigs(ctx context.Context, configID string, limit int) ([]HyprConfig, error) {
+	src, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	tags, programs := relatedFilter(src)
+	if len(tags) == 0 && len(programs) == 0 {
+		return []HyprConfig{}, nil
+	}
+
+	candidates, err := mserve.PaginateMongo[HyprConfig](
+		ctx,
+		m.Collection,
+		bson.M{
+			"_id":     bson.M{"$ne": configID},
+			"private": false,
+			"$or": bson.A{
+				bson.M{"tags": bson.M{"$in": tags}},
+				bson.M{"program_configs.program": bson.M{"$in": programs}},
+			},
+		},
+		1,
+		relatedCandidates,
+		listFindOptions(ctx, options.Find().SetSort(mongoSearchSort[SearchSortLikes])),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if candidates, err = decodeListForRead(ctx, candidates); err != nil {
+		return nil, err
+	}
+	return rankRelated(src, candidates.Items, relatedLimit(limit)), nil
+}
+
+func (m *ConfigManagerMemory) ListRelatedConfigs(ctx context.Context, configID string, limit int) ([]HyprConfig, error) {
+	src, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every public config is a candidate, rankRelated drops those sharing nothing
+	candidates, err := m.pageSorted(ctx, 1, relatedCandidates, SearchSortLikes, func(cfg *HyprConfig) bool {
+		return !cfg.Private && cfg.ID != configID
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rankRelated(src, candidates.Items, relatedLimit(limit)), nil
+}
+
+func (m *ConfigManagerSQLite) ListRelatedConfigs(ctx context.Context, configID string, limit int) ([]HyprConfig, error) {
+	src, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	tags, programs := relatedFilter(src)
+	if len(tags) == 0 && len(programs) == 0 {
+		return []HyprConfig{}, nil
+	}
+
+	// An empty IN list matches nothing, so either side may be empty
+	where := `private = 0 AND id != ? AND (
+		id IN (SELECT config_id FROM config_tags WHERE tag IN (` + sqlPlaceholders(len(tags)) + `))
+		OR EXISTS (SELECT 1 FROM json_each(configs.doc, '$.program_configs') p
+			WHERE json_extract(p.value, '$.program') IN (` + sqlPlaceholders(len(programs)) + `)))`
+	args := []any{configID}
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	for _, p := range programs {
+		args = append(args, p)
+	}
+
+	candidates, err := m.listConfigsSorted(ctx, where, args, 1, relatedCandidates, SearchSortLikes)
+	if err != nil {
+		return nil, err
+	}
+	return rankRelated(src, candidates.Items, relatedLimit(limit)), nil
+}
+
+// sqlPlaceholders returns n comma separated ? placeholders.
+func sqlPlaceholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return "?" + strings.Repeat(", ?", n-1)
+}
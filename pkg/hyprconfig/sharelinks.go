@@ -0,0 +1,198 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultShareLinkTTL is used when CreateShareLink is called without a ttl.
+	DefaultShareLinkTTL = 7 * 24 * time.Hour
+	MaxShareLinkTTL     = 30 * 24 * time.Hour
+)
+
+// ShareLink lets anyone holding its token read one config, even while it is private.
+// Only a hash of the token is stored; the token itself is returned once by CreateShareLink.
+type ShareLink struct {
+	ID        string `json:"id" bson:"_id"`
+	ConfigID  string `json:"config_id" bson:"config_id"`
+	CreatedBy string `json:"created_by" bson:"created_by"`
+	Hash      string `json:"-" bson:"hash"` // never returned
+
+	CreatedTimestamp time.Time  `json:"created_timestamp" bson:"created_timestamp"`
+	ExpiresAt        time.Time  `json:"expires_at" bson:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// CreatedShareLink is a new share link together with its token.
+type CreatedShareLink struct {
+	ShareLink
+	Token string `json:"token"`
+	URL   string `json:"url"` // relative URL of the shared config
+}
+
+// ShareURL returns the relative URL that reads configID with a share link token.
+func ShareURL(configID, token string) string {
+	return "/config/" + url.PathEscape(configID) + "?share=" + url.QueryEscape(token)
+}
+
+// active reports whether the link can still be used at now.
+func (l *ShareLink) active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// newShareLink validates ttl and generates a link to configID created by userID.
+func newShareLink(configID, userID string, ttl time.Duration) (*CreatedShareLink, error) {
+	if ttl == 0 {
+		ttl = DefaultShareLinkTTL
+	}
+	if ttl < 0 || ttl > MaxShareLinkTTL {
+		return nil, invalidf("share link ttl must be between 0 and %s", MaxShareLinkTTL)
+	}
+
+	token, err := newToken("")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &CreatedShareLink{
+		ShareLink: ShareLink{
+			ID:               uuid.NewString(),
+			ConfigID:         configID,
+			CreatedBy:        userID,
+			Hash:             hashToken(token),
+			CreatedTimestamp: now,
+			ExpiresAt:        now.Add(ttl),
+		},
+		Token: token,
+		URL:   ShareURL(configID, token),
+	}, nil
+}
+
+type shareTokenKey struct{}
+
+// WithShareToken returns a context whose config reads also accept the share link token.
+func WithShareToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, shareTokenKey{}, token)
+}
+
+// sharedRead decides a read of a config the caller may not see on their own. It returns nil
+// when ctx carries an active share link to configID, and ErrForbidden when it carries none.
+// Unknown, expired and revoked tokens are ErrNotFound so they do not confirm the config exists.
+// lookup returns the link with the given token hash, or ErrNotFound.
+func sharedRead(ctx context.Context, configID string, lookup func(hash string) (*ShareLink, error)) error {
+	token, _ := ctx.Value(shareTokenKey{}).(string)
+	if token == "" {
+		return ErrForbidden
+	}
+
+	link, err := lookup(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if link.ConfigID != configID || !link.active(time.Now()) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// shareLink returns the link with the given token hash.
+func (m *ConfigManagerMongo) shareLink(ctx context.Context, hash string) (*ShareLink, error) {
+	var link ShareLink
+	err := m.ShareLinksCollection.FindOne(ctx, bson.M{"hash": hash}).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to fetch share link: %w", err)
+	}
+	return &link, nil
+}
+
+// ownConfig checks that the signed-in user owns the config, or is an admin.
+func (m *ConfigManagerMongo) ownConfig(ctx context.Context, configID string) (string, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var cfg HyprConfig
+	err = m.Collection.FindOne(ctx, bson.M{"_id": configID},
+		options.FindOne().SetProjection(bson.M{"owner_id": 1}),
+	).Decode(&cfg)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", ErrNotFound
+	} else if err != nil {
+		return "", err
+	}
+	if !canWrite(&cfg, user) {
+		return "", ErrForbidden
+	}
+	return user.UserID, nil
+}
+
+// CreateShareLink creates a read-only link to a config, valid for ttl (DefaultShareLinkTTL when zero).
+func (m *ConfigManagerMongo) CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (_ *CreatedShareLink, err error) {
+	defer func() { m.logMutation(ctx, "CreateShareLink", err, slog.String("config_id", configID)) }()
+	userID, err := m.ownConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := newShareLink(configID, userID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.ShareLinksCollection.InsertOne(ctx, link.ShareLink); err != nil {
+		return nil, fmt.Errorf("failed to insert share link: %w", err)
+	}
+	return link, nil
+}
+
+// ListShareLinks returns the share links of a config, oldest first, including revoked and expired ones.
+func (m *ConfigManagerMongo) ListShareLinks(ctx context.Context, configID string) ([]ShareLink, error) {
+	if _, err := m.ownConfig(ctx, configID); err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.ShareLinksCollection.Find(ctx, bson.M{"config_id": configID},
+		options.Find().SetSort(bson.M{"created_timestamp": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	links := []ShareLink{}
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	return links, nil
+}
+
+// RevokeShareLink stops a share link from working. Revoking a revoked link does nothing.
+func (m *ConfigManagerMongo) RevokeShareLink(ctx context.Context, configID, linkID string) (err error) {
+	defer func() {
+		m.logMutation(ctx, "RevokeShareLink", err, slog.String("config_id", configID), slog.String("link_id", linkID))
+	}()
+	if _, err := m.ownConfig(ctx, configID); err != nil {
+		return err
+	}
+
+	res, err := m.ShareLinksCollection.UpdateOne(ctx,
+		bson.M{"_id": linkID, "config_id": configID},
+		[]bson.M{{"$set": bson.M{"revoked_at": bson.M{"$ifNull": bson.A{"$revoked_at", time.Now()}}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke share link: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerShareLinks(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		cfg := newTestConfig(t, m, "alice", true)
+		other := newTestConfig(t, m, "alice", true)
+		ctx := asUser("alice")
+
+		link, err := m.CreateShareLink(ctx, cfg.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Token == "" || link.URL != ShareURL(cfg.ID, link.Token) || link.CreatedBy != "alice" {
+			t.Errorf("created link = %+v", link)
+		}
+		if d := time.Until(link.ExpiresAt); d < DefaultShareLinkTTL-time.Minute || d > DefaultShareLinkTTL {
+			t.Errorf("default expiry in %s, want %s", d, DefaultShareLinkTTL)
+		}
+
+		for name, ctx := range map[string]context.Context{
+			"anonymous": WithShareToken(context.Background(), link.Token),
+			"bob":       WithShareToken(asUser("bob"), link.Token),
+		} {
+			if got, err := m.GetConfig(ctx, cfg.ID); err != nil || got.ID != cfg.ID {
+				t.Errorf("%s with token: got %v", name, err)
+			}
+		}
+		if _, err := m.GetConfig(asUser("bob"), cfg.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("bob without token: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.GetConfig(WithShareToken(asUser("bob"), "wrong"), cfg.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("wrong token: got %v, want ErrNotFound", err)
+		}
+		if _, err := m.GetConfig(WithShareToken(asUser("bob"), link.Token), other.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("token for another config: got %v, want ErrNotFound", err)
+		}
+
+		if _, err := m.CreateShareLink(asUser("bob"), cfg.ID, 0); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner create: got %v, want ErrForbidden", err)
+		}
+		if _, err := m.ListShareLinks(asUser("bob"), cfg.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner list: got %v, want ErrForbidden", err)
+		}
+		if err := m.RevokeShareLink(asUser("bob"), cfg.ID, link.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("non-owner revoke: got %v, want ErrForbidden", err)
+		}
+		if err := m.RevokeShareLink(ctx, other.ID, link.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("revoke through another config: got %v, want ErrNotFound", err)
+		}
+
+		if err := m.RevokeShareLink(ctx, cfg.ID, link.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.RevokeShareLink(ctx, cfg.ID, link.ID); err != nil {
+			t.Errorf("revoking twice: %v", err)
+		}
+		if _, err := m.GetConfig(WithShareToken(asUser("bob"), link.Token), cfg.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("revoked token: got %v, want ErrNotFound", err)
+		}
+
+		short, err := m.CreateShareLink(ctx, cfg.ID, time.Millisecond)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		if _, err := m.GetConfig(WithShareToken(asUser("bob"), short.Token), cfg.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expired token: got %v, want ErrNotFound", err)
+		}
+
+		links, err := m.ListShareLinks(ctx, cfg.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 2 || links[0].ID != link.ID || links[0].RevokedAt == nil || links[1].ID != short.ID {
+			t.Errorf("links = %+v", links)
+		}
+
+		if err := m.DeleteConfig(ctx, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.ListShareLinks(ctx, cfg.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("links of a deleted config: got %v, want ErrNotFound", err)
+		}
+	})
+}
+
+func TestNewShareLinkTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{-time.Hour, MaxShareLinkTTL + time.Hour} {
+		if _, err := newShareLink("cfg", "alice", ttl); !errors.Is(err, ErrValidation) {
+			t.Errorf("ttl %s: got %v, want ErrValidation", ttl, err)
+		}
+	}
+	if _, err := newShareLink("cfg", "alice", MaxShareLinkTTL); err != nil {
+		t.Errorf("max ttl: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package hyprconfig
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// hyprSourceRe matches a source line of a Hyprland config, capturing the sourced path.
+var hyprSourceRe = regexp.MustCompile(`^\s*source\s*=\s*(.+)$`)
+
+// ResolveSourcedFiles returns the absolute entryPath followed by every file it sources with
+// source = lines, directly or through other sourced files, in the order Hyprland reads them.
+// Sourced paths may start with ~ or $HOME, contain globs like conf.d/*.conf, and are otherwise
+// relative to the directory of the file sourcing them. Each file is listed once, so source cycles
+// end; sourced files that don't exist or can't be read are left out, as Hyprland skips them too.
+func ResolveSourcedFiles(entryPath string) ([]string, error) {
+	home, _ := os.UserHomeDir()
+	return resolveSourcedFiles(entryPath, &sourceResolver{home: home})
+}
+
+// ResolveSourcedFilesIn is ResolveSourcedFiles for a config below home, which ~ and $HOME stand
+// for. Sourced files outside home are neither listed nor read, so a config from an untrusted
+// repository extracted to home can't pull in other files of the machine.
+func ResolveSourcedFilesIn(entryPath, home string) ([]string, error) {
+	home, err := filepath.Abs(home)
+	if err != nil {
+		return nil, err
+	}
+	return resolveSourcedFiles(entryPath, &sourceResolver{home: home, confined: true})
+}
+
+func resolveSourcedFiles(entryPath string, r *sourceResolver) ([]string, error) {
+	entry, err := filepath.Abs(entryPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", entryPath, err)
+	}
+	r.seen = map[string]bool{}
+	r.visit(entry, data)
+	return r.files, nil
+}
+
+type sourceResolver struct {
+	home     string
+	confined bool            // sourced files outside home are skipped
+	seen     map[string]bool // real paths of the files listed so far
+	files    []string
+}
+
+// visit lists the file at path with content data, then the files it sources.
+func (r *sourceResolver) visit(path string, data []byte) {
+	key := path
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		key = real
+	}
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+	r.files = append(r.files, path)
+
+	for _, sourced := range r.sourcedPaths(filepath.Dir(path), data) {
+		if r.confined && !isBelow(r.home, sourced) {
+			continue
+		}
+		info, err := os.Stat(sourced)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(sourced)
+		if err != nil {
+			continue
+		}
+		r.visit(sourced, content)
+	}
+}
+
+// sourcedPaths returns the absolute paths the source lines of data refer to, with globs expanded,
+// relative paths resolved against dir.
+func (r *sourceResolver) sourcedPaths(dir string, data []byte) []string {
+	var paths []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		m := hyprSourceRe.FindStringSubmatch(stripHyprComment(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		p := strings.TrimSpace(m[1])
+		switch {
+		case p == "~" || p == "$HOME":
+			continue // a directory
+		case strings.HasPrefix(p, "~/"):
+			p = filepath.Join(r.home, p[len("~/"):])
+		case strings.HasPrefix(p, "$HOME/"):
+			p = filepath.Join(r.home, p[len("$HOME/"):])
+		case !filepath.IsAbs(p):
+			p = filepath.Join(dir, p)
+		}
+
+		// Glob returns the matches sorted, and nothing for a missing file
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+	return paths
+}
+
+// isBelow reports whether the clean absolute path p is dir or inside it.
+func isBelow(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
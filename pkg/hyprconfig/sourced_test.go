@@ -0,0 +1,101 @@
+package hyprconfig
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestResolveSourcedFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	hypr := filepath.Join(home, ".config", "hypr")
+	files := map[string]string{
+		"hyprland.conf": "source = ~/.config/hypr/colors.conf\n" +
+			"# source = ~/.config/hypr/disabled.conf\n" +
+			"source=monitors.conf # per machine\n" +
+			"  source = $HOME/.cache/wal/colors-hyprland.conf\n" +
+			"source = conf.d/*.conf\n" +
+			"source = ~/.config/hypr/missing.conf\n" +
+			"general {\n    gaps_in = 5\n}\n",
+		"colors.conf":          "$accent = rgb(ff0000)\n",
+		"disabled.conf":        "",
+		"monitors.conf":        "source = ./hyprland.conf\nsource = ../hypr/machines/desk.conf\n",
+		"machines/desk.conf":   "monitor = DP-1, 2560x1440, 0x0, 1\nsource = ~/.config/hypr/colors.conf\n",
+		"conf.d/20-binds.conf": "bind = SUPER, Q, killactive\n",
+		"conf.d/10-env.conf":   "env = XCURSOR_SIZE,24\n",
+		"conf.d/notes.txt":     "",
+	}
+	for name, content := range files {
+		p := filepath.Join(hypr, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wal := filepath.Join(home, ".cache", "wal", "colors-hyprland.conf")
+	if err := os.MkdirAll(filepath.Dir(wal), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(wal, []byte("$background = rgb(101010)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveSourcedFiles(filepath.Join(hypr, "hyprland.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(hypr, "hyprland.conf"),
+		filepath.Join(hypr, "colors.conf"),
+		filepath.Join(hypr, "monitors.conf"),
+		filepath.Join(hypr, "machines/desk.conf"), // the cycle back to hyprland.conf ends
+		wal,
+		filepath.Join(hypr, "conf.d/10-env.conf"),
+		filepath.Join(hypr, "conf.d/20-binds.conf"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+
+	if _, err := ResolveSourcedFiles(filepath.Join(hypr, "missing.conf")); err == nil {
+		t.Error("missing entry file: got nil error")
+	}
+}
+
+func TestResolveSourcedFilesIn(t *testing.T) {
+	// The real home holds a secret a config below home must not reach
+	t.Setenv("HOME", t.TempDir())
+	secret := filepath.Join(os.Getenv("HOME"), ".config", "gh", "hosts.yml")
+	home := t.TempDir()
+	hypr := filepath.Join(home, ".config", "hypr")
+	files := map[string]string{
+		secret: "oauth_token: secret\n",
+		filepath.Join(hypr, "hyprland.conf"): "source = ~/.config/hypr/colors.conf\n" +
+			"source = ~/.config/gh/hosts.yml\n" +
+			"source = " + secret + "\n" +
+			"source = ../../../escape.conf\n",
+		filepath.Join(hypr, "colors.conf"):       "$accent = rgb(ff0000)\n",
+		filepath.Join(home, "..", "escape.conf"): "source = ~/.config/hypr/colors.conf\n",
+	}
+	for p, content := range files {
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ResolveSourcedFilesIn(filepath.Join(hypr, "hyprland.conf"), home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(hypr, "hyprland.conf"), filepath.Join(hypr, "colors.conf")}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
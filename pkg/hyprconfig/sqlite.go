@@ -0,0 +1,1879 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3" // registers the sqlite3 driver
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sqliteSchema creates the tables used by ConfigManagerSQLite. Configs and programs are stored as
+// JSON documents with the columns needed for filtering and sorting pulled out next to them.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS configs (
+	id                TEXT PRIMARY KEY,
+	owner_id          TEXT NOT NULL,
+	private           INTEGER NOT NULL DEFAULT 0,
+	likes             INTEGER NOT NULL DEFAULT 0,
+	updated_timestamp INTEGER NOT NULL,
+	doc               TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_configs_owner ON configs(owner_id);
+CREATE INDEX IF NOT EXISTS idx_configs_private ON configs(private);
+CREATE INDEX IF NOT EXISTS idx_configs_likes ON configs(likes DESC);
+CREATE INDEX IF NOT EXISTS idx_configs_updated ON configs(updated_timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS config_tags (
+	config_id TEXT NOT NULL REFERENCES configs(id) ON DELETE CASCADE,
+	tag       TEXT NOT NULL,
+	PRIMARY KEY (config_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_config_tags_tag ON config_tags(tag);
+
+CREATE TABLE IF NOT EXISTS favorites (
+	user_id      TEXT NOT NULL,
+	config_id    TEXT NOT NULL,
+	favorited_at INTEGER NOT NULL,
+	PRIMARY KEY (user_id, config_id)
+);
+CREATE INDEX IF NOT EXISTS idx_favorites_config ON favorites(config_id);
+
+CREATE TABLE IF NOT EXISTS user_state (
+	user_id    TEXT PRIMARY KEY,
+	config_id  TEXT NOT NULL,
+	applied_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_state_config ON user_state(config_id);
+
+CREATE TABLE IF NOT EXISTS allowed_programs (
+	program_name TEXT PRIMARY KEY,
+	category     TEXT NOT NULL DEFAULT '',
+	doc          TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_allowed_programs_category ON allowed_programs(category);
+
+CREATE TABLE IF NOT EXISTS program_requests (
+	id                TEXT PRIMARY KEY,
+	program_name      TEXT NOT NULL,
+	status            TEXT NOT NULL,
+	created_timestamp INTEGER NOT NULL,
+	doc               TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_program_requests_status ON program_requests(status, created_timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+	id        TEXT PRIMARY KEY,
+	config_id TEXT NOT NULL DEFAULT '',
+	timestamp INTEGER NOT NULL,
+	doc       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_log_config ON audit_log(config_id, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS files (
+	id   TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	data BLOB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id       TEXT PRIMARY KEY,
+	owner_id TEXT NOT NULL,
+	secret   TEXT NOT NULL,
+	doc      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_owner ON webhooks(owner_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id                TEXT PRIMARY KEY,
+	webhook_id        TEXT NOT NULL,
+	status            TEXT NOT NULL,
+	next_attempt      INTEGER NOT NULL,
+	created_timestamp INTEGER NOT NULL,
+	doc               TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id       TEXT PRIMARY KEY,
+	owner_id TEXT NOT NULL,
+	hash     TEXT NOT NULL UNIQUE,
+	doc      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner ON api_keys(owner_id);
+
+CREATE TABLE IF NOT EXISTS share_links (
+	id        TEXT PRIMARY KEY,
+	config_id TEXT NOT NULL REFERENCES configs(id) ON DELETE CASCADE,
+	hash      TEXT NOT NULL UNIQUE,
+	doc       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_share_links_config ON share_links(config_id);
+
+CREATE TABLE IF NOT EXISTS user_quota (
+	user_id           TEXT PRIMARY KEY,
+	used_bytes        INTEGER NOT NULL DEFAULT 0,
+	quota_bytes       INTEGER NOT NULL DEFAULT 0,
+	updated_timestamp INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS user_config_limits (
+	user_id           TEXT PRIMARY KEY,
+	config_limit      INTEGER NOT NULL DEFAULT 0,
+	updated_timestamp INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS follows (
+	follower_id TEXT NOT NULL,
+	followee_id TEXT NOT NULL,
+	followed_at INTEGER NOT NULL,
+	PRIMARY KEY (follower_id, followee_id)
+);
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows(followee_id);
+
+CREATE TABLE IF NOT EXISTS follower_counts (
+	user_id   TEXT PRIMARY KEY,
+	followers INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id                TEXT PRIMARY KEY,
+	user_id           TEXT NOT NULL,
+	read_at           INTEGER NOT NULL DEFAULT 0,
+	created_timestamp INTEGER NOT NULL,
+	doc               TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_timestamp DESC);
+`
+
+// sqliteFTSSchema indexes title, description and tags for ConfigSearchFilters.Query.
+const sqliteFTSSchema = `CREATE VIRTUAL TABLE IF NOT EXISTS configs_fts USING fts5(id UNINDEXED, title, description, tags)`
+
+// ConfigManagerSQLite is a ConfigManager for single box installs, backed by a SQLite database.
+// File content is stored inline and uncompressed. Query search uses FTS5 when the driver was built
+// with it (go build -tags sqlite_fts5) and falls back to case-insensitive LIKE matching otherwise.
+// findOpts are ignored: lists are sorted newest first, ties by id.
+type ConfigManagerSQLite struct {
+	db     *sql.DB
+	fts    bool
+	limits SizeLimits
+}
+
+// sqlQuerier is satisfied by both *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// NewConfigManagerSQLite opens (creating if needed) the SQLite database at path and its schema.
+func NewConfigManagerSQLite(path string) (*ConfigManagerSQLite, error) {
+	if path == "" {
+		return nil, errors.New("config manager: sqlite path must not be empty")
+	}
+	dsn := path
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_foreign_keys=on&_busy_timeout=5000"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// A single connection serialises writers and keeps :memory: databases alive
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+
+	m := &ConfigManagerSQLite{db: db, fts: true, limits: DefaultSizeLimits()}
+	if _, err := db.ExecContext(ctx, sqliteFTSSchema); err != nil {
+		if !strings.Contains(err.Error(), "no such module: fts5") {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to create sqlite search index: %w", err)
+		}
+		slog.Warn("sqlite driver built without FTS5, search falls back to LIKE; build with -tags sqlite_fts5")
+		m.fts = false
+	}
+	return m, nil
+}
+
+// Close closes the underlying database.
+func (m *ConfigManagerSQLite) Close() error {
+	return m.db.Close()
+}
+
+// withTx runs fn in a transaction, committing when it returns nil.
+func (m *ConfigManagerSQLite) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// registry returns the programs configs may use, looked up through q so it can be used inside a
+// transaction. Lookups are cheap, so nothing is cached.
+func (m *ConfigManagerSQLite) registry(q sqlQuerier) *ProgramRegistry {
+	return NewProgramRegistry(m.lookupAllowedPrograms(q), m.allowedProgramNames(q), 0)
+}
+
+// allowedProgramNames returns a lister of the names in allowed_programs bound to q.
+func (m *ConfigManagerSQLite) allowedProgramNames(q sqlQuerier) func(ctx context.Context) ([]string, error) {
+	return func(ctx context.Context) ([]string, error) {
+		rows, err := q.QueryContext(ctx, `SELECT program_name FROM allowed_programs`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list allowed programs: %w", err)
+		}
+		defer rows.Close()
+
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		}
+		return names, rows.Err()
+	}
+}
+
+// lookupAllowedPrograms returns a ProgramsChecker bound to q that looks up all names in one query.
+func (m *ConfigManagerSQLite) lookupAllowedPrograms(q sqlQuerier) ProgramsChecker {
+	return func(ctx context.Context, names []string) (map[string]struct{}, error) {
+		args := make([]any, len(names))
+		for i, name := range names {
+			args[i] = name
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(names)), ",")
+		rows, err := q.QueryContext(ctx, `SELECT program_name FROM allowed_programs WHERE program_name IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("database error checking programs: %w", err)
+		}
+		defer rows.Close()
+
+		allowed := map[string]struct{}{}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			allowed[name] = struct{}{}
+		}
+		return allowed, rows.Err()
+	}
+}
+
+// getConfig loads a config without any visibility check.
+func (m *ConfigManagerSQLite) getConfig(ctx context.Context, q sqlQuerier, id string) (*HyprConfig, error) {
+	var doc string
+	var likes int64
+	err := q.QueryRowContext(ctx, `SELECT doc, likes FROM configs WHERE id = ?`, id).Scan(&doc, &likes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeConfigRow(doc, likes)
+}
+
+func decodeConfigRow(doc string, likes int64) (*HyprConfig, error) {
+	var cfg HyprConfig
+	if err := json.Unmarshal([]byte(doc), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	// likes are kept up to date in their column only
+	cfg.Likes = likes
+	return &cfg, nil
+}
+
+// putConfig inserts or replaces a config document together with its tags and search entry.
+func (m *ConfigManagerSQLite) putConfig(ctx context.Context, tx *sql.Tx, cfg *HyprConfig) error {
+	stored := *cfg
+	stored.refreshDerived()
+	stored.Warnings = nil
+	stored.DependencyReport = nil
+	doc, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO configs (id, owner_id, private, likes, updated_timestamp, doc)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			owner_id = excluded.owner_id,
+			private = excluded.private,
+			updated_timestamp = excluded.updated_timestamp,
+			doc = excluded.doc`,
+		cfg.ID, cfg.OwnerID, cfg.Private, cfg.Likes, cfg.UpdatedTimestamp.UnixNano(), string(doc))
+	if err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM config_tags WHERE config_id = ?`, cfg.ID); err != nil {
+		return err
+	}
+	for _, tag := range cfg.Tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO config_tags (config_id, tag) VALUES (?, ?)`, cfg.ID, tag); err != nil {
+			return fmt.Errorf("failed to write config tags: %w", err)
+		}
+	}
+
+	if !m.fts {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM configs_fts WHERE id = ?`, cfg.ID); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO configs_fts (id, title, description, tags) VALUES (?, ?, ?, ?)`,
+		cfg.ID, cfg.Title, cfg.Description, strings.Join(cfg.Tags, " "))
+	if err != nil {
+		return fmt.Errorf("failed to index config: %w", err)
+	}
+	return nil
+}
+
+// loadWritable loads a config in tx that the signed-in user may modify.
+func (m *ConfigManagerSQLite) loadWritable(ctx context.Context, tx *sql.Tx, id string) (*HyprConfig, *session.UserSessionData, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, err := m.getConfig(ctx, tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !canWrite(cfg, user) {
+		return nil, nil, ErrForbidden
+	}
+	return cfg, user, nil
+}
+
+// mutate loads a writable config, applies fn and writes it back together with the audit entry
+// returned by fn in one transaction. Changes in content size are charged to the owner's quota.
+func (m *ConfigManagerSQLite) mutate(ctx context.Context, id string, fn func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error)) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		cfg, user, err := m.loadWritable(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		before := cfg.contentSize()
+		entry, err := fn(tx, cfg, user)
+		if err != nil {
+			return err
+		}
+		if err := m.chargeQuota(ctx, tx, cfg.OwnerID, cfg.contentSize()-before); err != nil {
+			return err
+		}
+		if err := m.putConfig(ctx, tx, cfg); err != nil {
+			return err
+		}
+		return recordMutation(ctx, tx, entry)
+	})
+}
+
+// recordMutation writes the audit log entry of a config mutation and queues the webhook
+// deliveries it triggers.
+func recordMutation(ctx context.Context, tx *sql.Tx, entry AuditEntry) error {
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	if typ := notificationTypeFor(entry.Action); typ != "" {
+		users, err := configAudience(ctx, tx, entry.ConfigID)
+		if err != nil {
+			return err
+		}
+		if err := notify(ctx, tx, typ, entry.ActorID, entry.ConfigID, users); err != nil {
+			return err
+		}
+	}
+
+	event := webhookEventFor(entry.Action)
+	if event == "" {
+		return nil
+	}
+	hooks, err := queryWebhooks(ctx, tx, `owner_id IN (
+		SELECT user_id FROM favorites WHERE config_id = ?
+		UNION SELECT user_id FROM user_state WHERE config_id = ?)`, []any{entry.ConfigID, entry.ConfigID})
+	if err != nil {
+		return err
+	}
+	for _, d := range newWebhookDeliveries(hooks, event, entry.ConfigID) {
+		if err := putWebhookDelivery(ctx, tx, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertAuditEntry writes an audit log entry.
+func insertAuditEntry(ctx context.Context, q sqlQuerier, entry AuditEntry) error {
+	doc, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `INSERT INTO audit_log (id, config_id, timestamp, doc) VALUES (?, ?, ?, ?)`,
+		entry.ID, entry.ConfigID, entry.Timestamp.UnixNano(), string(doc))
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// sqliteSearchSort maps the SearchSort values to ORDER BY clauses, ties broken by id.
+var sqliteSearchSort = map[string]string{
+	SearchSortUpdated: sqliteDeprecatedLast + `updated_timestamp DESC, id ASC`,
+	SearchSortCreated: sqliteDeprecatedLast + `julianday(json_extract(doc, '$.created_timestamp')) DESC, id ASC`,
+	SearchSortLikes:   sqliteDeprecatedLast + `likes DESC, id ASC`,
+	SearchSortTitle:   sqliteDeprecatedLast + `json_extract(doc, '$.title') COLLATE NOCASE ASC, id ASC`,
+
+	SearchSortVerified: sqliteDeprecatedLast + `COALESCE(json_extract(doc, '$.verified'), 0) DESC, likes DESC, id ASC`,
+}
+
+// sqliteDeprecatedLast starts every sqliteSearchSort order, ranking deprecated configs last.
+const sqliteDeprecatedLast = `COALESCE(json_extract(doc, '$.deprecated'), 0) ASC, `
+
+// listConfigs returns one page of the configs matching where, newest first.
+func (m *ConfigManagerSQLite) listConfigs(ctx context.Context, where string, args []any, page, limit int) (mserve.Page[HyprConfig], error) {
+	return m.listConfigsSorted(ctx, where, args, page, limit, SearchSortUpdated)
+}
+
+// listConfigsSorted is listConfigs with the order given by one of the SearchSort values.
+func (m *ConfigManagerSQLite) listConfigsSorted(ctx context.Context, where string, args []any, page, limit int, sortBy string) (mserve.Page[HyprConfig], error) {
+	if page < 1 || limit < 1 {
+		return mserve.Page[HyprConfig]{}, errors.New("page and limit must be >= 1")
+	}
+
+	var total int
+	if err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM configs WHERE `+where, args...).Scan(&total); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT doc, likes FROM configs WHERE `+where+` ORDER BY `+sqliteSearchSort[sortBy]+` LIMIT ? OFFSET ?`,
+		append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	defer rows.Close()
+
+	items := []HyprConfig{}
+	for rows.Next() {
+		var doc string
+		var likes int64
+		if err := rows.Scan(&doc, &likes); err != nil {
+			return mserve.Page[HyprConfig]{}, err
+		}
+		cfg, err := decodeConfigRow(doc, likes)
+		if err != nil {
+			return mserve.Page[HyprConfig]{}, err
+		}
+		items = append(items, *cfg)
+	}
+	if err := rows.Err(); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return summarizeList(ctx, mserve.Page[HyprConfig]{
+		Items:      items,
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}), nil
+}
+
+// visibleWhere limits configs to public ones and those owned by user (which may be nil).
+func visibleWhere(user *session.UserSessionData) (string, []any) {
+	if user == nil {
+		return "private = 0", nil
+	}
+	return "(private = 0 OR owner_id = ?)", []any{user.UserID}
+}
+
+func (m *ConfigManagerSQLite) CreateConfig(ctx context.Context, cfg *HyprConfig) (*HyprConfig, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		if !isAdmin(user.Roles) {
+			limit, err := m.configLimit(ctx, tx, user.UserID)
+			if err != nil {
+				return err
+			}
+			if err := limit.check(); err != nil {
+				return err
+			}
+		}
+		if err := prepareNewConfig(ctx, cfg, user.UserID, m.registry(tx).Allowed, m.limits); err != nil {
+			return err
+		}
+		if err := m.checkDuplicate(ctx, tx, cfg); err != nil {
+			return err
+		}
+		if err := m.chargeQuota(ctx, tx, user.UserID, cfg.contentSize()); err != nil {
+			return err
+		}
+		if err := m.putConfig(ctx, tx, cfg); err != nil {
+			return err
+		}
+		return insertAuditEntry(ctx, tx, newAuditEntry(user.UserID, AuditCreateConfig, cfg.ID, "", nil))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (m *ConfigManagerSQLite) GetConfig(ctx context.Context, id string) (*HyprConfig, error) {
+	user, _ := getUserFromContext(ctx) // user may be nil for public configs
+
+	cfg, err := m.getConfig(ctx, m.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canRead(cfg, user) {
+		err := sharedRead(ctx, id, func(hash string) (*ShareLink, error) {
+			return m.shareLink(ctx, "hash = ?", hash)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func (m *ConfigManagerSQLite) GetProgramConfig(ctx context.Context, configID, progID string) (*HyprProgramConfig, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+	prog := findProgramConfig(cfg.ProgramConfigs, progID)
+	if prog == nil {
+		return nil, ErrNotFound
+	}
+	return prog, nil
+}
+
+func (m *ConfigManagerSQLite) GetProgramFile(ctx context.Context, configID, progID, filePath string) (io.ReadCloser, *FileEntry, error) {
+	prog, err := m.GetProgramConfig(ctx, configID, progID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return openProgramFile(ctx, nil, prog, filePath, m.limits.ExtraInstallPrefixes)
+}
+
+func (m *ConfigManagerSQLite) ExportConfigArchive(ctx context.Context, configID string, w io.Writer) error {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return err
+	}
+	return writeConfigArchive(ctx, nil, cfg, w, m.limits.ExtraInstallPrefixes)
+}
+
+func (m *ConfigManagerSQLite) GetInstallScript(ctx context.Context, configID, distro string, includeOptional bool) (string, error) {
+	if platform, err := NormalizePlatform(distro); err == nil {
+		distro = platform
+	}
+	if _, ok := installCommands[distro]; !ok {
+		return GenerateInstallScript(&HyprConfig{}, distro)
+	}
+
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return "", err
+	}
+	programs, err := m.queryAllowedPrograms(ctx, "1 = 1", nil)
+	if err != nil {
+		return "", err
+	}
+	return GenerateInstallScript(cfg, distro, IncludeOptional(includeOptional), WithPackageNames(programs))
+}
+
+func (m *ConfigManagerSQLite) ExportHomeManager(ctx context.Context, configID string) (string, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return "", err
+	}
+	programs, err := m.queryAllowedPrograms(ctx, "1 = 1", nil)
+	if err != nil {
+		return "", err
+	}
+	return exportHomeManager(cfg, templateValues(ctx), m.limits.ExtraInstallPrefixes, WithPackageNames(programs))
+}
+
+func (m *ConfigManagerSQLite) SizeLimits() SizeLimits {
+	return m.limits
+}
+
+// SetSizeLimits replaces the content size limits, see WithSizeLimits.
+func (m *ConfigManagerSQLite) SetSizeLimits(limits SizeLimits) {
+	m.limits = limits
+}
+
+func (m *ConfigManagerSQLite) UpdateConfig(ctx context.Context, id string, updates bson.M, opts UpdateOptions) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		existing, user, err := m.loadWritable(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		merged, err := mergeConfigUpdates(ctx, existing, updates, opts, user.UserID, m.registry(tx).Allowed, m.limits)
+		if err != nil {
+			return err
+		}
+		if err := m.putConfig(ctx, tx, merged); err != nil {
+			return err
+		}
+		return recordMutation(ctx, tx, newAuditEntry(user.UserID, AuditUpdateConfig, id, "", updatedFields(updates)))
+	})
+}
+
+func (m *ConfigManagerSQLite) DeleteConfig(ctx context.Context, id string) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		cfg, user, err := m.loadWritable(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := m.chargeQuota(ctx, tx, cfg.OwnerID, -cfg.contentSize()); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM configs WHERE id = ?`, id); err != nil {
+			return err
+		}
+		for _, fileID := range galleryFileIDs(cfg) {
+			if err := (sqliteFileStore{tx}).Delete(ctx, fileID); err != nil {
+				return err
+			}
+		}
+		if m.fts {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM configs_fts WHERE id = ?`, id); err != nil {
+				return err
+			}
+		}
+		return recordMutation(ctx, tx, newAuditEntry(user.UserID, AuditDeleteConfig, id, "", nil))
+	})
+}
+
+func (m *ConfigManagerSQLite) ListConfigs(
+	ctx context.Context,
+	page, limit int,
+	findOpts *options.FindOptions,
+) (mserve.Page[HyprConfig], error) {
+	user, _ := getUserFromContext(ctx) // user may be nil
+
+	where, args := visibleWhere(user)
+	return m.listConfigs(ctx, where, args, page, limit)
+}
+
+func (m *ConfigManagerSQLite) ListMyConfigs(
+	ctx context.Context,
+	page, limit int,
+	findOpts *options.FindOptions,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return m.listConfigs(ctx, "owner_id = ?", []any{user.UserID}, page, limit)
+}
+
+func (m *ConfigManagerSQLite) ListConfigsWithFilters(
+	ctx context.Context,
+	page, limit int,
+	filters ConfigSearchFilters,
+	findOpts *options.FindOptions,
+) (mserve.Page[HyprConfig], error) {
+	user, _ := getUserFromContext(ctx) // user may be nil
+
+	if err := filters.normalize(); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	sortBy, err := searchSort(filters.Sort)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	where, args := m.searchWhere(filters, user)
+	return m.listConfigsSorted(ctx, where, args, page, limit, sortBy)
+}
+
+func (m *ConfigManagerSQLite) AdminListConfigs(
+	ctx context.Context,
+	page, limit int,
+	filters ConfigSearchFilters,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+	if !isAdmin(user.Roles) {
+		return mserve.Page[HyprConfig]{}, ErrForbidden
+	}
+
+	if err := filters.normalize(); err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	sortBy, err := searchSort(filters.Sort)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	where, args := m.filterWhere(filters, []string{"1 = 1"}, nil)
+	return m.listConfigsSorted(ctx, where, args, page, limit, sortBy)
+}
+
+// searchWhere is the SQL equivalent of buildSearchFilter.
+func (m *ConfigManagerSQLite) searchWhere(filters ConfigSearchFilters, user *session.UserSessionData) (string, []any) {
+	visible, args := visibleWhere(user)
+	return m.filterWhere(filters, []string{visible}, args)
+}
+
+// filterWhere adds the conditions of filters to parts and joins them.
+func (m *ConfigManagerSQLite) filterWhere(filters ConfigSearchFilters, parts []string, args []any) (string, []any) {
+	if q := strings.TrimSpace(filters.Query); q != "" {
+		if m.fts {
+			parts = append(parts, `id IN (SELECT id FROM configs_fts WHERE configs_fts MATCH ?)`)
+			args = append(args, ftsQuery(q))
+		} else {
+			like := "%" + escapeLike(q) + "%"
+			parts = append(parts, `(json_extract(doc, '$.title') LIKE ? ESCAPE '\'
+				OR json_extract(doc, '$.description') LIKE ? ESCAPE '\'
+				OR EXISTS (SELECT 1 FROM config_tags t WHERE t.config_id = configs.id AND t.tag LIKE ? ESCAPE '\'))`)
+			args = append(args, like, like, like)
+		}
+	}
+
+	for _, tag := range filters.Tags {
+		parts = append(parts, `EXISTS (SELECT 1 FROM config_tags t WHERE t.config_id = configs.id AND t.tag = ?)`)
+		args = append(args, tag)
+	}
+
+	if filters.Program != "" {
+		parts = append(parts, `EXISTS (SELECT 1 FROM json_each(configs.doc, '$.program_configs') p
+			WHERE json_extract(p.value, '$.program') = ?)`)
+		args = append(args, filters.Program)
+	}
+
+	if filters.Monitors > 0 {
+		parts = append(parts, `COALESCE(json_extract(doc, '$.display.monitor_count'), 0) IN (0, ?)`)
+		args = append(args, filters.Monitors)
+	}
+
+	if filters.Resolution != "" {
+		parts = append(parts, `EXISTS (SELECT 1 FROM json_each(configs.doc, '$.display.resolutions') r WHERE r.value = ?)`)
+		args = append(args, filters.Resolution)
+	}
+
+	if filters.Theme != "" {
+		parts = append(parts, `COALESCE(json_extract(doc, '$.theme'), '') = ?`)
+		args = append(args, filters.Theme)
+	}
+
+	for _, c := range filters.paletteColors() {
+		t := filters.ColorTolerance
+		parts = append(parts, `EXISTS (SELECT 1 FROM json_each(configs.doc, '$.palette') c
+			WHERE json_extract(c.value, '$.r') BETWEEN ? AND ?
+			AND json_extract(c.value, '$.g') BETWEEN ? AND ?
+			AND json_extract(c.value, '$.b') BETWEEN ? AND ?)`)
+		args = append(args, c.R-t, c.R+t, c.G-t, c.G+t, c.B-t, c.B+t)
+	}
+
+	if filters.License != "" {
+		parts = append(parts, `COALESCE(json_extract(doc, '$.license'), '') = ?`)
+		args = append(args, licenseFilterValue(filters.License))
+	}
+
+	if filters.HyprlandVersion != "" {
+		parts = append(parts, `COALESCE(json_extract(doc, '$.hyprland_version_key'), 0) <= ?`)
+		args = append(args, hyprlandVersionKey(filters.HyprlandVersion))
+	}
+
+	if filters.OwnerID != "" {
+		parts = append(parts, "owner_id = ?")
+		args = append(args, filters.OwnerID)
+	}
+
+	if filters.Private != nil {
+		parts = append(parts, "private = ?")
+		args = append(args, *filters.Private)
+	}
+
+	if filters.Verified != nil {
+		parts = append(parts, `COALESCE(json_extract(doc, '$.verified'), 0) = ?`)
+		args = append(args, *filters.Verified)
+	}
+
+	if filters.Deprecated != nil {
+		parts = append(parts, `COALESCE(json_extract(doc, '$.deprecated'), 0) = ?`)
+		args = append(args, *filters.Deprecated)
+	}
+
+	if filters.Platform != "" {
+		// no required program may be limited to other platforms
+		parts = append(parts, `NOT EXISTS (SELECT 1 FROM json_each(configs.doc, '$.program_configs') p
+			WHERE COALESCE(json_extract(p.value, '$.optional'), 0) = 0
+			AND json_array_length(p.value, '$.platform') > 0
+			AND NOT EXISTS (SELECT 1 FROM json_each(p.value, '$.platform') pl WHERE pl.value = ?))`)
+		args = append(args, filters.Platform)
+	}
+
+	if filters.UpdatedFrom != nil {
+		parts = append(parts, "updated_timestamp >= ?")
+		args = append(args, time.Unix(*filters.UpdatedFrom, 0).UnixNano())
+	}
+	if filters.UpdatedTo != nil {
+		parts = append(parts, "updated_timestamp <= ?")
+		args = append(args, time.Unix(*filters.UpdatedTo, 0).UnixNano())
+	}
+
+	return strings.Join(parts, " AND "), args
+}
+
+// ftsQuery turns free text into an FTS5 query matching every word as a prefix,
+// quoting each word so user input can't use the FTS query syntax.
+func ftsQuery(q string) string {
+	words := strings.Fields(q)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (m *ConfigManagerSQLite) FavoriteConfig(ctx context.Context, configID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`INSERT OR IGNORE INTO favorites (user_id, config_id, favorited_at) VALUES (?, ?, ?)`,
+			user.UserID, configID, time.Now().UnixNano())
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil // already favorited, ignore
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE configs SET likes = likes + 1 WHERE id = ?`, configID); err != nil {
+			return err
+		}
+		var ownerID string
+		err = tx.QueryRowContext(ctx, `SELECT owner_id FROM configs WHERE id = ?`, configID).Scan(&ownerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return notify(ctx, tx, NotificationConfigFavorited, user.UserID, configID, []string{ownerID})
+	})
+}
+
+func (m *ConfigManagerSQLite) UnfavoriteConfig(ctx context.Context, configID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM favorites WHERE user_id = ? AND config_id = ?`, user.UserID, configID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil // not favorited before, nothing to do
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE configs SET likes = likes - 1 WHERE id = ?`, configID)
+		return err
+	})
+}
+
+func (m *ConfigManagerSQLite) ListFavorites(
+	ctx context.Context,
+	page, limit int,
+) (mserve.Page[HyprConfig], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[HyprConfig]{}, err
+	}
+
+	return m.listConfigs(ctx, "id IN (SELECT config_id FROM favorites WHERE user_id = ?)", []any{user.UserID}, page, limit)
+}
+
+func (m *ConfigManagerSQLite) ApplyConfig(ctx context.Context, configID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = m.db.ExecContext(ctx, `
+		INSERT INTO user_state (user_id, config_id, applied_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET config_id = excluded.config_id, applied_at = excluded.applied_at`,
+		user.UserID, configID, time.Now().UnixNano())
+	return err
+}
+
+func (m *ConfigManagerSQLite) GetAppliedConfig(ctx context.Context) (*HyprConfig, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var configID string
+	err = m.db.QueryRowContext(ctx, `SELECT config_id FROM user_state WHERE user_id = ?`, user.UserID).Scan(&configID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applied config state: %w", err)
+	}
+	return loadAppliedConfig(ctx, configID, m.GetConfig)
+}
+
+func (m *ConfigManagerSQLite) CountUsersUsingConfig(ctx context.Context, configID string) (int64, error) {
+	var n int64
+	err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_state WHERE config_id = ?`, configID).Scan(&n)
+	return n, err
+}
+
+func (m *ConfigManagerSQLite) AddProgramConfig(
+	ctx context.Context,
+	configID string,
+	newProg HyprProgramConfig,
+	parentID *string,
+	changelog string,
+) error {
+	if newProg.ID == "" {
+		newProg.ID = uuid.NewString()
+	}
+	return m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		if err := addProgram(ctx, cfg, newProg, parentID, m.registry(tx).Allowed, m.limits); err != nil {
+			return AuditEntry{}, err
+		}
+		cfg.clearVerification()
+		recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+		return newAuditEntry(user.UserID, AuditAddProgramConfig, configID, newProg.ID, nil), nil
+	})
+}
+
+func (m *ConfigManagerSQLite) RemoveProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	changelog string,
+) error {
+	return m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		if err := removeProgram(cfg, progID); err != nil {
+			return AuditEntry{}, err
+		}
+		cfg.clearVerification()
+		recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+		return newAuditEntry(user.UserID, AuditRemoveProgramConfig, configID, progID, nil), nil
+	})
+}
+
+func (m *ConfigManagerSQLite) MoveProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	newParentID *string,
+	changelog string,
+) error {
+	return m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		if err := moveProgram(cfg, progID, newParentID); err != nil {
+			return AuditEntry{}, err
+		}
+		cfg.clearVerification()
+		recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+		return newAuditEntry(user.UserID, AuditMoveProgramConfig, configID, progID, []string{"parent"}), nil
+	})
+}
+
+func (m *ConfigManagerSQLite) UpdateProgramConfig(
+	ctx context.Context,
+	configID string,
+	progID string,
+	updates HyprProgramConfig,
+	changelog string,
+) error {
+	return m.mutate(ctx, configID, func(tx *sql.Tx, cfg *HyprConfig, user *session.UserSessionData) (AuditEntry, error) {
+		var before HyprProgramConfig
+		if existing := findProgramConfig(cfg.ProgramConfigs, progID); existing != nil {
+			before = *existing
+		}
+		if err := updateProgram(ctx, cfg, progID, updates, m.registry(tx).Allowed, m.limits); err != nil {
+			return AuditEntry{}, err
+		}
+		cfg.clearVerification()
+		recordChangelog(cfg, cfg.Version, changelog, user.UserID)
+		changes := changedProgramFields(before, *findProgramConfig(cfg.ProgramConfigs, progID))
+		return newAuditEntry(user.UserID, AuditUpdateProgramConfig, configID, progID, changes), nil
+	})
+}
+
+func (m *ConfigManagerSQLite) ListAuditLog(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[AuditEntry], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[AuditEntry]{}, err
+	}
+
+	if !isAdmin(user.Roles) {
+		var ownerID string
+		err := m.db.QueryRowContext(ctx, `SELECT owner_id FROM configs WHERE id = ?`, configID).Scan(&ownerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return mserve.Page[AuditEntry]{}, ErrNotFound
+		} else if err != nil {
+			return mserve.Page[AuditEntry]{}, err
+		}
+		if ownerID != user.UserID {
+			return mserve.Page[AuditEntry]{}, ErrForbidden
+		}
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT doc FROM audit_log WHERE config_id = ? ORDER BY timestamp DESC, id ASC`, configID)
+	if err != nil {
+		return mserve.Page[AuditEntry]{}, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return mserve.Page[AuditEntry]{}, err
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal([]byte(doc), &entry); err != nil {
+			return mserve.Page[AuditEntry]{}, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return mserve.Page[AuditEntry]{}, err
+	}
+	return mserve.Paginate(entries, page, limit)
+}
+
+func (m *ConfigManagerSQLite) GetChangelog(
+	ctx context.Context,
+	configID string,
+	page, limit int,
+) (mserve.Page[ChangelogEntry], error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return mserve.Page[ChangelogEntry]{}, err
+	}
+
+	entries := make([]ChangelogEntry, 0, len(cfg.Changelog))
+	for i := len(cfg.Changelog) - 1; i >= 0; i-- {
+		entries = append(entries, cfg.Changelog[i])
+	}
+	return mserve.Paginate(entries, page, limit)
+}
+
+// putAllowedProgram inserts or replaces an allowed program.
+func putAllowedProgram(ctx context.Context, q sqlQuerier, p AllowedPrograms) error {
+	doc, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to encode allowed program: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO allowed_programs (program_name, category, doc) VALUES (?, ?, ?)
+		ON CONFLICT(program_name) DO UPDATE SET category = excluded.category, doc = excluded.doc`,
+		p.ProgramName, p.Category, string(doc))
+	if err != nil {
+		return fmt.Errorf("failed to write allowed program: %w", err)
+	}
+	return nil
+}
+
+// queryAllowedPrograms returns the allowed programs matching where, sorted by name.
+func (m *ConfigManagerSQLite) queryAllowedPrograms(ctx context.Context, where string, args []any) ([]AllowedPrograms, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT doc FROM allowed_programs WHERE `+where+` ORDER BY program_name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allowed programs: %w", err)
+	}
+	defer rows.Close()
+
+	var programs []AllowedPrograms
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var p AllowedPrograms
+		if err := json.Unmarshal([]byte(doc), &p); err != nil {
+			return nil, fmt.Errorf("failed to decode allowed program: %w", err)
+		}
+		programs = append(programs, p)
+	}
+	return programs, rows.Err()
+}
+
+func (m *ConfigManagerSQLite) AddAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	program := AllowedPrograms{ProgramName: programName}
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		found, err := m.lookupAllowedPrograms(tx)(ctx, []string{programName})
+		if err != nil {
+			return err
+		}
+		if _, ok := found[programName]; ok {
+			return invalidf("program '%s' is already allowed", programName)
+		}
+		if err := putAllowedProgram(ctx, tx, program); err != nil {
+			return err
+		}
+		return insertAuditEntry(ctx, tx, newAuditEntry(user.UserID, AuditAddAllowedProgram, "", "", []string{programName}))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+func (m *ConfigManagerSQLite) GetAllowedProgram(ctx context.Context, programName string) (*AllowedPrograms, error) {
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	programs, err := m.queryAllowedPrograms(ctx, "program_name = ?", []any{programName})
+	if err != nil {
+		return nil, err
+	}
+	if len(programs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &programs[0], nil
+}
+
+func (m *ConfigManagerSQLite) ListAllowedPrograms(ctx context.Context) ([]AllowedPrograms, error) {
+	return m.queryAllowedPrograms(ctx, "1 = 1", nil)
+}
+
+func (m *ConfigManagerSQLite) ListAllowedProgramsPaged(
+	ctx context.Context,
+	page, limit int,
+	filters AllowedProgramFilters,
+) (mserve.Page[AllowedPrograms], error) {
+	where := []string{"1 = 1"}
+	var args []any
+	if prefix := strings.ToLower(strings.TrimSpace(filters.Prefix)); prefix != "" {
+		where = append(where, `program_name LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	if filters.Category != "" {
+		where = append(where, "category = ?")
+		args = append(args, filters.Category)
+	}
+
+	programs, err := m.queryAllowedPrograms(ctx, strings.Join(where, " AND "), args)
+	if err != nil {
+		return mserve.Page[AllowedPrograms]{}, err
+	}
+	return mserve.Paginate(programs, page, limit)
+}
+
+func (m *ConfigManagerSQLite) RemoveAllowedProgram(ctx context.Context, programName string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return invalidf("program name cannot be empty")
+	}
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM allowed_programs WHERE program_name = ?`, programName)
+		if err != nil {
+			return fmt.Errorf("failed to delete allowed program: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrNotFound
+		}
+		return insertAuditEntry(ctx, tx, newAuditEntry(user.UserID, AuditRemoveAllowedProgram, "", "", []string{programName}))
+	})
+}
+
+func (m *ConfigManagerSQLite) ImportAllowedPrograms(
+	ctx context.Context,
+	programs []AllowedPrograms,
+	upsert bool,
+) ([]ProgramImportResult, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	return m.importAllowedPrograms(ctx, user.UserID, programs, upsert)
+}
+
+func (m *ConfigManagerSQLite) SeedDefaultPrograms(ctx context.Context) ([]ProgramImportResult, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	return m.importAllowedPrograms(ctx, user.UserID, DefaultAllowedPrograms(), false)
+}
+
+func (m *ConfigManagerSQLite) importAllowedPrograms(ctx context.Context, actorID string, programs []AllowedPrograms, upsert bool) ([]ProgramImportResult, error) {
+	var results []ProgramImportResult
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT program_name FROM allowed_programs`)
+		if err != nil {
+			return err
+		}
+		existing := map[string]bool{}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return err
+			}
+			existing[name] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		var writes []AllowedPrograms
+		results, writes = planProgramImport(programs, upsert, existing)
+		for _, p := range writes {
+			if err := putAllowedProgram(ctx, tx, p); err != nil {
+				return err
+			}
+		}
+		return insertAuditEntry(ctx, tx, newAuditEntry(actorID, AuditImportAllowedPrograms, "", "", importedProgramNames(results)))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import allowed programs: %w", err)
+	}
+	return results, nil
+}
+
+// putProgramRequest inserts or replaces a program request.
+func putProgramRequest(ctx context.Context, q sqlQuerier, req ProgramRequest) error {
+	doc, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode program request: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO program_requests (id, program_name, status, created_timestamp, doc) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET status = excluded.status, doc = excluded.doc`,
+		req.ID, req.ProgramName, req.Status, req.CreatedTimestamp.UnixNano(), string(doc))
+	if err != nil {
+		return fmt.Errorf("failed to write program request: %w", err)
+	}
+	return nil
+}
+
+// queryProgramRequests returns the program requests matching where, newest first.
+func queryProgramRequests(ctx context.Context, q sqlQuerier, where string, args []any) ([]ProgramRequest, error) {
+	rows, err := q.QueryContext(ctx, `SELECT doc FROM program_requests WHERE `+where+` ORDER BY created_timestamp DESC, id ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up program requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []ProgramRequest
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var req ProgramRequest
+		if err := json.Unmarshal([]byte(doc), &req); err != nil {
+			return nil, fmt.Errorf("failed to decode program request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+func (m *ConfigManagerSQLite) RequestAllowedProgram(ctx context.Context, programName string, reason string) (*ProgramRequest, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	programName = NormalizeProgramName(programName)
+	if programName == "" {
+		return nil, invalidf("program name cannot be empty")
+	}
+
+	var req ProgramRequest
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		allowed, err := m.registry(tx).IsAllowed(ctx, programName)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return invalidf("program '%s' is already allowed", programName)
+		}
+
+		pending, err := queryProgramRequests(ctx, tx, "program_name = ? AND status = ?", []any{programName, ProgramRequestPending})
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			req = pending[0]
+			return nil
+		}
+
+		now := time.Now()
+		req = ProgramRequest{
+			ID:               uuid.NewString(),
+			ProgramName:      programName,
+			Reason:           strings.TrimSpace(reason),
+			RequestedBy:      user.UserID,
+			Status:           ProgramRequestPending,
+			CreatedTimestamp: now,
+			UpdatedTimestamp: now,
+		}
+		return putProgramRequest(ctx, tx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (m *ConfigManagerSQLite) ListProgramRequests(
+	ctx context.Context,
+	page, limit int,
+	status string,
+) (mserve.Page[ProgramRequest], error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return mserve.Page[ProgramRequest]{}, err
+	}
+	if !isAdmin(user.Roles) {
+		return mserve.Page[ProgramRequest]{}, ErrForbidden
+	}
+
+	where, args := "1 = 1", []any(nil)
+	if status != "" {
+		where, args = "status = ?", []any{status}
+	}
+	requests, err := queryProgramRequests(ctx, m.db, where, args)
+	if err != nil {
+		return mserve.Page[ProgramRequest]{}, err
+	}
+	return mserve.Paginate(requests, page, limit)
+}
+
+func (m *ConfigManagerSQLite) ApproveProgramRequest(ctx context.Context, requestID string) (*AllowedPrograms, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+
+	var program AllowedPrograms
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		req, err := pendingProgramRequestSQL(ctx, tx, requestID)
+		if err != nil {
+			return err
+		}
+		program = AllowedPrograms{ProgramName: req.ProgramName}
+		found, err := m.lookupAllowedPrograms(tx)(ctx, []string{program.ProgramName})
+		if err != nil {
+			return err
+		}
+		if _, ok := found[program.ProgramName]; !ok {
+			if err := putAllowedProgram(ctx, tx, program); err != nil {
+				return err
+			}
+		}
+		if err := putProgramRequest(ctx, tx, reviewedRequest(req, ProgramRequestApproved, user.UserID, "")); err != nil {
+			return err
+		}
+		return insertAuditEntry(ctx, tx, newAuditEntry(user.UserID, AuditAddAllowedProgram, "", "", []string{program.ProgramName}))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+func (m *ConfigManagerSQLite) RejectProgramRequest(ctx context.Context, requestID string, note string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		req, err := pendingProgramRequestSQL(ctx, tx, requestID)
+		if err != nil {
+			return err
+		}
+		return putProgramRequest(ctx, tx, reviewedRequest(req, ProgramRequestRejected, user.UserID, strings.TrimSpace(note)))
+	})
+}
+
+// pendingProgramRequestSQL returns a request that is still pending.
+func pendingProgramRequestSQL(ctx context.Context, q sqlQuerier, requestID string) (ProgramRequest, error) {
+	requests, err := queryProgramRequests(ctx, q, "id = ?", []any{requestID})
+	if err != nil {
+		return ProgramRequest{}, err
+	}
+	if len(requests) == 0 {
+		return ProgramRequest{}, ErrNotFound
+	}
+	req := requests[0]
+	if req.Status != ProgramRequestPending {
+		return ProgramRequest{}, invalidf("program request %s has already been %s", requestID, req.Status)
+	}
+	return req, nil
+}
+
+// reviewedRequest returns req with the review decision recorded.
+func reviewedRequest(req ProgramRequest, status, reviewerID, note string) ProgramRequest {
+	req.Status = status
+	req.ReviewedBy = reviewerID
+	req.ReviewNote = note
+	req.UpdatedTimestamp = time.Now()
+	return req
+}
+
+// queryWebhooks returns the webhooks matching where, oldest first.
+func queryWebhooks(ctx context.Context, q sqlQuerier, where string, args []any) ([]Webhook, error) {
+	rows, err := q.QueryContext(ctx, `SELECT doc, secret FROM webhooks WHERE `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		var doc, secret string
+		if err := rows.Scan(&doc, &secret); err != nil {
+			return nil, err
+		}
+		var hook Webhook
+		if err := json.Unmarshal([]byte(doc), &hook); err != nil {
+			return nil, fmt.Errorf("failed to decode webhook: %w", err)
+		}
+		hook.Secret = secret // not part of the JSON document
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// putWebhookDelivery inserts or replaces a webhook delivery.
+func putWebhookDelivery(ctx context.Context, q sqlQuerier, d WebhookDelivery) error {
+	doc, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook delivery: %w", err)
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, webhook_id, status, next_attempt, created_timestamp, doc) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET status = excluded.status, next_attempt = excluded.next_attempt, doc = excluded.doc`,
+		d.ID, d.WebhookID, d.Status, d.NextAttempt.UnixNano(), d.CreatedTimestamp.UnixNano(), string(doc))
+	if err != nil {
+		return fmt.Errorf("failed to write webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// queryWebhookDeliveries returns the deliveries matching where in the given order.
+func queryWebhookDeliveries(ctx context.Context, q sqlQuerier, where, orderBy string, args []any) ([]WebhookDelivery, error) {
+	rows, err := q.QueryContext(ctx, `SELECT doc FROM webhook_deliveries WHERE `+where+` ORDER BY `+orderBy, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []WebhookDelivery
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		var d WebhookDelivery
+		if err := json.Unmarshal([]byte(doc), &d); err != nil {
+			return nil, fmt.Errorf("failed to decode webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (m *ConfigManagerSQLite) CreateWebhook(ctx context.Context, req WebhookRequest) (*Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := newWebhook(req, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := json.Marshal(hook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook: %w", err)
+	}
+	_, err = m.db.ExecContext(ctx, `INSERT INTO webhooks (id, owner_id, secret, doc) VALUES (?, ?, ?, ?)`,
+		hook.ID, hook.OwnerID, hook.Secret, string(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %w", err)
+	}
+	return hook, nil
+}
+
+func (m *ConfigManagerSQLite) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryWebhooks(ctx, m.db, "owner_id = ?", []any{user.UserID})
+}
+
+// webhook loads a webhook by id.
+func (m *ConfigManagerSQLite) webhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	hooks, err := queryWebhooks(ctx, m.db, "id = ?", []any{webhookID})
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return nil, ErrNotFound
+	}
+	return &hooks[0], nil
+}
+
+// ownedWebhook loads a webhook the signed-in user owns, or any webhook for admins.
+func (m *ConfigManagerSQLite) ownedWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := m.webhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if hook.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+	return hook, nil
+}
+
+func (m *ConfigManagerSQLite) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if _, err := m.ownedWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+
+	if _, err := m.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+func (m *ConfigManagerSQLite) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID string,
+	page, limit int,
+) (mserve.Page[WebhookDelivery], error) {
+	if _, err := m.ownedWebhook(ctx, webhookID); err != nil {
+		return mserve.Page[WebhookDelivery]{}, err
+	}
+
+	deliveries, err := queryWebhookDeliveries(ctx, m.db, "webhook_id = ?", "created_timestamp DESC, id ASC", []any{webhookID})
+	if err != nil {
+		return mserve.Page[WebhookDelivery]{}, err
+	}
+	return mserve.Paginate(deliveries, page, limit)
+}
+
+func (m *ConfigManagerSQLite) DeliverPendingWebhooks(ctx context.Context) (int, error) {
+	due, err := queryWebhookDeliveries(ctx, m.db, "status = ? AND next_attempt <= ?", "next_attempt ASC LIMIT ?",
+		[]any{WebhookDeliveryPending, time.Now().UnixNano(), webhookBatchSize})
+	if err != nil {
+		return 0, err
+	}
+
+	return deliverWebhooks(ctx, due, m.webhook, func(ctx context.Context, d WebhookDelivery) error {
+		return putWebhookDelivery(ctx, m.db, d)
+	})
+}
+
+// queryAPIKeys returns the API keys matching where, oldest first.
+func queryAPIKeys(ctx context.Context, q sqlQuerier, where string, args []any) ([]APIKey, error) {
+	rows, err := q.QueryContext(ctx, `SELECT doc, hash FROM api_keys WHERE `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var doc, hash string
+		if err := rows.Scan(&doc, &hash); err != nil {
+			return nil, err
+		}
+		var key APIKey
+		if err := json.Unmarshal([]byte(doc), &key); err != nil {
+			return nil, fmt.Errorf("failed to decode api key: %w", err)
+		}
+		key.Hash = hash // not part of the JSON document
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (m *ConfigManagerSQLite) CreateAPIKey(
+	ctx context.Context,
+	name string,
+	scopes []string,
+	expiry time.Duration,
+) (*CreatedAPIKey, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newAPIKey(user.UserID, name, scopes, expiry)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := json.Marshal(key.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode api key: %w", err)
+	}
+	_, err = m.db.ExecContext(ctx, `INSERT INTO api_keys (id, owner_id, hash, doc) VALUES (?, ?, ?, ?)`,
+		key.ID, key.OwnerID, key.Hash, string(doc))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return key, nil
+}
+
+func (m *ConfigManagerSQLite) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryAPIKeys(ctx, m.db, "owner_id = ?", []any{user.UserID})
+}
+
+func (m *ConfigManagerSQLite) RevokeAPIKey(ctx context.Context, keyID string) error {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := queryAPIKeys(ctx, m.db, "id = ?", []any{keyID})
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return ErrNotFound
+	}
+	key := keys[0]
+	if key.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return ErrForbidden
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	key.RevokedAt = &now
+	doc, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode api key: %w", err)
+	}
+	if _, err := m.db.ExecContext(ctx, `UPDATE api_keys SET doc = ? WHERE id = ?`, string(doc), keyID); err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+func (m *ConfigManagerSQLite) AuthenticateAPIKey(ctx context.Context, token string) (*APIKey, error) {
+	keys, err := queryAPIKeys(ctx, m.db, "hash = ?", []any{hashToken(token)})
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 || !keys[0].active(time.Now()) {
+		return nil, ErrUnauthorized
+	}
+	return &keys[0], nil
+}
+
+// queryShareLinks returns the share links matching where, oldest first.
+func queryShareLinks(ctx context.Context, q sqlQuerier, where string, args []any) ([]ShareLink, error) {
+	rows, err := q.QueryContext(ctx, `SELECT doc, hash FROM share_links WHERE `+where+` ORDER BY rowid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []ShareLink{}
+	for rows.Next() {
+		var doc, hash string
+		if err := rows.Scan(&doc, &hash); err != nil {
+			return nil, err
+		}
+		var link ShareLink
+		if err := json.Unmarshal([]byte(doc), &link); err != nil {
+			return nil, fmt.Errorf("failed to decode share link: %w", err)
+		}
+		link.Hash = hash // not part of the JSON document
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// shareLink returns the one share link matching where, or ErrNotFound.
+func (m *ConfigManagerSQLite) shareLink(ctx context.Context, where string, args ...any) (*ShareLink, error) {
+	links, err := queryShareLinks(ctx, m.db, where, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, ErrNotFound
+	}
+	return &links[0], nil
+}
+
+func (m *ConfigManagerSQLite) CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (*CreatedShareLink, error) {
+	var link *CreatedShareLink
+	err := m.withTx(ctx, func(tx *sql.Tx) error {
+		_, user, err := m.loadWritable(ctx, tx, configID)
+		if err != nil {
+			return err
+		}
+		if link, err = newShareLink(configID, user.UserID, ttl); err != nil {
+			return err
+		}
+		doc, err := json.Marshal(link.ShareLink)
+		if err != nil {
+			return fmt.Errorf("failed to encode share link: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO share_links (id, config_id, hash, doc) VALUES (?, ?, ?, ?)`,
+			link.ID, link.ConfigID, link.Hash, string(doc))
+		if err != nil {
+			return fmt.Errorf("failed to insert share link: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (m *ConfigManagerSQLite) ListShareLinks(ctx context.Context, configID string) ([]ShareLink, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := m.getConfig(ctx, m.db, configID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWrite(cfg, user) {
+		return nil, ErrForbidden
+	}
+	return queryShareLinks(ctx, m.db, "config_id = ?", []any{configID})
+}
+
+func (m *ConfigManagerSQLite) RevokeShareLink(ctx context.Context, configID, linkID string) error {
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		if _, _, err := m.loadWritable(ctx, tx, configID); err != nil {
+			return err
+		}
+		links, err := queryShareLinks(ctx, tx, "id = ? AND config_id = ?", []any{linkID, configID})
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return ErrNotFound
+		}
+		link := links[0]
+		if link.RevokedAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		link.RevokedAt = &now
+		doc, err := json.Marshal(link)
+		if err != nil {
+			return fmt.Errorf("failed to encode share link: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE share_links SET doc = ? WHERE id = ?`, string(doc), linkID); err != nil {
+			return fmt.Errorf("failed to revoke share link: %w", err)
+		}
+		return nil
+	})
+}
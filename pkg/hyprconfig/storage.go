@@ -0,0 +1,324 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrUnknownFile       = errors.New("file content references an unknown file")
+	ErrFileStoreDisabled = errors.New("file store is not configured")
+)
+
+const (
+	// DefaultOffloadThreshold is the size above which file content is moved out of the config document.
+	DefaultOffloadThreshold int64 = 256 << 10
+	// DefaultFileBucket is the GridFS bucket used for offloaded file content.
+	DefaultFileBucket = "config_files"
+)
+
+// FileStore holds file content that is too large to embed in a config document.
+type FileStore interface {
+	Put(ctx context.Context, name string, data []byte) (string, error)
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// GridFSStore is a FileStore backed by a GridFS bucket.
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFSStore opens (lazily creating) the named GridFS bucket in db.
+func NewGridFSStore(db *mongo.Database, bucketName string) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open gridfs bucket %s: %w", bucketName, err)
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (s *GridFSStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	id := primitive.NewObjectID()
+	if err := s.bucket.UploadFromStreamWithID(id, name, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to upload file %s: %w", name, err)
+	}
+	return id.Hex(), nil
+}
+
+func (s *GridFSStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrUnknownFile
+	}
+	stream, err := s.bucket.OpenDownloadStream(oid)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (s *GridFSStore) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrUnknownFile
+	}
+	if err := s.bucket.DeleteContext(ctx, oid); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+// memFileStore is an in-memory FileStore.
+type memFileStore struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+	next  int
+}
+
+func newMemFileStore() *memFileStore {
+	return &memFileStore{files: map[string][]byte{}}
+}
+
+func (s *memFileStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next++
+	id := fmt.Sprintf("file-%d", s.next)
+	s.files[id] = append([]byte{}, data...)
+	return id, nil
+}
+
+func (s *memFileStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memFileStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, id)
+	return nil
+}
+
+// sqliteFileStore is a FileStore kept in the files table, usable inside a transaction.
+type sqliteFileStore struct {
+	q sqlQuerier
+}
+
+func (s sqliteFileStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	id := uuid.NewString()
+	if _, err := s.q.ExecContext(ctx, `INSERT INTO files (id, name, data) VALUES (?, ?, ?)`, id, name, data); err != nil {
+		return "", fmt.Errorf("failed to store file %s: %w", name, err)
+	}
+	return id, nil
+}
+
+func (s sqliteFileStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	var data []byte
+	err := s.q.QueryRowContext(ctx, `SELECT data FROM files WHERE id = ?`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s sqliteFileStore) Delete(ctx context.Context, id string) error {
+	_, err := s.q.ExecContext(ctx, `DELETE FROM files WHERE id = ?`, id)
+	return err
+}
+
+// storedFiles returns the offloaded file content in the program config tree, keyed by file id.
+func storedFiles(list []HyprProgramConfig) map[string]FileContent {
+	files := map[string]FileContent{}
+	cfg := HyprConfig{ProgramConfigs: list}
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		pc.eachFile(func(_ string, fc *FileContent) {
+			if fc.FileID != "" {
+				files[fc.FileID] = *fc
+			}
+		})
+	})
+	return files
+}
+
+// resolveFileRefs checks that content sent without data only references files the config already
+// owns, so a client cannot attach someone else's file. Content sent with data replaces the reference.
+func (pc *HyprProgramConfig) resolveFileRefs(known map[string]FileContent) error {
+	var err error
+	pc.Walk(func(p *HyprProgramConfig) {
+		p.eachFile(func(_ string, fc *FileContent) {
+			if fc.FileID == "" || err != nil {
+				return
+			}
+			if len(fc.Data) > 0 {
+				fc.FileID = ""
+				fc.Size = 0
+				return
+			}
+			stored, ok := known[fc.FileID]
+			if !ok || stored.Hash != fc.Hash {
+				err = fmt.Errorf("program %s: %w", p.Program, ErrUnknownFile)
+				return
+			}
+			fc.Size = stored.Size
+		})
+	})
+	return err
+}
+
+// offloadFiles moves uncompressed content larger than the offload threshold into the file store;
+// content already encoded for inline storage is left alone. It returns the ids of the uploaded files so they can be cleaned up if the write fails.
+func (m *ConfigManagerMongo) offloadFiles(ctx context.Context, pc *HyprProgramConfig) ([]string, error) {
+	if m.files == nil || m.offloadThreshold <= 0 {
+		return nil, nil
+	}
+
+	var uploaded []string
+	var err error
+	pc.Walk(func(p *HyprProgramConfig) {
+		p.eachFile(func(_ string, fc *FileContent) {
+			if err != nil || fc.Encoding != EncodingNone || int64(len(fc.Data)) <= m.offloadThreshold {
+				return
+			}
+			var id string
+			id, err = m.files.Put(ctx, p.ID+"-"+p.Program, fc.Data)
+			if err != nil {
+				return
+			}
+			uploaded = append(uploaded, id)
+			fc.FileID = id
+			fc.Size = int64(len(fc.Data))
+			fc.Data = nil
+		})
+	})
+	if err != nil {
+		m.deleteFiles(ctx, uploaded)
+		return nil, err
+	}
+	return uploaded, nil
+}
+
+// hydrateFiles loads offloaded content back into Data.
+func (m *ConfigManagerMongo) hydrateFiles(ctx context.Context, pc *HyprProgramConfig) error {
+	var err error
+	pc.Walk(func(p *HyprProgramConfig) {
+		p.eachFile(func(_ string, fc *FileContent) {
+			if err != nil || fc.FileID == "" || len(fc.Data) > 0 {
+				return
+			}
+			if m.files == nil {
+				err = ErrFileStoreDisabled
+				return
+			}
+			var rc io.ReadCloser
+			rc, err = m.files.Open(ctx, fc.FileID)
+			if err != nil {
+				return
+			}
+			defer rc.Close()
+			fc.Data, err = io.ReadAll(rc)
+		})
+	})
+	return err
+}
+
+// deleteFiles removes files from the store. Failures are ignored: an orphaned file only wastes space.
+func (m *ConfigManagerMongo) deleteFiles(ctx context.Context, ids []string) {
+	if m.files == nil {
+		return
+	}
+	for _, id := range ids {
+		_ = m.files.Delete(ctx, id)
+	}
+}
+
+// deleteOrphanedFiles removes files referenced by before that are no longer referenced by after.
+func (m *ConfigManagerMongo) deleteOrphanedFiles(ctx context.Context, before map[string]FileContent, after []HyprProgramConfig) {
+	still := storedFiles(after)
+	var orphaned []string
+	for id := range before {
+		if _, ok := still[id]; !ok {
+			orphaned = append(orphaned, id)
+		}
+	}
+	m.deleteFiles(ctx, orphaned)
+}
+
+// GetProgramFile streams a file of a program config: the one installed at filePath, see
+// HyprProgramConfig.FileEntries, or the first one when filePath is empty. The returned entry has
+// no file Data, its FileContent.Size is the size of the file and its TargetPath is below ~/.
+func (m *ConfigManagerMongo) GetProgramFile(ctx context.Context, configID, progID, filePath string) (io.ReadCloser, *FileEntry, error) {
+	cfg, err := m.GetConfig(ctx, configID)
+	if err != nil {
+		return nil, nil, err
+	}
+	prog := findProgramConfig(cfg.ProgramConfigs, progID)
+	if prog == nil {
+		return nil, nil, ErrNotFound
+	}
+	return openProgramFile(ctx, m.files, prog, filePath, m.limits.ExtraInstallPrefixes)
+}
+
+// openProgramFile reads the file of prog at filePath, inline or from files, and verifies its hash
+// before anything is served. A missing file or one without data is reported as ErrNotFound.
+func openProgramFile(ctx context.Context, files FileStore, prog *HyprProgramConfig, filePath string, extraPrefixes []string) (io.ReadCloser, *FileEntry, error) {
+	entry, ok := prog.findFile(filePath, extraPrefixes...)
+	if !ok {
+		return nil, nil, fmt.Errorf("program config %s has no file %q: %w", prog.ID, filePath, ErrNotFound)
+	}
+	content := entry.FileContent
+	if content.FileID != "" && len(content.Data) == 0 {
+		if files == nil {
+			return nil, nil, ErrFileStoreDisabled
+		}
+		rc, err := files.Open(ctx, content.FileID)
+		if err != nil {
+			return nil, nil, err
+		}
+		content.Data, err = io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read stored file: %w", err)
+		}
+	} else if err := content.Decompress(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(content.Data) == 0 {
+		return nil, nil, fmt.Errorf("program config %s has no file content: %w", prog.ID, ErrNotFound)
+	}
+	// A mismatch means the stored data is corrupt, which is not the caller's fault
+	if content.Hash != "" && ComputeHash(content.Data) != content.Hash {
+		return nil, nil, fmt.Errorf("stored file content of program config %s does not match its hash", prog.ID)
+	}
+
+	if p, err := prog.FilePath(entry, extraPrefixes...); err == nil {
+		entry.TargetPath = "~/" + p
+	}
+	entry.FileContent.Data = nil
+	entry.FileContent.Encoding = EncodingNone
+	entry.FileContent.Size = int64(len(content.Data))
+	return io.NopCloser(bytes.NewReader(content.Data)), &entry, nil
+}
@@ -0,0 +1,128 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestOffloadAndHydrateFiles(t *testing.T) {
+	store := newMemFileStore()
+	m := &ConfigManagerMongo{files: store, offloadThreshold: 16}
+	ctx := context.Background()
+
+	large := bytes.Repeat([]byte("wallpaper"), 10)
+	small := []byte("font_size 12")
+	pc := HyprProgramConfig{
+		ID: "p1", Program: "hyprpaper",
+		FileContent: FileContent{Data: large, FileType: FileTypeImage},
+		SubConfigs: []*HyprProgramConfig{
+			{ID: "p2", Program: "kitty", FileContent: FileContent{Data: small, FileType: FileTypeConfig}},
+		},
+	}
+
+	uploaded, err := m.offloadFiles(ctx, &pc)
+	if err != nil {
+		t.Fatalf("offloadFiles: %v", err)
+	}
+	if len(uploaded) != 1 || len(store.files) != 1 {
+		t.Fatalf("expected exactly one file offloaded, got %v", uploaded)
+	}
+	fc := pc.FileContent
+	if fc.FileID != uploaded[0] || len(fc.Data) != 0 || fc.Size != int64(len(large)) {
+		t.Errorf("offloaded content = %+v", fc)
+	}
+	if pc.SubConfigs[0].FileContent.FileID != "" {
+		t.Error("content below the threshold was offloaded")
+	}
+
+	if err := m.hydrateFiles(ctx, &pc); err != nil {
+		t.Fatalf("hydrateFiles: %v", err)
+	}
+	if !bytes.Equal(pc.FileContent.Data, large) {
+		t.Error("hydrateFiles did not restore the data")
+	}
+}
+
+func TestOpenProgramFile(t *testing.T) {
+	store := newMemFileStore()
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("font_size 12\n"), 20)
+	id, _ := store.Put(ctx, "kitty", data)
+	compressed := FileContent{Data: data, Hash: ComputeHash(data)}
+	if err := compressed.Compress(); err != nil || compressed.Encoding != EncodingGzip {
+		t.Fatalf("compress: %v", err)
+	}
+
+	for name, fc := range map[string]FileContent{
+		"inline":     {Data: data, Hash: ComputeHash(data)},
+		"compressed": compressed,
+		"offloaded":  {FileID: id, Hash: ComputeHash(data), Size: int64(len(data))},
+	} {
+		rc, prog, err := openProgramFile(ctx, store, &HyprProgramConfig{ID: "p", FileContent: fc}, "", nil)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		got, _ := io.ReadAll(rc)
+		if !bytes.Equal(got, data) || prog.FileContent.Size != int64(len(data)) || len(prog.FileContent.Data) != 0 {
+			t.Errorf("%s: read %q, size %d", name, got, prog.FileContent.Size)
+		}
+	}
+
+	if _, _, err := openProgramFile(ctx, store, &HyprProgramConfig{ID: "p"}, "", nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("no content: got %v, want ErrNotFound", err)
+	}
+	corrupt := FileContent{Data: []byte("font_size 13"), Hash: ComputeHash(data)}
+	if _, _, err := openProgramFile(ctx, store, &HyprProgramConfig{ID: "p", FileContent: corrupt}, "", nil); err == nil || errors.Is(err, ErrValidation) {
+		t.Errorf("corrupt content: got %v, want a storage error", err)
+	}
+}
+
+func TestDeleteOrphanedFiles(t *testing.T) {
+	store := newMemFileStore()
+	m := &ConfigManagerMongo{files: store}
+	ctx := context.Background()
+
+	keep, _ := store.Put(ctx, "keep", []byte("a"))
+	drop, _ := store.Put(ctx, "drop", []byte("b"))
+	before := []HyprProgramConfig{
+		{ID: "a", FileContent: FileContent{FileID: keep}},
+		{ID: "b", FileContent: FileContent{FileID: drop}},
+	}
+
+	m.deleteOrphanedFiles(ctx, storedFiles(before), before[:1])
+	if _, ok := store.files[keep]; !ok {
+		t.Error("still referenced file was deleted")
+	}
+	if _, ok := store.files[drop]; ok {
+		t.Error("orphaned file was not deleted")
+	}
+}
+
+func TestResolveFileRefs(t *testing.T) {
+	known := map[string]FileContent{"f1": {FileID: "f1", Hash: "h1", Size: 42}}
+
+	ref := HyprProgramConfig{Program: "kitty", FileContent: FileContent{FileID: "f1", Hash: "h1"}}
+	if err := ref.resolveFileRefs(known); err != nil {
+		t.Fatalf("known reference returned error: %v", err)
+	}
+	if ref.FileContent.Size != 42 {
+		t.Errorf("size not taken from the stored file, got %d", ref.FileContent.Size)
+	}
+
+	foreign := HyprProgramConfig{Program: "kitty", FileContent: FileContent{FileID: "f2", Hash: "h2"}}
+	if err := foreign.resolveFileRefs(known); !errors.Is(err, ErrUnknownFile) {
+		t.Errorf("foreign reference: got %v, want ErrUnknownFile", err)
+	}
+
+	replaced := HyprProgramConfig{Program: "kitty", FileContent: FileContent{FileID: "f2", Data: []byte("new")}}
+	if err := replaced.resolveFileRefs(known); err != nil {
+		t.Fatalf("reference with new data returned error: %v", err)
+	}
+	if replaced.FileContent.FileID != "" {
+		t.Error("new data did not replace the file reference")
+	}
+}
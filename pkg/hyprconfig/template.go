@@ -0,0 +1,182 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on the template variables of a config.
+const (
+	MaxTemplateVariables         = 50
+	MaxVariableDescriptionLength = 500
+	MaxVariableDefaultLength     = 1024
+)
+
+// CodeInvalidPlaceholder is the FieldError code of a malformed or undeclared {{placeholder}}.
+const CodeInvalidPlaceholder = "invalid_placeholder"
+
+var (
+	ErrInvalidPlaceholder = errors.New("invalid template placeholder")
+	ErrMissingVariable    = errors.New("missing template variable")
+)
+
+// variableNameRe is what the name of a template variable, and so a placeholder, may be.
+var variableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TemplateVariable is a value that differs per machine, such as a monitor name, written as
+// {{name}} in the files of a config and filled in when it is applied or exported.
+type TemplateVariable struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	Default     string `json:"default,omitempty" bson:"default,omitempty"`
+	Required    bool   `json:"required,omitempty" bson:"required,omitempty"` // a value must be given unless there is a Default
+}
+
+type templateValuesKey struct{}
+
+// WithTemplateValues returns a context whose exported configs fill in their template variables
+// with values, keyed by variable name.
+func WithTemplateValues(ctx context.Context, values map[string]string) context.Context {
+	return context.WithValue(ctx, templateValuesKey{}, values)
+}
+
+func templateValues(ctx context.Context) map[string]string {
+	values, _ := ctx.Value(templateValuesKey{}).(map[string]string)
+	return values
+}
+
+// templated reports whether placeholders in fc are substituted: only text and config files are,
+// or files without a type.
+func templated(fc *FileContent) bool {
+	switch fc.FileType {
+	case "", FileTypeText, FileTypeConfig:
+		return true
+	}
+	return false
+}
+
+// validateVariables records every invalid or duplicate variable of hc in verr.
+func (hc *HyprConfig) validateVariables(verr *ValidationError) {
+	if len(hc.Variables) > MaxTemplateVariables {
+		verr.addf("variables", CodeTooMany, "%d variables, at most %d allowed", len(hc.Variables), MaxTemplateVariables)
+	}
+	seen := map[string]bool{}
+	for i, v := range hc.Variables {
+		path := fmt.Sprintf("variables[%d]", i)
+		switch {
+		case v.Name == "":
+			verr.addf(path+".name", CodeRequired, "variable name cannot be empty")
+		case !variableNameRe.MatchString(v.Name):
+			verr.addf(path+".name", CodeInvalid, "variable name %q may only contain letters, digits and _, and not start with a digit", v.Name)
+		case seen[v.Name]:
+			verr.addf(path+".name", CodeInvalid, "variable %q is declared more than once", v.Name)
+		}
+		seen[v.Name] = true
+		if n := utf8.RuneCountInString(v.Description); n > MaxVariableDescriptionLength {
+			verr.addf(path+".description", CodeTooLong, "description is %d characters, at most %d allowed", n, MaxVariableDescriptionLength)
+		}
+		if n := utf8.RuneCountInString(v.Default); n > MaxVariableDefaultLength {
+			verr.addf(path+".default", CodeTooLong, "default is %d characters, at most %d allowed", n, MaxVariableDefaultLength)
+		}
+	}
+}
+
+// checkPlaceholders records every malformed or undeclared placeholder in the files of pc and its
+// sub configs in verr, at paths starting with prefix. Configs without variables aren't templated.
+func (hc *HyprConfig) checkPlaceholders(pc *HyprProgramConfig, prefix string, verr *ValidationError) {
+	if len(hc.Variables) == 0 {
+		return
+	}
+	declared := map[string]string{}
+	for _, v := range hc.Variables {
+		declared[v.Name] = ""
+	}
+	var check func(pc *HyprProgramConfig, prefix string)
+	check = func(pc *HyprProgramConfig, prefix string) {
+		pc.eachFile(func(field string, fc *FileContent) {
+			if templated(fc) && len(fc.Data) > 0 {
+				if _, err := expandTemplate(fc.Data, declared); err != nil {
+					verr.add(prefix+field+".data", fmt.Errorf("program %s: %w", pc.Program, err))
+				}
+			}
+		})
+		for i, sub := range pc.SubConfigs {
+			if sub != nil {
+				check(sub, fmt.Sprintf("%ssub_configs[%d].", prefix, i))
+			}
+		}
+	}
+	check(pc, prefix)
+}
+
+// resolveVariables returns the value of every variable of hc: the one in values, else its
+// default. Required variables left without a value are an ErrMissingVariable.
+func (hc *HyprConfig) resolveVariables(values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(hc.Variables))
+	var missing []string
+	for _, v := range hc.Variables {
+		value, ok := values[v.Name]
+		if !ok || value == "" {
+			value = v.Default
+		}
+		if value == "" && v.Required {
+			missing = append(missing, v.Name)
+		}
+		resolved[v.Name] = value
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: %s", ErrMissingVariable, strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
+// expandTemplate replaces every {{name}} placeholder in data, which may have spaces inside the
+// braces, with its value. \{{ is a literal {{. A placeholder that is malformed or whose name
+// isn't in values is an ErrInvalidPlaceholder.
+func expandTemplate(data []byte, values map[string]string) ([]byte, error) {
+	return expandTemplateLiteral(data, values, "{{")
+}
+
+// expandTemplateLiteral is expandTemplate writing literal for every \{{, for output that is
+// itself a template.
+func expandTemplateLiteral(data []byte, values map[string]string, literal string) ([]byte, error) {
+	var out bytes.Buffer
+	rest := data
+	for {
+		i := bytes.Index(rest, []byte("{{"))
+		if i < 0 {
+			out.Write(rest)
+			return out.Bytes(), nil
+		}
+		if i > 0 && rest[i-1] == '\\' {
+			out.Write(rest[:i-1])
+			out.WriteString(literal)
+			rest = rest[i+2:]
+			continue
+		}
+
+		line := bytes.Count(data[:len(data)-len(rest)+i], []byte("\n")) + 1
+		end := bytes.Index(rest[i+2:], []byte("}}"))
+		if end < 0 || bytes.IndexByte(rest[i+2:i+2+end], '\n') >= 0 {
+			return nil, fmt.Errorf("%w: unclosed {{ on line %d, write \\{{ for a literal {{", ErrInvalidPlaceholder, line)
+		}
+		name := strings.TrimSpace(string(rest[i+2 : i+2+end]))
+		if !variableNameRe.MatchString(name) {
+			return nil, fmt.Errorf("%w: {{%s}} on line %d is not a variable name", ErrInvalidPlaceholder, name, line)
+		}
+		value, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: {{%s}} on line %d is not a declared variable", ErrInvalidPlaceholder, name, line)
+		}
+		out.Write(rest[:i])
+		out.WriteString(value)
+		rest = rest[i+2+end+2:]
+	}
+}
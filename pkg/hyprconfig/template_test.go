@@ -0,0 +1,131 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	values := map[string]string{"monitor": "DP-1", "user": "alice"}
+	for in, want := range map[string]string{
+		"monitor = {{monitor}},preferred,auto,1": "monitor = DP-1,preferred,auto,1",
+		"path = /home/{{ user }}/{{user}}":       "path = /home/alice/alice",
+		`format = "\{{not a placeholder}}"`:      `format = "{{not a placeholder}}"`,
+		"no placeholders {single} }}":            "no placeholders {single} }}",
+	} {
+		if got, err := expandTemplate([]byte(in), values); err != nil || string(got) != want {
+			t.Errorf("expandTemplate(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	for in, msg := range map[string]string{
+		"a\nmonitor = {{monitr}}":   "{{monitr}} on line 2 is not a declared variable",
+		"{{ user-name }}":           "{{user-name}} on line 1 is not a variable name",
+		"{{monitor\n}}":             "unclosed {{ on line 1",
+		"exec = {{monitor} {{user}": "unclosed {{ on line 1",
+	} {
+		_, err := expandTemplate([]byte(in), values)
+		if !errors.Is(err, ErrInvalidPlaceholder) || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expandTemplate(%q): got %v, want an ErrInvalidPlaceholder with %q", in, err, msg)
+		}
+	}
+}
+
+func templatedConfig() *HyprConfig {
+	return &HyprConfig{
+		Title: "rice",
+		Variables: []TemplateVariable{
+			{Name: "monitor", Description: "output name, see hyprctl monitors", Required: true},
+			{Name: "terminal", Default: "kitty"},
+		},
+		ProgramConfigs: []HyprProgramConfig{
+			{ID: "hypr", Title: "hyprland", Program: "hyprland", FileContent: FileContent{
+				Data: []byte("monitor = {{monitor}},preferred,auto,1\n$terminal = {{terminal}}\n"), FileType: FileTypeConfig,
+			}},
+			// Scripts aren't templated
+			{ID: "script", Title: "script", Program: "waybar", InstallPath: "~/.config/waybar/launch.sh", FileContent: FileContent{
+				Data: []byte("echo {{ $1 }}\n"), FileType: FileTypeScript,
+			}},
+		},
+	}
+}
+
+func TestRenderConfigVariables(t *testing.T) {
+	files, err := RenderConfig(templatedConfig(), map[string]string{"monitor": "DP-1", "unused": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hypr := string(files[".config/hypr/hyprland.conf"]); !strings.HasPrefix(hypr, "monitor = DP-1,preferred,auto,1\n$terminal = kitty\n") {
+		t.Errorf("hyprland.conf = %q", hypr)
+	}
+	if script := string(files[".config/waybar/launch.sh"]); script != "echo {{ $1 }}\n" {
+		t.Errorf("launch.sh = %q", script)
+	}
+
+	if _, err := RenderConfig(templatedConfig(), map[string]string{"monitor": ""}); !errors.Is(err, ErrMissingVariable) || !strings.Contains(err.Error(), "monitor") {
+		t.Errorf("missing monitor: got %v, want ErrMissingVariable", err)
+	}
+
+	// Configs without variables are rendered as-is
+	cfg := templatedConfig()
+	cfg.Variables = nil
+	files, err = RenderConfig(cfg, nil)
+	if err != nil || !strings.HasPrefix(string(files[".config/hypr/hyprland.conf"]), "monitor = {{monitor}}") {
+		t.Errorf("untemplated config: %q, %v", files[".config/hypr/hyprland.conf"], err)
+	}
+}
+
+func TestTemplateValidation(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice := asUser("alice")
+		fieldErrors := func(err error) []string {
+			t.Helper()
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got %v, want a *ValidationError", err)
+			}
+			var got []string
+			for _, fe := range verr.Errors {
+				got = append(got, fe.Path+" "+fe.Code)
+			}
+			return got
+		}
+
+		bad := templatedConfig()
+		bad.Variables = append(bad.Variables, TemplateVariable{Name: "2fast"}, TemplateVariable{Name: "terminal"})
+		bad.ProgramConfigs[0].FileContent.Data = []byte("monitor = {{monitr}}\n")
+		_, err := m.CreateConfig(alice, bad)
+		want := "variables[2].name invalid,variables[3].name invalid,program_configs[0].file_content.data invalid_placeholder"
+		if got := strings.Join(fieldErrors(err), ","); got != want {
+			t.Errorf("problems = %s, want %s", got, want)
+		}
+
+		cfg, err := m.CreateConfig(alice, templatedConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = m.AddProgramConfig(alice, cfg.ID, HyprProgramConfig{ID: "kitty", Title: "kitty", Program: "kitty", FileContent: FileContent{
+			Data: []byte("font_size {{font_size}}\n"), FileType: FileTypeConfig,
+		}}, nil, "")
+		if got := strings.Join(fieldErrors(err), ","); got != "file_content.data invalid_placeholder" {
+			t.Errorf("adding a program with an undeclared placeholder: %s", got)
+		}
+
+		// Dropping a variable still used by a file is rejected
+		err = m.UpdateConfig(alice, cfg.ID, map[string]any{"variables": []TemplateVariable{{Name: "terminal"}}}, UpdateOptions{})
+		if got := strings.Join(fieldErrors(err), ","); got != "program_configs[0].file_content.data invalid_placeholder" {
+			t.Errorf("dropping a used variable: %s", got)
+		}
+
+		var buf strings.Builder
+		ctx := WithTemplateValues(context.Background(), map[string]string{"monitor": "HDMI-A-1"})
+		if err := m.ExportConfigArchive(ctx, cfg.ID, &buf); err != nil {
+			t.Errorf("export with values: %v", err)
+		}
+		if err := m.ExportConfigArchive(context.Background(), cfg.ID, &buf); !errors.Is(err, ErrMissingVariable) {
+			t.Errorf("export without values: got %v, want ErrMissingVariable", err)
+		}
+	})
+}
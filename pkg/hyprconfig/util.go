@@ -1,13 +1,15 @@
 package hyprconfig
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"net/http"
 	"os"
-	"regexp"
+	"path"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Seann-Moser/credentials/session"
 	"github.com/Seann-Moser/hypr-config-manager/pkg/utils"
@@ -25,16 +27,127 @@ func getUserFromContext(ctx context.Context) (*session.UserSessionData, error) {
 	return user, nil
 }
 
+// AdminRole is the role, by rbac role id, that may moderate configs and manage the server.
+const AdminRole = "admin"
+
 func isAdmin(roles []string) bool {
 	for _, r := range roles {
-		if r == "admin" {
+		if r == AdminRole {
 			return true
 		}
 	}
 	return false
 }
 
+// validationError marks an error as caused by invalid input without changing its message.
+type validationError struct{ error }
+
+func (e validationError) Unwrap() error        { return e.error }
+func (e validationError) Is(target error) bool { return target == ErrValidation }
+
+// invalidf formats an error that matches ErrValidation.
+func invalidf(format string, args ...any) error {
+	return validationError{fmt.Errorf(format, args...)}
+}
+
+// searchSort validates a ConfigSearchFilters.Sort value, defaulting to SearchSortUpdated.
+func searchSort(sort string) (string, error) {
+	switch sort {
+	case "":
+		return SearchSortUpdated, nil
+	case SearchSortUpdated, SearchSortCreated, SearchSortLikes, SearchSortTitle, SearchSortVerified:
+		return sort, nil
+	}
+	return "", invalidf("unknown sort %q", sort)
+}
+
+// normalize canonicalizes the platform, resolution, colors and license of filters.
+func (f *ConfigSearchFilters) normalize() error {
+	if f.Platform != "" {
+		platform, err := NormalizePlatform(f.Platform)
+		if err != nil {
+			return err
+		}
+		f.Platform = platform
+	}
+	if f.Monitors < 0 {
+		return invalidf("invalid monitors %d: must not be negative", f.Monitors)
+	}
+	if f.Resolution != "" {
+		res, err := normalizeResolution(f.Resolution)
+		if err != nil {
+			return err
+		}
+		f.Resolution = res
+	}
+	if err := f.normalizeColorFilters(); err != nil {
+		return err
+	}
+	if f.HyprlandVersion != "" {
+		v, err := NormalizeHyprlandVersion(f.HyprlandVersion)
+		if err != nil {
+			return err
+		}
+		f.HyprlandVersion = v
+	}
+	if strings.EqualFold(strings.TrimSpace(f.License), LicenseUnspecified) {
+		f.License = LicenseUnspecified
+	} else if f.License != "" {
+		license, err := NormalizeLicense(f.License)
+		if err != nil {
+			return err
+		}
+		f.License = license
+	}
+	return nil
+}
+
+// licenseFilterValue is the License stored for configs matching the license filter.
+func licenseFilterValue(license string) string {
+	if license == LicenseUnspecified {
+		return ""
+	}
+	return license
+}
+
 func buildSearchFilter(filters ConfigSearchFilters, user *session.UserSessionData) bson.M {
+	andParts := searchFilterParts(filters)
+
+	// 🔒 Respect visibility rules:
+	// Private configs only visible to owners or admins
+	orClause := []bson.M{
+		{"private": false},
+	}
+
+	if user != nil {
+		orClause = append(orClause, bson.M{
+			"owner_id": user.UserID,
+		})
+	}
+
+	// Final Filter
+	finalFilter := bson.M{
+		"$or": []bson.M(orClause),
+	}
+
+	if len(andParts) > 0 {
+		finalFilter["$and"] = andParts
+	}
+
+	return finalFilter
+}
+
+// buildAdminSearchFilter is buildSearchFilter without the visibility rules.
+func buildAdminSearchFilter(filters ConfigSearchFilters) bson.M {
+	andParts := searchFilterParts(filters)
+	if len(andParts) == 0 {
+		return bson.M{}
+	}
+	return bson.M{"$and": andParts}
+}
+
+// searchFilterParts returns the conditions of filters, which all have to match.
+func searchFilterParts(filters ConfigSearchFilters) []bson.M {
 	andParts := []bson.M{}
 
 	// 🔍 Text Search (title, description, tags)
@@ -63,6 +176,54 @@ func buildSearchFilter(filters ConfigSearchFilters, user *session.UserSessionDat
 		})
 	}
 
+	// 🐧 Platform filter: no required program may be limited to other platforms
+	if filters.Platform != "" {
+		andParts = append(andParts, bson.M{
+			"program_configs": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+				"optional":   bson.M{"$ne": true},
+				"platform.0": bson.M{"$exists": true},
+				"platform":   bson.M{"$nin": []string{filters.Platform}},
+			}}},
+		})
+	}
+
+	// 🖥 Display filters, configs without a layout fit any number of monitors
+	if filters.Monitors > 0 {
+		andParts = append(andParts, bson.M{
+			"display.monitor_count": bson.M{"$in": bson.A{nil, 0, filters.Monitors}},
+		})
+	}
+	if filters.Resolution != "" {
+		andParts = append(andParts, bson.M{"display.resolutions": filters.Resolution})
+	}
+
+	// 🎨 Theme and palette filters, every searched color needs a palette color close to it
+	if filters.Theme != "" {
+		andParts = append(andParts, bson.M{"theme": filters.Theme})
+	}
+	for _, c := range filters.paletteColors() {
+		t := filters.ColorTolerance
+		andParts = append(andParts, bson.M{"palette": bson.M{"$elemMatch": bson.M{
+			"r": bson.M{"$gte": c.R - t, "$lte": c.R + t},
+			"g": bson.M{"$gte": c.G - t, "$lte": c.G + t},
+			"b": bson.M{"$gte": c.B - t, "$lte": c.B + t},
+		}}})
+	}
+
+	// 📜 License filter, configs without one are stored without the field
+	if filters.License == LicenseUnspecified {
+		andParts = append(andParts, bson.M{"license": bson.M{"$in": bson.A{nil, ""}}})
+	} else if filters.License != "" {
+		andParts = append(andParts, bson.M{"license": filters.License})
+	}
+
+	// 🧩 Hyprland version filter, configs without a minimum work on any version
+	if filters.HyprlandVersion != "" {
+		andParts = append(andParts, bson.M{
+			"hyprland_version_key": bson.M{"$not": bson.M{"$gt": hyprlandVersionKey(filters.HyprlandVersion)}},
+		})
+	}
+
 	// 👤 Owner filter
 	if filters.OwnerID != "" {
 		andParts = append(andParts, bson.M{
@@ -77,6 +238,24 @@ func buildSearchFilter(filters ConfigSearchFilters, user *session.UserSessionDat
 		})
 	}
 
+	// ✅ Verified filter, unverified configs have no verified field
+	if filters.Verified != nil {
+		if *filters.Verified {
+			andParts = append(andParts, bson.M{"verified": true})
+		} else {
+			andParts = append(andParts, bson.M{"verified": bson.M{"$ne": true}})
+		}
+	}
+
+	// 🪦 Deprecated filter, like verified the field is missing unless set
+	if filters.Deprecated != nil {
+		if *filters.Deprecated {
+			andParts = append(andParts, bson.M{"deprecated": true})
+		} else {
+			andParts = append(andParts, bson.M{"deprecated": bson.M{"$ne": true}})
+		}
+	}
+
 	// 🕒 Date Range Filter
 	if filters.UpdatedFrom != nil || filters.UpdatedTo != nil {
 		rangeFilter := bson.M{}
@@ -89,28 +268,17 @@ func buildSearchFilter(filters ConfigSearchFilters, user *session.UserSessionDat
 		andParts = append(andParts, bson.M{"updated_timestamp": rangeFilter})
 	}
 
-	// 🔒 Respect visibility rules:
-	// Private configs only visible to owners or admins
-	orClause := []bson.M{
-		{"private": false},
-	}
-
-	if user != nil {
-		orClause = append(orClause, bson.M{
-			"owner_id": user.UserID,
-		})
-	}
+	return andParts
+}
 
-	// Final Filter
-	finalFilter := bson.M{
-		"$or": []bson.M(orClause),
+// NormalizeProgramName trims and lowercases a program name and reduces
+// absolute or relative executable paths (e.g. /usr/bin/waybar) to their basename.
+func NormalizeProgramName(name string) string {
+	name = strings.TrimSpace(name)
+	if strings.Contains(name, "/") {
+		name = path.Base(name)
 	}
-
-	if len(andParts) > 0 {
-		finalFilter["$and"] = andParts
-	}
-
-	return finalFilter
+	return strings.ToLower(name)
 }
 
 // StringSlicesEqual checks if two slices contain the same set of strings,
@@ -146,134 +314,101 @@ func StringSlicesEqual(a, b []string) bool {
 	return true
 }
 
-func ExtractLines(filePath string) ([]string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
+var ignore = map[string]struct{}{
+	"va11-popup":   {},
+	"va11-confirm": {},
+}
 
-	var sourceLines []string
-	var customStartReached bool
-	var customSection []string
+// ExecCommand is a program launched by an exec or exec-once line of a Hyprland config.
+type ExecCommand struct {
+	Program string // the binary, with $variables resolved
+	Line    string // the whole command line after exec= or exec-once=, comments removed
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
+// execKeywords are the Hyprland keywords whose value is a command line.
+var execKeywords = map[string]bool{"exec": true, "exec-once": true, "execr": true, "execr-once": true}
 
-		// Check if the line starts with "source="
-		if strings.HasPrefix(line, "source=") {
-			sourceLines = append(sourceLines, line)
-		}
+// ExtractExecCommands returns every program launched by exec or exec-once lines (including
+// binds) in input, in file order, with the full command line it is launched from. A line that
+// runs several programs separated by &, && or ; yields one ExecCommand per program. Programs
+// launched through one of the ExecWrappers, like uwsm app -- waybar or sh -c "sleep 1 && swww
+// init", are reported instead of the wrapper.
+func ExtractExecCommands(input string) []ExecCommand {
+	pairs := ParseKeyValuePairs(input)
 
-		// Start of CUSTOM section
-		if line == "### CUSTOM START" {
-			customStartReached = true
-			customSection = append(customSection, line)
-			continue
+	var commands []ExecCommand
+	ParseHyprDoc([]byte(input)).Walk(func(_ string, n *HyprNode) {
+		commandLine, ok := execCommandLine(n)
+		if !ok {
+			return
 		}
 
-		// End of CUSTOM section
-		if line == "### CUSTOM END" && customStartReached {
-			customSection = append(customSection, line)
-			break
+		// Hyprland substitutes its variables before the shell sees the line
+		for _, words := range shellCommands(expandHyprVariables(commandLine, pairs, nil)) {
+			for _, program := range execPrograms(words, 0) {
+				if _, ok := ignore[program]; ok {
+					continue
+				}
+				commands = append(commands, ExecCommand{Program: program, Line: commandLine})
+			}
 		}
+	})
+	return commands
+}
 
-		// Collect lines inside CUSTOM section
-		if customStartReached {
-			customSection = append(customSection, line)
-		}
+// execCommandLine returns the command line n runs: the value of an exec keyword, or the
+// arguments of a bind to the exec dispatcher.
+func execCommandLine(n *HyprNode) (string, bool) {
+	if n.Kind != HyprNodeKeyword {
+		return "", false
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if execKeywords[n.Key] {
+		return n.Value, n.Value != ""
 	}
-
-	// Print or return the extracted lines
-	fmt.Println("Source Lines:")
-	for _, line := range sourceLines {
-		fmt.Println(line)
+	match := bindLineRe.FindStringSubmatch(n.Key)
+	if match == nil {
+		return "", false
 	}
-
-	fmt.Println("\nCustom Section:")
-	for _, line := range customSection {
-		fmt.Println(line)
+	fields := strings.SplitN(n.Value, ",", 4)
+	if strings.Contains(match[1], "d") && len(fields) == 4 {
+		fields = append(fields[:2], strings.SplitN(fields[3], ",", 2)...)
 	}
-
-	return sourceLines, nil
-}
-
-// ParseKeyValuePairs takes a string and returns a map of key-value pairs
-func ParseKeyValuePairs(input string) map[string]string {
-	// Define a regular expression to match the pattern "$key = value"
-	re := regexp.MustCompile(`\$(\w+)\s*=\s*(\S+)`)
-
-	// Create a map to store the key-value pairs
-	result := make(map[string]string)
-
-	// Find all matches in the input string
-	matches := re.FindAllStringSubmatch(input, -1)
-
-	// Loop through each match and populate the map
-	for _, match := range matches {
-		// match[1] is the key (e.g., "terminal"), and match[2] is the value (e.g., "kitty")
-		result["$"+match[1]] = match[2]
+	if len(fields) < 4 || !execKeywords[strings.TrimSpace(fields[2])] {
+		return "", false
 	}
-
-	return result
+	commandLine := strings.TrimSpace(fields[3])
+	return commandLine, commandLine != ""
 }
 
-var ignore = map[string]struct{}{
-	"va11-popup":   {},
-	"va11-confirm": {},
+// stripHyprComment cuts a Hyprland line at the first # that is not escaped as ##.
+func stripHyprComment(line string) string {
+	code, _, _ := cutHyprComment(line)
+	return code
 }
 
-// ExtractExecOnceCommands takes a multi-line string and returns a list of commands and arguments, separated
-func ExtractExecOnceCommands(input string) []string {
-	// Regular expression to match lines with exec or exec-once
-	pairs := ParseKeyValuePairs(input)
-	reList := []*regexp.Regexp{
-		regexp.MustCompile(`#*\s*exec-once\s*=\s*([^\n]+)`),
-		regexp.MustCompile(`#*\s*exec\s*[=,]\s*([^\n]+)`),
+// cutHyprComment splits a Hyprland line around the first # that is not escaped as ##, returning
+// the text before it and the comment after it.
+func cutHyprComment(line string) (code, comment string, found bool) {
+	for i := 0; i < len(line); i++ {
+		if line[i] != '#' {
+			continue
+		}
+		if i+1 < len(line) && line[i+1] == '#' {
+			i++
+			continue
+		}
+		return line[:i], line[i+1:], true
 	}
+	return line, "", false
+}
 
+// ExtractExecOnceCommands takes a multi-line string and returns the deduplicated programs
+// launched by its exec and exec-once lines. Use ExtractExecCommands for the full command lines.
+func ExtractExecOnceCommands(input string) []string {
 	var commands []string
-	for _, re := range reList {
-		// Find all matches for the exec or exec-once pattern
-		matches := re.FindAllStringSubmatch(input, -1)
-
-		for _, match := range matches {
-			// match[1] contains the command and its arguments (after exec= or exec-once=)
-			if strings.Contains(match[0], "#") {
-				continue
-			}
-			commandLine := match[1]
-
-			// Split by '&' or '&&' to handle both simple background execution and sequential execution
-			parts := strings.FieldsFunc(commandLine, func(c rune) bool {
-				return c == '&' || c == '\n' || c == ';'
-			})
-
-			for _, part := range parts {
-				// Trim whitespace and split by spaces to handle command with arguments
-				pts := strings.Fields(strings.TrimSpace(part))
-				if len(pts) > 0 {
-					if v, ok := pairs[pts[0]]; ok {
-						if _, ok := ignore[strings.TrimSpace(v)]; ok {
-							continue
-						}
-						commands = append(commands, strings.TrimSpace(v)) // Get only the main command
-					} else {
-						if _, ok := ignore[strings.TrimSpace(pts[0])]; ok {
-							continue
-						}
-						commands = append(commands, strings.TrimSpace(pts[0])) // Get only the main command
-					}
-				}
-			}
-		}
+	for _, cmd := range ExtractExecCommands(input) {
+		commands = append(commands, cmd.Program)
 	}
-
 	return utils.DeduplicateStrings(commands)
 }
 
@@ -286,3 +421,30 @@ func ExtractExecOnceCommandsFile(input string) ([]string, error) {
 	}
 	return ExtractExecOnceCommands(string(data)), nil
 }
+
+// DetectFileType guesses the FileType of a file from its name and content.
+func DetectFileType(name string, data []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	switch ext {
+	case ".sh", ".bash", ".zsh", ".fish", ".py":
+		return FileTypeScript
+	case ".conf", ".ini", ".toml", ".json", ".jsonc", ".yaml", ".yml", ".css", ".rasi", ".lua":
+		return FileTypeConfig
+	}
+	if bytes.HasPrefix(data, []byte("#!")) {
+		return FileTypeScript
+	}
+
+	contentType := http.DetectContentType(data)
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return FileTypeImage
+	case strings.HasPrefix(contentType, "text/") && utf8.Valid(data):
+		if name == "config" || strings.HasPrefix(name, "config.") {
+			return FileTypeConfig
+		}
+		return FileTypeText
+	default:
+		return FileTypeBinary
+	}
+}
@@ -0,0 +1,124 @@
+package hyprconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Codes of a FieldError, so clients can react to a problem without parsing its message.
+const (
+	CodeRequired           = "required"
+	CodeInvalid            = "invalid"
+	CodeInvalidProgram     = "invalid_program"
+	CodeInvalidEnvVar      = "invalid_env_var"
+	CodeInvalidPlatform    = "invalid_platform"
+	CodeInvalidLicense     = "invalid_license"
+	CodeTooLarge           = "too_large"
+	CodeBinaryNotAllowed   = "binary_not_allowed"
+	CodeMissingHash        = "missing_hash"
+	CodeHashMismatch       = "hash_mismatch"
+	CodeDependencyCycle    = "dependency_cycle"
+	CodeInvalidInstallPath = "invalid_install_path"
+	CodeInvalidFileMode    = "invalid_file_mode"
+)
+
+// FieldError is one problem found by Validate. Path points at the offending field in the JSON
+// document that was validated, e.g. program_configs[2].file_content.data.
+type FieldError struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	err error // the error the problem was found with, for errors.Is
+}
+
+// ValidationError lists every problem Validate found. It matches ErrValidation and the
+// errors of its problems, e.g. ErrContentTooLarge.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		problems[i] = fe.Path + ": " + fe.Message
+	}
+	if len(problems) == 1 {
+		return problems[0]
+	}
+	return fmt.Sprintf("%d problems: %s", len(problems), strings.Join(problems, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		if fe.err != nil {
+			errs = append(errs, fe.err)
+		}
+	}
+	return errs
+}
+
+// add records err as a problem at path, with the code of the error it wraps.
+func (e *ValidationError) add(path string, err error) {
+	e.Errors = append(e.Errors, FieldError{Path: path, Code: validationCode(err), Message: err.Error(), err: err})
+}
+
+// addf records a problem at path that wraps no other error.
+func (e *ValidationError) addf(path, code, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// nest records the problems of a nested Validate call below prefix.
+func (e *ValidationError) nest(prefix string, err error) {
+	var nested *ValidationError
+	if !errors.As(err, &nested) {
+		e.add(prefix, err)
+		return
+	}
+	for _, fe := range nested.Errors {
+		fe.Path = prefix + "." + fe.Path
+		e.Errors = append(e.Errors, fe)
+	}
+}
+
+// errOrNil returns e when it holds any problem.
+func (e *ValidationError) errOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func validationCode(err error) string {
+	switch {
+	case errors.Is(err, ErrContentTooLarge):
+		return CodeTooLarge
+	case errors.Is(err, ErrBinaryNotAllowed):
+		return CodeBinaryNotAllowed
+	case errors.Is(err, ErrMissingHash):
+		return CodeMissingHash
+	case errors.Is(err, ErrHashMismatch):
+		return CodeHashMismatch
+	case errors.Is(err, ErrInvalidEnvVar):
+		return CodeInvalidEnvVar
+	case errors.Is(err, ErrInvalidPlatform):
+		return CodeInvalidPlatform
+	case errors.Is(err, ErrInvalidLicense):
+		return CodeInvalidLicense
+	case errors.Is(err, ErrInvalidPlaceholder):
+		return CodeInvalidPlaceholder
+	case errors.Is(err, ErrDependencyCycle):
+		return CodeDependencyCycle
+	case errors.Is(err, ErrInvalidInstallPath):
+		return CodeInvalidInstallPath
+	case errors.Is(err, ErrInvalidFileMode):
+		return CodeInvalidFileMode
+	case errors.Is(err, ErrUnsafeCommand):
+		return CodeUnsafeCommand
+	}
+	return CodeInvalid
+}
@@ -0,0 +1,99 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidateReportsEveryProblem(t *testing.T) {
+	data := []byte("font_size 12\nexec-once = mystery\n")
+	cfg := HyprConfig{
+		ProgramConfigs: []HyprProgramConfig{
+			{Title: "term", Program: "kitty", FileContent: FileContent{Data: data, FileType: FileTypeConfig, Hash: "deadbeef"}},
+			{Title: "bar", Program: "notabar", Platform: []string{"arch", "beos"}, EnvVars: map[string]string{
+				"LD_PRELOAD": "/tmp/evil.so",
+				"OK":         "1",
+				"1BAD":       "x",
+			}},
+			{Title: "launcher", Program: "wofi", SubConfigs: []*HyprProgramConfig{
+				{Title: "style", Program: "wofi", FileContent: FileContent{Data: make([]byte, 15), FileType: FileTypeConfig}},
+			}},
+		},
+	}
+
+	err := cfg.Validate(context.Background(), allowOnly(), SizeLimits{MaxTextBytes: 10})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a *ValidationError", err)
+	}
+
+	type problem struct{ path, code string }
+	var got []problem
+	for _, fe := range verr.Errors {
+		got = append(got, problem{fe.Path, fe.Code})
+		if fe.Message == "" {
+			t.Errorf("%s: empty message", fe.Path)
+		}
+	}
+	want := []problem{
+		{"title", CodeRequired},
+		{"program_configs[0].file_content.data", CodeTooLarge},
+		{"program_configs[1].program", CodeInvalidProgram},
+		{"program_configs[1].env_vars.1BAD", CodeInvalidEnvVar},
+		{"program_configs[1].env_vars.LD_PRELOAD", CodeInvalidEnvVar},
+		{"program_configs[1].platform[1]", CodeInvalidPlatform},
+		{"program_configs[2].sub_configs[0].file_content.data", CodeTooLarge},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems =\n%v\nwant\n%v", got, want)
+	}
+
+	for _, target := range []error{ErrValidation, ErrContentTooLarge, ErrInvalidEnvVar, ErrInvalidPlatform} {
+		if !errors.Is(err, target) {
+			t.Errorf("error does not match %v", target)
+		}
+	}
+	if errors.Is(err, ErrHashMismatch) {
+		t.Error("oversized content should not be hashed")
+	}
+}
+
+func TestProgramConfigValidateContentProblems(t *testing.T) {
+	data := []byte("exec-once = mystery\nexec-once = mystery --again\nexec-once = other\n")
+	pc := HyprProgramConfig{Title: "hyprland", Program: "hyprland", FileContent: FileContent{Data: data, Hash: "deadbeef"}}
+
+	err := pc.Validate(context.Background(), allowOnly(), SizeLimits{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 3 {
+		t.Fatalf("got %v, want a hash mismatch and two unknown programs", err)
+	}
+	if fe := verr.Errors[0]; fe.Path != "file_content.hash" || fe.Code != CodeHashMismatch {
+		t.Errorf("first problem = %+v, want a hash mismatch", fe)
+	}
+	for _, fe := range verr.Errors[1:] {
+		if fe.Path != "file_content.data" || fe.Code != CodeInvalidProgram {
+			t.Errorf("problem = %+v, want an unknown exec-once program", fe)
+		}
+	}
+}
+
+func TestProgramConfigValidateWrappedPrograms(t *testing.T) {
+	data := []byte(`exec-once = uwsm app -- waybar
+exec-once = sh -c "sleep 1 && swww init"
+exec-once = env GDK_BACKEND=wayland /usr/bin/kitty
+exec-once = systemd-run --user --scope -p MemoryMax=1G mystery
+`)
+	pc := HyprProgramConfig{Title: "hyprland", Program: "hyprland", FileContent: FileContent{Data: data}}
+
+	err := pc.Validate(context.Background(), allowOnly("hyprland", "waybar", "sleep", "swww", "kitty"), SizeLimits{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 {
+		t.Fatalf("got %v, want only the unknown program", err)
+	}
+	if fe := verr.Errors[0]; fe.Code != CodeInvalidProgram || !strings.Contains(fe.Message, "mystery") {
+		t.Errorf("problem = %+v, want mystery rejected", fe)
+	}
+}
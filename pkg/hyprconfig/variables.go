@@ -0,0 +1,94 @@
+package hyprconfig
+
+import (
+	"maps"
+	"regexp"
+)
+
+// hyprVariableRe matches the name of a Hyprland variable declaration, like $mainMod.
+var hyprVariableRe = regexp.MustCompile(`^\$\w+$`)
+
+// hyprVariableRefRe matches a reference to a variable in a value.
+var hyprVariableRefRe = regexp.MustCompile(`\$\w+`)
+
+// maxVariablePasses bounds the substitution passes of resolveHyprVariables, and so how long a
+// chain of variables referring to each other gets resolved.
+const maxVariablePasses = 32
+
+// ParseKeyValuePairs returns the $variables declared in a Hyprland config, keyed with their $,
+// each with its whole value up to any comment. References to other variables in a value are
+// resolved, so with $launcher = wofi, $menu = $launcher --show drun is "wofi --show drun".
+// References to undeclared variables and between variables in a cycle are kept as written. A
+// variable declared twice keeps its last value.
+func ParseKeyValuePairs(input string) map[string]string {
+	return resolveHyprVariables(ParseHyprDoc([]byte(input)).Variables())
+}
+
+// resolveHyprVariables returns vars with the references in their values substituted until none
+// that can be resolved are left, or maxVariablePasses passes were made.
+func resolveHyprVariables(vars map[string]string) map[string]string {
+	cyclic := hyprVariableCycles(vars)
+	resolved := maps.Clone(vars)
+	for range maxVariablePasses {
+		changed := false
+		for name, value := range resolved {
+			if next := expandHyprVariables(value, resolved, cyclic); next != value {
+				resolved[name] = next
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return resolved
+}
+
+// expandHyprVariables replaces the references in s to the variables of vars with their values,
+// once, leaving those to the variables in skip.
+func expandHyprVariables(s string, vars map[string]string, skip map[string]bool) string {
+	return hyprVariableRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if value, ok := vars[ref]; ok && !skip[ref] {
+			return value
+		}
+		return ref
+	})
+}
+
+// hyprVariableCycles returns the variables of vars that refer back to themselves, directly or
+// through others.
+func hyprVariableCycles(vars map[string]string) map[string]bool {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	cyclic := map[string]bool{}
+	var visit func(name string, path []string)
+	visit = func(name string, path []string) {
+		switch state[name] {
+		case done:
+			return
+		case visiting:
+			for i := len(path) - 1; i >= 0; i-- {
+				cyclic[path[i]] = true
+				if path[i] == name {
+					break
+				}
+			}
+			return
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, ref := range hyprVariableRefRe.FindAllString(vars[name], -1) {
+			if _, ok := vars[ref]; ok {
+				visit(ref, path)
+			}
+		}
+		state[name] = done
+	}
+	for name := range vars {
+		visit(name, nil)
+	}
+	return cyclic
+}
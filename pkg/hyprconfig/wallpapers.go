@@ -0,0 +1,172 @@
+package hyprconfig
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// wallpaperPrograms are the programs whose files set wallpapers: Hyprland through exec lines
+// running swww or swaybg, and hyprpaper through its own config.
+var wallpaperPrograms = map[string]struct{}{
+	"hyprland":  {},
+	"hyprpaper": {},
+}
+
+// WallpaperRef is an image a config sets as wallpaper.
+type WallpaperRef struct {
+	ProgramID string `json:"program_id"`
+	File      string `json:"file"`                // target path of the file referencing it
+	Path      string `json:"path"`                // as written, e.g. /home/me/Pictures/wall.png
+	HomePath  string `json:"home_path,omitempty"` // relative to $HOME, empty outside it
+
+	// Bundled is set when a file of the config installs to HomePath.
+	Bundled bool `json:"bundled"`
+}
+
+// ParseWallpaperPaths returns the wallpaper images referenced by a hyprpaper config (preload,
+// wallpaper and path lines) or by exec lines of a Hyprland config running swww img or swaybg -i,
+// each once and in order. Paths built from variables other than a leading $HOME are skipped.
+func ParseWallpaperPaths(data []byte) []string {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(p string) {
+		p = strings.Trim(strings.TrimSpace(p), `"'`)
+		rest := strings.TrimPrefix(p, "$HOME/")
+		if p == "" || strings.Contains(rest, "$") || seen[p] {
+			return
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	hyprLines(data, func(_ int, keyword, value string) {
+		switch keyword {
+		case "preload", "path":
+			add(value)
+		case "wallpaper":
+			// wallpaper = monitor, [contain:|tile:]path
+			if _, p, ok := strings.Cut(value, ","); ok {
+				p = strings.TrimSpace(p)
+				for _, mode := range []string{"contain:", "tile:"} {
+					p = strings.TrimPrefix(p, mode)
+				}
+				add(p)
+			}
+		case "exec", "exec-once":
+			for _, p := range execWallpapers(value) {
+				add(p)
+			}
+		}
+	})
+	return paths
+}
+
+// execWallpapers returns the images an exec line passes to swww img or swaybg.
+func execWallpapers(command string) []string {
+	var paths []string
+	for _, part := range strings.FieldsFunc(command, func(r rune) bool { return r == ';' || r == '&' || r == '|' }) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		switch path.Base(fields[0]) {
+		case "swww":
+			for i := 1; i+1 < len(fields); i++ {
+				if fields[i] == "img" && !strings.HasPrefix(fields[i+1], "-") {
+					paths = append(paths, fields[i+1])
+					break
+				}
+			}
+		case "swaybg":
+			for i := 1; i < len(fields); i++ {
+				if p, ok := strings.CutPrefix(fields[i], "--image="); ok {
+					paths = append(paths, p)
+				} else if (fields[i] == "-i" || fields[i] == "--image") && i+1 < len(fields) {
+					paths = append(paths, fields[i+1])
+				}
+			}
+		}
+	}
+	return paths
+}
+
+// wallpaperHomePath returns a wallpaper path as a clean path relative to $HOME, or "" when it
+// isn't below a home directory. ~/ and $HOME/ paths are below the current user's home, and so
+// are absolute paths below /home/<user>/ or /root/, as written by whoever exported the config.
+func wallpaperHomePath(p string) string {
+	var rest string
+	switch {
+	case strings.HasPrefix(p, "~/"):
+		rest = p[2:]
+	case strings.HasPrefix(p, "$HOME/"):
+		rest = p[len("$HOME/"):]
+	case strings.HasPrefix(p, "/root/"):
+		rest = p[len("/root/"):]
+	case strings.HasPrefix(p, "/home/"):
+		_, after, ok := strings.Cut(p[len("/home/"):], "/")
+		if !ok {
+			return ""
+		}
+		rest = after
+	default:
+		return ""
+	}
+	rest = path.Clean(rest)
+	if rest == "." || rest == ".." || strings.HasPrefix(rest, "../") {
+		return ""
+	}
+	return rest
+}
+
+// Wallpapers returns the wallpapers set by the Hyprland and hyprpaper files of cfg, sub configs
+// included, marking those a file of cfg installs. Install paths outside DefaultInstallPrefixes
+// and extraPrefixes can't hold a bundled wallpaper. Offloaded files are skipped.
+func Wallpapers(cfg *HyprConfig, extraPrefixes ...string) []WallpaperRef {
+	installed := map[string]bool{}
+	cfg.Walk(func(pc *HyprProgramConfig) {
+		for _, e := range pc.FileEntries() {
+			if p, err := pc.FilePath(e, extraPrefixes...); err == nil {
+				installed[p] = true
+			}
+		}
+	})
+
+	var refs []WallpaperRef
+	seen := map[string]bool{}
+	eachProgramFile(cfg, wallpaperPrograms, func(pc *HyprProgramConfig, e FileEntry, data []byte) {
+		for _, p := range ParseWallpaperPaths(data) {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			ref := WallpaperRef{ProgramID: pc.ID, File: e.TargetPath, Path: p, HomePath: wallpaperHomePath(p)}
+			ref.Bundled = ref.HomePath != "" && installed[ref.HomePath]
+			refs = append(refs, ref)
+		}
+	})
+	return refs
+}
+
+// WallpaperWarnings returns a validation warning for each wallpaper of cfg that isn't bundled
+// with it, so whoever applies the config knows to provide the image.
+func WallpaperWarnings(cfg *HyprConfig, extraPrefixes ...string) []string {
+	var warnings []string
+	for _, w := range Wallpapers(cfg, extraPrefixes...) {
+		if !w.Bundled {
+			warnings = append(warnings, fmt.Sprintf("wallpaper %s is not bundled with the config and has to be installed separately", w.Path))
+		}
+	}
+	return warnings
+}
+
+// homeRelativeWallpapers rewrites the absolute wallpaper paths below a home directory in data
+// to ~/ paths, so they point into the home directory of whoever applies the config.
+func homeRelativeWallpapers(data []byte) []byte {
+	for _, p := range ParseWallpaperPaths(data) {
+		if home := wallpaperHomePath(p); strings.HasPrefix(p, "/") && home != "" {
+			data = bytes.ReplaceAll(data, []byte(p), []byte("~/"+home))
+		}
+	}
+	return data
+}
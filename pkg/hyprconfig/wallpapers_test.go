@@ -0,0 +1,83 @@
+package hyprconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWallpaperPaths(t *testing.T) {
+	data := []byte(`preload = ~/Pictures/wall.png
+preload = /home/alice/Pictures/beach.jpg
+wallpaper = DP-1, /home/alice/Pictures/beach.jpg
+wallpaper = , contain:$HOME/Pictures/center.png
+wallpaper = HDMI-A-1, $wallpaper_dir/skipped.png
+# preload = ~/Pictures/commented.png
+exec-once = swww-daemon & swww img "/root/walls/night.png" --transition-type fade
+exec-once = swaybg -m fill -i /usr/share/backgrounds/default.png
+exec = swaybg --image=~/Pictures/wall.png
+`)
+	want := []string{
+		"~/Pictures/wall.png",
+		"/home/alice/Pictures/beach.jpg",
+		"$HOME/Pictures/center.png",
+		"/root/walls/night.png",
+		"/usr/share/backgrounds/default.png",
+	}
+	if got := ParseWallpaperPaths(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseWallpaperPaths() = %q, want %q", got, want)
+	}
+
+	for p, want := range map[string]string{
+		"~/Pictures/wall.png":            "Pictures/wall.png",
+		"$HOME/Pictures/wall.png":        "Pictures/wall.png",
+		"/home/alice/Pictures/wall.png":  "Pictures/wall.png",
+		"/root/walls/../wall.png":        "wall.png",
+		"/home/alice":                    "",
+		"~/../bob/wall.png":              "",
+		"/usr/share/backgrounds/a.png":   "",
+		"Pictures/relative-to-where.png": "",
+	} {
+		if got := wallpaperHomePath(p); got != want {
+			t.Errorf("wallpaperHomePath(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestWallpapers(t *testing.T) {
+	cfg := &HyprConfig{ProgramConfigs: []HyprProgramConfig{{
+		ID: "hypr", Program: "hyprland",
+		FileContent: FileContent{Data: []byte("exec-once = swww img /home/alice/Pictures/wall.png\n"), FileType: FileTypeConfig},
+		Files: []FileEntry{
+			{TargetPath: "~/Pictures/wall.png", FileContent: FileContent{Data: []byte("\x89PNG\r\n\x1a\n"), FileType: FileTypeImage}},
+		},
+		SubConfigs: []*HyprProgramConfig{{
+			ID: "paper", Program: "hyprpaper",
+			FileContent: FileContent{Data: []byte("preload = ~/wallpapers/missing.png\nwallpaper = ,~/Pictures/wall.png\n"), FileType: FileTypeConfig},
+		}},
+	}}}
+
+	refs := Wallpapers(cfg)
+	want := []WallpaperRef{
+		{ProgramID: "hypr", File: "~/.config/hypr/hyprland.conf", Path: "/home/alice/Pictures/wall.png", HomePath: "Pictures/wall.png", Bundled: true},
+		{ProgramID: "paper", File: "~/.config/hypr/hyprpaper.conf", Path: "~/wallpapers/missing.png", HomePath: "wallpapers/missing.png"},
+		{ProgramID: "paper", File: "~/.config/hypr/hyprpaper.conf", Path: "~/Pictures/wall.png", HomePath: "Pictures/wall.png", Bundled: true},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("Wallpapers() = %+v, want %+v", refs, want)
+	}
+	if got := WallpaperWarnings(cfg); len(got) != 1 || !strings.Contains(got[0], "~/wallpapers/missing.png") {
+		t.Errorf("WallpaperWarnings() = %q", got)
+	}
+
+	files, err := RenderConfig(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(files[".config/hypr/hyprland.conf"]); !strings.Contains(got, "swww img ~/Pictures/wall.png\n") {
+		t.Errorf("rendered hyprland.conf = %q, want the wallpaper path relative to ~/", got)
+	}
+	if _, ok := files["Pictures/wall.png"]; !ok {
+		t.Errorf("the bundled wallpaper isn't rendered at its referenced path: %v", files)
+	}
+}
@@ -0,0 +1,255 @@
+package hyprconfig
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	AppliedEventSnapshot      = "snapshot"       // first event of every watch
+	AppliedEventApplied       = "applied"        // the user applied a config
+	AppliedEventConfigUpdated = "config_updated" // the applied config changed after it was applied
+	AppliedEventConfigDeleted = "config_deleted" // the applied config no longer exists
+)
+
+// WatchPollInterval is how often WatchAppliedConfig reloads the applied config state. Backends
+// with change notifications also reload as soon as something changes.
+var WatchPollInterval = 5 * time.Second
+
+// AppliedConfigEvent is sent by WatchAppliedConfig when the caller's applied config changes.
+// ConfigID is empty when the user has not applied a config.
+type AppliedConfigEvent struct {
+	Type            string    `json:"type"`
+	ConfigID        string    `json:"config_id,omitempty"`
+	AppliedAt       time.Time `json:"applied_at,omitempty"`
+	ConfigUpdatedAt time.Time `json:"config_updated_at,omitempty"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// appliedSnapshot is the state a watch compares between reloads.
+type appliedSnapshot struct {
+	state         *UserHyprState // nil if no config is applied
+	configExists  bool
+	configUpdated time.Time
+}
+
+// appliedChange returns the event type for the change from prev to next, or "" if nothing
+// a watcher cares about changed.
+func appliedChange(prev, next appliedSnapshot) string {
+	switch {
+	case (prev.state == nil) != (next.state == nil):
+		return AppliedEventApplied
+	case next.state == nil:
+		return ""
+	case prev.state.ConfigID != next.state.ConfigID || !prev.state.AppliedAt.Equal(next.state.AppliedAt):
+		return AppliedEventApplied
+	case prev.configExists && !next.configExists:
+		return AppliedEventConfigDeleted
+	case next.configExists && !next.configUpdated.Equal(prev.configUpdated) && next.configUpdated.After(next.state.AppliedAt):
+		return AppliedEventConfigUpdated
+	}
+	return ""
+}
+
+func (s appliedSnapshot) event(eventType string) AppliedConfigEvent {
+	e := AppliedConfigEvent{Type: eventType, Timestamp: time.Now()}
+	if s.state != nil {
+		e.ConfigID = s.state.ConfigID
+		e.AppliedAt = s.state.AppliedAt
+	}
+	if s.configExists {
+		e.ConfigUpdatedAt = s.configUpdated
+	}
+	return e
+}
+
+// watchApplied sends a snapshot event and then an event for every change seen by load. It
+// reloads every WatchPollInterval and whenever wake fires; a nil wake means polling only. The
+// returned channel is closed once ctx is cancelled.
+func watchApplied(
+	ctx context.Context,
+	load func(ctx context.Context) (appliedSnapshot, error),
+	wake <-chan struct{},
+) (<-chan AppliedConfigEvent, error) {
+	prev, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan AppliedConfigEvent, 1)
+	events <- prev.event(AppliedEventSnapshot)
+
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(WatchPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-wake:
+			}
+
+			next, err := load(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("failed to reload applied config", "err", err)
+				}
+				continue
+			}
+			eventType := appliedChange(prev, next)
+			prev = next
+			if eventType == "" {
+				continue
+			}
+			select {
+			case events <- next.event(eventType):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// WatchAppliedConfig streams changes of the caller's applied config until ctx is cancelled.
+func (m *ConfigManagerMongo) WatchAppliedConfig(ctx context.Context) (<-chan AppliedConfigEvent, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	load := func(ctx context.Context) (appliedSnapshot, error) {
+		var snap appliedSnapshot
+		var state UserHyprState
+		err := m.StateCollection.FindOne(ctx, bson.M{"user_id": user.UserID}).Decode(&state)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return snap, nil
+		} else if err != nil {
+			return snap, err
+		}
+		snap.state = &state
+
+		var cfg HyprConfig
+		err = m.Collection.FindOne(ctx, bson.M{"_id": state.ConfigID},
+			options.FindOne().SetProjection(bson.M{"updated_timestamp": 1}),
+		).Decode(&cfg)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return snap, nil
+		} else if err != nil {
+			return snap, err
+		}
+		snap.configExists = true
+		snap.configUpdated = cfg.UpdatedTimestamp
+		return snap, nil
+	}
+	return watchApplied(ctx, load, m.changeNotifier(ctx, user.UserID))
+}
+
+// changeNotifier fires when the user's state or any config changes, using change streams. It
+// returns nil when change streams are unavailable (e.g. a standalone server), leaving the watch
+// to polling.
+func (m *ConfigManagerMongo) changeNotifier(ctx context.Context, userID string) <-chan struct{} {
+	pipelines := map[*mongo.Collection]mongo.Pipeline{
+		m.StateCollection: {{{Key: "$match", Value: bson.M{"fullDocument.user_id": userID}}}},
+		m.Collection: {{{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": []string{"update", "replace", "delete"}},
+		}}}},
+	}
+
+	var streams []*mongo.ChangeStream
+	for coll, pipeline := range pipelines {
+		cs, err := coll.Watch(ctx, pipeline, options.ChangeStream().SetFullDocument(options.UpdateLookup))
+		if err != nil {
+			slog.Debug("change streams unavailable, polling for applied config changes", "err", err)
+			for _, cs := range streams {
+				_ = cs.Close(context.Background())
+			}
+			return nil
+		}
+		streams = append(streams, cs)
+	}
+
+	wake := make(chan struct{}, 1)
+	for _, cs := range streams {
+		go func(cs *mongo.ChangeStream) {
+			defer cs.Close(context.Background())
+			for cs.Next(ctx) {
+				select {
+				case wake <- struct{}{}:
+				default: // a reload is already pending
+				}
+			}
+		}(cs)
+	}
+	return wake
+}
+
+// WatchAppliedConfig streams changes of the caller's applied config until ctx is cancelled.
+func (m *ConfigManagerMemory) WatchAppliedConfig(ctx context.Context) (<-chan AppliedConfigEvent, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return watchApplied(ctx, func(context.Context) (appliedSnapshot, error) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		var snap appliedSnapshot
+		state, ok := m.state[user.UserID]
+		if !ok {
+			return snap, nil
+		}
+		snap.state = &state
+		if cfg, ok := m.configs[state.ConfigID]; ok {
+			snap.configExists = true
+			snap.configUpdated = cfg.UpdatedTimestamp
+		}
+		return snap, nil
+	}, nil)
+}
+
+// WatchAppliedConfig streams changes of the caller's applied config until ctx is cancelled.
+func (m *ConfigManagerSQLite) WatchAppliedConfig(ctx context.Context) (<-chan AppliedConfigEvent, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return watchApplied(ctx, func(ctx context.Context) (appliedSnapshot, error) {
+		var snap appliedSnapshot
+		var state UserHyprState
+		var appliedAt int64
+		err := m.db.QueryRowContext(ctx, `SELECT user_id, config_id, applied_at FROM user_state WHERE user_id = ?`,
+			user.UserID).Scan(&state.UserID, &state.ConfigID, &appliedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return snap, nil
+		} else if err != nil {
+			return snap, err
+		}
+		state.AppliedAt = time.Unix(0, appliedAt)
+		snap.state = &state
+
+		var updated string
+		err = m.db.QueryRowContext(ctx, `SELECT json_extract(doc, '$.updated_timestamp') FROM configs WHERE id = ?`,
+			state.ConfigID).Scan(&updated)
+		if errors.Is(err, sql.ErrNoRows) {
+			return snap, nil
+		} else if err != nil {
+			return snap, err
+		}
+		snap.configExists = true
+		snap.configUpdated, err = time.Parse(time.RFC3339Nano, updated)
+		return snap, err
+	}, nil)
+}
@@ -0,0 +1,93 @@
+package hyprconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// nextAppliedEvent waits for the next event of a watch.
+func nextAppliedEvent(t *testing.T, events <-chan AppliedConfigEvent) AppliedConfigEvent {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("watch closed early")
+		}
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an applied config event")
+	}
+	return AppliedConfigEvent{}
+}
+
+func TestManagerWatchAppliedConfig(t *testing.T) {
+	interval := WatchPollInterval
+	WatchPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { WatchPollInterval = interval })
+
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		if _, err := m.WatchAppliedConfig(context.Background()); err == nil {
+			t.Error("anonymous watch should fail")
+		}
+
+		cfg := newTestConfig(t, m, "alice", false)
+		ctx, cancel := context.WithCancel(asUser("bob"))
+		defer cancel()
+
+		events, err := m.WatchAppliedConfig(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := nextAppliedEvent(t, events); e.Type != AppliedEventSnapshot || e.ConfigID != "" {
+			t.Errorf("first event = %+v, want an empty snapshot", e)
+		}
+
+		if err := m.ApplyConfig(asUser("bob"), cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if e := nextAppliedEvent(t, events); e.Type != AppliedEventApplied || e.ConfigID != cfg.ID {
+			t.Errorf("event after apply = %+v", e)
+		}
+
+		if err := m.UpdateConfig(asUser("alice"), cfg.ID, map[string]any{"title": "riced"}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if e := nextAppliedEvent(t, events); e.Type != AppliedEventConfigUpdated || !e.ConfigUpdatedAt.After(e.AppliedAt) {
+			t.Errorf("event after update = %+v", e)
+		}
+
+		if err := m.DeleteConfig(asUser("alice"), cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if e := nextAppliedEvent(t, events); e.Type != AppliedEventConfigDeleted {
+			t.Errorf("event after delete = %+v", e)
+		}
+
+		cancel()
+		for range events {
+		}
+	})
+}
+
+func TestAppliedChange(t *testing.T) {
+	applied := time.Now()
+	state := &UserHyprState{ConfigID: "a", AppliedAt: applied}
+	base := appliedSnapshot{state: state, configExists: true, configUpdated: applied.Add(-time.Hour)}
+
+	for name, tc := range map[string]struct {
+		next appliedSnapshot
+		want string
+	}{
+		"unchanged":            {base, ""},
+		"other config":         {appliedSnapshot{state: &UserHyprState{ConfigID: "b", AppliedAt: applied}}, AppliedEventApplied},
+		"reapplied":            {appliedSnapshot{state: &UserHyprState{ConfigID: "a", AppliedAt: applied.Add(time.Second)}, configExists: true, configUpdated: base.configUpdated}, AppliedEventApplied},
+		"updated":              {appliedSnapshot{state: state, configExists: true, configUpdated: applied.Add(time.Second)}, AppliedEventConfigUpdated},
+		"updated before apply": {appliedSnapshot{state: state, configExists: true, configUpdated: applied.Add(-time.Minute)}, ""},
+		"deleted":              {appliedSnapshot{state: state}, AppliedEventConfigDeleted},
+	} {
+		if got := appliedChange(base, tc.next); got != tc.want {
+			t.Errorf("%s: appliedChange = %q, want %q", name, got, tc.want)
+		}
+	}
+}
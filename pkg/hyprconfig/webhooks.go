@@ -0,0 +1,500 @@
+package hyprconfig
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Seann-Moser/mserve"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	WebhookEventConfigUpdated = "config.updated"
+	WebhookEventConfigDeleted = "config.deleted"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+const (
+	// MaxWebhookAttempts is how often a delivery is tried before it is marked failed.
+	MaxWebhookAttempts = 6
+
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body keyed with the webhook secret.
+	WebhookSignatureHeader = "X-Hypr-Signature"
+	WebhookEventHeader     = "X-Hypr-Event"
+	WebhookDeliveryHeader  = "X-Hypr-Delivery"
+
+	webhookBatchSize  = 50
+	webhookMaxBackoff = time.Hour
+)
+
+var (
+	ErrInvalidWebhook = errors.New("invalid webhook")
+
+	webhookEvents = []string{WebhookEventConfigUpdated, WebhookEventConfigDeleted}
+
+	// webhookClient posts deliveries. Receivers are expected to answer quickly, only at a public
+	// address and without redirecting.
+	webhookClient = &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: webhookDialControl}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	// webhookIPAllowed reports whether webhooks may be delivered to ip, replaced in tests.
+	webhookIPAllowed = publicIP
+
+	// sharedAddressSpace is the carrier-grade NAT range, which is as internal as a private one.
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// publicIP reports whether ip is a unicast address outside loopback, link-local, private and
+// shared ranges, one a server may be asked to call.
+func publicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// webhookDialControl refuses connections to addresses webhookIPAllowed rejects. It runs after
+// the host name is resolved, so a name pointing at an internal address is refused as well.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !webhookIPAllowed(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrInvalidWebhook, ip)
+	}
+	return nil
+}
+
+// Webhook is a URL a user wants called when a config they applied or favorited changes.
+type Webhook struct {
+	ID      string   `json:"id" bson:"_id"`
+	OwnerID string   `json:"owner_id" bson:"owner_id"`
+	URL     string   `json:"url" bson:"url"`
+	Secret  string   `json:"-" bson:"secret"` // never returned
+	Events  []string `json:"events" bson:"events"`
+
+	CreatedTimestamp time.Time `json:"created_timestamp" bson:"created_timestamp"`
+}
+
+// WebhookRequest registers a webhook. Events defaults to every event.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookPayload is the JSON body posted to a webhook.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	ConfigID  string    `json:"config_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookDelivery is one queued or attempted call of a webhook.
+type WebhookDelivery struct {
+	ID        string `json:"id" bson:"_id"`
+	WebhookID string `json:"webhook_id" bson:"webhook_id"`
+	Event     string `json:"event" bson:"event"`
+	ConfigID  string `json:"config_id" bson:"config_id"`
+	Payload   string `json:"payload" bson:"payload"`
+
+	Status      string    `json:"status" bson:"status"` // pending, delivered, failed
+	Attempts    int       `json:"attempts" bson:"attempts"`
+	LastStatus  int       `json:"last_status,omitempty" bson:"last_status,omitempty"` // HTTP status of the last attempt
+	LastError   string    `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt" bson:"next_attempt"`
+
+	CreatedTimestamp time.Time `json:"created_timestamp" bson:"created_timestamp"`
+	UpdatedTimestamp time.Time `json:"updated_timestamp" bson:"updated_timestamp"`
+}
+
+// newWebhook validates req and builds the webhook owned by ownerID.
+func newWebhook(req WebhookRequest, ownerID string) (*Webhook, error) {
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidWebhook)
+	}
+	// Names are checked again once resolved, when deliveries dial them
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if ip, err := netip.ParseAddr(host); (err == nil && !webhookIPAllowed(ip)) || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, fmt.Errorf("%w: url must point at a public address, not %s", ErrInvalidWebhook, u.Hostname())
+	}
+	if req.Secret == "" {
+		return nil, fmt.Errorf("%w: secret cannot be empty", ErrInvalidWebhook)
+	}
+
+	events := req.Events
+	if len(events) == 0 {
+		events = webhookEvents
+	}
+	for _, e := range events {
+		if !containsExact(webhookEvents, e) {
+			return nil, fmt.Errorf("%w: unknown event %q, expected one of %s", ErrInvalidWebhook, e, strings.Join(webhookEvents, ", "))
+		}
+	}
+
+	return &Webhook{
+		ID:               uuid.NewString(),
+		OwnerID:          ownerID,
+		URL:              u.String(),
+		Secret:           req.Secret,
+		Events:           append([]string(nil), events...),
+		CreatedTimestamp: time.Now(),
+	}, nil
+}
+
+// webhookEventFor returns the webhook event sent for an audited action, or "" if none is sent.
+func webhookEventFor(action string) string {
+	switch action {
+	case AuditUpdateConfig, AuditAddProgramConfig, AuditRemoveProgramConfig, AuditMoveProgramConfig, AuditUpdateProgramConfig:
+		return WebhookEventConfigUpdated
+	case AuditDeleteConfig:
+		return WebhookEventConfigDeleted
+	}
+	return ""
+}
+
+// newWebhookDeliveries queues one delivery of event for every hook subscribed to it.
+func newWebhookDeliveries(hooks []Webhook, event, configID string) []WebhookDelivery {
+	now := time.Now()
+	payload, _ := json.Marshal(WebhookPayload{Event: event, ConfigID: configID, Timestamp: now})
+
+	var deliveries []WebhookDelivery
+	for _, hook := range hooks {
+		if !containsExact(hook.Events, event) {
+			continue
+		}
+		deliveries = append(deliveries, WebhookDelivery{
+			ID:               uuid.NewString(),
+			WebhookID:        hook.ID,
+			Event:            event,
+			ConfigID:         configID,
+			Payload:          string(payload),
+			Status:           WebhookDeliveryPending,
+			NextAttempt:      now,
+			CreatedTimestamp: now,
+			UpdatedTimestamp: now,
+		})
+	}
+	return deliveries
+}
+
+// SignWebhookPayload returns the value of WebhookSignatureHeader for payload.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given failed attempt: 30s doubling up to an hour.
+func webhookBackoff(attempt int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempt && d < webhookMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, webhookMaxBackoff)
+}
+
+// attemptWebhookDelivery posts d to hook and records the outcome on d. A nil hook means the
+// webhook was deleted and the delivery is dropped.
+func attemptWebhookDelivery(ctx context.Context, hook *Webhook, d *WebhookDelivery) {
+	now := time.Now()
+	d.Attempts++
+	d.UpdatedTimestamp = now
+	d.LastStatus = 0
+	d.LastError = ""
+
+	if hook == nil {
+		d.Status = WebhookDeliveryFailed
+		d.LastError = "webhook was deleted"
+		return
+	}
+
+	err := postWebhook(ctx, hook, d)
+	switch {
+	case err == nil:
+		d.Status = WebhookDeliveryDelivered
+	case d.Attempts >= MaxWebhookAttempts:
+		d.Status = WebhookDeliveryFailed
+		d.LastError = err.Error()
+	default:
+		d.LastError = err.Error()
+		d.NextAttempt = now.Add(webhookBackoff(d.Attempts))
+	}
+}
+
+func postWebhook(ctx context.Context, hook *Webhook, d *WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, strings.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, d.ID)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(hook.Secret, []byte(d.Payload)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	d.LastStatus = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// deliverWebhooks attempts every due delivery, looking up its webhook and saving the outcome.
+func deliverWebhooks(
+	ctx context.Context,
+	due []WebhookDelivery,
+	lookup func(ctx context.Context, webhookID string) (*Webhook, error),
+	save func(ctx context.Context, d WebhookDelivery) error,
+) (int, error) {
+	attempted := 0
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return attempted, ctx.Err()
+		}
+		hook, err := lookup(ctx, d.WebhookID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return attempted, err
+		}
+		attemptWebhookDelivery(ctx, hook, &d)
+		if err := save(ctx, d); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+	return attempted, nil
+}
+
+// RunWebhookDeliveries calls DeliverPendingWebhooks every interval until ctx is cancelled.
+func RunWebhookDeliveries(ctx context.Context, m ConfigManager, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.DeliverPendingWebhooks(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("failed to deliver webhooks", "err", err)
+			}
+		}
+	}
+}
+
+// recordMutation writes the audit log entry of a config mutation and queues the webhook
+// deliveries and notifications it triggers. None may fail the mutation, so errors are only logged.
+func (m *ConfigManagerMongo) recordMutation(ctx context.Context, entry AuditEntry) {
+	m.audit(ctx, entry)
+
+	if typ := notificationTypeFor(entry.Action); typ != "" {
+		m.notifyConfigAudience(ctx, typ, entry)
+	}
+	event := webhookEventFor(entry.Action)
+	if event == "" || m.WebhooksCollection == nil {
+		return
+	}
+	if err := m.enqueueWebhooks(ctx, event, entry.ConfigID); err != nil {
+		slog.Warn("failed to queue webhook deliveries", "event", event, "config_id", entry.ConfigID, "err", err)
+	}
+}
+
+// enqueueWebhooks queues event for the webhooks of every user who applied or favorited configID.
+func (m *ConfigManagerMongo) enqueueWebhooks(ctx context.Context, event, configID string) error {
+	users := map[string]struct{}{}
+	for _, coll := range []*mongo.Collection{m.FavoritesCollection, m.StateCollection} {
+		ids, err := coll.Distinct(ctx, "user_id", bson.M{"config_id": configID})
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				users[s] = struct{}{}
+			}
+		}
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	userIDs := make([]string, 0, len(users))
+	for id := range users {
+		userIDs = append(userIDs, id)
+	}
+
+	cursor, err := m.WebhooksCollection.Find(ctx, bson.M{
+		"owner_id": bson.M{"$in": userIDs},
+		"events":   event,
+	})
+	if err != nil {
+		return err
+	}
+	var hooks []Webhook
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return err
+	}
+
+	deliveries := newWebhookDeliveries(hooks, event, configID)
+	if len(deliveries) == 0 {
+		return nil
+	}
+	docs := make([]any, len(deliveries))
+	for i := range deliveries {
+		docs[i] = deliveries[i]
+	}
+	_, err = m.WebhookDeliveriesCollection.InsertMany(ctx, docs)
+	return err
+}
+
+// CreateWebhook registers a webhook for the signed-in user.
+func (m *ConfigManagerMongo) CreateWebhook(ctx context.Context, req WebhookRequest) (_ *Webhook, err error) {
+	defer func() { m.logMutation(ctx, "CreateWebhook", err, slog.Any("events", req.Events)) }()
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := newWebhook(req, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.WebhooksCollection.InsertOne(ctx, hook); err != nil {
+		return nil, fmt.Errorf("failed to insert webhook: %w", err)
+	}
+	return hook, nil
+}
+
+// ListWebhooks returns the signed-in user's webhooks, oldest first.
+func (m *ConfigManagerMongo) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := m.WebhooksCollection.Find(ctx, bson.M{"owner_id": user.UserID},
+		options.Find().SetSort(bson.M{"created_timestamp": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	hooks := []Webhook{}
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// ownedWebhook loads a webhook the signed-in user owns, or any webhook for admins.
+func (m *ConfigManagerMongo) ownedWebhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	user, err := getUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := m.webhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if hook.OwnerID != user.UserID && !isAdmin(user.Roles) {
+		return nil, ErrForbidden
+	}
+	return hook, nil
+}
+
+func (m *ConfigManagerMongo) webhook(ctx context.Context, webhookID string) (*Webhook, error) {
+	var hook Webhook
+	err := m.WebhooksCollection.FindOne(ctx, bson.M{"_id": webhookID}).Decode(&hook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch webhook: %w", err)
+	}
+	return &hook, nil
+}
+
+// DeleteWebhook removes a webhook. Its queued deliveries are dropped on their next attempt.
+func (m *ConfigManagerMongo) DeleteWebhook(ctx context.Context, webhookID string) (err error) {
+	defer func() { m.logMutation(ctx, "DeleteWebhook", err, slog.String("webhook_id", webhookID)) }()
+	if _, err := m.ownedWebhook(ctx, webhookID); err != nil {
+		return err
+	}
+
+	if _, err := m.WebhooksCollection.DeleteOne(ctx, bson.M{"_id": webhookID}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook, newest first.
+func (m *ConfigManagerMongo) ListWebhookDeliveries(
+	ctx context.Context,
+	webhookID string,
+	page, limit int,
+) (mserve.Page[WebhookDelivery], error) {
+	if _, err := m.ownedWebhook(ctx, webhookID); err != nil {
+		return mserve.Page[WebhookDelivery]{}, err
+	}
+
+	return mserve.PaginateMongo[WebhookDelivery](
+		ctx,
+		m.WebhookDeliveriesCollection,
+		bson.M{"webhook_id": webhookID},
+		page,
+		limit,
+		options.Find().SetSort(bson.M{"created_timestamp": -1}),
+	)
+}
+
+// DeliverPendingWebhooks attempts the deliveries that are due and returns how many were attempted.
+func (m *ConfigManagerMongo) DeliverPendingWebhooks(ctx context.Context) (int, error) {
+	cursor, err := m.WebhookDeliveriesCollection.Find(ctx, bson.M{
+		"status":       WebhookDeliveryPending,
+		"next_attempt": bson.M{"$lte": time.Now()},
+	}, options.Find().SetSort(bson.M{"next_attempt": 1}).SetLimit(webhookBatchSize))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+	var due []WebhookDelivery
+	if err := cursor.All(ctx, &due); err != nil {
+		return 0, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+
+	return deliverWebhooks(ctx, due, m.webhook, func(ctx context.Context, d WebhookDelivery) error {
+		_, err := m.WebhookDeliveriesCollection.ReplaceOne(ctx, bson.M{"_id": d.ID}, d)
+		return err
+	})
+}
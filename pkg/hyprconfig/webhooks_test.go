@@ -0,0 +1,213 @@
+package hyprconfig
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records the requests posted to it and answers with status.
+type webhookReceiver struct {
+	mu      sync.Mutex
+	status  int
+	bodies  []string
+	headers []http.Header
+	*httptest.Server
+}
+
+// allowLoopbackWebhooks lets webhooks call the loopback receivers of the test.
+func allowLoopbackWebhooks(t *testing.T) {
+	old := webhookIPAllowed
+	t.Cleanup(func() { webhookIPAllowed = old })
+	webhookIPAllowed = func(ip netip.Addr) bool { return ip.IsLoopback() || old(ip) }
+}
+
+func newWebhookReceiver(t *testing.T, status int) *webhookReceiver {
+	allowLoopbackWebhooks(t)
+	rec := &webhookReceiver{status: status}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.bodies = append(rec.bodies, string(body))
+		rec.headers = append(rec.headers, r.Header.Clone())
+		rec.mu.Unlock()
+		w.WriteHeader(rec.status)
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func TestManagerWebhooks(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		rec := newWebhookReceiver(t, http.StatusNoContent)
+		bob := asUser("bob")
+		cfg := newTestConfig(t, m, "alice", false)
+
+		hook, err := m.CreateWebhook(bob, WebhookRequest{URL: rec.URL, Secret: "s3cret", Events: []string{WebhookEventConfigUpdated}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// carol has a webhook but neither applied nor favorited the config
+		if _, err := m.CreateWebhook(asUser("carol"), WebhookRequest{URL: rec.URL, Secret: "other"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.FavoriteConfig(bob, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := m.UpdateConfig(asUser("alice"), cfg.ID, map[string]any{"title": "riced"}, UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := m.DeliverPendingWebhooks(context.Background()); err != nil || n != 1 {
+			t.Fatalf("delivered %d, %v; want 1", n, err)
+		}
+		if len(rec.bodies) != 1 {
+			t.Fatalf("receiver got %d requests, want 1", len(rec.bodies))
+		}
+		if got, want := rec.headers[0].Get(WebhookSignatureHeader), SignWebhookPayload("s3cret", []byte(rec.bodies[0])); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if rec.headers[0].Get(WebhookEventHeader) != WebhookEventConfigUpdated {
+			t.Errorf("event header = %q", rec.headers[0].Get(WebhookEventHeader))
+		}
+
+		log, err := m.ListWebhookDeliveries(bob, hook.ID, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if log.Total != 1 || log.Items[0].Status != WebhookDeliveryDelivered || log.Items[0].LastStatus != http.StatusNoContent {
+			t.Errorf("delivery log = %+v", log.Items)
+		}
+		if _, err := m.ListWebhookDeliveries(asUser("carol"), hook.ID, 1, 10); !errors.Is(err, ErrForbidden) {
+			t.Errorf("other user's delivery log: got %v, want ErrForbidden", err)
+		}
+
+		// bob only subscribed to updates
+		if err := m.DeleteConfig(asUser("alice"), cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if n, _ := m.DeliverPendingWebhooks(context.Background()); n != 0 {
+			t.Errorf("delivered %d deletes to a webhook without that event", n)
+		}
+
+		if err := m.DeleteWebhook(asUser("carol"), hook.ID); !errors.Is(err, ErrForbidden) {
+			t.Errorf("deleting another user's webhook: got %v, want ErrForbidden", err)
+		}
+		if err := m.DeleteWebhook(bob, hook.ID); err != nil {
+			t.Fatal(err)
+		}
+		if hooks, _ := m.ListWebhooks(bob); len(hooks) != 0 {
+			t.Errorf("webhooks after delete = %+v", hooks)
+		}
+	})
+}
+
+func TestManagerWebhookRetry(t *testing.T) {
+	forEachManager(t, func(t *testing.T, m ConfigManager) {
+		rec := newWebhookReceiver(t, http.StatusInternalServerError)
+		bob := asUser("bob")
+		cfg := newTestConfig(t, m, "alice", false)
+
+		hook, err := m.CreateWebhook(bob, WebhookRequest{URL: rec.URL, Secret: "s3cret"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.ApplyConfig(bob, cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.DeleteConfig(asUser("alice"), cfg.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if n, _ := m.DeliverPendingWebhooks(context.Background()); n != 1 {
+			t.Fatalf("delivered %d, want 1", n)
+		}
+		// the retry is not due yet
+		if n, _ := m.DeliverPendingWebhooks(context.Background()); n != 0 {
+			t.Errorf("retried %d deliveries before the backoff passed", n)
+		}
+
+		log, _ := m.ListWebhookDeliveries(bob, hook.ID, 1, 10)
+		if log.Total != 1 {
+			t.Fatalf("delivery log = %+v", log.Items)
+		}
+		d := log.Items[0]
+		if d.Status != WebhookDeliveryPending || d.Attempts != 1 || d.LastStatus != http.StatusInternalServerError || d.Event != WebhookEventConfigDeleted {
+			t.Errorf("delivery after a failed attempt = %+v", d)
+		}
+		if !d.NextAttempt.After(time.Now()) {
+			t.Errorf("next attempt %v is not in the future", d.NextAttempt)
+		}
+	})
+}
+
+func TestNewWebhookValidation(t *testing.T) {
+	for _, req := range []WebhookRequest{
+		{URL: "ftp://example.com/hook", Secret: "s"},
+		{URL: "/relative", Secret: "s"},
+		{URL: "https://example.com/hook"},
+		{URL: "https://example.com/hook", Secret: "s", Events: []string{"config.created"}},
+		{URL: "http://127.0.0.1:8080/hook", Secret: "s"},
+		{URL: "http://[::1]/hook", Secret: "s"},
+		{URL: "http://[::ffff:10.0.0.1]/hook", Secret: "s"},
+		{URL: "http://169.254.169.254/latest/meta-data", Secret: "s"},
+		{URL: "http://192.168.1.1/hook", Secret: "s"},
+		{URL: "http://100.64.0.1/hook", Secret: "s"},
+		{URL: "http://0.0.0.0/hook", Secret: "s"},
+		{URL: "http://LOCALHOST./hook", Secret: "s"},
+		{URL: "http://admin.localhost/hook", Secret: "s"},
+	} {
+		if _, err := newWebhook(req, "bob"); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("newWebhook(%+v) = %v, want ErrInvalidWebhook", req, err)
+		}
+	}
+
+	hook, err := newWebhook(WebhookRequest{URL: "https://example.com/hook", Secret: "s"}, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hook.Events) != len(webhookEvents) {
+		t.Errorf("default events = %v, want every event", hook.Events)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, w := range want {
+		if got := webhookBackoff(i + 1); got != w {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := webhookBackoff(20); got != webhookMaxBackoff {
+		t.Errorf("webhookBackoff(20) = %v, want the cap", got)
+	}
+}
+
+func TestWebhookDeliveryOnlyToPublicAddresses(t *testing.T) {
+	rec := newWebhookReceiver(t, http.StatusNoContent)
+	hook := &Webhook{URL: rec.URL, Secret: "s"}
+
+	// A receiver redirecting elsewhere is not followed
+	redirect := httptest.NewServer(http.RedirectHandler(rec.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+	d := &WebhookDelivery{Payload: "{}"}
+	attemptWebhookDelivery(context.Background(), &Webhook{URL: redirect.URL, Secret: "s"}, d)
+	if d.Status == WebhookDeliveryDelivered || d.LastStatus != http.StatusTemporaryRedirect || len(rec.bodies) != 0 {
+		t.Errorf("delivery to a redirect = %+v, receiver got %d requests", d, len(rec.bodies))
+	}
+
+	// Hooks registered before, or resolving to, an internal address are refused when dialed
+	webhookIPAllowed = publicIP
+	d = &WebhookDelivery{Payload: "{}"}
+	attemptWebhookDelivery(context.Background(), hook, d)
+	if d.Status == WebhookDeliveryDelivered || !strings.Contains(d.LastError, "not a public address") || len(rec.bodies) != 0 {
+		t.Errorf("delivery to loopback = %+v, receiver got %d requests", d, len(rec.bodies))
+	}
+}
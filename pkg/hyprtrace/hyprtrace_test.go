@@ -0,0 +1,84 @@
+package hyprtrace
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Seann-Moser/credentials/session"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hchandler"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanParentage(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	m := NewConfigManager(hyprconfig.NewInMemoryConfigManager(), tp)
+
+	alice := (&session.UserSessionData{UserID: "alice", SignedIn: true}).WithContext(context.Background())
+	cfg, err := m.CreateConfig(alice, &hyprconfig.HyprConfig{
+		Title:          "rice",
+		ProgramConfigs: []hyprconfig.HyprProgramConfig{{Title: "term", Program: "kitty"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := hchandler.NewHandler(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	for _, ep := range h.GetEndpoints() {
+		router.HandleFunc(ep.Path, ep.Handler).Methods(ep.Methods...)
+	}
+	router.Use(Middleware(tp))
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	exporter.Reset()
+	for _, id := range []string{cfg.ID, "missing"} {
+		resp, err := http.Get(srv.URL + "/config/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("%d spans, want a request and a manager span per request", len(spans))
+	}
+	for i, want := range []struct {
+		configID, result string
+		status           int64
+	}{
+		{cfg.ID, "ok", http.StatusOK},
+		{"missing", "not_found", http.StatusNotFound},
+	} {
+		// Spans are exported when they end, children first.
+		child, parent := spans[2*i], spans[2*i+1]
+		if parent.Name != "GET /config/{config_id}" || child.Name != "ConfigManager.GetConfig" {
+			t.Fatalf("spans %q and %q, want the manager span then the request span", child.Name, parent.Name)
+		}
+		if child.Parent.SpanID() != parent.SpanContext.SpanID() || child.SpanContext.TraceID() != parent.SpanContext.TraceID() {
+			t.Errorf("%s: manager span is not a child of the request span", want.configID)
+		}
+		attrs := attribute.NewSet(child.Attributes...)
+		if v, _ := attrs.Value("config_id"); v.AsString() != want.configID {
+			t.Errorf("config_id = %q, want %q", v.AsString(), want.configID)
+		}
+		if v, _ := attrs.Value("result"); v.AsString() != want.result {
+			t.Errorf("%s: result = %q, want %q", want.configID, v.AsString(), want.result)
+		}
+		parentAttrs := attribute.NewSet(parent.Attributes...)
+		if v, _ := parentAttrs.Value("http.response.status_code"); v.AsInt64() != want.status {
+			t.Errorf("%s: status_code = %d, want %d", want.configID, v.AsInt64(), want.status)
+		}
+	}
+}
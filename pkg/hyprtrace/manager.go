@@ -0,0 +1,489 @@
+// Package hyprtrace adds OpenTelemetry tracing to a hyprconfig.ConfigManager and to the HTTP
+// handler, so the core packages stay free of the otel dependency.
+package hyprtrace
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+	"github.com/Seann-Moser/mserve"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of this package.
+const instrumentationName = "github.com/Seann-Moser/hypr-config-manager/pkg/hyprtrace"
+
+// ConfigManager wraps a hyprconfig.ConfigManager and starts a span for every call, named
+// "ConfigManager.<method>" with the operation, the config id when there is one, and the result:
+// "ok" or the hyprconfig.ErrorType of the error.
+type ConfigManager struct {
+	next   hyprconfig.ConfigManager
+	tracer trace.Tracer
+}
+
+var _ hyprconfig.ConfigManager = (*ConfigManager)(nil)
+
+// NewConfigManager wraps next with spans from tp.
+func NewConfigManager(next hyprconfig.ConfigManager, tp trace.TracerProvider) *ConfigManager {
+	return &ConfigManager{next: next, tracer: tp.Tracer(instrumentationName)}
+}
+
+// start starts the span of a call to operation and returns the function ending it with the
+// call's error.
+func (m *ConfigManager) start(ctx context.Context, operation, configID string) (context.Context, func(*error)) {
+	attrs := []attribute.KeyValue{attribute.String("operation", operation)}
+	if configID != "" {
+		attrs = append(attrs, attribute.String("config_id", configID))
+	}
+	ctx, span := m.tracer.Start(ctx, "ConfigManager."+operation, trace.WithAttributes(attrs...))
+	return ctx, func(err *error) {
+		defer span.End()
+		if *err == nil {
+			span.SetAttributes(attribute.String("result", "ok"))
+			return
+		}
+		result := hyprconfig.ErrorType(*err)
+		span.SetAttributes(attribute.String("result", result))
+		span.RecordError(*err)
+		if result == "internal" {
+			// Domain errors are the caller's problem, only storage failures mark the span
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+}
+
+func (m *ConfigManager) SizeLimits() hyprconfig.SizeLimits {
+	return m.next.SizeLimits()
+}
+
+func (m *ConfigManager) CreateConfig(ctx context.Context, cfg *hyprconfig.HyprConfig) (_ *hyprconfig.HyprConfig, err error) {
+	ctx, end := m.start(ctx, "CreateConfig", "")
+	defer end(&err)
+	return m.next.CreateConfig(ctx, cfg)
+}
+
+func (m *ConfigManager) GetConfig(ctx context.Context, id string) (_ *hyprconfig.HyprConfig, err error) {
+	ctx, end := m.start(ctx, "GetConfig", id)
+	defer end(&err)
+	return m.next.GetConfig(ctx, id)
+}
+
+func (m *ConfigManager) GetProgramConfig(ctx context.Context, configID, progID string) (_ *hyprconfig.HyprProgramConfig, err error) {
+	ctx, end := m.start(ctx, "GetProgramConfig", configID)
+	defer end(&err)
+	return m.next.GetProgramConfig(ctx, configID, progID)
+}
+
+func (m *ConfigManager) GetProgramFile(ctx context.Context, configID, progID, filePath string) (_ io.ReadCloser, _ *hyprconfig.FileEntry, err error) {
+	ctx, end := m.start(ctx, "GetProgramFile", configID)
+	defer end(&err)
+	return m.next.GetProgramFile(ctx, configID, progID, filePath)
+}
+
+func (m *ConfigManager) AddGalleryImage(ctx context.Context, configID string, data []byte) (_ *hyprconfig.GalleryImage, err error) {
+	ctx, end := m.start(ctx, "AddGalleryImage", configID)
+	defer end(&err)
+	return m.next.AddGalleryImage(ctx, configID, data)
+}
+
+func (m *ConfigManager) GetGalleryImage(ctx context.Context, configID string, index int) (_ io.ReadCloser, _ *hyprconfig.GalleryImage, err error) {
+	ctx, end := m.start(ctx, "GetGalleryImage", configID)
+	defer end(&err)
+	return m.next.GetGalleryImage(ctx, configID, index)
+}
+
+func (m *ConfigManager) RemoveGalleryImage(ctx context.Context, configID string, index int) (err error) {
+	ctx, end := m.start(ctx, "RemoveGalleryImage", configID)
+	defer end(&err)
+	return m.next.RemoveGalleryImage(ctx, configID, index)
+}
+
+func (m *ConfigManager) ExportConfigArchive(ctx context.Context, configID string, w io.Writer) (err error) {
+	ctx, end := m.start(ctx, "ExportConfigArchive", configID)
+	defer end(&err)
+	return m.next.ExportConfigArchive(ctx, configID, w)
+}
+
+func (m *ConfigManager) GetInstallScript(ctx context.Context, configID, distro string, includeOptional bool) (_ string, err error) {
+	ctx, end := m.start(ctx, "GetInstallScript", configID)
+	defer end(&err)
+	return m.next.GetInstallScript(ctx, configID, distro, includeOptional)
+}
+
+func (m *ConfigManager) ExportHomeManager(ctx context.Context, configID string) (_ string, err error) {
+	ctx, end := m.start(ctx, "ExportHomeManager", configID)
+	defer end(&err)
+	return m.next.ExportHomeManager(ctx, configID)
+}
+
+func (m *ConfigManager) UpdateConfig(ctx context.Context, id string, updates bson.M, opts hyprconfig.UpdateOptions) (err error) {
+	ctx, end := m.start(ctx, "UpdateConfig", id)
+	defer end(&err)
+	return m.next.UpdateConfig(ctx, id, updates, opts)
+}
+
+func (m *ConfigManager) DeleteConfig(ctx context.Context, id string) (err error) {
+	ctx, end := m.start(ctx, "DeleteConfig", id)
+	defer end(&err)
+	return m.next.DeleteConfig(ctx, id)
+}
+
+func (m *ConfigManager) ListConfigs(ctx context.Context, page, limit int, findOpts *options.FindOptions) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "ListConfigs", "")
+	defer end(&err)
+	return m.next.ListConfigs(ctx, page, limit, findOpts)
+}
+
+func (m *ConfigManager) ListMyConfigs(ctx context.Context, page, limit int, findOpts *options.FindOptions) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "ListMyConfigs", "")
+	defer end(&err)
+	return m.next.ListMyConfigs(ctx, page, limit, findOpts)
+}
+
+func (m *ConfigManager) ListSimilarConfigs(ctx context.Context, configID string, page, limit int) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "ListSimilarConfigs", configID)
+	defer end(&err)
+	return m.next.ListSimilarConfigs(ctx, configID, page, limit)
+}
+
+func (m *ConfigManager) ListConfigsWithFilters(ctx context.Context, page, limit int, filters hyprconfig.ConfigSearchFilters, findOpts *options.FindOptions) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "ListConfigsWithFilters", "")
+	defer end(&err)
+	return m.next.ListConfigsWithFilters(ctx, page, limit, filters, findOpts)
+}
+
+func (m *ConfigManager) AdminListConfigs(ctx context.Context, page, limit int, filters hyprconfig.ConfigSearchFilters) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "AdminListConfigs", "")
+	defer end(&err)
+	return m.next.AdminListConfigs(ctx, page, limit, filters)
+}
+
+func (m *ConfigManager) VerifyConfig(ctx context.Context, configID string) (err error) {
+	ctx, end := m.start(ctx, "VerifyConfig", configID)
+	defer end(&err)
+	return m.next.VerifyConfig(ctx, configID)
+}
+
+func (m *ConfigManager) UnverifyConfig(ctx context.Context, configID string) (err error) {
+	ctx, end := m.start(ctx, "UnverifyConfig", configID)
+	defer end(&err)
+	return m.next.UnverifyConfig(ctx, configID)
+}
+
+func (m *ConfigManager) DeprecateConfig(ctx context.Context, configID string, successorID *string, message string) (err error) {
+	ctx, end := m.start(ctx, "DeprecateConfig", configID)
+	defer end(&err)
+	return m.next.DeprecateConfig(ctx, configID, successorID, message)
+}
+
+func (m *ConfigManager) FavoriteConfig(ctx context.Context, configID string) (err error) {
+	ctx, end := m.start(ctx, "FavoriteConfig", configID)
+	defer end(&err)
+	return m.next.FavoriteConfig(ctx, configID)
+}
+
+func (m *ConfigManager) UnfavoriteConfig(ctx context.Context, configID string) (err error) {
+	ctx, end := m.start(ctx, "UnfavoriteConfig", configID)
+	defer end(&err)
+	return m.next.UnfavoriteConfig(ctx, configID)
+}
+
+func (m *ConfigManager) ListFavorites(ctx context.Context, page, limit int) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "ListFavorites", "")
+	defer end(&err)
+	return m.next.ListFavorites(ctx, page, limit)
+}
+
+func (m *ConfigManager) ListConfigFavoriters(ctx context.Context, configID string, page, limit int) (_ mserve.Page[hyprconfig.ConfigFavoriter], err error) {
+	ctx, end := m.start(ctx, "ListConfigFavoriters", configID)
+	defer end(&err)
+	return m.next.ListConfigFavoriters(ctx, configID, page, limit)
+}
+
+func (m *ConfigManager) CountConfigFavorites(ctx context.Context, configID string) (_ int64, err error) {
+	ctx, end := m.start(ctx, "CountConfigFavorites", configID)
+	defer end(&err)
+	return m.next.CountConfigFavorites(ctx, configID)
+}
+
+func (m *ConfigManager) ApplyConfig(ctx context.Context, configID string) (err error) {
+	ctx, end := m.start(ctx, "ApplyConfig", configID)
+	defer end(&err)
+	return m.next.ApplyConfig(ctx, configID)
+}
+
+func (m *ConfigManager) GetAppliedConfig(ctx context.Context) (_ *hyprconfig.HyprConfig, err error) {
+	ctx, end := m.start(ctx, "GetAppliedConfig", "")
+	defer end(&err)
+	return m.next.GetAppliedConfig(ctx)
+}
+
+func (m *ConfigManager) WatchAppliedConfig(ctx context.Context) (_ <-chan hyprconfig.AppliedConfigEvent, err error) {
+	ctx, end := m.start(ctx, "WatchAppliedConfig", "")
+	defer end(&err)
+	return m.next.WatchAppliedConfig(ctx)
+}
+
+func (m *ConfigManager) CountUsersUsingConfig(ctx context.Context, configID string) (_ int64, err error) {
+	ctx, end := m.start(ctx, "CountUsersUsingConfig", configID)
+	defer end(&err)
+	return m.next.CountUsersUsingConfig(ctx, configID)
+}
+
+func (m *ConfigManager) GetAuthorStats(ctx context.Context, ownerID string) (_ *hyprconfig.AuthorStats, err error) {
+	ctx, end := m.start(ctx, "GetAuthorStats", "")
+	defer end(&err)
+	return m.next.GetAuthorStats(ctx, ownerID)
+}
+
+func (m *ConfigManager) ListRelatedConfigs(ctx context.Context, configID string, limit int) (_ []hyprconfig.HyprConfig, err error) {
+	ctx, end := m.start(ctx, "ListRelatedConfigs", configID)
+	defer end(&err)
+	return m.next.ListRelatedConfigs(ctx, configID, limit)
+}
+
+func (m *ConfigManager) GetRandomConfig(ctx context.Context, filters hyprconfig.ConfigSearchFilters) (_ *hyprconfig.HyprConfig, err error) {
+	ctx, end := m.start(ctx, "GetRandomConfig", "")
+	defer end(&err)
+	return m.next.GetRandomConfig(ctx, filters)
+}
+
+func (m *ConfigManager) FollowAuthor(ctx context.Context, ownerID string) (err error) {
+	ctx, end := m.start(ctx, "FollowAuthor", "")
+	defer end(&err)
+	return m.next.FollowAuthor(ctx, ownerID)
+}
+
+func (m *ConfigManager) UnfollowAuthor(ctx context.Context, ownerID string) (err error) {
+	ctx, end := m.start(ctx, "UnfollowAuthor", "")
+	defer end(&err)
+	return m.next.UnfollowAuthor(ctx, ownerID)
+}
+
+func (m *ConfigManager) ListFollowing(ctx context.Context) (_ []hyprconfig.FollowedAuthor, err error) {
+	ctx, end := m.start(ctx, "ListFollowing", "")
+	defer end(&err)
+	return m.next.ListFollowing(ctx)
+}
+
+func (m *ConfigManager) ListFollowedFeed(ctx context.Context, page, limit int) (_ mserve.Page[hyprconfig.HyprConfig], err error) {
+	ctx, end := m.start(ctx, "ListFollowedFeed", "")
+	defer end(&err)
+	return m.next.ListFollowedFeed(ctx, page, limit)
+}
+
+func (m *ConfigManager) ListNotifications(ctx context.Context, page, limit int, unreadOnly bool) (_ mserve.Page[hyprconfig.Notification], err error) {
+	ctx, end := m.start(ctx, "ListNotifications", "")
+	defer end(&err)
+	return m.next.ListNotifications(ctx, page, limit, unreadOnly)
+}
+
+func (m *ConfigManager) MarkNotificationsRead(ctx context.Context, ids []string) (_ int64, err error) {
+	ctx, end := m.start(ctx, "MarkNotificationsRead", "")
+	defer end(&err)
+	return m.next.MarkNotificationsRead(ctx, ids)
+}
+
+func (m *ConfigManager) CountUnreadNotifications(ctx context.Context) (_ int64, err error) {
+	ctx, end := m.start(ctx, "CountUnreadNotifications", "")
+	defer end(&err)
+	return m.next.CountUnreadNotifications(ctx)
+}
+
+func (m *ConfigManager) AddProgramConfig(ctx context.Context, configID string, newProg hyprconfig.HyprProgramConfig, parentID *string, changelog string) (err error) {
+	ctx, end := m.start(ctx, "AddProgramConfig", configID)
+	defer end(&err)
+	return m.next.AddProgramConfig(ctx, configID, newProg, parentID, changelog)
+}
+
+func (m *ConfigManager) RemoveProgramConfig(ctx context.Context, configID string, progID string, changelog string) (err error) {
+	ctx, end := m.start(ctx, "RemoveProgramConfig", configID)
+	defer end(&err)
+	return m.next.RemoveProgramConfig(ctx, configID, progID, changelog)
+}
+
+func (m *ConfigManager) MoveProgramConfig(ctx context.Context, configID string, progID string, newParentID *string, changelog string) (err error) {
+	ctx, end := m.start(ctx, "MoveProgramConfig", configID)
+	defer end(&err)
+	return m.next.MoveProgramConfig(ctx, configID, progID, newParentID, changelog)
+}
+
+func (m *ConfigManager) UpdateProgramConfig(ctx context.Context, configID string, progID string, updates hyprconfig.HyprProgramConfig, changelog string) (err error) {
+	ctx, end := m.start(ctx, "UpdateProgramConfig", configID)
+	defer end(&err)
+	return m.next.UpdateProgramConfig(ctx, configID, progID, updates, changelog)
+}
+
+func (m *ConfigManager) GetChangelog(ctx context.Context, configID string, page, limit int) (_ mserve.Page[hyprconfig.ChangelogEntry], err error) {
+	ctx, end := m.start(ctx, "GetChangelog", configID)
+	defer end(&err)
+	return m.next.GetChangelog(ctx, configID, page, limit)
+}
+
+func (m *ConfigManager) ListAuditLog(ctx context.Context, configID string, page, limit int) (_ mserve.Page[hyprconfig.AuditEntry], err error) {
+	ctx, end := m.start(ctx, "ListAuditLog", configID)
+	defer end(&err)
+	return m.next.ListAuditLog(ctx, configID, page, limit)
+}
+
+func (m *ConfigManager) AddAllowedProgram(ctx context.Context, programName string) (_ *hyprconfig.AllowedPrograms, err error) {
+	ctx, end := m.start(ctx, "AddAllowedProgram", "")
+	defer end(&err)
+	return m.next.AddAllowedProgram(ctx, programName)
+}
+
+func (m *ConfigManager) GetAllowedProgram(ctx context.Context, programName string) (_ *hyprconfig.AllowedPrograms, err error) {
+	ctx, end := m.start(ctx, "GetAllowedProgram", "")
+	defer end(&err)
+	return m.next.GetAllowedProgram(ctx, programName)
+}
+
+func (m *ConfigManager) ListAllowedPrograms(ctx context.Context) (_ []hyprconfig.AllowedPrograms, err error) {
+	ctx, end := m.start(ctx, "ListAllowedPrograms", "")
+	defer end(&err)
+	return m.next.ListAllowedPrograms(ctx)
+}
+
+func (m *ConfigManager) ListAllowedProgramsPaged(ctx context.Context, page, limit int, filters hyprconfig.AllowedProgramFilters) (_ mserve.Page[hyprconfig.AllowedPrograms], err error) {
+	ctx, end := m.start(ctx, "ListAllowedProgramsPaged", "")
+	defer end(&err)
+	return m.next.ListAllowedProgramsPaged(ctx, page, limit, filters)
+}
+
+func (m *ConfigManager) RemoveAllowedProgram(ctx context.Context, programName string) (err error) {
+	ctx, end := m.start(ctx, "RemoveAllowedProgram", "")
+	defer end(&err)
+	return m.next.RemoveAllowedProgram(ctx, programName)
+}
+
+func (m *ConfigManager) ImportAllowedPrograms(ctx context.Context, programs []hyprconfig.AllowedPrograms, upsert bool) (_ []hyprconfig.ProgramImportResult, err error) {
+	ctx, end := m.start(ctx, "ImportAllowedPrograms", "")
+	defer end(&err)
+	return m.next.ImportAllowedPrograms(ctx, programs, upsert)
+}
+
+func (m *ConfigManager) SeedDefaultPrograms(ctx context.Context) (_ []hyprconfig.ProgramImportResult, err error) {
+	ctx, end := m.start(ctx, "SeedDefaultPrograms", "")
+	defer end(&err)
+	return m.next.SeedDefaultPrograms(ctx)
+}
+
+func (m *ConfigManager) RequestAllowedProgram(ctx context.Context, programName string, reason string) (_ *hyprconfig.ProgramRequest, err error) {
+	ctx, end := m.start(ctx, "RequestAllowedProgram", "")
+	defer end(&err)
+	return m.next.RequestAllowedProgram(ctx, programName, reason)
+}
+
+func (m *ConfigManager) ListProgramRequests(ctx context.Context, page, limit int, status string) (_ mserve.Page[hyprconfig.ProgramRequest], err error) {
+	ctx, end := m.start(ctx, "ListProgramRequests", "")
+	defer end(&err)
+	return m.next.ListProgramRequests(ctx, page, limit, status)
+}
+
+func (m *ConfigManager) ApproveProgramRequest(ctx context.Context, requestID string) (_ *hyprconfig.AllowedPrograms, err error) {
+	ctx, end := m.start(ctx, "ApproveProgramRequest", "")
+	defer end(&err)
+	return m.next.ApproveProgramRequest(ctx, requestID)
+}
+
+func (m *ConfigManager) RejectProgramRequest(ctx context.Context, requestID string, note string) (err error) {
+	ctx, end := m.start(ctx, "RejectProgramRequest", "")
+	defer end(&err)
+	return m.next.RejectProgramRequest(ctx, requestID, note)
+}
+
+func (m *ConfigManager) CreateWebhook(ctx context.Context, req hyprconfig.WebhookRequest) (_ *hyprconfig.Webhook, err error) {
+	ctx, end := m.start(ctx, "CreateWebhook", "")
+	defer end(&err)
+	return m.next.CreateWebhook(ctx, req)
+}
+
+func (m *ConfigManager) ListWebhooks(ctx context.Context) (_ []hyprconfig.Webhook, err error) {
+	ctx, end := m.start(ctx, "ListWebhooks", "")
+	defer end(&err)
+	return m.next.ListWebhooks(ctx)
+}
+
+func (m *ConfigManager) DeleteWebhook(ctx context.Context, webhookID string) (err error) {
+	ctx, end := m.start(ctx, "DeleteWebhook", "")
+	defer end(&err)
+	return m.next.DeleteWebhook(ctx, webhookID)
+}
+
+func (m *ConfigManager) ListWebhookDeliveries(ctx context.Context, webhookID string, page, limit int) (_ mserve.Page[hyprconfig.WebhookDelivery], err error) {
+	ctx, end := m.start(ctx, "ListWebhookDeliveries", "")
+	defer end(&err)
+	return m.next.ListWebhookDeliveries(ctx, webhookID, page, limit)
+}
+
+func (m *ConfigManager) DeliverPendingWebhooks(ctx context.Context) (_ int, err error) {
+	ctx, end := m.start(ctx, "DeliverPendingWebhooks", "")
+	defer end(&err)
+	return m.next.DeliverPendingWebhooks(ctx)
+}
+
+func (m *ConfigManager) CreateAPIKey(ctx context.Context, name string, scopes []string, expiry time.Duration) (_ *hyprconfig.CreatedAPIKey, err error) {
+	ctx, end := m.start(ctx, "CreateAPIKey", "")
+	defer end(&err)
+	return m.next.CreateAPIKey(ctx, name, scopes, expiry)
+}
+
+func (m *ConfigManager) ListAPIKeys(ctx context.Context) (_ []hyprconfig.APIKey, err error) {
+	ctx, end := m.start(ctx, "ListAPIKeys", "")
+	defer end(&err)
+	return m.next.ListAPIKeys(ctx)
+}
+
+func (m *ConfigManager) RevokeAPIKey(ctx context.Context, keyID string) (err error) {
+	ctx, end := m.start(ctx, "RevokeAPIKey", "")
+	defer end(&err)
+	return m.next.RevokeAPIKey(ctx, keyID)
+}
+
+func (m *ConfigManager) AuthenticateAPIKey(ctx context.Context, token string) (_ *hyprconfig.APIKey, err error) {
+	ctx, end := m.start(ctx, "AuthenticateAPIKey", "")
+	defer end(&err)
+	return m.next.AuthenticateAPIKey(ctx, token)
+}
+
+func (m *ConfigManager) CreateShareLink(ctx context.Context, configID string, ttl time.Duration) (_ *hyprconfig.CreatedShareLink, err error) {
+	ctx, end := m.start(ctx, "CreateShareLink", configID)
+	defer end(&err)
+	return m.next.CreateShareLink(ctx, configID, ttl)
+}
+
+func (m *ConfigManager) ListShareLinks(ctx context.Context, configID string) (_ []hyprconfig.ShareLink, err error) {
+	ctx, end := m.start(ctx, "ListShareLinks", configID)
+	defer end(&err)
+	return m.next.ListShareLinks(ctx, configID)
+}
+
+func (m *ConfigManager) RevokeShareLink(ctx context.Context, configID, linkID string) (err error) {
+	ctx, end := m.start(ctx, "RevokeShareLink", configID)
+	defer end(&err)
+	return m.next.RevokeShareLink(ctx, configID, linkID)
+}
+
+func (m *ConfigManager) GetQuotaUsage(ctx context.Context) (_ *hyprconfig.QuotaUsage, err error) {
+	ctx, end := m.start(ctx, "GetQuotaUsage", "")
+	defer end(&err)
+	return m.next.GetQuotaUsage(ctx)
+}
+
+func (m *ConfigManager) SetUserQuota(ctx context.Context, userID string, quotaBytes int64) (_ *hyprconfig.QuotaUsage, err error) {
+	ctx, end := m.start(ctx, "SetUserQuota", "")
+	defer end(&err)
+	return m.next.SetUserQuota(ctx, userID, quotaBytes)
+}
+
+func (m *ConfigManager) SetUserConfigLimit(ctx context.Context, userID string, limit int64) (_ *hyprconfig.ConfigLimit, err error) {
+	ctx, end := m.start(ctx, "SetUserConfigLimit", "")
+	defer end(&err)
+	return m.next.SetUserConfigLimit(ctx, userID, limit)
+}
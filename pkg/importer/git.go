@@ -0,0 +1,261 @@
+package importer
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/builder"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/configfinder"
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+const (
+	// DefaultTimeout bounds the whole download and extraction.
+	DefaultTimeout = 60 * time.Second
+	// MaxArchiveBytes is the largest repository tarball that will be downloaded.
+	MaxArchiveBytes = 64 << 20
+	// MaxExtractedBytes is the most data that will be written to disk while extracting.
+	MaxExtractedBytes = 128 << 20
+)
+
+var ErrInvalidRepoURL = errors.New("invalid repository url")
+
+// GitImportRequest is the body of the git import endpoint.
+type GitImportRequest struct {
+	URL    string `json:"url"`              // https://github.com/<owner>/<repo>
+	Ref    string `json:"ref,omitempty"`    // branch, tag or commit; default branch when empty
+	Subdir string `json:"subdir,omitempty"` // directory laid out like ~/.config, e.g. ".config"
+}
+
+// GitImportResult is the created config and any files that were left out.
+type GitImportResult struct {
+	Config   *hyprconfig.HyprConfig `json:"config"`
+	Warnings []string               `json:"warnings,omitempty"`
+}
+
+// GitImporter creates configs from dotfiles repositories hosted on GitHub.
+type GitImporter struct {
+	manager hyprconfig.ConfigManager
+	client  *http.Client
+	timeout time.Duration
+	apiBase string
+}
+
+func NewGitImporter(manager hyprconfig.ConfigManager) *GitImporter {
+	return &GitImporter{
+		manager: manager,
+		client:  &http.Client{},
+		timeout: DefaultTimeout,
+		apiBase: "https://api.github.com",
+	}
+}
+
+// ImportFromGit downloads the repository tarball at ref, builds a config from subdir with the
+// directory builder and creates it under the calling user. Files over the size limits are skipped
+// and reported as warnings.
+func (g *GitImporter) ImportFromGit(ctx context.Context, repoURL, ref, subdir string) (*GitImportResult, error) {
+	owner, repo, err := parseGitHubURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	subdir = path.Clean("/" + subdir)[1:]
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	tarballURL := fmt.Sprintf("%s/repos/%s/%s/tarball/%s", g.apiBase, owner, repo, url.PathEscape(ref))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tarballURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download repository: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download repository: %s", resp.Status)
+	}
+
+	dir, err := os.MkdirTemp("", "hypr-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// The repository is laid out like ~/.config, so it goes below a home of its own: the files it
+	// sources are resolved against that home, never the server's
+	root := filepath.Join(dir, ".config")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		return nil, err
+	}
+	limits := g.manager.SizeLimits()
+	warnings, err := extractTarball(io.LimitReader(resp.Body, MaxArchiveBytes), root, subdir, maxFileBytes(limits))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := builder.BuildConfigFromDirectoryWithOptions(root, nil, configfinder.FinderOptions{HomeDir: dir})
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, pruneOversized(cfg, limits)...)
+	if len(cfg.ProgramConfigs) == 0 {
+		return nil, fmt.Errorf("no recognized program directories found in %s/%s", repoURL, subdir)
+	}
+
+	cfg.Title = repo
+	cfg.Author.URL = repoURL
+	created, err := g.manager.CreateConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &GitImportResult{Config: created, Warnings: warnings}, nil
+}
+
+// parseGitHubURL accepts https://github.com/<owner>/<repo>[.git] and returns owner and repo.
+func parseGitHubURL(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidRepoURL, err)
+	}
+	if u.Scheme != "https" {
+		return "", "", fmt.Errorf("%w: only https urls are supported", ErrInvalidRepoURL)
+	}
+	if u.Host != "github.com" {
+		return "", "", fmt.Errorf("%w: only github.com repositories are supported", ErrInvalidRepoURL)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%w: expected https://github.com/<owner>/<repo>", ErrInvalidRepoURL)
+	}
+	return parts[0], strings.TrimSuffix(parts[1], ".git"), nil
+}
+
+// maxFileBytes is the largest file worth extracting under limits.
+func maxFileBytes(limits hyprconfig.SizeLimits) int64 {
+	max := limits.MaxTextBytes
+	for _, l := range []int64{limits.MaxImageBytes, limits.MaxBinaryBytes} {
+		if l > max {
+			max = l
+		}
+	}
+	return max
+}
+
+// extractTarball writes the regular files under subdir of a GitHub tarball into dest, dropping the
+// top-level "<owner>-<repo>-<sha>/" directory. Files larger than maxFile are skipped with a warning.
+func extractTarball(r io.Reader, dest, subdir string, maxFile int64) ([]string, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository archive: %w", err)
+	}
+	defer gz.Close()
+
+	var warnings []string
+	var written int64
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return warnings, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read repository archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Strip the top-level directory, then keep only files under subdir
+		_, name, ok := strings.Cut(path.Clean(hdr.Name), "/")
+		if !ok || strings.HasPrefix(name, "../") {
+			continue
+		}
+		if subdir != "" {
+			if name, ok = strings.CutPrefix(name, subdir+"/"); !ok {
+				continue
+			}
+		}
+
+		if maxFile > 0 && hdr.Size > maxFile {
+			warnings = append(warnings, fmt.Sprintf("skipped %s: %d bytes is over the %d byte file limit", name, hdr.Size, maxFile))
+			continue
+		}
+		written += hdr.Size
+		if written > MaxExtractedBytes {
+			return nil, fmt.Errorf("repository is larger than %d bytes", MaxExtractedBytes)
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return nil, err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(f, io.LimitReader(tr, hdr.Size))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// pruneOversized drops the files, FileContent and every entry of Files, that would fail the
+// per-file limits (including binary content when it is not allowed), returning a warning for
+// each. Program configs left without files or sub configs are dropped. The builder sized
+// bundled images against the default limits, so they are checked again here.
+func pruneOversized(cfg *hyprconfig.HyprConfig, limits hyprconfig.SizeLimits) []string {
+	var warnings []string
+	var prune func(pc *hyprconfig.HyprProgramConfig) bool
+	prune = func(pc *hyprconfig.HyprProgramConfig) bool {
+		if err := limits.CheckFile(pc.FileContent); err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipped %s: %v", pc.InstallPath, err))
+			pc.FileContent = hyprconfig.FileContent{}
+			pc.InstallPath = ""
+		}
+		files := pc.Files[:0]
+		for _, f := range pc.Files {
+			if err := limits.CheckFile(f.FileContent); err != nil {
+				warnings = append(warnings, fmt.Sprintf("skipped %s: %v", f.TargetPath, err))
+				continue
+			}
+			files = append(files, f)
+		}
+		pc.Files = files
+
+		subs := pc.SubConfigs[:0]
+		for _, sub := range pc.SubConfigs {
+			if sub != nil && prune(sub) {
+				subs = append(subs, sub)
+			}
+		}
+		pc.SubConfigs = subs
+		return len(pc.FileContent.Data) > 0 || len(pc.Files) > 0 || len(pc.SubConfigs) > 0
+	}
+
+	kept := cfg.ProgramConfigs[:0]
+	for i := range cfg.ProgramConfigs {
+		if prune(&cfg.ProgramConfigs[i]) {
+			kept = append(kept, cfg.ProgramConfigs[i])
+		}
+	}
+	cfg.ProgramConfigs = kept
+	return warnings
+}
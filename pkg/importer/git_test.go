@@ -0,0 +1,237 @@
+package importer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Seann-Moser/hypr-config-manager/pkg/hyprconfig"
+)
+
+// tarball builds a gzipped tar from name -> content.
+func tarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type fakeManager struct {
+	hyprconfig.ConfigManager
+	limits  hyprconfig.SizeLimits
+	created *hyprconfig.HyprConfig
+}
+
+func (f *fakeManager) SizeLimits() hyprconfig.SizeLimits { return f.limits }
+
+func (f *fakeManager) CreateConfig(ctx context.Context, cfg *hyprconfig.HyprConfig) (*hyprconfig.HyprConfig, error) {
+	f.created = cfg
+	return cfg, nil
+}
+
+func TestParseGitHubURL(t *testing.T) {
+	owner, repo, err := parseGitHubURL("https://github.com/someone/dots.git")
+	if err != nil || owner != "someone" || repo != "dots" {
+		t.Errorf("parseGitHubURL = %q, %q, %v", owner, repo, err)
+	}
+	for _, bad := range []string{
+		"http://github.com/someone/dots",
+		"https://gitlab.com/someone/dots",
+		"https://github.com/someone",
+		"file:///etc/passwd",
+	} {
+		if _, _, err := parseGitHubURL(bad); !errors.Is(err, ErrInvalidRepoURL) {
+			t.Errorf("parseGitHubURL(%q) = %v, want ErrInvalidRepoURL", bad, err)
+		}
+	}
+}
+
+func TestExtractTarball(t *testing.T) {
+	data := tarball(t, map[string]string{
+		"someone-dots-abc/README.md":                 "readme",
+		"someone-dots-abc/.config/kitty/kitty.conf":  "font_size 12\n",
+		"someone-dots-abc/.config/hypr/big.conf":     strings.Repeat("x", 100),
+		"someone-dots-abc/.config/../../escape.conf": "nope",
+	})
+	dest := t.TempDir()
+
+	warnings, err := extractTarball(bytes.NewReader(data), dest, ".config", 50)
+	if err != nil {
+		t.Fatalf("extractTarball: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "kitty", "kitty.conf")); err != nil {
+		t.Errorf("kitty.conf not extracted: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "README.md")); err == nil {
+		t.Error("file outside subdir was extracted")
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "big.conf") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestImportFromGit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	data := tarball(t, map[string]string{
+		"someone-dots-abc/.config/kitty/kitty.conf": "font_size 12\n",
+		"someone-dots-abc/.config/kitty/bg.bin":     "\x00\x01\x02\x03",
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/someone/dots/tarball/main" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	mgr := &fakeManager{limits: hyprconfig.DefaultSizeLimits()}
+	g := NewGitImporter(mgr)
+	g.apiBase = srv.URL
+
+	result, err := g.ImportFromGit(context.Background(), "https://github.com/someone/dots", "main", ".config")
+	if err != nil {
+		t.Fatalf("ImportFromGit: %v", err)
+	}
+	cfg := mgr.created
+	if cfg == nil || cfg.Author.URL != "https://github.com/someone/dots" || cfg.Title != "dots" {
+		t.Fatalf("created config = %+v", cfg)
+	}
+	if len(cfg.ProgramConfigs) != 1 || len(cfg.ProgramConfigs[0].SubConfigs) != 0 {
+		t.Errorf("expected kitty with the binary file pruned, got %+v", cfg.ProgramConfigs)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "bg.bin") {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
+
+func TestImportFromGitSourcesStayInRepository(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	hosts := filepath.Join(home, ".config", "gh", "hosts.yml")
+	if err := os.MkdirAll(filepath.Dir(hosts), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hosts, []byte("oauth_token: server-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data := tarball(t, map[string]string{
+		"someone-dots-abc/.config/hypr/hyprland.conf": "source = ~/.config/gh/hosts.yml\nsource = " + hosts + "\nsource = ~/.config/hypr/colors.conf\n",
+		"someone-dots-abc/.config/hypr/colors.conf":   "$accent = rgb(ff0000)\n",
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	mgr := &fakeManager{limits: hyprconfig.DefaultSizeLimits()}
+	g := NewGitImporter(mgr)
+	g.apiBase = srv.URL
+	if _, err := g.ImportFromGit(context.Background(), "https://github.com/someone/dots", "main", ".config"); err != nil {
+		t.Fatalf("ImportFromGit: %v", err)
+	}
+
+	var paths []string
+	mgr.created.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+		if strings.Contains(string(pc.FileContent.Data), "server-secret") {
+			t.Errorf("%s holds the server's file", pc.InstallPath)
+		}
+		paths = append(paths, pc.InstallPath)
+	})
+	if want := []string{"~/.config/hypr/hyprland.conf", "~/.config/hypr/colors.conf"}; !slices.Equal(paths, want) {
+		t.Errorf("imported %q, want %q", paths, want)
+	}
+}
+
+func TestImportFromGitWallpapersStayInRepository(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	wall := filepath.Join(home, "Pictures", "private.png")
+	if err := os.MkdirAll(filepath.Dir(wall), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(wall, []byte(png), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	data := tarball(t, map[string]string{
+		"someone-dots-abc/.config/hypr/hyprland.conf":  "exec-once = hyprpaper\n",
+		"someone-dots-abc/.config/hypr/hyprpaper.conf": "preload = ~/Pictures/private.png\nwallpaper = ," + wall + "\n",
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	mgr := &fakeManager{limits: hyprconfig.DefaultSizeLimits()}
+	g := NewGitImporter(mgr)
+	g.apiBase = srv.URL
+	if _, err := g.ImportFromGit(context.Background(), "https://github.com/someone/dots", "main", ".config"); err != nil {
+		t.Fatalf("ImportFromGit: %v", err)
+	}
+	mgr.created.Walk(func(pc *hyprconfig.HyprProgramConfig) {
+		for _, f := range pc.Files {
+			t.Errorf("%s bundled %s from the server", pc.Program, f.TargetPath)
+		}
+	})
+}
+
+func TestPruneOversized(t *testing.T) {
+	limits := hyprconfig.DefaultSizeLimits()
+	limits.MaxImageBytes = 8
+	png := hyprconfig.FileContent{Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), FileType: hyprconfig.FileTypeImage}
+	conf := hyprconfig.FileContent{Data: []byte("preload = ~/Pictures/wall.png\n"), FileType: hyprconfig.FileTypeConfig}
+	cfg := &hyprconfig.HyprConfig{ProgramConfigs: []hyprconfig.HyprProgramConfig{{
+		Program: "hyprland", InstallPath: "~/.config/hypr/hyprland.conf", FileContent: conf,
+		SubConfigs: []*hyprconfig.HyprProgramConfig{{
+			Program: "hyprland", InstallPath: "~/.config/hypr/paper.conf", FileContent: conf,
+			SubConfigs: []*hyprconfig.HyprProgramConfig{{
+				Program: "hyprpaper", InstallPath: "~/.config/hypr/hyprpaper.conf", FileContent: conf,
+				Files: []hyprconfig.FileEntry{{TargetPath: "Pictures/wall.png", FileContent: png}},
+			}},
+		}, {
+			Program: "hyprpaper", Files: []hyprconfig.FileEntry{{TargetPath: "Pictures/other.png", FileContent: png}},
+		}},
+	}}}
+
+	warnings := pruneOversized(cfg, limits)
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "Pictures/wall.png") || !strings.Contains(warnings[1], "Pictures/other.png") {
+		t.Errorf("warnings = %q", warnings)
+	}
+	subs := cfg.ProgramConfigs[0].SubConfigs
+	if len(subs) != 1 {
+		t.Fatalf("sub configs = %+v, want the one left without files dropped", subs)
+	}
+	nested := subs[0].SubConfigs
+	if len(nested) != 1 || len(nested[0].Files) != 0 || len(nested[0].FileContent.Data) == 0 {
+		t.Errorf("nested sub config = %+v, want its config kept and its image dropped", nested)
+	}
+}